@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/docker/registry"
 	dockerregistry "github.com/replicatedhq/kots/pkg/docker/registry"
 	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/kotsadm"
 	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
@@ -51,6 +53,16 @@ func AdminPushImagesCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			options.Log = log
+			options.Concurrency = v.GetInt("parallelism")
+			options.RetryAttempts = v.GetInt("push-retries")
+			if !v.GetBool("no-journal") {
+				options.JournalPath = kotsadm.PushJournalPath(imageSource)
+			}
+			options.ImagePolicy, err = getImagePolicyFromFlags(v)
+			if err != nil {
+				return errors.Wrap(err, "failed to get image policy")
+			}
 
 			if _, err := os.Stat(imageSource); err == nil {
 				err = kotsadm.PushImages(imageSource, *options)
@@ -68,6 +80,13 @@ func AdminPushImagesCmd() *cobra.Command {
 	cmd.Flags().String("registry-username", "", "user name to use to authenticate with the registry")
 	cmd.Flags().String("registry-password", "", "password to use to authenticate with the registry")
 	cmd.Flags().Bool("skip-registry-check", false, "skip the connectivity test and validation of the provided registry information")
+	cmd.Flags().Int("parallelism", 1, "number of images to push at the same time")
+	cmd.Flags().Int("push-retries", 5, "number of times to attempt pushing each image before giving up")
+	cmd.Flags().Bool("no-journal", false, "do not record pushed images in a journal next to the airgap bundle. without the journal, a re-run pushes all images again")
+	cmd.Flags().Bool("require-signature", false, "only push images that have a cosign signature made with one of the keys in --signature-key")
+	cmd.Flags().StringSlice("signature-key", []string{}, "path to a PEM encoded cosign public key that images can be signed with (can be specified multiple times)")
+	cmd.Flags().Bool("require-sbom", false, "only push images that have an SBOM attached with cosign")
+	cmd.Flags().Bool("policy-dry-run", false, "report images that do not satisfy --require-signature or --require-sbom without blocking them")
 
 	cmd.Flags().String("kotsadm-tag", "", "set to override the tag of kotsadm. this may create an incompatible deployment because the version of kots and kotsadm are designed to work together")
 	cmd.Flags().MarkHidden("kotsadm-tag")
@@ -75,6 +94,31 @@ func AdminPushImagesCmd() *cobra.Command {
 	return cmd
 }
 
+func getImagePolicyFromFlags(v *viper.Viper) (*imagetypes.ImagePolicy, error) {
+	policy := &imagetypes.ImagePolicy{
+		RequireSignature: v.GetBool("require-signature"),
+		RequireSBOM:      v.GetBool("require-sbom"),
+		DryRun:           v.GetBool("policy-dry-run"),
+	}
+	if !policy.RequireSignature && !policy.RequireSBOM {
+		return nil, nil
+	}
+
+	for _, keyFile := range v.GetStringSlice("signature-key") {
+		publicKey, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read signature key %s", keyFile)
+		}
+		policy.PublicKeys = append(policy.PublicKeys, publicKey)
+	}
+
+	if policy.RequireSignature && len(policy.PublicKeys) == 0 {
+		return nil, errors.New("--signature-key is required with --require-signature")
+	}
+
+	return policy, nil
+}
+
 func genAndCheckPushOptions(endpoint string, namespace string, log *logger.CLILogger, v *viper.Viper) (*kotsadmtypes.PushImagesOptions, error) {
 	hostname, err := getHostnameFromEndpoint(endpoint)
 	if err != nil {
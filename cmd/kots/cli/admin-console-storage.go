@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AdminConsoleStorageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Manage the storage used for admin console archives",
+	}
+
+	cmd.AddCommand(AdminConsoleStorageMigrateCmd())
+
+	return cmd
+}
+
+func AdminConsoleStorageMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move app version and support bundle archives to another storage backend",
+		Long: `Copy all app version and support bundle archives from one storage backend to another.
+Supported backends are s3, blob (the kotsadm data volume) and filesystem (a directory on the kotsadm data volume,
+or KOTSADM_FILESTORE_FILESYSTEM_DIR if it is set on the admin console).
+The source defaults to the backend that the admin console is currently using. Once the migration completes,
+set KOTSADM_FILESTORE_BACKEND (and KOTSADM_FILESTORE_DEDUPE) on the admin console to switch to the new backend.
+Archives cannot be deleted from the backend the admin console is using. After switching, migrate again from the
+old backend with --delete-source to copy archives written during the first migration and remove the old copies.
+
+Examples:
+kubectl kots admin-console storage migrate --to filesystem -n default
+kubectl kots admin-console storage migrate --from blob --to filesystem --dedupe --delete-source -n default
+kubectl kots admin-console storage migrate --from blob --to blob --dedupe -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			to := v.GetString("to")
+			if to == "" {
+				return errors.New("--to is required")
+			}
+
+			namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+			if err != nil {
+				return errors.Wrap(err, "failed to get namespace")
+			}
+			if err := validateNamespace(namespace); err != nil {
+				return errors.Wrap(err, "failed to validate namespace")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+			if err != nil {
+				return err
+			}
+
+			current := struct {
+				Backend string `json:"backend"`
+				Dedupe  bool   `json:"dedupe"`
+			}{}
+			if err := client.do(http.MethodGet, "/api/v1/filestore", nil, &current); err != nil {
+				return errors.Wrap(err, "failed to get current storage backend")
+			}
+
+			from := current.Backend
+			fromDedupe := current.Dedupe
+			if cmd.Flags().Changed("from") {
+				from = v.GetString("from")
+				fromDedupe = v.GetBool("from-dedupe")
+			}
+
+			requestPayload := map[string]interface{}{
+				"from":         from,
+				"fromDedupe":   fromDedupe,
+				"to":           to,
+				"toDedupe":     v.GetBool("dedupe"),
+				"deleteSource": v.GetBool("delete-source"),
+				"dryRun":       v.GetBool("dry-run"),
+			}
+
+			response := struct {
+				Archives []string `json:"archives"`
+				DryRun   bool     `json:"dryRun"`
+			}{}
+
+			log.ActionWithSpinner("Migrating archives from %s to %s", describeStorageBackend(from, fromDedupe), describeStorageBackend(to, v.GetBool("dedupe")))
+			if err := client.do(http.MethodPost, "/api/v1/filestore/migrate", requestPayload, &response); err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to migrate archives")
+			}
+
+			if response.DryRun {
+				log.FinishSpinner()
+				for _, archive := range response.Archives {
+					log.Info("%s", archive)
+				}
+				log.ActionWithoutSpinner("%d archives would be migrated", len(response.Archives))
+				return nil
+			}
+
+			message, err := waitForFileStoreMigration(client)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to migrate archives")
+			}
+			log.FinishSpinner()
+
+			log.ActionWithoutSpinner("%s", message)
+			if to != current.Backend || v.GetBool("dedupe") != current.Dedupe {
+				log.ActionWithoutSpinner("Set KOTSADM_FILESTORE_BACKEND=%s and KOTSADM_FILESTORE_DEDUPE=%s on the admin console to start using the new storage", to, dedupeEnvValue(v.GetBool("dedupe")))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("from", "", "backend to migrate from. defaults to the backend currently used by the admin console. supported values: s3, blob, filesystem")
+	cmd.Flags().Bool("from-dedupe", false, "set if the archives in the source backend are deduplicated. only used with --from")
+	cmd.Flags().String("to", "", "backend to migrate to. supported values: s3, blob, filesystem")
+	cmd.Flags().Bool("dedupe", false, "store the archives in the destination as content-addressed chunks shared between archives")
+	cmd.Flags().Bool("delete-source", false, "delete each archive from the source backend after it has been migrated")
+	cmd.Flags().Bool("dry-run", false, "list the archives that would be migrated without migrating them")
+
+	return cmd
+}
+
+// waitForFileStoreMigration polls the migration task until it finishes and returns its final message
+func waitForFileStoreMigration(client *kotsadmAPIClient) (string, error) {
+	for {
+		time.Sleep(time.Second)
+
+		status := struct {
+			CurrentMessage string `json:"currentMessage"`
+			Status         string `json:"status"`
+		}{}
+		if err := client.do(http.MethodGet, "/api/v1/filestore/migrate/status", nil, &status); err != nil {
+			return "", errors.Wrap(err, "failed to get migration status")
+		}
+
+		switch status.Status {
+		case "running":
+			continue
+		case "success":
+			return status.CurrentMessage, nil
+		case "failed":
+			return "", errors.New(status.CurrentMessage)
+		default:
+			return "", errors.New("migration is no longer running, check the admin console logs and run the migration again")
+		}
+	}
+}
+
+func describeStorageBackend(backend string, dedupe bool) string {
+	if dedupe {
+		return backend + " (deduplicated)"
+	}
+	return backend
+}
+
+func dedupeEnvValue(dedupe bool) string {
+	if dedupe {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AirgapCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "airgap",
+		Short: "KOTS airgap bundle utilities",
+	}
+
+	cmd.AddCommand(AirgapVerifyCmd())
+
+	return cmd
+}
+
+func AirgapVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "verify [airgap bundle]",
+		Short:         "Verify the integrity of an airgap bundle",
+		Long:          `Checks every file and image in the airgap bundle against the digests in its integrity manifest. If public keys are provided, the manifest must be signed with one of them.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			if len(args) != 1 {
+				cmd.Help()
+				os.Exit(1)
+			}
+
+			publicKeys := [][]byte{}
+			for _, keyFile := range v.GetStringSlice("public-key") {
+				publicKey, err := ioutil.ReadFile(keyFile)
+				if err != nil {
+					return errors.Wrapf(err, "failed to read public key %s", keyFile)
+				}
+				publicKeys = append(publicKeys, publicKey)
+			}
+
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			airgapPath := args[0]
+			fileInfo, err := os.Stat(airgapPath)
+			if err != nil {
+				return errors.Wrap(err, "failed to stat airgap bundle")
+			}
+
+			log.ActionWithSpinner("Verifying airgap bundle")
+
+			var result *integrity.Result
+			if fileInfo.IsDir() {
+				result, err = integrity.VerifyDir(airgapPath, publicKeys)
+			} else {
+				result, err = integrity.VerifyBundle(airgapPath, publicKeys)
+			}
+			if err != nil {
+				log.FinishSpinnerWithError()
+				if verifyErr, ok := errors.Cause(err).(integrity.Error); ok {
+					for _, entry := range verifyErr.Missing {
+						log.ChildActionWithoutSpinner("missing: %s", entry)
+					}
+					for _, entry := range verifyErr.Corrupted {
+						log.ChildActionWithoutSpinner("corrupted: %s", entry)
+					}
+					for _, entry := range verifyErr.Unexpected {
+						log.ChildActionWithoutSpinner("not in manifest: %s", entry)
+					}
+					return errors.New("airgap bundle failed integrity check")
+				}
+				return err
+			}
+			log.FinishSpinner()
+
+			log.ChildActionWithoutSpinner("Verified %d files and %d images", result.Files, result.Images)
+			if result.SignatureVerified {
+				log.ChildActionWithoutSpinner("Integrity manifest signature is valid")
+			} else {
+				log.ChildActionWithoutSpinner("Integrity manifest signature was not checked, no public keys provided")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("public-key", []string{}, "path to a PEM encoded public key the integrity manifest must be signed with (can be specified multiple times)")
+
+	return cmd
+}
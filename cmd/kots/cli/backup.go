@@ -67,6 +67,7 @@ func BackupCmd() *cobra.Command {
 	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json)")
 
 	cmd.AddCommand(BackupListCmd())
+	cmd.AddCommand(BackupVerifyCmd())
 
 	return cmd
 }
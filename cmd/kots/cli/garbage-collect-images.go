@@ -7,20 +7,28 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/auth"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 func GarbageCollectImagesCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:           "garbage-collect-images [namespace]",
-		Short:         "Run image garbage collection",
-		Long:          `Triggers image garbage collection for all apps`,
+		Use:   "garbage-collect-images [namespace]",
+		Short: "Run image garbage collection",
+		Long: `Triggers image garbage collection for all apps.
+
+Images that are not referenced by any retained app version are deleted from the configured registry.
+For registries other than the embedded cluster registry, image deletion must be enabled by setting
+"enable-image-deletion" to "true" in the kotsadm-confg configmap. Use --dry-run to list the images
+that would be deleted, and --protect to keep images that match a pattern.`,
 		SilenceUsage:  true,
 		SilenceErrors: false,
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -86,7 +94,9 @@ func GarbageCollectImagesCmd() *cobra.Command {
 			}
 
 			requestPayload := map[string]interface{}{
-				"ignoreRollback": v.GetBool("ignore-rollback"),
+				"ignoreRollback":  v.GetBool("ignore-rollback"),
+				"dryRun":          v.GetBool("dry-run"),
+				"protectedImages": v.GetStringSlice("protect"),
 			}
 			requestBody, err := json.Marshal(requestPayload)
 			if err != nil {
@@ -111,7 +121,8 @@ func GarbageCollectImagesCmd() *cobra.Command {
 			}
 
 			type Response struct {
-				Error string `json:"error"`
+				Error   string                                         `json:"error"`
+				Results map[string]*registrytypes.GarbageCollectResult `json:"results"`
 			}
 			response := Response{}
 			if err = json.Unmarshal(b, &response); err != nil {
@@ -126,6 +137,11 @@ func GarbageCollectImagesCmd() *cobra.Command {
 				return errors.Errorf("unexpected response from server %v: %s", resp.StatusCode, b)
 			}
 
+			if v.GetBool("dry-run") {
+				printGarbageCollectDryRun(log, response.Results)
+				return nil
+			}
+
 			log.ActionWithoutSpinner("Garbage collection has been triggered")
 
 			return nil
@@ -133,6 +149,32 @@ func GarbageCollectImagesCmd() *cobra.Command {
 	}
 
 	cmd.Flags().Bool("ignore-rollback", false, "force images garbage collection even if rollback is enabled for the application")
+	cmd.Flags().Bool("dry-run", false, "list the images that would be deleted without deleting them")
+	cmd.Flags().StringSlice("protect", []string{}, "patterns for images that will not be deleted, matched against repository, repository:tag and repository@digest (e.g. \"myns/*:stable\")")
 
 	return cmd
 }
+
+func printGarbageCollectDryRun(log *logger.CLILogger, results map[string]*registrytypes.GarbageCollectResult) {
+	if len(results) == 0 {
+		log.ActionWithoutSpinner("Image garbage collection is not enabled for the registries of the installed apps")
+		return
+	}
+
+	appSlugs := []string{}
+	for appSlug := range results {
+		appSlugs = append(appSlugs, appSlug)
+	}
+	sort.Strings(appSlugs)
+
+	for _, appSlug := range appSlugs {
+		result := results[appSlug]
+		log.ActionWithoutSpinner("App %s: %d images would be deleted, %d protected, %d in use", appSlug, len(result.Deleted), len(result.Protected), result.Kept)
+		for _, image := range result.Deleted {
+			log.Info("  delete:  %s@%s (%s)", image.Repository, image.Digest, strings.Join(image.Tags, ", "))
+		}
+		for _, image := range result.Protected {
+			log.Info("  protect: %s@%s (%s)", image.Repository, image.Digest, strings.Join(image.Tags, ", "))
+		}
+	}
+}
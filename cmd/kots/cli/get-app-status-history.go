@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func GetAppStatusHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "app-status-history [appSlug]",
+		Short: "Get the status history and availability of an app",
+		Long: `Get the status changes of an app during a window of time, and the percentage of that time the app was ready.
+Time before the first recorded status is not counted. History is kept for 90 days.
+
+Examples:
+kubectl kots get app-status-history my-app -n default
+kubectl kots get app-status-history my-app -n default --window 30d -o json`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: getAppStatusHistoryCmd,
+	}
+
+	cmd.Flags().String("window", "7d", "how far back to report, in days like 30d or a duration like 12h")
+	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json)")
+
+	return cmd
+}
+
+func getAppStatusHistoryCmd(cmd *cobra.Command, args []string) error {
+	v := viper.GetViper()
+
+	appSlug := args[0]
+
+	output := v.GetString("output")
+	if output != "json" && output != "" {
+		return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+	}
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return errors.Wrap(err, "failed to get namespace")
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+	client, err := newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("window", v.GetString("window"))
+
+	response := handlers.GetAppStatusHistoryResponse{}
+	if err := client.do(http.MethodGet, fmt.Sprintf("/api/v1/app/%s/status-history?%s", url.PathEscape(appSlug), query.Encode()), nil, &response); err != nil {
+		return errors.Wrap(err, "failed to get app status history")
+	}
+
+	print.AppStatusHistory(&appstatushistorytypes.History{
+		Entries:      response.History,
+		Availability: response.Availability,
+	}, output)
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func GetAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Get the admin console audit log",
+		Long: `Get the audit log of write actions performed in the admin console, newest first.
+
+Examples:
+kubectl kots get audit -n default
+kubectl kots get audit -n default --app my-app --since 24h
+kubectl kots get audit -n default --limit 0 -o jsonl > audit.jsonl`,
+		SilenceUsage:  false,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: getAuditCmd,
+	}
+
+	cmd.Flags().String("app", "", "only show events for the app with this slug")
+	cmd.Flags().String("user", "", "only show events for the user with this username")
+	cmd.Flags().String("since", "", "only show events newer than a relative duration like 24h, or an RFC3339 timestamp")
+	cmd.Flags().String("until", "", "only show events older than a relative duration like 1h, or an RFC3339 timestamp")
+	cmd.Flags().Int("limit", 1000, "maximum number of events to return. set to 0 to return all events")
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json, jsonl")
+
+	return cmd
+}
+
+func getAuditCmd(cmd *cobra.Command, args []string) error {
+	v := viper.GetViper()
+
+	output := v.GetString("output")
+	if output != "json" && output != "jsonl" && output != "" {
+		return errors.Errorf("output format %s not supported (allowed formats are: json, jsonl)", output)
+	}
+
+	query := url.Values{}
+	if app := v.GetString("app"); app != "" {
+		query.Set("appSlug", app)
+	}
+	if user := v.GetString("user"); user != "" {
+		query.Set("username", user)
+	}
+	for _, flag := range []string{"since", "until"} {
+		if val := v.GetString(flag); val != "" {
+			t, err := parseTimeOrDuration(val, time.Now())
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse --%s", flag)
+			}
+			query.Set(flag, t.Format(time.RFC3339))
+		}
+	}
+	query.Set("limit", fmt.Sprintf("%d", v.GetInt("limit")))
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return errors.Wrap(err, "failed to get namespace")
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+	client, err := newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+	if err != nil {
+		return err
+	}
+
+	response := handlers.ListAuditEventsResponse{}
+	if err := client.do(http.MethodGet, fmt.Sprintf("/api/v1/audit?%s", query.Encode()), nil, &response); err != nil {
+		return errors.Wrap(err, "failed to get audit events")
+	}
+
+	print.AuditEvents(response.Events, output)
+	return nil
+}
+
+// parseTimeOrDuration accepts either an RFC3339 timestamp or a duration that is subtracted from now
+func parseTimeOrDuration(val string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(val); err == nil {
+		return now.Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is not a duration or an RFC3339 timestamp", val)
+	}
+	return t, nil
+}
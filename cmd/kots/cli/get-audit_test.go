@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTimeOrDuration(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseTimeOrDuration("24h", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 9, 30, 12, 0, 0, 0, time.UTC), got)
+
+	got, err = parseTimeOrDuration("2023-09-01T00:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = parseTimeOrDuration("yesterday", now)
+	require.Error(t, err)
+}
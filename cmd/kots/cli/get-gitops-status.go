@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func GetGitOpsStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitops-status [appSlug]",
+		Short: "Get the GitOps drift status of an app",
+		Long: `Compare the current version of each GitOps enabled downstream to the configured branch and path of the repo,
+and to the objects running in the cluster. Resources that were added, modified or removed are listed.
+Drift is checked every 15 minutes, use --refresh to check now.
+
+Examples:
+kubectl kots get gitops-status my-app -n default
+kubectl kots get gitops-status my-app -n default --refresh -o json`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: getGitOpsStatusCmd,
+	}
+
+	cmd.Flags().Bool("refresh", false, "check for drift now instead of showing the result of the last check")
+	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json)")
+
+	return cmd
+}
+
+func getGitOpsStatusCmd(cmd *cobra.Command, args []string) error {
+	v := viper.GetViper()
+
+	appSlug := args[0]
+
+	output := v.GetString("output")
+	if output != "json" && output != "" {
+		return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+	}
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return errors.Wrap(err, "failed to get namespace")
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+	client, err := newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+	if err != nil {
+		return err
+	}
+
+	method := http.MethodGet
+	path := fmt.Sprintf("/api/v1/app/%s/gitops-status", url.PathEscape(appSlug))
+	if v.GetBool("refresh") {
+		method = http.MethodPost
+		path = fmt.Sprintf("%s/check", path)
+	}
+
+	response := handlers.GetAppGitOpsStatusResponse{}
+	if err := client.do(method, path, nil, &response); err != nil {
+		return errors.Wrap(err, "failed to get gitops status")
+	}
+
+	print.GitOpsStatus(response.Downstreams, output)
+	return nil
+}
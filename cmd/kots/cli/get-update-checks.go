@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func GetUpdateChecksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update-checks",
+		Short: "Get the scheduled update checks of all apps",
+		Long: `List the update check schedule of every app, with the result of the last check and when the next check runs.
+Failed checks are retried with a backoff before the next scheduled check.
+
+Examples:
+kubectl kots get update-checks -n default
+kubectl kots get update-checks -n default -o json`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: getUpdateChecksCmd,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json)")
+
+	return cmd
+}
+
+func getUpdateChecksCmd(cmd *cobra.Command, args []string) error {
+	v := viper.GetViper()
+
+	output := v.GetString("output")
+	if output != "json" && output != "" {
+		return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+	}
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return errors.Wrap(err, "failed to get namespace")
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+	client, err := newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+	if err != nil {
+		return err
+	}
+
+	response := handlers.GetUpdateCheckStatusesResponse{}
+	if err := client.do(http.MethodGet, "/api/v1/update-checks", nil, &response); err != nil {
+		return errors.Wrap(err, "failed to get update checks")
+	}
+
+	print.UpdateChecks(response.Apps, output)
+	return nil
+}
@@ -34,6 +34,13 @@ kubectl kots get apps`,
 	cmd.AddCommand(GetVersionsCmd())
 	cmd.AddCommand(GetConfigCmd())
 	cmd.AddCommand(GetRestoresCmd())
+	cmd.AddCommand(GetAuditCmd())
+	cmd.AddCommand(GetVersionRetentionCmd())
+	cmd.AddCommand(GetSoakPolicyCmd())
+	cmd.AddCommand(GetAppStatusHistoryCmd())
+	cmd.AddCommand(GetGitOpsStatusCmd())
+	cmd.AddCommand(GetUpdateChecksCmd())
+	cmd.AddCommand(GetSnapshotRetentionCmd())
 
 	return cmd
 }
@@ -331,6 +331,10 @@ func InstallCmd() *cobra.Command {
 				}
 				defer os.RemoveAll(airgapRootDir)
 
+				if err := kotsadm.VerifyAirgapBundle(airgapArchive, deployOptions.Namespace, airgapRootDir, log); err != nil {
+					return errors.Wrap(err, "failed to verify airgap bundle")
+				}
+
 				err = kotsadm.ExtractAppAirgapArchive(airgapArchive, airgapRootDir, v.GetBool("disable-image-push"), deployOptions.ProgressWriter)
 				if err != nil {
 					return errors.Wrap(err, "failed to extract images")
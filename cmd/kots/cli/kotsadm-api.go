@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+// kotsadmAPIClient makes authenticated requests to the admin console api through a port forward
+type kotsadmAPIClient struct {
+	localPort int
+	authSlug  string
+}
+
+// newKotsadmAPIClient starts a port forward to the admin console running in the namespace.
+// The port forward is stopped when stopCh is closed.
+func newKotsadmAPIClient(namespace string, stopCh chan struct{}, log *logger.CLILogger, debug bool) (*kotsadmAPIClient, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get clientset")
+	}
+
+	getPodName := func() (string, error) {
+		return k8sutil.FindKotsadm(clientset, namespace)
+	}
+
+	localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start port forwarding")
+	}
+
+	go func() {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				log.Error(err)
+			}
+		case <-stopCh:
+		}
+	}()
+
+	authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+	if err != nil {
+		log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+		if debug {
+			return nil, errors.Wrap(err, "failed to get kotsadm auth slug")
+		}
+		os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+	}
+
+	return &kotsadmAPIClient{
+		localPort: localPort,
+		authSlug:  authSlug,
+	}, nil
+}
+
+// do sends the request payload as json and decodes the json response into response if it is not nil.
+// Error responses from the api are returned as errors.
+func (c *kotsadmAPIClient) do(method string, path string, requestPayload interface{}, response interface{}) error {
+	var body io.Reader
+	if requestPayload != nil {
+		requestBody, err := json.Marshal(requestPayload)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal request json")
+		}
+		body = bytes.NewBuffer(requestBody)
+	}
+
+	url := fmt.Sprintf("http://localhost:%d%s", c.localPort, path)
+	newReq, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", c.authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read server response")
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		errorResponse := struct {
+			Error string `json:"error"`
+		}{}
+		if err := json.Unmarshal(b, &errorResponse); err == nil && errorResponse.Error != "" {
+			return errors.New(errorResponse.Error)
+		}
+		return errors.Errorf("unexpected response from server %v: %s", resp.StatusCode, b)
+	}
+
+	if response == nil || len(b) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(b, response); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal server response: %s", b)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func BackupVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [backupName]",
+		Short: "Verify that a backup can be restored",
+		Long: `Restore the app from a backup into temporary namespaces, and wait for the app's status informers to report ready.
+The result is recorded on the backup and the temporary namespaces are deleted. The app that is running is not affected.
+
+Examples:
+kubectl kots backup verify instance-abcd1 --app my-app -n default
+kubectl kots backup verify instance-abcd1 --app my-app --wait=false -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			appSlug := v.GetString("app")
+			if appSlug == "" {
+				return errors.New("--app is required")
+			}
+			backupName := args[0]
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			path := fmt.Sprintf("/api/v1/app/%s/snapshot/%s/verify-restore", url.PathEscape(appSlug), url.PathEscape(backupName))
+
+			response := handlers.RestoreVerificationResponse{}
+			if err := client.do(http.MethodPost, path, nil, &response); err != nil {
+				return errors.Wrap(err, "failed to start restore verification")
+			}
+
+			if !v.GetBool("wait") {
+				log.ActionWithoutSpinner("Restore verification %s of backup %s has started", response.RestoreVerification.RestoreName, backupName)
+				return nil
+			}
+
+			log.ActionWithSpinner("Restoring backup %s into temporary namespaces", backupName)
+			for {
+				if response.RestoreVerification == nil || response.RestoreVerification.Status != snapshottypes.RestoreVerificationRunning {
+					break
+				}
+				time.Sleep(5 * time.Second)
+				if err := client.do(http.MethodGet, path, nil, &response); err != nil {
+					log.FinishSpinnerWithError()
+					return errors.Wrap(err, "failed to get restore verification")
+				}
+			}
+
+			if response.RestoreVerification == nil || response.RestoreVerification.Status != snapshottypes.RestoreVerificationPassed {
+				log.FinishSpinnerWithError()
+				if response.RestoreVerification != nil {
+					return errors.Errorf("backup %s could not be verified: %s", backupName, response.RestoreVerification.Error)
+				}
+				return errors.Errorf("backup %s could not be verified", backupName)
+			}
+
+			log.FinishSpinner()
+			log.ActionWithoutSpinner("Backup %s was restored and the app became ready", backupName)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("app", "", "slug of the app to restore from the backup")
+	cmd.Flags().Bool("wait", true, "wait for the verification to finish")
+
+	return cmd
+}
+
+func SetRestoreVerificationCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore-verification [appSlug]",
+		Short: "Set the schedule for verifying that the latest backup of an app can be restored",
+		Long: `Set the schedule for restoring the latest backup of an app into temporary namespaces, to verify that it can be restored.
+The result is recorded on the backup and shown by "kubectl kots get backups".
+
+Examples:
+kubectl kots set restore-verification my-app -n default
+kubectl kots set restore-verification my-app --schedule "0 3 * * 0" -n default
+kubectl kots set restore-verification my-app --disable -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			appSlug := args[0]
+
+			schedule := v.GetString("schedule")
+			if v.GetBool("disable") {
+				schedule = ""
+			} else if schedule == "" {
+				return errors.New("--schedule cannot be empty, use --disable to disable scheduled restore verifications")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := handlers.RestoreVerificationScheduleRequest{
+				Schedule: schedule,
+			}
+			response := handlers.RestoreVerificationScheduleResponse{}
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/app/%s/snapshot/restore-verification/schedule", url.PathEscape(appSlug)), requestPayload, &response); err != nil {
+				return errors.Wrap(err, "failed to set restore verification schedule")
+			}
+
+			if response.NextAt == nil {
+				log.ActionWithoutSpinner("Scheduled restore verifications of %s have been disabled", appSlug)
+			} else {
+				log.ActionWithoutSpinner("The latest backup of %s will be verified next at %s", appSlug, response.NextAt.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("schedule", "@weekly", "cron spec for verifying the latest backup")
+	cmd.Flags().Bool("disable", false, "disable scheduled restore verifications")
+
+	return cmd
+}
@@ -41,6 +41,8 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(RemoveCmd())
 	cmd.AddCommand(AdminConsoleCmd())
 	cmd.AddCommand(ResetPasswordCmd())
+	cmd.AddCommand(UserCmd())
+	cmd.AddCommand(WebhookCmd())
 	cmd.AddCommand(ResetTLSCmd())
 	cmd.AddCommand(VersionCmd())
 	cmd.AddCommand(VeleroCmd())
@@ -52,9 +54,12 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(AppStatusCmd())
 	cmd.AddCommand(GetCmd())
 	cmd.AddCommand(SetCmd())
+	cmd.AddCommand(PruneVersionsCmd())
+	cmd.AddCommand(ApprovedVersionsCmd())
 	cmd.AddCommand(CompletionCmd())
 	cmd.AddCommand(DockerRegistryCmd())
 	cmd.AddCommand(EnableHACmd())
+	cmd.AddCommand(AirgapCmd())
 
 	viper.BindPFlags(cmd.Flags())
 
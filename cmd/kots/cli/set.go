@@ -30,6 +30,10 @@ func SetCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(SetConfigCmd())
+	cmd.AddCommand(SetVersionRetentionCmd())
+	cmd.AddCommand(SetSoakPolicyCmd())
+	cmd.AddCommand(SetRestoreVerificationCmd())
+	cmd.AddCommand(SetSnapshotRetentionCmd())
 
 	return cmd
 }
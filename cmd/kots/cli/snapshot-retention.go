@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func SetSnapshotRetentionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot-retention [appSlug]",
+		Short: "Set the policy for pruning old backups",
+		Long: `Set the grandfather-father-son policy that decides which backups are kept. For each tier, the latest backup of each of the
+most recent hours, days, weeks or months is kept. Backups that are not kept by any tier are deleted hourly.
+Use --instance to set the policy for instance backups instead of the backups of an app.
+Use --dry-run to list the backups that would be kept and removed without changing the policy.
+
+Examples:
+kubectl kots set snapshot-retention my-app --daily 7 --weekly 4 --monthly 6 -n default
+kubectl kots set snapshot-retention --instance --hourly 24 --daily 7 --dry-run -n default
+kubectl kots set snapshot-retention my-app --disable -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			path, err := snapshotRetentionPath(v, args)
+			if err != nil {
+				return err
+			}
+
+			var policy *snapshottypes.RetentionPolicy
+			if !v.GetBool("disable") {
+				policy = &snapshottypes.RetentionPolicy{
+					Hourly:  v.GetInt("hourly"),
+					Daily:   v.GetInt("daily"),
+					Weekly:  v.GetInt("weekly"),
+					Monthly: v.GetInt("monthly"),
+				}
+			} else if v.GetBool("dry-run") {
+				return errors.New("--dry-run cannot be used with --disable")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newVersionRetentionAPIClient(v, log, stopCh)
+			if err != nil {
+				return err
+			}
+
+			if v.GetBool("dry-run") {
+				output := v.GetString("output")
+				if output != "json" && output != "" {
+					return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+				}
+
+				preview := snapshottypes.RetentionPreview{}
+				requestPayload := handlers.PreviewSnapshotRetentionRequest{Policy: policy}
+				if err := client.do(http.MethodPost, path+"/preview", requestPayload, &preview); err != nil {
+					return errors.Wrap(err, "failed to preview snapshot retention")
+				}
+
+				print.SnapshotRetentionPreview(&preview, output)
+				return nil
+			}
+
+			requestPayload := handlers.SnapshotRetentionPolicyRequest{Policy: policy}
+			if err := client.do(http.MethodPut, path, requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to set snapshot retention policy")
+			}
+
+			if policy == nil {
+				log.ActionWithoutSpinner("Backup pruning has been disabled, backups are expired by their TTL")
+			} else {
+				log.ActionWithoutSpinner("The snapshot retention policy has been updated")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("instance", false, "set the policy for instance backups")
+	cmd.Flags().Int("hourly", 0, "number of hourly backups to keep")
+	cmd.Flags().Int("daily", 0, "number of daily backups to keep")
+	cmd.Flags().Int("weekly", 0, "number of weekly backups to keep")
+	cmd.Flags().Int("monthly", 0, "number of monthly backups to keep")
+	cmd.Flags().Bool("disable", false, "remove the retention policy so that backups are expired by their TTL")
+	cmd.Flags().Bool("dry-run", false, "list the backups that the policy would keep and remove without setting it")
+	cmd.Flags().StringP("output", "o", "", "output format of --dry-run. supported values: json")
+
+	return cmd
+}
+
+func GetSnapshotRetentionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot-retention [appSlug]",
+		Short: "Get the policy for pruning old backups",
+		Long: `Get the snapshot retention policy of an app, or of instance backups with --instance.
+
+Examples:
+kubectl kots get snapshot-retention my-app -n default
+kubectl kots get snapshot-retention --instance -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			path, err := snapshotRetentionPath(v, args)
+			if err != nil {
+				return err
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newVersionRetentionAPIClient(v, log, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := handlers.SnapshotRetentionPolicyResponse{}
+			if err := client.do(http.MethodGet, path, nil, &response); err != nil {
+				return errors.Wrap(err, "failed to get snapshot retention policy")
+			}
+
+			print.SnapshotRetentionPolicy(response.Policy, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("instance", false, "get the policy for instance backups")
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func snapshotRetentionPath(v *viper.Viper, args []string) (string, error) {
+	if v.GetBool("instance") {
+		if len(args) > 0 {
+			return "", errors.New("an app slug cannot be used with --instance")
+		}
+		return "/api/v1/snapshot/retention", nil
+	}
+	if len(args) == 0 {
+		return "", errors.New("an app slug or --instance is required")
+	}
+	return fmt.Sprintf("/api/v1/app/%s/snapshot/retention", url.PathEscape(args[0])), nil
+}
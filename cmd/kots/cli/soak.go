@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func SetSoakPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "soak-policy [appSlug]",
+		Short: "Set the policy that holds new versions back from auto-deploy",
+		Long: `Set the policy that holds new versions back from auto-deploy until they have soaked. A version can be auto-deployed
+once it has been available upstream for the minimum number of days, or once one of the approving instances has
+deployed it successfully. Approved versions are exported from an instance with "kubectl kots approved-versions export"
+and imported with "kubectl kots approved-versions import".
+
+Examples:
+kubectl kots set soak-policy my-app --min-days 7 -n default
+kubectl kots set soak-policy my-app --min-days 14 --approving-instance staging -n default
+kubectl kots set soak-policy my-app --disable -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			appSlug := args[0]
+
+			var policy *soaktypes.Policy
+			if !v.GetBool("disable") {
+				policy = &soaktypes.Policy{
+					MinDays:            v.GetInt("min-days"),
+					ApprovingInstances: v.GetStringSlice("approving-instance"),
+				}
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"policy": policy,
+			}
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/app/%s/soak-policy", url.PathEscape(appSlug)), requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to set soak policy")
+			}
+
+			if policy == nil {
+				log.ActionWithoutSpinner("New versions of %s will be auto-deployed as soon as they are available", appSlug)
+			} else {
+				log.ActionWithoutSpinner("The soak policy for %s has been updated", appSlug)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("min-days", 0, "number of days a version has to be available upstream before it is auto-deployed")
+	cmd.Flags().StringSlice("approving-instance", []string{}, "name of an instance whose approved versions can be auto-deployed right away. can be specified multiple times")
+	cmd.Flags().Bool("disable", false, "remove the soak policy")
+
+	return cmd
+}
+
+func GetSoakPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "soak-policy [appSlug]",
+		Short:         "Get the soak policy and the approved versions imported from other instances",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := struct {
+				Policy           *soaktypes.Policy            `json:"policy"`
+				ApprovedVersions []soaktypes.ApprovedVersions `json:"approvedVersions"`
+			}{}
+			if err := client.do(http.MethodGet, fmt.Sprintf("/api/v1/app/%s/soak-policy", url.PathEscape(args[0])), nil, &response); err != nil {
+				return errors.Wrap(err, "failed to get soak policy")
+			}
+
+			print.SoakPolicy(response.Policy, response.ApprovedVersions, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func ApprovedVersionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approved-versions",
+		Short: "Share the versions that were deployed successfully with other instances",
+		Long: `Export the versions of an app that were deployed successfully on this instance, and import them on instances
+whose soak policy lists this instance as an approving instance.`,
+	}
+
+	cmd.AddCommand(ApprovedVersionsExportCmd())
+	cmd.AddCommand(ApprovedVersionsImportCmd())
+
+	return cmd
+}
+
+func ApprovedVersionsExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [appSlug]",
+		Short: "Export the versions that were deployed successfully on this instance",
+		Long: `Export the versions that were deployed successfully on this instance to a file.
+
+Examples:
+kubectl kots approved-versions export my-app --instance-name staging --dest approved-versions.json -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			instanceName := v.GetString("instance-name")
+			if instanceName == "" {
+				return errors.New("--instance-name is required")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			approved := soaktypes.ApprovedVersions{}
+			path := fmt.Sprintf("/api/v1/app/%s/approved-versions/export?instanceName=%s", url.PathEscape(args[0]), url.QueryEscape(instanceName))
+			if err := client.do(http.MethodGet, path, nil, &approved); err != nil {
+				return errors.Wrap(err, "failed to export approved versions")
+			}
+
+			b, err := json.MarshalIndent(approved, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal approved versions")
+			}
+			dest := v.GetString("dest")
+			if err := ioutil.WriteFile(dest, b, 0644); err != nil {
+				return errors.Wrapf(err, "failed to write %s", dest)
+			}
+
+			log.ActionWithoutSpinner("%d approved versions of %s were exported to %s", len(approved.Versions), args[0], dest)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("instance-name", "", "name that other instances use to refer to this instance in their soak policy")
+	cmd.Flags().String("dest", "approved-versions.json", "file to write the approved versions to")
+
+	return cmd
+}
+
+func ApprovedVersionsImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [appSlug]",
+		Short: "Import the versions that were deployed successfully on another instance",
+		Long: `Import a file exported with "kubectl kots approved-versions export". Versions previously imported from
+the same instance are replaced.
+
+Examples:
+kubectl kots approved-versions import my-app --file approved-versions.json -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			filename := v.GetString("file")
+			if filename == "" {
+				return errors.New("--file is required")
+			}
+			b, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", filename)
+			}
+			approved := soaktypes.ApprovedVersions{}
+			if err := json.Unmarshal(b, &approved); err != nil {
+				return errors.Wrapf(err, "failed to parse %s", filename)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			if err := client.do(http.MethodPost, fmt.Sprintf("/api/v1/app/%s/approved-versions/import", url.PathEscape(args[0])), approved, nil); err != nil {
+				return errors.Wrap(err, "failed to import approved versions")
+			}
+
+			log.ActionWithoutSpinner("%d versions approved by %s were imported", len(approved.Versions), approved.InstanceName)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("file", "", "file exported with \"kubectl kots approved-versions export\"")
+
+	return cmd
+}
@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func UserCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage admin console users",
+		Long: `Manage local admin console users. Local users log in with their own username and password
+and are granted the roles assigned to them instead of sharing the admin console password.`,
+	}
+
+	cmd.AddCommand(UserListCmd())
+	cmd.AddCommand(UserCreateCmd())
+	cmd.AddCommand(UserResetPasswordCmd())
+	cmd.AddCommand(UserSetRolesCmd())
+	cmd.AddCommand(UserDisableCmd())
+	cmd.AddCommand(UserEnableCmd())
+
+	return cmd
+}
+
+func UserListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List admin console users",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := struct {
+				Users []print.UserResponse `json:"users"`
+			}{}
+			if err := client.do(http.MethodGet, "/api/v1/users", nil, &response); err != nil {
+				return errors.Wrap(err, "failed to list users")
+			}
+
+			print.Users(response.Users, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func UserCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [username]",
+		Short: "Create an admin console user",
+		Long: `Create an admin console user with the given roles.
+
+Examples:
+kubectl kots user create jdoe --roles cluster-admin -n default
+kubectl kots user create support-engineer --roles support -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			username := args[0]
+			roles := v.GetStringSlice("roles")
+			if len(roles) == 0 {
+				return errors.New("at least one role is required, use the --roles flag")
+			}
+
+			password, err := getUserPassword(v, username)
+			if err != nil {
+				return err
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"username": username,
+				"password": password,
+				"roles":    roles,
+			}
+			if err := client.do(http.MethodPost, "/api/v1/users", requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to create user")
+			}
+
+			log.ActionWithoutSpinner("User %s has been created", username)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("roles", []string{}, "comma separated list of roles to assign to the user. supported values: cluster-admin, support")
+	cmd.Flags().String("password", "", "password for the user. if not provided, you will be prompted for one")
+
+	return cmd
+}
+
+func UserResetPasswordCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "reset-password [username]",
+		Short:         "Reset the password for an admin console user",
+		Long:          `Reset the password for an admin console user. This also unlocks the user if they have been locked out after too many failed logins.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			username := args[0]
+			password, err := getUserPassword(v, username)
+			if err != nil {
+				return err
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"password": password,
+			}
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/user/%s/password", url.PathEscape(username)), requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to reset password")
+			}
+
+			log.ActionWithoutSpinner("The password for user %s has been reset", username)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("password", "", "new password for the user. if not provided, you will be prompted for one")
+
+	return cmd
+}
+
+func UserSetRolesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "set-roles [username]",
+		Short:         "Set the roles assigned to an admin console user",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			username := args[0]
+			roles := v.GetStringSlice("roles")
+			if len(roles) == 0 {
+				return errors.New("at least one role is required, use the --roles flag")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"roles": roles,
+			}
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/user/%s/roles", url.PathEscape(username)), requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to set roles")
+			}
+
+			log.ActionWithoutSpinner("The roles for user %s have been updated", username)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("roles", []string{}, "comma separated list of roles to assign to the user. supported values: cluster-admin, support")
+
+	return cmd
+}
+
+func UserDisableCmd() *cobra.Command {
+	return userSetDisabledCmd("disable", "Disable an admin console user so that they can no longer log in", "disabled")
+}
+
+func UserEnableCmd() *cobra.Command {
+	return userSetDisabledCmd("enable", "Enable a disabled admin console user", "enabled")
+}
+
+func userSetDisabledCmd(action string, short string, result string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           fmt.Sprintf("%s [username]", action),
+		Short:         short,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			username := args[0]
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/user/%s/%s", url.PathEscape(username), action), nil, nil); err != nil {
+				return errors.Wrapf(err, "failed to %s user", action)
+			}
+
+			log.ActionWithoutSpinner("User %s has been %s", username, result)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newUserAPIClient(cmd *cobra.Command, stopCh chan struct{}) (*kotsadmAPIClient, error) {
+	v := viper.GetViper()
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get namespace")
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return nil, errors.Wrap(err, "failed to validate namespace")
+	}
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+	return newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+}
+
+func getUserPassword(v *viper.Viper, username string) (string, error) {
+	if password := v.GetString("password"); password != "" {
+		return password, nil
+	}
+
+	password, err := util.PromptForNewPasswordWithLabel(fmt.Sprintf("Enter a new password for user %s (6+ characters):", username))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to prompt for password")
+	}
+	return password, nil
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	versionretentiontypes "github.com/replicatedhq/kots/pkg/versionretention/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func SetVersionRetentionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version-retention [appSlug]",
+		Short: "Set the policy for pruning old app versions",
+		Long: `Set the policy that decides which app versions and their archives are kept. Versions that are not kept by any of the rules
+are pruned daily. The deployed version, versions newer than it and the latest version are always kept.
+
+Examples:
+kubectl kots set version-retention my-app --keep-last 20 --keep-previously-deployed -n default
+kubectl kots set version-retention my-app --keep-newer-than-days 90 -n default
+kubectl kots set version-retention my-app --disable -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			appSlug := args[0]
+
+			var policy *versionretentiontypes.Policy
+			if !v.GetBool("disable") {
+				policy = versionRetentionPolicyFromFlags(v)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newVersionRetentionAPIClient(v, log, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"policy": policy,
+			}
+			if err := client.do(http.MethodPut, fmt.Sprintf("/api/v1/app/%s/version-retention", url.PathEscape(appSlug)), requestPayload, nil); err != nil {
+				return errors.Wrap(err, "failed to set version retention policy")
+			}
+
+			if policy == nil {
+				log.ActionWithoutSpinner("Version pruning has been disabled for %s", appSlug)
+			} else {
+				log.ActionWithoutSpinner("The version retention policy for %s has been updated", appSlug)
+			}
+			return nil
+		},
+	}
+
+	addVersionRetentionPolicyFlags(cmd.Flags())
+	cmd.Flags().Bool("disable", false, "remove the retention policy so that all versions are kept")
+
+	return cmd
+}
+
+func GetVersionRetentionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "version-retention [appSlug]",
+		Short:         "Get the policy for pruning old app versions",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newVersionRetentionAPIClient(v, log, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := struct {
+				Policy *versionretentiontypes.Policy `json:"policy"`
+			}{}
+			if err := client.do(http.MethodGet, fmt.Sprintf("/api/v1/app/%s/version-retention", url.PathEscape(args[0])), nil, &response); err != nil {
+				return errors.Wrap(err, "failed to get version retention policy")
+			}
+
+			print.VersionRetentionPolicy(response.Policy, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func PruneVersionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune-versions [appSlug]",
+		Short: "Remove old app versions according to the version retention policy",
+		Long: `Remove the app versions and archives that are not kept by the app's version retention policy.
+Use --dry-run to list the versions that would be removed. Policy flags can be used with --dry-run
+to preview a policy before setting it.
+
+Examples:
+kubectl kots prune-versions my-app --dry-run -n default
+kubectl kots prune-versions my-app --dry-run --keep-last 10 -n default
+kubectl kots prune-versions my-app -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			requestPayload := map[string]interface{}{
+				"dryRun": v.GetBool("dry-run"),
+			}
+			if cmd.Flags().Changed("keep-last") || cmd.Flags().Changed("keep-previously-deployed") || cmd.Flags().Changed("keep-newer-than-days") {
+				if !v.GetBool("dry-run") {
+					return errors.New("policy flags can only be used with --dry-run, use \"kubectl kots set version-retention\" to change the policy")
+				}
+				requestPayload["policy"] = versionRetentionPolicyFromFlags(v)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newVersionRetentionAPIClient(v, log, stopCh)
+			if err != nil {
+				return err
+			}
+
+			result := versionretentiontypes.PruneResult{}
+			if err := client.do(http.MethodPost, fmt.Sprintf("/api/v1/app/%s/version-retention/prune", url.PathEscape(args[0])), requestPayload, &result); err != nil {
+				return errors.Wrap(err, "failed to prune versions")
+			}
+
+			print.PruneResult(&result, output)
+			return nil
+		},
+	}
+
+	addVersionRetentionPolicyFlags(cmd.Flags())
+	cmd.Flags().Bool("dry-run", false, "list the versions that would be removed without removing them")
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func addVersionRetentionPolicyFlags(flags *pflag.FlagSet) {
+	flags.Int("keep-last", 0, "number of most recent versions to keep")
+	flags.Bool("keep-previously-deployed", false, "keep every version that has been deployed")
+	flags.Int("keep-newer-than-days", 0, "keep versions created in the last number of days")
+}
+
+func versionRetentionPolicyFromFlags(v *viper.Viper) *versionretentiontypes.Policy {
+	return &versionretentiontypes.Policy{
+		KeepLast:               v.GetInt("keep-last"),
+		KeepPreviouslyDeployed: v.GetBool("keep-previously-deployed"),
+		KeepNewerThanDays:      v.GetInt("keep-newer-than-days"),
+	}
+}
+
+func newVersionRetentionAPIClient(v *viper.Viper, log *logger.CLILogger, stopCh chan struct{}) (*kotsadmAPIClient, error) {
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get namespace")
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return nil, errors.Wrap(err, "failed to validate namespace")
+	}
+
+	return newKotsadmAPIClient(namespace, stopCh, log, v.GetBool("debug"))
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	webhooktypes "github.com/replicatedhq/kots/pkg/webhooks/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func WebhookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Manage webhooks for app lifecycle events",
+		Long: `Manage webhooks that are notified of app lifecycle events such as available updates, deployments,
+app status changes and snapshots. Deliveries are signed with the webhook secret: the X-Kots-Signature header
+contains "sha256=" followed by the hex HMAC-SHA256 of "<X-Kots-Timestamp>.<request body>".`,
+	}
+
+	cmd.AddCommand(WebhookListCmd())
+	cmd.AddCommand(WebhookCreateCmd())
+	cmd.AddCommand(WebhookDeleteCmd())
+	cmd.AddCommand(WebhookTestCmd())
+	cmd.AddCommand(WebhookDeliveriesCmd())
+
+	return cmd
+}
+
+func WebhookListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List webhooks",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := struct {
+				Webhooks []webhooktypes.Webhook `json:"webhooks"`
+			}{}
+			if err := client.do(http.MethodGet, "/api/v1/webhooks", nil, &response); err != nil {
+				return errors.Wrap(err, "failed to list webhooks")
+			}
+
+			print.Webhooks(response.Webhooks, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func WebhookCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [url]",
+		Short: "Create a webhook",
+		Long: `Create a webhook that receives app lifecycle events. If no secret is provided, one is generated
+and printed. The secret cannot be retrieved later.
+
+Supported events: update.available, preflight.completed, deploy.succeeded, deploy.failed,
+appstatus.changed, snapshot.started, snapshot.completed
+
+Examples:
+kubectl kots webhook create https://example.com/hooks/kots -n default
+kubectl kots webhook create https://example.com/hooks/kots --events deploy.succeeded,deploy.failed -n default`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			requestPayload := map[string]interface{}{
+				"url":        args[0],
+				"secret":     v.GetString("secret"),
+				"eventTypes": v.GetStringSlice("events"),
+			}
+			response := struct {
+				Webhook *webhooktypes.Webhook `json:"webhook"`
+				Secret  string                `json:"secret"`
+			}{}
+			if err := client.do(http.MethodPost, "/api/v1/webhooks", requestPayload, &response); err != nil {
+				return errors.Wrap(err, "failed to create webhook")
+			}
+
+			log.ActionWithoutSpinner("Webhook %s has been created", response.Webhook.ID)
+			if v.GetString("secret") == "" {
+				log.ActionWithoutSpinner("Signing secret: %s", response.Secret)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("events", []string{}, "comma separated list of events to send to the webhook. all events are sent if not provided")
+	cmd.Flags().String("secret", "", "secret used to sign deliveries. one is generated if not provided")
+
+	return cmd
+}
+
+func WebhookDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "rm [id]",
+		Aliases:       []string{"delete"},
+		Short:         "Delete a webhook and its delivery history",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			if err := client.do(http.MethodDelete, fmt.Sprintf("/api/v1/webhook/%s", url.PathEscape(args[0])), nil, nil); err != nil {
+				return errors.Wrap(err, "failed to delete webhook")
+			}
+
+			log.ActionWithoutSpinner("Webhook %s has been deleted", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func WebhookTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "test [id]",
+		Short:         "Send a test event to a webhook",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			delivery := webhooktypes.Delivery{}
+			if err := client.do(http.MethodPost, fmt.Sprintf("/api/v1/webhook/%s/test", url.PathEscape(args[0])), nil, &delivery); err != nil {
+				return errors.Wrap(err, "failed to send test event")
+			}
+
+			if delivery.Status != webhooktypes.DeliverySucceeded {
+				return errors.Errorf("test event delivery failed: %s", delivery.Error)
+			}
+
+			log.ActionWithoutSpinner("Test event delivered, the webhook responded with status %d", delivery.StatusCode)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func WebhookDeliveriesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "deliveries [id]",
+		Short:         "List recent deliveries of a webhook",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			client, err := newUserAPIClient(cmd, stopCh)
+			if err != nil {
+				return err
+			}
+
+			response := struct {
+				Deliveries []webhooktypes.Delivery `json:"deliveries"`
+			}{}
+			path := fmt.Sprintf("/api/v1/webhook/%s/deliveries?limit=%d", url.PathEscape(args[0]), v.GetInt("limit"))
+			if err := client.do(http.MethodGet, path, nil, &response); err != nil {
+				return errors.Wrap(err, "failed to list webhook deliveries")
+			}
+
+			print.WebhookDeliveries(response.Deliveries, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("limit", 50, "maximum number of deliveries to list. 0 lists all deliveries")
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
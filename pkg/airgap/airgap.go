@@ -100,10 +100,17 @@ func CreateAppFromAirgap(opts CreateAirgapAppOpts) (finalError error) {
 		return errors.Wrap(err, "failed to set task status")
 	}
 
+	// on the api side, headless intalls don't have the airgap file, only the directory with its metadata
+	if err := store.GetStore().SetTaskStatus(taskID, "Verifying package...", "running"); err != nil {
+		return errors.Wrap(err, "failed to set task status")
+	}
+	if err := verifyAirgapIntegrity(opts.AirgapPath); err != nil {
+		return errors.Wrap(err, "failed to verify airgap bundle integrity")
+	}
+
 	airgapBundle := ""
 	archiveDir := opts.AirgapPath
 	if strings.ToLower(filepath.Ext(opts.AirgapPath)) == ".airgap" {
-		// on the api side, headless intalls don't have the airgap file
 		dir, err := extractAppMetaFromAirgapBundle(opts.AirgapPath)
 		if err != nil {
 			return errors.Wrap(err, "failed to extract archive")
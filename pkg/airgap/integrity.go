@@ -0,0 +1,77 @@
+package airgap
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+// uploadedAirgapFiles are the files from the bundle that the cli uploads along with the integrity manifest
+// after it has verified the full bundle and pushed the images. images.json is generated by the cli and is not checked.
+var uploadedAirgapFiles = []string{"airgap.yaml", "app.tar.gz"}
+
+// verifyAirgapIntegrity checks an airgap bundle, or the files the cli uploaded from it, against its integrity manifest
+// before anything in it is trusted.
+// Bundles built without a manifest are accepted unless signing keys are configured.
+func verifyAirgapIntegrity(airgapPath string) error {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to get k8s clientset")
+	}
+
+	publicKeys, err := integrity.GetPublicKeys(clientset, util.PodNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get airgap signing keys")
+	}
+
+	return verifyAirgapIntegrityWithKeys(airgapPath, publicKeys)
+}
+
+func verifyAirgapIntegrityWithKeys(airgapPath string, publicKeys [][]byte) error {
+	fileInfo, err := os.Stat(airgapPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat airgap bundle")
+	}
+
+	var result *integrity.Result
+	if fileInfo.IsDir() {
+		result, err = integrity.VerifyFiles(airgapPath, uploadedAirgapFiles, publicKeys)
+	} else {
+		result, err = integrity.VerifyBundle(airgapPath, publicKeys)
+	}
+	if err != nil {
+		cause := errors.Cause(err)
+		if cause == integrity.ErrManifestMissing && len(publicKeys) == 0 {
+			logger.Infof("Airgap bundle does not include an integrity manifest, skipping integrity check")
+			return nil
+		}
+		if verifyErr, ok := cause.(integrity.Error); ok {
+			return util.ActionableError{
+				NoRetry: true,
+				Message: verifyErr.Error(),
+			}
+		}
+		if cause == integrity.ErrManifestMissing && fileInfo.IsDir() {
+			// older cli versions do not upload the manifest along with the metadata of the bundle
+			return util.ActionableError{
+				NoRetry: true,
+				Message: "airgap signing keys are configured but no integrity manifest was uploaded, upgrade the kots cli or upload the signed .airgap bundle through the admin console",
+			}
+		}
+		if cause == integrity.ErrManifestMissing || cause == integrity.ErrSignatureMissing {
+			return util.ActionableError{
+				NoRetry: true,
+				Message: cause.Error(),
+			}
+		}
+		return errors.Wrap(err, "failed to verify airgap bundle")
+	}
+
+	logger.Infof("Verified integrity of %d files and %d images in airgap bundle (signature verified: %t)", result.Files, result.Images, result.SignatureVerified)
+
+	return nil
+}
@@ -0,0 +1,466 @@
+package integrity
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/license"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ManifestFileName is the name of the integrity manifest in the root of the airgap bundle
+	ManifestFileName = "integrity.json"
+	// SignatureFileName is the name of the detached, base64 encoded signature of the integrity manifest
+	SignatureFileName = "integrity.json.sig"
+	// PublicKeysSecretName is the secret that holds PEM encoded public keys that airgap bundles must be signed with.
+	// Every key in the secret data is treated as a public key.
+	PublicKeysSecretName = "kotsadm-airgap-signing-keys"
+
+	registryStorageRoot = "images/docker/registry/v2"
+)
+
+var (
+	ErrManifestMissing  = errors.New("airgap bundle does not include an integrity manifest")
+	ErrSignatureMissing = errors.New("airgap bundle integrity manifest is not signed")
+)
+
+// Manifest lists the expected digests of the bundle contents
+type Manifest struct {
+	// Files maps every file path in the bundle, other than the manifest and its signature, to its sha256 digest
+	Files map[string]string `json:"files"`
+	// Images maps images to their manifest digests. Only used with bundles in the docker registry format,
+	// images in the docker archive format are plain files and are covered by Files.
+	Images map[string]string `json:"images,omitempty"`
+}
+
+// Result is the outcome of a successful verification
+type Result struct {
+	Files             int
+	Images            int
+	SignatureVerified bool
+	// Manifest and Signature are the verified manifest and its signature, if any, so that they can be passed on
+	// along with the files that are uploaded from the bundle
+	Manifest  []byte
+	Signature []byte
+}
+
+// Error lists every entry that failed verification
+type Error struct {
+	Missing    []string
+	Corrupted  []string
+	Unexpected []string
+}
+
+func (e Error) Error() string {
+	parts := []string{}
+	if len(e.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing: %s", strings.Join(e.Missing, ", ")))
+	}
+	if len(e.Corrupted) > 0 {
+		parts = append(parts, fmt.Sprintf("corrupted: %s", strings.Join(e.Corrupted, ", ")))
+	}
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, fmt.Sprintf("not in manifest: %s", strings.Join(e.Unexpected, ", ")))
+	}
+	return fmt.Sprintf("airgap bundle failed integrity check (%s)", strings.Join(parts, "; "))
+}
+
+func (e Error) isEmpty() bool {
+	return len(e.Missing) == 0 && len(e.Corrupted) == 0 && len(e.Unexpected) == 0
+}
+
+func (e Error) sorted() Error {
+	sort.Strings(e.Missing)
+	sort.Strings(e.Corrupted)
+	sort.Strings(e.Unexpected)
+	return e
+}
+
+// bundleContents holds the sha256 digests of all files in a bundle along with the manifest and signature contents
+type bundleContents struct {
+	digests   map[string]string
+	manifest  []byte
+	signature []byte
+}
+
+// VerifyBundle checks the contents of an airgap bundle against its integrity manifest.
+// If public keys are provided, the manifest must be signed by one of them.
+// The bundle is read only once.
+func VerifyBundle(airgapBundle string, publicKeys [][]byte) (*Result, error) {
+	contents, err := readBundleContents(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read airgap bundle")
+	}
+
+	return verifyContents(contents, publicKeys)
+}
+
+// VerifyDir checks the contents of an extracted airgap bundle against its integrity manifest.
+func VerifyDir(root string, publicKeys [][]byte) (*Result, error) {
+	contents, err := readDirContents(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read airgap directory")
+	}
+
+	return verifyContents(contents, publicKeys)
+}
+
+// VerifyFiles checks the named files in a directory against the integrity manifest in the same directory.
+// This is used for the subset of a bundle that the cli uploads after verifying the full bundle,
+// so manifest entries that are not named are not required, and images are not checked.
+func VerifyFiles(root string, names []string, publicKeys [][]byte) (*Result, error) {
+	contents := &bundleContents{
+		digests: map[string]string{},
+	}
+
+	for _, name := range []string{ManifestFileName, SignatureFileName} {
+		if err := contents.addFile(root, name); err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, errors.Wrapf(err, "failed to read %s", name)
+		}
+	}
+
+	verifyErr := Error{}
+	for _, name := range names {
+		err := contents.addFile(root, name)
+		if os.IsNotExist(errors.Cause(err)) {
+			verifyErr.Missing = append(verifyErr.Missing, cleanPath(name))
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", name)
+		}
+	}
+
+	manifest, result, err := readManifest(contents, publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, actual := range contents.digests {
+		expected, ok := manifest.Files[name]
+		if !ok {
+			verifyErr.Unexpected = append(verifyErr.Unexpected, name)
+			continue
+		}
+		if actual != expected {
+			verifyErr.Corrupted = append(verifyErr.Corrupted, name)
+		}
+	}
+
+	if !verifyErr.isEmpty() {
+		return nil, verifyErr.sorted()
+	}
+
+	result.Files = len(contents.digests)
+
+	return result, nil
+}
+
+// GetPublicKeys returns the public keys airgap bundles must be signed with. No keys means signatures are not checked.
+func GetPublicKeys(clientset kubernetes.Interface, namespace string) ([][]byte, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), PublicKeysSecretName, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get public keys secret")
+	}
+
+	names := []string{}
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	publicKeys := [][]byte{}
+	for _, name := range names {
+		publicKeys = append(publicKeys, secret.Data[name])
+	}
+
+	return publicKeys, nil
+}
+
+func verifyContents(contents *bundleContents, publicKeys [][]byte) (*Result, error) {
+	manifest, result, err := readManifest(contents, publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	verifyErr := Error{}
+
+	for name, expected := range manifest.Files {
+		actual, ok := contents.digests[name]
+		if !ok {
+			verifyErr.Missing = append(verifyErr.Missing, name)
+			continue
+		}
+		if actual != expected {
+			verifyErr.Corrupted = append(verifyErr.Corrupted, name)
+		}
+	}
+
+	for name := range contents.digests {
+		if _, ok := manifest.Files[name]; !ok {
+			verifyErr.Unexpected = append(verifyErr.Unexpected, name)
+		}
+	}
+
+	for image, manifestDigest := range manifest.Images {
+		missing, corrupted := verifyRegistryImage(contents.digests, image, normalizeDigest(manifestDigest))
+		if missing {
+			verifyErr.Missing = append(verifyErr.Missing, image)
+		} else if corrupted {
+			verifyErr.Corrupted = append(verifyErr.Corrupted, image)
+		}
+	}
+
+	if !verifyErr.isEmpty() {
+		return nil, verifyErr.sorted()
+	}
+
+	result.Files = len(manifest.Files)
+	result.Images = len(manifest.Images)
+
+	return result, nil
+}
+
+// readManifest checks the manifest signature and returns the manifest with cleaned paths and normalized digests
+func readManifest(contents *bundleContents, publicKeys [][]byte) (*Manifest, *Result, error) {
+	if contents.manifest == nil {
+		return nil, nil, ErrManifestMissing
+	}
+
+	result := &Result{
+		Manifest:  contents.manifest,
+		Signature: contents.signature,
+	}
+
+	if len(publicKeys) > 0 {
+		if contents.signature == nil {
+			return nil, nil, ErrSignatureMissing
+		}
+		if err := verifySignature(contents.manifest, contents.signature, publicKeys); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to verify integrity manifest signature")
+		}
+		result.SignatureVerified = true
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(contents.manifest, &manifest); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal integrity manifest")
+	}
+
+	files := map[string]string{}
+	for name, expected := range manifest.Files {
+		files[cleanPath(name)] = normalizeDigest(expected)
+	}
+	manifest.Files = files
+
+	return &manifest, result, nil
+}
+
+// verifyRegistryImage checks that the manifest blob of the image is in the registry storage and hashes to its digest.
+// Layers and configs are blobs too, so they are covered by the files section.
+func verifyRegistryImage(digests map[string]string, image string, manifestDigest string) (missing bool, corrupted bool) {
+	hexDigest := strings.TrimPrefix(manifestDigest, "sha256:")
+	if len(hexDigest) < 2 {
+		return false, true
+	}
+
+	blobPath := path.Join(registryStorageRoot, "blobs", "sha256", hexDigest[:2], hexDigest, "data")
+	actual, ok := digests[blobPath]
+	if !ok {
+		return true, false
+	}
+
+	return false, actual != manifestDigest
+}
+
+func verifySignature(message []byte, encodedSignature []byte, publicKeys [][]byte) error {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedSignature)))
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+
+	var lastErr error
+	for _, publicKey := range publicKeys {
+		lastErr = verify(message, signature, publicKey)
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	return lastErr
+}
+
+// verify follows license.Verify, but uses sha256 since the manifest is not limited to license sized payloads
+func verify(message, signature, publicKeyPEM []byte) error {
+	pubBlock, _ := pem.Decode(publicKeyPEM)
+	if pubBlock == nil {
+		return errors.New("failed to decode public key PEM")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(pubBlock.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to load public key from PEM")
+	}
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("public key is not an RSA key")
+	}
+
+	var opts rsa.PSSOptions
+	opts.SaltLength = rsa.PSSSaltLengthAuto
+
+	hashed := sha256.Sum256(message)
+
+	err = rsa.VerifyPSS(rsaPublicKey, crypto.SHA256, hashed[:], signature, &opts)
+	if err != nil {
+		// this ordering makes errors.Cause a little more useful
+		return errors.Wrap(license.ErrSignatureInvalid, err.Error())
+	}
+
+	return nil
+}
+
+func readBundleContents(airgapBundle string) (*bundleContents, error) {
+	fileReader, err := os.Open(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	defer fileReader.Close()
+
+	gzipReader, err := gzip.NewReader(fileReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get new gzip reader")
+	}
+	defer gzipReader.Close()
+
+	contents := &bundleContents{
+		digests: map[string]string{},
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read archive")
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := contents.add(cleanPath(header.Name), tarReader); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", header.Name)
+		}
+	}
+
+	return contents, nil
+}
+
+func readDirContents(root string) (*bundleContents, error) {
+	contents := &bundleContents{
+		digests: map[string]string{},
+	}
+
+	err := filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return errors.Wrap(err, "failed to get relative path")
+		}
+
+		f, err := os.Open(filePath)
+		if err != nil {
+			return errors.Wrap(err, "failed to open file")
+		}
+		defer f.Close()
+
+		if err := contents.add(cleanPath(filepath.ToSlash(relPath)), f); err != nil {
+			return errors.Wrapf(err, "failed to read %s", relPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk dir")
+	}
+
+	return contents, nil
+}
+
+func (c *bundleContents) addFile(root string, name string) error {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return errors.Wrap(err, "failed to open file")
+	}
+	defer f.Close()
+
+	return c.add(cleanPath(name), f)
+}
+
+func (c *bundleContents) add(name string, r io.Reader) error {
+	switch name {
+	case ManifestFileName:
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		c.manifest = b
+	case SignatureFileName:
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		c.signature = b
+	default:
+		h := sha256.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		c.digests[name] = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
+
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func normalizeDigest(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	if !strings.HasPrefix(d, "sha256:") {
+		d = "sha256:" + d
+	}
+	return d
+}
@@ -0,0 +1,186 @@
+package integrity
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyBundle(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	manifestBlob := []byte(`{"schemaVersion":2}`)
+	manifestHex := sha256Hex(manifestBlob)
+	manifestBlobPath := filepath.Join("images/docker/registry/v2/blobs/sha256", manifestHex[:2], manifestHex, "data")
+
+	files := map[string][]byte{
+		"airgap.yaml":    []byte("kind: Airgap"),
+		"app.tar.gz":     []byte("release"),
+		manifestBlobPath: manifestBlob,
+	}
+
+	validManifest := Manifest{
+		Files: map[string]string{
+			"airgap.yaml":    "sha256:" + sha256Hex(files["airgap.yaml"]),
+			"app.tar.gz":     "sha256:" + sha256Hex(files["app.tar.gz"]),
+			manifestBlobPath: "sha256:" + manifestHex,
+		},
+		Images: map[string]string{
+			"nginx:1.21": "sha256:" + manifestHex,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		manifest   *Manifest
+		signWith   *rsa.PrivateKey
+		publicKeys [][]byte
+		modify     func(files map[string][]byte)
+		wantErr    error
+		wantResult *Result
+	}{
+		{
+			name:       "valid unsigned",
+			manifest:   &validManifest,
+			wantResult: &Result{Files: 3, Images: 1},
+		},
+		{
+			name:       "valid signed",
+			manifest:   &validManifest,
+			signWith:   privateKey,
+			publicKeys: [][]byte{publicKeyPEM(t, otherKey), publicKeyPEM(t, privateKey)},
+			wantResult: &Result{Files: 3, Images: 1, SignatureVerified: true},
+		},
+		{
+			name:       "signed with unknown key",
+			manifest:   &validManifest,
+			signWith:   otherKey,
+			publicKeys: [][]byte{publicKeyPEM(t, privateKey)},
+			wantErr:    errors.New("signature is invalid"),
+		},
+		{
+			name:       "signature required",
+			manifest:   &validManifest,
+			publicKeys: [][]byte{publicKeyPEM(t, privateKey)},
+			wantErr:    ErrSignatureMissing,
+		},
+		{
+			name:    "no manifest",
+			wantErr: ErrManifestMissing,
+		},
+		{
+			name:     "corrupted, missing and unexpected files",
+			manifest: &validManifest,
+			modify: func(files map[string][]byte) {
+				files["app.tar.gz"] = []byte("tampered")
+				delete(files, manifestBlobPath)
+				files["extra.yaml"] = []byte("extra")
+			},
+			wantErr: Error{
+				Missing:    []string{manifestBlobPath, "nginx:1.21"},
+				Corrupted:  []string{"app.tar.gz"},
+				Unexpected: []string{"extra.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			bundleFiles := map[string][]byte{}
+			for name, b := range files {
+				bundleFiles[name] = b
+			}
+			if tt.modify != nil {
+				tt.modify(bundleFiles)
+			}
+
+			if tt.manifest != nil {
+				b, err := json.Marshal(tt.manifest)
+				req.NoError(err)
+				bundleFiles[ManifestFileName] = b
+
+				if tt.signWith != nil {
+					hashed := sha256.Sum256(b)
+					signature, err := rsa.SignPSS(rand.Reader, tt.signWith, crypto.SHA256, hashed[:], nil)
+					req.NoError(err)
+					bundleFiles[SignatureFileName] = []byte(base64.StdEncoding.EncodeToString(signature))
+				}
+			}
+
+			bundle := writeBundle(t, bundleFiles)
+
+			result, err := VerifyBundle(bundle, tt.publicKeys)
+			if tt.wantErr != nil {
+				req.Error(err)
+				if verifyErr, ok := tt.wantErr.(Error); ok {
+					req.Equal(verifyErr, errors.Cause(err))
+				} else {
+					req.Contains(err.Error(), tt.wantErr.Error())
+				}
+				return
+			}
+			req.NoError(err)
+			tt.wantResult.Manifest = bundleFiles[ManifestFileName]
+			tt.wantResult.Signature = bundleFiles[SignatureFileName]
+			assert.Equal(t, tt.wantResult, result)
+		})
+	}
+}
+
+func writeBundle(t *testing.T, files map[string][]byte) string {
+	bundle := filepath.Join(t.TempDir(), "app.airgap")
+
+	f, err := os.Create(bundle)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzipWriter := gzip.NewWriter(f)
+	defer gzipWriter.Close()
+
+	tarWriter := tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	for name, b := range files {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(b)),
+			Typeflag: tar.TypeReg,
+		})
+		require.NoError(t, err)
+		_, err = tarWriter.Write(b)
+		require.NoError(t, err)
+	}
+
+	return bundle
+}
+
+func publicKeyPEM(t *testing.T, privateKey *rsa.PrivateKey) []byte {
+	b, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,137 @@
+package airgap
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_verifyAirgapIntegrityWithKeys_dir uses the layout the cli uploads: the airgap metadata, the generated
+// images.json and the manifest of the full bundle along with its signature.
+func Test_verifyAirgapIntegrityWithKeys_dir(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})
+
+	bundleFiles := map[string][]byte{
+		"airgap.yaml":                      []byte("kind: Airgap"),
+		"app.tar.gz":                       []byte("release"),
+		"images/docker-archive/nginx/1.21": []byte("image"),
+	}
+
+	manifest := integrity.Manifest{Files: map[string]string{}}
+	for name, b := range bundleFiles {
+		manifest.Files[name] = fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	hashed := sha256.Sum256(manifestBytes)
+	signature, err := rsa.SignPSS(rand.Reader, privateKey, crypto.SHA256, hashed[:], nil)
+	require.NoError(t, err)
+	signatureBytes := []byte(base64.StdEncoding.EncodeToString(signature))
+
+	tests := []struct {
+		name           string
+		withManifest   bool
+		withSignature  bool
+		publicKeys     [][]byte
+		modify         func(dir string)
+		wantErr        bool
+		wantActionable bool
+	}{
+		{
+			name: "no manifest and no keys",
+		},
+		{
+			name:           "no manifest and keys",
+			publicKeys:     [][]byte{publicKey},
+			wantErr:        true,
+			wantActionable: true,
+		},
+		{
+			name:         "unsigned manifest and no keys",
+			withManifest: true,
+		},
+		{
+			name:           "unsigned manifest and keys",
+			withManifest:   true,
+			publicKeys:     [][]byte{publicKey},
+			wantErr:        true,
+			wantActionable: true,
+		},
+		{
+			name:          "signed manifest and keys",
+			withManifest:  true,
+			withSignature: true,
+			publicKeys:    [][]byte{publicKey},
+		},
+		{
+			name:          "tampered file",
+			withManifest:  true,
+			withSignature: true,
+			publicKeys:    [][]byte{publicKey},
+			modify: func(dir string) {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.tar.gz"), []byte("tampered"), 0644))
+			},
+			wantErr:        true,
+			wantActionable: true,
+		},
+		{
+			name:          "missing file",
+			withManifest:  true,
+			withSignature: true,
+			publicKeys:    [][]byte{publicKey},
+			modify: func(dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "app.tar.gz")))
+			},
+			wantErr:        true,
+			wantActionable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"airgap.yaml", "app.tar.gz"} {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), bundleFiles[name], 0644))
+			}
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "images.json"), []byte("[]"), 0644))
+			if tt.withManifest {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, integrity.ManifestFileName), manifestBytes, 0644))
+			}
+			if tt.withSignature {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, integrity.SignatureFileName), signatureBytes, 0644))
+			}
+			if tt.modify != nil {
+				tt.modify(dir)
+			}
+
+			err := verifyAirgapIntegrityWithKeys(dir, tt.publicKeys)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			_, ok := errors.Cause(err).(util.ActionableError)
+			assert.Equal(t, tt.wantActionable, ok)
+		})
+	}
+}
@@ -59,6 +59,18 @@ func UpdateAppFromPath(a *apptypes.App, airgapRoot string, airgapBundlePath stri
 		return errors.Wrap(err, "failed to set tasks status")
 	}
 
+	if err := store.GetStore().SetTaskStatus("update-download", "Verifying package...", "running"); err != nil {
+		return errors.Wrap(err, "failed to set tasks status")
+	}
+	// updates uploaded by the cli don't have the airgap file, only the directory with its metadata
+	airgapPath := airgapBundlePath
+	if airgapPath == "" {
+		airgapPath = airgapRoot
+	}
+	if err := verifyAirgapIntegrity(airgapPath); err != nil {
+		return errors.Wrap(err, "failed to verify airgap bundle integrity")
+	}
+
 	registrySettings, err := store.GetStore().GetRegistryDetailsForApp(a.ID)
 	if err != nil {
 		return errors.Wrap(err, "failed to get app registry settings")
@@ -81,6 +93,25 @@ func UpdateAppFromPath(a *apptypes.App, airgapRoot string, airgapBundlePath stri
 		}
 	}
 
+	delta, err := kotsutil.FindAirgapDeltaInDir(airgapRoot)
+	if err != nil {
+		return errors.Wrap(err, "failed to find airgap delta")
+	}
+
+	if delta != nil {
+		missingBase, err := GetMissingDeltaBaseVersion(a, airgap, delta)
+		if err != nil {
+			return errors.Wrap(err, "failed to check delta base version")
+		}
+
+		if missingBase != "" {
+			return util.ActionableError{
+				NoRetry: true,
+				Message: fmt.Sprintf("This airgap bundle only contains changes since version %s, which must be uploaded first.", missingBase),
+			}
+		}
+	}
+
 	archiveDir, baseSequence, err := store.GetStore().GetAppVersionBaseArchive(a.ID, airgap.Spec.VersionLabel)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get base archive dir for version %s", airgap.Spec.VersionLabel)
@@ -358,3 +389,59 @@ func getMissingRequiredVersions(airgap *kotsv1beta1.Airgap, license *kotsv1beta1
 
 	return missingVersions, nil
 }
+
+// GetMissingDeltaBaseVersion returns the version label of the base version a delta bundle was built against,
+// or an empty string if that version (or a later one) is already installed.
+func GetMissingDeltaBaseVersion(app *apptypes.App, airgap *kotsv1beta1.Airgap, delta *kotsutil.AirgapDelta) (string, error) {
+	appVersions, err := store.GetStore().FindDownstreamVersions(app.ID, true)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get downstream versions")
+	}
+
+	license, err := kotsutil.LoadLicenseFromBytes([]byte(app.License))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to load license")
+	}
+
+	return getMissingDeltaBaseVersion(airgap, delta, license, appVersions.AllVersions)
+}
+
+func getMissingDeltaBaseVersion(airgap *kotsv1beta1.Airgap, delta *kotsutil.AirgapDelta, license *kotsv1beta1.License, installedVersions []*downstreamtypes.DownstreamVersion) (string, error) {
+	baseName := delta.BaseVersionLabel
+	if baseName == "" {
+		baseName = delta.BaseUpdateCursor
+	}
+
+	// unlike required releases, the base of a delta bundle is needed even when nothing is installed yet
+	if len(installedVersions) == 0 {
+		return baseName, nil
+	}
+
+	channelID := delta.BaseChannelID
+	if channelID == "" {
+		channelID = airgap.Spec.ChannelID
+	}
+
+	// the base version is treated as the only required release of the bundle
+	baseAirgap := &kotsv1beta1.Airgap{
+		Spec: kotsv1beta1.AirgapSpec{
+			ChannelID: channelID,
+			RequiredReleases: []kotsv1beta1.AirgapReleaseMeta{
+				{
+					VersionLabel: delta.BaseVersionLabel,
+					UpdateCursor: delta.BaseUpdateCursor,
+				},
+			},
+		},
+	}
+
+	missingVersions, err := getMissingRequiredVersions(baseAirgap, license, installedVersions)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check base version")
+	}
+	if len(missingVersions) == 0 {
+		return "", nil
+	}
+
+	return baseName, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/blang/semver"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	"github.com/replicatedhq/kots/pkg/cursor"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	"github.com/stretchr/testify/require"
 )
@@ -203,3 +204,81 @@ func Test_getMissingRequiredVersions(t *testing.T) {
 		})
 	}
 }
+
+func Test_getMissingDeltaBaseVersion(t *testing.T) {
+	channelID := "channel-id"
+	airgap := &kotsv1beta1.Airgap{
+		Spec: kotsv1beta1.AirgapSpec{
+			ChannelID: channelID,
+		},
+	}
+	delta := &kotsutil.AirgapDelta{
+		BaseVersionLabel: "0.1.120",
+		BaseUpdateCursor: "120",
+	}
+	tests := []struct {
+		name              string
+		installedVersions []*downstreamtypes.DownstreamVersion
+		want              string
+	}{
+		{
+			name:              "nothing is installed yet",
+			installedVersions: []*downstreamtypes.DownstreamVersion{},
+			want:              "0.1.120",
+		},
+		{
+			name: "base version is installed",
+			installedVersions: []*downstreamtypes.DownstreamVersion{
+				{
+					ChannelID:    channelID,
+					VersionLabel: "0.1.120",
+					UpdateCursor: "120",
+				},
+			},
+			want: "",
+		},
+		{
+			name: "later version is installed",
+			installedVersions: []*downstreamtypes.DownstreamVersion{
+				{
+					ChannelID:    channelID,
+					VersionLabel: "0.1.122",
+					UpdateCursor: "122",
+				},
+			},
+			want: "",
+		},
+		{
+			name: "only older versions are installed",
+			installedVersions: []*downstreamtypes.DownstreamVersion{
+				{
+					ChannelID:    channelID,
+					VersionLabel: "0.1.115",
+					UpdateCursor: "115",
+				},
+			},
+			want: "0.1.120",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			for _, v := range tt.installedVersions {
+				s := semver.MustParse(v.VersionLabel)
+				v.Semver = &s
+
+				c := cursor.MustParse(v.UpdateCursor)
+				v.Cursor = &c
+			}
+
+			license := &kotsv1beta1.License{
+				Spec: kotsv1beta1.LicenseSpec{},
+			}
+
+			got, err := getMissingDeltaBaseVersion(airgap, delta, license, tt.installedVersions)
+			req.NoError(err)
+			req.Equal(tt.want, got)
+		})
+	}
+}
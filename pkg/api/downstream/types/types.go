@@ -6,6 +6,7 @@ import (
 
 	"github.com/blang/semver"
 	"github.com/replicatedhq/kots/pkg/cursor"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	kotssemver "github.com/replicatedhq/kots/pkg/semver"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
@@ -37,7 +38,10 @@ type DownstreamVersion struct {
 	PreflightSkipped   bool                               `json:"preflightSkipped"`
 	CommitURL          string                             `json:"commitUrl,omitempty"`
 	GitDeployable      bool                               `json:"gitDeployable,omitempty"`
+	PullRequest        *gitopstypes.PullRequest           `json:"pullRequest,omitempty"`
 	UpstreamReleasedAt *time.Time                         `json:"upstreamReleasedAt,omitempty"`
+	// AutoDeployScheduledAt is set when the version is waiting for the next maintenance window to be auto-deployed
+	AutoDeployScheduledAt *time.Time `json:"autoDeployScheduledAt,omitempty"`
 
 	// The following fields are not queried by default and are only added as additional details when needed
 	// because they make the queries really slow when there is a large number of versions
@@ -58,6 +62,14 @@ type DownstreamVersion struct {
 	AppIconURI                 string                          `json:"appIconUri,omitempty"`
 }
 
+// PendingAutoDeploy is a version that is waiting for the next maintenance window to be auto-deployed
+type PendingAutoDeploy struct {
+	AppID       string
+	ClusterID   string
+	Sequence    int64
+	ScheduledAt time.Time
+}
+
 type DownloadStatus struct {
 	Message string `json:"message,omitempty"`
 	Status  string `json:"status,omitempty"`
@@ -158,4 +170,18 @@ type DownstreamOutput struct {
 	HelmStdout   string `json:"helmStdout"`
 	HelmStderr   string `json:"helmStderr"`
 	RenderError  string `json:"renderError"`
+
+	ResourceResults []DownstreamResourceResult `json:"resourceResults,omitempty"`
+}
+
+// DownstreamResourceResult is the outcome of applying a single object when deploying with the server-side applier
+type DownstreamResourceResult struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Action    string `json:"action"`
+	DryRun    bool   `json:"dryRun,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
@@ -11,8 +11,10 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/appstatushistory"
 	"github.com/replicatedhq/kots/pkg/automation"
 	"github.com/replicatedhq/kots/pkg/binaries"
+	"github.com/replicatedhq/kots/pkg/filestore"
 	"github.com/replicatedhq/kots/pkg/handlers"
 	"github.com/replicatedhq/kots/pkg/helm"
 	identitymigrate "github.com/replicatedhq/kots/pkg/identity/migrate"
@@ -30,6 +32,9 @@ import (
 	"github.com/replicatedhq/kots/pkg/supportbundle"
 	"github.com/replicatedhq/kots/pkg/updatechecker"
 	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/replicatedhq/kots/pkg/version"
+	"github.com/replicatedhq/kots/pkg/versionretention"
+	"github.com/replicatedhq/kots/pkg/webhooks"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -127,6 +132,10 @@ func Start(params *APIServerParams) {
 
 	supportbundle.StartServer()
 
+	if !util.IsHelmManaged() {
+		webhooks.Start()
+	}
+
 	if err := informers.Start(); err != nil {
 		log.Println("Failed to start informers:", err)
 	}
@@ -138,12 +147,31 @@ func Start(params *APIServerParams) {
 		if err := snapshotscheduler.Start(); err != nil {
 			log.Println("Failed to start snapshot scheduler:", err)
 		}
+		if err := versionretention.StartPruneCronJob(); err != nil {
+			log.Println("Failed to start version pruning cron job:", err)
+		}
+		if err := appstatushistory.StartPruneCronJob(); err != nil {
+			log.Println("Failed to start app status history pruning cron job:", err)
+		}
+		if err := version.StartGitOpsPullRequestSyncCronJob(); err != nil {
+			log.Println("Failed to start gitops pull request sync cron job:", err)
+		}
+		if err := version.StartGitOpsDriftCheckCronJob(); err != nil {
+			log.Println("Failed to start gitops drift check cron job:", err)
+		}
+		if err := updatechecker.StartPendingAutoDeployCronJob(); err != nil {
+			log.Println("Failed to start pending auto-deploy cron job:", err)
+		}
 	}
 
 	if err := session.StartSessionPurgeCronJob(); err != nil {
 		log.Println("Failed to start session purge cron job:", err)
 	}
 
+	if err := filestore.StartGarbageCollectionCronJob(); err != nil {
+		log.Println("Failed to start file store garbage collection cron job:", err)
+	}
+
 	waitForAirgap, err := automation.NeedToWaitForAirgapApp()
 	if err != nil {
 		log.Println("Failed to check if airgap install is in progress:", err)
@@ -7,29 +7,32 @@ import (
 )
 
 type App struct {
-	ID                    string         `json:"id"`
-	Slug                  string         `json:"slug"`
-	Name                  string         `json:"name"`
-	License               string         `json:"license"`
-	IsAirgap              bool           `json:"isAirgap"`
-	CurrentSequence       int64          `json:"currentSequence"`
-	UpstreamURI           string         `json:"upstreamUri"`
-	IconURI               string         `json:"iconUri"`
-	UpdatedAt             *time.Time     `json:"updatedAt"`
-	CreatedAt             time.Time      `json:"createdAt"`
-	LastUpdateCheckAt     *time.Time     `json:"lastUpdateCheckAt"`
-	HasPreflight          bool           `json:"hasPreflight"`
-	IsConfigurable        bool           `json:"isConfigurable"`
-	SnapshotTTL           string         `json:"snapshotTtl"`
-	SnapshotSchedule      string         `json:"snapshotSchedule"`
-	RestoreInProgressName string         `json:"restoreInProgressName"`
-	RestoreUndeployStatus UndeployStatus `json:"restoreUndeloyStatus"`
-	UpdateCheckerSpec     string         `json:"updateCheckerSpec"`
-	AutoDeploy            AutoDeploy     `json:"autoDeploy"`
-	IsGitOps              bool           `json:"isGitOps"`
-	InstallState          string         `json:"installState"`
-	LastLicenseSync       string         `json:"lastLicenseSync"`
-	ChannelChanged        bool           `json:"channelChanged"`
+	ID                          string             `json:"id"`
+	Slug                        string             `json:"slug"`
+	Name                        string             `json:"name"`
+	License                     string             `json:"license"`
+	IsAirgap                    bool               `json:"isAirgap"`
+	CurrentSequence             int64              `json:"currentSequence"`
+	UpstreamURI                 string             `json:"upstreamUri"`
+	IconURI                     string             `json:"iconUri"`
+	UpdatedAt                   *time.Time         `json:"updatedAt"`
+	CreatedAt                   time.Time          `json:"createdAt"`
+	LastUpdateCheckAt           *time.Time         `json:"lastUpdateCheckAt"`
+	HasPreflight                bool               `json:"hasPreflight"`
+	IsConfigurable              bool               `json:"isConfigurable"`
+	SnapshotTTL                 string             `json:"snapshotTtl"`
+	SnapshotSchedule            string             `json:"snapshotSchedule"`
+	RestoreVerificationSchedule string             `json:"restoreVerificationSchedule,omitempty"`
+	RestoreVerificationNextAt   *time.Time         `json:"restoreVerificationNextAt,omitempty"`
+	RestoreInProgressName       string             `json:"restoreInProgressName"`
+	RestoreUndeployStatus       UndeployStatus     `json:"restoreUndeloyStatus"`
+	UpdateCheckerSpec           string             `json:"updateCheckerSpec"`
+	AutoDeploy                  AutoDeploy         `json:"autoDeploy"`
+	MaintenanceWindow           *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	IsGitOps                    bool               `json:"isGitOps"`
+	InstallState                string             `json:"installState"`
+	LastLicenseSync             string             `json:"lastLicenseSync"`
+	ChannelChanged              bool               `json:"channelChanged"`
 }
 
 func (a *App) GetID() string {
@@ -19,6 +19,19 @@ const (
 	AutoDeploySequence              AutoDeploy = "sequence"
 )
 
+// MaintenanceWindow limits auto-deploys to the times when the window is open.
+// Versions found outside of the window are deployed when the next window opens.
+type MaintenanceWindow struct {
+	// Schedule is a cron spec for when the window opens
+	Schedule string `json:"schedule"`
+	// Duration is how long the window stays open, e.g. "4h"
+	Duration string `json:"duration"`
+	// Timezone is the IANA time zone of the schedule and blackout dates, defaults to UTC
+	Timezone string `json:"timezone,omitempty"`
+	// BlackoutDates are dates in YYYY-MM-DD format on which nothing is auto-deployed
+	BlackoutDates []string `json:"blackoutDates,omitempty"`
+}
+
 type AppType interface {
 	GetID() string
 	GetSlug() string
@@ -44,9 +44,17 @@ func GetV1Beta2ChartsArchive(deployedVersionArchive string) ([]byte, error) {
 	return archive, nil
 }
 
+// GetV1Beta2ChartsFileMap returns a map of the v1beta2 chart archives and values files to be deployed
+func GetV1Beta2ChartsFileMap(deployedVersionArchive string) (map[string][]byte, error) {
+	return getChartsDirFileMap(filepath.Join(deployedVersionArchive, "helm"))
+}
+
 // GetRenderedV1Beta2FileMap returns a map of the rendered v1beta2 charts to be deployed
 func GetRenderedV1Beta2FileMap(deployedVersionArchive, downstream string) (map[string][]byte, error) {
-	chartsDir := filepath.Join(deployedVersionArchive, "rendered", downstream, "helm")
+	return getChartsDirFileMap(filepath.Join(deployedVersionArchive, "rendered", downstream, "helm"))
+}
+
+func getChartsDirFileMap(chartsDir string) (map[string][]byte, error) {
 	if _, err := os.Stat(chartsDir); err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -0,0 +1,234 @@
+package appstate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	batchinformers "k8s.io/client-go/informers/batch/v1"
+	"k8s.io/client-go/kubernetes"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	CronJobResourceKind = "cronjob"
+	CronJobOwnerKind    = "CronJob"
+)
+
+func init() {
+	registerResourceKindNames(CronJobResourceKind, "cronjobs", "cj")
+}
+
+func runCronJobController(
+	ctx context.Context, clientset kubernetes.Interface, targetNamespace string,
+	informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+) {
+	listwatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.BatchV1().CronJobs(targetNamespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.BatchV1().CronJobs(targetNamespace).Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedInformer(
+		listwatch,
+		&batchv1.CronJob{},
+		time.Minute,
+	)
+
+	// cronjobs rely on the status of the jobs they create and of the pods of those jobs
+	jobInformer := batchinformers.NewJobInformer(
+		clientset,
+		targetNamespace,
+		time.Minute,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	podInformer := newJobPodInformer(clientset, targetNamespace)
+
+	eventHandler := NewCronJobEventHandler(
+		batchlisters.NewJobLister(jobInformer.GetIndexer()),
+		corelisters.NewPodLister(podInformer.GetIndexer()),
+		filterStatusInformersByResourceKind(informers, CronJobResourceKind),
+		resourceStateCh,
+	)
+
+	onJobChanged := func(job *batchv1.Job) {
+		if cronJob := cronJobForJob(informer.GetStore(), job); cronJob != nil {
+			eventHandler.ObjectUpdated(cronJob)
+		}
+	}
+	jobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if job, ok := obj.(*batchv1.Job); ok {
+				onJobChanged(job)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if job, ok := new.(*batchv1.Job); ok {
+				onJobChanged(job)
+			}
+		},
+	})
+	podInformer.AddEventHandler(jobPodEventHandler(jobInformer.GetStore(), onJobChanged))
+	go jobInformer.Run(ctx.Done())
+	go podInformer.Run(ctx.Done())
+
+	runInformer(ctx, informer, eventHandler)
+	return
+}
+
+// cronJobForJob returns the cronjob from cronJobStore that created the job, or nil
+func cronJobForJob(cronJobStore cache.Store, job *batchv1.Job) *batchv1.CronJob {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind != CronJobOwnerKind {
+			continue
+		}
+		item, exists, err := cronJobStore.GetByKey(fmt.Sprintf("%s/%s", job.Namespace, owner.Name))
+		if err != nil || !exists {
+			return nil
+		}
+		cronJob, _ := item.(*batchv1.CronJob)
+		return cronJob
+	}
+	return nil
+}
+
+type cronJobEventHandler struct {
+	jobLister       batchlisters.JobLister
+	podLister       corelisters.PodLister
+	informers       []types.StatusInformer
+	resourceStateCh chan<- types.ResourceState
+}
+
+func NewCronJobEventHandler(jobLister batchlisters.JobLister, podLister corelisters.PodLister, informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState) *cronJobEventHandler {
+	return &cronJobEventHandler{
+		jobLister:       jobLister,
+		podLister:       podLister,
+		informers:       informers,
+		resourceStateCh: resourceStateCh,
+	}
+}
+
+func (h *cronJobEventHandler) ObjectCreated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeCronJobResourceState(r, CalculateCronJobState(h.jobLister, h.podLister, r))
+}
+
+func (h *cronJobEventHandler) ObjectUpdated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeCronJobResourceState(r, CalculateCronJobState(h.jobLister, h.podLister, r))
+}
+
+func (h *cronJobEventHandler) ObjectDeleted(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeCronJobResourceState(r, types.StateMissing)
+}
+
+func (h *cronJobEventHandler) cast(obj interface{}) *batchv1.CronJob {
+	r, _ := obj.(*batchv1.CronJob)
+	return r
+}
+
+func (h *cronJobEventHandler) getInformer(r *batchv1.CronJob) (types.StatusInformer, bool) {
+	if r != nil {
+		for _, informer := range h.informers {
+			if r.Namespace == informer.Namespace && r.Name == informer.Name {
+				return informer, true
+			}
+		}
+	}
+	return types.StatusInformer{}, false
+}
+
+func makeCronJobResourceState(r *batchv1.CronJob, state types.State) types.ResourceState {
+	return types.ResourceState{
+		Kind:      CronJobResourceKind,
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		State:     state,
+	}
+}
+
+// CalculateCronJobState reports the result of the most recent job that finished. A job that is
+// currently running is only taken into account if its pods are failing, or if it is the first run.
+// When the jobs have been removed by the history limits, the last schedule and last successful
+// times are compared instead.
+func CalculateCronJobState(jobLister batchlisters.JobLister, podLister corelisters.PodLister, r *batchv1.CronJob) types.State {
+	if r == nil {
+		return types.StateMissing
+	}
+
+	jobs, err := listCronJobJobs(jobLister, r)
+	if err != nil {
+		log.Printf("failed to get cronjob job list: %s", err)
+		return types.StateUnavailable
+	}
+
+	for _, job := range jobs {
+		switch state := CalculateJobState(podLister, job); state {
+		case types.StateReady, types.StateUnavailable, types.StateDegraded:
+			return state
+		}
+	}
+
+	if r.Status.LastScheduleTime == nil {
+		// never scheduled
+		return types.StateReady
+	}
+	if r.Status.LastSuccessfulTime != nil && !r.Status.LastSuccessfulTime.Before(r.Status.LastScheduleTime) {
+		return types.StateReady
+	}
+	if len(r.Status.Active) > 0 {
+		if r.Status.LastSuccessfulTime == nil {
+			// the first run is in progress
+			return types.StateUpdating
+		}
+		// the run that was last scheduled is in progress
+		return types.StateReady
+	}
+	return types.StateUnavailable
+}
+
+// listCronJobJobs returns the jobs created by the cronjob, newest first
+func listCronJobJobs(jobLister batchlisters.JobLister, r *batchv1.CronJob) ([]*batchv1.Job, error) {
+	jobList, err := jobLister.Jobs(r.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []*batchv1.Job{}
+	for _, job := range jobList {
+		for _, owner := range job.ObjectMeta.OwnerReferences {
+			if owner.Kind == CronJobOwnerKind && owner.Name == r.Name {
+				jobs = append(jobs, job)
+				break
+			}
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+
+	return jobs, nil
+}
@@ -0,0 +1,197 @@
+package appstate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func mockCronJobJob(name string, cronJobName string, created time.Time, status batchv1.JobStatus) *batchv1.Job {
+	job := mockJob(name, status)
+	job.CreationTimestamp = metav1.NewTime(created)
+	job.OwnerReferences = []metav1.OwnerReference{
+		{Kind: CronJobOwnerKind, Name: cronJobName},
+	}
+	return job
+}
+
+func mockCronJob(lastSchedule *time.Time, lastSuccessful *time.Time) *batchv1.CronJob {
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: metav1.NamespaceDefault,
+		},
+	}
+	if lastSchedule != nil {
+		t := metav1.NewTime(*lastSchedule)
+		cronJob.Status.LastScheduleTime = &t
+	}
+	if lastSuccessful != nil {
+		t := metav1.NewTime(*lastSuccessful)
+		cronJob.Status.LastSuccessfulTime = &t
+	}
+	return cronJob
+}
+
+func mockActiveCronJob(lastSchedule *time.Time, lastSuccessful *time.Time, activeJobName string) *batchv1.CronJob {
+	cronJob := mockCronJob(lastSchedule, lastSuccessful)
+	cronJob.Status.Active = []corev1.ObjectReference{{Kind: "Job", Name: activeJobName, Namespace: cronJob.Namespace}}
+	return cronJob
+}
+
+func TestCalculateCronJobState(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	completed := batchv1.JobStatus{
+		Succeeded:  1,
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+	}
+	failed := batchv1.JobStatus{
+		Failed:     6,
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+	}
+	active := batchv1.JobStatus{Active: 1}
+
+	type args struct {
+		clientset kubernetes.Interface
+		r         *batchv1.CronJob
+	}
+	tests := []struct {
+		name string
+		args args
+		want types.State
+	}{
+		{
+			name: "expect ready state when cronjob was never scheduled",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r:         mockCronJob(nil, nil),
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect ready state when last job completed",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", earlier, failed),
+					mockCronJobJob("backup-2", "backup", now, completed),
+				),
+				r: mockCronJob(&now, &now),
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect unavailable state when last job failed",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", earlier, completed),
+					mockCronJobJob("backup-2", "backup", now, failed),
+				),
+				r: mockCronJob(&now, &earlier),
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect result of previous job when a job is running",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", earlier, failed),
+					mockCronJobJob("backup-2", "backup", now, active),
+				),
+				r: mockCronJob(&now, nil),
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect updating state when the first job is running",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", now, active),
+				),
+				r: mockActiveCronJob(&now, nil, "backup-1"),
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect updating state when the first job is running and not listed yet",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r:         mockActiveCronJob(&now, nil, "backup-1"),
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect ready state when a job is running after a job succeeded",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r:         mockActiveCronJob(&now, &earlier, "backup-2"),
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect degraded state when running job pod is crash looping",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", earlier, completed),
+					mockCronJobJob("backup-2", "backup", now, active),
+					mockJobPod("backup-2-abcde", "backup-2", "CrashLoopBackOff"),
+				),
+				r: mockCronJob(&now, &earlier),
+			},
+			want: types.StateDegraded,
+		},
+		{
+			name: "expect jobs of other cronjobs to be ignored",
+			args: args{
+				clientset: fake.NewSimpleClientset(
+					mockCronJobJob("backup-1", "backup", earlier, completed),
+					mockCronJobJob("report-1", "report", now, failed),
+				),
+				r: mockCronJob(&earlier, &earlier),
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect unavailable state when failed jobs were removed by the history limit",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r:         mockCronJob(&now, &earlier),
+			},
+			want: types.StateUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobLister, podLister := testJobListers(t, tt.args.clientset)
+			if got := CalculateCronJobState(jobLister, podLister, tt.args.r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateCronJobState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronJobForJob(t *testing.T) {
+	cronJobStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	if err := cronJobStore.Add(mockCronJob(nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cronJobForJob(cronJobStore, mockCronJobJob("backup-1", "backup", time.Now(), batchv1.JobStatus{})); got == nil || got.Name != "backup" {
+		t.Errorf("cronJobForJob() = %v, want backup", got)
+	}
+	if got := cronJobForJob(cronJobStore, mockCronJobJob("report-1", "report", time.Now(), batchv1.JobStatus{})); got != nil {
+		t.Errorf("cronJobForJob() = %v, want nil", got)
+	}
+	if got := cronJobForJob(cronJobStore, mockJob("migrate", batchv1.JobStatus{})); got != nil {
+		t.Errorf("cronJobForJob() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,368 @@
+package appstate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	// customResourceResolveInterval is how often a custom resource kind that is not served yet is resolved again,
+	// for example while the CRD is still being installed
+	customResourceResolveInterval = 30 * time.Second
+)
+
+// conditions that report the readiness of a custom resource, in order of preference
+var customResourceReadyConditionTypes = []string{"Ready", "Available"}
+
+// isCustomResourceKind returns true for group qualified kinds such as "certificates.cert-manager.io"
+func isCustomResourceKind(kind string) bool {
+	return strings.Contains(kind, ".")
+}
+
+func newCustomResourceController(dynamicClient dynamic.Interface, kind string) runControllerFunc {
+	return func(
+		ctx context.Context, clientset kubernetes.Interface, targetNamespace string,
+		informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+	) {
+		var gvr schema.GroupVersionResource
+		var namespaced bool
+		for {
+			var err error
+			gvr, namespaced, err = resolveCustomResource(clientset.Discovery(), kind)
+			if err == nil {
+				break
+			}
+			log.Printf("Failed to resolve custom resource kind %s, retrying in %s: %v", kind, customResourceResolveInterval, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(customResourceResolveInterval):
+			}
+		}
+
+		var resourceInterface dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+		if namespaced {
+			resourceInterface = dynamicClient.Resource(gvr).Namespace(targetNamespace)
+		}
+
+		listwatch := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resourceInterface.List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resourceInterface.Watch(context.TODO(), options)
+			},
+		}
+		informer := cache.NewSharedInformer(
+			listwatch,
+			&unstructured.Unstructured{},
+			time.Minute,
+		)
+
+		eventHandler := NewCustomResourceEventHandler(
+			filterStatusInformersByResourceKind(informers, kind),
+			namespaced,
+			resourceStateCh,
+		)
+
+		runInformer(ctx, informer, eventHandler)
+	}
+}
+
+// resolveCustomResource finds the resource served by the cluster for a kind in the form
+// resource.group or resource.version.group, where resource may also be the singular name or the kind
+func resolveCustomResource(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, bool, error) {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	var gvr schema.GroupVersionResource
+	var err error
+	fullySpecified, groupResource := schema.ParseResourceArg(strings.ToLower(kind))
+	if fullySpecified != nil {
+		gvr, err = mapper.ResourceFor(*fullySpecified)
+	}
+	if fullySpecified == nil || err != nil {
+		gvr, err = mapper.ResourceFor(groupResource.WithVersion(""))
+		if err != nil {
+			return schema.GroupVersionResource{}, false, errors.Wrap(err, "failed to find resource")
+		}
+	}
+
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, errors.Wrap(err, "failed to find kind")
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, errors.Wrap(err, "failed to get rest mapping")
+	}
+
+	return gvr, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+type customResourceEventHandler struct {
+	informers  []types.StatusInformer
+	namespaced bool
+	// readyExpressions are keyed by readyExpressionKey of the informer
+	readyExpressions map[string]*readyExpression
+	resourceStateCh  chan<- types.ResourceState
+}
+
+func NewCustomResourceEventHandler(informers []types.StatusInformer, namespaced bool, resourceStateCh chan<- types.ResourceState) *customResourceEventHandler {
+	readyExpressions := map[string]*readyExpression{}
+	for _, informer := range informers {
+		if informer.ReadyExpression == "" {
+			continue
+		}
+		expression, err := parseReadyExpression(informer.ReadyExpression)
+		if err != nil {
+			// a nil expression reports the resource as unavailable so that the mistake is visible
+			log.Printf("Invalid ready expression for %s/%s/%s: %v", informer.Namespace, informer.Kind, informer.Name, err)
+		}
+		readyExpressions[readyExpressionKey(informer)] = expression
+	}
+
+	return &customResourceEventHandler{
+		informers:        informers,
+		namespaced:       namespaced,
+		readyExpressions: readyExpressions,
+		resourceStateCh:  resourceStateCh,
+	}
+}
+
+func (h *customResourceEventHandler) ObjectCreated(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(informer, h.calculateState(informer, r))
+}
+
+func (h *customResourceEventHandler) ObjectUpdated(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(informer, h.calculateState(informer, r))
+}
+
+func (h *customResourceEventHandler) ObjectDeleted(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(informer, types.StateMissing)
+}
+
+func (h *customResourceEventHandler) cast(obj interface{}) *unstructured.Unstructured {
+	r, _ := obj.(*unstructured.Unstructured)
+	return r
+}
+
+func (h *customResourceEventHandler) getInformer(r *unstructured.Unstructured) (types.StatusInformer, bool) {
+	if r != nil {
+		for _, informer := range h.informers {
+			if (!h.namespaced || r.GetNamespace() == informer.Namespace) && r.GetName() == informer.Name {
+				return informer, true
+			}
+		}
+	}
+	return types.StatusInformer{}, false
+}
+
+func (h *customResourceEventHandler) calculateState(informer types.StatusInformer, r *unstructured.Unstructured) types.State {
+	if informer.ReadyExpression == "" {
+		return calculateCustomResourceState(r, nil)
+	}
+	expression := h.readyExpressions[readyExpressionKey(informer)]
+	if expression == nil {
+		return types.StateUnavailable
+	}
+	return calculateCustomResourceState(r, expression)
+}
+
+func readyExpressionKey(informer types.StatusInformer) string {
+	return fmt.Sprintf("%s/%s/%s", informer.Namespace, informer.Kind, informer.Name)
+}
+
+// makeCustomResourceResourceState uses the kind and namespace of the informer, since the kind of
+// a custom resource can be written in several ways and cluster scoped resources have no namespace
+func makeCustomResourceResourceState(informer types.StatusInformer, state types.State) types.ResourceState {
+	return types.ResourceState{
+		Kind:      informer.Kind,
+		Name:      informer.Name,
+		Namespace: informer.Namespace,
+		State:     state,
+	}
+}
+
+// calculateCustomResourceState evaluates the ready expression if there is one. Otherwise the Ready
+// or Available condition is used, and resources that have neither condition are ready once they exist.
+func calculateCustomResourceState(r *unstructured.Unstructured, expression *readyExpression) types.State {
+	if isCustomResourceGenerationStale(r) {
+		return types.StateUpdating
+	}
+
+	_, hasStatus := r.Object["status"]
+
+	if expression != nil {
+		ready, err := expression.Evaluate(r.Object)
+		if err != nil {
+			log.Printf("Failed to evaluate ready expression for %s/%s: %v", r.GetNamespace(), r.GetName(), err)
+			return types.StateUnavailable
+		}
+		if ready {
+			return types.StateReady
+		}
+		if !hasStatus {
+			// the operator has not reconciled the resource yet
+			return types.StateUpdating
+		}
+		return types.StateUnavailable
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(r.Object, "status", "conditions")
+	for _, conditionType := range customResourceReadyConditionTypes {
+		condition, ok := findCustomResourceCondition(conditions, conditionType)
+		if !ok {
+			continue
+		}
+		if observedGeneration, ok := condition["observedGeneration"].(int64); ok && observedGeneration < r.GetGeneration() {
+			return types.StateUpdating
+		}
+		switch condition["status"] {
+		case string(metav1.ConditionTrue):
+			return types.StateReady
+		case string(metav1.ConditionFalse):
+			return types.StateUnavailable
+		default:
+			return types.StateUpdating
+		}
+	}
+
+	return types.StateReady
+}
+
+func isCustomResourceGenerationStale(r *unstructured.Unstructured) bool {
+	observedGeneration, found, err := unstructured.NestedInt64(r.Object, "status", "observedGeneration")
+	if err != nil || !found {
+		return false
+	}
+	return observedGeneration < r.GetGeneration()
+}
+
+func findCustomResourceCondition(conditions []interface{}, conditionType string) (map[string]interface{}, bool) {
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == conditionType {
+			return condition, true
+		}
+	}
+	return nil, false
+}
+
+// readyExpression is a JSONPath template such as "{.status.phase}", optionally followed by
+// "==value" or "!=value". Without a comparison the result must be "true".
+type readyExpression struct {
+	path     *jsonpath.JSONPath
+	operator string
+	value    string
+}
+
+func parseReadyExpression(str string) (*readyExpression, error) {
+	str = strings.TrimSpace(str)
+	if !strings.HasPrefix(str, "{") {
+		return nil, errors.New("expression must start with a JSONPath template such as {.status.phase}")
+	}
+
+	end, err := templateEnd(str)
+	if err != nil {
+		return nil, err
+	}
+	template, comparison := str[:end], strings.TrimSpace(str[end:])
+
+	expression := &readyExpression{
+		path: jsonpath.New("ready").AllowMissingKeys(true),
+	}
+	if err := expression.path.Parse(template); err != nil {
+		return nil, errors.Wrap(err, "failed to parse jsonpath")
+	}
+
+	switch {
+	case comparison == "":
+	case strings.HasPrefix(comparison, "=="), strings.HasPrefix(comparison, "!="):
+		expression.operator = comparison[:2]
+		expression.value = strings.TrimSpace(comparison[2:])
+	default:
+		return nil, errors.Errorf("unexpected %q after jsonpath, expected == or !=", comparison)
+	}
+
+	return expression, nil
+}
+
+// templateEnd returns the index after the brace that closes the jsonpath template at the start of str,
+// so that the value compared against can contain braces. Braces in quoted filter values are ignored.
+func templateEnd(str string) (int, error) {
+	depth := 0
+	var quote rune
+	for i, c := range str {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, errors.New("jsonpath template is missing a closing brace")
+}
+
+func (e *readyExpression) Evaluate(obj map[string]interface{}) (bool, error) {
+	buf := new(bytes.Buffer)
+	if err := e.path.Execute(buf, obj); err != nil {
+		return false, errors.Wrap(err, "failed to execute jsonpath")
+	}
+	result := strings.TrimSpace(buf.String())
+
+	switch e.operator {
+	case "==":
+		return result == e.value, nil
+	case "!=":
+		return result != e.value, nil
+	default:
+		return result == "true", nil
+	}
+}
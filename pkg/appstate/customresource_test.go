@@ -0,0 +1,331 @@
+package appstate
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func makeCustomResource(generation int64, status map[string]interface{}) *unstructured.Unstructured {
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name":       "my-db",
+				"namespace":  "default",
+				"generation": generation,
+			},
+		},
+	}
+	if status != nil {
+		r.Object["status"] = status
+	}
+	return r
+}
+
+func makeCondition(conditionType string, status string) interface{} {
+	return map[string]interface{}{
+		"type":   conditionType,
+		"status": status,
+	}
+}
+
+func TestCalculateCustomResourceState(t *testing.T) {
+	tests := []struct {
+		name            string
+		resource        *unstructured.Unstructured
+		readyExpression string
+		want            types.State
+	}{
+		{
+			name:     "no status",
+			resource: makeCustomResource(1, nil),
+			want:     types.StateReady,
+		},
+		{
+			name: "ready condition true",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{makeCondition("Ready", "True")},
+			}),
+			want: types.StateReady,
+		},
+		{
+			name: "ready condition false",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{makeCondition("Ready", "False")},
+			}),
+			want: types.StateUnavailable,
+		},
+		{
+			name: "ready condition unknown",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{makeCondition("Ready", "Unknown")},
+			}),
+			want: types.StateUpdating,
+		},
+		{
+			name: "ready condition takes precedence over available",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{makeCondition("Available", "True"), makeCondition("Ready", "False")},
+			}),
+			want: types.StateUnavailable,
+		},
+		{
+			name: "available condition",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{makeCondition("Progressing", "True"), makeCondition("Available", "True")},
+			}),
+			want: types.StateReady,
+		},
+		{
+			name: "stale observed generation",
+			resource: makeCustomResource(2, map[string]interface{}{
+				"observedGeneration": int64(1),
+				"conditions":         []interface{}{makeCondition("Ready", "True")},
+			}),
+			want: types.StateUpdating,
+		},
+		{
+			name: "stale condition observed generation",
+			resource: makeCustomResource(2, map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)},
+				},
+			}),
+			want: types.StateUpdating,
+		},
+		{
+			name: "expression matches",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"phase": "Running",
+			}),
+			readyExpression: "{.status.phase}==Running",
+			want:            types.StateReady,
+		},
+		{
+			name: "expression does not match",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"phase": "Failed",
+			}),
+			readyExpression: "{.status.phase} == Running",
+			want:            types.StateUnavailable,
+		},
+		{
+			name:            "expression without status",
+			resource:        makeCustomResource(1, nil),
+			readyExpression: "{.status.phase}==Running",
+			want:            types.StateUpdating,
+		},
+		{
+			name: "expression not equal",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"endpoint": "db.example.com:5432",
+			}),
+			readyExpression: "{.status.endpoint}!=",
+			want:            types.StateReady,
+		},
+		{
+			name: "expression value contains braces",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"message": "{ok}",
+			}),
+			readyExpression: "{.status.message}=={ok}",
+			want:            types.StateReady,
+		},
+		{
+			name: "expression value ends with a brace",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"message": "failed}",
+			}),
+			readyExpression: "{.status.message}!=failed}",
+			want:            types.StateUnavailable,
+		},
+		{
+			name: "expression filter contains braces",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready}", "status": "True"},
+				},
+			}),
+			readyExpression: `{.status.conditions[?(@.type=="Ready}")].status}==True`,
+			want:            types.StateReady,
+		},
+		{
+			name: "boolean expression",
+			resource: makeCustomResource(1, map[string]interface{}{
+				"healthy": true,
+			}),
+			readyExpression: "{.status.healthy}",
+			want:            types.StateReady,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expression *readyExpression
+			if tt.readyExpression != "" {
+				var err error
+				expression, err = parseReadyExpression(tt.readyExpression)
+				if err != nil {
+					t.Fatalf("parseReadyExpression() error = %v", err)
+				}
+			}
+			if got := calculateCustomResourceState(tt.resource, expression); got != tt.want {
+				t.Errorf("calculateCustomResourceState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReadyExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    bool
+	}{
+		{
+			name:       "jsonpath",
+			expression: "{.status.ready}",
+		},
+		{
+			name:       "comparison",
+			expression: "{.status.phase}==Running",
+		},
+		{
+			name:       "value with braces",
+			expression: "{.status.phase}=={Running}",
+		},
+		{
+			name:       "missing closing brace",
+			expression: "{.status.phase==Running",
+			wantErr:    true,
+		},
+		{
+			name:       "missing braces",
+			expression: ".status.phase==Running",
+			wantErr:    true,
+		},
+		{
+			name:       "unknown operator",
+			expression: "{.status.replicas}>1",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid jsonpath",
+			expression: "{.status[}",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseReadyExpression(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseReadyExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomResourceEventHandlerReadyExpressions(t *testing.T) {
+	// resources with the same name in different namespaces use their own ready expression
+	informers := []types.StatusInformer{
+		{Kind: "databases.example.com", Name: "my-db", Namespace: "a", ReadyExpression: "{.status.phase}==Running"},
+		{Kind: "databases.example.com", Name: "my-db", Namespace: "b", ReadyExpression: "{.status.phase}==Healthy"},
+	}
+
+	resourceStateCh := make(chan types.ResourceState, 2)
+	handler := NewCustomResourceEventHandler(informers, true, resourceStateCh)
+
+	tests := []struct {
+		namespace string
+		wantState types.State
+	}{
+		{namespace: "a", wantState: types.StateReady},
+		{namespace: "b", wantState: types.StateUnavailable},
+	}
+	for _, tt := range tests {
+		r := makeCustomResource(1, map[string]interface{}{"phase": "Running"})
+		r.SetNamespace(tt.namespace)
+
+		handler.ObjectCreated(r)
+		got := <-resourceStateCh
+		if got.Namespace != tt.namespace || got.State != tt.wantState {
+			t.Errorf("ObjectCreated() in namespace %s = %s/%s, want %s", tt.namespace, got.Namespace, got.State, tt.wantState)
+		}
+	}
+}
+
+func TestResolveCustomResource(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*discoveryfake.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", SingularName: "certificate", Kind: "Certificate", Namespaced: true},
+				{Name: "clusterissuers", SingularName: "clusterissuer", Kind: "ClusterIssuer", Namespaced: false},
+			},
+		},
+	}
+
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	clusterIssuers := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+
+	tests := []struct {
+		name           string
+		kind           string
+		wantGVR        schema.GroupVersionResource
+		wantNamespaced bool
+		wantErr        bool
+	}{
+		{
+			name:           "resource.group",
+			kind:           "certificates.cert-manager.io",
+			wantGVR:        certificates,
+			wantNamespaced: true,
+		},
+		{
+			name:           "resource.version.group",
+			kind:           "certificates.v1.cert-manager.io",
+			wantGVR:        certificates,
+			wantNamespaced: true,
+		},
+		{
+			name:           "kind.group",
+			kind:           "Certificate.cert-manager.io",
+			wantGVR:        certificates,
+			wantNamespaced: true,
+		},
+		{
+			name:    "cluster scoped",
+			kind:    "clusterissuer.cert-manager.io",
+			wantGVR: clusterIssuers,
+		},
+		{
+			name:    "not served",
+			kind:    "databases.example.com",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gvr, namespaced, err := resolveCustomResource(clientset.Discovery(), tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCustomResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gvr != tt.wantGVR {
+				t.Errorf("resolveCustomResource() gvr = %v, want %v", gvr, tt.wantGVR)
+			}
+			if namespaced != tt.wantNamespaced {
+				t.Errorf("resolveCustomResource() namespaced = %v, want %v", namespaced, tt.wantNamespaced)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package appstate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	JobResourceKind = "job"
+
+	// jobNameLabel is added to the pods created by jobs
+	jobNameLabel = "job-name"
+)
+
+func init() {
+	registerResourceKindNames(JobResourceKind, "jobs")
+}
+
+func runJobController(
+	ctx context.Context, clientset kubernetes.Interface, targetNamespace string,
+	informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+) {
+	listwatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.BatchV1().Jobs(targetNamespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.BatchV1().Jobs(targetNamespace).Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedInformer(
+		listwatch,
+		&batchv1.Job{},
+		time.Minute,
+	)
+
+	podInformer := newJobPodInformer(clientset, targetNamespace)
+
+	eventHandler := NewJobEventHandler(
+		corelisters.NewPodLister(podInformer.GetIndexer()),
+		filterStatusInformersByResourceKind(informers, JobResourceKind),
+		resourceStateCh,
+	)
+
+	// containers restarting in place do not update the job status, so recalculate the job when its pods change
+	podInformer.AddEventHandler(jobPodEventHandler(informer.GetStore(), func(job *batchv1.Job) {
+		eventHandler.ObjectUpdated(job)
+	}))
+	go podInformer.Run(ctx.Done())
+
+	runInformer(ctx, informer, eventHandler)
+	return
+}
+
+// newJobPodInformer returns an informer for the pods created by jobs in the namespace
+func newJobPodInformer(clientset kubernetes.Interface, targetNamespace string) cache.SharedIndexInformer {
+	return coreinformers.NewFilteredPodInformer(
+		clientset,
+		targetNamespace,
+		time.Minute,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		func(options *metav1.ListOptions) {
+			options.LabelSelector = jobNameLabel
+		},
+	)
+}
+
+// jobPodEventHandler calls onJobChanged with the job from jobStore that created the pod
+func jobPodEventHandler(jobStore cache.Store, onJobChanged func(job *batchv1.Job)) cache.ResourceEventHandler {
+	handle := func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Labels[jobNameLabel] == "" {
+			return
+		}
+		item, exists, err := jobStore.GetByKey(fmt.Sprintf("%s/%s", pod.Namespace, pod.Labels[jobNameLabel]))
+		if err != nil || !exists {
+			return
+		}
+		if job, ok := item.(*batchv1.Job); ok {
+			onJobChanged(job)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: handle,
+		UpdateFunc: func(old, new interface{}) {
+			handle(new)
+		},
+	}
+}
+
+type jobEventHandler struct {
+	podLister       corelisters.PodLister
+	informers       []types.StatusInformer
+	resourceStateCh chan<- types.ResourceState
+}
+
+func NewJobEventHandler(podLister corelisters.PodLister, informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState) *jobEventHandler {
+	return &jobEventHandler{
+		podLister:       podLister,
+		informers:       informers,
+		resourceStateCh: resourceStateCh,
+	}
+}
+
+func (h *jobEventHandler) ObjectCreated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeJobResourceState(r, CalculateJobState(h.podLister, r))
+}
+
+func (h *jobEventHandler) ObjectUpdated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeJobResourceState(r, CalculateJobState(h.podLister, r))
+}
+
+func (h *jobEventHandler) ObjectDeleted(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makeJobResourceState(r, types.StateMissing)
+}
+
+func (h *jobEventHandler) cast(obj interface{}) *batchv1.Job {
+	r, _ := obj.(*batchv1.Job)
+	return r
+}
+
+func (h *jobEventHandler) getInformer(r *batchv1.Job) (types.StatusInformer, bool) {
+	if r != nil {
+		for _, informer := range h.informers {
+			if r.Namespace == informer.Namespace && r.Name == informer.Name {
+				return informer, true
+			}
+		}
+	}
+	return types.StatusInformer{}, false
+}
+
+func makeJobResourceState(r *batchv1.Job, state types.State) types.ResourceState {
+	return types.ResourceState{
+		Kind:      JobResourceKind,
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		State:     state,
+	}
+}
+
+// CalculateJobState maps completed jobs to ready and failed jobs to unavailable. Running jobs are
+// updating, or degraded if pods have failed or are crash looping while the job is being retried.
+func CalculateJobState(podLister corelisters.PodLister, r *batchv1.Job) types.State {
+	if r == nil {
+		return types.StateMissing
+	}
+
+	for _, condition := range r.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return types.StateReady
+		case batchv1.JobFailed:
+			return types.StateUnavailable
+		}
+	}
+
+	completions := int32(1)
+	if r.Spec.Completions != nil {
+		completions = *r.Spec.Completions
+	}
+	if r.Status.Succeeded >= completions {
+		return types.StateReady
+	}
+
+	// the job has not failed yet, but pods are failing and being retried
+	if r.Status.Failed > 0 {
+		return types.StateDegraded
+	}
+	if r.Status.Active > 0 && hasFailingJobPods(podLister, r) {
+		return types.StateDegraded
+	}
+
+	return types.StateUpdating
+}
+
+func hasFailingJobPods(podLister corelisters.PodLister, r *batchv1.Job) bool {
+	if r.Spec.Selector == nil {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(r.Spec.Selector)
+	if err != nil {
+		log.Printf("failed to parse job selector: %s", err)
+		return false
+	}
+
+	pods, err := podLister.Pods(r.Namespace).List(selector)
+	if err != nil {
+		log.Printf("failed to get job pod list: %s", err)
+		return false
+	}
+
+	for _, pod := range pods {
+		if isPodFailing(pod) {
+			return true
+		}
+	}
+	return false
+}
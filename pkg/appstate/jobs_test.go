@@ -0,0 +1,180 @@
+package appstate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func mockJobPod(name string, jobName string, waitingReason string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{"job-name": jobName},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Ready: true}},
+		},
+	}
+	if waitingReason != "" {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}}},
+		}
+	}
+	return pod
+}
+
+// testJobListers returns listers backed by informers that have synced the objects in clientset
+func testJobListers(t *testing.T, clientset kubernetes.Interface) (batchlisters.JobLister, corelisters.PodLister) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	jobLister := factory.Batch().V1().Jobs().Lister()
+	podLister := factory.Core().V1().Pods().Lister()
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	return jobLister, podLister
+}
+
+func mockJob(name string, status batchv1.JobStatus) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: batchv1.JobSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"job-name": name},
+			},
+		},
+		Status: status,
+	}
+}
+
+func TestCalculateJobState(t *testing.T) {
+	completions := int32(3)
+
+	type args struct {
+		clientset kubernetes.Interface
+		r         *batchv1.Job
+	}
+	tests := []struct {
+		name string
+		args args
+		want types.State
+	}{
+		{
+			name: "expect ready state when job is complete",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r: mockJob("migrate", batchv1.JobStatus{
+					Succeeded: 1,
+					Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+					},
+				}),
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect unavailable state when job failed",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r: mockJob("migrate", batchv1.JobStatus{
+					Failed: 6,
+					Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+					},
+				}),
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect updating state when job is active",
+			args: args{
+				clientset: fake.NewSimpleClientset(mockJobPod("migrate-abcde", "migrate", "")),
+				r:         mockJob("migrate", batchv1.JobStatus{Active: 1}),
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect degraded state when job is active and has failed pods",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r:         mockJob("migrate", batchv1.JobStatus{Active: 1, Failed: 2}),
+			},
+			want: types.StateDegraded,
+		},
+		{
+			name: "expect degraded state when job pod is crash looping",
+			args: args{
+				clientset: fake.NewSimpleClientset(mockJobPod("migrate-abcde", "migrate", "CrashLoopBackOff")),
+				r:         mockJob("migrate", batchv1.JobStatus{Active: 1}),
+			},
+			want: types.StateDegraded,
+		},
+		{
+			name: "expect updating state when pod of another job is crash looping",
+			args: args{
+				clientset: fake.NewSimpleClientset(mockJobPod("other-abcde", "other", "CrashLoopBackOff")),
+				r:         mockJob("migrate", batchv1.JobStatus{Active: 1}),
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect updating state when job has not completed all completions",
+			args: args{
+				clientset: fake.NewSimpleClientset(),
+				r: func() *batchv1.Job {
+					job := mockJob("migrate", batchv1.JobStatus{Succeeded: 2})
+					job.Spec.Completions = &completions
+					return job
+				}(),
+			},
+			want: types.StateUpdating,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, podLister := testJobListers(t, tt.args.clientset)
+			if got := CalculateJobState(podLister, tt.args.r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateJobState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobPodEventHandler(t *testing.T) {
+	jobStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	job := mockJob("migrate", batchv1.JobStatus{Active: 1})
+	if err := jobStore.Add(job); err != nil {
+		t.Fatal(err)
+	}
+
+	var changed []string
+	handler := jobPodEventHandler(jobStore, func(job *batchv1.Job) {
+		changed = append(changed, job.Name)
+	})
+
+	handler.OnAdd(mockJobPod("migrate-abcde", "migrate", ""), false)
+	handler.OnUpdate(nil, mockJobPod("migrate-abcde", "migrate", "CrashLoopBackOff"))
+	handler.OnUpdate(nil, mockJobPod("other-abcde", "other", "CrashLoopBackOff"))
+
+	if want := []string{"migrate", "migrate"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("jobPodEventHandler() changed = %v, want %v", changed, want)
+	}
+}
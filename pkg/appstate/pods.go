@@ -0,0 +1,174 @@
+package appstate
+
+import (
+	"context"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	PodResourceKind = "pod"
+)
+
+// podFailingWaitingReasons are container waiting reasons that will not resolve without intervention
+var podFailingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+func init() {
+	registerResourceKindNames(PodResourceKind, "pods", "po")
+}
+
+func runPodController(
+	ctx context.Context, clientset kubernetes.Interface, targetNamespace string,
+	informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+) {
+	listwatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.CoreV1().Pods(targetNamespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.CoreV1().Pods(targetNamespace).Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedInformer(
+		listwatch,
+		&corev1.Pod{},
+		time.Minute,
+	)
+
+	eventHandler := NewPodEventHandler(
+		filterStatusInformersByResourceKind(informers, PodResourceKind),
+		resourceStateCh,
+	)
+
+	runInformer(ctx, informer, eventHandler)
+	return
+}
+
+type podEventHandler struct {
+	informers       []types.StatusInformer
+	resourceStateCh chan<- types.ResourceState
+}
+
+func NewPodEventHandler(informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState) *podEventHandler {
+	return &podEventHandler{
+		informers:       informers,
+		resourceStateCh: resourceStateCh,
+	}
+}
+
+func (h *podEventHandler) ObjectCreated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makePodResourceState(r, CalculatePodState(r))
+}
+
+func (h *podEventHandler) ObjectUpdated(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makePodResourceState(r, CalculatePodState(r))
+}
+
+func (h *podEventHandler) ObjectDeleted(obj interface{}) {
+	r := h.cast(obj)
+	if _, ok := h.getInformer(r); !ok {
+		return
+	}
+	h.resourceStateCh <- makePodResourceState(r, types.StateMissing)
+}
+
+func (h *podEventHandler) cast(obj interface{}) *corev1.Pod {
+	r, _ := obj.(*corev1.Pod)
+	return r
+}
+
+func (h *podEventHandler) getInformer(r *corev1.Pod) (types.StatusInformer, bool) {
+	if r != nil {
+		for _, informer := range h.informers {
+			if r.Namespace == informer.Namespace && r.Name == informer.Name {
+				return informer, true
+			}
+		}
+	}
+	return types.StatusInformer{}, false
+}
+
+func makePodResourceState(r *corev1.Pod, state types.State) types.ResourceState {
+	return types.ResourceState{
+		Kind:      PodResourceKind,
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		State:     state,
+	}
+}
+
+// CalculatePodState maps completed pods to ready and failed pods to unavailable. Running pods are ready
+// when the Ready condition is true and degraded when only some of their containers are ready.
+func CalculatePodState(r *corev1.Pod) types.State {
+	switch r.Status.Phase {
+	case corev1.PodSucceeded:
+		return types.StateReady
+	case corev1.PodFailed, corev1.PodUnknown:
+		return types.StateUnavailable
+	}
+
+	if isPodFailing(r) {
+		if countReadyContainers(r) > 0 {
+			return types.StateDegraded
+		}
+		return types.StateUnavailable
+	}
+
+	if r.Status.Phase == corev1.PodPending {
+		return types.StateUpdating
+	}
+
+	for _, condition := range r.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return types.StateReady
+		}
+	}
+	if countReadyContainers(r) > 0 {
+		return types.StateDegraded
+	}
+	return types.StateUpdating
+}
+
+// isPodFailing returns true if any container of the pod is waiting for a reason that will not resolve by itself
+func isPodFailing(r *corev1.Pod) bool {
+	statuses := append([]corev1.ContainerStatus{}, r.Status.InitContainerStatuses...)
+	statuses = append(statuses, r.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && podFailingWaitingReasons[status.State.Waiting.Reason] {
+			return true
+		}
+	}
+	return false
+}
+
+func countReadyContainers(r *corev1.Pod) int {
+	ready := 0
+	for _, status := range r.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+	}
+	return ready
+}
@@ -0,0 +1,142 @@
+package appstate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCalculatePodState(t *testing.T) {
+	type args struct {
+		r *corev1.Pod
+	}
+	tests := []struct {
+		name string
+		args args
+		want types.State
+	}{
+		{
+			name: "expect ready state when pod succeeded",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+				},
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect unavailable state when pod failed",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{Phase: corev1.PodFailed},
+				},
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect updating state when pod is pending",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
+				},
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect unavailable state when pod image cannot be pulled",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+						},
+					},
+				},
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect ready state when pod is running and ready",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						Conditions: []corev1.PodCondition{
+							{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+						},
+						ContainerStatuses: []corev1.ContainerStatus{{Ready: true}},
+					},
+				},
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "expect updating state when pod is running and not ready",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						Conditions: []corev1.PodCondition{
+							{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+						},
+						ContainerStatuses: []corev1.ContainerStatus{{Ready: false}},
+					},
+				},
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "expect unavailable state when pod is crash looping",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+			want: types.StateUnavailable,
+		},
+		{
+			name: "expect degraded state when one container of the pod is crash looping",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{Ready: true},
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+			want: types.StateDegraded,
+		},
+		{
+			name: "expect unavailable state when init container is crash looping",
+			args: args{
+				r: &corev1.Pod{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						InitContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+			want: types.StateUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculatePodState(tt.args.r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculatePodState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
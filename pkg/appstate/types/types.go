@@ -3,6 +3,7 @@ package types
 import (
 	"errors"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -16,16 +17,23 @@ var (
 	StatusInformerRegexp = regexp.MustCompile(`^(?:([^\/]+)\/)?([^\/]+)\/([^\/]+)$`)
 )
 
+// StatusInformerString has the format [namespace/]kind/name[;readyExpression].
+// Custom resources are referenced by a group qualified kind such as "certificates.cert-manager.io".
+// The optional ready expression overrides how readiness of a custom resource is evaluated.
 type StatusInformerString string
 
 type StatusInformer struct {
 	Kind      string
 	Name      string
 	Namespace string
+	// ReadyExpression is a JSONPath expression, optionally compared to a value with == or !=,
+	// for example "{.status.phase}==Running". Only used for custom resources.
+	ReadyExpression string
 }
 
 func (s StatusInformerString) Parse() (i StatusInformer, err error) {
-	matches := StatusInformerRegexp.FindStringSubmatch(string(s))
+	str, readyExpression, _ := strings.Cut(string(s), ";")
+	matches := StatusInformerRegexp.FindStringSubmatch(str)
 	if len(matches) != 4 {
 		err = errors.New("status informer format string incorrect")
 		return
@@ -33,6 +41,7 @@ func (s StatusInformerString) Parse() (i StatusInformer, err error) {
 	i.Namespace = matches[1]
 	i.Kind = matches[2]
 	i.Name = matches[3]
+	i.ReadyExpression = strings.TrimSpace(readyExpression)
 	return
 }
 
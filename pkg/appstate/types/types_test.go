@@ -29,6 +29,34 @@ func TestStatusInformerString_Parse(t *testing.T) {
 				Name:      "sentry-web",
 			},
 		},
+		{
+			name: "custom resource",
+			str:  "default/certificates.cert-manager.io/my-cert",
+			want: StatusInformer{
+				Namespace: "default",
+				Kind:      "certificates.cert-manager.io",
+				Name:      "my-cert",
+			},
+		},
+		{
+			name: "custom resource with ready expression",
+			str:  "databases.example.com/my-db;{.status.phase}==Running",
+			want: StatusInformer{
+				Kind:            "databases.example.com",
+				Name:            "my-db",
+				ReadyExpression: "{.status.phase}==Running",
+			},
+		},
+		{
+			name: "ready expression containing slashes",
+			str:  "default/databases.example.com/my-db; {.status.endpoint}!=http://pending/",
+			want: StatusInformer{
+				Namespace:       "default",
+				Kind:            "databases.example.com",
+				Name:            "my-db",
+				ReadyExpression: "{.status.endpoint}!=http://pending/",
+			},
+		},
 		{
 			name:    "no match",
 			str:     "sentry-web",
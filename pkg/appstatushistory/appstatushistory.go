@@ -0,0 +1,159 @@
+package appstatushistory
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// Retention is how long status history is kept, and the longest window availability can be computed for
+	Retention = 90 * 24 * time.Hour
+
+	// pruneHistoryCronSpec - daily cron spec for the history pruning job
+	pruneHistoryCronSpec = "30 2 * * *"
+)
+
+// StartPruneCronJob starts the job that deletes status history older than the retention period
+func StartPruneCronJob() error {
+	logger.Debug("starting app status history pruning cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(pruneHistoryCronSpec, func() {
+		logger.Debug("running app status history pruning job")
+		if err := store.GetStore().DeleteAppStatusHistoryBefore(time.Now().Add(-Retention)); err != nil {
+			logger.Error(errors.Wrap(err, "failed to prune app status history"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// HasChanged returns true if the state of any resource differs between the two sets of resource states
+func HasChanged(prev appstatetypes.ResourceStates, next appstatetypes.ResourceStates) bool {
+	if len(prev) != len(next) {
+		return true
+	}
+
+	prevStates := map[string]appstatetypes.State{}
+	for _, r := range prev {
+		prevStates[resourceKey(r)] = r.State
+	}
+	for _, r := range next {
+		state, ok := prevStates[resourceKey(r)]
+		if !ok || state != r.State {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceKey(r appstatetypes.ResourceState) string {
+	return strings.Join([]string{r.Namespace, r.Kind, r.Name}, "/")
+}
+
+// GetHistory returns the status transitions of the app during the window ending now, along with its availability
+func GetHistory(appID string, window time.Duration, now time.Time) (*types.History, error) {
+	start := now.Add(-window)
+
+	previous, err := store.GetStore().GetLatestAppStatusHistoryEntryBefore(appID, start)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get status before window")
+	}
+
+	entries, err := store.GetStore().ListAppStatusHistory(appID, start, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list status history")
+	}
+
+	return &types.History{
+		Entries:      entries,
+		Availability: ComputeAvailability(previous, entries, start, now),
+	}, nil
+}
+
+// ComputeAvailability sums the time spent in each state between start and end. previous is the last
+// entry before start, if any. Time before the first known state is not counted as observed.
+// entries must be sorted oldest first.
+func ComputeAvailability(previous *types.Entry, entries []types.Entry, start time.Time, end time.Time) types.Availability {
+	availability := types.Availability{
+		Start:        start,
+		End:          end,
+		StateSeconds: map[appstatetypes.State]int64{},
+	}
+
+	var currentState appstatetypes.State
+	currentSince := start
+	if previous != nil {
+		currentState = previous.State
+	}
+
+	addSegment := func(until time.Time) {
+		if currentState == "" || !until.After(currentSince) {
+			return
+		}
+		seconds := int64(until.Sub(currentSince).Seconds())
+		availability.StateSeconds[currentState] += seconds
+		availability.ObservedSeconds += seconds
+	}
+
+	for _, entry := range entries {
+		if entry.CreatedAt.Before(start) {
+			currentState = entry.State
+			continue
+		}
+		if entry.CreatedAt.After(end) {
+			break
+		}
+		addSegment(entry.CreatedAt)
+		currentState = entry.State
+		currentSince = entry.CreatedAt
+	}
+	addSegment(end)
+
+	if availability.ObservedSeconds > 0 {
+		ready := availability.StateSeconds[appstatetypes.StateReady]
+		availability.Percent = float64(ready) * 100 / float64(availability.ObservedSeconds)
+	}
+
+	return availability
+}
+
+// ParseWindow parses a duration that may also be given in days, such as "7d"
+func ParseWindow(s string) (time.Duration, error) {
+	var window time.Duration
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.Errorf("invalid window %q", s)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, errors.Errorf("invalid window %q", s)
+		}
+		window = d
+	}
+
+	if window <= 0 {
+		return 0, errors.New("window must be positive")
+	}
+	if window > Retention {
+		return 0, errors.Errorf("window cannot be longer than %d days", int(Retention.Hours()/24))
+	}
+	return window, nil
+}
@@ -0,0 +1,188 @@
+package appstatushistory
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/appstatushistory/types"
+)
+
+func TestHasChanged(t *testing.T) {
+	web := appstatetypes.ResourceState{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateReady}
+	db := appstatetypes.ResourceState{Kind: "statefulset", Name: "db", Namespace: "default", State: appstatetypes.StateReady}
+	degradedWeb := web
+	degradedWeb.State = appstatetypes.StateDegraded
+
+	tests := []struct {
+		name string
+		prev appstatetypes.ResourceStates
+		next appstatetypes.ResourceStates
+		want bool
+	}{
+		{
+			name: "same states in a different order",
+			prev: appstatetypes.ResourceStates{web, db},
+			next: appstatetypes.ResourceStates{db, web},
+			want: false,
+		},
+		{
+			name: "resource state changed",
+			prev: appstatetypes.ResourceStates{web, db},
+			next: appstatetypes.ResourceStates{degradedWeb, db},
+			want: true,
+		},
+		{
+			name: "resource added",
+			prev: appstatetypes.ResourceStates{web},
+			next: appstatetypes.ResourceStates{web, db},
+			want: true,
+		},
+		{
+			name: "resource replaced",
+			prev: appstatetypes.ResourceStates{web},
+			next: appstatetypes.ResourceStates{db},
+			want: true,
+		},
+		{
+			name: "no resources",
+			prev: nil,
+			next: appstatetypes.ResourceStates{},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasChanged(tt.prev, tt.next); got != tt.want {
+				t.Errorf("HasChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeAvailability(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+
+	tests := []struct {
+		name     string
+		previous *types.Entry
+		entries  []types.Entry
+		want     types.Availability
+	}{
+		{
+			name: "no history",
+			want: types.Availability{
+				Start:        start,
+				End:          end,
+				StateSeconds: map[appstatetypes.State]int64{},
+			},
+		},
+		{
+			name:     "ready for the whole window",
+			previous: &types.Entry{State: appstatetypes.StateReady, CreatedAt: at(-5)},
+			want: types.Availability{
+				Start:           start,
+				End:             end,
+				ObservedSeconds: 36000,
+				Percent:         100,
+				StateSeconds: map[appstatetypes.State]int64{
+					appstatetypes.StateReady: 36000,
+				},
+			},
+		},
+		{
+			name:     "degraded in the middle of the window",
+			previous: &types.Entry{State: appstatetypes.StateReady, CreatedAt: at(-5)},
+			entries: []types.Entry{
+				{State: appstatetypes.StateDegraded, CreatedAt: at(4)},
+				{State: appstatetypes.StateReady, CreatedAt: at(5)},
+			},
+			want: types.Availability{
+				Start:           start,
+				End:             end,
+				ObservedSeconds: 36000,
+				Percent:         90,
+				StateSeconds: map[appstatetypes.State]int64{
+					appstatetypes.StateReady:    32400,
+					appstatetypes.StateDegraded: 3600,
+				},
+			},
+		},
+		{
+			name: "first status recorded during the window",
+			entries: []types.Entry{
+				{State: appstatetypes.StateUpdating, CreatedAt: at(2)},
+				{State: appstatetypes.StateReady, CreatedAt: at(4)},
+			},
+			want: types.Availability{
+				Start:           start,
+				End:             end,
+				ObservedSeconds: 28800,
+				Percent:         75,
+				StateSeconds: map[appstatetypes.State]int64{
+					appstatetypes.StateUpdating: 7200,
+					appstatetypes.StateReady:    21600,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeAvailability(tt.previous, tt.entries, start, end)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputeAvailability() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "days",
+			window: "7d",
+			want:   7 * 24 * time.Hour,
+		},
+		{
+			name:   "duration",
+			window: "12h",
+			want:   12 * time.Hour,
+		},
+		{
+			name:    "longer than retention",
+			window:  "91d",
+			wantErr: true,
+		},
+		{
+			name:    "negative",
+			window:  "-1h",
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			window:  "a week",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWindow(tt.window)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"time"
+
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+)
+
+// Entry is a transition of the app status. The app stays in this state until the next entry.
+type Entry struct {
+	ID             string                       `json:"id"`
+	AppID          string                       `json:"appId"`
+	State          appstatetypes.State          `json:"state"`
+	ResourceStates appstatetypes.ResourceStates `json:"resourceStates"`
+	Sequence       int64                        `json:"sequence"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+}
+
+type Availability struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// ObservedSeconds is the part of the window for which the app status is known
+	ObservedSeconds int64 `json:"observedSeconds"`
+	// Percent is the percentage of the observed time the app was ready
+	Percent float64 `json:"percent"`
+	// StateSeconds is the time spent in each state during the window
+	StateSeconds map[appstatetypes.State]int64 `json:"stateSeconds"`
+}
+
+type History struct {
+	Entries      []Entry      `json:"entries"`
+	Availability Availability `json:"availability"`
+}
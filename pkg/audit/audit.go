@@ -0,0 +1,410 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/audit/types"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// maxCapturedBodySize is the number of bytes of the request body that are read to build the summary.
+	// Larger bodies (uploads, archives) are not summarized.
+	maxCapturedBodySize = 64 * 1024
+
+	maxSummaryValueLength = 64
+	maxSummaryLength      = 1024
+	maxSummaryDepth       = 8
+
+	redactedValue = "[REDACTED]"
+	unsetValue    = "<unset>"
+)
+
+// sensitiveKeySegments are the words that mark a key as sensitive. Keys are split into words so that
+// keys such as "author" or "keyword" are not redacted.
+var sensitiveKeySegments = map[string]bool{
+	"password":      true,
+	"passwords":     true,
+	"passwd":        true,
+	"passphrase":    true,
+	"secret":        true,
+	"secrets":       true,
+	"token":         true,
+	"tokens":        true,
+	"key":           true,
+	"keys":          true,
+	"apikey":        true,
+	"accesskey":     true,
+	"secretkey":     true,
+	"privatekey":    true,
+	"license":       true,
+	"cert":          true,
+	"certs":         true,
+	"certificate":   true,
+	"certificates":  true,
+	"credential":    true,
+	"credentials":   true,
+	"auth":          true,
+	"authorization": true,
+	"private":       true,
+}
+
+// StatusRecorder records the status code written by the handler
+type StatusRecorder struct {
+	http.ResponseWriter
+	StatusCode int
+}
+
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+}
+
+func (s *StatusRecorder) WriteHeader(code int) {
+	s.StatusCode = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *StatusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CaptureRequestBody returns up to maxCapturedBodySize bytes of a json request body without consuming it,
+// so that the handler can still read the full body. It returns nil for other content types.
+func CaptureRequestBody(r *http.Request) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && mediaType != "" {
+		return nil
+	}
+
+	captured, err := ioutil.ReadAll(io.LimitReader(r.Body, maxCapturedBodySize+1))
+	r.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(captured), r.Body),
+		Closer: r.Body,
+	}
+	if err != nil || len(captured) > maxCapturedBodySize {
+		return nil
+	}
+
+	return captured
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Summarize describes the fields of a json request body, including nested fields. Values of fields that look
+// sensitive are redacted and long values are truncated.
+func Summarize(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	leaves := map[string]leaf{}
+	flatten("", fields, false, 0, leaves)
+
+	parts := []string{}
+	for _, path := range sortedPaths(leaves) {
+		parts = append(parts, fmt.Sprintf("%s=%s", path, leaves[path].summary))
+	}
+
+	return truncateSummary(strings.Join(parts, " "))
+}
+
+// Diff describes the fields that changed between two values, including nested fields. Values of fields
+// that look sensitive are redacted, they are only reported as changed.
+func Diff(before interface{}, after interface{}) string {
+	beforeLeaves, err := flattenValue(before)
+	if err != nil {
+		return ""
+	}
+	afterLeaves, err := flattenValue(after)
+	if err != nil {
+		return ""
+	}
+
+	all := map[string]leaf{}
+	for path, l := range beforeLeaves {
+		all[path] = l
+	}
+	for path, l := range afterLeaves {
+		all[path] = l
+	}
+
+	parts := []string{}
+	for _, path := range sortedPaths(all) {
+		b, inBefore := beforeLeaves[path]
+		a, inAfter := afterLeaves[path]
+		if inBefore && inAfter && b.raw == a.raw {
+			continue
+		}
+
+		beforeSummary, afterSummary := unsetValue, unsetValue
+		if inBefore {
+			beforeSummary = b.summary
+		}
+		if inAfter {
+			afterSummary = a.summary
+		}
+		if path == "" {
+			path = "value"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s -> %s", path, beforeSummary, afterSummary))
+	}
+
+	return truncateSummary(strings.Join(parts, " "))
+}
+
+// leaf is a value that is not descended into when flattening
+type leaf struct {
+	// raw is the json of the value, so that changes to redacted values can be detected
+	raw     string
+	summary string
+}
+
+func flattenValue(value interface{}) (map[string]leaf, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	leaves := map[string]leaf{}
+	if v == nil {
+		// nothing existed before, or nothing exists after
+		return leaves, nil
+	}
+	flatten("", v, false, 0, leaves)
+	return leaves, nil
+}
+
+// flatten adds the values nested in the value to leaves, keyed by their path. Sensitive fields and
+// fields deeper than maxSummaryDepth are added as a single leaf.
+func flatten(path string, value interface{}, isSensitive bool, depth int, leaves map[string]leaf) {
+	if isSensitive || depth >= maxSummaryDepth {
+		leaves[path] = newLeaf(value, isSensitive)
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			leaves[path] = newLeaf(value, false)
+			return
+		}
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flatten(childPath, child, isSensitiveKey(key), depth+1, leaves)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			leaves[path] = newLeaf(value, false)
+			return
+		}
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, i), child, false, depth+1, leaves)
+		}
+	default:
+		leaves[path] = newLeaf(value, false)
+	}
+}
+
+// isSensitiveKey returns true if any word of the key is sensitive
+func isSensitiveKey(key string) bool {
+	for _, segment := range keySegments(key) {
+		if sensitiveKeySegments[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+// keySegments splits a key into lowercase words on "_", "-", "." and camelCase boundaries, e.g. "TLSCertFile" is
+// split into "tls", "cert" and "file"
+func keySegments(key string) []string {
+	runes := []rune(key)
+	segments := []string{}
+	start := 0
+	addSegment := func(end int) {
+		if end > start {
+			segments = append(segments, strings.ToLower(string(runes[start:end])))
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			addSegment(i)
+			start = i + 1
+		case unicode.IsUpper(r) && i > start:
+			// a new word starts at an upper case letter, unless it is part of an acronym
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(runes[i-1]) || nextIsLower {
+				addSegment(i)
+				start = i
+			}
+		}
+	}
+	addSegment(len(runes))
+
+	return segments
+}
+
+func newLeaf(value interface{}, isSensitive bool) leaf {
+	raw, _ := json.Marshal(value)
+	if isSensitive {
+		return leaf{raw: string(raw), summary: redactedValue}
+	}
+	return leaf{raw: string(raw), summary: summarizeValue(value)}
+}
+
+func summarizeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return fmt.Sprintf("{%d fields}", len(v))
+	case []interface{}:
+		return fmt.Sprintf("[%d items]", len(v))
+	case string:
+		if len(v) > maxSummaryValueLength {
+			v = v[:maxSummaryValueLength] + "..."
+		}
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func sortedPaths(leaves map[string]leaf) []string {
+	paths := make([]string, 0, len(leaves))
+	for path := range leaves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func truncateSummary(summary string) string {
+	if len(summary) > maxSummaryLength {
+		return summary[:maxSummaryLength] + "..."
+	}
+	return summary
+}
+
+type detailsKey struct{}
+
+// Details are added to the audit event of a request by its handler
+type Details struct {
+	// Session is the session that was created or ended by a request that is not made with a session
+	Session *sessiontypes.Session
+
+	hasChange bool
+	before    interface{}
+	after     interface{}
+}
+
+// WithDetails returns a copy of the request that handlers can add details to with RecordChange and RecordSession
+func WithDetails(r *http.Request) (*http.Request, *Details) {
+	details := &Details{}
+	return r.WithContext(context.WithValue(r.Context(), detailsKey{}, details)), details
+}
+
+// RecordChange describes the request by the difference between the state before and after it was handled,
+// instead of by its body. It does nothing if the request is not audited.
+func RecordChange(r *http.Request, before interface{}, after interface{}) {
+	if details, ok := r.Context().Value(detailsKey{}).(*Details); ok {
+		details.hasChange = true
+		details.before = before
+		details.after = after
+	}
+}
+
+// RecordSession adds the session that was created or ended by the request to its audit event
+func RecordSession(r *http.Request, sess *sessiontypes.Session) {
+	if details, ok := r.Context().Value(detailsKey{}).(*Details); ok {
+		details.Session = sess
+	}
+}
+
+// Summary returns the change recorded by the handler, or the summary of the request body if there is none
+func (d *Details) Summary(bodySummary string) string {
+	if !d.hasChange {
+		return bodySummary
+	}
+	return Diff(d.before, d.after)
+}
+
+// NewEvent builds the audit event for a request that has been handled
+func NewEvent(r *http.Request, sess *sessiontypes.Session, resource string, statusCode int, summary string) types.Event {
+	event := types.Event{
+		ID:         ksuid.New().String(),
+		CreatedAt:  time.Now(),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Resource:   resource,
+		StatusCode: statusCode,
+		Outcome:    OutcomeFromStatus(statusCode),
+		Summary:    summary,
+	}
+
+	if route := mux.CurrentRoute(r); route != nil {
+		event.Route = route.GetName()
+	}
+
+	if sess != nil {
+		event.SessionID = sess.ID
+		event.UserID = sess.UserID
+		event.Username = sess.Username
+	}
+
+	vars := mux.Vars(r)
+	event.AppSlug = vars["appSlug"]
+	if sequence, err := strconv.ParseInt(vars["sequence"], 10, 64); err == nil {
+		event.Sequence = &sequence
+	}
+
+	return event
+}
+
+func OutcomeFromStatus(statusCode int) types.Outcome {
+	switch {
+	case statusCode == http.StatusForbidden:
+		return types.OutcomeDenied
+	case statusCode >= http.StatusBadRequest:
+		return types.OutcomeFailure
+	default:
+		return types.OutcomeSuccess
+	}
+}
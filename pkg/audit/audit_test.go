@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/audit/types"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "empty body",
+			body: "",
+			want: "",
+		},
+		{
+			name: "not json",
+			body: "not json",
+			want: "",
+		},
+		{
+			name: "sensitive values are redacted",
+			body: `{"username":"jdoe","password":"hunter22","roles":["support"]}`,
+			want: `password=[REDACTED] roles[0]="support" username="jdoe"`,
+		},
+		{
+			name: "nested values are described",
+			body: `{"configGroups":[{"name":"a"},{"name":"b"}],"gitOpsInput":{"uri":"x","branch":"main"},"isPending":true,"sequence":3,"note":null,"tags":[],"labels":{}}`,
+			want: `configGroups[0].name="a" configGroups[1].name="b" gitOpsInput.branch="main" gitOpsInput.uri="x" isPending=true labels={0 fields} note=null sequence=3 tags=[0 items]`,
+		},
+		{
+			name: "nested sensitive values are redacted",
+			body: `{"gitOpsInput":{"uri":"x","auth":{"username":"jdoe","password":"hunter22"}},"items":[{"name":"a","value":"b","privateKey":"c"}]}`,
+			want: `gitOpsInput.auth=[REDACTED] gitOpsInput.uri="x" items[0].name="a" items[0].privateKey=[REDACTED] items[0].value="b"`,
+		},
+		{
+			name: "long values are truncated",
+			body: `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			want: `name="` + strings.Repeat("a", maxSummaryValueLength) + `..."`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Summarize([]byte(tt.body)))
+		})
+	}
+}
+
+func Test_isSensitiveKey(t *testing.T) {
+	sensitive := []string{
+		"password",
+		"newPassword",
+		"password_bcrypt",
+		"apiToken",
+		"privateKey",
+		"private-key",
+		"APIKey",
+		"apikey",
+		"TLSCertFile",
+		"tls.cert",
+		"licenseData",
+		"auth",
+		"Authorization",
+		"credentials",
+		"clientSecret",
+	}
+	for _, key := range sensitive {
+		assert.True(t, isSensitiveKey(key), key)
+	}
+
+	notSensitive := []string{
+		"author",
+		"authorName",
+		"keyword",
+		"keywords",
+		"monkey",
+		"certainty",
+		"tokenizer",
+		"secretary",
+		"privateer",
+		"username",
+		"name",
+	}
+	for _, key := range notSensitive {
+		assert.False(t, isSensitiveKey(key), key)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type auth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	type settings struct {
+		Name    string            `json:"name"`
+		Enabled bool              `json:"enabled"`
+		Roles   []string          `json:"roles,omitempty"`
+		Labels  map[string]string `json:"labels,omitempty"`
+		Auth    *auth             `json:"auth,omitempty"`
+	}
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   string
+	}{
+		{
+			name:   "no change",
+			before: settings{Name: "a", Roles: []string{"admin"}},
+			after:  settings{Name: "a", Roles: []string{"admin"}},
+			want:   "",
+		},
+		{
+			name:   "changed fields are described",
+			before: settings{Name: "a", Roles: []string{"admin"}},
+			after:  settings{Name: "b", Enabled: true, Roles: []string{"admin", "support"}},
+			want:   `enabled: false -> true name: "a" -> "b" roles[1]: <unset> -> "support"`,
+		},
+		{
+			name:   "nested fields are described",
+			before: settings{Labels: map[string]string{"team": "a", "env": "dev"}},
+			after:  settings{Labels: map[string]string{"team": "b"}},
+			want:   `labels.env: "dev" -> <unset> labels.team: "a" -> "b"`,
+		},
+		{
+			name:   "sensitive changes are redacted",
+			before: settings{Auth: &auth{Username: "jdoe", Password: "hunter22"}},
+			after:  settings{Auth: &auth{Username: "jdoe", Password: "hunter23"}},
+			want:   `auth: [REDACTED] -> [REDACTED]`,
+		},
+		{
+			name:   "sensitive values that did not change are omitted",
+			before: settings{Name: "a", Auth: &auth{Password: "hunter22"}},
+			after:  settings{Name: "b", Auth: &auth{Password: "hunter22"}},
+			want:   `name: "a" -> "b"`,
+		},
+		{
+			name:   "nil before",
+			before: nil,
+			after:  &settings{Name: "a"},
+			want:   `enabled: <unset> -> false name: <unset> -> "a"`,
+		},
+		{
+			name:   "scalar values",
+			before: 1,
+			after:  2,
+			want:   `value: 1 -> 2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Diff(tt.before, tt.after))
+		})
+	}
+}
+
+func TestDetails(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/api/v1/app/my-app/soak", nil)
+
+	// recording on a request that is not audited does nothing
+	RecordChange(req, "a", "b")
+	RecordSession(req, &sessiontypes.Session{ID: "sess"})
+
+	req, details := WithDetails(req)
+	assert.Equal(t, "body", details.Summary("body"))
+
+	RecordChange(req, map[string]int{"days": 1}, map[string]int{"days": 2})
+	assert.Equal(t, "days: 1 -> 2", details.Summary("body"))
+
+	RecordSession(req, &sessiontypes.Session{ID: "sess"})
+	require.NotNil(t, details.Session)
+	assert.Equal(t, "sess", details.Session.ID)
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	body := `{"key":"value"}`
+
+	req := httptest.NewRequest("POST", "/api/v1/app/my-app/config", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, body, string(CaptureRequestBody(req)))
+
+	// the handler must still be able to read the entire body
+	remaining, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(remaining))
+
+	req = httptest.NewRequest("POST", "/api/v1/upload", strings.NewReader(body))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	assert.Nil(t, CaptureRequestBody(req))
+
+	large := `{"data":"` + strings.Repeat("a", maxCapturedBodySize) + `"}`
+	req = httptest.NewRequest("POST", "/api/v1/app/my-app/config", strings.NewReader(large))
+	assert.Nil(t, CaptureRequestBody(req))
+	remaining, err = ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(remaining))
+}
+
+func TestNewEvent(t *testing.T) {
+	r := mux.NewRouter()
+
+	var got types.Event
+	r.Name("DeployAppVersion").Path("/api/v1/app/{appSlug}/sequence/{sequence}/deploy").Methods("POST").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			sess := &sessiontypes.Session{ID: "session-id", UserID: "user-id", Username: "jdoe"}
+			got = NewEvent(req, sess, "app.my-app.downstream.", http.StatusNoContent, "isSkipPreflights=false")
+		})
+
+	req := httptest.NewRequest("POST", "/api/v1/app/my-app/sequence/4/deploy", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, got.Sequence)
+	assert.Equal(t, int64(4), *got.Sequence)
+	assert.NotEmpty(t, got.ID)
+	assert.Equal(t, "DeployAppVersion", got.Route)
+	assert.Equal(t, "my-app", got.AppSlug)
+	assert.Equal(t, "jdoe", got.Username)
+	assert.Equal(t, "user-id", got.UserID)
+	assert.Equal(t, "session-id", got.SessionID)
+	assert.Equal(t, "app.my-app.downstream.", got.Resource)
+	assert.Equal(t, types.OutcomeSuccess, got.Outcome)
+	assert.Equal(t, "isSkipPreflights=false", got.Summary)
+}
+
+func TestOutcomeFromStatus(t *testing.T) {
+	assert.Equal(t, types.OutcomeSuccess, OutcomeFromStatus(http.StatusOK))
+	assert.Equal(t, types.OutcomeDenied, OutcomeFromStatus(http.StatusForbidden))
+	assert.Equal(t, types.OutcomeFailure, OutcomeFromStatus(http.StatusBadRequest))
+	assert.Equal(t, types.OutcomeFailure, OutcomeFromStatus(http.StatusInternalServerError))
+}
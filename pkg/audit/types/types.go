@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Event is a single entry in the audit log. Events are never updated or deleted once recorded.
+type Event struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UserID     string    `json:"userId,omitempty"`
+	Username   string    `json:"username,omitempty"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route,omitempty"`
+	Resource   string    `json:"resource,omitempty"`
+	AppSlug    string    `json:"appSlug,omitempty"`
+	Sequence   *int64    `json:"sequence,omitempty"`
+	Outcome    Outcome   `json:"outcome"`
+	StatusCode int       `json:"statusCode"`
+	// Summary describes the change that was requested with sensitive values redacted
+	Summary string `json:"summary,omitempty"`
+}
+
+type ListOptions struct {
+	AppSlug  string
+	Username string
+	Since    *time.Time
+	Until    *time.Time
+	// Limit is the maximum number of events to return, newest first. Zero means no limit.
+	Limit int
+}
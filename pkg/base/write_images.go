@@ -24,11 +24,13 @@ type RewriteImageOptions struct {
 	Log               *logger.CLILogger
 	ReportWriter      io.Writer
 	KotsKinds         *kotsutil.KotsKinds
+	ImagePolicy       *imagetypes.ImagePolicy
 }
 
 type RewriteImagesResult struct {
-	Images        []kustomizeimage.Image          // images to be rewritten
-	CheckedImages []kotsv1beta1.InstallationImage // all images found in the installation
+	Images             []kustomizeimage.Image          // images to be rewritten
+	CheckedImages      []kotsv1beta1.InstallationImage // all images found in the installation
+	ImagePolicyResults []imagetypes.ImagePolicyResult  // images checked against the image policy
 }
 
 func RewriteImages(options RewriteImageOptions) (*RewriteImagesResult, error) {
@@ -44,13 +46,15 @@ func RewriteImages(options RewriteImageOptions) (*RewriteImagesResult, error) {
 		}
 	}
 
-	newImages, err := image.RewriteImages(options.SourceRegistry, options.DestRegistry, options.AppSlug, options.Log, options.ReportWriter, options.BaseDir, additionalImages, options.CopyImages, allImagesPrivate, checkedImages, options.DockerHubRegistry)
+	policyReport := &imagetypes.ImagePolicyReport{}
+	newImages, err := image.RewriteImages(options.SourceRegistry, options.DestRegistry, options.AppSlug, options.Log, options.ReportWriter, options.BaseDir, additionalImages, options.CopyImages, allImagesPrivate, checkedImages, options.DockerHubRegistry, options.ImagePolicy, policyReport)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to save images")
 	}
 
 	return &RewriteImagesResult{
-		Images:        newImages,
-		CheckedImages: makeInstallationImages(checkedImages),
+		Images:             newImages,
+		CheckedImages:      makeInstallationImages(checkedImages),
+		ImagePolicyResults: policyReport.Results(),
 	}, nil
 }
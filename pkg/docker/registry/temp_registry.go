@@ -200,6 +200,39 @@ func (r *TempRegistry) getImageLayers(imageName string, tagOrDigest string) ([]t
 	return layers, nil
 }
 
+// HasBlob returns true if the blob with the given digest is stored in the temp registry for the given image.
+// Delta airgap bundles can omit blobs that are expected to already exist in the destination registry.
+func (r *TempRegistry) HasBlob(image string, digest string) (bool, error) {
+	imageRef, err := reference.ParseDockerRef(image)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to normalize image %s", image)
+	}
+
+	imageParts := strings.Split(reference.TrimNamed(imageRef).Name(), "/") // strip tag and digest
+	imageName := imageParts[len(imageParts)-1]                             // strip hostname and repo if any
+
+	url := fmt.Sprintf("http://localhost:%s/v2/%s/blobs/%s", r.port, imageName, digest)
+	newRequest, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create http request")
+	}
+
+	resp, err := http.DefaultClient.Do(newRequest)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute http request")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
 func (r *TempRegistry) SrcRef(image string) (containerstypes.ImageReference, error) {
 	parsed, err := reference.ParseDockerRef(image)
 	if err != nil {
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/events/types"
+	"github.com/segmentio/ksuid"
+)
+
+// Handler is called for every published event
+type Handler func(event types.Event)
+
+var (
+	subscribersMtx sync.RWMutex
+	subscribers    []Handler
+)
+
+// Subscribe registers a handler that is called for every event published after this call
+func Subscribe(handler Handler) {
+	subscribersMtx.Lock()
+	defer subscribersMtx.Unlock()
+
+	subscribers = append(subscribers, handler)
+}
+
+// Publish sends the event to all subscribers. Handlers run in their own goroutine so that
+// publishing never blocks or fails the code path that emits the event.
+func Publish(event types.Event) {
+	if event.ID == "" {
+		event.ID = ksuid.New().String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	subscribersMtx.RLock()
+	defer subscribersMtx.RUnlock()
+
+	for _, handler := range subscribers {
+		go handler(event)
+	}
+}
+
+// PublishAppEvent publishes an event about an app version. sequence is ignored when negative.
+func PublishAppEvent(eventType types.EventType, appID string, appSlug string, sequence int64, data map[string]interface{}) {
+	event := types.Event{
+		Type:    eventType,
+		AppID:   appID,
+		AppSlug: appSlug,
+		Data:    data,
+	}
+	if sequence >= 0 {
+		event.Sequence = &sequence
+	}
+
+	Publish(event)
+}
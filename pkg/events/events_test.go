@@ -0,0 +1,32 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/events/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublish(t *testing.T) {
+	received := make(chan types.Event, 1)
+	Subscribe(func(event types.Event) {
+		received <- event
+	})
+
+	PublishAppEvent(types.EventDeployFailed, "app-id", "my-app", 3, map[string]interface{}{"error": "boom"})
+
+	select {
+	case event := <-received:
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.CreatedAt.IsZero())
+		assert.Equal(t, types.EventDeployFailed, event.Type)
+		assert.Equal(t, "my-app", event.AppSlug)
+		require.NotNil(t, event.Sequence)
+		assert.Equal(t, int64(3), *event.Sequence)
+		assert.Equal(t, "boom", event.Data["error"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered to the subscriber")
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"time"
+)
+
+type EventType string
+
+const (
+	EventUpdateAvailable    EventType = "update.available"
+	EventPreflightCompleted EventType = "preflight.completed"
+	EventDeploySucceeded    EventType = "deploy.succeeded"
+	EventDeployFailed       EventType = "deploy.failed"
+	EventAppStatusChanged   EventType = "appstatus.changed"
+	EventSnapshotStarted    EventType = "snapshot.started"
+	EventSnapshotCompleted  EventType = "snapshot.completed"
+	EventGitOpsDrift        EventType = "gitops.drift"
+	EventWebhookTest        EventType = "webhook.test"
+)
+
+// EventTypes are the types that can be subscribed to
+var EventTypes = []EventType{
+	EventUpdateAvailable,
+	EventPreflightCompleted,
+	EventDeploySucceeded,
+	EventDeployFailed,
+	EventAppStatusChanged,
+	EventSnapshotStarted,
+	EventSnapshotCompleted,
+	EventGitOpsDrift,
+}
+
+func IsValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      EventType              `json:"type"`
+	CreatedAt time.Time              `json:"createdAt"`
+	AppID     string                 `json:"appId,omitempty"`
+	AppSlug   string                 `json:"appSlug,omitempty"`
+	Sequence  *int64                 `json:"sequence,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
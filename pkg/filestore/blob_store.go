@@ -43,10 +43,10 @@ func (s *BlobStore) WaitForReady(ctx context.Context) error {
 }
 
 func (s *BlobStore) WriteArchive(outputPath string, body io.ReadSeeker) error {
-	return s.writeFile(filepath.Join(ArchivesDir, outputPath), body)
+	return writeFile(filepath.Join(ArchivesDir, outputPath), body)
 }
 
-func (s *BlobStore) writeFile(outputPath string, body io.ReadSeeker) error {
+func writeFile(outputPath string, body io.ReadSeeker) error {
 	parentPath, _ := filepath.Split(outputPath)
 	err := os.MkdirAll(parentPath, 0755)
 	if err != nil {
@@ -68,13 +68,13 @@ func (s *BlobStore) writeFile(outputPath string, body io.ReadSeeker) error {
 }
 
 func (s *BlobStore) ReadArchive(path string) (string, error) {
-	return s.readFile(filepath.Join(ArchivesDir, path))
+	return readFile(filepath.Join(ArchivesDir, path))
 }
 
 // readFile creates a new copy of the file under /tmp and returns the path for it.
 // the caller is responsible for cleaning up.
 // this is so that the original files are not removed by the caller on cleanup by mistake.
-func (s *BlobStore) readFile(path string) (string, error) {
+func readFile(path string) (string, error) {
 	fileReader, err := os.Open(path)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to open file %q", path)
@@ -103,11 +103,15 @@ func (s *BlobStore) readFile(path string) (string, error) {
 	return outputPath, nil
 }
 
+func (s *BlobStore) ListArchives(prefix string) ([]string, error) {
+	return listFiles(ArchivesDir, prefix)
+}
+
 func (s *BlobStore) DeleteArchive(path string) error {
-	return s.deleteFile(filepath.Join(ArchivesDir, path))
+	return deleteFile(filepath.Join(ArchivesDir, path))
 }
 
-func (s *BlobStore) deleteFile(path string) error {
+func deleteFile(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil
 	}
@@ -117,3 +121,41 @@ func (s *BlobStore) deleteFile(path string) error {
 	}
 	return nil
 }
+
+// listFiles returns the paths, relative to rootDir and using forward slashes, of all files under rootDir
+// whose relative path starts with prefix
+func listFiles(rootDir string, prefix string) ([]string, error) {
+	// only walk the deepest directory that can contain matches
+	walkDir := rootDir
+	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
+		walkDir = filepath.Join(rootDir, filepath.FromSlash(prefix[:idx]))
+	}
+
+	paths := []string{}
+	err := filepath.Walk(walkDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get relative path for %q", path)
+		}
+		relPath = filepath.ToSlash(relPath)
+		if strings.HasPrefix(relPath, prefix) {
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk %q", walkDir)
+	}
+
+	return paths, nil
+}
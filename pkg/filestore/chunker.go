@@ -0,0 +1,76 @@
+package filestore
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	minChunkSize = 16 * 1024
+	maxChunkSize = 256 * 1024
+
+	// a boundary is found when the top chunkBoundaryBits bits of the rolling hash are zero,
+	// which gives an average chunk size of about minChunkSize + 64KiB
+	chunkBoundaryBits = 16
+)
+
+// gearTable maps each byte to a pseudo-random value for the rolling gear hash.
+// it must never change, otherwise chunk boundaries (and therefore dedupe) change for existing archives.
+var gearTable = newGearTable(0x6b6f74732d636463)
+
+func newGearTable(seed uint64) [256]uint64 {
+	table := [256]uint64{}
+	for i := range table {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}
+
+// chunker splits a stream into content-defined chunks. boundaries only depend on the bytes
+// right before them, so an insertion or removal only changes the chunks around it.
+type chunker struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{
+		r:   bufio.NewReader(r),
+		buf: make([]byte, 0, maxChunkSize),
+	}
+}
+
+// Next returns the next chunk, or io.EOF when the stream has been consumed.
+// the returned slice is only valid until the next call.
+func (c *chunker) Next() ([]byte, error) {
+	c.buf = c.buf[:0]
+
+	var hash uint64
+	for {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			if len(c.buf) == 0 {
+				return nil, io.EOF
+			}
+			return c.buf, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c.buf = append(c.buf, b)
+		hash = (hash << 1) + gearTable[b]
+
+		if len(c.buf) < minChunkSize {
+			continue
+		}
+		if hash>>(64-chunkBoundaryBits) == 0 || len(c.buf) >= maxChunkSize {
+			return c.buf, nil
+		}
+	}
+}
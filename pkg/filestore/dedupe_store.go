@@ -0,0 +1,652 @@
+package filestore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+const (
+	// DedupePrefix is where the dedupe store keeps its manifests and chunks in the backend
+	DedupePrefix    = "dedupe/"
+	manifestsPrefix = DedupePrefix + "manifests/"
+	chunksPrefix    = DedupePrefix + "chunks/"
+
+	chunkedArchiveKind = "ChunkedArchive"
+	compressionGzip    = "gzip"
+)
+
+// chunkedArchive is stored in the backend in place of the archive and lists the chunks
+// that make up its (uncompressed) content
+type chunkedArchive struct {
+	Kind        string   `json:"kind"`
+	Compression string   `json:"compression,omitempty"`
+	Size        int64    `json:"size"`
+	SHA256      string   `json:"sha256"`
+	Chunks      []string `json:"chunks"`
+	// Gzip is how the content is compressed back into the archive that was written. Not set in manifests written
+	// before it was added, those archives are compressed with the default settings.
+	Gzip *gzipParams `json:"gzip,omitempty"`
+	// ArchiveSHA256 is the digest of the archive that was written, before it was decompressed
+	ArchiveSHA256 string `json:"archiveSha256,omitempty"`
+}
+
+// gzipParams reproduce the exact bytes of a gzip stream that was written by the go gzip writer
+type gzipParams struct {
+	Level   int       `json:"level"`
+	Name    string    `json:"name,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+	Extra   []byte    `json:"extra,omitempty"`
+	ModTime time.Time `json:"modTime"`
+	OS      byte      `json:"os"`
+}
+
+// DedupeStore splits archives into content-addressed chunks stored in the backend, so that blocks shared
+// between archives (e.g. near-identical app versions) are only stored once.
+// Gzipped archives are chunked after decompression since a small change in the input changes the entire
+// compressed stream. This is only done if compressing the content again reproduces the exact bytes that were written,
+// other archives are chunked as is.
+// Archives that were written to the backend before dedupe was enabled can still be read.
+type DedupeStore struct {
+	Backend FileStore
+	// Dedupe is false when dedupe is disabled on a backend that can have chunked archives from when it was enabled.
+	// Archives are written to the backend as is, and chunked archives can still be read until they are deleted.
+	Dedupe bool
+
+	// mtx prevents chunks of an archive that is being written from being garbage collected
+	// before its manifest is written
+	mtx sync.Mutex
+	// chunks is the set of chunks in the backend, so that writing a chunk does not have to check the backend.
+	// It is loaded on the first write and reloaded when chunks are garbage collected.
+	chunks map[string]bool
+	// gcPending is set when archives were deleted since chunks were last garbage collected
+	gcPending bool
+}
+
+func NewDedupeStore(backend FileStore) *DedupeStore {
+	return &DedupeStore{
+		Backend: backend,
+		Dedupe:  true,
+		// chunks can be left over from archives that were deleted before a restart
+		gcPending: true,
+	}
+}
+
+// NewChunkedArchiveReader returns a store that writes archives to the backend as is, but can still read and delete
+// chunked archives that were written while dedupe was enabled on the backend
+func NewChunkedArchiveReader(backend FileStore) *DedupeStore {
+	return &DedupeStore{
+		Backend:   backend,
+		Dedupe:    false,
+		gcPending: true,
+	}
+}
+
+func (s *DedupeStore) Init() error {
+	return s.Backend.Init()
+}
+
+func (s *DedupeStore) WaitForReady(ctx context.Context) error {
+	return s.Backend.WaitForReady(ctx)
+}
+
+func (s *DedupeStore) WriteArchive(outputPath string, body io.ReadSeeker) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.Dedupe {
+		return s.writeArchiveAsIs(outputPath, body)
+	}
+
+	archiveSHA256, err := hashArchive(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash archive")
+	}
+
+	gzipParams, err := detectReproducibleGzip(body, archiveSHA256)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect compression")
+	}
+
+	var content io.Reader = body
+	if gzipParams != nil {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to create gzip reader")
+		}
+		defer gzipReader.Close()
+		content = gzipReader
+	}
+
+	archive, err := s.writeChunks(content)
+	if err != nil {
+		return errors.Wrap(err, "failed to write chunks")
+	}
+	if gzipParams != nil {
+		archive.Compression = compressionGzip
+		archive.Gzip = gzipParams
+	}
+	archive.ArchiveSHA256 = archiveSHA256
+
+	manifest, err := json.Marshal(archive)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	if err := s.Backend.WriteArchive(manifestsPrefix+outputPath, bytes.NewReader(manifest)); err != nil {
+		return errors.Wrap(err, "failed to write manifest")
+	}
+
+	// remove a copy of the archive that was written before dedupe was enabled, it's been replaced by the manifest
+	existing, err := s.Backend.ListArchives(outputPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to list existing archives")
+	}
+	if containsPath(existing, outputPath) {
+		if err := s.Backend.DeleteArchive(outputPath); err != nil {
+			return errors.Wrap(err, "failed to delete non deduplicated archive")
+		}
+	}
+
+	return nil
+}
+
+// writeArchiveAsIs writes the archive to the backend and removes the chunked archive that it replaces
+func (s *DedupeStore) writeArchiveAsIs(outputPath string, body io.ReadSeeker) error {
+	if err := s.Backend.WriteArchive(outputPath, body); err != nil {
+		return err
+	}
+
+	manifestPath := manifestsPrefix + outputPath
+	existing, err := s.Backend.ListArchives(manifestPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to list manifests")
+	}
+	if containsPath(existing, manifestPath) {
+		if err := s.Backend.DeleteArchive(manifestPath); err != nil {
+			return errors.Wrap(err, "failed to delete manifest")
+		}
+		s.gcPending = true
+	}
+
+	return nil
+}
+
+func hashArchive(body io.ReadSeeker) (string, error) {
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "failed to seek")
+	}
+	defer body.Seek(0, io.SeekStart)
+
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", errors.Wrap(err, "failed to read archive")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// detectReproducibleGzip returns the parameters that compress the content of a gzip archive back into the same bytes,
+// or nil if the body is not a gzip stream or it was compressed differently. The body is left at its start.
+func detectReproducibleGzip(body io.ReadSeeker, archiveSHA256 string) (*gzipParams, error) {
+	defer body.Seek(0, io.SeekStart)
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "failed to seek")
+	}
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(body, header); err != nil {
+		return nil, nil
+	}
+
+	// the go gzip writer records the best compression and best speed levels in the XFL byte of the header
+	level := gzip.DefaultCompression
+	switch header[8] {
+	case 2:
+		level = gzip.BestCompression
+	case 4:
+		level = gzip.BestSpeed
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "failed to seek")
+	}
+	gzipReader, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, nil
+	}
+	defer gzipReader.Close()
+
+	params := &gzipParams{
+		Level:   level,
+		Name:    gzipReader.Name,
+		Comment: gzipReader.Comment,
+		Extra:   gzipReader.Extra,
+		ModTime: gzipReader.ModTime,
+		OS:      gzipReader.OS,
+	}
+
+	h := sha256.New()
+	gzipWriter, err := newGzipWriter(h, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gzip writer")
+	}
+	if _, err := io.Copy(gzipWriter, gzipReader); err != nil {
+		return nil, nil
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close gzip writer")
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != archiveSHA256 {
+		return nil, nil
+	}
+
+	return params, nil
+}
+
+func newGzipWriter(w io.Writer, params *gzipParams) (*gzip.Writer, error) {
+	if params == nil {
+		return gzip.NewWriter(w), nil
+	}
+
+	gzipWriter, err := gzip.NewWriterLevel(w, params.Level)
+	if err != nil {
+		return nil, err
+	}
+	gzipWriter.Name = params.Name
+	gzipWriter.Comment = params.Comment
+	gzipWriter.Extra = params.Extra
+	gzipWriter.ModTime = params.ModTime
+	gzipWriter.OS = params.OS
+	return gzipWriter, nil
+}
+
+func (s *DedupeStore) writeChunks(content io.Reader) (*chunkedArchive, error) {
+	archive := &chunkedArchive{
+		Kind:   chunkedArchiveKind,
+		Chunks: []string{},
+	}
+
+	written := map[string]bool{}
+	archiveHash := sha256.New()
+	c := newChunker(content)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read chunk")
+		}
+
+		archiveHash.Write(chunk)
+		archive.Size += int64(len(chunk))
+
+		chunkHash := sha256.Sum256(chunk)
+		digest := hex.EncodeToString(chunkHash[:])
+		archive.Chunks = append(archive.Chunks, digest)
+
+		if written[digest] {
+			continue
+		}
+		if err := s.writeChunk(digest, chunk); err != nil {
+			return nil, errors.Wrapf(err, "failed to write chunk %s", digest)
+		}
+		written[digest] = true
+	}
+
+	archive.SHA256 = hex.EncodeToString(archiveHash.Sum(nil))
+	return archive, nil
+}
+
+func (s *DedupeStore) writeChunk(digest string, chunk []byte) error {
+	if s.chunks == nil {
+		if err := s.loadChunkIndex(); err != nil {
+			return errors.Wrap(err, "failed to load chunk index")
+		}
+	}
+	if s.chunks[digest] {
+		return nil
+	}
+
+	compressed := bytes.NewBuffer(nil)
+	gzipWriter := gzip.NewWriter(compressed)
+	if _, err := gzipWriter.Write(chunk); err != nil {
+		return errors.Wrap(err, "failed to compress chunk")
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return errors.Wrap(err, "failed to close gzip writer")
+	}
+
+	if err := s.Backend.WriteArchive(chunkPath(digest), bytes.NewReader(compressed.Bytes())); err != nil {
+		return err
+	}
+	s.chunks[digest] = true
+
+	return nil
+}
+
+func (s *DedupeStore) loadChunkIndex() error {
+	chunkPaths, err := s.Backend.ListArchives(chunksPrefix)
+	if err != nil {
+		return errors.Wrap(err, "failed to list chunks")
+	}
+
+	s.chunks = map[string]bool{}
+	for _, chunkPath := range chunkPaths {
+		s.chunks[path.Base(chunkPath)] = true
+	}
+
+	return nil
+}
+
+func (s *DedupeStore) ReadArchive(archivePath string) (string, error) {
+	if !s.Dedupe {
+		// most archives are not chunked when dedupe is disabled
+		outputPath, err := s.Backend.ReadArchive(archivePath)
+		if err == nil {
+			return outputPath, nil
+		}
+		archive, manifestErr := s.readManifest(manifestsPrefix + archivePath)
+		if manifestErr != nil || archive == nil {
+			return "", err
+		}
+		return s.readChunkedArchive(archivePath, archive)
+	}
+
+	archive, err := s.readManifest(manifestsPrefix + archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read manifest")
+	}
+	if archive == nil {
+		// written before dedupe was enabled
+		return s.Backend.ReadArchive(archivePath)
+	}
+
+	return s.readChunkedArchive(archivePath, archive)
+}
+
+func (s *DedupeStore) readChunkedArchive(archivePath string, archive *chunkedArchive) (string, error) {
+	tmpDir, err := ioutil.TempDir("", "kotsadm")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temp dir")
+	}
+
+	outputPath := filepath.Join(tmpDir, path.Base(archivePath))
+	if err := s.assembleArchive(archive, outputPath); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", errors.Wrap(err, "failed to assemble archive")
+	}
+
+	return outputPath, nil
+}
+
+func (s *DedupeStore) assembleArchive(archive *chunkedArchive, outputPath string) error {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file %q", outputPath)
+	}
+	defer outputFile.Close()
+
+	outputHash := sha256.New()
+	output := io.MultiWriter(outputFile, outputHash)
+
+	var contentWriter io.Writer = output
+	var gzipWriter *gzip.Writer
+	if archive.Compression == compressionGzip {
+		gzipWriter, err = newGzipWriter(output, archive.Gzip)
+		if err != nil {
+			return errors.Wrap(err, "failed to create gzip writer")
+		}
+		contentWriter = gzipWriter
+	}
+
+	archiveHash := sha256.New()
+	size := int64(0)
+	for _, digest := range archive.Chunks {
+		chunk, err := s.readChunk(digest)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read chunk %s", digest)
+		}
+		if _, err := contentWriter.Write(chunk); err != nil {
+			return errors.Wrap(err, "failed to write chunk")
+		}
+		archiveHash.Write(chunk)
+		size += int64(len(chunk))
+	}
+
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return errors.Wrap(err, "failed to close gzip writer")
+		}
+	}
+
+	if size != archive.Size {
+		return errors.Errorf("archive size mismatch: expected %d, got %d", archive.Size, size)
+	}
+	if digest := hex.EncodeToString(archiveHash.Sum(nil)); digest != archive.SHA256 {
+		return errors.Errorf("archive checksum mismatch: expected %s, got %s", archive.SHA256, digest)
+	}
+	if archive.ArchiveSHA256 != "" {
+		if digest := hex.EncodeToString(outputHash.Sum(nil)); digest != archive.ArchiveSHA256 {
+			// the content matches, only the compression differs. this happens if the go gzip implementation changes,
+			// failing would make the archive unreadable.
+			logger.Infof("compressed archive does not match the archive that was written, its content is the same: expected %s, got %s", archive.ArchiveSHA256, digest)
+		}
+	}
+
+	return nil
+}
+
+func (s *DedupeStore) readChunk(digest string) ([]byte, error) {
+	chunkFile, err := s.Backend.ReadArchive(chunkPath(digest))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read chunk archive")
+	}
+	defer os.RemoveAll(filepath.Dir(chunkFile))
+
+	f, err := os.Open(chunkFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open chunk")
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gzip reader")
+	}
+	defer gzipReader.Close()
+
+	chunk, err := ioutil.ReadAll(gzipReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress chunk")
+	}
+
+	chunkHash := sha256.Sum256(chunk)
+	if hex.EncodeToString(chunkHash[:]) != digest {
+		return nil, errors.New("chunk checksum mismatch")
+	}
+
+	return chunk, nil
+}
+
+// readManifest returns nil if there is no manifest at the given path
+func (s *DedupeStore) readManifest(manifestPath string) (*chunkedArchive, error) {
+	existing, err := s.Backend.ListArchives(manifestPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list manifests")
+	}
+	if !containsPath(existing, manifestPath) {
+		return nil, nil
+	}
+
+	manifestFile, err := s.Backend.ReadArchive(manifestPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest archive")
+	}
+	defer os.RemoveAll(filepath.Dir(manifestFile))
+
+	data, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest file")
+	}
+
+	archive := chunkedArchive{}
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest")
+	}
+	if archive.Kind != chunkedArchiveKind {
+		return nil, errors.Errorf("unexpected manifest kind %q", archive.Kind)
+	}
+
+	return &archive, nil
+}
+
+// DeleteArchive deletes the archive. Chunks that are no longer referenced are deleted by GarbageCollectChunks,
+// which is not done for every archive since it reads all manifests.
+func (s *DedupeStore) DeleteArchive(archivePath string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := s.Backend.DeleteArchive(archivePath); err != nil {
+		return errors.Wrap(err, "failed to delete non deduplicated archive")
+	}
+
+	if err := s.Backend.DeleteArchive(manifestsPrefix + archivePath); err != nil {
+		return errors.Wrap(err, "failed to delete manifest")
+	}
+	s.gcPending = true
+
+	return nil
+}
+
+// GarbageCollectChunks deletes chunks that are no longer referenced by any manifest
+func (s *DedupeStore) GarbageCollectChunks() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := s.garbageCollectChunks(); err != nil {
+		return err
+	}
+	s.gcPending = false
+
+	return nil
+}
+
+func (s *DedupeStore) garbageCollectChunks() error {
+	// the index is reloaded on the next write
+	s.chunks = nil
+
+	manifestPaths, err := s.Backend.ListArchives(manifestsPrefix)
+	if err != nil {
+		return errors.Wrap(err, "failed to list manifests")
+	}
+
+	referenced := map[string]bool{}
+	for _, manifestPath := range manifestPaths {
+		archive, err := s.readManifest(manifestPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read manifest %s", manifestPath)
+		}
+		if archive == nil {
+			continue
+		}
+		for _, digest := range archive.Chunks {
+			referenced[digest] = true
+		}
+	}
+
+	chunkPaths, err := s.Backend.ListArchives(chunksPrefix)
+	if err != nil {
+		return errors.Wrap(err, "failed to list chunks")
+	}
+	for _, chunkPath := range chunkPaths {
+		if referenced[path.Base(chunkPath)] {
+			continue
+		}
+		if err := s.Backend.DeleteArchive(chunkPath); err != nil {
+			return errors.Wrapf(err, "failed to delete chunk %s", chunkPath)
+		}
+	}
+
+	return nil
+}
+
+// garbageCollectChunksIfPending garbage collects chunks if archives were deleted since the last garbage collection
+func (s *DedupeStore) garbageCollectChunksIfPending() error {
+	s.mtx.Lock()
+	pending := s.gcPending
+	s.mtx.Unlock()
+
+	if !pending {
+		return nil
+	}
+	return s.GarbageCollectChunks()
+}
+
+// GarbageCollectChunks deletes chunks that are no longer referenced if the store deduplicates archives
+func GarbageCollectChunks(store FileStore) error {
+	dedupeStore, ok := store.(*DedupeStore)
+	if !ok {
+		return nil
+	}
+	return dedupeStore.GarbageCollectChunks()
+}
+
+func (s *DedupeStore) ListArchives(prefix string) ([]string, error) {
+	backendPaths, err := s.Backend.ListArchives(prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list archives")
+	}
+
+	manifestPaths, err := s.Backend.ListArchives(manifestsPrefix + prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list manifests")
+	}
+
+	unique := map[string]bool{}
+	for _, p := range backendPaths {
+		if strings.HasPrefix(p, DedupePrefix) {
+			continue
+		}
+		unique[p] = true
+	}
+	for _, p := range manifestPaths {
+		unique[strings.TrimPrefix(p, manifestsPrefix)] = true
+	}
+
+	paths := make([]string, 0, len(unique))
+	for p := range unique {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func chunkPath(digest string) string {
+	return chunksPrefix + digest[:2] + "/" + digest
+}
+
+func containsPath(paths []string, p string) bool {
+	for _, existing := range paths {
+		if existing == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package filestore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunker(t *testing.T) {
+	original := randomBytes(1, 2*1024*1024)
+
+	// inserting bytes near the start must only change the chunks around the insertion
+	modified := append([]byte{}, original[:1000]...)
+	modified = append(modified, []byte("inserted")...)
+	modified = append(modified, original[1000:]...)
+
+	originalChunks := chunkDigests(t, original)
+	modifiedChunks := chunkDigests(t, modified)
+	require.Greater(t, len(originalChunks), 4)
+
+	shared := 0
+	for digest := range modifiedChunks {
+		if originalChunks[digest] {
+			shared++
+		}
+	}
+	assert.GreaterOrEqual(t, shared, len(originalChunks)-2)
+}
+
+func TestDedupeStore_RoundTrip(t *testing.T) {
+	rootDir := t.TempDir()
+	store := NewDedupeStore(&FilesystemStore{RootDir: rootDir})
+	require.NoError(t, store.Init())
+
+	files := map[string][]byte{
+		"upstream/userdata/installation.yaml": []byte("apiVersion: kots.io/v1beta1\nkind: Installation\n"),
+		"upstream/data.bin":                   randomBytes(2, 512*1024),
+	}
+	archive := tarGz(t, files)
+
+	require.NoError(t, store.WriteArchive("app-id/0.tar.gz", bytes.NewReader(archive)))
+
+	raw := []byte("not compressed")
+	require.NoError(t, store.WriteArchive("supportbundles/id/file.txt", bytes.NewReader(raw)))
+
+	archivePath, err := store.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "0.tar.gz", filepath.Base(archivePath))
+	assert.Equal(t, files, untarGz(t, archivePath))
+
+	// the archive is compressed back into the exact bytes that were written
+	got, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, archive, got)
+
+	rawPath, err := store.ReadArchive("supportbundles/id/file.txt")
+	require.NoError(t, err)
+	got, err = ioutil.ReadFile(rawPath)
+	require.NoError(t, err)
+	assert.Equal(t, raw, got)
+
+	paths, err := store.ListArchives("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-id/0.tar.gz", "supportbundles/id/file.txt"}, paths)
+}
+
+func TestDedupeStore_SharesChunks(t *testing.T) {
+	rootDir := t.TempDir()
+	backend := &FilesystemStore{RootDir: rootDir}
+	store := NewDedupeStore(backend)
+	require.NoError(t, store.Init())
+
+	data := randomBytes(3, 1024*1024)
+	v0 := tarGz(t, map[string][]byte{"data.bin": data, "version.yaml": []byte("version: 1.0.0")})
+	v1 := tarGz(t, map[string][]byte{"data.bin": data, "version.yaml": []byte("version: 1.0.1")})
+
+	require.NoError(t, store.WriteArchive("app-id/0.tar.gz", bytes.NewReader(v0)))
+	chunksAfterFirst, err := backend.ListArchives(chunksPrefix)
+	require.NoError(t, err)
+
+	require.NoError(t, store.WriteArchive("app-id/1.tar.gz", bytes.NewReader(v1)))
+	chunksAfterSecond, err := backend.ListArchives(chunksPrefix)
+	require.NoError(t, err)
+
+	// only the chunks around the changed file are new
+	assert.LessOrEqual(t, len(chunksAfterSecond)-len(chunksAfterFirst), 2)
+
+	// deleting the first version keeps the chunks that are still used by the second
+	require.NoError(t, store.DeleteArchive("app-id/0.tar.gz"))
+	require.NoError(t, store.GarbageCollectChunks())
+
+	archivePath, err := store.ReadArchive("app-id/1.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, data, untarGz(t, archivePath)["data.bin"])
+
+	// chunks are only removed when garbage collected
+	require.NoError(t, store.DeleteArchive("app-id/1.tar.gz"))
+	remaining, err := backend.ListArchives(chunksPrefix)
+	require.NoError(t, err)
+	assert.NotEmpty(t, remaining)
+
+	require.NoError(t, store.garbageCollectChunksIfPending())
+	remaining, err = backend.ListArchives(chunksPrefix)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	// chunks that were garbage collected are written again
+	require.NoError(t, store.WriteArchive("app-id/2.tar.gz", bytes.NewReader(v1)))
+	archivePath, err = store.ReadArchive("app-id/2.tar.gz")
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, v1, got)
+}
+
+func TestDedupeStore_NonReproducibleGzip(t *testing.T) {
+	rootDir := t.TempDir()
+	backend := &FilesystemStore{RootDir: rootDir}
+	store := NewDedupeStore(backend)
+	require.NoError(t, store.Init())
+
+	// a gzip stream with a level the go gzip writer does not record in the header can't be reproduced
+	buf := bytes.NewBuffer(nil)
+	gzipWriter, err := gzip.NewWriterLevel(buf, 3)
+	require.NoError(t, err)
+	for i := 0; i < 10000; i++ {
+		_, err = fmt.Fprintf(gzipWriter, "line %d: %x\n", i, randomBytes(int64(i%10), 8))
+		require.NoError(t, err)
+	}
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	archive := buf.Bytes()
+
+	require.NoError(t, store.WriteArchive("app-id/0.tar.gz", bytes.NewReader(archive)))
+
+	manifest, err := store.readManifest(manifestsPrefix + "app-id/0.tar.gz")
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Compression)
+
+	archivePath, err := store.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, archive, got)
+}
+
+func TestDedupeStore_DedupeDisabled(t *testing.T) {
+	rootDir := t.TempDir()
+	backend := &FilesystemStore{RootDir: rootDir}
+	require.NoError(t, backend.Init())
+
+	// the backend is used as is unless it has chunked archives
+	assert.Equal(t, FileStore(backend), withDedupe(backend, false))
+
+	v0 := tarGz(t, map[string][]byte{"a.yaml": []byte("a: b")})
+	v1 := tarGz(t, map[string][]byte{"a.yaml": []byte("a: c")})
+	require.NoError(t, NewDedupeStore(backend).WriteArchive("app-id/0.tar.gz", bytes.NewReader(v0)))
+	require.NoError(t, NewDedupeStore(backend).WriteArchive("app-id/1.tar.gz", bytes.NewReader(v1)))
+
+	store := withDedupe(backend, false)
+	require.IsType(t, &DedupeStore{}, store)
+
+	// archives that were chunked while dedupe was enabled can still be read
+	archivePath, err := store.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, v0, got)
+
+	// rewriting an archive replaces its manifest with the archive
+	require.NoError(t, store.WriteArchive("app-id/0.tar.gz", bytes.NewReader(v0)))
+	_, err = os.Stat(filepath.Join(rootDir, "app-id", "0.tar.gz"))
+	require.NoError(t, err)
+	manifests, err := backend.ListArchives(manifestsPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, []string{manifestsPrefix + "app-id/1.tar.gz"}, manifests)
+
+	paths, err := store.ListArchives("app-id/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-id/0.tar.gz", "app-id/1.tar.gz"}, paths)
+
+	// deleting the last chunked archive leaves no chunks behind
+	require.NoError(t, store.DeleteArchive("app-id/1.tar.gz"))
+	require.NoError(t, GarbageCollectChunks(store))
+	remaining, err := backend.ListArchives(DedupePrefix)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	archivePath, err = store.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	got, err = ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, v0, got)
+}
+
+func TestDedupeStore_NonDeduplicatedArchives(t *testing.T) {
+	rootDir := t.TempDir()
+	backend := &FilesystemStore{RootDir: rootDir}
+	require.NoError(t, backend.Init())
+
+	archive := tarGz(t, map[string][]byte{"a.yaml": []byte("a: b")})
+	require.NoError(t, backend.WriteArchive("app-id/0.tar.gz", bytes.NewReader(archive)))
+
+	store := NewDedupeStore(backend)
+
+	archivePath, err := store.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, archive, got)
+
+	// rewriting the archive replaces the original copy with a manifest
+	require.NoError(t, store.WriteArchive("app-id/0.tar.gz", bytes.NewReader(archive)))
+	_, err = os.Stat(filepath.Join(rootDir, "app-id", "0.tar.gz"))
+	assert.True(t, os.IsNotExist(err))
+
+	paths, err := store.ListArchives("app-id/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-id/0.tar.gz"}, paths)
+}
+
+func TestMigrateArchives(t *testing.T) {
+	from := &FilesystemStore{RootDir: t.TempDir()}
+	require.NoError(t, from.Init())
+
+	archive := tarGz(t, map[string][]byte{"a.yaml": []byte("a: b")})
+	require.NoError(t, from.WriteArchive("app-id/0.tar.gz", bytes.NewReader(archive)))
+	require.NoError(t, from.WriteArchive("supportbundles/id/supportbundle.tar.gz", bytes.NewReader(archive)))
+
+	toBackend := &FilesystemStore{RootDir: t.TempDir()}
+	to := NewDedupeStore(toBackend)
+	require.NoError(t, to.Init())
+
+	result, err := MigrateArchives(from, to, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-id/0.tar.gz", "supportbundles/id/supportbundle.tar.gz"}, result.Archives)
+	migrated, err := to.ListArchives("")
+	require.NoError(t, err)
+	assert.Empty(t, migrated)
+
+	progress := []string{}
+	result, err = MigrateArchives(from, to, MigrateOptions{
+		DeleteSource: true,
+		Progress: func(migrated int, total int) {
+			progress = append(progress, fmt.Sprintf("%d/%d", migrated, total))
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Archives, 2)
+	assert.Equal(t, []string{"1/2", "2/2"}, progress)
+
+	migrated, err = to.ListArchives("")
+	require.NoError(t, err)
+	assert.Equal(t, result.Archives, migrated)
+
+	remaining, err := from.ListArchives("")
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	archivePath, err := to.ReadArchive("app-id/0.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a.yaml": []byte("a: b")}, untarGz(t, archivePath))
+}
+
+func randomBytes(seed int64, size int) []byte {
+	b := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(b)
+	return b
+}
+
+func chunkDigests(t *testing.T, data []byte) map[string]bool {
+	digests := map[string]bool{}
+	c := newChunker(bytes.NewReader(data))
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		digest := sha256.Sum256(chunk)
+		digests[hex.EncodeToString(digest[:])] = true
+	}
+	return digests
+}
+
+func tarGz(t *testing.T, files map[string][]byte) []byte {
+	buf := bytes.NewBuffer(nil)
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	// stable order so that versions with the same files produce the same stream
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := files[name]
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tarWriter.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	return buf.Bytes()
+}
+
+func untarGz(t *testing.T, archivePath string) map[string][]byte {
+	f, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string][]byte{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = content
+	}
+	return files
+}
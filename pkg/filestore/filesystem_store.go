@@ -0,0 +1,67 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultFilesystemDir = "/kotsadmdata/filestore"
+)
+
+// FilesystemDirFromEnv returns the directory of the filesystem backend configured with KOTSADM_FILESTORE_FILESYSTEM_DIR,
+// so that it can be a volume other than the kotsadm data volume
+func FilesystemDirFromEnv() string {
+	if dir := os.Getenv("KOTSADM_FILESTORE_FILESYSTEM_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultFilesystemDir
+}
+
+// FilesystemStore stores archives in a directory on the local filesystem, usually a mounted PVC.
+// Unlike the BlobStore, the directory is never replaced by an ephemeral one. It defaults to a directory
+// on the kotsadm data volume.
+type FilesystemStore struct {
+	RootDir string
+}
+
+func (s *FilesystemStore) Init() error {
+	if s.RootDir == "" {
+		return errors.New("filesystem store root directory is not set")
+	}
+
+	err := os.MkdirAll(s.RootDir, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create filesystem store directory %q", s.RootDir)
+	}
+	return nil
+}
+
+func (s *FilesystemStore) WaitForReady(ctx context.Context) error {
+	// the volume is either mounted or the pod does not start
+	return nil
+}
+
+func (s *FilesystemStore) WriteArchive(outputPath string, body io.ReadSeeker) error {
+	return writeFile(s.fullPath(outputPath), body)
+}
+
+func (s *FilesystemStore) ReadArchive(path string) (string, error) {
+	return readFile(s.fullPath(path))
+}
+
+func (s *FilesystemStore) DeleteArchive(path string) error {
+	return deleteFile(s.fullPath(path))
+}
+
+func (s *FilesystemStore) ListArchives(prefix string) ([]string, error) {
+	return listFiles(s.RootDir, prefix)
+}
+
+func (s *FilesystemStore) fullPath(path string) string {
+	return filepath.Join(s.RootDir, filepath.FromSlash(path))
+}
@@ -0,0 +1,43 @@
+package filestore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// garbageCollectionCronSpec - hourly cron spec for the chunk garbage collection job
+	garbageCollectionCronSpec = "@hourly"
+)
+
+// StartGarbageCollectionCronJob starts the job that deletes chunks that are no longer referenced by any archive.
+// Chunks are not garbage collected when an archive is deleted since it reads all manifests.
+// Chunks left over from when dedupe was enabled are garbage collected when archives are migrated.
+func StartGarbageCollectionCronJob() error {
+	if !DedupeFromEnv() {
+		return nil
+	}
+
+	logger.Debug("starting file store garbage collection cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(garbageCollectionCronSpec, func() {
+		dedupeStore, ok := GetStore().(*DedupeStore)
+		if !ok {
+			return
+		}
+		logger.Debug("running file store garbage collection job")
+		if err := dedupeStore.garbageCollectChunksIfPending(); err != nil {
+			logger.Error(errors.Wrap(err, "failed to garbage collect file store chunks"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
@@ -0,0 +1,95 @@
+package filestore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+type MigrateOptions struct {
+	// DeleteSource removes each archive from the source store once it has been copied
+	DeleteSource bool
+	// DryRun only lists the archives that would be migrated
+	DryRun bool
+	// Progress is called after each archive has been migrated
+	Progress func(migrated int, total int)
+}
+
+type MigrateResult struct {
+	Archives []string `json:"archives"`
+}
+
+// MigrateArchives copies all archives from one store to another. It can be run again after a failure,
+// archives that were already copied are overwritten. Internal dedupe chunks and manifests are never
+// copied as is, archives are read through the source store and written through the destination store.
+func MigrateArchives(from FileStore, to FileStore, opts MigrateOptions) (*MigrateResult, error) {
+	paths, err := from.ListArchives("")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list archives")
+	}
+
+	archivePaths := []string{}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, DedupePrefix) {
+			archivePaths = append(archivePaths, path)
+		}
+	}
+
+	result := &MigrateResult{
+		Archives: []string{},
+	}
+	for _, path := range archivePaths {
+		if !opts.DryRun {
+			if err := migrateArchive(from, to, path); err != nil {
+				return result, errors.Wrapf(err, "failed to migrate archive %s", path)
+			}
+			if opts.DeleteSource {
+				if err := from.DeleteArchive(path); err != nil {
+					return result, errors.Wrapf(err, "failed to delete archive %s from source", path)
+				}
+			}
+			logger.Debugf("migrated archive %s", path)
+		}
+
+		result.Archives = append(result.Archives, path)
+		if opts.Progress != nil {
+			opts.Progress(len(result.Archives), len(archivePaths))
+		}
+	}
+
+	if !opts.DryRun {
+		// manifests are replaced when migrating between dedupe and non dedupe on the same backend
+		// or deleted from the source, the chunks they referenced are no longer used
+		if err := GarbageCollectChunks(from); err != nil {
+			return result, errors.Wrap(err, "failed to garbage collect source chunks")
+		}
+		if err := GarbageCollectChunks(to); err != nil {
+			return result, errors.Wrap(err, "failed to garbage collect destination chunks")
+		}
+	}
+
+	return result, nil
+}
+
+func migrateArchive(from FileStore, to FileStore, path string) error {
+	archivePath, err := from.ReadArchive(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read archive")
+	}
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to open archive")
+	}
+	defer f.Close()
+
+	if err := to.WriteArchive(path, f); err != nil {
+		return errors.Wrap(err, "failed to write archive")
+	}
+
+	return nil
+}
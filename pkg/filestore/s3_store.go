@@ -160,3 +160,24 @@ func (s *S3Store) DeleteArchive(path string) error {
 
 	return nil
 }
+
+func (s *S3Store) ListArchives(prefix string) ([]string, error) {
+	newSession := awssession.New(kotss3.GetConfig())
+	s3Client := s3.New(newSession)
+
+	paths := []string{}
+	err := s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(os.Getenv("S3_BUCKET_NAME")),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			paths = append(paths, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects in s3")
+	}
+
+	return paths, nil
+}
@@ -2,6 +2,15 @@ package filestore
 
 import (
 	"os"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+const (
+	BackendS3         = "s3"
+	BackendBlob       = "blob"
+	BackendFilesystem = "filesystem"
 )
 
 var (
@@ -19,8 +28,71 @@ func GetStore() FileStore {
 }
 
 func storeFromEnv() FileStore {
+	store, err := NewStore(BackendFromEnv(), DedupeFromEnv())
+	if err != nil {
+		logger.Errorf("failed to create file store, falling back to the default: %v", err)
+		return defaultStore()
+	}
+	return store
+}
+
+// BackendFromEnv returns the backend configured with KOTSADM_FILESTORE_BACKEND.
+// When not set, S3 is used if an S3 endpoint is configured and the blob store otherwise.
+func BackendFromEnv() string {
+	if backend := os.Getenv("KOTSADM_FILESTORE_BACKEND"); backend != "" {
+		return backend
+	}
+	if os.Getenv("S3_ENDPOINT") == "" {
+		return BackendBlob
+	}
+	return BackendS3
+}
+
+// DedupeFromEnv returns true if archives are deduplicated (KOTSADM_FILESTORE_DEDUPE=1)
+func DedupeFromEnv() bool {
+	return os.Getenv("KOTSADM_FILESTORE_DEDUPE") == "1"
+}
+
+func defaultStore() FileStore {
 	if os.Getenv("S3_ENDPOINT") == "" {
 		return &BlobStore{}
 	}
 	return &S3Store{}
 }
+
+// NewStore returns the file store for the given backend. When dedupe is true, archives are split into
+// content-addressed chunks so that blocks shared between archives are only stored once. When it's false,
+// the backend is used as is, unless it has archives that were chunked while dedupe was enabled.
+func NewStore(backend string, dedupe bool) (FileStore, error) {
+	var store FileStore
+	switch backend {
+	case BackendS3:
+		store = &S3Store{}
+	case BackendBlob:
+		store = &BlobStore{}
+	case BackendFilesystem:
+		store = &FilesystemStore{RootDir: FilesystemDirFromEnv()}
+	default:
+		return nil, errors.Errorf("unknown file store backend %q", backend)
+	}
+
+	return withDedupe(store, dedupe), nil
+}
+
+func withDedupe(store FileStore, dedupe bool) FileStore {
+	if dedupe {
+		return NewDedupeStore(store)
+	}
+
+	manifests, err := store.ListArchives(manifestsPrefix)
+	if err != nil {
+		// the backend may not be ready yet, keep chunked archives readable in case there are any
+		logger.Errorf("failed to check file store for chunked archives: %v", err)
+		return NewChunkedArchiveReader(store)
+	}
+	if len(manifests) > 0 {
+		return NewChunkedArchiveReader(store)
+	}
+
+	return store
+}
@@ -11,4 +11,6 @@ type FileStore interface {
 	WriteArchive(outputPath string, body io.ReadSeeker) error
 	ReadArchive(path string) (string, error)
 	DeleteArchive(path string) error
+	// ListArchives returns the paths of all archives that start with the given prefix
+	ListArchives(prefix string) ([]string, error)
 }
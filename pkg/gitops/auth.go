@@ -0,0 +1,291 @@
+package gitops
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	go_git_http "github.com/go-git/go-git/v5/plumbing/transport/http"
+	go_git_ssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"golang.org/x/crypto/ssh"
+)
+
+// Ways to authenticate to the repo
+const (
+	// AuthTypeSSH uses the deploy key generated when gitops is configured
+	AuthTypeSSH = "ssh"
+	// AuthTypeHTTPS uses a username with a password or access token over https
+	AuthTypeHTTPS = "https"
+	// AuthTypeGitHubApp uses a short lived installation token of a GitHub App over https
+	AuthTypeGitHubApp = "githubapp"
+)
+
+const (
+	defaultAuthorName  = "KOTS Admin Console"
+	defaultAuthorEmail = "help@replicated.com"
+)
+
+// KeepExistingValue can be set as the value of any Credentials field to keep the value that is already stored.
+// Empty values remove the stored value.
+const KeepExistingValue = "***HIDDEN***"
+
+// Credentials configure how the admin console authenticates to the repo, and how commits are authored and signed.
+// Fields that are not used by the auth type or signing format are removed when the credentials are stored.
+type Credentials struct {
+	AuthType string
+	Username string
+	// Password is the password or access token used with AuthTypeHTTPS
+	Password                string
+	GitHubAppID             string
+	GitHubAppInstallationID string
+	// GitHubAppPrivateKey is the PEM encoded private key of the GitHub App
+	GitHubAppPrivateKey  string
+	AuthorName           string
+	AuthorEmail          string
+	SigningFormat        string
+	SigningKey           string
+	SigningKeyPassphrase string
+}
+
+func IsValidAuthType(authType string) bool {
+	switch authType {
+	case "", AuthTypeSSH, AuthTypeHTTPS, AuthTypeGitHubApp, KeepExistingValue:
+		return true
+	}
+	return false
+}
+
+// ValidateCredentials checks that the fields required by the auth type and signing format are set and can be parsed.
+// Values that are kept with KeepExistingValue were validated when they were stored.
+func ValidateCredentials(c Credentials) error {
+	if !IsValidAuthType(c.AuthType) {
+		return errors.Errorf("unsupported auth type %q", c.AuthType)
+	}
+	if !IsValidSigningFormat(c.SigningFormat) {
+		return errors.Errorf("unsupported signing format %q", c.SigningFormat)
+	}
+
+	if c.AuthType == AuthTypeGitHubApp && (c.GitHubAppID == "" || c.GitHubAppInstallationID == "") {
+		return errors.New("a github app id and installation id are required")
+	}
+	if c.GitHubAppPrivateKey != "" && c.GitHubAppPrivateKey != KeepExistingValue {
+		if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(c.GitHubAppPrivateKey)); err != nil {
+			return errors.Wrap(err, "failed to parse github app private key")
+		}
+	}
+
+	if c.SigningKey != "" && c.SigningKey != KeepExistingValue {
+		if c.SigningFormat == "" {
+			return errors.New("a signing format is required with a signing key")
+		}
+		if c.SigningFormat == KeepExistingValue || c.SigningKeyPassphrase == KeepExistingValue {
+			// the key is checked against the stored format and passphrase when it is used
+			return nil
+		}
+		if _, err := newCommitSigner(c.SigningFormat, c.SigningKey, c.SigningKeyPassphrase); err != nil {
+			return errors.Wrap(err, "failed to parse signing key")
+		}
+	}
+
+	return nil
+}
+
+func getAuth(gitOpsConfig *GitOpsConfig) (transport.AuthMethod, error) {
+	switch gitOpsConfig.AuthType {
+	case "", AuthTypeSSH:
+		signer, err := ssh.ParsePrivateKey([]byte(gitOpsConfig.PrivateKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse deploy key")
+		}
+		auth := &go_git_ssh.PublicKeys{User: "git", Signer: signer}
+		auth.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return auth, nil
+
+	case AuthTypeHTTPS:
+		if gitOpsConfig.Password == "" {
+			return nil, errors.New("a password or token is required for https auth")
+		}
+		username := gitOpsConfig.Username
+		if username == "" {
+			// most providers accept any non empty username with a token
+			username = "git"
+		}
+		return &go_git_http.BasicAuth{Username: username, Password: gitOpsConfig.Password}, nil
+
+	case AuthTypeGitHubApp:
+		token, err := gitOpsConfig.gitHubAppInstallationToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get github app installation token")
+		}
+		return &go_git_http.BasicAuth{Username: "x-access-token", Password: token}, nil
+	}
+
+	return nil, errors.Errorf("unsupported auth type: %s", gitOpsConfig.AuthType)
+}
+
+// gitHubAppInstallationToken exchanges a JWT signed with the private key of the app for an installation token,
+// which expires after an hour. A new token is requested for every operation.
+func (g *GitOpsConfig) gitHubAppInstallationToken() (string, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(g.GitHubAppPrivateKey))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse private key")
+	}
+
+	now := time.Now()
+	signedJWT, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
+		// allow for clock drift
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(9 * time.Minute).Unix(),
+		Issuer:    g.GitHubAppID,
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign jwt")
+	}
+
+	apiURL, err := g.APIURL()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get api url")
+	}
+
+	client := &providerAPIClient{
+		baseURL:    strings.TrimSuffix(apiURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		authHeader: "Authorization",
+		authValue:  fmt.Sprintf("Bearer %s", signedJWT),
+	}
+
+	response := struct {
+		Token string `json:"token"`
+	}{}
+	if err := client.do(http.MethodPost, fmt.Sprintf("/app/installations/%s/access_tokens", g.GitHubAppInstallationID), nil, &response); err != nil {
+		return "", errors.Wrap(err, "failed to create installation token")
+	}
+
+	return response.Token, nil
+}
+
+func (g *GitOpsConfig) authorSignature() *object.Signature {
+	signature := &object.Signature{
+		Name:  g.AuthorName,
+		Email: g.AuthorEmail,
+		When:  time.Now(),
+	}
+	if signature.Name == "" {
+		signature.Name = defaultAuthorName
+	}
+	if signature.Email == "" {
+		signature.Email = defaultAuthorEmail
+	}
+	return signature
+}
+
+// setCredentialsInSecretData stores the credentials of the provider at idx. Secret values are encrypted.
+// Values set to KeepExistingValue are not changed, and values that are not used by the auth type or signing format
+// are removed so that credentials of a previous auth type are not left behind.
+func setCredentialsInSecretData(secretData map[string][]byte, idx int64, c Credentials) {
+	authType := c.AuthType
+	if authType == KeepExistingValue {
+		authType = string(secretData[fmt.Sprintf("provider.%d.authType", idx)])
+	}
+	signingFormat := c.SigningFormat
+	if signingFormat == KeepExistingValue {
+		signingFormat = string(secretData[fmt.Sprintf("provider.%d.signingFormat", idx)])
+	}
+
+	isHTTPS := authType == AuthTypeHTTPS
+	isGitHubApp := authType == AuthTypeGitHubApp
+	isSigned := signingFormat != ""
+
+	values := []struct {
+		name     string
+		value    string
+		isUsed   bool
+		isSecret bool
+	}{
+		{name: "authType", value: c.AuthType, isUsed: true},
+		{name: "username", value: c.Username, isUsed: isHTTPS},
+		{name: "password", value: c.Password, isUsed: isHTTPS, isSecret: true},
+		{name: "githubAppId", value: c.GitHubAppID, isUsed: isGitHubApp},
+		{name: "githubAppInstallationId", value: c.GitHubAppInstallationID, isUsed: isGitHubApp},
+		{name: "githubAppPrivateKey", value: c.GitHubAppPrivateKey, isUsed: isGitHubApp, isSecret: true},
+		{name: "authorName", value: c.AuthorName, isUsed: true},
+		{name: "authorEmail", value: c.AuthorEmail, isUsed: true},
+		{name: "signingFormat", value: c.SigningFormat, isUsed: true},
+		{name: "signingKey", value: c.SigningKey, isUsed: isSigned, isSecret: true},
+		{name: "signingKeyPassphrase", value: c.SigningKeyPassphrase, isUsed: isSigned, isSecret: true},
+	}
+	for _, v := range values {
+		key := fmt.Sprintf("provider.%d.%s", idx, v.name)
+		if v.isUsed && v.value == KeepExistingValue {
+			continue
+		}
+		delete(secretData, key)
+		if !v.isUsed || v.value == "" {
+			continue
+		}
+		if v.isSecret {
+			secretData[key] = encryptSecretValue(v.value)
+		} else {
+			secretData[key] = []byte(v.value)
+		}
+	}
+}
+
+func setCredentialsFromSecretData(gitOpsConfig *GitOpsConfig, idx int64, secretData map[string][]byte) error {
+	plainValues := map[string]*string{
+		"authType":                &gitOpsConfig.AuthType,
+		"username":                &gitOpsConfig.Username,
+		"githubAppId":             &gitOpsConfig.GitHubAppID,
+		"githubAppInstallationId": &gitOpsConfig.GitHubAppInstallationID,
+		"authorName":              &gitOpsConfig.AuthorName,
+		"authorEmail":             &gitOpsConfig.AuthorEmail,
+		"signingFormat":           &gitOpsConfig.SigningFormat,
+	}
+	for name, value := range plainValues {
+		*value = string(secretData[fmt.Sprintf("provider.%d.%s", idx, name)])
+	}
+
+	secretValues := map[string]*string{
+		"apiToken":             &gitOpsConfig.APIToken,
+		"password":             &gitOpsConfig.Password,
+		"githubAppPrivateKey":  &gitOpsConfig.GitHubAppPrivateKey,
+		"signingKey":           &gitOpsConfig.SigningKey,
+		"signingKeyPassphrase": &gitOpsConfig.SigningKeyPassphrase,
+	}
+	for name, value := range secretValues {
+		encoded, ok := secretData[fmt.Sprintf("provider.%d.%s", idx, name)]
+		if !ok {
+			continue
+		}
+		decrypted, err := decryptSecretValue(encoded)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt %s", name)
+		}
+		*value = decrypted
+	}
+
+	return nil
+}
+
+func encryptSecretValue(value string) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte(value))))
+}
+
+func decryptSecretValue(encoded []byte) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode")
+	}
+	decrypted, err := crypto.Decrypt(decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt")
+	}
+	return string(decrypted), nil
+}
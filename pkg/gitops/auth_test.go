@@ -0,0 +1,117 @@
+package gitops
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	go_git_http "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuthGitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v3/app/installations/42/access_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		token, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil || token.Claims.(*jwt.StandardClaims).Issuer != "1234" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token": "ghs_installation", "expires_at": "2030-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	// the api url is always https
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	config := &GitOpsConfig{
+		Provider:                "github_enterprise",
+		Hostname:                strings.TrimPrefix(server.URL, "https://"),
+		AuthType:                AuthTypeGitHubApp,
+		GitHubAppID:             "1234",
+		GitHubAppInstallationID: "42",
+		GitHubAppPrivateKey:     string(privateKeyPEM),
+	}
+
+	auth, err := getAuth(config)
+	require.NoError(t, err)
+	assert.Equal(t, &go_git_http.BasicAuth{Username: "x-access-token", Password: "ghs_installation"}, auth)
+
+	config.GitHubAppInstallationID = "43"
+	_, err = getAuth(config)
+	assert.Error(t, err)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials Credentials
+		wantErr     bool
+	}{
+		{
+			name:        "default",
+			credentials: Credentials{},
+		},
+		{
+			name:        "unknown auth type",
+			credentials: Credentials{AuthType: "oauth"},
+			wantErr:     true,
+		},
+		{
+			name:        "github app without installation",
+			credentials: Credentials{AuthType: AuthTypeGitHubApp, GitHubAppID: "1"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown signing format",
+			credentials: Credentials{SigningFormat: "x509"},
+			wantErr:     true,
+		},
+		{
+			name: "kept values",
+			credentials: Credentials{
+				AuthType:             KeepExistingValue,
+				GitHubAppPrivateKey:  KeepExistingValue,
+				SigningFormat:        KeepExistingValue,
+				SigningKey:           KeepExistingValue,
+				SigningKeyPassphrase: KeepExistingValue,
+			},
+		},
+		{
+			name:        "invalid signing key",
+			credentials: Credentials{SigningFormat: SigningFormatSSH, SigningKey: "not a key"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCredentials(tt.credentials)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
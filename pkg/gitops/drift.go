@@ -0,0 +1,469 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/pkg/errors"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/util"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// LiveObjectGetter returns the object of the resource in the cluster, or nil if it does not exist
+type LiveObjectGetter func(resource gitopstypes.Resource) (map[string]interface{}, error)
+
+// resourceKey identifies a resource regardless of the version of its api group
+type resourceKey struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+type parsedResource struct {
+	resource gitopstypes.Resource
+	object   map[string]interface{}
+}
+
+// GetRepoManifests returns the manifests of the app in the configured branch and path of the repo.
+// Helm charts and the kustomization written in the multi file formats are not included.
+func GetRepoManifests(gitOpsConfig *GitOpsConfig, appSlug string) ([]byte, error) {
+	auth, err := getAuth(gitOpsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get auth")
+	}
+
+	workDir, err := ioutil.TempDir("", "kotsadm")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(workDir)
+
+	cloneURL, err := gitOpsConfig.CloneURL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get clone url")
+	}
+
+	cloneOptions := &git.CloneOptions{
+		RemoteName: git.DefaultRemoteName,
+		URL:        cloneURL,
+		Auth:       auth,
+	}
+	if _, _, err := CloneAndCheckout(workDir, cloneOptions, gitOpsConfig.Branch); err != nil {
+		return nil, err
+	}
+
+	repoPath := strings.TrimPrefix(gitOpsConfig.Path, "/")
+	files, err := listAppRepoFiles(workDir, repoPath, appSlug)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list app files")
+	}
+	sort.Strings(files)
+
+	var manifests [][]byte
+	for _, filename := range files {
+		if strings.HasPrefix(filename, path.Join(appSlug, "helm")+"/") || filename == path.Join(appSlug, "kustomization.yaml") {
+			continue
+		}
+		if ext := path.Ext(filename); ext != ".yaml" && ext != ".yml" {
+			// files that users added to the app directory, such as a readme
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(workDir, repoPath, filepath.FromSlash(filename)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", filename)
+		}
+		manifests = append(manifests, content)
+	}
+
+	return bytes.Join(manifests, []byte("\n---\n")), nil
+}
+
+// CompareManifests returns the resources in actual that were added, modified or removed compared to desired
+func CompareManifests(desired []byte, actual []byte) (gitopstypes.ResourceDrift, error) {
+	desiredResources, err := parseResources(desired)
+	if err != nil {
+		return gitopstypes.ResourceDrift{}, errors.Wrap(err, "failed to parse desired manifests")
+	}
+	actualResources, err := parseResources(actual)
+	if err != nil {
+		return gitopstypes.ResourceDrift{}, errors.Wrap(err, "failed to parse actual manifests")
+	}
+
+	drift := newResourceDrift()
+	for key, d := range desiredResources {
+		a, ok := actualResources[key]
+		if !ok {
+			drift.Removed = append(drift.Removed, d.resource)
+		} else if !reflect.DeepEqual(d.object, a.object) {
+			drift.Modified = append(drift.Modified, d.resource)
+		}
+	}
+	for key, a := range actualResources {
+		if _, ok := desiredResources[key]; !ok {
+			drift.Added = append(drift.Added, a.resource)
+		}
+	}
+
+	sortResourceDrift(&drift)
+	return drift, nil
+}
+
+// CompareClusterObjects returns the resources in desired that are missing from the cluster, or whose live object
+// does not contain the desired fields. Fields that are set by the cluster or by controllers are ignored.
+// Resources without a namespace are looked up in defaultNamespace.
+func CompareClusterObjects(desired []byte, defaultNamespace string, getLiveObject LiveObjectGetter) (gitopstypes.ResourceDrift, error) {
+	desiredResources, err := parseResources(desired)
+	if err != nil {
+		return gitopstypes.ResourceDrift{}, errors.Wrap(err, "failed to parse desired manifests")
+	}
+
+	drift := newResourceDrift()
+	for _, d := range desiredResources {
+		resource := d.resource
+		if resource.Namespace == "" {
+			resource.Namespace = defaultNamespace
+		}
+
+		live, err := getLiveObject(resource)
+		if err != nil {
+			return gitopstypes.ResourceDrift{}, errors.Wrapf(err, "failed to get %s %s", resource.Kind, resource.Name)
+		}
+		if live == nil {
+			drift.Removed = append(drift.Removed, d.resource)
+			continue
+		}
+
+		// round trip through json so that numbers have the same type as in the desired object
+		b, err := json.Marshal(live)
+		if err != nil {
+			return gitopstypes.ResourceDrift{}, errors.Wrap(err, "failed to marshal live object")
+		}
+		liveObject := map[string]interface{}{}
+		if err := json.Unmarshal(b, &liveObject); err != nil {
+			return gitopstypes.ResourceDrift{}, errors.Wrap(err, "failed to unmarshal live object")
+		}
+
+		desiredObject := normalizeDesiredObject(d.object)
+		if !isSubset(desiredObject, liveObject) {
+			drift.Modified = append(drift.Modified, d.resource)
+		}
+	}
+
+	sortResourceDrift(&drift)
+	return drift, nil
+}
+
+// NewClusterObjectGetter returns a LiveObjectGetter that reads objects from the cluster kotsadm runs in
+func NewClusterObjectGetter() (LiveObjectGetter, error) {
+	cfg, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discovery client")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disc))
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+
+	return func(resource gitopstypes.Resource) (map[string]interface{}, error) {
+		gv, err := schema.ParseGroupVersion(resource.APIVersion)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse api version")
+		}
+
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: resource.Kind}, gv.Version)
+		if meta.IsNoMatchError(err) {
+			// the crd is not installed, so the resource cannot exist
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to get rest mapping")
+		}
+
+		var dr dynamic.ResourceInterface
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			dr = dynamicClient.Resource(mapping.Resource).Namespace(resource.Namespace)
+		} else {
+			dr = dynamicClient.Resource(mapping.Resource)
+		}
+
+		obj, err := dr.Get(context.TODO(), resource.Name, metav1.GetOptions{})
+		if kuberneteserrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return obj.Object, nil
+	}, nil
+}
+
+func newResourceDrift() gitopstypes.ResourceDrift {
+	return gitopstypes.ResourceDrift{
+		Added:    []gitopstypes.Resource{},
+		Modified: []gitopstypes.Resource{},
+		Removed:  []gitopstypes.Resource{},
+	}
+}
+
+func parseResources(manifests []byte) (map[resourceKey]parsedResource, error) {
+	resources := map[resourceKey]parsedResource{}
+	for _, doc := range util.ConvertToSingleDocs(manifests) {
+		object := map[string]interface{}{}
+		if err := k8syaml.Unmarshal(doc, &object); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal resource")
+		}
+
+		apiVersion, _ := object["apiVersion"].(string)
+		kind, _ := object["kind"].(string)
+		metadata, _ := object["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+		if kind == "" || name == "" {
+			// comments only or not a kubernetes resource
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse api version of %s %s", kind, name)
+		}
+
+		key := resourceKey{group: gv.Group, kind: kind, namespace: namespace, name: name}
+		resources[key] = parsedResource{
+			resource: gitopstypes.Resource{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				Namespace:  namespace,
+				Name:       name,
+			},
+			object: object,
+		}
+	}
+	return resources, nil
+}
+
+// normalizeDesiredObject removes fields that are never returned by the api server as written
+func normalizeDesiredObject(object map[string]interface{}) map[string]interface{} {
+	normalized := map[string]interface{}{}
+	for k, v := range object {
+		if k == "status" {
+			continue
+		}
+		normalized[k] = v
+	}
+
+	// stringData is write only and is merged into data
+	if normalized["kind"] == "Secret" {
+		if stringData, ok := normalized["stringData"].(map[string]interface{}); ok {
+			data := map[string]interface{}{}
+			if existing, ok := normalized["data"].(map[string]interface{}); ok {
+				for k, v := range existing {
+					data[k] = v
+				}
+			}
+			for k, v := range stringData {
+				if s, ok := v.(string); ok {
+					data[k] = base64.StdEncoding.EncodeToString([]byte(s))
+				}
+			}
+			normalized["data"] = data
+			delete(normalized, "stringData")
+		}
+	}
+
+	return normalized
+}
+
+// listMergeKeys are the fields that identify the items of a list, in the order they are tried.
+// The api server and admission webhooks can add items to these lists, e.g. injected sidecars or volumes.
+var listMergeKeys = []string{"name", "containerPort", "mountPath"}
+
+// isSubset returns true if every field that is set in desired has the same value in live
+func isSubset(desired interface{}, live interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return len(d) == 0 && live == nil
+		}
+		for k, v := range d {
+			lv, ok := l[k]
+			if !ok {
+				// the api server drops null and empty values
+				if isEmptyValue(v) {
+					continue
+				}
+				return false
+			}
+			if !isSubset(v, lv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return len(d) == 0 && live == nil
+		}
+		if mergeKey := listMergeKey(d); mergeKey != "" {
+			return isKeyedListSubset(d, l, mergeKey)
+		}
+		if len(d) != len(l) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return isScalarEqual(desired, live)
+	}
+}
+
+// listMergeKey returns the merge key that is set in every item of the list, or an empty string if the items
+// are not objects identified by one of listMergeKeys.
+func listMergeKey(items []interface{}) string {
+	if len(items) == 0 {
+		return ""
+	}
+	for _, key := range listMergeKeys {
+		found := true
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			if _, ok := m[key]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return key
+		}
+	}
+	return ""
+}
+
+// isKeyedListSubset matches the items by the merge key instead of by position, and allows live to have more items.
+func isKeyedListSubset(desired []interface{}, live []interface{}, mergeKey string) bool {
+	liveItems := map[string]interface{}{}
+	for _, item := range live {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := m[mergeKey]; ok {
+			liveItems[fmt.Sprint(v)] = m
+		}
+	}
+	for _, item := range desired {
+		liveItem, ok := liveItems[fmt.Sprint(item.(map[string]interface{})[mergeKey])]
+		if !ok {
+			return false
+		}
+		if !isSubset(item, liveItem) {
+			return false
+		}
+	}
+	return true
+}
+
+// isScalarEqual compares resource quantities by value because the api server stores them in canonical form,
+// e.g. a desired cpu of 0.5 is 500m in the live object and a memory of 1Gi can be 1073741824.
+func isScalarEqual(desired interface{}, live interface{}) bool {
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+	_, desiredIsString := desired.(string)
+	_, liveIsString := live.(string)
+	if !desiredIsString && !liveIsString {
+		return false
+	}
+	desiredQuantity, ok := quantityFromValue(desired)
+	if !ok {
+		return false
+	}
+	liveQuantity, ok := quantityFromValue(live)
+	if !ok {
+		return false
+	}
+	return desiredQuantity.Cmp(liveQuantity) == 0
+}
+
+func quantityFromValue(value interface{}) (resource.Quantity, bool) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		str = strconv.FormatInt(v, 10)
+	default:
+		return resource.Quantity{}, false
+	}
+	quantity, err := resource.ParseQuantity(str)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return quantity, true
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func sortResourceDrift(drift *gitopstypes.ResourceDrift) {
+	for _, resources := range [][]gitopstypes.Resource{drift.Added, drift.Modified, drift.Removed} {
+		sort.Slice(resources, func(i, j int) bool {
+			a, b := resources[i], resources[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package gitops
+
+import (
+	"testing"
+
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDesiredApp = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        resources: {}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: info
+`
+
+func TestCompareManifests(t *testing.T) {
+	repo := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        resources: {}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+	drift, err := CompareManifests([]byte(testDesiredApp), []byte(repo))
+	require.NoError(t, err)
+	assert.Equal(t, gitopstypes.ResourceDrift{
+		Added:    []gitopstypes.Resource{{APIVersion: "v1", Kind: "Service", Name: "web"}},
+		Modified: []gitopstypes.Resource{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+		Removed:  []gitopstypes.Resource{{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"}},
+	}, drift)
+
+	drift, err = CompareManifests([]byte(testDesiredApp), []byte(testDesiredApp))
+	require.NoError(t, err)
+	assert.True(t, drift.IsEmpty())
+}
+
+func TestCompareClusterObjects(t *testing.T) {
+	live := map[string]map[string]interface{}{
+		"Deployment/app/web": {
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":            "web",
+				"namespace":       "app",
+				"resourceVersion": "123",
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(2),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":            "web",
+								"image":           "nginx:1.25",
+								"imagePullPolicy": "IfNotPresent",
+							},
+						},
+					},
+				},
+			},
+			"status": map[string]interface{}{"replicas": int64(2)},
+		},
+		"Secret/app/creds": {
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "creds", "namespace": "app"},
+			"data":       map[string]interface{}{"password": "aHVudGVyMg=="},
+		},
+		"ConfigMap/app/settings": {
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "settings", "namespace": "app"},
+			"data":       map[string]interface{}{"level": "debug"},
+		},
+	}
+	getter := func(resource gitopstypes.Resource) (map[string]interface{}, error) {
+		return live[resource.Kind+"/"+resource.Namespace+"/"+resource.Name], nil
+	}
+
+	drift, err := CompareClusterObjects([]byte(testDesiredApp), "app", getter)
+	require.NoError(t, err)
+	assert.Equal(t, gitopstypes.ResourceDrift{
+		Added:    []gitopstypes.Resource{},
+		Modified: []gitopstypes.Resource{{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"}},
+		Removed:  []gitopstypes.Resource{},
+	}, drift)
+
+	delete(live, "Deployment/app/web")
+	live["ConfigMap/app/settings"]["data"] = map[string]interface{}{"level": "info"}
+
+	drift, err = CompareClusterObjects([]byte(testDesiredApp), "app", getter)
+	require.NoError(t, err)
+	assert.Equal(t, gitopstypes.ResourceDrift{
+		Added:    []gitopstypes.Resource{},
+		Modified: []gitopstypes.Resource{},
+		Removed:  []gitopstypes.Resource{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+	}, drift)
+}
+
+func Test_isSubset(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired interface{}
+		live    interface{}
+		want    bool
+	}{
+		{
+			name:    "same value",
+			desired: map[string]interface{}{"replicas": float64(2)},
+			live:    map[string]interface{}{"replicas": float64(2), "paused": false},
+			want:    true,
+		},
+		{
+			name:    "different value",
+			desired: map[string]interface{}{"replicas": float64(2)},
+			live:    map[string]interface{}{"replicas": float64(3)},
+			want:    false,
+		},
+		{
+			name:    "cpu quantity in canonical form",
+			desired: map[string]interface{}{"cpu": float64(0.5)},
+			live:    map[string]interface{}{"cpu": "500m"},
+			want:    true,
+		},
+		{
+			name:    "memory quantity in bytes",
+			desired: map[string]interface{}{"memory": "1Gi"},
+			live:    map[string]interface{}{"memory": "1073741824"},
+			want:    true,
+		},
+		{
+			name:    "different quantity",
+			desired: map[string]interface{}{"memory": "1Gi"},
+			live:    map[string]interface{}{"memory": "512Mi"},
+			want:    false,
+		},
+		{
+			name:    "strings that are not quantities",
+			desired: map[string]interface{}{"image": "nginx:1.25"},
+			live:    map[string]interface{}{"image": "nginx:1.26"},
+			want:    false,
+		},
+		{
+			name: "items matched by name with items added by the server",
+			desired: []interface{}{
+				map[string]interface{}{"name": "web", "image": "nginx:1.25"},
+			},
+			live: []interface{}{
+				map[string]interface{}{"name": "istio-proxy", "image": "istio/proxyv2"},
+				map[string]interface{}{"name": "web", "image": "nginx:1.25", "imagePullPolicy": "IfNotPresent"},
+			},
+			want: true,
+		},
+		{
+			name: "item missing in live",
+			desired: []interface{}{
+				map[string]interface{}{"name": "web"},
+				map[string]interface{}{"name": "worker"},
+			},
+			live: []interface{}{
+				map[string]interface{}{"name": "web"},
+			},
+			want: false,
+		},
+		{
+			name: "item changed in live",
+			desired: []interface{}{
+				map[string]interface{}{"containerPort": float64(80), "protocol": "TCP"},
+			},
+			live: []interface{}{
+				map[string]interface{}{"containerPort": float64(80), "protocol": "UDP"},
+			},
+			want: false,
+		},
+		{
+			name:    "lists without merge keys are compared in order",
+			desired: []interface{}{"a", "b"},
+			live:    []interface{}{"b", "a"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isSubset(tt.desired, tt.live))
+		})
+	}
+}
@@ -12,16 +12,16 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	go_git_ssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/mikesmitty/edkey"
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/apparchive"
 	"github.com/replicatedhq/kots/pkg/crypto"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
@@ -33,6 +33,13 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// ActionCommit pushes commits directly to the configured branch
+	ActionCommit = "commit"
+	// ActionPullRequest pushes each version to its own branch and opens a pull request against the configured branch
+	ActionPullRequest = "pullrequest"
+)
+
 type GitOpsConfig struct {
 	Provider    string `json:"provider"`
 	RepoURI     string `json:"repoUri"`
@@ -45,16 +52,36 @@ type GitOpsConfig struct {
 	Action      string `json:"action"`
 	PublicKey   string `json:"publicKey"`
 	PrivateKey  string `json:"-"`
+	APIToken    string `json:"-"`
 	IsConnected bool   `json:"isConnected"`
+
+	AuthType                string `json:"authType"`
+	Username                string `json:"username,omitempty"`
+	Password                string `json:"-"`
+	GitHubAppID             string `json:"githubAppId,omitempty"`
+	GitHubAppInstallationID string `json:"githubAppInstallationId,omitempty"`
+	GitHubAppPrivateKey     string `json:"-"`
+	AuthorName              string `json:"authorName,omitempty"`
+	AuthorEmail             string `json:"authorEmail,omitempty"`
+	SigningFormat           string `json:"signingFormat,omitempty"`
+	SigningKey              string `json:"-"`
+	SigningKeyPassphrase    string `json:"-"`
 }
 
 type GlobalGitOpsConfig struct {
-	Enabled  bool   `json:"enabled"`
-	Hostname string `json:"hostname"`
-	HTTPPort string `json:"httpPort"`
-	SSHPort  string `json:"sshPort"`
-	Provider string `json:"provider"`
-	URI      string `json:"uri"`
+	Enabled                 bool   `json:"enabled"`
+	Hostname                string `json:"hostname"`
+	HTTPPort                string `json:"httpPort"`
+	SSHPort                 string `json:"sshPort"`
+	Provider                string `json:"provider"`
+	URI                     string `json:"uri"`
+	AuthType                string `json:"authType"`
+	Username                string `json:"username,omitempty"`
+	GitHubAppID             string `json:"githubAppId,omitempty"`
+	GitHubAppInstallationID string `json:"githubAppInstallationId,omitempty"`
+	AuthorName              string `json:"authorName,omitempty"`
+	AuthorEmail             string `json:"authorEmail,omitempty"`
+	SigningFormat           string `json:"signingFormat,omitempty"`
 }
 
 type KeyPair struct {
@@ -79,22 +106,13 @@ func (g *GitOpsConfig) CommitURL(hash string) string {
 }
 
 func (g *GitOpsConfig) CloneURL() (string, error) {
-	// copied this logic from node js api
-	uriParts := strings.Split(g.RepoURI, "/")
-
-	if len(uriParts) < 5 {
-		return "", errors.Errorf("unexpected url format: %s", g.RepoURI)
+	owner, repo, err := g.repoOwnerAndName()
+	if err != nil {
+		return "", err
 	}
 
-	owner := uriParts[3]
-	repo := uriParts[4]
-
-	if g.Provider == "bitbucket_server" {
-		if len(uriParts) < 7 {
-			return "", errors.Errorf("unexpected bitbucket server url format: %s", g.RepoURI)
-		}
-		owner = uriParts[4]
-		repo = uriParts[6]
+	if g.AuthType == AuthTypeHTTPS || g.AuthType == AuthTypeGitHubApp {
+		return g.httpsCloneURL(owner, repo)
 	}
 
 	switch g.Provider {
@@ -106,13 +124,58 @@ func (g *GitOpsConfig) CloneURL() (string, error) {
 		return fmt.Sprintf("git@bitbucket.org:%s/%s.git", owner, repo), nil
 	case "bitbucket_server":
 		return fmt.Sprintf("git@%s:%s/%s/%s.git", g.Hostname, g.SSHPort, owner, repo), nil
-	case "github_enterprise", "gitlab_enterprise":
+	case "github_enterprise", "gitlab_enterprise", "gitea":
 		return fmt.Sprintf("git@%s:%s/%s.git", g.Hostname, owner, repo), nil
 	}
 
 	return "", errors.Errorf("unsupported provider type: %s", g.Provider)
 }
 
+func (g *GitOpsConfig) httpsCloneURL(owner string, repo string) (string, error) {
+	host := g.Hostname
+	if g.HTTPPort != "" {
+		host = fmt.Sprintf("%s:%s", g.Hostname, g.HTTPPort)
+	}
+
+	switch g.Provider {
+	case "github":
+		return fmt.Sprintf("https://github.com/%s/%s.git", owner, repo), nil
+	case "gitlab":
+		return fmt.Sprintf("https://gitlab.com/%s/%s.git", owner, repo), nil
+	case "bitbucket":
+		return fmt.Sprintf("https://bitbucket.org/%s/%s.git", owner, repo), nil
+	case "bitbucket_server":
+		return fmt.Sprintf("https://%s/scm/%s/%s.git", host, owner, repo), nil
+	case "github_enterprise", "gitlab_enterprise", "gitea":
+		return fmt.Sprintf("https://%s/%s/%s.git", host, owner, repo), nil
+	}
+
+	return "", errors.Errorf("unsupported provider type: %s", g.Provider)
+}
+
+// repoOwnerAndName returns the owner (the project for bitbucket server) and the name of the repo
+func (g *GitOpsConfig) repoOwnerAndName() (string, string, error) {
+	// copied this logic from node js api
+	uriParts := strings.Split(g.RepoURI, "/")
+
+	if len(uriParts) < 5 {
+		return "", "", errors.Errorf("unexpected url format: %s", g.RepoURI)
+	}
+
+	owner := uriParts[3]
+	repo := uriParts[4]
+
+	if g.Provider == "bitbucket_server" {
+		if len(uriParts) < 7 {
+			return "", "", errors.Errorf("unexpected bitbucket server url format: %s", g.RepoURI)
+		}
+		owner = uriParts[4]
+		repo = uriParts[6]
+	}
+
+	return owner, repo, nil
+}
+
 // GetDownstreamGitOps will return the gitops config for a downstream,
 // This implementation copies how it works in typescript.
 func GetDownstreamGitOps(appID string, clusterID string) (*GitOpsConfig, error) {
@@ -192,6 +255,10 @@ func GetDownstreamGitOpsConfig(clientset kubernetes.Interface, appID string, clu
 					Action:     configMapData["action"],
 				}
 
+				if err := setCredentialsFromSecretData(&gitOpsConfig, idx, secret.Data); err != nil {
+					return nil, errors.Wrap(err, "failed to get credentials")
+				}
+
 				if lastError, ok := configMapData["lastError"]; ok && lastError == "" {
 					gitOpsConfig.IsConnected = true
 				}
@@ -451,7 +518,7 @@ func SetGitOpsError(appID string, clusterID string, errMsg string) error {
 // TestGitOpsConnection will attempt a clone of the target gitops repo.
 // It returns the default branch name from the clone.
 func TestGitOpsConnection(gitOpsConfig *GitOpsConfig) (string, error) {
-	auth, err := getAuth(gitOpsConfig.PrivateKey)
+	auth, err := getAuth(gitOpsConfig)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get auth")
 	}
@@ -484,17 +551,19 @@ func TestGitOpsConnection(gitOpsConfig *GitOpsConfig) (string, error) {
 	return ref.Name().Short(), nil
 }
 
-func CreateGitOps(provider string, repoURI string, hostname string, httpPort string, sshPort string) error {
+// CreateGitOps adds the provider and repo to the gitops secret. The api token is only needed to open pull requests,
+// KeepExistingValue keeps the stored token and an empty token removes it.
+func CreateGitOps(provider string, repoURI string, hostname string, httpPort string, sshPort string, apiToken string, credentials Credentials) error {
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
 		return errors.Wrap(err, "failed to get k8s client set")
 	}
 
-	err = createGitOps(clientset, provider, repoURI, hostname, httpPort, sshPort)
+	err = createGitOps(clientset, provider, repoURI, hostname, httpPort, sshPort, apiToken, credentials)
 	return errors.Wrap(err, "failed to create gitops")
 }
 
-func createGitOps(clientset kubernetes.Interface, provider string, repoURI string, hostname string, httpPort string, sshPort string) error {
+func createGitOps(clientset kubernetes.Interface, provider string, repoURI string, hostname string, httpPort string, sshPort string, apiToken string, credentials Credentials) error {
 	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
 	if err != nil && !kuberneteserrors.IsNotFound(err) {
 		return errors.Wrap(err, "failed to get secret")
@@ -559,6 +628,16 @@ func createGitOps(clientset kubernetes.Interface, provider string, repoURI strin
 		secretData[fmt.Sprintf("provider.%d.publicKey", repoIdx)] = []byte(keyPair.PublicKeySSH)
 	}
 
+	apiTokenKey := fmt.Sprintf("provider.%d.apiToken", repoIdx)
+	if apiToken != KeepExistingValue {
+		delete(secretData, apiTokenKey)
+		if apiToken != "" {
+			secretData[apiTokenKey] = encryptSecretValue(apiToken)
+		}
+	}
+
+	setCredentialsInSecretData(secretData, repoIdx, credentials)
+
 	hostnameKey := fmt.Sprintf("provider.%d.hostname", repoIdx)
 	_, ok := secretData[hostnameKey]
 	if ok {
@@ -649,6 +728,14 @@ func GetGitOps() (GlobalGitOpsConfig, error) {
 		Hostname: string(secret.Data["provider.0.hostname"]),
 		HTTPPort: string(secret.Data["provider.0.httpPort"]),
 		SSHPort:  string(secret.Data["provider.0.sshPort"]),
+		AuthType: string(secret.Data["provider.0.authType"]),
+		Username: string(secret.Data["provider.0.username"]),
+
+		GitHubAppID:             string(secret.Data["provider.0.githubAppId"]),
+		GitHubAppInstallationID: string(secret.Data["provider.0.githubAppInstallationId"]),
+		AuthorName:              string(secret.Data["provider.0.authorName"]),
+		AuthorEmail:             string(secret.Data["provider.0.authorEmail"]),
+		SigningFormat:           string(secret.Data["provider.0.signingFormat"]),
 	}
 
 	return parsedConfig, nil
@@ -701,43 +788,62 @@ func gitOpsConfigFromSecretData(idx int64, secretData map[string][]byte) (string
 	return provider, publicKey, privateKey, repoURI, hostname, httpPort, sshPort
 }
 
-func getAuth(privateKey string) (transport.AuthMethod, error) {
-	var auth transport.AuthMethod
-	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+// CreateGitOpsCommit commits the rendered app to the configured branch, or in pull request mode to a branch
+// for the version with a pull request opened against the configured branch. It returns nil if nothing changed.
+func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName string, newSequence int, archiveDir string, downstreamName string) (*gitopstypes.CommitResult, error) {
+	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse deploy key")
+		return nil, errors.Wrap(err, "failed to load kots kinds")
 	}
-	auth = &go_git_ssh.PublicKeys{User: "git", Signer: signer}
-	auth.(*go_git_ssh.PublicKeys).HostKeyCallback = ssh.InsecureIgnoreHostKey()
-	return auth, nil
-}
 
-func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName string, newSequence int, archiveDir string, downstreamName string) (string, error) {
-	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
+	out, _, err := apparchive.GetRenderedApp(archiveDir, downstreamName, kotsKinds.GetKustomizeBinaryPath())
 	if err != nil {
-		return "", errors.Wrap(err, "failed to load kots kinds")
+		return nil, errors.Wrap(err, "failed to get rendered app")
 	}
 
-	out, _, err := apparchive.GetRenderedApp(archiveDir, downstreamName, kotsKinds.GetKustomizeBinaryPath())
+	var v1Beta2Charts map[string][]byte
+	if gitOpsConfig.Format == FormatMultiFile || gitOpsConfig.Format == FormatKustomize {
+		v1Beta2Charts, err = apparchive.GetV1Beta2ChartsFileMap(archiveDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get v1beta2 charts")
+		}
+	}
+
+	files, err := RepoFiles(gitOpsConfig.Format, appSlug, out, v1Beta2Charts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get repo files")
+	}
+
+	isPullRequest := gitOpsConfig.Action == ActionPullRequest
+
+	var prProvider PullRequestProvider
+	if isPullRequest {
+		// fail before pushing anything if pull requests cannot be opened
+		prProvider, err = gitOpsConfig.PullRequestProvider()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get pull request provider")
+		}
+	}
+
+	signer, err := gitOpsConfig.commitSigner()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get rendered app")
+		return nil, errors.Wrap(err, "failed to get commit signer")
 	}
 
-	// using the deploy key, create the commit in a new branch
-	auth, err := getAuth(gitOpsConfig.PrivateKey)
+	auth, err := getAuth(gitOpsConfig)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get auth")
+		return nil, errors.Wrap(err, "failed to get auth")
 	}
 
 	workDir, err := ioutil.TempDir("", "kotsadm")
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create temp dir")
+		return nil, errors.Wrap(err, "failed to create temp dir")
 	}
 	defer os.RemoveAll(workDir)
 
 	cloneURL, err := gitOpsConfig.CloneURL()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get clone url")
+		return nil, errors.Wrap(err, "failed to get clone url")
 	}
 
 	cloneOptions := &git.CloneOptions{
@@ -748,64 +854,101 @@ func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName stri
 	}
 	cloned, workTree, err := CloneAndCheckout(workDir, cloneOptions, gitOpsConfig.Branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	dirPath := filepath.Join(workDir, gitOpsConfig.Path)
-	_, err = os.Stat(dirPath)
-	if os.IsNotExist(err) {
-		// create subdirectory if not exist
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to mkdir")
-		}
-	} // ignore error here and let the stat of the file below handle any errors
-
-	filePath := filepath.Join(dirPath, fmt.Sprintf("%s.yaml", appSlug))
-	_, err = os.Stat(filePath)
-	if err == nil { // if the file has not changed, end now
-		currentRevision, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to read current app yaml")
+	branch := gitOpsConfig.Branch
+	if isPullRequest {
+		// the version branch always starts from the target branch, so that updating a version replaces its previous commit
+		branch = PullRequestBranchName(appSlug, newSequence)
+		if err := checkoutNewBranch(cloned, workTree, branch); err != nil {
+			return nil, errors.Wrapf(err, "failed to create branch %s", branch)
 		}
-		if string(currentRevision) == string(out) {
-			return "", nil
-		}
-	} else if !os.IsNotExist(err) {
-		return "", errors.Wrap(err, "failed to stat current app yaml")
 	}
 
-	err = ioutil.WriteFile(filePath, out, 0644)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to write updated app yaml")
+	if err := writeRepoFiles(workTree, workDir, gitOpsConfig.Path, gitOpsConfig.Format, appSlug, files); err != nil {
+		return nil, errors.Wrap(err, "failed to write app files")
 	}
 
-	_, err = workTree.Add(strings.TrimPrefix(filepath.Join(gitOpsConfig.Path, fmt.Sprintf("%s.yaml", appSlug)), "/"))
+	status, err := workTree.Status()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to add to worktree")
+		return nil, errors.Wrap(err, "failed to get worktree status")
+	}
+	if status.IsClean() { // if the files have not changed, end now
+		return nil, nil
 	}
 
 	// commit it
-	updatedHash, err := workTree.Commit(fmt.Sprintf("Updating %s to version %d", appName, newSequence), &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "KOTS Admin Console",
-			Email: "help@replicated.com",
-			When:  time.Now(),
-		},
+	commitMessage := fmt.Sprintf("Updating %s to version %d", appName, newSequence)
+	updatedHash, err := signer.commit(cloned, workTree, commitMessage, &git.CommitOptions{
+		Author: gitOpsConfig.authorSignature(),
 	})
 	if err != nil {
-		return "", errors.Wrap(err, "failed to commit")
+		return nil, errors.Wrap(err, "failed to commit")
 	}
 
-	err = cloned.Push(&git.PushOptions{
+	pushOptions := &git.PushOptions{
 		RemoteName: cloneOptions.RemoteName,
 		Auth:       auth,
+	}
+	if isPullRequest {
+		pushOptions.RefSpecs = []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", branch, branch)),
+		}
+	}
+	err = cloned.Push(pushOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to push")
+	}
+
+	result := &gitopstypes.CommitResult{
+		CommitURL: gitOpsConfig.CommitURL(updatedHash.String()),
+	}
+
+	if isPullRequest {
+		pr, err := prProvider.FindOpenPullRequest(branch, gitOpsConfig.Branch)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find open pull request")
+		}
+		if pr == nil {
+			pr, err = prProvider.CreatePullRequest(PullRequestOptions{
+				Title:       commitMessage,
+				Description: fmt.Sprintf("Created by the admin console for version %d of %s.", newSequence, appName),
+				HeadBranch:  branch,
+				BaseBranch:  gitOpsConfig.Branch,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create pull request")
+			}
+		}
+		pr.Branch = branch
+		result.PullRequest = pr
+	}
+
+	return result, nil
+}
+
+// PullRequestBranchName returns the branch that a version is pushed to in pull request mode
+func PullRequestBranchName(appSlug string, sequence int) string {
+	return fmt.Sprintf("kots/%s/version-%d", appSlug, sequence)
+}
+
+func checkoutNewBranch(r *git.Repository, workTree *git.Worktree, branch string) error {
+	head, err := r.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to get HEAD ref, the target branch must exist to open pull requests")
+	}
+
+	err = workTree.Checkout(&git.CheckoutOptions{
+		Hash:   head.Hash(),
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: true,
 	})
 	if err != nil {
-		return "", errors.Wrap(err, "failed to push")
+		return errors.Wrap(err, "failed to checkout")
 	}
 
-	return gitOpsConfig.CommitURL(updatedHash.String()), nil
+	return nil
 }
 
 func generatePrivateKey_ed25519() (*KeyPair, error) {
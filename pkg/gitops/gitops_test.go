@@ -1,13 +1,16 @@
 package gitops
 
 import (
+	"context"
 	"encoding/base64"
 	"testing"
 
+	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -78,7 +81,7 @@ func Test_createGitOps(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := createGitOps(clientset, test.provider, test.repoURI, test.hostname, test.httpPort, test.sshPort)
+			err := createGitOps(clientset, test.provider, test.repoURI, test.hostname, test.httpPort, test.sshPort, "", Credentials{})
 			assert.NoError(t, err)
 
 			err = updateDownstreamGitOps(clientset, test.appID, test.clusterID, test.repoURI, test.branch, test.path, test.format, test.action)
@@ -335,3 +338,121 @@ func Test_isGitOpsRepoConfiguredForMultipleApps(t *testing.T) {
 		})
 	}
 }
+
+func Test_createGitOpsCredentials(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	repoURI := "https://github.com/test_org/test_repo"
+
+	err := createGitOps(clientset, "github", repoURI, "", "", "", "", Credentials{
+		AuthType:      AuthTypeHTTPS,
+		Username:      "bot",
+		Password:      "token-1",
+		AuthorName:    "Release Bot",
+		AuthorEmail:   "bot@example.com",
+		SigningFormat: SigningFormatSSH,
+		SigningKey:    "signing-key",
+	})
+	assert.NoError(t, err)
+	err = updateDownstreamGitOps(clientset, "app", "cluster", repoURI, "main", "", "", "")
+	assert.NoError(t, err)
+
+	config, err := GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, AuthTypeHTTPS, config.AuthType)
+	assert.Equal(t, "bot", config.Username)
+	assert.Equal(t, "token-1", config.Password)
+	assert.Equal(t, "Release Bot", config.AuthorName)
+	assert.Equal(t, "bot@example.com", config.AuthorEmail)
+	assert.Equal(t, SigningFormatSSH, config.SigningFormat)
+	assert.Equal(t, "signing-key", config.SigningKey)
+
+	cloneURL, err := config.CloneURL()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/test_org/test_repo.git", cloneURL)
+
+	// kept values are not changed, empty values are removed and signing is turned off without a format
+	err = createGitOps(clientset, "github", repoURI, "", "", "", "", Credentials{
+		AuthType:             AuthTypeHTTPS,
+		Username:             KeepExistingValue,
+		Password:             KeepExistingValue,
+		AuthorName:           "",
+		AuthorEmail:          KeepExistingValue,
+		SigningKey:           KeepExistingValue,
+		SigningKeyPassphrase: KeepExistingValue,
+	})
+	assert.NoError(t, err)
+
+	config, err = GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", config.Username)
+	assert.Equal(t, "token-1", config.Password)
+	assert.Equal(t, "", config.AuthorName)
+	assert.Equal(t, "bot@example.com", config.AuthorEmail)
+	assert.Equal(t, "", config.SigningFormat)
+	assert.Equal(t, "", config.SigningKey)
+
+	signer, err := config.commitSigner()
+	assert.NoError(t, err)
+	assert.Nil(t, signer)
+	assert.Equal(t, "KOTS Admin Console", config.authorSignature().Name)
+
+	// credentials that are not used by the new auth type are removed
+	err = createGitOps(clientset, "github", repoURI, "", "", "", "", Credentials{
+		AuthType:                AuthTypeGitHubApp,
+		Username:                KeepExistingValue,
+		Password:                KeepExistingValue,
+		GitHubAppID:             "1",
+		GitHubAppInstallationID: "2",
+		AuthorEmail:             KeepExistingValue,
+	})
+	assert.NoError(t, err)
+
+	config, err = GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, AuthTypeGitHubApp, config.AuthType)
+	assert.Equal(t, "", config.Username)
+	assert.Equal(t, "", config.Password)
+	assert.Equal(t, "1", config.GitHubAppID)
+	assert.Equal(t, "2", config.GitHubAppInstallationID)
+	assert.Equal(t, "bot@example.com", config.AuthorEmail)
+
+	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, secret.Data, "provider.0.username")
+	assert.NotContains(t, secret.Data, "provider.0.password")
+	assert.NotContains(t, secret.Data, "provider.0.signingKey")
+}
+
+func Test_createGitOpsAPIToken(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	repoURI := "https://github.com/test_org/test_repo"
+
+	err := createGitOps(clientset, "github", repoURI, "", "", "", "token-1", Credentials{})
+	assert.NoError(t, err)
+	err = updateDownstreamGitOps(clientset, "app", "cluster", repoURI, "main", "", "", "")
+	assert.NoError(t, err)
+
+	config, err := GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", config.APIToken)
+
+	// a kept token is not changed
+	err = createGitOps(clientset, "github", repoURI, "", "", "", KeepExistingValue, Credentials{})
+	assert.NoError(t, err)
+
+	config, err = GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", config.APIToken)
+
+	// an empty token is removed
+	err = createGitOps(clientset, "github", repoURI, "", "", "", "", Credentials{})
+	assert.NoError(t, err)
+
+	config, err = GetDownstreamGitOpsConfig(clientset, "app", "cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "", config.APIToken)
+
+	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, secret.Data, "provider.0.apiToken")
+}
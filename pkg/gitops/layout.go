@@ -0,0 +1,304 @@
+package gitops
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	"gopkg.in/yaml.v2"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// Repo layouts that the rendered app can be written in
+const (
+	// FormatSingle writes all resources to <path>/<appSlug>.yaml
+	FormatSingle = "single"
+	// FormatMultiFile writes each resource to <path>/<appSlug>/manifests/<kind>/<namespace>/<name>.yaml and
+	// v1beta2 helm charts with their values to <path>/<appSlug>/helm/<chart>/
+	FormatMultiFile = "multifile"
+	// FormatKustomize is FormatMultiFile with a kustomization.yaml that lists the manifests and the helm charts.
+	// Each chart has its own kustomization.yaml that inflates the chart, which requires kustomize build --enable-helm.
+	FormatKustomize = "kustomize"
+
+	// clusterScopedDirName is used in place of the namespace directory for cluster scoped resources
+	clusterScopedDirName = "_cluster"
+
+	// generatedFilesDirName is the directory under the configured path that lists the files written for each app,
+	// so that only files written by the admin console are removed when they are no longer part of the app
+	generatedFilesDirName = ".kots"
+)
+
+func IsValidFormat(format string) bool {
+	switch format {
+	case "", FormatSingle, FormatMultiFile, FormatKustomize:
+		return true
+	}
+	return false
+}
+
+// RepoFiles returns the files to write for the app, keyed by their path relative to the configured path in the repo
+func RepoFiles(format string, appSlug string, rendered []byte, v1Beta2Charts map[string][]byte) (map[string][]byte, error) {
+	if format == "" || format == FormatSingle {
+		return map[string][]byte{
+			fmt.Sprintf("%s.yaml", appSlug): rendered,
+		}, nil
+	}
+
+	manifests, err := splitManifests(rendered)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to split manifests")
+	}
+
+	files := map[string][]byte{}
+	for filename, content := range manifests {
+		files[path.Join(appSlug, "manifests", filename)] = content
+	}
+	for filename, content := range v1Beta2Charts {
+		files[path.Join(appSlug, "helm", filepath.ToSlash(filename))] = content
+	}
+
+	if format == FormatKustomize {
+		kustomization := kustomizetypes.Kustomization{
+			TypeMeta: kustomizetypes.TypeMeta{
+				APIVersion: "kustomize.config.k8s.io/v1beta1",
+				Kind:       "Kustomization",
+			},
+		}
+		for filename := range manifests {
+			kustomization.Resources = append(kustomization.Resources, path.Join("manifests", filename))
+		}
+
+		chartKustomizations, err := helmChartKustomizations(v1Beta2Charts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get helm chart kustomizations")
+		}
+		for chartDir, content := range chartKustomizations {
+			files[path.Join(appSlug, "helm", chartDir, "kustomization.yaml")] = content
+			kustomization.Resources = append(kustomization.Resources, path.Join("helm", chartDir))
+		}
+		sort.Strings(kustomization.Resources)
+
+		b, err := k8syaml.Marshal(kustomization)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal kustomization")
+		}
+		files[path.Join(appSlug, "kustomization.yaml")] = b
+	}
+
+	return files, nil
+}
+
+// helmChartKustomizations returns a kustomization.yaml for each chart directory that inflates the chart archive
+// with the values in the directory. The release name of the chart is the name of its directory.
+func helmChartKustomizations(v1Beta2Charts map[string][]byte) (map[string][]byte, error) {
+	archives := map[string]string{}
+	for filename := range v1Beta2Charts {
+		chartDir, name := path.Split(filepath.ToSlash(filename))
+		chartDir = strings.TrimSuffix(chartDir, "/")
+		if chartDir == "" || strings.Contains(chartDir, "/") || !strings.HasSuffix(name, ".tgz") {
+			continue
+		}
+		archives[chartDir] = name
+	}
+
+	kustomizations := map[string][]byte{}
+	for chartDir, archive := range archives {
+		kustomization := kustomizetypes.Kustomization{
+			TypeMeta: kustomizetypes.TypeMeta{
+				APIVersion: "kustomize.config.k8s.io/v1beta1",
+				Kind:       "Kustomization",
+			},
+			HelmGlobals: &kustomizetypes.HelmGlobals{
+				ChartHome: ".",
+			},
+			HelmCharts: []kustomizetypes.HelmChart{
+				{
+					Name:        archive,
+					ReleaseName: chartDir,
+					ValuesFile:  "values.yaml",
+					IncludeCRDs: true,
+				},
+			},
+		}
+
+		b, err := k8syaml.Marshal(kustomization)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal kustomization for %s", chartDir)
+		}
+		kustomizations[chartDir] = b
+	}
+
+	return kustomizations, nil
+}
+
+// splitManifests returns each resource keyed by <kind>/<namespace>/<name>.yaml
+func splitManifests(rendered []byte) (map[string][]byte, error) {
+	manifests := map[string][]byte{}
+	for _, doc := range util.ConvertToSingleDocs(rendered) {
+		doc = []byte(strings.TrimPrefix(string(doc), "---\n"))
+
+		o := util.OverlySimpleGVK{}
+		if err := yaml.Unmarshal(doc, &o); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal resource")
+		}
+		if o.Kind == "" || o.Metadata.Name == "" {
+			// comments only or not a kubernetes resource
+			continue
+		}
+
+		namespace := o.Metadata.Namespace
+		if namespace == "" {
+			namespace = clusterScopedDirName
+		}
+
+		filename := path.Join(strings.ToLower(o.Kind), namespace, fmt.Sprintf("%s.yaml", o.Metadata.Name))
+		for i := 1; ; i++ {
+			if _, exists := manifests[filename]; !exists {
+				break
+			}
+			// the same resource in different api groups, such as an ingress in extensions and networking.k8s.io
+			filename = path.Join(strings.ToLower(o.Kind), namespace, fmt.Sprintf("%s-%d.yaml", o.Metadata.Name, i))
+		}
+
+		manifests[filename] = doc
+	}
+	return manifests, nil
+}
+
+// writeRepoFiles writes the files of the app to the work tree and stages them. The layouts with multiple files also write
+// the list of files that were written, the single file format does not need one and removes a list left by a previous format.
+// Files that were previously written for the app in any format and that are not part of the new files are removed.
+// Other files, including files that users added to the app directory, are not changed.
+func writeRepoFiles(workTree *git.Worktree, workDir string, repoPath string, format string, appSlug string, files map[string][]byte) error {
+	repoPath = strings.TrimPrefix(repoPath, "/")
+
+	generatedFiles, err := listGeneratedRepoFiles(workDir, repoPath, appSlug)
+	if err != nil {
+		return errors.Wrap(err, "failed to list generated files")
+	}
+
+	for _, generated := range generatedFiles {
+		if _, ok := files[generated]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(workDir, repoPath, filepath.FromSlash(generated))); os.IsNotExist(err) {
+			// removed from the repo by a user
+			continue
+		}
+		if _, err := workTree.Remove(path.Join(repoPath, generated)); err != nil {
+			return errors.Wrapf(err, "failed to remove %s", generated)
+		}
+	}
+
+	filenames := []string{}
+	for filename, content := range files {
+		filePath := filepath.Join(workDir, repoPath, filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return errors.Wrap(err, "failed to mkdir")
+		}
+		if err := ioutil.WriteFile(filePath, content, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", filename)
+		}
+		if _, err := workTree.Add(path.Join(repoPath, filename)); err != nil {
+			return errors.Wrapf(err, "failed to add %s to worktree", filename)
+		}
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	generatedFilesList := generatedFilesListPath(appSlug)
+	filePath := filepath.Join(workDir, repoPath, filepath.FromSlash(generatedFilesList))
+
+	if format == "" || format == FormatSingle {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to stat generated files list")
+		}
+		if _, err := workTree.Remove(path.Join(repoPath, generatedFilesList)); err != nil {
+			return errors.Wrap(err, "failed to remove generated files list")
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errors.Wrap(err, "failed to mkdir")
+	}
+	if err := ioutil.WriteFile(filePath, []byte(strings.Join(filenames, "\n")+"\n"), 0644); err != nil {
+		return errors.Wrap(err, "failed to write generated files list")
+	}
+	if _, err := workTree.Add(path.Join(repoPath, generatedFilesList)); err != nil {
+		return errors.Wrap(err, "failed to add generated files list to worktree")
+	}
+
+	return nil
+}
+
+// generatedFilesListPath is the path of the list of files written for the app, relative to the configured path.
+// It is not a yaml file so that tools that apply every manifest in the path ignore it.
+func generatedFilesListPath(appSlug string) string {
+	return path.Join(generatedFilesDirName, fmt.Sprintf("%s.files", appSlug))
+}
+
+// listGeneratedRepoFiles returns the files that were written for the app, relative to the configured path. Only files in
+// the recorded list are returned. Without a list, the app was written in the single file format, which only ever writes
+// <appSlug>.yaml, so files that users added to the app directory are never treated as generated.
+func listGeneratedRepoFiles(workDir string, repoPath string, appSlug string) ([]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(workDir, repoPath, filepath.FromSlash(generatedFilesListPath(appSlug))))
+	if err == nil {
+		files := []string{}
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, line)
+			}
+		}
+		return files, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to read generated files list")
+	}
+
+	singleFile := fmt.Sprintf("%s.yaml", appSlug)
+	if _, err := os.Stat(filepath.Join(workDir, repoPath, singleFile)); os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to stat app yaml")
+	}
+
+	return []string{singleFile}, nil
+}
+
+// listAppRepoFiles returns the files that belong to the app in the repo, relative to the configured path
+func listAppRepoFiles(workDir string, repoPath string, appSlug string) ([]string, error) {
+	files := []string{}
+
+	singleFile := fmt.Sprintf("%s.yaml", appSlug)
+	if _, err := os.Stat(filepath.Join(workDir, repoPath, singleFile)); err == nil {
+		files = append(files, singleFile)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to stat app yaml")
+	}
+
+	appDir := filepath.Join(workDir, repoPath, appSlug)
+	if _, err := os.Stat(appDir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	filesMap, err := util.GetFilesMap(appDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get files map")
+	}
+	for filename := range filesMap {
+		files = append(files, path.Join(appSlug, filepath.ToSlash(filename)))
+	}
+
+	return files, nil
+}
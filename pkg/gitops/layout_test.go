@@ -0,0 +1,215 @@
+package gitops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRenderedApp = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: app
+---
+# Source: comments only
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: app
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`
+
+func TestRepoFiles(t *testing.T) {
+	charts := map[string][]byte{
+		"redis/redis-1.0.0.tgz": []byte("chart"),
+		"redis/values.yaml":     []byte("replicas: 1\n"),
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   map[string][]byte
+	}{
+		{
+			name:   "single",
+			format: FormatSingle,
+			want: map[string][]byte{
+				"my-app.yaml": []byte(testRenderedApp),
+			},
+		},
+		{
+			name:   "empty format is single",
+			format: "",
+			want: map[string][]byte{
+				"my-app.yaml": []byte(testRenderedApp),
+			},
+		},
+		{
+			name:   "multifile",
+			format: FormatMultiFile,
+			want: map[string][]byte{
+				"my-app/manifests/deployment/app/web.yaml":          []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: app"),
+				"my-app/manifests/service/app/web.yaml":             []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: app"),
+				"my-app/manifests/clusterrole/_cluster/reader.yaml": []byte("apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: reader\n"),
+				"my-app/helm/redis/redis-1.0.0.tgz":                 []byte("chart"),
+				"my-app/helm/redis/values.yaml":                     []byte("replicas: 1\n"),
+			},
+		},
+		{
+			name:   "kustomize",
+			format: FormatKustomize,
+			want: map[string][]byte{
+				"my-app/manifests/deployment/app/web.yaml":          []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: app"),
+				"my-app/manifests/service/app/web.yaml":             []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: app"),
+				"my-app/manifests/clusterrole/_cluster/reader.yaml": []byte("apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: reader\n"),
+				"my-app/helm/redis/redis-1.0.0.tgz":                 []byte("chart"),
+				"my-app/helm/redis/values.yaml":                     []byte("replicas: 1\n"),
+				"my-app/helm/redis/kustomization.yaml": []byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+helmCharts:
+- includeCRDs: true
+  name: redis-1.0.0.tgz
+  releaseName: redis
+  valuesFile: values.yaml
+helmGlobals:
+  chartHome: .
+kind: Kustomization
+`),
+				"my-app/kustomization.yaml": []byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- helm/redis
+- manifests/clusterrole/_cluster/reader.yaml
+- manifests/deployment/app/web.yaml
+- manifests/service/app/web.yaml
+`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepoFiles(tt.format, "my-app", []byte(testRenderedApp), charts)
+			require.NoError(t, err)
+
+			want := map[string]string{}
+			for k, v := range tt.want {
+				want[k] = string(v)
+			}
+			gotStr := map[string]string{}
+			for k, v := range got {
+				gotStr[k] = string(v)
+			}
+			assert.Equal(t, want, gotStr)
+		})
+	}
+}
+
+func TestWriteRepoFiles(t *testing.T) {
+	req := require.New(t)
+
+	workDir := t.TempDir()
+	repo, err := git.PlainInit(workDir, false)
+	req.NoError(err)
+	workTree, err := repo.Worktree()
+	req.NoError(err)
+
+	// a previous version in the single file format, and a file that does not belong to the app
+	req.NoError(writeRepoFiles(workTree, workDir, "/deploy", FormatSingle, "my-app", map[string][]byte{
+		"my-app.yaml": []byte("old"),
+	}))
+	_, err = os.Stat(filepath.Join(workDir, "deploy", ".kots", "my-app.files"))
+	assert.True(t, os.IsNotExist(err), "the single file format does not write a list of files")
+	req.NoError(os.WriteFile(filepath.Join(workDir, "deploy", "other-app.yaml"), []byte("other"), 0644))
+	_, err = workTree.Add("deploy/other-app.yaml")
+	req.NoError(err)
+	_, err = workTree.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com"}})
+	req.NoError(err)
+
+	// switch to multiple files
+	req.NoError(writeRepoFiles(workTree, workDir, "/deploy", FormatMultiFile, "my-app", map[string][]byte{
+		"my-app/manifests/deployment/app/web.yaml": []byte("web"),
+		"my-app/manifests/service/app/web.yaml":    []byte("svc"),
+	}))
+	// a file that a user added to the app directory
+	req.NoError(os.WriteFile(filepath.Join(workDir, "deploy", "my-app", "README.md"), []byte("readme"), 0644))
+	_, err = workTree.Add("deploy/my-app/README.md")
+	req.NoError(err)
+	_, err = workTree.Commit("multifile", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com"}})
+	req.NoError(err)
+
+	// the service was removed from the app
+	req.NoError(writeRepoFiles(workTree, workDir, "/deploy", FormatMultiFile, "my-app", map[string][]byte{
+		"my-app/manifests/deployment/app/web.yaml": []byte("web v2"),
+	}))
+
+	status, err := workTree.Status()
+	req.NoError(err)
+	assert.Equal(t, git.Modified, status.File("deploy/my-app/manifests/deployment/app/web.yaml").Staging)
+	assert.Equal(t, git.Deleted, status.File("deploy/my-app/manifests/service/app/web.yaml").Staging)
+	assert.Equal(t, git.Modified, status.File("deploy/.kots/my-app.files").Staging)
+	assert.Len(t, status, 3)
+
+	files, err := listGeneratedRepoFiles(workDir, "deploy", "my-app")
+	req.NoError(err)
+	assert.Equal(t, []string{"my-app/manifests/deployment/app/web.yaml"}, files)
+
+	_, err = os.Stat(filepath.Join(workDir, "deploy", "my-app.yaml"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(workDir, "deploy", "other-app.yaml"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(workDir, "deploy", "my-app", "README.md"))
+	assert.NoError(t, err)
+
+	// switch back to a single file
+	_, err = workTree.Commit("service removed", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com"}})
+	req.NoError(err)
+	req.NoError(writeRepoFiles(workTree, workDir, "/deploy", FormatSingle, "my-app", map[string][]byte{
+		"my-app.yaml": []byte("new"),
+	}))
+
+	status, err = workTree.Status()
+	req.NoError(err)
+	assert.Equal(t, git.Added, status.File("deploy/my-app.yaml").Staging)
+	assert.Equal(t, git.Deleted, status.File("deploy/my-app/manifests/deployment/app/web.yaml").Staging)
+	assert.Equal(t, git.Deleted, status.File("deploy/.kots/my-app.files").Staging)
+	assert.Len(t, status, 3)
+}
+
+func TestListGeneratedRepoFiles(t *testing.T) {
+	req := require.New(t)
+
+	// a repo that was written in the single file format, with files that users added to the app directory
+	workDir := t.TempDir()
+	for _, filename := range []string{
+		"deploy/my-app.yaml",
+		"deploy/my-app/kustomization.yaml",
+		"deploy/my-app/manifests/deployment/app/web.yaml",
+		"deploy/my-app/helm/redis/values.yaml",
+		"deploy/my-app/patches/web.yaml",
+		"deploy/other-app.yaml",
+	} {
+		filePath := filepath.Join(workDir, filepath.FromSlash(filename))
+		req.NoError(os.MkdirAll(filepath.Dir(filePath), 0755))
+		req.NoError(os.WriteFile(filePath, []byte("content"), 0644))
+	}
+
+	files, err := listGeneratedRepoFiles(workDir, "deploy", "my-app")
+	req.NoError(err)
+	assert.Equal(t, []string{"my-app.yaml"}, files)
+
+	// nothing was written for the app yet
+	files, err = listGeneratedRepoFiles(workDir, "deploy", "new-app")
+	req.NoError(err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,472 @@
+package gitops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+)
+
+type PullRequestOptions struct {
+	Title       string
+	Description string
+	HeadBranch  string
+	BaseBranch  string
+}
+
+// PullRequestProvider opens and tracks pull requests (merge requests on GitLab) through the REST API of a git provider
+type PullRequestProvider interface {
+	CreatePullRequest(opts PullRequestOptions) (*gitopstypes.PullRequest, error)
+	// FindOpenPullRequest returns nil if there is no open pull request from the head branch to the base branch
+	FindOpenPullRequest(headBranch string, baseBranch string) (*gitopstypes.PullRequest, error)
+	GetPullRequest(number int64) (*gitopstypes.PullRequest, error)
+}
+
+// PullRequestProvider returns the provider for the repo of this config, authenticated with its api token
+func (g *GitOpsConfig) PullRequestProvider() (PullRequestProvider, error) {
+	apiToken := g.APIToken
+	if apiToken == "" && g.AuthType == AuthTypeGitHubApp {
+		// installation tokens can open pull requests when the app has the pull requests permission
+		token, err := g.gitHubAppInstallationToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get github app installation token")
+		}
+		apiToken = token
+	}
+	if apiToken == "" {
+		return nil, errors.New("an api token is required to open pull requests")
+	}
+
+	owner, repo, err := g.repoOwnerAndName()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get repo owner and name")
+	}
+
+	apiURL, err := g.APIURL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get api url")
+	}
+
+	return NewPullRequestProvider(g.Provider, apiURL, apiToken, owner, repo)
+}
+
+// APIURL returns the base url of the provider's REST API
+func (g *GitOpsConfig) APIURL() (string, error) {
+	host := g.Hostname
+	if g.HTTPPort != "" {
+		host = fmt.Sprintf("%s:%s", g.Hostname, g.HTTPPort)
+	}
+
+	switch g.Provider {
+	case "github":
+		return "https://api.github.com", nil
+	case "github_enterprise":
+		return fmt.Sprintf("https://%s/api/v3", host), nil
+	case "gitlab":
+		return "https://gitlab.com/api/v4", nil
+	case "gitlab_enterprise":
+		return fmt.Sprintf("https://%s/api/v4", host), nil
+	case "bitbucket":
+		return "https://api.bitbucket.org/2.0", nil
+	case "bitbucket_server":
+		return fmt.Sprintf("https://%s/rest/api/1.0", host), nil
+	case "gitea":
+		return fmt.Sprintf("https://%s/api/v1", host), nil
+	}
+
+	return "", errors.Errorf("unsupported provider type: %s", g.Provider)
+}
+
+func NewPullRequestProvider(provider string, apiURL string, token string, owner string, repo string) (PullRequestProvider, error) {
+	client := &providerAPIClient{
+		baseURL:    strings.TrimSuffix(apiURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	switch provider {
+	case "github", "github_enterprise", "gitea":
+		client.authHeader, client.authValue = "Authorization", fmt.Sprintf("token %s", token)
+		return &githubPullRequestProvider{client: client, owner: owner, repo: repo}, nil
+	case "gitlab", "gitlab_enterprise":
+		client.authHeader, client.authValue = "PRIVATE-TOKEN", token
+		return &gitlabPullRequestProvider{client: client, project: url.PathEscape(fmt.Sprintf("%s/%s", owner, repo))}, nil
+	case "bitbucket":
+		client.authHeader, client.authValue = "Authorization", fmt.Sprintf("Bearer %s", token)
+		return &bitbucketPullRequestProvider{client: client, owner: owner, repo: repo}, nil
+	case "bitbucket_server":
+		client.authHeader, client.authValue = "Authorization", fmt.Sprintf("Bearer %s", token)
+		return &bitbucketServerPullRequestProvider{client: client, project: owner, repo: repo}, nil
+	}
+
+	return nil, errors.Errorf("unsupported provider type: %s", provider)
+}
+
+type providerAPIClient struct {
+	baseURL    string
+	authHeader string
+	authValue  string
+	httpClient *http.Client
+}
+
+func (c *providerAPIClient) do(method string, path string, payload interface{}, response interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal request")
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set(c.authHeader, c.authValue)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status code %d from %s %s: %s", resp.StatusCode, method, path, string(respBody))
+	}
+
+	if response != nil {
+		if err := json.Unmarshal(respBody, response); err != nil {
+			return errors.Wrap(err, "failed to unmarshal response")
+		}
+	}
+
+	return nil
+}
+
+// githubPullRequestProvider also works with gitea, whose pull request api is compatible
+type githubPullRequestProvider struct {
+	client *providerAPIClient
+	owner  string
+	repo   string
+}
+
+type githubPullRequest struct {
+	Number   int64      `json:"number"`
+	HTMLURL  string     `json:"html_url"`
+	State    string     `json:"state"`
+	Merged   bool       `json:"merged"`
+	MergedAt *time.Time `json:"merged_at"`
+	Head     struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p *githubPullRequestProvider) CreatePullRequest(opts PullRequestOptions) (*gitopstypes.PullRequest, error) {
+	payload := map[string]interface{}{
+		"title": opts.Title,
+		"body":  opts.Description,
+		"head":  opts.HeadBranch,
+		"base":  opts.BaseBranch,
+	}
+	pr := githubPullRequest{}
+	if err := p.client.do(http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls", p.owner, p.repo), payload, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to create pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (p *githubPullRequestProvider) FindOpenPullRequest(headBranch string, baseBranch string) (*gitopstypes.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "open")
+	query.Set("head", fmt.Sprintf("%s:%s", p.owner, headBranch))
+	query.Set("base", baseBranch)
+	query.Set("per_page", "100")
+
+	prs := []githubPullRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls?%s", p.owner, p.repo, query.Encode()), nil, &prs); err != nil {
+		return nil, errors.Wrap(err, "failed to list pull requests")
+	}
+
+	// gitea ignores the head and base filters
+	for _, pr := range prs {
+		if pr.Head.Ref == headBranch && pr.Base.Ref == baseBranch {
+			return pr.toPullRequest(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *githubPullRequestProvider) GetPullRequest(number int64) (*gitopstypes.PullRequest, error) {
+	pr := githubPullRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d", p.owner, p.repo, number), nil, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to get pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (pr githubPullRequest) toPullRequest() *gitopstypes.PullRequest {
+	state := gitopstypes.PullRequestStateOpen
+	if pr.Merged || pr.MergedAt != nil {
+		state = gitopstypes.PullRequestStateMerged
+	} else if pr.State == "closed" {
+		state = gitopstypes.PullRequestStateClosed
+	}
+	return &gitopstypes.PullRequest{
+		Number: pr.Number,
+		URL:    pr.HTMLURL,
+		Branch: pr.Head.Ref,
+		State:  state,
+	}
+}
+
+type gitlabPullRequestProvider struct {
+	client  *providerAPIClient
+	project string
+}
+
+type gitlabMergeRequest struct {
+	IID          int64  `json:"iid"`
+	WebURL       string `json:"web_url"`
+	State        string `json:"state"`
+	SourceBranch string `json:"source_branch"`
+}
+
+func (p *gitlabPullRequestProvider) CreatePullRequest(opts PullRequestOptions) (*gitopstypes.PullRequest, error) {
+	payload := map[string]interface{}{
+		"title":         opts.Title,
+		"description":   opts.Description,
+		"source_branch": opts.HeadBranch,
+		"target_branch": opts.BaseBranch,
+	}
+	mr := gitlabMergeRequest{}
+	if err := p.client.do(http.MethodPost, fmt.Sprintf("/projects/%s/merge_requests", p.project), payload, &mr); err != nil {
+		return nil, errors.Wrap(err, "failed to create merge request")
+	}
+	return mr.toPullRequest(), nil
+}
+
+func (p *gitlabPullRequestProvider) FindOpenPullRequest(headBranch string, baseBranch string) (*gitopstypes.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "opened")
+	query.Set("source_branch", headBranch)
+	query.Set("target_branch", baseBranch)
+
+	mrs := []gitlabMergeRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/projects/%s/merge_requests?%s", p.project, query.Encode()), nil, &mrs); err != nil {
+		return nil, errors.Wrap(err, "failed to list merge requests")
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return mrs[0].toPullRequest(), nil
+}
+
+func (p *gitlabPullRequestProvider) GetPullRequest(number int64) (*gitopstypes.PullRequest, error) {
+	mr := gitlabMergeRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/projects/%s/merge_requests/%d", p.project, number), nil, &mr); err != nil {
+		return nil, errors.Wrap(err, "failed to get merge request")
+	}
+	return mr.toPullRequest(), nil
+}
+
+func (mr gitlabMergeRequest) toPullRequest() *gitopstypes.PullRequest {
+	state := gitopstypes.PullRequestStateOpen
+	switch mr.State {
+	case "merged":
+		state = gitopstypes.PullRequestStateMerged
+	case "closed":
+		state = gitopstypes.PullRequestStateClosed
+	}
+	return &gitopstypes.PullRequest{
+		Number: mr.IID,
+		URL:    mr.WebURL,
+		Branch: mr.SourceBranch,
+		State:  state,
+	}
+}
+
+type bitbucketPullRequestProvider struct {
+	client *providerAPIClient
+	owner  string
+	repo   string
+}
+
+type bitbucketBranchRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+type bitbucketPullRequest struct {
+	ID          int64              `json:"id"`
+	State       string             `json:"state"`
+	Source      bitbucketBranchRef `json:"source"`
+	Destination bitbucketBranchRef `json:"destination"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (p *bitbucketPullRequestProvider) CreatePullRequest(opts PullRequestOptions) (*gitopstypes.PullRequest, error) {
+	payload := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Description,
+		"source": map[string]interface{}{
+			"branch": map[string]string{"name": opts.HeadBranch},
+		},
+		"destination": map[string]interface{}{
+			"branch": map[string]string{"name": opts.BaseBranch},
+		},
+	}
+	pr := bitbucketPullRequest{}
+	if err := p.client.do(http.MethodPost, fmt.Sprintf("/repositories/%s/%s/pullrequests", p.owner, p.repo), payload, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to create pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (p *bitbucketPullRequestProvider) FindOpenPullRequest(headBranch string, baseBranch string) (*gitopstypes.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("q", fmt.Sprintf(`source.branch.name="%s" AND destination.branch.name="%s"`, headBranch, baseBranch))
+
+	response := struct {
+		Values []bitbucketPullRequest `json:"values"`
+	}{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", p.owner, p.repo, query.Encode()), nil, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to list pull requests")
+	}
+	for _, pr := range response.Values {
+		if pr.Source.Branch.Name == headBranch && pr.Destination.Branch.Name == baseBranch {
+			return pr.toPullRequest(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *bitbucketPullRequestProvider) GetPullRequest(number int64) (*gitopstypes.PullRequest, error) {
+	pr := bitbucketPullRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", p.owner, p.repo, number), nil, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to get pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (pr bitbucketPullRequest) toPullRequest() *gitopstypes.PullRequest {
+	return &gitopstypes.PullRequest{
+		Number: pr.ID,
+		URL:    pr.Links.HTML.Href,
+		Branch: pr.Source.Branch.Name,
+		State:  bitbucketPullRequestState(pr.State),
+	}
+}
+
+type bitbucketServerPullRequestProvider struct {
+	client  *providerAPIClient
+	project string
+	repo    string
+}
+
+type bitbucketServerRef struct {
+	ID        string `json:"id"`
+	DisplayID string `json:"displayId"`
+}
+
+type bitbucketServerPullRequest struct {
+	ID      int64              `json:"id"`
+	State   string             `json:"state"`
+	FromRef bitbucketServerRef `json:"fromRef"`
+	ToRef   bitbucketServerRef `json:"toRef"`
+	Links   struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (p *bitbucketServerPullRequestProvider) CreatePullRequest(opts PullRequestOptions) (*gitopstypes.PullRequest, error) {
+	payload := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Description,
+		"fromRef":     map[string]string{"id": fmt.Sprintf("refs/heads/%s", opts.HeadBranch)},
+		"toRef":       map[string]string{"id": fmt.Sprintf("refs/heads/%s", opts.BaseBranch)},
+	}
+	pr := bitbucketServerPullRequest{}
+	if err := p.client.do(http.MethodPost, fmt.Sprintf("/projects/%s/repos/%s/pull-requests", p.project, p.repo), payload, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to create pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (p *bitbucketServerPullRequestProvider) FindOpenPullRequest(headBranch string, baseBranch string) (*gitopstypes.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("direction", "OUTGOING")
+	query.Set("at", fmt.Sprintf("refs/heads/%s", headBranch))
+
+	response := struct {
+		Values []bitbucketServerPullRequest `json:"values"`
+	}{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/projects/%s/repos/%s/pull-requests?%s", p.project, p.repo, query.Encode()), nil, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to list pull requests")
+	}
+	for _, pr := range response.Values {
+		if pr.ToRef.ID == fmt.Sprintf("refs/heads/%s", baseBranch) {
+			return pr.toPullRequest(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *bitbucketServerPullRequestProvider) GetPullRequest(number int64) (*gitopstypes.PullRequest, error) {
+	pr := bitbucketServerPullRequest{}
+	if err := p.client.do(http.MethodGet, fmt.Sprintf("/projects/%s/repos/%s/pull-requests/%d", p.project, p.repo, number), nil, &pr); err != nil {
+		return nil, errors.Wrap(err, "failed to get pull request")
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (pr bitbucketServerPullRequest) toPullRequest() *gitopstypes.PullRequest {
+	prURL := ""
+	if len(pr.Links.Self) > 0 {
+		prURL = pr.Links.Self[0].Href
+	}
+	return &gitopstypes.PullRequest{
+		Number: pr.ID,
+		URL:    prURL,
+		Branch: pr.FromRef.DisplayID,
+		State:  bitbucketPullRequestState(pr.State),
+	}
+}
+
+func bitbucketPullRequestState(state string) gitopstypes.PullRequestState {
+	switch state {
+	case "MERGED":
+		return gitopstypes.PullRequestStateMerged
+	case "DECLINED", "SUPERSEDED":
+		return gitopstypes.PullRequestStateClosed
+	default:
+		return gitopstypes.PullRequestStateOpen
+	}
+}
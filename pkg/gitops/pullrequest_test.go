@@ -0,0 +1,249 @@
+package gitops
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// newStubServer serves the given json responses by "METHOD path" and records the requests it receives
+func newStubServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]stubRequest) {
+	requests := []stubRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := stubRequest{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.RawQuery,
+			Header: r.Header,
+		}
+		if r.Body != nil {
+			_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		}
+		requests = append(requests, req)
+
+		response, ok := responses[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPullRequestProviders(t *testing.T) {
+	tests := []struct {
+		name           string
+		provider       string
+		apiPath        string
+		responses      map[string]string
+		wantAuthHeader string
+		wantAuthValue  string
+		wantCreatePath string
+		wantCreateBody map[string]interface{}
+		wantCreated    *gitopstypes.PullRequest
+		wantFound      *gitopstypes.PullRequest
+		wantMerged     *gitopstypes.PullRequest
+	}{
+		{
+			name:     "github",
+			provider: "github",
+			responses: map[string]string{
+				"POST /repos/org/repo/pulls":  `{"number": 7, "html_url": "https://github.com/org/repo/pull/7", "state": "open", "head": {"ref": "kots/app/version-3"}, "base": {"ref": "main"}}`,
+				"GET /repos/org/repo/pulls":   `[{"number": 6, "state": "open", "head": {"ref": "other"}, "base": {"ref": "main"}}, {"number": 7, "html_url": "https://github.com/org/repo/pull/7", "state": "open", "head": {"ref": "kots/app/version-3"}, "base": {"ref": "main"}}]`,
+				"GET /repos/org/repo/pulls/7": `{"number": 7, "html_url": "https://github.com/org/repo/pull/7", "state": "closed", "merged_at": "2023-01-01T00:00:00Z", "head": {"ref": "kots/app/version-3"}}`,
+			},
+			wantAuthHeader: "Authorization",
+			wantAuthValue:  "token secret",
+			wantCreatePath: "/repos/org/repo/pulls",
+			wantCreateBody: map[string]interface{}{"title": "Update", "body": "desc", "head": "kots/app/version-3", "base": "main"},
+			wantCreated:    &gitopstypes.PullRequest{Number: 7, URL: "https://github.com/org/repo/pull/7", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantFound:      &gitopstypes.PullRequest{Number: 7, URL: "https://github.com/org/repo/pull/7", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantMerged:     &gitopstypes.PullRequest{Number: 7, URL: "https://github.com/org/repo/pull/7", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateMerged},
+		},
+		{
+			name:     "gitea",
+			provider: "gitea",
+			responses: map[string]string{
+				"POST /repos/org/repo/pulls":  `{"number": 2, "html_url": "https://gitea.local/org/repo/pulls/2", "state": "open", "merged": false, "head": {"ref": "kots/app/version-3"}}`,
+				"GET /repos/org/repo/pulls":   `[]`,
+				"GET /repos/org/repo/pulls/2": `{"number": 2, "html_url": "https://gitea.local/org/repo/pulls/2", "state": "closed", "merged": false, "head": {"ref": "kots/app/version-3"}}`,
+			},
+			wantAuthHeader: "Authorization",
+			wantAuthValue:  "token secret",
+			wantCreatePath: "/repos/org/repo/pulls",
+			wantCreateBody: map[string]interface{}{"title": "Update", "body": "desc", "head": "kots/app/version-3", "base": "main"},
+			wantCreated:    &gitopstypes.PullRequest{Number: 2, URL: "https://gitea.local/org/repo/pulls/2", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantMerged:     &gitopstypes.PullRequest{Number: 2, URL: "https://gitea.local/org/repo/pulls/2", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateClosed},
+		},
+		{
+			name:     "gitlab",
+			provider: "gitlab",
+			responses: map[string]string{
+				"POST /projects/org%2Frepo/merge_requests":  `{"iid": 3, "web_url": "https://gitlab.com/org/repo/-/merge_requests/3", "state": "opened", "source_branch": "kots/app/version-3"}`,
+				"GET /projects/org%2Frepo/merge_requests":   `[{"iid": 3, "web_url": "https://gitlab.com/org/repo/-/merge_requests/3", "state": "opened", "source_branch": "kots/app/version-3"}]`,
+				"GET /projects/org%2Frepo/merge_requests/3": `{"iid": 3, "web_url": "https://gitlab.com/org/repo/-/merge_requests/3", "state": "merged", "source_branch": "kots/app/version-3"}`,
+			},
+			wantAuthHeader: "Private-Token",
+			wantAuthValue:  "secret",
+			wantCreatePath: "/projects/org%2Frepo/merge_requests",
+			wantCreateBody: map[string]interface{}{"title": "Update", "description": "desc", "source_branch": "kots/app/version-3", "target_branch": "main"},
+			wantCreated:    &gitopstypes.PullRequest{Number: 3, URL: "https://gitlab.com/org/repo/-/merge_requests/3", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantFound:      &gitopstypes.PullRequest{Number: 3, URL: "https://gitlab.com/org/repo/-/merge_requests/3", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantMerged:     &gitopstypes.PullRequest{Number: 3, URL: "https://gitlab.com/org/repo/-/merge_requests/3", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateMerged},
+		},
+		{
+			name:     "bitbucket",
+			provider: "bitbucket",
+			responses: map[string]string{
+				"POST /repositories/org/repo/pullrequests":  `{"id": 4, "state": "OPEN", "source": {"branch": {"name": "kots/app/version-3"}}, "destination": {"branch": {"name": "main"}}, "links": {"html": {"href": "https://bitbucket.org/org/repo/pull-requests/4"}}}`,
+				"GET /repositories/org/repo/pullrequests":   `{"values": []}`,
+				"GET /repositories/org/repo/pullrequests/4": `{"id": 4, "state": "DECLINED", "source": {"branch": {"name": "kots/app/version-3"}}, "links": {"html": {"href": "https://bitbucket.org/org/repo/pull-requests/4"}}}`,
+			},
+			wantAuthHeader: "Authorization",
+			wantAuthValue:  "Bearer secret",
+			wantCreatePath: "/repositories/org/repo/pullrequests",
+			wantCreateBody: map[string]interface{}{
+				"title":       "Update",
+				"description": "desc",
+				"source":      map[string]interface{}{"branch": map[string]interface{}{"name": "kots/app/version-3"}},
+				"destination": map[string]interface{}{"branch": map[string]interface{}{"name": "main"}},
+			},
+			wantCreated: &gitopstypes.PullRequest{Number: 4, URL: "https://bitbucket.org/org/repo/pull-requests/4", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantMerged:  &gitopstypes.PullRequest{Number: 4, URL: "https://bitbucket.org/org/repo/pull-requests/4", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateClosed},
+		},
+		{
+			name:     "bitbucket server",
+			provider: "bitbucket_server",
+			responses: map[string]string{
+				"POST /projects/org/repos/repo/pull-requests":  `{"id": 5, "state": "OPEN", "fromRef": {"id": "refs/heads/kots/app/version-3", "displayId": "kots/app/version-3"}, "toRef": {"id": "refs/heads/main"}, "links": {"self": [{"href": "https://bitbucket.local/projects/org/repos/repo/pull-requests/5"}]}}`,
+				"GET /projects/org/repos/repo/pull-requests":   `{"values": [{"id": 5, "state": "OPEN", "fromRef": {"id": "refs/heads/kots/app/version-3", "displayId": "kots/app/version-3"}, "toRef": {"id": "refs/heads/main"}, "links": {"self": [{"href": "https://bitbucket.local/projects/org/repos/repo/pull-requests/5"}]}}]}`,
+				"GET /projects/org/repos/repo/pull-requests/5": `{"id": 5, "state": "MERGED", "fromRef": {"id": "refs/heads/kots/app/version-3", "displayId": "kots/app/version-3"}, "links": {"self": [{"href": "https://bitbucket.local/projects/org/repos/repo/pull-requests/5"}]}}`,
+			},
+			wantAuthHeader: "Authorization",
+			wantAuthValue:  "Bearer secret",
+			wantCreatePath: "/projects/org/repos/repo/pull-requests",
+			wantCreateBody: map[string]interface{}{
+				"title":       "Update",
+				"description": "desc",
+				"fromRef":     map[string]interface{}{"id": "refs/heads/kots/app/version-3"},
+				"toRef":       map[string]interface{}{"id": "refs/heads/main"},
+			},
+			wantCreated: &gitopstypes.PullRequest{Number: 5, URL: "https://bitbucket.local/projects/org/repos/repo/pull-requests/5", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantFound:   &gitopstypes.PullRequest{Number: 5, URL: "https://bitbucket.local/projects/org/repos/repo/pull-requests/5", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateOpen},
+			wantMerged:  &gitopstypes.PullRequest{Number: 5, URL: "https://bitbucket.local/projects/org/repos/repo/pull-requests/5", Branch: "kots/app/version-3", State: gitopstypes.PullRequestStateMerged},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			server, requests := newStubServer(t, tt.responses)
+
+			provider, err := NewPullRequestProvider(tt.provider, server.URL, "secret", "org", "repo")
+			req.NoError(err)
+
+			created, err := provider.CreatePullRequest(PullRequestOptions{
+				Title:       "Update",
+				Description: "desc",
+				HeadBranch:  "kots/app/version-3",
+				BaseBranch:  "main",
+			})
+			req.NoError(err)
+			assert.Equal(t, tt.wantCreated, created)
+
+			createRequest := (*requests)[0]
+			assert.Equal(t, tt.wantCreatePath, createRequest.Path)
+			assert.Equal(t, tt.wantCreateBody, createRequest.Body)
+			assert.Equal(t, tt.wantAuthValue, createRequest.Header.Get(tt.wantAuthHeader))
+
+			found, err := provider.FindOpenPullRequest("kots/app/version-3", "main")
+			req.NoError(err)
+			assert.Equal(t, tt.wantFound, found)
+
+			merged, err := provider.GetPullRequest(created.Number)
+			req.NoError(err)
+			assert.Equal(t, tt.wantMerged, merged)
+		})
+	}
+}
+
+func TestPullRequestProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Validation Failed"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewPullRequestProvider("github", server.URL, "secret", "org", "repo")
+	require.NoError(t, err)
+
+	_, err = provider.CreatePullRequest(PullRequestOptions{HeadBranch: "a", BaseBranch: "main"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Validation Failed")
+}
+
+func TestGitOpsConfig_PullRequestProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      GitOpsConfig
+		wantAPIURL  string
+		wantErr     bool
+		wantProvErr bool
+	}{
+		{
+			name:       "github enterprise",
+			config:     GitOpsConfig{Provider: "github_enterprise", RepoURI: "https://github.example.com/org/repo", Hostname: "github.example.com", APIToken: "secret"},
+			wantAPIURL: "https://github.example.com/api/v3",
+		},
+		{
+			name:       "gitea with port",
+			config:     GitOpsConfig{Provider: "gitea", RepoURI: "https://gitea.example.com:3000/org/repo", Hostname: "gitea.example.com", HTTPPort: "3000", APIToken: "secret"},
+			wantAPIURL: "https://gitea.example.com:3000/api/v1",
+		},
+		{
+			name:        "missing token",
+			config:      GitOpsConfig{Provider: "github", RepoURI: "https://github.com/org/repo"},
+			wantAPIURL:  "https://api.github.com",
+			wantProvErr: true,
+		},
+		{
+			name:        "unsupported provider",
+			config:      GitOpsConfig{Provider: "other", RepoURI: "https://git.example.com/org/repo", APIToken: "secret"},
+			wantErr:     true,
+			wantProvErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiURL, err := tt.config.APIURL()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAPIURL, apiURL)
+			}
+
+			_, err = tt.config.PullRequestProvider()
+			assert.Equal(t, tt.wantProvErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package gitops
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Formats that commits can be signed with
+const (
+	SigningFormatSSH = "ssh"
+	SigningFormatGPG = "gpg"
+)
+
+const (
+	sshSignatureNamespace = "git"
+	sshSignatureHashAlgo  = "sha512"
+)
+
+func IsValidSigningFormat(format string) bool {
+	switch format {
+	case "", SigningFormatSSH, SigningFormatGPG, KeepExistingValue:
+		return true
+	}
+	return false
+}
+
+// commitSigner signs the commits created for versions
+type commitSigner struct {
+	gpgEntity *openpgp.Entity
+	sshSigner ssh.Signer
+}
+
+func newCommitSigner(format string, key string, passphrase string) (*commitSigner, error) {
+	switch format {
+	case SigningFormatGPG:
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read armored key")
+		}
+		if len(entities) == 0 || entities[0].PrivateKey == nil {
+			return nil, errors.New("no private key found")
+		}
+		entity := entities[0]
+		if entity.PrivateKey.Encrypted {
+			if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, errors.Wrap(err, "failed to decrypt private key")
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				if err := subkey.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+					return nil, errors.Wrap(err, "failed to decrypt private subkey")
+				}
+			}
+		}
+		return &commitSigner{gpgEntity: entity}, nil
+
+	case SigningFormatSSH:
+		var signer ssh.Signer
+		var err error
+		if passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(key))
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse private key")
+		}
+		return &commitSigner{sshSigner: signer}, nil
+	}
+
+	return nil, errors.Errorf("unsupported signing format: %s", format)
+}
+
+// commitSigner returns nil if commits are not signed
+func (g *GitOpsConfig) commitSigner() (*commitSigner, error) {
+	if g.SigningFormat == "" || g.SigningKey == "" {
+		return nil, nil
+	}
+	return newCommitSigner(g.SigningFormat, g.SigningKey, g.SigningKeyPassphrase)
+}
+
+// commit creates the commit, signed with the configured key. go-git only signs with gpg keys,
+// so ssh signatures are added to the commit after it is created and the branch is moved to the signed commit.
+func (s *commitSigner) commit(r *git.Repository, workTree *git.Worktree, message string, opts *git.CommitOptions) (plumbing.Hash, error) {
+	if s == nil {
+		return workTree.Commit(message, opts)
+	}
+
+	if s.gpgEntity != nil {
+		opts.SignKey = s.gpgEntity
+		return workTree.Commit(message, opts)
+	}
+
+	hash, err := workTree.Commit(message, opts)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to get commit")
+	}
+
+	unsigned := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to encode commit")
+	}
+	reader, err := unsigned.Reader()
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to read encoded commit")
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to read encoded commit")
+	}
+
+	signature, err := sshSign(s.sshSigner, content)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to sign commit")
+	}
+	commit.PGPSignature = signature
+
+	signed := r.Storer.NewEncodedObject()
+	if err := commit.Encode(signed); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to encode signed commit")
+	}
+	signedHash, err := r.Storer.SetEncodedObject(signed)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to store signed commit")
+	}
+
+	head, err := r.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to get HEAD")
+	}
+	branch := head.Name()
+	if head.Type() == plumbing.SymbolicReference {
+		branch = head.Target()
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(branch, signedHash)); err != nil {
+		return plumbing.ZeroHash, errors.Wrapf(err, "failed to update %s", branch)
+	}
+
+	return signedHash, nil
+}
+
+// sshSign returns an armored signature of the message in the format that git verifies with gpg.format=ssh
+// (https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig)
+func sshSign(signer ssh.Signer, message []byte) (string, error) {
+	hash := sha512.Sum512(message)
+
+	signedData := ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		HashAlgo  string
+		Hash      string
+	}{
+		Namespace: sshSignatureNamespace,
+		HashAlgo:  sshSignatureHashAlgo,
+		Hash:      string(hash[:]),
+	})
+
+	var signature *ssh.Signature
+	var err error
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// sha1 rsa signatures are rejected by git
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, append([]byte("SSHSIG"), signedData...), ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, append([]byte("SSHSIG"), signedData...))
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign")
+	}
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlgo  string
+		Signature string
+	}{
+		Version:   1,
+		PublicKey: string(signer.PublicKey().Marshal()),
+		Namespace: sshSignatureNamespace,
+		HashAlgo:  sshSignatureHashAlgo,
+		Signature: string(ssh.Marshal(signature)),
+	})...)
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b bytes.Buffer
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70])
+		b.WriteString("\n")
+		encoded = encoded[70:]
+	}
+	b.WriteString(encoded)
+	b.WriteString("\n-----END SSH SIGNATURE-----\n")
+
+	return b.String(), nil
+}
@@ -0,0 +1,132 @@
+package gitops
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func commitTestFile(t *testing.T, signer *commitSigner) (*git.Repository, plumbing.Hash) {
+	workDir := t.TempDir()
+	r, err := git.PlainInit(workDir, false)
+	require.NoError(t, err)
+	workTree, err := r.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "app.yaml"), []byte("kind: ConfigMap"), 0644))
+	_, err = workTree.Add("app.yaml")
+	require.NoError(t, err)
+
+	config := &GitOpsConfig{AuthorName: "Release Bot", AuthorEmail: "bot@example.com"}
+	hash, err := signer.commit(r, workTree, "Updating app to version 1", &git.CommitOptions{Author: config.authorSignature()})
+	require.NoError(t, err)
+
+	return r, hash
+}
+
+func TestCommitSignerSSH(t *testing.T) {
+	keyPair, err := generatePrivateKey_ed25519()
+	require.NoError(t, err)
+
+	signer, err := newCommitSigner(SigningFormatSSH, keyPair.PrivateKeyPEM, "")
+	require.NoError(t, err)
+
+	r, hash := commitTestFile(t, signer)
+
+	head, err := r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, hash, head.Hash())
+
+	commit, err := r.CommitObject(hash)
+	require.NoError(t, err)
+	assert.Equal(t, "Release Bot", commit.Author.Name)
+	require.True(t, strings.HasPrefix(commit.PGPSignature, "-----BEGIN SSH SIGNATURE-----\n"))
+
+	unsigned := &plumbing.MemoryObject{}
+	require.NoError(t, commit.EncodeWithoutSignature(unsigned))
+	content := new(bytes.Buffer)
+	reader, err := unsigned.Reader()
+	require.NoError(t, err)
+	_, err = content.ReadFrom(reader)
+	require.NoError(t, err)
+
+	// decode the armored signature and verify it with the public key
+	armored := strings.TrimPrefix(commit.PGPSignature, "-----BEGIN SSH SIGNATURE-----\n")
+	armored = strings.TrimSuffix(armored, "-----END SSH SIGNATURE-----\n")
+	blob, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(armored, "\n", ""))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(blob, []byte("SSHSIG")))
+
+	parsed := struct {
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlgo  string
+		Signature string
+	}{}
+	require.NoError(t, ssh.Unmarshal(blob[len("SSHSIG"):], &parsed))
+	assert.Equal(t, "git", parsed.Namespace)
+
+	publicKey, err := ssh.ParsePublicKey([]byte(parsed.PublicKey))
+	require.NoError(t, err)
+	authorizedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keyPair.PublicKeySSH))
+	require.NoError(t, err)
+	assert.Equal(t, authorizedKey.Marshal(), publicKey.Marshal())
+
+	signature := &ssh.Signature{}
+	require.NoError(t, ssh.Unmarshal([]byte(parsed.Signature), signature))
+
+	hashed := sha512Sum(content.Bytes())
+	signedData := ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		HashAlgo  string
+		Hash      string
+	}{Namespace: "git", HashAlgo: "sha512", Hash: string(hashed)})
+	assert.NoError(t, publicKey.Verify(append([]byte("SSHSIG"), signedData...), signature))
+}
+
+func TestCommitSignerGPG(t *testing.T) {
+	entity, err := openpgp.NewEntity("Release Bot", "", "bot@example.com", nil)
+	require.NoError(t, err)
+
+	privateKey := new(bytes.Buffer)
+	w, err := armor.Encode(privateKey, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+
+	publicKey := new(bytes.Buffer)
+	w, err = armor.Encode(publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	signer, err := newCommitSigner(SigningFormatGPG, privateKey.String(), "")
+	require.NoError(t, err)
+
+	r, hash := commitTestFile(t, signer)
+
+	commit, err := r.CommitObject(hash)
+	require.NoError(t, err)
+	_, err = commit.Verify(publicKey.String())
+	assert.NoError(t, err)
+}
+
+func sha512Sum(b []byte) []byte {
+	h := sha512.Sum512(b)
+	return h[:]
+}
@@ -1,5 +1,6 @@
 package types
 
 type DownstreamGitOps interface {
-	CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, archiveDir string, downstreamName string) (string, error)
+	// CreateGitOpsDownstreamCommit returns nil if gitops is not enabled for the downstream or if nothing changed
+	CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, archiveDir string, downstreamName string) (*CommitResult, error)
 }
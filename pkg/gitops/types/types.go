@@ -0,0 +1,86 @@
+package types
+
+import (
+	"time"
+)
+
+type PullRequestState string
+
+const (
+	PullRequestStateOpen   PullRequestState = "open"
+	PullRequestStateMerged PullRequestState = "merged"
+	PullRequestStateClosed PullRequestState = "closed"
+)
+
+type PullRequest struct {
+	Number int64            `json:"number"`
+	URL    string           `json:"url"`
+	Branch string           `json:"branch"`
+	State  PullRequestState `json:"state"`
+}
+
+// CommitResult describes the commit created for a version, and the pull request opened for it when gitops is in pull request mode
+type CommitResult struct {
+	CommitURL   string       `json:"commitUrl"`
+	PullRequest *PullRequest `json:"pullRequest,omitempty"`
+}
+
+// DownstreamPullRequest is a pull request opened for a downstream version
+type DownstreamPullRequest struct {
+	AppID       string      `json:"appId"`
+	ClusterID   string      `json:"clusterId"`
+	Sequence    int64       `json:"sequence"`
+	PullRequest PullRequest `json:"pullRequest"`
+}
+
+// Resource identifies a kubernetes resource in the rendered app, the gitops repo or the cluster
+type Resource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// ResourceDrift lists the resources that differ from the rendered manifests of the current version
+type ResourceDrift struct {
+	// Added are resources that are not in the rendered manifests
+	Added []Resource `json:"added"`
+	// Modified are resources that are in the rendered manifests with different contents
+	Modified []Resource `json:"modified"`
+	// Removed are resources in the rendered manifests that are missing
+	Removed []Resource `json:"removed"`
+}
+
+func (d ResourceDrift) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0
+}
+
+// DriftStatus is the result of the last drift check of a downstream
+type DriftStatus struct {
+	AppID     string `json:"appId"`
+	ClusterID string `json:"clusterId"`
+	// Sequence is the deployed version that the cluster is compared to, -1 if no version is deployed
+	Sequence int64 `json:"sequence"`
+	// RepoSequence is the latest version committed to the branch, or whose pull request was merged,
+	// that the repo is compared to. -1 if no version is in the branch.
+	RepoSequence int64     `json:"repoSequence"`
+	CheckedAt    time.Time `json:"checkedAt"`
+	// Repo compares the configured branch and path of the repo to the rendered manifests of RepoSequence
+	Repo ResourceDrift `json:"repo"`
+	// Cluster compares the live objects in the cluster to the rendered manifests of Sequence. Resources that are
+	// only in the cluster are not reported since it is not known which objects belong to the app.
+	Cluster ResourceDrift `json:"cluster"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func (s DriftStatus) HasDrift() bool {
+	return !s.Repo.IsEmpty() || !s.Cluster.IsEmpty()
+}
+
+// DownstreamDriftStatus is the drift status of a gitops enabled downstream
+type DownstreamDriftStatus struct {
+	ClusterID string `json:"clusterId"`
+	Name      string `json:"name"`
+	// Status is the result of the last drift check, nil if the downstream has not been checked yet
+	Status *DriftStatus `json:"status,omitempty"`
+}
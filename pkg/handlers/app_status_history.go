@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/appstatushistory"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+const defaultAppStatusHistoryWindow = "7d"
+
+type GetAppStatusHistoryResponse struct {
+	AppSlug      string                             `json:"appSlug"`
+	Window       string                             `json:"window"`
+	Availability appstatushistorytypes.Availability `json:"availability"`
+	History      []appstatushistorytypes.Entry      `json:"history"`
+}
+
+func (h *Handler) GetAppStatusHistory(w http.ResponseWriter, r *http.Request) {
+	if util.IsHelmManaged() {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("app status history is not available in helm managed mode")))
+		return
+	}
+
+	windowStr := r.URL.Query().Get("window")
+	if windowStr == "" {
+		windowStr = defaultAppStatusHistoryWindow
+	}
+	window, err := appstatushistory.ParseWindow(windowStr)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	appSlug := mux.Vars(r)["appSlug"]
+	appID, err := store.GetStore().GetAppIDFromSlug(appSlug)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	history, err := appstatushistory.GetHistory(appID, window, time.Now())
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app status history"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetAppStatusHistoryResponse{
+		AppSlug:      appSlug,
+		Window:       windowStr,
+		Availability: history.Availability,
+		History:      history.Entries,
+	})
+}
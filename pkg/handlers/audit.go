@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+const defaultAuditEventsLimit = 1000
+
+type ListAuditEventsResponse struct {
+	Events []audittypes.Event `json:"events"`
+}
+
+// ListAuditEvents returns audit events, newest first. The results can be filtered with the appSlug, username,
+// since and until (RFC3339) query parameters. limit defaults to 1000, and a limit of 0 returns all events.
+func (h *Handler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	opts, err := getAuditListOptions(r)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	events, err := store.GetStore().ListAuditEvents(*opts)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list audit events"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, ListAuditEventsResponse{Events: events})
+}
+
+func getAuditListOptions(r *http.Request) (*audittypes.ListOptions, error) {
+	query := r.URL.Query()
+
+	opts := audittypes.ListOptions{
+		AppSlug:  query.Get("appSlug"),
+		Username: query.Get("username"),
+		Limit:    defaultAuditEventsLimit,
+	}
+
+	if val := query.Get("since"); val != "" {
+		since, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse since")
+		}
+		opts.Since = &since
+	}
+
+	if val := query.Get("until"); val != "" {
+		until, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse until")
+		}
+		opts.Until = &until
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit < 0 {
+			return nil, errors.Errorf("invalid limit %q", val)
+		}
+		opts.Limit = limit
+	}
+
+	return &opts, nil
+}
+
+// auditSession adds an event to the audit log for requests that create or end a session. These routes are not
+// authenticated, so the handler records the session with audit.RecordSession.
+func auditSession(kotsStore store.Store, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		summary := audit.Summarize(audit.CaptureRequestBody(r))
+
+		r, details := audit.WithDetails(r)
+		recorder := audit.NewStatusRecorder(w)
+		next(recorder, r)
+
+		event := audit.NewEvent(r, details.Session, "", recorder.StatusCode, details.Summary(summary))
+		if err := kotsStore.CreateAuditEvent(event); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to record audit event for %s %s", r.Method, r.URL.Path))
+		}
+	}
+}
@@ -23,6 +23,8 @@ type DownstreamLogs struct {
 	HelmStdout   string `json:"helmStdout"`
 	HelmStderr   string `json:"helmStderr"`
 	RenderError  string `json:"renderError"`
+
+	ResourceResults []types.DownstreamResourceResult `json:"resourceResults,omitempty"`
 }
 
 func (h *Handler) GetDownstreamOutput(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +82,8 @@ func (h *Handler) GetDownstreamOutput(w http.ResponseWriter, r *http.Request) {
 		HelmStdout:   output.HelmStdout,
 		HelmStderr:   output.HelmStderr,
 		RenderError:  output.RenderError,
+
+		ResourceResults: output.ResourceResults,
 	}
 	getDownstreamOutputResponse := GetDownstreamOutputResponse{
 		Logs: downstreamLogs,
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/filestore"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+const migrateFileStoreTaskID = "filestore-migrate"
+
+type GetFileStoreResponse struct {
+	Backend string `json:"backend"`
+	Dedupe  bool   `json:"dedupe"`
+}
+
+type MigrateFileStoreRequest struct {
+	From         string `json:"from"`
+	FromDedupe   bool   `json:"fromDedupe"`
+	To           string `json:"to"`
+	ToDedupe     bool   `json:"toDedupe"`
+	DeleteSource bool   `json:"deleteSource"`
+	DryRun       bool   `json:"dryRun"`
+}
+
+type MigrateFileStoreResponse struct {
+	Archives []string `json:"archives"`
+	DryRun   bool     `json:"dryRun"`
+}
+
+type GetFileStoreMigrationStatusResponse struct {
+	CurrentMessage string `json:"currentMessage"`
+	Status         string `json:"status"`
+}
+
+// GetFileStore returns the file store backend that archives are currently written to
+func (h *Handler) GetFileStore(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, GetFileStoreResponse{
+		Backend: filestore.BackendFromEnv(),
+		Dedupe:  filestore.DedupeFromEnv(),
+	})
+}
+
+// MigrateFileStore copies all archives from one file store backend to another in the background.
+// The admin console keeps using the configured backend until KOTSADM_FILESTORE_BACKEND is changed, so archives
+// cannot be deleted from it. Archives written while the migration runs are copied by migrating again with
+// deleteSource once the admin console has been switched to the destination.
+func (h *Handler) MigrateFileStore(w http.ResponseWriter, r *http.Request) {
+	if util.IsHelmManaged() {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("file store migration is not supported in helm managed mode")))
+		return
+	}
+
+	request := MigrateFileStoreRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := validateFileStoreMigration(request); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	from, err := getFileStoreForMigration(request.From, request.FromDedupe)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Wrap(err, "invalid source")))
+		return
+	}
+	to, err := getFileStoreForMigration(request.To, request.ToDedupe)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Wrap(err, "invalid destination")))
+		return
+	}
+
+	if request.DryRun {
+		result, err := filestore.MigrateArchives(from, to, filestore.MigrateOptions{DryRun: true})
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to list archives to migrate"))
+			JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+			return
+		}
+		JSON(w, http.StatusOK, MigrateFileStoreResponse{
+			Archives: result.Archives,
+			DryRun:   true,
+		})
+		return
+	}
+
+	currentStatus, _, err := store.GetStore().GetTaskStatus(migrateFileStoreTaskID)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get %s task status", migrateFileStoreTaskID))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if currentStatus == "running" {
+		JSON(w, http.StatusConflict, types.NewErrorResponse(errors.New("a file store migration is already running")))
+		return
+	}
+
+	if err := initFileStore(r.Context(), to); err != nil {
+		logger.Error(errors.Wrap(err, "failed to initialize destination file store"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(errors.Wrap(err, "failed to initialize destination file store")))
+		return
+	}
+
+	if err := store.GetStore().SetTaskStatus(migrateFileStoreTaskID, "Listing archives...", "running"); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to set %s task status", migrateFileStoreTaskID))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	go migrateFileStore(from, to, request.DeleteSource)
+
+	JSON(w, http.StatusAccepted, MigrateFileStoreResponse{
+		Archives: []string{},
+	})
+}
+
+// GetFileStoreMigrationStatus returns the status of the last file store migration
+func (h *Handler) GetFileStoreMigrationStatus(w http.ResponseWriter, r *http.Request) {
+	status, message, err := store.GetStore().GetTaskStatus(migrateFileStoreTaskID)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get %s task status", migrateFileStoreTaskID))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetFileStoreMigrationStatusResponse{
+		CurrentMessage: message,
+		Status:         status,
+	})
+}
+
+func migrateFileStore(from filestore.FileStore, to filestore.FileStore, deleteSource bool) {
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+
+	go func() {
+		for {
+			select {
+			case <-time.After(time.Second):
+				if err := store.GetStore().UpdateTaskStatusTimestamp(migrateFileStoreTaskID); err != nil {
+					logger.Error(err)
+				}
+			case <-finishedChan:
+				return
+			}
+		}
+	}()
+
+	result, err := filestore.MigrateArchives(from, to, filestore.MigrateOptions{
+		DeleteSource: deleteSource,
+		Progress: func(migrated int, total int) {
+			message := fmt.Sprintf("Migrated %d of %d archives", migrated, total)
+			if err := store.GetStore().SetTaskStatus(migrateFileStoreTaskID, message, "running"); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to set %s task status", migrateFileStoreTaskID))
+			}
+		},
+	})
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to migrate archives"))
+		if err := store.GetStore().SetTaskStatus(migrateFileStoreTaskID, err.Error(), "failed"); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to set error on %s task status", migrateFileStoreTaskID))
+		}
+		return
+	}
+
+	message := fmt.Sprintf("%d archives have been migrated", len(result.Archives))
+	if err := store.GetStore().SetTaskStatus(migrateFileStoreTaskID, message, "success"); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to set %s task status", migrateFileStoreTaskID))
+	}
+}
+
+func validateFileStoreMigration(request MigrateFileStoreRequest) error {
+	if request.From == request.To {
+		if request.FromDedupe == request.ToDedupe {
+			return errors.New("source and destination file stores are the same")
+		}
+		if request.DeleteSource {
+			// the archives are rewritten in place, deleting them from the source would delete the migrated copies
+			return errors.New("cannot delete the source when migrating within the same backend")
+		}
+	}
+
+	// the plain and dedupe stores of a backend share the archive keys, so the backend is compared regardless of dedupe
+	if request.DeleteSource && request.From == filestore.BackendFromEnv() {
+		return errors.New("cannot delete archives from the file store the admin console is using, switch the admin console to the destination before deleting the source")
+	}
+
+	return nil
+}
+
+// getFileStoreForMigration reuses the active store when possible so that writes
+// and chunk garbage collection are not running concurrently on the same backend
+func getFileStoreForMigration(backend string, dedupe bool) (filestore.FileStore, error) {
+	if backend == filestore.BackendFromEnv() && dedupe == filestore.DedupeFromEnv() {
+		return filestore.GetStore(), nil
+	}
+	return filestore.NewStore(backend, dedupe)
+}
+
+func initFileStore(ctx context.Context, store filestore.FileStore) error {
+	if store == filestore.GetStore() {
+		// already initialized on startup
+		return nil
+	}
+
+	if err := store.Init(); err != nil {
+		return errors.Wrap(err, "failed to init")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := store.WaitForReady(ctx); err != nil {
+		return errors.Wrap(err, "failed to wait for file store")
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/filestore"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateFileStoreMigration(t *testing.T) {
+	t.Setenv("KOTSADM_FILESTORE_BACKEND", filestore.BackendBlob)
+	t.Setenv("KOTSADM_FILESTORE_DEDUPE", "")
+
+	tests := []struct {
+		name    string
+		request MigrateFileStoreRequest
+		wantErr bool
+	}{
+		{
+			name:    "same store",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, To: filestore.BackendBlob},
+			wantErr: true,
+		},
+		{
+			name:    "enable dedupe in place",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, To: filestore.BackendBlob, ToDedupe: true},
+		},
+		{
+			name:    "delete source in place",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, To: filestore.BackendBlob, ToDedupe: true, DeleteSource: true},
+			wantErr: true,
+		},
+		{
+			name:    "copy from the active backend",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, To: filestore.BackendFilesystem},
+		},
+		{
+			name:    "delete from the active backend",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, To: filestore.BackendFilesystem, DeleteSource: true},
+			wantErr: true,
+		},
+		{
+			// the dedupe store of the active backend shares its archive keys
+			name:    "delete from the active backend with a different dedupe setting",
+			request: MigrateFileStoreRequest{From: filestore.BackendBlob, FromDedupe: true, To: filestore.BackendFilesystem, DeleteSource: true},
+			wantErr: true,
+		},
+		{
+			name:    "delete from an inactive backend",
+			request: MigrateFileStoreRequest{From: filestore.BackendFilesystem, To: filestore.BackendBlob, DeleteSource: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFileStoreMigration(tt.request)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -2,24 +2,28 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/pkg/errors"
-	"github.com/replicatedhq/kots/pkg/k8sutil"
 	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
-	"github.com/replicatedhq/kots/pkg/kurl"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/registry"
+	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	"github.com/replicatedhq/kots/pkg/store"
 )
 
 type GarbageCollectImagesRequest struct {
-	IgnoreRollback bool `json:"ignoreRollback,omitempty"`
+	IgnoreRollback  bool     `json:"ignoreRollback,omitempty"`
+	DryRun          bool     `json:"dryRun,omitempty"`
+	ProtectedImages []string `json:"protectedImages,omitempty"`
 }
 
 type GarbageCollectImagesResponse struct {
 	Error string `json:"error,omitempty"`
+	// Results are the images that would be deleted for each app slug, only set for dry runs
+	Results map[string]*registrytypes.GarbageCollectResult `json:"results,omitempty"`
 }
 
 func (h *Handler) GarbageCollectImages(w http.ResponseWriter, r *http.Request) {
@@ -47,54 +51,67 @@ func (h *Handler) GarbageCollectImages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientset, err := k8sutil.GetClientset()
-	if err != nil {
-		response.Error = "failed to get k8s clientset"
-		logger.Error(errors.Wrap(err, response.Error))
-		JSON(w, http.StatusInternalServerError, response)
-		return
-	}
-
-	isKurl, err := kurl.IsKurl(clientset)
+	apps, err := store.GetStore().ListInstalledApps()
 	if err != nil {
-		response.Error = "failed to check kURL"
+		response.Error = "failed to list apps"
 		logger.Error(errors.Wrap(err, response.Error))
 		JSON(w, http.StatusInternalServerError, response)
 		return
 	}
 
-	if !isKurl {
-		response.Error = "image garbage collection is only supported in embedded clusters"
+	if len(apps) == 0 {
+		response.Error = "no installed apps found"
 		logger.Error(errors.New(response.Error))
 		JSON(w, http.StatusBadRequest, response)
 		return
 	}
 
-	apps, err := store.GetStore().ListInstalledApps()
-	if err != nil {
-		response.Error = "failed to list apps"
-		logger.Error(errors.Wrap(err, response.Error))
-		JSON(w, http.StatusInternalServerError, response)
-		return
+	options := registrytypes.GarbageCollectOptions{
+		IgnoreRollback:  garbageCollectImagesRequest.IgnoreRollback,
+		DryRun:          garbageCollectImagesRequest.DryRun,
+		ProtectedImages: garbageCollectImagesRequest.ProtectedImages,
 	}
 
-	if len(apps) == 0 {
-		response.Error = "no installed apps found"
-		logger.Error(errors.New(response.Error))
-		JSON(w, http.StatusBadRequest, response)
+	// a dry run only reads the registry, so the listing is returned to the caller
+	if options.DryRun {
+		response.Results = map[string]*registrytypes.GarbageCollectResult{}
+		for _, app := range apps {
+			result, err := registry.GarbageCollectImages(app.ID, options)
+			if err != nil {
+				if _, ok := err.(registry.AppRollbackError); ok {
+					response.Error = fmt.Sprintf("images would not be garbage collected because a version of app %s allows rollbacks", app.Slug)
+					logger.Error(errors.Wrap(err, response.Error))
+					JSON(w, http.StatusBadRequest, response)
+					return
+				}
+				response.Error = "failed to list unused images"
+				logger.Error(errors.Wrap(err, response.Error))
+				JSON(w, http.StatusInternalServerError, response)
+				return
+			}
+			if result != nil {
+				response.Results[app.Slug] = result
+			}
+		}
+
+		JSON(w, http.StatusOK, response)
 		return
 	}
 
 	go func() {
 		for _, app := range apps {
 			logger.Infof("Deleting images for app %s", app.Slug)
-			err := registry.DeleteUnusedImages(app.ID, garbageCollectImagesRequest.IgnoreRollback)
+			result, err := registry.GarbageCollectImages(app.ID, options)
 			if err != nil {
 				if _, ok := err.(registry.AppRollbackError); ok {
 					logger.Infof("not garbage collecting images because version allows rollbacks: %v", err)
 				} else {
 					logger.Error(errors.Wrap(err, "failed to delete unused images"))
 				}
+				continue
+			}
+			if result != nil && len(result.Failed) > 0 {
+				logger.Errorf("failed to delete %d unused images for app %s", len(result.Failed), app.Slug)
 			}
 		}
 	}()
@@ -9,10 +9,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/gitops"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/handlers/types"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/reporting"
 	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/replicatedhq/kots/pkg/version"
 )
 
 type UpdateAppGitOpsRequest struct {
@@ -35,6 +38,26 @@ type CreateGitOpsInput struct {
 	Hostname string `json:"hostname"`
 	HTTPPort string `json:"httpPort"`
 	SSHPort  string `json:"sshPort"`
+	// The credentials below keep their stored value when they are not set, and are removed when they are empty.
+	// APIToken is used to open pull requests when the action is "pullrequest"
+	APIToken *string `json:"apiToken"`
+	// AuthType is one of "ssh" (the default, using the generated deploy key), "https" or "githubapp"
+	AuthType *string `json:"authType"`
+	Username *string `json:"username"`
+	// Password is the password or access token for https auth
+	Password                *string `json:"password"`
+	GitHubAppID             *string `json:"githubAppId"`
+	GitHubAppInstallationID *string `json:"githubAppInstallationId"`
+	GitHubAppPrivateKey     *string `json:"githubAppPrivateKey"`
+
+	// AuthorName and AuthorEmail default to the admin console identity
+	AuthorName  *string `json:"authorName"`
+	AuthorEmail *string `json:"authorEmail"`
+
+	// SigningFormat is "ssh" or "gpg" to sign commits with SigningKey, empty to not sign commits
+	SigningFormat        *string `json:"signingFormat"`
+	SigningKey           *string `json:"signingKey"`
+	SigningKeyPassphrase *string `json:"signingKeyPassphrase"`
 }
 
 func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +68,16 @@ func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	gitOpsInput := updateAppGitOpsRequest.GitOpsInput
+	if gitOpsInput.Action != "" && gitOpsInput.Action != gitops.ActionCommit && gitOpsInput.Action != gitops.ActionPullRequest {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("unsupported gitops action %q", gitOpsInput.Action)))
+		return
+	}
+	if !gitops.IsValidFormat(gitOpsInput.Format) {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("unsupported gitops format %q", gitOpsInput.Format)))
+		return
+	}
+
 	appID := mux.Vars(r)["appId"]
 	clusterID := mux.Vars(r)["clusterId"]
 
@@ -55,7 +88,6 @@ func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gitOpsInput := updateAppGitOpsRequest.GitOpsInput
 	if err := gitops.UpdateDownstreamGitOps(a.ID, clusterID, gitOpsInput.URI, gitOpsInput.Branch, gitOpsInput.Path, gitOpsInput.Format, gitOpsInput.Action); err != nil {
 		logger.Error(err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -146,7 +178,7 @@ func (h *Handler) InitGitOpsConnection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if downstreamGitOps.Format != "single" {
+	if !gitops.IsValidFormat(downstreamGitOps.Format) {
 		logger.Error(errors.New("unsupported gitops format"))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -232,13 +264,22 @@ func (h *Handler) InitGitOpsConnection(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			_, err = gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(appVersions.CurrentVersion.ParentSequence), currentVersionArchive, d.Name)
+			commitResult, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(appVersions.CurrentVersion.ParentSequence), currentVersionArchive, d.Name)
 			if err != nil {
 				err = errors.Wrapf(err, "failed to create gitops commit for current version %d", appVersions.CurrentVersion.ParentSequence)
 				logger.Error(err)
 				finalError = err
 				return
 			}
+
+			if commitResult != nil {
+				if err := store.GetStore().SetDownstreamVersionGitOpsCommit(a.ID, d.ClusterID, appVersions.CurrentVersion.Sequence, commitResult.CommitURL, commitResult.PullRequest); err != nil {
+					err = errors.Wrapf(err, "failed to record gitops commit for current version %d", appVersions.CurrentVersion.ParentSequence)
+					logger.Error(err)
+					finalError = err
+					return
+				}
+			}
 		}
 
 		// Create git commits for sorted pending versions
@@ -260,13 +301,22 @@ func (h *Handler) InitGitOpsConnection(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			_, err = gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(pendingVersion.ParentSequence), pendingVersionArchive, d.Name)
+			commitResult, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(pendingVersion.ParentSequence), pendingVersionArchive, d.Name)
 			if err != nil {
 				err = errors.Wrapf(err, "failed to create gitops commit for pending version %d", pendingVersion.ParentSequence)
 				logger.Error(err)
 				finalError = err
 				return
 			}
+
+			if commitResult != nil {
+				if err := store.GetStore().SetDownstreamVersionGitOpsCommit(a.ID, d.ClusterID, pendingVersion.Sequence, commitResult.CommitURL, commitResult.PullRequest); err != nil {
+					err = errors.Wrapf(err, "failed to record gitops commit for pending version %d", pendingVersion.ParentSequence)
+					logger.Error(err)
+					finalError = err
+					return
+				}
+			}
 		}
 	}()
 
@@ -310,7 +360,25 @@ func (h *Handler) CreateGitOps(w http.ResponseWriter, r *http.Request) {
 	}
 
 	gitOpsInput := createGitOpsRequest.GitOpsInput
-	if err := gitops.CreateGitOps(gitOpsInput.Provider, gitOpsInput.URI, gitOpsInput.Hostname, gitOpsInput.HTTPPort, gitOpsInput.SSHPort); err != nil {
+	credentials := gitops.Credentials{
+		AuthType:                credentialValue(gitOpsInput.AuthType),
+		Username:                credentialValue(gitOpsInput.Username),
+		Password:                credentialValue(gitOpsInput.Password),
+		GitHubAppID:             credentialValue(gitOpsInput.GitHubAppID),
+		GitHubAppInstallationID: credentialValue(gitOpsInput.GitHubAppInstallationID),
+		GitHubAppPrivateKey:     credentialValue(gitOpsInput.GitHubAppPrivateKey),
+		AuthorName:              credentialValue(gitOpsInput.AuthorName),
+		AuthorEmail:             credentialValue(gitOpsInput.AuthorEmail),
+		SigningFormat:           credentialValue(gitOpsInput.SigningFormat),
+		SigningKey:              credentialValue(gitOpsInput.SigningKey),
+		SigningKeyPassphrase:    credentialValue(gitOpsInput.SigningKeyPassphrase),
+	}
+	if err := gitops.ValidateCredentials(credentials); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	if err := gitops.CreateGitOps(gitOpsInput.Provider, gitOpsInput.URI, gitOpsInput.Hostname, gitOpsInput.HTTPPort, gitOpsInput.SSHPort, credentialValue(gitOpsInput.APIToken), credentials); err != nil {
 		logger.Error(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -318,3 +386,84 @@ func (h *Handler) CreateGitOps(w http.ResponseWriter, r *http.Request) {
 
 	JSON(w, http.StatusNoContent, "")
 }
+
+// credentialValue keeps the stored value of credentials that are not set in the request
+func credentialValue(value *string) string {
+	if value == nil {
+		return gitops.KeepExistingValue
+	}
+	return *value
+}
+
+type GetAppGitOpsStatusResponse struct {
+	Downstreams []gitopstypes.DownstreamDriftStatus `json:"downstreams"`
+}
+
+// GetAppGitOpsStatus returns the result of the last drift check for each gitops enabled downstream of the app
+func (h *Handler) GetAppGitOpsStatus(w http.ResponseWriter, r *http.Request) {
+	h.getAppGitOpsStatus(w, r, false)
+}
+
+// CheckAppGitOpsDrift checks the gitops enabled downstreams of the app for drift now, instead of waiting for the periodic check
+func (h *Handler) CheckAppGitOpsDrift(w http.ResponseWriter, r *http.Request) {
+	h.getAppGitOpsStatus(w, r, true)
+}
+
+func (h *Handler) getAppGitOpsStatus(w http.ResponseWriter, r *http.Request, check bool) {
+	if util.IsHelmManaged() {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("gitops is not available in helm managed mode")))
+		return
+	}
+
+	a, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if check {
+		if _, err := version.CheckGitOpsDrift(a); err != nil {
+			logger.Error(errors.Wrap(err, "failed to check gitops drift"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list downstreams"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := GetAppGitOpsStatusResponse{
+		Downstreams: []gitopstypes.DownstreamDriftStatus{},
+	}
+	for _, d := range downstreams {
+		gitOpsConfig, err := gitops.GetDownstreamGitOps(a.ID, d.ClusterID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get downstream gitops"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if gitOpsConfig == nil {
+			continue
+		}
+
+		status, err := store.GetStore().GetGitOpsDriftStatus(a.ID, d.ClusterID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get drift status"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response.Downstreams = append(response.Downstreams, gitopstypes.DownstreamDriftStatus{
+			ClusterID: d.ClusterID,
+			Name:      d.Name,
+			Status:    status,
+		})
+	}
+
+	JSON(w, http.StatusOK, response)
+}
@@ -105,6 +105,8 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppRead, handler.GetApp))
 	r.Name("GetAppStatus").Path("/api/v1/app/{appSlug}/status").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppStatusRead, handler.GetAppStatus))
+	r.Name("GetAppStatusHistory").Path("/api/v1/app/{appSlug}/status-history").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppStatusRead, handler.GetAppStatusHistory))
 	r.Name("GetAppVersionHistory").Path("/api/v1/app/{appSlug}/versions").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamRead, handler.GetAppVersionHistory))
 	r.Name("GetLatestDeployableVersion").Path("/api/v1/app/{appSlug}/next-app-version").Methods("GET").
@@ -209,6 +211,10 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.SetAutomaticUpdatesConfig))
 	r.Name("GetAutomaticUpdatesConfig").Path("/api/v1/app/{appSlug}/automaticupdates").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.GetAutomaticUpdatesConfig))
+	r.Name("GetUpdateCheckStatuses").Path("/api/v1/update-checks").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppList, handler.GetUpdateCheckStatuses))
+	r.Name("SetMaintenanceWindow").Path("/api/v1/app/{appSlug}/maintenance-window").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.SetMaintenanceWindow))
 	r.Name("RemoveApp").Path("/api/v1/app/{appSlug}/remove").Methods("POST").
 		HandlerFunc(middleware.EnforceAccess(policy.AppUpdate, handler.RemoveApp))
 
@@ -229,6 +235,24 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsRead, handler.GetSnapshotConfig))
 	r.Name("SaveSnapshotConfig").Path("/api/v1/app/{appSlug}/snapshot/config").Methods("PUT").
 		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsWrite, handler.SaveSnapshotConfig))
+	r.Name("GetSnapshotHooks").Path("/api/v1/app/{appSlug}/snapshot/hooks").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsRead, handler.GetSnapshotHooks))
+	r.Name("SetSnapshotHooks").Path("/api/v1/app/{appSlug}/snapshot/hooks").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsWrite, handler.SetSnapshotHooks))
+	r.Name("GetSnapshotRetentionPolicy").Path("/api/v1/app/{appSlug}/snapshot/retention").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsRead, handler.GetSnapshotRetentionPolicy))
+	r.Name("SetSnapshotRetentionPolicy").Path("/api/v1/app/{appSlug}/snapshot/retention").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsWrite, handler.SetSnapshotRetentionPolicy))
+	r.Name("PreviewSnapshotRetention").Path("/api/v1/app/{appSlug}/snapshot/retention/preview").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsRead, handler.PreviewSnapshotRetention))
+	r.Name("VerifyRestore").Path("/api/v1/app/{appSlug}/snapshot/{snapshotName}/verify-restore").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppRestoreWrite, handler.VerifyRestore))
+	r.Name("GetRestoreVerification").Path("/api/v1/app/{appSlug}/snapshot/{snapshotName}/verify-restore").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppRestoreRead, handler.GetRestoreVerification))
+	r.Name("GetRestoreVerificationSchedule").Path("/api/v1/app/{appSlug}/snapshot/restore-verification/schedule").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsRead, handler.GetRestoreVerificationSchedule))
+	r.Name("SetRestoreVerificationSchedule").Path("/api/v1/app/{appSlug}/snapshot/restore-verification/schedule").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppSnapshotsettingsWrite, handler.SetRestoreVerificationSchedule))
 
 	// Global snapshot routes
 	r.Name("ListInstanceBackups").Path("/api/v1/snapshots").Methods("GET").
@@ -239,6 +263,12 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsRead, handler.GetInstanceSnapshotConfig))
 	r.Name("SaveInstanceSnapshotConfig").Path("/api/v1/snapshot/config").Methods("PUT").
 		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsWrite, handler.SaveInstanceSnapshotConfig))
+	r.Name("GetInstanceSnapshotRetentionPolicy").Path("/api/v1/snapshot/retention").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsRead, handler.GetInstanceSnapshotRetentionPolicy))
+	r.Name("SetInstanceSnapshotRetentionPolicy").Path("/api/v1/snapshot/retention").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsWrite, handler.SetInstanceSnapshotRetentionPolicy))
+	r.Name("PreviewInstanceSnapshotRetention").Path("/api/v1/snapshot/retention/preview").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsRead, handler.PreviewInstanceSnapshotRetention))
 	r.Name("GetGlobalSnapshotSettings").Path("/api/v1/snapshots/settings").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.SnapshotsettingsRead, handler.GetGlobalSnapshotSettings))
 	r.Name("UpdateGlobalSnapshotSettings").Path("/api/v1/snapshots/settings").Methods("PUT").
@@ -305,11 +335,71 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.GitOpsWrite, handler.ResetGitOps))
 	r.Name("GetGitOpsRepo").Path("/api/v1/gitops/get").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.GitOpsRead, handler.GetGitOpsRepo))
+	r.Name("GetAppGitOpsStatus").Path("/api/v1/app/{appSlug}/gitops-status").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppGitopsRead, handler.GetAppGitOpsStatus))
+	r.Name("CheckAppGitOpsDrift").Path("/api/v1/app/{appSlug}/gitops-status/check").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppGitopsWrite, handler.CheckAppGitOpsDrift))
 
 	// Password change
 	r.Name("ChangePassword").Path("/api/v1/password/change").Methods("PUT").
 		HandlerFunc(middleware.EnforceAccess(policy.PasswordChange, handler.ChangePassword))
 
+	// Audit log
+	r.Name("ListAuditEvents").Path("/api/v1/audit").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AuditRead, handler.ListAuditEvents))
+
+	// Local users
+	r.Name("ListUsers").Path("/api/v1/users").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.UserRead, handler.ListUsers))
+	r.Name("CreateUser").Path("/api/v1/users").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.UserWrite, handler.CreateUser))
+	r.Name("ResetUserPassword").Path("/api/v1/user/{username}/password").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.UserWrite, handler.ResetUserPassword))
+	r.Name("SetUserRoles").Path("/api/v1/user/{username}/roles").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.UserWrite, handler.SetUserRoles))
+	r.Name("DisableUser").Path("/api/v1/user/{username}/disable").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.UserWrite, handler.DisableUser))
+	r.Name("EnableUser").Path("/api/v1/user/{username}/enable").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.UserWrite, handler.EnableUser))
+
+	// File store
+	r.Name("GetFileStore").Path("/api/v1/filestore").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.FilestoreRead, handler.GetFileStore))
+	r.Name("MigrateFileStore").Path("/api/v1/filestore/migrate").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.FilestoreWrite, handler.MigrateFileStore))
+	r.Name("GetFileStoreMigrationStatus").Path("/api/v1/filestore/migrate/status").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.FilestoreRead, handler.GetFileStoreMigrationStatus))
+
+	// Version retention
+	r.Name("GetVersionRetentionPolicy").Path("/api/v1/app/{appSlug}/version-retention").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppVersionretentionRead, handler.GetVersionRetentionPolicy))
+	r.Name("SetVersionRetentionPolicy").Path("/api/v1/app/{appSlug}/version-retention").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppVersionretentionWrite, handler.SetVersionRetentionPolicy))
+	r.Name("PruneAppVersions").Path("/api/v1/app/{appSlug}/version-retention/prune").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppVersionretentionWrite, handler.PruneAppVersions))
+
+	// Soak policy
+	r.Name("GetSoakPolicy").Path("/api/v1/app/{appSlug}/soak-policy").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamRead, handler.GetSoakPolicy))
+	r.Name("SetSoakPolicy").Path("/api/v1/app/{appSlug}/soak-policy").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.SetSoakPolicy))
+	r.Name("ExportApprovedVersions").Path("/api/v1/app/{appSlug}/approved-versions/export").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamRead, handler.ExportApprovedVersions))
+	r.Name("ImportApprovedVersions").Path("/api/v1/app/{appSlug}/approved-versions/import").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.ImportApprovedVersions))
+
+	// Webhooks
+	r.Name("ListWebhooks").Path("/api/v1/webhooks").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.WebhookRead, handler.ListWebhooks))
+	r.Name("CreateWebhook").Path("/api/v1/webhooks").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.WebhookWrite, handler.CreateWebhook))
+	r.Name("DeleteWebhook").Path("/api/v1/webhook/{webhookId}").Methods("DELETE").
+		HandlerFunc(middleware.EnforceAccess(policy.WebhookWrite, handler.DeleteWebhook))
+	r.Name("TestWebhook").Path("/api/v1/webhook/{webhookId}/test").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.WebhookWrite, handler.TestWebhook))
+	r.Name("ListWebhookDeliveries").Path("/api/v1/webhook/{webhookId}/deliveries").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.WebhookRead, handler.ListWebhookDeliveries))
+
 	// Helm
 	r.Name("IsHelmManaged").Path("/api/v1/is-helm-managed").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.IsHelmManaged, handler.IsHelmManaged))
@@ -340,13 +430,13 @@ func RegisterTokenAuthRoutes(handler *Handler, debugRouter *mux.Router, loggingR
 
 func RegisterUnauthenticatedRoutes(handler *Handler, kotsStore store.Store, debugRouter *mux.Router, loggingRouter *mux.Router) {
 	debugRouter.HandleFunc("/healthz", handler.Healthz)
-	loggingRouter.HandleFunc("/api/v1/login", handler.Login)
+	loggingRouter.HandleFunc("/api/v1/login", auditSession(kotsStore, handler.Login))
 	loggingRouter.HandleFunc("/api/v1/login/info", handler.GetLoginInfo)
-	loggingRouter.HandleFunc("/api/v1/logout", handler.Logout) // this route uses its own auth
+	loggingRouter.HandleFunc("/api/v1/logout", auditSession(kotsStore, handler.Logout)) // this route uses its own auth
 	loggingRouter.Path("/api/v1/metadata").Methods("GET").HandlerFunc(GetMetadataHandler(GetMetaDataConfig, kotsStore))
 
 	loggingRouter.HandleFunc("/api/v1/oidc/login", handler.OIDCLogin)
-	loggingRouter.HandleFunc("/api/v1/oidc/login/callback", handler.OIDCLoginCallback)
+	loggingRouter.HandleFunc("/api/v1/oidc/login/callback", auditSession(kotsStore, handler.OIDCLoginCallback))
 
 	loggingRouter.Path("/api/v1/troubleshoot/{appId}/{bundleId}").Methods("PUT").HandlerFunc(handler.UploadSupportBundle)
 	loggingRouter.Path("/api/v1/troubleshoot/supportbundle/{bundleId}/redactions").Methods("PUT").HandlerFunc(handler.SetSupportBundleRedactions)
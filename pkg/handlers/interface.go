@@ -46,6 +46,7 @@ type KOTSHandler interface {
 	ListApps(w http.ResponseWriter, r *http.Request)
 	GetApp(w http.ResponseWriter, r *http.Request)
 	GetAppStatus(w http.ResponseWriter, r *http.Request)
+	GetAppStatusHistory(w http.ResponseWriter, r *http.Request)
 	GetAppVersionHistory(w http.ResponseWriter, r *http.Request)
 	GetLatestDeployableVersion(w http.ResponseWriter, r *http.Request)
 	GetUpdateDownloadStatus(w http.ResponseWriter, r *http.Request) // NOTE: appSlug is unused
@@ -102,6 +103,8 @@ type KOTSHandler interface {
 	AppUpdateCheck(w http.ResponseWriter, r *http.Request)
 	SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request)
 	GetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request)
+	GetUpdateCheckStatuses(w http.ResponseWriter, r *http.Request)
+	SetMaintenanceWindow(w http.ResponseWriter, r *http.Request)
 	RemoveApp(w http.ResponseWriter, r *http.Request)
 
 	// App snapshot routes
@@ -113,12 +116,24 @@ type KOTSHandler interface {
 	ListBackups(w http.ResponseWriter, r *http.Request)
 	GetSnapshotConfig(w http.ResponseWriter, r *http.Request)
 	SaveSnapshotConfig(w http.ResponseWriter, r *http.Request)
+	GetSnapshotHooks(w http.ResponseWriter, r *http.Request)
+	SetSnapshotHooks(w http.ResponseWriter, r *http.Request)
+	GetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	SetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	PreviewSnapshotRetention(w http.ResponseWriter, r *http.Request)
+	VerifyRestore(w http.ResponseWriter, r *http.Request)
+	GetRestoreVerification(w http.ResponseWriter, r *http.Request)
+	GetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request)
+	SetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request)
 
 	// Global snapshot routes
 	ListInstanceBackups(w http.ResponseWriter, r *http.Request)
 	CreateInstanceBackup(w http.ResponseWriter, r *http.Request)
 	GetInstanceSnapshotConfig(w http.ResponseWriter, r *http.Request)
 	SaveInstanceSnapshotConfig(w http.ResponseWriter, r *http.Request)
+	GetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	SetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	PreviewInstanceSnapshotRetention(w http.ResponseWriter, r *http.Request)
 	GetGlobalSnapshotSettings(w http.ResponseWriter, r *http.Request)
 	UpdateGlobalSnapshotSettings(w http.ResponseWriter, r *http.Request)
 	GetFileSystemSnapshotProviderInstructions(w http.ResponseWriter, r *http.Request)
@@ -155,10 +170,46 @@ type KOTSHandler interface {
 	CreateGitOps(w http.ResponseWriter, r *http.Request)
 	ResetGitOps(w http.ResponseWriter, r *http.Request)
 	GetGitOpsRepo(w http.ResponseWriter, r *http.Request)
+	GetAppGitOpsStatus(w http.ResponseWriter, r *http.Request)
+	CheckAppGitOpsDrift(w http.ResponseWriter, r *http.Request)
 
 	// Password change
 	ChangePassword(w http.ResponseWriter, r *http.Request)
 
+	// Audit log
+	ListAuditEvents(w http.ResponseWriter, r *http.Request)
+
+	// Local users
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	CreateUser(w http.ResponseWriter, r *http.Request)
+	ResetUserPassword(w http.ResponseWriter, r *http.Request)
+	SetUserRoles(w http.ResponseWriter, r *http.Request)
+	DisableUser(w http.ResponseWriter, r *http.Request)
+	EnableUser(w http.ResponseWriter, r *http.Request)
+
+	// File store
+	GetFileStore(w http.ResponseWriter, r *http.Request)
+	MigrateFileStore(w http.ResponseWriter, r *http.Request)
+	GetFileStoreMigrationStatus(w http.ResponseWriter, r *http.Request)
+
+	// Version retention
+	GetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	SetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request)
+	PruneAppVersions(w http.ResponseWriter, r *http.Request)
+
+	// Soak policy
+	GetSoakPolicy(w http.ResponseWriter, r *http.Request)
+	SetSoakPolicy(w http.ResponseWriter, r *http.Request)
+	ExportApprovedVersions(w http.ResponseWriter, r *http.Request)
+	ImportApprovedVersions(w http.ResponseWriter, r *http.Request)
+
+	// Webhooks
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	TestWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+
 	// Helm
 	IsHelmManaged(w http.ResponseWriter, r *http.Request)
 	GetAppValuesFile(w http.ResponseWriter, r *http.Request)
@@ -11,6 +11,7 @@ import (
 
 	oidc "github.com/coreos/go-oidc"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/handlers/types"
 	"github.com/replicatedhq/kots/pkg/identity"
 	identityclient "github.com/replicatedhq/kots/pkg/identity/client"
@@ -27,6 +28,8 @@ import (
 )
 
 type LoginRequest struct {
+	// Username is only set when logging in as a local user instead of with the shared password
+	Username string `json:"username,omitempty"`
 	Password string `json:"password"`
 }
 
@@ -76,13 +79,23 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	foundUser, err := user.LogIn(loginRequest.Password)
+	foundUser, err := user.LogIn(loginRequest.Username, loginRequest.Password)
 	if err == user.ErrInvalidPassword {
 		loginResponse.Error = "Invalid password. Please try again."
+		if loginRequest.Username != "" {
+			loginResponse.Error = "Invalid username or password. Please try again."
+		}
 		JSON(w, http.StatusUnauthorized, loginResponse)
 		return
 	} else if err == user.ErrTooManyAttempts {
 		loginResponse.Error = "Admin Console has been locked.  Please reset password using the \"kubectl kots reset-password\" command."
+		if loginRequest.Username != "" {
+			loginResponse.Error = "This account has been locked.  Please reset the password using the \"kubectl kots user reset-password\" command."
+		}
+		JSON(w, http.StatusUnauthorized, loginResponse)
+		return
+	} else if err == user.ErrUserDisabled {
+		loginResponse.Error = "This account has been disabled."
 		JSON(w, http.StatusUnauthorized, loginResponse)
 		return
 	} else if err != nil {
@@ -91,8 +104,13 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: super user permissions
-	roles := session.GetSessionRolesFromRBAC(nil, identity.DefaultGroups)
+	var roles []string
+	if foundUser.IsLocal() {
+		roles = foundUser.Roles
+	} else {
+		// TODO: super user permissions
+		roles = session.GetSessionRolesFromRBAC(nil, identity.DefaultGroups)
+	}
 
 	issuedAt, expiresAt := time.Now(), time.Now().Add(SessionTimeout)
 	createdSession, err := store.GetStore().CreateSession(foundUser, issuedAt, expiresAt, roles)
@@ -101,6 +119,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		JSON(w, http.StatusInternalServerError, loginResponse)
 		return
 	}
+	audit.RecordSession(r, createdSession)
 
 	signedJWT, err := session.SignJWT(createdSession)
 	if err != nil {
@@ -355,6 +374,7 @@ func (h *Handler) OIDCLoginCallback(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	audit.RecordSession(r, createdSession)
 
 	signedJWT, err := session.SignJWT(createdSession)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/session"
 	"github.com/replicatedhq/kots/pkg/store"
@@ -46,6 +47,8 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.RecordSession(r, sess)
+
 	if err := store.GetStore().DeleteSession(sess.ID); err != nil {
 		logger.Error(err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/audit"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/maintenancewindow"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/updatechecker"
+)
+
+type SetMaintenanceWindowRequest struct {
+	// MaintenanceWindow is removed when not set, which allows auto-deploys at any time
+	MaintenanceWindow *apptypes.MaintenanceWindow `json:"maintenanceWindow"`
+}
+
+type SetMaintenanceWindowResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// SetMaintenanceWindow sets the window during which versions are auto-deployed. Versions that are waiting for the
+// window are deployed or rescheduled according to the new window.
+func (h *Handler) SetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	response := SetMaintenanceWindowResponse{}
+
+	request := SetMaintenanceWindowRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	if request.MaintenanceWindow != nil {
+		if err := maintenancewindow.Validate(*request.MaintenanceWindow); err != nil {
+			response.Error = errors.Wrap(err, "invalid maintenance window").Error()
+			logger.Error(errors.New(response.Error))
+			JSON(w, http.StatusBadRequest, response)
+			return
+		}
+	}
+
+	foundApp, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		response.Error = "failed to get app from slug"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	if err := store.GetStore().SetMaintenanceWindow(foundApp.ID, request.MaintenanceWindow); err != nil {
+		response.Error = "failed to set maintenance window"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	audit.RecordChange(r, foundApp.MaintenanceWindow, request.MaintenanceWindow)
+
+	if err := updatechecker.ReschedulePendingAutoDeploys(foundApp.ID, request.MaintenanceWindow); err != nil {
+		response.Error = "failed to reschedule pending auto-deploys"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	JSON(w, http.StatusNoContent, "")
+}
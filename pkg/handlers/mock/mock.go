@@ -130,6 +130,18 @@ func (mr *MockKOTSHandlerMockRecorder) CheckAirgapBundleChunk(w, r interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAirgapBundleChunk", reflect.TypeOf((*MockKOTSHandler)(nil).CheckAirgapBundleChunk), w, r)
 }
 
+// CheckAppGitOpsDrift mocks base method.
+func (m *MockKOTSHandler) CheckAppGitOpsDrift(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CheckAppGitOpsDrift", w, r)
+}
+
+// CheckAppGitOpsDrift indicates an expected call of CheckAppGitOpsDrift.
+func (mr *MockKOTSHandlerMockRecorder) CheckAppGitOpsDrift(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAppGitOpsDrift", reflect.TypeOf((*MockKOTSHandler)(nil).CheckAppGitOpsDrift), w, r)
+}
+
 // CollectHelmSupportBundle mocks base method.
 func (m *MockKOTSHandler) CollectHelmSupportBundle(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -238,6 +250,30 @@ func (mr *MockKOTSHandlerMockRecorder) CreateInstanceBackup(w, r interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceBackup", reflect.TypeOf((*MockKOTSHandler)(nil).CreateInstanceBackup), w, r)
 }
 
+// CreateUser mocks base method.
+func (m *MockKOTSHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateUser", w, r)
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockKOTSHandlerMockRecorder) CreateUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockKOTSHandler)(nil).CreateUser), w, r)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockKOTSHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateWebhook", w, r)
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockKOTSHandlerMockRecorder) CreateWebhook(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockKOTSHandler)(nil).CreateWebhook), w, r)
+}
+
 // CurrentAppConfig mocks base method.
 func (m *MockKOTSHandler) CurrentAppConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -310,6 +346,18 @@ func (mr *MockKOTSHandlerMockRecorder) DeleteSupportBundle(w, r interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSupportBundle", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteSupportBundle), w, r)
 }
 
+// DeleteWebhook mocks base method.
+func (m *MockKOTSHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteWebhook", w, r)
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockKOTSHandlerMockRecorder) DeleteWebhook(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteWebhook), w, r)
+}
+
 // DeployAppVersion mocks base method.
 func (m *MockKOTSHandler) DeployAppVersion(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -334,6 +382,18 @@ func (mr *MockKOTSHandlerMockRecorder) DisableAppGitOps(w, r interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableAppGitOps", reflect.TypeOf((*MockKOTSHandler)(nil).DisableAppGitOps), w, r)
 }
 
+// DisableUser mocks base method.
+func (m *MockKOTSHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DisableUser", w, r)
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockKOTSHandlerMockRecorder) DisableUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockKOTSHandler)(nil).DisableUser), w, r)
+}
+
 // DockerHubSecretUpdated mocks base method.
 func (m *MockKOTSHandler) DockerHubSecretUpdated(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -418,6 +478,18 @@ func (mr *MockKOTSHandlerMockRecorder) DrainKurlNode(w, r interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrainKurlNode", reflect.TypeOf((*MockKOTSHandler)(nil).DrainKurlNode), w, r)
 }
 
+// EnableUser mocks base method.
+func (m *MockKOTSHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableUser", w, r)
+}
+
+// EnableUser indicates an expected call of EnableUser.
+func (mr *MockKOTSHandlerMockRecorder) EnableUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUser", reflect.TypeOf((*MockKOTSHandler)(nil).EnableUser), w, r)
+}
+
 // ExchangePlatformLicense mocks base method.
 func (m *MockKOTSHandler) ExchangePlatformLicense(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -430,6 +502,18 @@ func (mr *MockKOTSHandlerMockRecorder) ExchangePlatformLicense(w, r interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangePlatformLicense", reflect.TypeOf((*MockKOTSHandler)(nil).ExchangePlatformLicense), w, r)
 }
 
+// ExportApprovedVersions mocks base method.
+func (m *MockKOTSHandler) ExportApprovedVersions(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExportApprovedVersions", w, r)
+}
+
+// ExportApprovedVersions indicates an expected call of ExportApprovedVersions.
+func (mr *MockKOTSHandlerMockRecorder) ExportApprovedVersions(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportApprovedVersions", reflect.TypeOf((*MockKOTSHandler)(nil).ExportApprovedVersions), w, r)
+}
+
 // GarbageCollectImages mocks base method.
 func (m *MockKOTSHandler) GarbageCollectImages(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -574,6 +658,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppDashboard(w, r interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppDashboard", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppDashboard), w, r)
 }
 
+// GetAppGitOpsStatus mocks base method.
+func (m *MockKOTSHandler) GetAppGitOpsStatus(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppGitOpsStatus", w, r)
+}
+
+// GetAppGitOpsStatus indicates an expected call of GetAppGitOpsStatus.
+func (mr *MockKOTSHandlerMockRecorder) GetAppGitOpsStatus(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppGitOpsStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppGitOpsStatus), w, r)
+}
+
 // GetAppIdentityServiceConfig mocks base method.
 func (m *MockKOTSHandler) GetAppIdentityServiceConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -622,6 +718,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppStatus(w, r interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppStatus), w, r)
 }
 
+// GetAppStatusHistory mocks base method.
+func (m *MockKOTSHandler) GetAppStatusHistory(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppStatusHistory", w, r)
+}
+
+// GetAppStatusHistory indicates an expected call of GetAppStatusHistory.
+func (mr *MockKOTSHandlerMockRecorder) GetAppStatusHistory(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatusHistory", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppStatusHistory), w, r)
+}
+
 // GetAppValuesFile mocks base method.
 func (m *MockKOTSHandler) GetAppValuesFile(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -730,6 +838,30 @@ func (mr *MockKOTSHandlerMockRecorder) GetEmbeddedClusterNodes(w, r interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddedClusterNodes", reflect.TypeOf((*MockKOTSHandler)(nil).GetEmbeddedClusterNodes), w, r)
 }
 
+// GetFileStore mocks base method.
+func (m *MockKOTSHandler) GetFileStore(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetFileStore", w, r)
+}
+
+// GetFileStore indicates an expected call of GetFileStore.
+func (mr *MockKOTSHandlerMockRecorder) GetFileStore(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileStore", reflect.TypeOf((*MockKOTSHandler)(nil).GetFileStore), w, r)
+}
+
+// GetFileStoreMigrationStatus mocks base method.
+func (m *MockKOTSHandler) GetFileStoreMigrationStatus(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetFileStoreMigrationStatus", w, r)
+}
+
+// GetFileStoreMigrationStatus indicates an expected call of GetFileStoreMigrationStatus.
+func (mr *MockKOTSHandlerMockRecorder) GetFileStoreMigrationStatus(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileStoreMigrationStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetFileStoreMigrationStatus), w, r)
+}
+
 // GetFileSystemSnapshotProviderInstructions mocks base method.
 func (m *MockKOTSHandler) GetFileSystemSnapshotProviderInstructions(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -802,6 +934,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetInstanceSnapshotConfig(w, r interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceSnapshotConfig", reflect.TypeOf((*MockKOTSHandler)(nil).GetInstanceSnapshotConfig), w, r)
 }
 
+// GetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) GetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetInstanceSnapshotRetentionPolicy", w, r)
+}
+
+// GetInstanceSnapshotRetentionPolicy indicates an expected call of GetInstanceSnapshotRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) GetInstanceSnapshotRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).GetInstanceSnapshotRetentionPolicy), w, r)
+}
+
 // GetKotsadmRegistry mocks base method.
 func (m *MockKOTSHandler) GetKotsadmRegistry(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -982,6 +1126,30 @@ func (mr *MockKOTSHandlerMockRecorder) GetRestoreStatus(w, r interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestoreStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetRestoreStatus), w, r)
 }
 
+// GetRestoreVerification mocks base method.
+func (m *MockKOTSHandler) GetRestoreVerification(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetRestoreVerification", w, r)
+}
+
+// GetRestoreVerification indicates an expected call of GetRestoreVerification.
+func (mr *MockKOTSHandlerMockRecorder) GetRestoreVerification(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestoreVerification", reflect.TypeOf((*MockKOTSHandler)(nil).GetRestoreVerification), w, r)
+}
+
+// GetRestoreVerificationSchedule mocks base method.
+func (m *MockKOTSHandler) GetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetRestoreVerificationSchedule", w, r)
+}
+
+// GetRestoreVerificationSchedule indicates an expected call of GetRestoreVerificationSchedule.
+func (mr *MockKOTSHandlerMockRecorder) GetRestoreVerificationSchedule(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestoreVerificationSchedule", reflect.TypeOf((*MockKOTSHandler)(nil).GetRestoreVerificationSchedule), w, r)
+}
+
 // GetSnapshotConfig mocks base method.
 func (m *MockKOTSHandler) GetSnapshotConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -994,6 +1162,42 @@ func (mr *MockKOTSHandlerMockRecorder) GetSnapshotConfig(w, r interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotConfig", reflect.TypeOf((*MockKOTSHandler)(nil).GetSnapshotConfig), w, r)
 }
 
+// GetSnapshotHooks mocks base method.
+func (m *MockKOTSHandler) GetSnapshotHooks(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetSnapshotHooks", w, r)
+}
+
+// GetSnapshotHooks indicates an expected call of GetSnapshotHooks.
+func (mr *MockKOTSHandlerMockRecorder) GetSnapshotHooks(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotHooks", reflect.TypeOf((*MockKOTSHandler)(nil).GetSnapshotHooks), w, r)
+}
+
+// GetSnapshotRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) GetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetSnapshotRetentionPolicy", w, r)
+}
+
+// GetSnapshotRetentionPolicy indicates an expected call of GetSnapshotRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) GetSnapshotRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).GetSnapshotRetentionPolicy), w, r)
+}
+
+// GetSoakPolicy mocks base method.
+func (m *MockKOTSHandler) GetSoakPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetSoakPolicy", w, r)
+}
+
+// GetSoakPolicy indicates an expected call of GetSoakPolicy.
+func (mr *MockKOTSHandlerMockRecorder) GetSoakPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSoakPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).GetSoakPolicy), w, r)
+}
+
 // GetSupportBundle mocks base method.
 func (m *MockKOTSHandler) GetSupportBundle(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1042,6 +1246,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetSupportBundleRedactions(w, r interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSupportBundleRedactions", reflect.TypeOf((*MockKOTSHandler)(nil).GetSupportBundleRedactions), w, r)
 }
 
+// GetUpdateCheckStatuses mocks base method.
+func (m *MockKOTSHandler) GetUpdateCheckStatuses(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetUpdateCheckStatuses", w, r)
+}
+
+// GetUpdateCheckStatuses indicates an expected call of GetUpdateCheckStatuses.
+func (mr *MockKOTSHandlerMockRecorder) GetUpdateCheckStatuses(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateCheckStatuses", reflect.TypeOf((*MockKOTSHandler)(nil).GetUpdateCheckStatuses), w, r)
+}
+
 // GetUpdateDownloadStatus mocks base method.
 func (m *MockKOTSHandler) GetUpdateDownloadStatus(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1066,6 +1282,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetVeleroStatus(w, r interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVeleroStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetVeleroStatus), w, r)
 }
 
+// GetVersionRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) GetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetVersionRetentionPolicy", w, r)
+}
+
+// GetVersionRetentionPolicy indicates an expected call of GetVersionRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) GetVersionRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersionRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).GetVersionRetentionPolicy), w, r)
+}
+
 // IgnorePreflightRBACErrors mocks base method.
 func (m *MockKOTSHandler) IgnorePreflightRBACErrors(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1078,6 +1306,18 @@ func (mr *MockKOTSHandlerMockRecorder) IgnorePreflightRBACErrors(w, r interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IgnorePreflightRBACErrors", reflect.TypeOf((*MockKOTSHandler)(nil).IgnorePreflightRBACErrors), w, r)
 }
 
+// ImportApprovedVersions mocks base method.
+func (m *MockKOTSHandler) ImportApprovedVersions(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ImportApprovedVersions", w, r)
+}
+
+// ImportApprovedVersions indicates an expected call of ImportApprovedVersions.
+func (mr *MockKOTSHandlerMockRecorder) ImportApprovedVersions(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportApprovedVersions", reflect.TypeOf((*MockKOTSHandler)(nil).ImportApprovedVersions), w, r)
+}
+
 // InitGitOpsConnection mocks base method.
 func (m *MockKOTSHandler) InitGitOpsConnection(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1114,6 +1354,18 @@ func (mr *MockKOTSHandlerMockRecorder) ListApps(w, r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockKOTSHandler)(nil).ListApps), w, r)
 }
 
+// ListAuditEvents mocks base method.
+func (m *MockKOTSHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListAuditEvents", w, r)
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockKOTSHandlerMockRecorder) ListAuditEvents(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockKOTSHandler)(nil).ListAuditEvents), w, r)
+}
+
 // ListBackups mocks base method.
 func (m *MockKOTSHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1162,6 +1414,42 @@ func (mr *MockKOTSHandlerMockRecorder) ListSupportBundles(w, r interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSupportBundles", reflect.TypeOf((*MockKOTSHandler)(nil).ListSupportBundles), w, r)
 }
 
+// ListUsers mocks base method.
+func (m *MockKOTSHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListUsers", w, r)
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockKOTSHandlerMockRecorder) ListUsers(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockKOTSHandler)(nil).ListUsers), w, r)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockKOTSHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListWebhookDeliveries", w, r)
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockKOTSHandlerMockRecorder) ListWebhookDeliveries(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockKOTSHandler)(nil).ListWebhookDeliveries), w, r)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockKOTSHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListWebhooks", w, r)
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockKOTSHandlerMockRecorder) ListWebhooks(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockKOTSHandler)(nil).ListWebhooks), w, r)
+}
+
 // LiveAppConfig mocks base method.
 func (m *MockKOTSHandler) LiveAppConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1174,6 +1462,18 @@ func (mr *MockKOTSHandlerMockRecorder) LiveAppConfig(w, r interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveAppConfig", reflect.TypeOf((*MockKOTSHandler)(nil).LiveAppConfig), w, r)
 }
 
+// MigrateFileStore mocks base method.
+func (m *MockKOTSHandler) MigrateFileStore(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MigrateFileStore", w, r)
+}
+
+// MigrateFileStore indicates an expected call of MigrateFileStore.
+func (mr *MockKOTSHandlerMockRecorder) MigrateFileStore(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateFileStore", reflect.TypeOf((*MockKOTSHandler)(nil).MigrateFileStore), w, r)
+}
+
 // Ping mocks base method.
 func (m *MockKOTSHandler) Ping(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1198,6 +1498,42 @@ func (mr *MockKOTSHandlerMockRecorder) PreflightsReports(w, r interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreflightsReports", reflect.TypeOf((*MockKOTSHandler)(nil).PreflightsReports), w, r)
 }
 
+// PreviewInstanceSnapshotRetention mocks base method.
+func (m *MockKOTSHandler) PreviewInstanceSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PreviewInstanceSnapshotRetention", w, r)
+}
+
+// PreviewInstanceSnapshotRetention indicates an expected call of PreviewInstanceSnapshotRetention.
+func (mr *MockKOTSHandlerMockRecorder) PreviewInstanceSnapshotRetention(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewInstanceSnapshotRetention", reflect.TypeOf((*MockKOTSHandler)(nil).PreviewInstanceSnapshotRetention), w, r)
+}
+
+// PreviewSnapshotRetention mocks base method.
+func (m *MockKOTSHandler) PreviewSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PreviewSnapshotRetention", w, r)
+}
+
+// PreviewSnapshotRetention indicates an expected call of PreviewSnapshotRetention.
+func (mr *MockKOTSHandlerMockRecorder) PreviewSnapshotRetention(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewSnapshotRetention", reflect.TypeOf((*MockKOTSHandler)(nil).PreviewSnapshotRetention), w, r)
+}
+
+// PruneAppVersions mocks base method.
+func (m *MockKOTSHandler) PruneAppVersions(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PruneAppVersions", w, r)
+}
+
+// PruneAppVersions indicates an expected call of PruneAppVersions.
+func (mr *MockKOTSHandlerMockRecorder) PruneAppVersions(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneAppVersions", reflect.TypeOf((*MockKOTSHandler)(nil).PruneAppVersions), w, r)
+}
+
 // RedeployAppVersion mocks base method.
 func (m *MockKOTSHandler) RedeployAppVersion(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1246,6 +1582,18 @@ func (mr *MockKOTSHandlerMockRecorder) ResetGitOps(w, r interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetGitOps", reflect.TypeOf((*MockKOTSHandler)(nil).ResetGitOps), w, r)
 }
 
+// ResetUserPassword mocks base method.
+func (m *MockKOTSHandler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetUserPassword", w, r)
+}
+
+// ResetUserPassword indicates an expected call of ResetUserPassword.
+func (mr *MockKOTSHandlerMockRecorder) ResetUserPassword(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserPassword", reflect.TypeOf((*MockKOTSHandler)(nil).ResetUserPassword), w, r)
+}
+
 // RestoreApps mocks base method.
 func (m *MockKOTSHandler) RestoreApps(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1318,6 +1666,30 @@ func (mr *MockKOTSHandlerMockRecorder) SetAutomaticUpdatesConfig(w, r interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutomaticUpdatesConfig", reflect.TypeOf((*MockKOTSHandler)(nil).SetAutomaticUpdatesConfig), w, r)
 }
 
+// SetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) SetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetInstanceSnapshotRetentionPolicy", w, r)
+}
+
+// SetInstanceSnapshotRetentionPolicy indicates an expected call of SetInstanceSnapshotRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) SetInstanceSnapshotRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).SetInstanceSnapshotRetentionPolicy), w, r)
+}
+
+// SetMaintenanceWindow mocks base method.
+func (m *MockKOTSHandler) SetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaintenanceWindow", w, r)
+}
+
+// SetMaintenanceWindow indicates an expected call of SetMaintenanceWindow.
+func (mr *MockKOTSHandlerMockRecorder) SetMaintenanceWindow(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindow", reflect.TypeOf((*MockKOTSHandler)(nil).SetMaintenanceWindow), w, r)
+}
+
 // SetPrometheusAddress mocks base method.
 func (m *MockKOTSHandler) SetPrometheusAddress(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1354,6 +1726,78 @@ func (mr *MockKOTSHandlerMockRecorder) SetRedactMetadataAndYaml(w, r interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRedactMetadataAndYaml", reflect.TypeOf((*MockKOTSHandler)(nil).SetRedactMetadataAndYaml), w, r)
 }
 
+// SetRestoreVerificationSchedule mocks base method.
+func (m *MockKOTSHandler) SetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRestoreVerificationSchedule", w, r)
+}
+
+// SetRestoreVerificationSchedule indicates an expected call of SetRestoreVerificationSchedule.
+func (mr *MockKOTSHandlerMockRecorder) SetRestoreVerificationSchedule(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRestoreVerificationSchedule", reflect.TypeOf((*MockKOTSHandler)(nil).SetRestoreVerificationSchedule), w, r)
+}
+
+// SetSnapshotHooks mocks base method.
+func (m *MockKOTSHandler) SetSnapshotHooks(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSnapshotHooks", w, r)
+}
+
+// SetSnapshotHooks indicates an expected call of SetSnapshotHooks.
+func (mr *MockKOTSHandlerMockRecorder) SetSnapshotHooks(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotHooks", reflect.TypeOf((*MockKOTSHandler)(nil).SetSnapshotHooks), w, r)
+}
+
+// SetSnapshotRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) SetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSnapshotRetentionPolicy", w, r)
+}
+
+// SetSnapshotRetentionPolicy indicates an expected call of SetSnapshotRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) SetSnapshotRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).SetSnapshotRetentionPolicy), w, r)
+}
+
+// SetSoakPolicy mocks base method.
+func (m *MockKOTSHandler) SetSoakPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSoakPolicy", w, r)
+}
+
+// SetSoakPolicy indicates an expected call of SetSoakPolicy.
+func (mr *MockKOTSHandlerMockRecorder) SetSoakPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSoakPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).SetSoakPolicy), w, r)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockKOTSHandler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetUserRoles", w, r)
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockKOTSHandlerMockRecorder) SetUserRoles(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockKOTSHandler)(nil).SetUserRoles), w, r)
+}
+
+// SetVersionRetentionPolicy mocks base method.
+func (m *MockKOTSHandler) SetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetVersionRetentionPolicy", w, r)
+}
+
+// SetVersionRetentionPolicy indicates an expected call of SetVersionRetentionPolicy.
+func (mr *MockKOTSHandlerMockRecorder) SetVersionRetentionPolicy(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersionRetentionPolicy", reflect.TypeOf((*MockKOTSHandler)(nil).SetVersionRetentionPolicy), w, r)
+}
+
 // ShareSupportBundle mocks base method.
 func (m *MockKOTSHandler) ShareSupportBundle(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1390,6 +1834,18 @@ func (mr *MockKOTSHandlerMockRecorder) SyncLicense(w, r interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncLicense", reflect.TypeOf((*MockKOTSHandler)(nil).SyncLicense), w, r)
 }
 
+// TestWebhook mocks base method.
+func (m *MockKOTSHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "TestWebhook", w, r)
+}
+
+// TestWebhook indicates an expected call of TestWebhook.
+func (mr *MockKOTSHandlerMockRecorder) TestWebhook(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestWebhook", reflect.TypeOf((*MockKOTSHandler)(nil).TestWebhook), w, r)
+}
+
 // UpdateAdminConsole mocks base method.
 func (m *MockKOTSHandler) UpdateAdminConsole(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1509,3 +1965,15 @@ func (mr *MockKOTSHandlerMockRecorder) ValidateAppRegistry(w, r interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAppRegistry", reflect.TypeOf((*MockKOTSHandler)(nil).ValidateAppRegistry), w, r)
 }
+
+// VerifyRestore mocks base method.
+func (m *MockKOTSHandler) VerifyRestore(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "VerifyRestore", w, r)
+}
+
+// VerifyRestore indicates an expected call of VerifyRestore.
+func (mr *MockKOTSHandlerMockRecorder) VerifyRestore(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyRestore", reflect.TypeOf((*MockKOTSHandler)(nil).VerifyRestore), w, r)
+}
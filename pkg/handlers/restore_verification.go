@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+	cron "github.com/robfig/cron/v3"
+)
+
+type RestoreVerificationResponse struct {
+	// RestoreVerification is nil if the backup has never been verified
+	RestoreVerification *snapshottypes.RestoreVerification `json:"restoreVerification"`
+}
+
+type RestoreVerificationScheduleRequest struct {
+	// Schedule is a cron spec, scheduled verifications are disabled when empty
+	Schedule string `json:"schedule"`
+}
+
+type RestoreVerificationScheduleResponse struct {
+	Schedule string     `json:"schedule"`
+	NextAt   *time.Time `json:"nextAt,omitempty"`
+}
+
+// VerifyRestore starts a test restore of the backup into scratch namespaces
+func (h *Handler) VerifyRestore(w http.ResponseWriter, r *http.Request) {
+	// check minimal rbac
+	if err := requiresKotsadmVeleroAccess(w, r); err != nil {
+		return
+	}
+
+	a, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	verification, err := snapshot.StartRestoreVerification(r.Context(), util.PodNamespace, a, mux.Vars(r)["snapshotName"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to start restore verification"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, RestoreVerificationResponse{RestoreVerification: verification})
+}
+
+// GetRestoreVerification returns the result of the last test restore of the backup
+func (h *Handler) GetRestoreVerification(w http.ResponseWriter, r *http.Request) {
+	backup, err := snapshot.GetBackup(r.Context(), util.PodNamespace, mux.Vars(r)["snapshotName"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get backup"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	verification, err := snapshot.GetRestoreVerification(backup)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get restore verification"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, RestoreVerificationResponse{RestoreVerification: verification})
+}
+
+func (h *Handler) GetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request) {
+	a, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, RestoreVerificationScheduleResponse{
+		Schedule: a.RestoreVerificationSchedule,
+		NextAt:   a.RestoreVerificationNextAt,
+	})
+}
+
+func (h *Handler) SetRestoreVerificationSchedule(w http.ResponseWriter, r *http.Request) {
+	request := RestoreVerificationScheduleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var nextAt *time.Time
+	if request.Schedule != "" {
+		cronSchedule, err := cron.ParseStandard(request.Schedule)
+		if err != nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(fmt.Errorf("invalid cron schedule expression %q: %v", request.Schedule, err)))
+			return
+		}
+		next := cronSchedule.Next(time.Now())
+		nextAt = &next
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetRestoreVerificationSchedule(appID, request.Schedule, nextAt); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set restore verification schedule"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, RestoreVerificationScheduleResponse{
+		Schedule: request.Schedule,
+		NextAt:   nextAt,
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/session"
 	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
 	"github.com/replicatedhq/kots/pkg/store"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -92,20 +93,41 @@ func requireValidSession(kotsStore store.Store, w http.ResponseWriter, r *http.R
 		return nil, err
 	}
 
-	passwordUpdatedAt, err := kotsStore.GetPasswordUpdatedAt()
-	if err != nil {
-		response := types.ErrorResponse{Error: util.StrPointer("failed to validate session with current password")}
-		JSON(w, http.StatusUnauthorized, response)
-		return nil, err
-	}
-	if passwordUpdatedAt != nil && passwordUpdatedAt.After(sess.IssuedAt) {
-		if err := kotsStore.DeleteSession(sess.ID); err != nil {
-			logger.Error(errors.Wrapf(err, "password was updated after session created. failed to delete invalid session %s", sess.ID))
+	if sess.Username != "" {
+		localUser, err := kotsStore.GetUser(sess.UserID)
+		if err != nil && !kotsStore.IsNotFound(err) {
+			response := types.ErrorResponse{Error: util.StrPointer("failed to validate session with current user")}
+			JSON(w, http.StatusUnauthorized, response)
+			return nil, errors.Wrap(err, "failed to get user")
+		} else if err != nil {
+			localUser = nil
+		}
+		if err := validateLocalUserSession(localUser, sess); err != nil {
+			if err := kotsStore.DeleteSession(sess.ID); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to delete invalid session %s", sess.ID))
+			}
+			response := types.ErrorResponse{Error: util.StrPointer(err.Error())}
+			JSON(w, http.StatusUnauthorized, response)
+			return nil, err
+		}
+		// role changes take effect without having to login again
+		sess.Roles = localUser.Roles
+	} else {
+		passwordUpdatedAt, err := kotsStore.GetPasswordUpdatedAt()
+		if err != nil {
+			response := types.ErrorResponse{Error: util.StrPointer("failed to validate session with current password")}
+			JSON(w, http.StatusUnauthorized, response)
+			return nil, err
+		}
+		if passwordUpdatedAt != nil && passwordUpdatedAt.After(sess.IssuedAt) {
+			if err := kotsStore.DeleteSession(sess.ID); err != nil {
+				logger.Error(errors.Wrapf(err, "password was updated after session created. failed to delete invalid session %s", sess.ID))
+			}
+			err := errors.New("password changed, please login again")
+			response := types.ErrorResponse{Error: util.StrPointer(err.Error())}
+			JSON(w, http.StatusUnauthorized, response)
+			return nil, err
 		}
-		err := errors.New("password changed, please login again")
-		response := types.ErrorResponse{Error: util.StrPointer(err.Error())}
-		JSON(w, http.StatusUnauthorized, response)
-		return nil, err
 	}
 
 	// give the user the full session timeout if they have been active at least an hour
@@ -129,6 +151,21 @@ func requireValidSession(kotsStore store.Store, w http.ResponseWriter, r *http.R
 	return sess, nil
 }
 
+// validateLocalUserSession makes sure the local user that owns the session still exists, is enabled
+// and has not had their password reset since the session was issued
+func validateLocalUserSession(localUser *usertypes.User, sess *sessiontypes.Session) error {
+	if localUser == nil {
+		return errors.New("user not found, please login again")
+	}
+	if localUser.IsDisabled {
+		return errors.New("user is disabled")
+	}
+	if localUser.PasswordUpdatedAt != nil && localUser.PasswordUpdatedAt.After(sess.IssuedAt) {
+		return errors.New("password changed, please login again")
+	}
+	return nil
+}
+
 func requireValidKOTSToken(w http.ResponseWriter, r *http.Request) error {
 	if r.Header.Get("Authorization") == "" {
 		w.WriteHeader(http.StatusUnauthorized)
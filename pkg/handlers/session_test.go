@@ -13,6 +13,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/session/types"
 	"github.com/replicatedhq/kots/pkg/store"
 	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -478,3 +479,83 @@ func Test_requireValidSession_PasswordUpdated_AfterSessionIssuedErr_logDeleteSes
 	req.Equal(want, got)
 	req.Equal(401, w.Code)
 }
+
+func Test_requireValidSession_localUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	sess := &types.Session{
+		ID:        "session-id",
+		UserID:    "user-id",
+		Username:  "jdoe",
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(12 * time.Hour),
+		Roles:     []string{"cluster-admin"},
+		HasRBAC:   true,
+	}
+	sessionJWT := signJWT(t, sess)
+
+	newRequest := func() *http.Request {
+		return &http.Request{
+			Header: http.Header{
+				"Authorization": []string{fmt.Sprintf("Bearer %v", sessionJWT)},
+			},
+		}
+	}
+
+	t.Run("roles are taken from the user", func(t *testing.T) {
+		mockStore.EXPECT().GetSession(sess.ID).Return(&types.Session{
+			ID: sess.ID, UserID: sess.UserID, Username: sess.Username, IssuedAt: sess.IssuedAt, ExpiresAt: sess.ExpiresAt, Roles: sess.Roles, HasRBAC: true,
+		}, nil)
+		mockStore.EXPECT().GetUser("user-id").Return(&usertypes.User{ID: "user-id", Username: "jdoe", Roles: []string{"support"}}, nil)
+
+		got, err := requireValidSession(mockStore, httptest.NewRecorder(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, []string{"support"}, got.Roles)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		mockStore.EXPECT().GetSession(sess.ID).Return(sess, nil)
+		mockStore.EXPECT().GetUser("user-id").Return(&usertypes.User{ID: "user-id", Username: "jdoe", IsDisabled: true}, nil)
+		mockStore.EXPECT().DeleteSession(sess.ID).Return(nil)
+
+		w := httptest.NewRecorder()
+		_, err := requireValidSession(mockStore, w, newRequest())
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("password set in the same second before the session was issued", func(t *testing.T) {
+		passwordUpdatedAt := sess.IssuedAt.Add(-time.Millisecond)
+		mockStore.EXPECT().GetSession(sess.ID).Return(sess, nil)
+		mockStore.EXPECT().GetUser("user-id").Return(&usertypes.User{ID: "user-id", Username: "jdoe", PasswordUpdatedAt: &passwordUpdatedAt}, nil)
+
+		_, err := requireValidSession(mockStore, httptest.NewRecorder(), newRequest())
+		require.NoError(t, err)
+	})
+
+	t.Run("password reset right after the session was issued", func(t *testing.T) {
+		passwordUpdatedAt := sess.IssuedAt.Add(time.Microsecond)
+		mockStore.EXPECT().GetSession(sess.ID).Return(sess, nil)
+		mockStore.EXPECT().GetUser("user-id").Return(&usertypes.User{ID: "user-id", Username: "jdoe", PasswordUpdatedAt: &passwordUpdatedAt}, nil)
+		mockStore.EXPECT().DeleteSession(sess.ID).Return(nil)
+
+		w := httptest.NewRecorder()
+		_, err := requireValidSession(mockStore, w, newRequest())
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("password reset after session was issued", func(t *testing.T) {
+		passwordUpdatedAt := sess.IssuedAt.Add(time.Minute)
+		mockStore.EXPECT().GetSession(sess.ID).Return(sess, nil)
+		mockStore.EXPECT().GetUser("user-id").Return(&usertypes.User{ID: "user-id", Username: "jdoe", PasswordUpdatedAt: &passwordUpdatedAt}, nil)
+		mockStore.EXPECT().DeleteSession(sess.ID).Return(nil)
+
+		w := httptest.NewRecorder()
+		_, err := requireValidSession(mockStore, w, newRequest())
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+type SnapshotHooksRequest struct {
+	Hooks []snapshottypes.OperatorHook `json:"hooks"`
+}
+
+type SnapshotHooksResponse struct {
+	Hooks []snapshottypes.OperatorHook `json:"hooks"`
+}
+
+func (h *Handler) GetSnapshotHooks(w http.ResponseWriter, r *http.Request) {
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	hooks, err := store.GetStore().GetSnapshotHooks(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get snapshot hooks"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, SnapshotHooksResponse{Hooks: hooks})
+}
+
+// SetSnapshotHooks replaces the backup hooks of the app. The hooks are merged into the app's velero Backup spec by the next backups.
+func (h *Handler) SetSnapshotHooks(w http.ResponseWriter, r *http.Request) {
+	request := SnapshotHooksRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := snapshot.ValidateOperatorHooks(request.Hooks); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetSnapshotHooks(appID, request.Hooks); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set snapshot hooks"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	hooks := request.Hooks
+	if hooks == nil {
+		hooks = []snapshottypes.OperatorHook{}
+	}
+	JSON(w, http.StatusOK, SnapshotHooksResponse{Hooks: hooks})
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+type SnapshotRetentionPolicyRequest struct {
+	// Policy is removed when nil, and backups are only expired by their TTL
+	Policy *snapshottypes.RetentionPolicy `json:"policy"`
+}
+
+type SnapshotRetentionPolicyResponse struct {
+	Policy *snapshottypes.RetentionPolicy `json:"policy"`
+}
+
+type PreviewSnapshotRetentionRequest struct {
+	// Policy is previewed instead of the current policy when set
+	Policy *snapshottypes.RetentionPolicy `json:"policy,omitempty"`
+}
+
+func (h *Handler) GetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := store.GetStore().GetSnapshotRetentionPolicy(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get snapshot retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, SnapshotRetentionPolicyResponse{Policy: policy})
+}
+
+func (h *Handler) SetSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	request := SnapshotRetentionPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Policy != nil {
+		if err := snapshot.ValidateRetentionPolicy(*request.Policy); err != nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetSnapshotRetentionPolicy(appID, request.Policy); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set snapshot retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, SnapshotRetentionPolicyResponse{Policy: request.Policy})
+}
+
+// PreviewSnapshotRetention lists the app backups that the retention policy keeps and prunes, without pruning them
+func (h *Handler) PreviewSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	// check minimal rbac
+	if err := requiresKotsadmVeleroAccess(w, r); err != nil {
+		return
+	}
+
+	request := PreviewSnapshotRetentionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy := request.Policy
+	if policy == nil {
+		policy, err = store.GetStore().GetSnapshotRetentionPolicy(appID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get snapshot retention policy"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if policy == nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("the app does not have a snapshot retention policy")))
+			return
+		}
+	}
+	if err := snapshot.ValidateRetentionPolicy(*policy); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	preview, err := snapshot.PreviewAppRetention(r.Context(), util.PodNamespace, appID, *policy)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to preview snapshot retention"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, preview)
+}
+
+func (h *Handler) GetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	clusterID, err := getInstanceClusterID()
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := store.GetStore().GetInstanceSnapshotRetentionPolicy(clusterID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get instance snapshot retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, SnapshotRetentionPolicyResponse{Policy: policy})
+}
+
+func (h *Handler) SetInstanceSnapshotRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	request := SnapshotRetentionPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Policy != nil {
+		if err := snapshot.ValidateRetentionPolicy(*request.Policy); err != nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+	}
+
+	clusterID, err := getInstanceClusterID()
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetInstanceSnapshotRetentionPolicy(clusterID, request.Policy); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set instance snapshot retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, SnapshotRetentionPolicyResponse{Policy: request.Policy})
+}
+
+// PreviewInstanceSnapshotRetention lists the instance backups that the retention policy keeps and prunes, without pruning them
+func (h *Handler) PreviewInstanceSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	// check minimal rbac
+	if err := requiresKotsadmVeleroAccess(w, r); err != nil {
+		return
+	}
+
+	request := PreviewSnapshotRetentionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	policy := request.Policy
+	if policy == nil {
+		clusterID, err := getInstanceClusterID()
+		if err != nil {
+			logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		policy, err = store.GetStore().GetInstanceSnapshotRetentionPolicy(clusterID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get instance snapshot retention policy"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if policy == nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("instance snapshots do not have a retention policy")))
+			return
+		}
+	}
+	if err := snapshot.ValidateRetentionPolicy(*policy); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	preview, err := snapshot.PreviewInstanceRetention(r.Context(), util.PodNamespace, *policy)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to preview instance snapshot retention"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, preview)
+}
+
+// getInstanceClusterID returns the id of the cluster that instance snapshot settings are stored on
+func getInstanceClusterID() (string, error) {
+	clusters, err := store.GetStore().ListClusters()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list clusters")
+	}
+	if len(clusters) == 0 {
+		return "", errors.New("no clusters found")
+	}
+	return clusters[0].ClusterID, nil
+}
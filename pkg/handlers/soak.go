@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/soak"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+type GetSoakPolicyResponse struct {
+	// Policy is nil when versions are auto-deployed as soon as they are available
+	Policy *soaktypes.Policy `json:"policy"`
+	// ApprovedVersions are the approved versions imported from other instances
+	ApprovedVersions []soaktypes.ApprovedVersions `json:"approvedVersions"`
+}
+
+type SetSoakPolicyRequest struct {
+	// Policy is removed when nil
+	Policy *soaktypes.Policy `json:"policy"`
+}
+
+func (h *Handler) GetSoakPolicy(w http.ResponseWriter, r *http.Request) {
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := store.GetStore().GetSoakPolicy(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get soak policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	approved, err := store.GetStore().ListApprovedVersions(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list approved versions"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetSoakPolicyResponse{Policy: policy, ApprovedVersions: approved})
+}
+
+func (h *Handler) SetSoakPolicy(w http.ResponseWriter, r *http.Request) {
+	request := SetSoakPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Policy != nil {
+		if err := soak.ValidatePolicy(*request.Policy); err != nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	previousPolicy, err := store.GetStore().GetSoakPolicy(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get soak policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetSoakPolicy(appID, request.Policy); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set soak policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	audit.RecordChange(r, previousPolicy, request.Policy)
+
+	approved, err := store.GetStore().ListApprovedVersions(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list approved versions"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetSoakPolicyResponse{Policy: request.Policy, ApprovedVersions: approved})
+}
+
+// ExportApprovedVersions returns the versions that were deployed successfully on this instance, named by the
+// instanceName query param, to be imported by other instances
+func (h *Handler) ExportApprovedVersions(w http.ResponseWriter, r *http.Request) {
+	instanceName := r.URL.Query().Get("instanceName")
+	if instanceName == "" {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("an instance name is required")))
+		return
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	approved, err := soak.ExportApprovedVersions(appID, instanceName)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to export approved versions"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, approved)
+}
+
+// ImportApprovedVersions stores the approved versions exported from another instance,
+// replacing the ones previously imported from the same instance
+func (h *Handler) ImportApprovedVersions(w http.ResponseWriter, r *http.Request) {
+	approved := soaktypes.ApprovedVersions{}
+	if err := json.NewDecoder(r.Body).Decode(&approved); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if approved.InstanceName == "" {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("the approved versions do not have an instance name")))
+		return
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetApprovedVersions(appID, approved); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set approved versions"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
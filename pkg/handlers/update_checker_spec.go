@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
@@ -10,8 +11,10 @@ import (
 	"github.com/replicatedhq/kots/pkg/helm"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/maintenancewindow"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/updatechecker"
+	updatecheckertypes "github.com/replicatedhq/kots/pkg/updatechecker/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	cron "github.com/robfig/cron/v3"
 )
@@ -26,9 +29,12 @@ type SetAutomaticUpdatesConfigResponse struct {
 }
 
 type GetAutomaticUpdatesConfigResponse struct {
-	UpdateCheckerSpec string              `json:"updateCheckerSpec"`
-	AutoDeploy        apptypes.AutoDeploy `json:"autoDeploy"`
-	Error             string              `json:"error"`
+	UpdateCheckerSpec string                      `json:"updateCheckerSpec"`
+	AutoDeploy        apptypes.AutoDeploy         `json:"autoDeploy"`
+	MaintenanceWindow *apptypes.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// NextMaintenanceWindowAt is the next time auto-deploys are allowed, or now if the window is open
+	NextMaintenanceWindowAt *time.Time `json:"nextMaintenanceWindowAt,omitempty"`
+	Error                   string     `json:"error"`
 }
 
 func (h *Handler) SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request) {
@@ -192,7 +198,34 @@ func (h *Handler) GetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Reque
 		}
 		getCheckerSpecResponse.UpdateCheckerSpec = foundApp.UpdateCheckerSpec
 		getCheckerSpecResponse.AutoDeploy = foundApp.AutoDeploy
+		getCheckerSpecResponse.MaintenanceWindow = foundApp.MaintenanceWindow
+
+		if foundApp.MaintenanceWindow != nil {
+			nextOpening, err := maintenancewindow.NextOpening(*foundApp.MaintenanceWindow, time.Now())
+			if err != nil {
+				// still return the window so that it can be fixed
+				logger.Error(errors.Wrap(err, "failed to get next maintenance window"))
+			} else {
+				getCheckerSpecResponse.NextMaintenanceWindowAt = &nextOpening
+			}
+		}
 	}
 
 	JSON(w, http.StatusOK, getCheckerSpecResponse)
 }
+
+type GetUpdateCheckStatusesResponse struct {
+	Apps []updatecheckertypes.AppUpdateCheckStatus `json:"apps"`
+}
+
+// GetUpdateCheckStatuses returns the schedule and the result of the last update check of every app
+func (h *Handler) GetUpdateCheckStatuses(w http.ResponseWriter, r *http.Request) {
+	statuses, err := updatechecker.GetUpdateCheckStatuses()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get update check statuses"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetUpdateCheckStatusesResponse{Apps: statuses})
+}
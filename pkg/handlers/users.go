@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/user"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+)
+
+type UserResponse struct {
+	usertypes.User
+	IsLockedOut bool `json:"isLockedOut"`
+}
+
+type ListUsersResponse struct {
+	Users []UserResponse `json:"users"`
+}
+
+type CreateUserRequest struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+type ResetUserPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+func newUserResponse(u *usertypes.User) UserResponse {
+	return UserResponse{
+		User:        *u,
+		IsLockedOut: user.IsLockedOut(u),
+	}
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := store.GetStore().ListUsers()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list users"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := ListUsersResponse{
+		Users: []UserResponse{},
+	}
+	for _, u := range users {
+		response.Users = append(response.Users, newUserResponse(u))
+	}
+
+	JSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	createUserRequest := CreateUserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&createUserRequest); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	createdUser, err := user.CreateUser(store.GetStore(), createUserRequest.Username, createUserRequest.Password, createUserRequest.Roles)
+	if err != nil {
+		if isUserInputError(err) {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+		logger.Error(errors.Wrap(err, "failed to create user"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("created user %s", createdUser.Username)
+	JSON(w, http.StatusCreated, newUserResponse(createdUser))
+}
+
+func (h *Handler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	resetUserPasswordRequest := ResetUserPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&resetUserPasswordRequest); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	foundUser, ok := getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := user.ResetPassword(store.GetStore(), foundUser.ID, resetUserPasswordRequest.Password); err != nil {
+		if isUserInputError(err) {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+		logger.Error(errors.Wrap(err, "failed to reset user password"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("reset password for user %s", foundUser.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	setUserRolesRequest := SetUserRolesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&setUserRolesRequest); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	foundUser, ok := getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := user.SetRoles(store.GetStore(), foundUser.ID, setUserRolesRequest.Roles); err != nil {
+		if isUserInputError(err) {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+		logger.Error(errors.Wrap(err, "failed to set user roles"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	audit.RecordChange(r, foundUser.Roles, setUserRolesRequest.Roles)
+
+	logger.Infof("set roles for user %s to %v", foundUser.Username, setUserRolesRequest.Roles)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	setUserDisabled(w, r, true)
+}
+
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	setUserDisabled(w, r, false)
+}
+
+func setUserDisabled(w http.ResponseWriter, r *http.Request, isDisabled bool) {
+	foundUser, ok := getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := store.GetStore().SetUserDisabled(foundUser.ID, isDisabled); err != nil {
+		logger.Error(errors.Wrap(err, "failed to update user"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("set disabled=%t for user %s", isDisabled, foundUser.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getUserFromRequest writes the error response and returns false if the user could not be found
+func getUserFromRequest(w http.ResponseWriter, r *http.Request) (*usertypes.User, bool) {
+	username := mux.Vars(r)["username"]
+
+	foundUser, err := store.GetStore().GetUserByUsername(username)
+	if store.GetStore().IsNotFound(err) {
+		JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("user %s not found", username)))
+		return nil, false
+	} else if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get user"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return foundUser, true
+}
+
+func isUserInputError(err error) bool {
+	switch errors.Cause(err) {
+	case user.ErrUserExists, user.ErrInvalidUsername, user.ErrPasswordTooShort, user.ErrNoRoles:
+		return true
+	}
+	return errors.Is(err, user.ErrUnknownRole)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/versionretention"
+	versionretentiontypes "github.com/replicatedhq/kots/pkg/versionretention/types"
+)
+
+type GetVersionRetentionPolicyResponse struct {
+	// Policy is nil when versions are never pruned
+	Policy *versionretentiontypes.Policy `json:"policy"`
+}
+
+type SetVersionRetentionPolicyRequest struct {
+	// Policy disables pruning when nil
+	Policy *versionretentiontypes.Policy `json:"policy"`
+}
+
+type PruneAppVersionsRequest struct {
+	DryRun bool `json:"dryRun"`
+	// Policy overrides the app's retention policy, to preview the effect of a policy before setting it.
+	// It can only be used for dry runs.
+	Policy *versionretentiontypes.Policy `json:"policy,omitempty"`
+}
+
+func (h *Handler) GetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := store.GetStore().GetVersionRetentionPolicy(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get version retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, GetVersionRetentionPolicyResponse{Policy: policy})
+}
+
+func (h *Handler) SetVersionRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	request := SetVersionRetentionPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Policy != nil {
+		if err := versionretention.ValidatePolicy(*request.Policy); err != nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	previousPolicy, err := store.GetStore().GetVersionRetentionPolicy(appID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get version retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().SetVersionRetentionPolicy(appID, request.Policy); err != nil {
+		logger.Error(errors.Wrap(err, "failed to set version retention policy"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	audit.RecordChange(r, previousPolicy, request.Policy)
+
+	JSON(w, http.StatusOK, GetVersionRetentionPolicyResponse{Policy: request.Policy})
+}
+
+// PruneAppVersions deletes the versions that are not kept by the retention policy, or lists them for a dry run
+func (h *Handler) PruneAppVersions(w http.ResponseWriter, r *http.Request) {
+	request := PruneAppVersionsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	appID, err := store.GetStore().GetAppIDFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app id from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	policy := request.Policy
+	if policy != nil {
+		if !request.DryRun {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("a policy can only be provided for dry runs")))
+			return
+		}
+	} else {
+		policy, err = store.GetStore().GetVersionRetentionPolicy(appID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get version retention policy"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if policy == nil {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.New("the app does not have a version retention policy")))
+			return
+		}
+	}
+
+	if err := versionretention.ValidatePolicy(*policy); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	result, err := versionretention.Prune(appID, *policy, request.DryRun)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to prune app versions"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, result)
+}
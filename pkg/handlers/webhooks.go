@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/webhooks"
+	webhooktypes "github.com/replicatedhq/kots/pkg/webhooks/types"
+)
+
+const defaultWebhookDeliveriesLimit = 50
+
+type ListWebhooksResponse struct {
+	Webhooks   []webhooktypes.Webhook `json:"webhooks"`
+	EventTypes []eventtypes.EventType `json:"eventTypes"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is used to sign deliveries, one is generated when empty
+	Secret string `json:"secret"`
+	// EventTypes the webhook subscribes to, all events are sent when empty
+	EventTypes []string `json:"eventTypes"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook *webhooktypes.Webhook `json:"webhook"`
+	// Secret is only returned when the webhook is created
+	Secret string `json:"secret"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []webhooktypes.Delivery `json:"deliveries"`
+}
+
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := store.GetStore().ListWebhooks()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list webhooks"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, ListWebhooksResponse{
+		Webhooks:   hooks,
+		EventTypes: eventtypes.EventTypes,
+	})
+}
+
+// CreateWebhook only validates the url. Whether the address it resolves to is allowed is checked on every delivery,
+// see webhooks.AllowPrivateTargetsEnv, and blocked deliveries are recorded as failed in the delivery history.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	request := CreateWebhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Error(errors.Wrap(err, "failed to decode request body"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := validateCreateWebhookRequest(request); err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to generate webhook secret"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		secret = generated
+	}
+
+	webhook, err := store.GetStore().CreateWebhook(request.URL, secret, request.EventTypes)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to create webhook"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusCreated, CreateWebhookResponse{
+		Webhook: webhook,
+		Secret:  secret,
+	})
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := mux.Vars(r)["webhookId"]
+
+	err := store.GetStore().DeleteWebhook(webhookID)
+	if store.GetStore().IsNotFound(err) {
+		JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("webhook %s not found", webhookID)))
+		return
+	} else if err != nil {
+		logger.Error(errors.Wrap(err, "failed to delete webhook"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhook sends a test event to the webhook and returns the resulting delivery
+func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := mux.Vars(r)["webhookId"]
+
+	delivery, err := webhooks.SendTestEvent(webhookID)
+	if store.GetStore().IsNotFound(err) {
+		JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("webhook %s not found", webhookID)))
+		return
+	} else if err != nil {
+		logger.Error(errors.Wrap(err, "failed to send test event"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, delivery)
+}
+
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID := mux.Vars(r)["webhookId"]
+
+	limit := defaultWebhookDeliveriesLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 0 {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("invalid limit %q", l)))
+			return
+		}
+		limit = parsed
+	}
+
+	if _, err := store.GetStore().GetWebhook(webhookID); store.GetStore().IsNotFound(err) {
+		JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("webhook %s not found", webhookID)))
+		return
+	} else if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get webhook"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deliveries, err := store.GetStore().ListWebhookDeliveries(webhookID, limit)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list webhook deliveries"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, ListWebhookDeliveriesResponse{Deliveries: deliveries})
+}
+
+func validateCreateWebhookRequest(request CreateWebhookRequest) error {
+	u, err := url.Parse(request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook url %q, an absolute http or https url is required", request.URL)
+	}
+	for _, eventType := range request.EventTypes {
+		if !eventtypes.IsValidEventType(eventType) {
+			return errors.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+	return hex.EncodeToString(b), nil
+}
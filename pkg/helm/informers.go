@@ -19,13 +19,14 @@ import (
 	"github.com/replicatedhq/kots/pkg/render"
 	"github.com/replicatedhq/kots/pkg/template"
 	"github.com/replicatedhq/kots/pkg/util"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 var monitorMap map[string]*appstate.Monitor
 var monitorMux *sync.Mutex
 
-func initMonitor(clientset kubernetes.Interface, targetNamespace string) {
+func initMonitor(clientset kubernetes.Interface, dynamicClient dynamic.Interface, targetNamespace string) {
 	if monitorMap == nil {
 		monitorMap = make(map[string]*appstate.Monitor)
 		monitorMux = new(sync.Mutex)
@@ -33,7 +34,7 @@ func initMonitor(clientset kubernetes.Interface, targetNamespace string) {
 
 	monitorMux.Lock()
 	if monitorMap[targetNamespace] == nil {
-		monitorMap[targetNamespace] = appstate.NewMonitor(clientset, targetNamespace)
+		monitorMap[targetNamespace] = appstate.NewMonitor(clientset, dynamicClient, targetNamespace)
 	}
 	nsMon := monitorMap[targetNamespace]
 	monitorMux.Unlock()
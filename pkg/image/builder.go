@@ -40,7 +40,7 @@ var imagePolicy = []byte(`{
   "default": [{"type": "insecureAcceptAnything"}]
 }`)
 
-func RewriteImages(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, appSlug string, log *logger.CLILogger, reportWriter io.Writer, upstreamDir string, additionalImages []string, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, dockerHubRegistry dockerregistrytypes.RegistryOptions) ([]kustomizeimage.Image, error) {
+func RewriteImages(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, appSlug string, log *logger.CLILogger, reportWriter io.Writer, upstreamDir string, additionalImages []string, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, dockerHubRegistry dockerregistrytypes.RegistryOptions, imagePolicy *types.ImagePolicy, policyReport *types.ImagePolicyReport) ([]kustomizeimage.Image, error) {
 	newImages := []kustomizeimage.Image{}
 	savedImages := map[string]bool{}
 
@@ -59,7 +59,7 @@ func RewriteImages(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions
 				return err
 			}
 
-			newImagesSubset, err := rewriteImagesInFileBetweenRegistries(srcRegistry, destRegistry, appSlug, log, reportWriter, contents, copyImages, allImagesPrivate, checkedImages, savedImages, dockerHubRegistry)
+			newImagesSubset, err := rewriteImagesInFileBetweenRegistries(srcRegistry, destRegistry, appSlug, log, reportWriter, contents, copyImages, allImagesPrivate, checkedImages, savedImages, dockerHubRegistry, imagePolicy, policyReport)
 			if err != nil {
 				return errors.Wrapf(err, "failed to copy images mentioned in %s", path)
 			}
@@ -73,13 +73,20 @@ func RewriteImages(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions
 	}
 
 	for _, additionalImage := range additionalImages {
-		newImage, err := rewriteOneImage(srcRegistry, destRegistry, additionalImage, appSlug, reportWriter, log, copyImages, allImagesPrivate, checkedImages, dockerHubRegistry)
+		newImage, err := rewriteOneImage(srcRegistry, destRegistry, additionalImage, appSlug, reportWriter, log, copyImages, allImagesPrivate, checkedImages, dockerHubRegistry, imagePolicy, policyReport)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to process addditional image %s", additionalImage)
 		}
 		newImages = append(newImages, newImage...)
 	}
 
+	for _, line := range ImagePolicyReportLines(imagePolicy, policyReport) {
+		log.Info(line)
+		if reportWriter != nil {
+			io.WriteString(reportWriter, line+"\n")
+		}
+	}
+
 	return newImages, nil
 }
 
@@ -194,7 +201,7 @@ func GetPrivateImages(baseDir string, kotsKindsImages []string, checkedImages ma
 	return result, objectsWithImages, nil
 }
 
-func rewriteImagesInFileBetweenRegistries(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, appSlug string, log *logger.CLILogger, reportWriter io.Writer, fileData []byte, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, savedImages map[string]bool, dockerHubRegistry dockerregistrytypes.RegistryOptions) ([]kustomizeimage.Image, error) {
+func rewriteImagesInFileBetweenRegistries(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, appSlug string, log *logger.CLILogger, reportWriter io.Writer, fileData []byte, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, savedImages map[string]bool, dockerHubRegistry dockerregistrytypes.RegistryOptions, imagePolicy *types.ImagePolicy, policyReport *types.ImagePolicyReport) ([]kustomizeimage.Image, error) {
 	newImages := []kustomizeimage.Image{}
 
 	err := listImagesInFile(fileData, func(images []string, doc k8sdoc.K8sDoc) error {
@@ -209,7 +216,7 @@ func rewriteImagesInFileBetweenRegistries(srcRegistry, destRegistry dockerregist
 				log.ChildActionWithSpinner("Found image %s", image)
 			}
 
-			newImage, err := rewriteOneImage(srcRegistry, destRegistry, image, appSlug, reportWriter, log, copyImages, allImagesPrivate, checkedImages, dockerHubRegistry)
+			newImage, err := rewriteOneImage(srcRegistry, destRegistry, image, appSlug, reportWriter, log, copyImages, allImagesPrivate, checkedImages, dockerHubRegistry, imagePolicy, policyReport)
 			if err != nil {
 				log.FinishChildSpinner()
 				return errors.Wrapf(err, "failed to transfer image %s", image)
@@ -249,7 +256,7 @@ func listImagesInFile(contents []byte, handler processImagesFunc) error {
 	return nil
 }
 
-func rewriteOneImage(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, image string, appSlug string, reportWriter io.Writer, log *logger.CLILogger, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, dockerHubRegistry dockerregistrytypes.RegistryOptions) ([]kustomizeimage.Image, error) {
+func rewriteOneImage(srcRegistry, destRegistry dockerregistrytypes.RegistryOptions, image string, appSlug string, reportWriter io.Writer, log *logger.CLILogger, copyImages, allImagesPrivate bool, checkedImages map[string]types.ImageInfo, dockerHubRegistry dockerregistrytypes.RegistryOptions, imagePolicy *types.ImagePolicy, policyReport *types.ImagePolicyReport) ([]kustomizeimage.Image, error) {
 	sourceCtx := &containerstypes.SystemContext{DockerDisableV1Ping: true}
 
 	// allow pulling images from http/invalid https docker repos
@@ -338,6 +345,10 @@ func rewriteOneImage(srcRegistry, destRegistry dockerregistrytypes.RegistryOptio
 		return kustomizeImage(destRegistry, image)
 	}
 
+	if err := enforceImagePolicy(imagePolicy, policyReport, srcRef, sourceCtx, image, nil); err != nil {
+		return nil, err
+	}
+
 	imageListSelection := copy.CopySystemImage
 	if _, ok := parsedSrc.(reference.Canonical); ok {
 		// this could be a multi-arch image, copy all architectures so that the digests match.
@@ -404,16 +415,36 @@ func rewriteOneImage(srcRegistry, destRegistry dockerregistrytypes.RegistryOptio
 }
 
 func CopyImage(opts types.CopyImageOptions) error {
-	srcCtx := &containerstypes.SystemContext{}
-	destCtx := &containerstypes.SystemContext{}
+	srcCtx, destCtx, err := getCopySystemContexts(opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to get system contexts")
+	}
 
-	if opts.SkipSrcTLSVerify {
-		srcCtx = &containerstypes.SystemContext{
-			DockerInsecureSkipTLSVerify: containerstypes.OptionalBoolTrue,
-			DockerDisableV1Ping:         true,
-		}
+	imageListSelection := copy.CopySystemImage
+	if opts.CopyAll {
+		imageListSelection = copy.CopyAllImages
+	}
+
+	_, err = CopyImageWithGC(context.Background(), opts.DestRef, opts.SrcRef, &copy.Options{
+		RemoveSignatures:      true,
+		SignBy:                "",
+		ReportWriter:          opts.ReportWriter,
+		SourceCtx:             srcCtx,
+		DestinationCtx:        destCtx,
+		ForceManifestMIMEType: "",
+		ImageListSelection:    imageListSelection,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to copy image")
 	}
 
+	return nil
+}
+
+func getCopySystemContexts(opts types.CopyImageOptions) (*containerstypes.SystemContext, *containerstypes.SystemContext, error) {
+	srcCtx := getSourceSystemContext(opts)
+	destCtx := &containerstypes.SystemContext{}
+
 	if opts.SkipDestTLSVerify {
 		destCtx = &containerstypes.SystemContext{
 			DockerInsecureSkipTLSVerify: containerstypes.OptionalBoolTrue,
@@ -427,7 +458,7 @@ func CopyImage(opts types.CopyImageOptions) error {
 	if registry.IsECREndpoint(registryHost) && username != "AWS" {
 		login, err := registry.GetECRLogin(registryHost, username, password)
 		if err != nil {
-			return errors.Wrap(err, "failed to get ECR login")
+			return nil, nil, errors.Wrap(err, "failed to get ECR login")
 		}
 		username = login.Username
 		password = login.Password
@@ -440,25 +471,17 @@ func CopyImage(opts types.CopyImageOptions) error {
 		}
 	}
 
-	imageListSelection := copy.CopySystemImage
-	if opts.CopyAll {
-		imageListSelection = copy.CopyAllImages
-	}
+	return srcCtx, destCtx, nil
+}
 
-	_, err := CopyImageWithGC(context.Background(), opts.DestRef, opts.SrcRef, &copy.Options{
-		RemoveSignatures:      true,
-		SignBy:                "",
-		ReportWriter:          opts.ReportWriter,
-		SourceCtx:             srcCtx,
-		DestinationCtx:        destCtx,
-		ForceManifestMIMEType: "",
-		ImageListSelection:    imageListSelection,
-	})
-	if err != nil {
-		return errors.Wrap(err, "failed to copy image")
+func getSourceSystemContext(opts types.CopyImageOptions) *containerstypes.SystemContext {
+	if opts.SkipSrcTLSVerify {
+		return &containerstypes.SystemContext{
+			DockerInsecureSkipTLSVerify: containerstypes.OptionalBoolTrue,
+			DockerDisableV1Ping:         true,
+		}
 	}
-
-	return nil
+	return &containerstypes.SystemContext{}
 }
 
 // if dockerHubRegistry is provided, its credentials will be used for DockerHub images to increase the rate limit.
@@ -591,6 +614,7 @@ type ProcessImageOptions struct {
 	PushImages       bool
 	CreateAppDir     bool
 	ReportWriter     io.Writer
+	ImagePolicy      *types.ImagePolicy
 }
 
 // RewriteBaseImages Will rewrite images found in base and copy them (if necessary) to the configured registry.
@@ -618,6 +642,7 @@ func RewriteBaseImages(options ProcessImageOptions, baseDir string, kotsKinds *k
 		KotsKinds:    kotsKinds,
 		IsAirgap:     options.IsAirgap,
 		CopyImages:   options.CopyImages,
+		ImagePolicy:  options.ImagePolicy,
 	}
 	if license != nil {
 		rewriteImageOptions.AppSlug = license.Spec.AppSlug
@@ -644,11 +669,13 @@ type RewriteImagesBetweenRegistriesOptions struct {
 	Log               *logger.CLILogger
 	ReportWriter      io.Writer
 	KotsKinds         *kotsutil.KotsKinds
+	ImagePolicy       *types.ImagePolicy
 }
 
 type RewriteImagesResult struct {
-	Images        []kustomizeimage.Image          // images to be rewritten
-	CheckedImages []kotsv1beta1.InstallationImage // all images found in the installation
+	Images             []kustomizeimage.Image          // images to be rewritten
+	CheckedImages      []kotsv1beta1.InstallationImage // all images found in the installation
+	ImagePolicyResults []types.ImagePolicyResult       // images checked against the image policy
 }
 
 func RewriteImagesBetweenRegistries(options RewriteImagesBetweenRegistriesOptions) (*RewriteImagesResult, error) {
@@ -664,14 +691,16 @@ func RewriteImagesBetweenRegistries(options RewriteImagesBetweenRegistriesOption
 		}
 	}
 
-	newImages, err := RewriteImages(options.SourceRegistry, options.DestRegistry, options.AppSlug, options.Log, options.ReportWriter, options.BaseDir, additionalImages, options.CopyImages, allImagesPrivate, checkedImages, options.DockerHubRegistry)
+	policyReport := &types.ImagePolicyReport{}
+	newImages, err := RewriteImages(options.SourceRegistry, options.DestRegistry, options.AppSlug, options.Log, options.ReportWriter, options.BaseDir, additionalImages, options.CopyImages, allImagesPrivate, checkedImages, options.DockerHubRegistry, options.ImagePolicy, policyReport)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to save images")
 	}
 
 	return &RewriteImagesResult{
-		Images:        newImages,
-		CheckedImages: makeInstallationImages(checkedImages),
+		Images:             newImages,
+		CheckedImages:      makeInstallationImages(checkedImages),
+		ImagePolicyResults: policyReport.Results(),
 	}, nil
 }
 
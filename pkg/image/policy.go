@@ -0,0 +1,377 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	imagedocker "github.com/containers/image/v5/docker"
+	dockerref "github.com/containers/image/v5/docker/reference"
+	containersimage "github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/signature"
+	containerstypes "github.com/containers/image/v5/types"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ImagePolicySecretName is the secret that configures the image policy.
+// "requireSignature", "requireSBOM" and "dryRun" are set to "true" to enable them,
+// and every key ending in ".pub" is a PEM encoded cosign public key.
+const ImagePolicySecretName = "kotsadm-image-policy"
+
+// cosign stores signatures in the registries.d sigstore attachments layout, which has to be enabled explicitly
+var sigstoreAttachmentsConfig = []byte(`default-docker:
+  use-sigstore-attachments: true
+`)
+
+// GetImagePolicy returns the image policy configured in the namespace, or nil if there is none.
+func GetImagePolicy(clientset kubernetes.Interface, namespace string) (*types.ImagePolicy, error) {
+	if namespace == "" {
+		return nil, nil
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), ImagePolicySecretName, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get image policy secret")
+	}
+
+	policy := &types.ImagePolicy{
+		RequireSignature: string(secret.Data["requireSignature"]) == "true",
+		RequireSBOM:      string(secret.Data["requireSBOM"]) == "true",
+		DryRun:           string(secret.Data["dryRun"]) == "true",
+	}
+
+	keyNames := []string{}
+	for name := range secret.Data {
+		if strings.HasSuffix(name, ".pub") {
+			keyNames = append(keyNames, name)
+		}
+	}
+	sort.Strings(keyNames)
+	for _, name := range keyNames {
+		policy.PublicKeys = append(policy.PublicKeys, secret.Data[name])
+	}
+
+	return policy, nil
+}
+
+// IsImagePolicyEnabled returns true if the policy has any requirements
+func IsImagePolicyEnabled(policy *types.ImagePolicy) bool {
+	return policy != nil && (policy.RequireSignature || policy.RequireSBOM)
+}
+
+// EnforceImagePolicy checks the source image of the copy against the policy and adds the result to the report.
+// An error is returned if the image fails the policy, unless the policy is a dry run.
+// originalImage is the image as referenced by the application, signatures must be made for its repository.
+func EnforceImagePolicy(policy *types.ImagePolicy, report *types.ImagePolicyReport, opts types.CopyImageOptions, originalImage string) error {
+	if !IsImagePolicyEnabled(policy) {
+		return nil
+	}
+
+	var destImage *policyRegistryImage
+	if opts.DestRef != nil && opts.DestRef.Transport().Name() == imagedocker.Transport.Name() {
+		_, destCtx, err := getCopySystemContexts(opts)
+		if err != nil {
+			return errors.Wrap(err, "failed to get destination registry context")
+		}
+		destImage = &policyRegistryImage{ref: opts.DestRef, sysCtx: destCtx}
+	}
+
+	return enforceImagePolicy(policy, report, opts.SrcRef, getSourceSystemContext(opts), originalImage, destImage)
+}
+
+func enforceImagePolicy(policy *types.ImagePolicy, report *types.ImagePolicyReport, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext, originalImage string, destImage *policyRegistryImage) error {
+	if !IsImagePolicyEnabled(policy) {
+		return nil
+	}
+
+	result := checkImagePolicy(policy, srcRef, srcCtx, originalImage, destImage)
+	report.Add(result)
+
+	if result.Passed || policy.DryRun {
+		return nil
+	}
+
+	return errors.Errorf("image %s does not satisfy the image policy: %s", result.Image, strings.Join(result.Failures, "; "))
+}
+
+// CheckImagePolicy checks the image against every requirement of the policy. Errors reaching the registry count as failures.
+func CheckImagePolicy(policy *types.ImagePolicy, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext, originalImage string) types.ImagePolicyResult {
+	return checkImagePolicy(policy, srcRef, srcCtx, originalImage, nil)
+}
+
+func checkImagePolicy(policy *types.ImagePolicy, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext, originalImage string, destImage *policyRegistryImage) types.ImagePolicyResult {
+	ctx := context.Background()
+
+	result := types.ImagePolicyResult{
+		Image: originalImage,
+	}
+	if result.Image == "" {
+		result.Image = transportImageName(srcRef)
+	}
+
+	policyRef, policyCtx, err := policyImageSource(ctx, srcRef, srcCtx, originalImage, destImage)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result
+	}
+
+	if policy.RequireSignature {
+		if failure := checkImageSignature(ctx, policy.PublicKeys, policyRef, policyCtx, originalImage); failure != "" {
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	if policy.RequireSBOM {
+		if failure := checkImageSBOM(ctx, policyRef, policyCtx); failure != "" {
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	result.Passed = len(result.Failures) == 0
+
+	return result
+}
+
+// policyRegistryImage is a registry image that signatures and SBOMs of an archived image can be looked up for
+type policyRegistryImage struct {
+	ref    containerstypes.ImageReference
+	sysCtx *containerstypes.SystemContext
+}
+
+// policyImageSource returns the image that signatures and SBOMs are looked up for.
+// Images in docker or OCI archives, such as the images in airgap bundles, do not carry cosign attachments.
+// They are looked up for the destination image first, which has them if the signed image was mirrored there
+// (for example with "cosign copy"), and then for the image the application references in its source registry.
+// The registry image must have the same config as the archived image.
+func policyImageSource(ctx context.Context, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext, originalImage string, destImage *policyRegistryImage) (containerstypes.ImageReference, *containerstypes.SystemContext, error) {
+	if srcRef.Transport().Name() == imagedocker.Transport.Name() {
+		return srcRef, srcCtx, nil
+	}
+
+	archivedImage, err := srcRef.NewImage(ctx, srcCtx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read archived image")
+	}
+	defer archivedImage.Close()
+
+	archivedInfo, err := archivedImage.Inspect(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to inspect archived image")
+	}
+
+	candidates := []policyRegistryImage{}
+	if destImage != nil {
+		candidates = append(candidates, *destImage)
+	}
+	if originalImage != "" {
+		named, err := dockerref.ParseDockerRef(originalImage)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to parse docker ref %q", originalImage)
+		}
+		upstreamRef, err := imagedocker.NewReference(named)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to create reference for %q", originalImage)
+		}
+		candidates = append(candidates, policyRegistryImage{ref: upstreamRef, sysCtx: srcCtx})
+	}
+
+	lookupErrs := []string{}
+	for _, candidate := range candidates {
+		candidateCtx, err := matchArchivedImage(ctx, archivedImage, archivedInfo, candidate)
+		if err != nil {
+			lookupErrs = append(lookupErrs, err.Error())
+			continue
+		}
+		return candidate.ref, candidateCtx, nil
+	}
+
+	if len(lookupErrs) == 0 {
+		return nil, nil, errors.Errorf("image policy is not supported for %s images without a registry image to check", srcRef.Transport().Name())
+	}
+
+	return nil, nil, errors.Errorf("image policy is not supported for images in airgap archives unless the signed image is in the destination registry: %s", strings.Join(lookupErrs, "; "))
+}
+
+// matchArchivedImage returns the system context to read the registry image with if it has the same config as the archived image
+func matchArchivedImage(ctx context.Context, archivedImage containerstypes.ImageCloser, archivedInfo *containerstypes.ImageInspectInfo, registryImage policyRegistryImage) (*containerstypes.SystemContext, error) {
+	imageName := transportImageName(registryImage.ref)
+
+	// pick the same platform as the archived image if the registry image is a manifest list
+	sysCtx := *registryImage.sysCtx
+	sysCtx.ArchitectureChoice = archivedInfo.Architecture
+	sysCtx.OSChoice = archivedInfo.Os
+	sysCtx.VariantChoice = archivedInfo.Variant
+
+	img, err := registryImage.ref.NewImage(ctx, &sysCtx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", imageName)
+	}
+	defer img.Close()
+
+	if archivedImage.ConfigInfo().Digest != img.ConfigInfo().Digest {
+		return nil, errors.Errorf("archived image does not match %s", imageName)
+	}
+
+	return &sysCtx, nil
+}
+
+// ImagePolicyReportLines summarizes the report, listing every image that failed the policy
+func ImagePolicyReportLines(policy *types.ImagePolicy, report *types.ImagePolicyReport) []string {
+	results := report.Results()
+	if len(results) == 0 {
+		return nil
+	}
+
+	passed, failed := []string{}, []string{}
+	for _, result := range results {
+		if result.Passed {
+			passed = append(passed, result.Image)
+		} else {
+			failed = append(failed, fmt.Sprintf("%s (%s)", result.Image, strings.Join(result.Failures, "; ")))
+		}
+	}
+	sort.Strings(passed)
+	sort.Strings(failed)
+
+	header := fmt.Sprintf("Image policy: %d images passed, %d images failed", len(passed), len(failed))
+	if policy != nil && policy.DryRun {
+		header += " (dry run, images were not blocked)"
+	}
+
+	lines := []string{header}
+	for _, image := range passed {
+		lines = append(lines, fmt.Sprintf("  passed: %s", image))
+	}
+	for _, image := range failed {
+		lines = append(lines, fmt.Sprintf("  failed: %s", image))
+	}
+
+	return lines
+}
+
+// checkImageSignature uses the sigstore policy requirement of containers/image, which reads cosign signatures from the
+// registry. The image is accepted if it is signed with any of the keys.
+func checkImageSignature(ctx context.Context, publicKeys [][]byte, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext, originalImage string) string {
+	if len(publicKeys) == 0 {
+		return "signature required but no public keys are configured"
+	}
+
+	registriesDir, err := ioutil.TempDir("", "kots-registries-d")
+	if err != nil {
+		return fmt.Sprintf("failed to create registries config: %v", err)
+	}
+	defer os.RemoveAll(registriesDir)
+
+	if err := ioutil.WriteFile(filepath.Join(registriesDir, "default.yaml"), sigstoreAttachmentsConfig, 0644); err != nil {
+		return fmt.Sprintf("failed to write registries config: %v", err)
+	}
+
+	sysCtx := *srcCtx
+	sysCtx.RegistriesDirPath = registriesDir
+
+	src, err := srcRef.NewImageSource(ctx, &sysCtx)
+	if err != nil {
+		return fmt.Sprintf("failed to read image: %v", err)
+	}
+	defer src.Close()
+
+	signedIdentity, err := signedIdentityForImage(originalImage)
+	if err != nil {
+		return fmt.Sprintf("failed to parse image name: %v", err)
+	}
+
+	unparsedImage := containersimage.UnparsedInstance(src, nil)
+
+	var lastErr error
+	for _, publicKey := range publicKeys {
+		allowed, err := isSignedWithKey(ctx, unparsedImage, publicKey, signedIdentity)
+		if allowed {
+			return ""
+		}
+		lastErr = err
+	}
+
+	logger.Debugf("image %s signature verification failed: %v", transportImageName(srcRef), lastErr)
+
+	return "no valid signature from a trusted key"
+}
+
+func isSignedWithKey(ctx context.Context, unparsedImage containerstypes.UnparsedImage, publicKey []byte, signedIdentity signature.PolicyReferenceMatch) (bool, error) {
+	requirement, err := signature.NewPRSigstoreSigned(
+		signature.PRSigstoreSignedWithKeyData(publicKey),
+		signature.PRSigstoreSignedWithSignedIdentity(signedIdentity),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create sigstore requirement")
+	}
+
+	policyContext, err := signature.NewPolicyContext(&signature.Policy{
+		Default: signature.PolicyRequirements{requirement},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create policy")
+	}
+	defer policyContext.Destroy()
+
+	return policyContext.IsRunningImageAllowed(ctx, unparsedImage)
+}
+
+// signedIdentityForImage requires signatures to be made for the repository the application references.
+// The image is usually read through the proxy or a local registry, so its own name cannot be matched.
+func signedIdentityForImage(originalImage string) (signature.PolicyReferenceMatch, error) {
+	if originalImage == "" {
+		return signature.NewPRMMatchRepoDigestOrExact(), nil
+	}
+
+	named, err := dockerref.ParseDockerRef(originalImage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse docker ref %q", originalImage)
+	}
+
+	return signature.NewPRMExactRepository(named.Name())
+}
+
+// checkImageSBOM looks for an SBOM attached with "cosign attach sbom", which is stored under the sha256-<digest>.sbom tag
+func checkImageSBOM(ctx context.Context, srcRef containerstypes.ImageReference, srcCtx *containerstypes.SystemContext) string {
+	d, err := topLevelManifestDigest(ctx, srcRef, srcCtx)
+	if err != nil {
+		return fmt.Sprintf("failed to read image: %v", err)
+	}
+
+	sbomTag := fmt.Sprintf("%s-%s.sbom", d.Algorithm(), d.Encoded())
+	tagged, err := dockerref.WithTag(dockerref.TrimNamed(srcRef.DockerReference()), sbomTag)
+	if err != nil {
+		return fmt.Sprintf("failed to create SBOM reference: %v", err)
+	}
+
+	sbomRef, err := imagedocker.NewReference(tagged)
+	if err != nil {
+		return fmt.Sprintf("failed to create SBOM reference: %v", err)
+	}
+
+	if _, err := topLevelManifestDigest(ctx, sbomRef, srcCtx); err != nil {
+		logger.Debugf("SBOM %s not found: %v", tagged.String(), err)
+		return "no SBOM attached"
+	}
+
+	return ""
+}
+
+func transportImageName(ref containerstypes.ImageReference) string {
+	if ref.DockerReference() != nil {
+		return ref.DockerReference().String()
+	}
+	return ref.StringWithinTransport()
+}
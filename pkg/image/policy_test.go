@@ -0,0 +1,328 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/opencontainers/go-digest"
+	"github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_GetImagePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []corev1.Secret
+		want    *types.ImagePolicy
+	}{
+		{
+			name: "no policy",
+			want: nil,
+		},
+		{
+			name: "policy with keys",
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      ImagePolicySecretName,
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"requireSignature": []byte("true"),
+						"requireSBOM":      []byte("false"),
+						"dryRun":           []byte("true"),
+						"b.pub":            []byte("key-b"),
+						"a.pub":            []byte("key-a"),
+						"notes":            []byte("not a key"),
+					},
+				},
+			},
+			want: &types.ImagePolicy{
+				RequireSignature: true,
+				PublicKeys:       [][]byte{[]byte("key-a"), []byte("key-b")},
+				DryRun:           true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			for _, secret := range tt.secrets {
+				secret := secret
+				_, err := clientset.CoreV1().Secrets(secret.Namespace).Create(context.TODO(), &secret, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			got, err := GetImagePolicy(clientset, "default")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_enforceImagePolicy(t *testing.T) {
+	srcRef, err := alltransports.ParseImageName("docker-archive:/does-not-exist.tar")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		policy       *types.ImagePolicy
+		wantErr      bool
+		wantFailures []string
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+		},
+		{
+			name:    "unreadable archive",
+			policy:  &types.ImagePolicy{RequireSignature: true, RequireSBOM: true},
+			wantErr: true,
+			wantFailures: []string{
+				"failed to read archived image",
+			},
+		},
+		{
+			name:   "dry run",
+			policy: &types.ImagePolicy{RequireSBOM: true, DryRun: true},
+			wantFailures: []string{
+				"failed to read archived image",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &types.ImagePolicyReport{}
+
+			err := EnforceImagePolicy(tt.policy, report, types.CopyImageOptions{SrcRef: srcRef}, "registry.example.com/app/nginx:1.21")
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			results := report.Results()
+			if tt.wantFailures == nil {
+				assert.Empty(t, results)
+				return
+			}
+
+			require.Len(t, results, 1)
+			assert.Equal(t, "registry.example.com/app/nginx:1.21", results[0].Image)
+			assert.False(t, results[0].Passed)
+			require.Len(t, results[0].Failures, len(tt.wantFailures))
+			for i, failure := range tt.wantFailures {
+				assert.Contains(t, results[0].Failures[i], failure)
+			}
+
+			lines := ImagePolicyReportLines(tt.policy, report)
+			require.Len(t, lines, 2)
+			assert.Contains(t, lines[0], "0 images passed, 1 images failed")
+		})
+	}
+}
+
+func Test_CheckImagePolicy_archive(t *testing.T) {
+	layer := make([]byte, 1024) // empty tar
+	layerDigest := digest.FromBytes(layer)
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":["%s"]}}`, layerDigest))
+	configDigest := digest.FromBytes(config)
+
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":"%s"},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar","size":%d,"digest":"%s"}]}`, len(config), configDigest, len(layer), layerDigest))
+	manifestDigest := digest.FromBytes(manifest)
+	sbomTag := fmt.Sprintf("sha256-%s.sbom", manifestDigest.Encoded())
+
+	tests := []struct {
+		name         string
+		archiveImage []byte
+		withSBOM     bool
+		wantFailures []string
+	}{
+		{
+			name:         "sbom attached in the source registry",
+			archiveImage: config,
+			withSBOM:     true,
+		},
+		{
+			name:         "no sbom in the source registry",
+			archiveImage: config,
+			wantFailures: []string{"no SBOM attached"},
+		},
+		{
+			name:         "archived image differs from the source registry",
+			archiveImage: []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":{"Env":["A=b"]},"rootfs":{"type":"layers","diff_ids":["%s"]}}`, layerDigest)),
+			withSBOM:     true,
+			wantFailures: []string{"image policy is not supported for images in airgap archives unless the signed image is in the destination registry: archived image does not match"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case "/v2/app/nginx/manifests/1.21", "/v2/app/nginx/manifests/" + manifestDigest.String():
+					writeManifest(w, r, manifest)
+				case "/v2/app/nginx/manifests/" + sbomTag:
+					if !tt.withSBOM {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					writeManifest(w, r, manifest)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			archivePath := writeDockerArchive(t, tt.archiveImage, layer)
+			srcRef, err := alltransports.ParseImageName("docker-archive:" + archivePath)
+			require.NoError(t, err)
+
+			originalImage := fmt.Sprintf("%s/app/nginx:1.21", strings.TrimPrefix(server.URL, "https://"))
+			srcCtx := getSourceSystemContext(types.CopyImageOptions{SkipSrcTLSVerify: true})
+
+			result := CheckImagePolicy(&types.ImagePolicy{RequireSBOM: true}, srcRef, srcCtx, originalImage)
+			assert.Equal(t, originalImage, result.Image)
+			assert.Equal(t, len(tt.wantFailures) == 0, result.Passed)
+			require.Len(t, result.Failures, len(tt.wantFailures))
+			for i, failure := range tt.wantFailures {
+				assert.Contains(t, result.Failures[i], failure)
+			}
+		})
+	}
+}
+
+func Test_EnforceImagePolicy_airgap(t *testing.T) {
+	layer := make([]byte, 1024) // empty tar
+	layerDigest := digest.FromBytes(layer)
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":["%s"]}}`, layerDigest))
+	configDigest := digest.FromBytes(config)
+
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":"%s"},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar","size":%d,"digest":"%s"}]}`, len(config), configDigest, len(layer), layerDigest))
+	manifestDigest := digest.FromBytes(manifest)
+	sbomTag := fmt.Sprintf("sha256-%s.sbom", manifestDigest.Encoded())
+
+	// the source registry of the application is not reachable in airgap installs
+	originalImage := "127.0.0.1:1/app/nginx:1.21"
+
+	tests := []struct {
+		name         string
+		mirrored     bool
+		wantErr      bool
+		wantFailures []string
+	}{
+		{
+			name:     "signed image mirrored to the destination registry",
+			mirrored: true,
+		},
+		{
+			name:    "signed image not in the destination registry",
+			wantErr: true,
+			wantFailures: []string{
+				"image policy is not supported for images in airgap archives unless the signed image is in the destination registry",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				if !tt.mirrored {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				switch r.URL.Path {
+				case "/v2/app/nginx/manifests/1.21", "/v2/app/nginx/manifests/" + manifestDigest.String(), "/v2/app/nginx/manifests/" + sbomTag:
+					writeManifest(w, r, manifest)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			archivePath := writeDockerArchive(t, config, layer)
+			srcRef, err := alltransports.ParseImageName("docker-archive:" + archivePath)
+			require.NoError(t, err)
+
+			destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s/app/nginx:1.21", strings.TrimPrefix(server.URL, "https://")))
+			require.NoError(t, err)
+
+			report := &types.ImagePolicyReport{}
+			opts := types.CopyImageOptions{
+				SrcRef:            srcRef,
+				DestRef:           destRef,
+				SkipDestTLSVerify: true,
+			}
+			err = EnforceImagePolicy(&types.ImagePolicy{RequireSBOM: true}, report, opts, originalImage)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			results := report.Results()
+			require.Len(t, results, 1)
+			assert.Equal(t, originalImage, results[0].Image)
+			assert.Equal(t, len(tt.wantFailures) == 0, results[0].Passed)
+			require.Len(t, results[0].Failures, len(tt.wantFailures))
+			for i, failure := range tt.wantFailures {
+				assert.Contains(t, results[0].Failures[i], failure)
+			}
+		})
+	}
+}
+
+func writeManifest(w http.ResponseWriter, r *http.Request, manifest []byte) {
+	w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(manifest)
+}
+
+func writeDockerArchive(t *testing.T, config []byte, layer []byte) string {
+	configName := digest.FromBytes(config).Encoded() + ".json"
+	archiveManifest := []byte(fmt.Sprintf(`[{"Config":"%s","RepoTags":["app/nginx:1.21"],"Layers":["layer.tar"]}]`, configName))
+
+	archivePath := filepath.Join(t.TempDir(), "nginx.tar")
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	tarWriter := tar.NewWriter(f)
+	defer tarWriter.Close()
+
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{configName, config},
+		{"layer.tar", layer},
+		{"manifest.json", archiveManifest},
+	} {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write(file.data)
+		require.NoError(t, err)
+	}
+
+	return archivePath
+}
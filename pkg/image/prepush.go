@@ -0,0 +1,193 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	containerstypes "github.com/containers/image/v5/types"
+	"github.com/docker/distribution/registry/api/errcode"
+	v2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentBlobChecks is the number of layers of an image that are checked in the destination at the same time
+const maxConcurrentBlobChecks = 6
+
+// CheckDestinationImage compares the source image with what the destination registry already has.
+// If the destination already has an identical image, the copy can be skipped entirely.
+// Otherwise, layers that already exist in the destination repository are reported so that
+// callers can account for them, CopyImage will not push them again.
+func CheckDestinationImage(opts types.CopyImageOptions) (*types.DestinationImageStatus, error) {
+	ctx := context.Background()
+
+	srcCtx, destCtx, err := getCopySystemContexts(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get system contexts")
+	}
+
+	srcImage, err := opts.SrcRef.NewImage(ctx, srcCtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open source image")
+	}
+	defer srcImage.Close()
+
+	status := &types.DestinationImageStatus{
+		ExistingBlobs: map[string]bool{},
+		MissingBlobs:  []containerstypes.BlobInfo{},
+	}
+
+	layers := srcImage.LayerInfos()
+
+	isPresent, err := isImagePresentInDestination(ctx, opts, srcImage, srcCtx, destCtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compare source and destination images")
+	}
+	if isPresent {
+		status.ImagePresent = true
+		for _, layer := range layers {
+			status.ExistingBlobs[layer.Digest.String()] = true
+			status.SkippedBytes += layer.Size
+		}
+		return status, nil
+	}
+
+	dest, err := opts.DestRef.NewImageDestination(ctx, destCtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open destination")
+	}
+	defer dest.Close()
+
+	// the layers are checked concurrently, the same way they are when the image is copied,
+	// so that images with many layers do not wait for a round trip per layer
+	reused := make([]bool, len(layers))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentBlobChecks)
+	for i, layer := range layers {
+		i, layer := i, layer
+		g.Go(func() error {
+			ok, _, err := dest.TryReusingBlob(gctx, layer, none.NoCache, false)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check blob %s in destination", layer.Digest)
+			}
+			reused[i] = ok
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for i, layer := range layers {
+		if reused[i] {
+			status.ExistingBlobs[layer.Digest.String()] = true
+			status.SkippedBytes += layer.Size
+		} else {
+			status.MissingBlobs = append(status.MissingBlobs, layer)
+		}
+	}
+
+	return status, nil
+}
+
+// DestinationImageExists returns true if the image referenced by opts.DestRef can be read from the destination registry.
+// Errors other than the image not being found, such as auth, tls or network errors, are returned.
+func DestinationImageExists(opts types.CopyImageOptions) (bool, error) {
+	_, destCtx, err := getCopySystemContexts(opts)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get system contexts")
+	}
+
+	src, err := opts.DestRef.NewImageSource(context.Background(), destCtx)
+	if err != nil {
+		if isImageNotFoundError(err) {
+			logger.Debugf("image %s not found in destination: %v", opts.DestRef.DockerReference(), err)
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to open image in destination")
+	}
+	src.Close()
+
+	return true, nil
+}
+
+// isImageNotFoundError returns true if the registry reported that the manifest or the repository does not exist
+func isImageNotFoundError(err error) bool {
+	var ec errcode.ErrorCoder
+	if errors.As(err, &ec) {
+		switch ec.ErrorCode() {
+		case v2.ErrorCodeManifestUnknown, v2.ErrorCodeNameUnknown:
+			return true
+		}
+	}
+	// registries that do not return the error codes of the distribution spec
+	var e errcode.Error
+	if errors.As(err, &e) && e.Message == "Not Found" {
+		return true
+	}
+	return strings.Contains(err.Error(), fmt.Sprintf("StatusCode: %d,", http.StatusNotFound))
+}
+
+// DestinationManifestDigest returns the digest of the manifest that opts.DestRef currently resolves to in the destination registry.
+func DestinationManifestDigest(opts types.CopyImageOptions) (string, error) {
+	_, destCtx, err := getCopySystemContexts(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get system contexts")
+	}
+
+	d, err := topLevelManifestDigest(context.Background(), opts.DestRef, destCtx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get manifest digest")
+	}
+
+	return d.String(), nil
+}
+
+func isImagePresentInDestination(ctx context.Context, opts types.CopyImageOptions, srcImage containerstypes.ImageCloser, srcCtx, destCtx *containerstypes.SystemContext) (bool, error) {
+	if opts.CopyAll {
+		// all architectures are copied, so the manifest lists must match
+		srcDigest, err := topLevelManifestDigest(ctx, opts.SrcRef, srcCtx)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get source manifest digest")
+		}
+		destDigest, err := topLevelManifestDigest(ctx, opts.DestRef, destCtx)
+		if err != nil {
+			// treat as not present, the push will surface real errors
+			return false, nil
+		}
+		return srcDigest == destDigest, nil
+	}
+
+	destImage, err := opts.DestRef.NewImage(ctx, destCtx)
+	if err != nil {
+		// treat as not present, the push will surface real errors
+		return false, nil
+	}
+	defer destImage.Close()
+
+	// layer digests can change when layers are compressed during the push,
+	// but the config blob is copied as is, so it identifies the image.
+	return srcImage.ConfigInfo().Digest == destImage.ConfigInfo().Digest, nil
+}
+
+func topLevelManifestDigest(ctx context.Context, ref containerstypes.ImageReference, sysCtx *containerstypes.SystemContext) (digest.Digest, error) {
+	src, err := ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open image source")
+	}
+	defer src.Close()
+
+	b, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get manifest")
+	}
+
+	return manifest.Digest(b)
+}
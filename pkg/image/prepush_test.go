@@ -0,0 +1,93 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DestinationImageExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "found",
+			statusCode: http.StatusOK,
+			body:       `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":2,"digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"},"layers":[]}`,
+			want:       true,
+		},
+		{
+			name:       "manifest unknown",
+			statusCode: http.StatusNotFound,
+			body:       `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`,
+			want:       false,
+		},
+		{
+			name:       "repository unknown",
+			statusCode: http.StatusNotFound,
+			body:       `{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`,
+			want:       false,
+		},
+		{
+			name:       "not found without error codes",
+			statusCode: http.StatusNotFound,
+			body:       `not found`,
+			want:       false,
+		},
+		{
+			name:       "denied",
+			statusCode: http.StatusForbidden,
+			body:       `{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`,
+			wantErr:    true,
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusInternalServerError,
+			body:       `internal error`,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				if strings.HasPrefix(r.URL.Path, "/v2/app/manifests/") {
+					w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+					w.WriteHeader(tt.statusCode)
+					fmt.Fprint(w, tt.body)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s/app:1.0.0", strings.TrimPrefix(server.URL, "https://")))
+			require.NoError(t, err)
+
+			got, err := DestinationImageExists(types.CopyImageOptions{
+				DestRef:           destRef,
+				SkipDestTLSVerify: true,
+			})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
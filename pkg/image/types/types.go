@@ -2,6 +2,7 @@ package types
 
 import (
 	"io"
+	"sync"
 
 	"github.com/containers/image/v5/types"
 )
@@ -24,3 +25,62 @@ type CopyImageOptions struct {
 	SkipDestTLSVerify bool
 	ReportWriter      io.Writer
 }
+
+// DestinationImageStatus describes what the destination registry already has for an image that is about to be copied.
+type DestinationImageStatus struct {
+	// ImagePresent is true when the destination already has an identical image, so the copy can be skipped.
+	ImagePresent bool
+	// ExistingBlobs are the layer digests that the destination repository already has and will not be pushed again.
+	ExistingBlobs map[string]bool
+	// MissingBlobs are the layer digests that the destination repository does not have.
+	MissingBlobs []types.BlobInfo
+	// SkippedBytes is the total size of the layers that will not be pushed.
+	SkippedBytes int64
+}
+
+// ImagePolicy is the admission policy that images must pass before they are copied to the destination registry.
+type ImagePolicy struct {
+	// RequireSignature requires a cosign signature made with one of PublicKeys.
+	RequireSignature bool
+	// PublicKeys are PEM encoded cosign public keys.
+	PublicKeys [][]byte
+	// RequireSBOM requires an SBOM attached to the image with cosign.
+	RequireSBOM bool
+	// DryRun only reports images that fail the policy, they are still copied.
+	DryRun bool
+}
+
+// ImagePolicyResult is the outcome of checking one image against the policy.
+type ImagePolicyResult struct {
+	Image    string
+	Passed   bool
+	Failures []string
+}
+
+// ImagePolicyReport collects the policy results of all checked images.
+// Images can be checked concurrently, so the report is guarded by a mutex. A nil report records nothing.
+type ImagePolicyReport struct {
+	mtx     sync.Mutex
+	results []ImagePolicyResult
+}
+
+func (r *ImagePolicyReport) Add(result ImagePolicyResult) {
+	if r == nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *ImagePolicyReport) Results() []ImagePolicyResult {
+	if r == nil {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if len(r.results) == 0 {
+		return nil
+	}
+	return append([]ImagePolicyResult{}, r.results...)
+}
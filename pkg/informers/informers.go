@@ -3,9 +3,12 @@ package informers
 import (
 	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	kotssnapshot "github.com/replicatedhq/kots/pkg/snapshot"
@@ -15,6 +18,7 @@ import (
 	veleroclientv1 "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
@@ -67,8 +71,11 @@ func Start() error {
 				backup, ok := obj.Object.(*velerov1.Backup)
 				if !ok {
 					logger.Errorf("failed to cast obj to backup")
+					continue
 				}
 
+				publishSnapshotCompletedEvent(backup)
+
 				if backup.Status.Phase == velerov1.BackupPhaseFailed || backup.Status.Phase == velerov1.BackupPhasePartiallyFailed {
 					if backup.Annotations == nil {
 						backup.Annotations = map[string]string{}
@@ -116,3 +123,46 @@ func Start() error {
 
 	return nil
 }
+
+var (
+	completedBackupsMtx sync.Mutex
+	completedBackups    = map[types.UID]bool{}
+)
+
+// publishSnapshotCompletedEvent publishes an event the first time a backup is seen in a completed phase.
+// Backups are modified again after they complete, so events are deduplicated by backup UID.
+func publishSnapshotCompletedEvent(backup *velerov1.Backup) {
+	switch backup.Status.Phase {
+	case velerov1.BackupPhaseCompleted, velerov1.BackupPhaseFailed, velerov1.BackupPhasePartiallyFailed:
+	default:
+		return
+	}
+	// the dedupe map does not survive restarts, so ignore modifications of backups that completed long ago
+	if backup.Status.CompletionTimestamp != nil && time.Since(backup.Status.CompletionTimestamp.Time) > time.Hour {
+		return
+	}
+
+	completedBackupsMtx.Lock()
+	if completedBackups[backup.UID] {
+		completedBackupsMtx.Unlock()
+		return
+	}
+	completedBackups[backup.UID] = true
+	completedBackupsMtx.Unlock()
+
+	backupType := "application"
+	if _, ok := backup.Annotations["kots.io/instance"]; ok {
+		backupType = "instance"
+	}
+
+	events.Publish(eventtypes.Event{
+		Type:    eventtypes.EventSnapshotCompleted,
+		AppID:   backup.Annotations["kots.io/app-id"],
+		AppSlug: backup.Annotations["kots.io/app-slug"],
+		Data: map[string]interface{}{
+			"backup": backup.Name,
+			"type":   backupType,
+			"phase":  string(backup.Status.Phase),
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package kotsadm
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+// VerifyAirgapBundle checks the airgap bundle against its integrity manifest before any images in it are pushed.
+// The manifest and its signature are written to destDir so that they are uploaded with the rest of the airgap
+// metadata and the admin console can verify the uploaded files against them.
+// Bundles built without a manifest are accepted unless signing keys are configured in the namespace.
+func VerifyAirgapBundle(airgapBundle string, namespace string, destDir string, log *logger.CLILogger) error {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to get clientset")
+	}
+
+	publicKeys, err := integrity.GetPublicKeys(clientset, namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get airgap signing keys")
+	}
+
+	log.ActionWithSpinner("Verifying airgap bundle integrity")
+
+	result, err := integrity.VerifyBundle(airgapBundle, publicKeys)
+	if err != nil {
+		if errors.Cause(err) == integrity.ErrManifestMissing && len(publicKeys) == 0 {
+			log.FinishSpinnerWithWarning(nil)
+			log.ChildActionWithoutSpinner("Airgap bundle does not include an integrity manifest, skipping integrity check")
+			return nil
+		}
+		log.FinishSpinnerWithError()
+		return errors.Wrap(err, "failed to verify airgap bundle")
+	}
+
+	log.FinishSpinner()
+
+	if err := ioutil.WriteFile(filepath.Join(destDir, integrity.ManifestFileName), result.Manifest, 0644); err != nil {
+		return errors.Wrap(err, "failed to write integrity manifest")
+	}
+	if result.Signature != nil {
+		if err := ioutil.WriteFile(filepath.Join(destDir, integrity.SignatureFileName), result.Signature, 0644); err != nil {
+			return errors.Wrap(err, "failed to write integrity manifest signature")
+		}
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
 	"github.com/replicatedhq/kots/pkg/docker/registry"
 	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
 	"github.com/replicatedhq/kots/pkg/identity"
@@ -242,6 +243,16 @@ func Deploy(deployOptions types.DeployOptions, log *logger.CLILogger) error {
 		if err := ensureConfigFromFile(deployOptions, clientset, "kotsadm-airgap-images", filepath.Join(airgapPath, "images.json")); err != nil {
 			return errors.Wrap(err, "failed to create config from images.json")
 		}
+		if _, err := os.Stat(filepath.Join(airgapPath, integrity.ManifestFileName)); err == nil {
+			if err := ensureConfigFromFile(deployOptions, clientset, "kotsadm-airgap-integrity", filepath.Join(airgapPath, integrity.ManifestFileName)); err != nil {
+				return errors.Wrap(err, "failed to create config from integrity manifest")
+			}
+		}
+		if _, err := os.Stat(filepath.Join(airgapPath, integrity.SignatureFileName)); err == nil {
+			if err := ensureConfigFromFile(deployOptions, clientset, "kotsadm-airgap-integrity-sig", filepath.Join(airgapPath, integrity.SignatureFileName)); err != nil {
+				return errors.Wrap(err, "failed to create config from integrity manifest signature")
+			}
+		}
 		if err := ensureWaitForAirgapConfig(deployOptions, clientset, "kotsadm-airgap-app"); err != nil {
 			return errors.Wrap(err, "failed to create config from app.tar.gz")
 		}
@@ -27,10 +27,19 @@ import (
 	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/client-go/kubernetes/scheme"
 	kustomizetypes "sigs.k8s.io/kustomize/api/types"
 )
 
+const (
+	defaultPushRetryAttempts = 5
+	pushRetryMaxBackoff      = 2 * time.Minute
+)
+
+var pushRetryInitialBackoff = 10 * time.Second
+
 // Pushes Admin Console images from airgap bundle to private registry
 func PushImages(airgapArchive string, options types.PushImagesOptions) error {
 	airgapRootDir, err := ioutil.TempDir("", "kotsadm-airgap")
@@ -39,18 +48,23 @@ func PushImages(airgapArchive string, options types.PushImagesOptions) error {
 	}
 	defer os.RemoveAll(airgapRootDir)
 
+	journal, err := loadPushJournal(options.JournalPath, airgapArchive, options.Log)
+	if err != nil {
+		return errors.Wrap(err, "failed to load push journal")
+	}
+
 	err = ExtractAppAirgapArchive(airgapArchive, airgapRootDir, false, options.ProgressWriter)
 	if err != nil {
 		return errors.Wrap(err, "failed to extract images")
 	}
 
 	if isAppArchive(airgapRootDir) {
-		_, err := TagAndPushAppImagesFromPath(airgapRootDir, options)
+		_, err := tagAndPushAppImagesFromPath(airgapRootDir, options, journal)
 		if err != nil {
 			return errors.Wrap(err, "failed to push app images")
 		}
 	} else {
-		err = pushKotsadmImagesFromPath(airgapRootDir, options)
+		err = pushKotsadmImagesFromPath(airgapRootDir, options, journal)
 		if err != nil {
 			return errors.Wrap(err, "failed to push kotsadm images")
 		}
@@ -123,67 +137,92 @@ func ExtractAppAirgapArchive(archive string, destDir string, excludeImages bool,
 	return nil
 }
 
-func pushKotsadmImagesFromPath(rootDir string, options types.PushImagesOptions) error {
+type kotsadmImage struct {
+	format    string
+	imageName string
+	tag       string
+}
+
+func pushKotsadmImagesFromPath(rootDir string, options types.PushImagesOptions, journal *pushJournal) error {
 	fileInfos, err := ioutil.ReadDir(rootDir)
 	if err != nil {
 		return errors.Wrap(err, "failed to read dir")
 	}
 
+	images := []kotsadmImage{}
 	for _, info := range fileInfos {
 		if !info.IsDir() {
 			continue
 		}
 
-		err = processImageNames(rootDir, info.Name(), options)
+		formatImages, err := processImageNames(rootDir, info.Name())
 		if err != nil {
 			return errors.Wrapf(err, "failed list images names for format %s", info.Name())
 		}
+		images = append(images, formatImages...)
 	}
 
-	return nil
+	g := errgroup.Group{}
+	g.SetLimit(pushConcurrency(options))
+	for _, i := range images {
+		i := i
+		g.Go(func() error {
+			err := pushOneImage(rootDir, i.format, i.imageName, i.tag, options, journal)
+			if err != nil {
+				return errors.Wrapf(err, "failed push image %s:%s", i.imageName, i.tag)
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
 }
 
-func processImageNames(rootDir string, format string, options types.PushImagesOptions) error {
+func processImageNames(rootDir string, format string) ([]kotsadmImage, error) {
 	fileInfos, err := ioutil.ReadDir(filepath.Join(rootDir, format))
 	if err != nil {
-		return errors.Wrap(err, "failed to read dir")
+		return nil, errors.Wrap(err, "failed to read dir")
 	}
 
+	images := []kotsadmImage{}
 	for _, info := range fileInfos {
 		if !info.IsDir() {
 			continue
 		}
 
-		err = processImageTags(rootDir, format, info.Name(), options)
+		tagImages, err := processImageTags(rootDir, format, info.Name())
 		if err != nil {
-			return errors.Wrapf(err, "failed list tags for image %s", info.Name())
+			return nil, errors.Wrapf(err, "failed list tags for image %s", info.Name())
 		}
+		images = append(images, tagImages...)
 	}
 
-	return nil
+	return images, nil
 }
 
-func processImageTags(rootDir string, format string, imageName string, options types.PushImagesOptions) error {
+func processImageTags(rootDir string, format string, imageName string) ([]kotsadmImage, error) {
 	fileInfos, err := ioutil.ReadDir(filepath.Join(rootDir, format, imageName))
 	if err != nil {
-		return errors.Wrap(err, "failed to read dir")
+		return nil, errors.Wrap(err, "failed to read dir")
 	}
 
+	images := []kotsadmImage{}
 	for _, info := range fileInfos {
 		if info.IsDir() {
 			continue
 		}
 
-		err = pushOneImage(rootDir, format, imageName, info.Name(), options)
-		if err != nil {
-			return errors.Wrapf(err, "failed push image %s:%s", imageName, info.Name())
-		}
+		images = append(images, kotsadmImage{
+			format:    format,
+			imageName: imageName,
+			tag:       info.Name(),
+		})
 	}
 
-	return nil
+	return images, nil
 }
 
-func pushOneImage(rootDir string, format string, imageName string, tag string, options types.PushImagesOptions) error {
+func pushOneImage(rootDir string, format string, imageName string, tag string, options types.PushImagesOptions, journal *pushJournal) error {
 	destCtx := &containerstypes.SystemContext{
 		DockerInsecureSkipTLSVerify: containerstypes.OptionalBoolTrue,
 		DockerDisableV1Ping:         true,
@@ -211,6 +250,19 @@ func pushOneImage(rootDir string, format string, imageName string, tag string, o
 		return errors.Wrapf(err, "failed to parse dest image name %s", destStr)
 	}
 
+	journalOpts := imagetypes.CopyImageOptions{
+		DestRef: destRef,
+		DestAuth: imagetypes.RegistryAuth{
+			Username: options.Registry.Username,
+			Password: options.Registry.Password,
+		},
+		SkipDestTLSVerify: true,
+	}
+	if journal.isPushed(journalOpts) {
+		writeProgressLine(options.ProgressWriter, fmt.Sprintf("Skipping %s, already pushed", destStr))
+		return nil
+	}
+
 	imageFile := filepath.Join(rootDir, format, imageName, tag)
 	localRef, err := alltransports.ParseImageName(fmt.Sprintf("%s:%s", format, imageFile))
 	if err != nil {
@@ -219,26 +271,75 @@ func pushOneImage(rootDir string, format string, imageName string, tag string, o
 
 	writeProgressLine(options.ProgressWriter, fmt.Sprintf("Pushing %s", destStr))
 
-	_, err = image.CopyImageWithGC(context.Background(), destRef, localRef, &copy.Options{
-		RemoveSignatures:      true,
-		SignBy:                "",
-		ReportWriter:          options.ProgressWriter,
-		SourceCtx:             nil,
-		DestinationCtx:        destCtx,
-		ForceManifestMIMEType: "",
+	err = retryWithBackoff(options.RetryAttempts, options.Log, func() error {
+		_, err := image.CopyImageWithGC(context.Background(), destRef, localRef, &copy.Options{
+			RemoveSignatures:      true,
+			SignBy:                "",
+			ReportWriter:          options.ProgressWriter,
+			SourceCtx:             nil,
+			DestinationCtx:        destCtx,
+			ForceManifestMIMEType: "",
+		})
+		return err
 	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to push image")
 	}
 
+	if err := journal.recordPushed(journalOpts); err != nil {
+		options.Log.Info("Failed to record %s in push journal: %v", destStr, err)
+	}
+
 	return nil
 }
 
+// pushConcurrency returns how many images can be pushed at the same time.
+// Progress reporting for the UI tracks one image at a time, so UI pushes are never concurrent.
+func pushConcurrency(options types.PushImagesOptions) int {
+	if options.LogForUI || options.Concurrency < 1 {
+		return 1
+	}
+	return options.Concurrency
+}
+
+// retryWithBackoff calls fn until it succeeds or the attempts run out, doubling the wait between attempts.
+func retryWithBackoff(attempts int, log *logger.CLILogger, fn func() error) error {
+	if attempts < 1 {
+		attempts = defaultPushRetryAttempts
+	}
+
+	backoff := pushRetryInitialBackoff
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+
+		log.ChildActionWithoutSpinner("encountered error (#%d) copying image, waiting %s before trying again: %s", i+1, backoff, err.Error())
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > pushRetryMaxBackoff {
+			backoff = pushRetryMaxBackoff
+		}
+	}
+
+	return err
+}
+
 func writeProgressLine(progressWriter io.Writer, line string) {
 	fmt.Fprint(progressWriter, fmt.Sprintf("%s\n", line))
 }
 
 func TagAndPushAppImagesFromPath(airgapRootDir string, options types.PushImagesOptions) ([]kustomizetypes.Image, error) {
+	return tagAndPushAppImagesFromPath(airgapRootDir, options, nil)
+}
+
+func tagAndPushAppImagesFromPath(airgapRootDir string, options types.PushImagesOptions, journal *pushJournal) ([]kustomizetypes.Image, error) {
 	airgap, err := kotsutil.FindAirgapMetaInDir(airgapRootDir)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find airgap meta")
@@ -246,9 +347,9 @@ func TagAndPushAppImagesFromPath(airgapRootDir string, options types.PushImagesO
 
 	switch airgap.Spec.Format {
 	case dockertypes.FormatDockerRegistry:
-		return PushAppImagesFromTempRegistry(airgapRootDir, airgap.Spec.SavedImages, options)
+		return pushAppImagesFromTempRegistry(airgapRootDir, airgap.Spec.SavedImages, options, journal)
 	case dockertypes.FormatDockerArchive, "":
-		return PushAppImagesFromDockerArchivePath(airgapRootDir, options)
+		return pushAppImagesFromDockerArchivePath(airgapRootDir, options, journal)
 	default:
 		return nil, errors.Errorf("Airgap bundle format '%s' is not supported", airgap.Spec.Format)
 	}
@@ -285,13 +386,26 @@ func TagAndPushAppImagesFromBundle(airgapBundle string, options types.PushImages
 }
 
 func PushAppImagesFromTempRegistry(airgapRootDir string, imageList []string, options types.PushImagesOptions) ([]kustomizetypes.Image, error) {
+	return pushAppImagesFromTempRegistry(airgapRootDir, imageList, options, nil)
+}
+
+func pushAppImagesFromTempRegistry(airgapRootDir string, imageList []string, options types.PushImagesOptions, journal *pushJournal) ([]kustomizetypes.Image, error) {
 	tempRegistry := &dockerregistry.TempRegistry{}
 	if err := tempRegistry.Start(filepath.Join(airgapRootDir, "images")); err != nil {
 		return nil, errors.Wrap(err, "failed to start temp registry")
 	}
 	defer tempRegistry.Stop()
 
-	rewrittenImages := []kustomizetypes.Image{}
+	delta, err := kotsutil.FindAirgapDeltaInDir(airgapRootDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find airgap delta")
+	}
+
+	rewrittenImages, err := verifyDeltaBaseImages(delta, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify delta base images")
+	}
+
 	imageInfos := make(map[string]*types.ImageInfo)
 
 	for _, image := range imageList {
@@ -321,6 +435,8 @@ func PushAppImagesFromTempRegistry(airgapRootDir string, imageList []string, opt
 		defer wc.Close()
 	}
 
+	policyReport := &imagetypes.ImagePolicyReport{}
+	allPushAppImageOpts := []types.PushAppImageOptions{}
 	for imageID, imageInfo := range imageInfos {
 		srcRef, err := tempRegistry.SrcRef(imageID)
 		if err != nil {
@@ -344,11 +460,12 @@ func PushAppImagesFromTempRegistry(airgapRootDir string, imageList []string, opt
 		rewrittenImages = append(rewrittenImages, *rewrittenImage)
 
 		pushAppImageOpts := types.PushAppImageOptions{
-			ImageID:      imageID,
-			ImageInfo:    imageInfo,
-			Log:          options.Log,
-			LogForUI:     options.LogForUI,
-			ReportWriter: reportWriter,
+			ImageID:       imageID,
+			ImageInfo:     imageInfo,
+			Log:           options.Log,
+			LogForUI:      options.LogForUI,
+			ReportWriter:  reportWriter,
+			RetryAttempts: options.RetryAttempts,
 			CopyImageOptions: imagetypes.CopyImageOptions{
 				SrcRef:  srcRef,
 				DestRef: destRef,
@@ -361,17 +478,89 @@ func PushAppImagesFromTempRegistry(airgapRootDir string, imageList []string, opt
 				SkipDestTLSVerify: true,
 				ReportWriter:      reportWriter,
 			},
+			OriginalImage:     imageID,
+			ImagePolicy:       options.ImagePolicy,
+			ImagePolicyReport: policyReport,
 		}
-		if err := pushAppImage(pushAppImageOpts); err != nil {
-			return nil, errors.Wrapf(err, "failed to push app image %s", imageID)
+		allPushAppImageOpts = append(allPushAppImageOpts, pushAppImageOpts)
+	}
+
+	// check all images before pushing anything so that a delta bundle missing blobs fails fast
+	statuses := make(map[string]*imagetypes.DestinationImageStatus)
+	missingBlobs := []string{}
+	alreadyPushed := make(map[string]bool)
+	for _, pushAppImageOpts := range allPushAppImageOpts {
+		if journal.isPushed(pushAppImageOpts.CopyImageOptions) {
+			alreadyPushed[pushAppImageOpts.ImageID] = true
+			continue
+		}
+		status := checkDestinationBeforePush(pushAppImageOpts)
+		statuses[pushAppImageOpts.ImageID] = status
+		if delta == nil || status == nil {
+			continue
+		}
+		for _, blob := range status.MissingBlobs {
+			hasBlob, err := tempRegistry.HasBlob(pushAppImageOpts.ImageID, blob.Digest.String())
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to check blob %s for image %s", blob.Digest, pushAppImageOpts.ImageID)
+			}
+			if !hasBlob {
+				missingBlobs = append(missingBlobs, fmt.Sprintf("%s@%s", pushAppImageOpts.ImageID, blob.Digest))
+			}
 		}
 	}
+	if len(missingBlobs) > 0 {
+		return nil, errors.Errorf("delta bundle is missing layers that are not in the registry, upload base version %s first: %s", deltaBaseName(delta), strings.Join(missingBlobs, ", "))
+	}
+
+	summary := &prePushSummary{}
+	g := errgroup.Group{}
+	g.SetLimit(pushConcurrency(options))
+	for _, pushAppImageOpts := range allPushAppImageOpts {
+		pushAppImageOpts := pushAppImageOpts
+		g.Go(func() error {
+			// images that are already in the registry must satisfy the policy too
+			if err := enforceAppImagePolicy(pushAppImageOpts); err != nil {
+				return errors.Wrapf(err, "failed to push app image %s", pushAppImageOpts.ImageID)
+			}
+			if alreadyPushed[pushAppImageOpts.ImageID] {
+				skipJournaledAppImage(pushAppImageOpts)
+				return nil
+			}
+			if applyDestinationImageStatus(pushAppImageOpts, statuses[pushAppImageOpts.ImageID], summary) {
+				return nil
+			}
+			if err := pushAppImageWithJournal(pushAppImageOpts, journal); err != nil {
+				return errors.Wrapf(err, "failed to push app image %s", pushAppImageOpts.ImageID)
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	writeImagePolicyReport(reportWriter, options.ImagePolicy, policyReport)
+	if err != nil {
+		return nil, err
+	}
+	summary.report(reportWriter)
 
 	return rewrittenImages, nil
 }
 
 func PushAppImagesFromDockerArchivePath(airgapRootDir string, options types.PushImagesOptions) ([]kustomizetypes.Image, error) {
-	rewrittenImages := []kustomizetypes.Image{}
+	return pushAppImagesFromDockerArchivePath(airgapRootDir, options, nil)
+}
+
+func pushAppImagesFromDockerArchivePath(airgapRootDir string, options types.PushImagesOptions, journal *pushJournal) ([]kustomizetypes.Image, error) {
+	delta, err := kotsutil.FindAirgapDeltaInDir(airgapRootDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find airgap delta")
+	}
+
+	rewrittenImages, err := verifyDeltaBaseImages(delta, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify delta base images")
+	}
+
 	imageInfos := make(map[string]*types.ImageInfo)
 
 	imagesDir := filepath.Join(airgapRootDir, "images")
@@ -416,48 +605,91 @@ func PushAppImagesFromDockerArchivePath(airgapRootDir string, options types.Push
 		defer wc.Close()
 	}
 
-	for imagePath, imageInfo := range imageInfos {
-		formatRoot := path.Join(imagesDir, imageInfo.Format)
-		pathWithoutRoot := imagePath[len(formatRoot)+1:]
-		rewrittenImage, err := image.RewriteDockerArchiveImage(options.Registry, strings.Split(pathWithoutRoot, string(os.PathSeparator)))
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to rewrite docker archive image")
-		}
-		rewrittenImages = append(rewrittenImages, rewrittenImage)
+	summary := &prePushSummary{}
+	policyReport := &imagetypes.ImagePolicyReport{}
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(pushConcurrency(options))
 
-		srcRef, err := alltransports.ParseImageName(fmt.Sprintf("%s:%s", dockertypes.FormatDockerArchive, imagePath))
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse src image name")
-		}
+	scheduleErr := func() error {
+		for imagePath, imageInfo := range imageInfos {
+			if ctx.Err() != nil {
+				// a push failed, there is no need to push the remaining images
+				return nil
+			}
 
-		destStr := fmt.Sprintf("docker://%s", image.DestImageFromKustomizeImage(rewrittenImage))
-		destRef, err := alltransports.ParseImageName(destStr)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to parse dest image name %s", destStr)
-		}
+			formatRoot := path.Join(imagesDir, imageInfo.Format)
+			pathWithoutRoot := imagePath[len(formatRoot)+1:]
+			rewrittenImage, err := image.RewriteDockerArchiveImage(options.Registry, strings.Split(pathWithoutRoot, string(os.PathSeparator)))
+			if err != nil {
+				return errors.Wrap(err, "failed to rewrite docker archive image")
+			}
+			rewrittenImages = append(rewrittenImages, rewrittenImage)
 
-		pushAppImageOpts := types.PushAppImageOptions{
-			ImageID:      imagePath,
-			ImageInfo:    imageInfo,
-			Log:          options.Log,
-			LogForUI:     options.LogForUI,
-			ReportWriter: reportWriter,
-			CopyImageOptions: imagetypes.CopyImageOptions{
-				SrcRef:  srcRef,
-				DestRef: destRef,
-				DestAuth: imagetypes.RegistryAuth{
-					Username: options.Registry.Username,
-					Password: options.Registry.Password,
+			srcRef, err := alltransports.ParseImageName(fmt.Sprintf("%s:%s", dockertypes.FormatDockerArchive, imagePath))
+			if err != nil {
+				return errors.Wrap(err, "failed to parse src image name")
+			}
+
+			destStr := fmt.Sprintf("docker://%s", image.DestImageFromKustomizeImage(rewrittenImage))
+			destRef, err := alltransports.ParseImageName(destStr)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse dest image name %s", destStr)
+			}
+
+			pushAppImageOpts := types.PushAppImageOptions{
+				ImageID:       imagePath,
+				ImageInfo:     imageInfo,
+				Log:           options.Log,
+				LogForUI:      options.LogForUI,
+				ReportWriter:  reportWriter,
+				RetryAttempts: options.RetryAttempts,
+				CopyImageOptions: imagetypes.CopyImageOptions{
+					SrcRef:  srcRef,
+					DestRef: destRef,
+					DestAuth: imagetypes.RegistryAuth{
+						Username: options.Registry.Username,
+						Password: options.Registry.Password,
+					},
+					CopyAll:           false, // docker-archive format does not support multi-arch images
+					SkipDestTLSVerify: true,
+					ReportWriter:      reportWriter,
 				},
-				CopyAll:           false, // docker-archive format does not support multi-arch images
-				SkipDestTLSVerify: true,
-				ReportWriter:      reportWriter,
-			},
-		}
-		if err := pushAppImage(pushAppImageOpts); err != nil {
-			return nil, errors.Wrapf(err, "failed to push app image %s", imagePath)
+				OriginalImage:     rewrittenImage.Name,
+				ImagePolicy:       options.ImagePolicy,
+				ImagePolicyReport: policyReport,
+			}
+			imagePath := imagePath
+			g.Go(func() error {
+				// images that are already in the registry must satisfy the policy too
+				if err := enforceAppImagePolicy(pushAppImageOpts); err != nil {
+					return errors.Wrapf(err, "failed to push app image %s", imagePath)
+				}
+				if journal.isPushed(pushAppImageOpts.CopyImageOptions) {
+					skipJournaledAppImage(pushAppImageOpts)
+					return nil
+				}
+				if applyDestinationImageStatus(pushAppImageOpts, checkDestinationBeforePush(pushAppImageOpts), summary) {
+					return nil
+				}
+				if err := pushAppImageWithJournal(pushAppImageOpts, journal); err != nil {
+					return errors.Wrapf(err, "failed to push app image %s", imagePath)
+				}
+				return nil
+			})
 		}
+		return nil
+	}()
+
+	// pushes that already started must finish before returning
+	err = g.Wait()
+	writeImagePolicyReport(reportWriter, options.ImagePolicy, policyReport)
+	if scheduleErr != nil {
+		return nil, scheduleErr
 	}
+	if err != nil {
+		return nil, err
+	}
+	summary.report(reportWriter)
 
 	return rewrittenImages, nil
 }
@@ -472,6 +704,16 @@ func PushAppImagesFromDockerArchiveBundle(airgapBundle string, options types.Pus
 		return nil, errors.Wrap(err, "failed to get images info from bundle")
 	}
 
+	delta, err := kotsutil.FindAirgapDeltaInBundle(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find airgap delta")
+	}
+
+	rewrittenImages, err := verifyDeltaBaseImages(delta, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify delta base images")
+	}
+
 	fileReader, err := os.Open(airgapBundle)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open file")
@@ -492,97 +734,151 @@ func PushAppImagesFromDockerArchiveBundle(airgapBundle string, options types.Pus
 		defer wc.Close()
 	}
 
-	rewrittenImages := []kustomizetypes.Image{}
+	summary := &prePushSummary{}
+	policyReport := &imagetypes.ImagePolicyReport{}
 
-	tarReader := tar.NewReader(gzipReader)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get read archive")
-		}
+	// images are extracted one at a time because the bundle is a stream, but pushed concurrently.
+	// extraction waits while all pushes are busy, so only a bounded number of images is on disk at a time.
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(pushConcurrency(options))
 
-		if header.Typeflag != tar.TypeReg {
-			continue
-		}
+	extractErr := func() error {
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			if ctx.Err() != nil {
+				// a push failed, there is no need to extract the remaining images
+				return nil
+			}
 
-		imagePath := header.Name
-		imageInfo, ok := imageInfos[imagePath]
-		if !ok {
-			continue
-		}
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to get read archive")
+			}
 
-		if options.LogForUI {
-			writeProgressLine(reportWriter, fmt.Sprintf("Extracting image %s", imagePath))
-		}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
 
-		tmpFile, err := ioutil.TempFile("", "kotsadm-app-image-")
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to create temp file")
-		}
-		defer tmpFile.Close()
-		defer os.Remove(tmpFile.Name())
+			imagePath := header.Name
+			imageInfo, ok := imageInfos[imagePath]
+			if !ok {
+				continue
+			}
 
-		_, err = io.Copy(tmpFile, tarReader)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to write file %q", imagePath)
-		}
+			if options.LogForUI {
+				writeProgressLine(reportWriter, fmt.Sprintf("Extracting image %s", imagePath))
+			}
 
-		// Close file to flush all data before pushing to registry
-		if err := tmpFile.Close(); err != nil {
-			return nil, errors.Wrap(err, "failed to close tmp file")
-		}
+			tmpFile, err := extractAppImage(tarReader, imagePath)
+			if err != nil {
+				return err
+			}
 
-		pathParts := strings.Split(imagePath, string(os.PathSeparator))
-		if len(pathParts) < 3 {
-			return nil, errors.Errorf("not enough path parts in %q", imagePath)
-		}
+			pathParts := strings.Split(imagePath, string(os.PathSeparator))
+			if len(pathParts) < 3 {
+				os.Remove(tmpFile)
+				return errors.Errorf("not enough path parts in %q", imagePath)
+			}
 
-		rewrittenImage, err := image.RewriteDockerArchiveImage(options.Registry, pathParts[2:])
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to rewrite docker archive image")
-		}
-		rewrittenImages = append(rewrittenImages, rewrittenImage)
+			rewrittenImage, err := image.RewriteDockerArchiveImage(options.Registry, pathParts[2:])
+			if err != nil {
+				os.Remove(tmpFile)
+				return errors.Wrap(err, "failed to rewrite docker archive image")
+			}
+			rewrittenImages = append(rewrittenImages, rewrittenImage)
 
-		srcRef, err := alltransports.ParseImageName(fmt.Sprintf("%s:%s", dockertypes.FormatDockerArchive, tmpFile.Name()))
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse src image name")
-		}
+			srcRef, err := alltransports.ParseImageName(fmt.Sprintf("%s:%s", dockertypes.FormatDockerArchive, tmpFile))
+			if err != nil {
+				os.Remove(tmpFile)
+				return errors.Wrap(err, "failed to parse src image name")
+			}
 
-		destStr := fmt.Sprintf("docker://%s", image.DestImageFromKustomizeImage(rewrittenImage))
-		destRef, err := alltransports.ParseImageName(destStr)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to parse dest image name %s", destStr)
-		}
+			destStr := fmt.Sprintf("docker://%s", image.DestImageFromKustomizeImage(rewrittenImage))
+			destRef, err := alltransports.ParseImageName(destStr)
+			if err != nil {
+				os.Remove(tmpFile)
+				return errors.Wrapf(err, "failed to parse dest image name %s", destStr)
+			}
 
-		pushAppImageOpts := types.PushAppImageOptions{
-			ImageID:      imagePath,
-			ImageInfo:    imageInfo,
-			Log:          options.Log,
-			LogForUI:     options.LogForUI,
-			ReportWriter: reportWriter,
-			CopyImageOptions: imagetypes.CopyImageOptions{
-				SrcRef:  srcRef,
-				DestRef: destRef,
-				DestAuth: imagetypes.RegistryAuth{
-					Username: options.Registry.Username,
-					Password: options.Registry.Password,
+			pushAppImageOpts := types.PushAppImageOptions{
+				ImageID:       imagePath,
+				ImageInfo:     imageInfo,
+				Log:           options.Log,
+				LogForUI:      options.LogForUI,
+				ReportWriter:  reportWriter,
+				RetryAttempts: options.RetryAttempts,
+				CopyImageOptions: imagetypes.CopyImageOptions{
+					SrcRef:  srcRef,
+					DestRef: destRef,
+					DestAuth: imagetypes.RegistryAuth{
+						Username: options.Registry.Username,
+						Password: options.Registry.Password,
+					},
+					CopyAll:           false, // docker-archive format does not support multi-arch images
+					SkipDestTLSVerify: true,
+					ReportWriter:      reportWriter,
 				},
-				CopyAll:           false, // docker-archive format does not support multi-arch images
-				SkipDestTLSVerify: true,
-				ReportWriter:      reportWriter,
-			},
-		}
-		if err := pushAppImage(pushAppImageOpts); err != nil {
-			return nil, errors.Wrapf(err, "failed to push app image %s", imagePath)
+				OriginalImage:     rewrittenImage.Name,
+				ImagePolicy:       options.ImagePolicy,
+				ImagePolicyReport: policyReport,
+			}
+			g.Go(func() error {
+				defer os.Remove(tmpFile)
+
+				// images that are already in the registry must satisfy the policy too
+				if err := enforceAppImagePolicy(pushAppImageOpts); err != nil {
+					return errors.Wrapf(err, "failed to push app image %s", imagePath)
+				}
+				if applyDestinationImageStatus(pushAppImageOpts, checkDestinationBeforePush(pushAppImageOpts), summary) {
+					return nil
+				}
+				if err := pushAppImage(pushAppImageOpts); err != nil {
+					return errors.Wrapf(err, "failed to push app image %s", imagePath)
+				}
+				return nil
+			})
 		}
+	}()
+
+	// pushes that already started must finish before the temp files and the report writer go away
+	err = g.Wait()
+	writeImagePolicyReport(reportWriter, options.ImagePolicy, policyReport)
+	if extractErr != nil {
+		return nil, extractErr
+	}
+	if err != nil {
+		return nil, err
 	}
+	summary.report(reportWriter)
 
 	return rewrittenImages, nil
 }
 
+// extractAppImage writes the current entry of the bundle to a temp file and returns its path
+func extractAppImage(tarReader *tar.Reader, imagePath string) (string, error) {
+	tmpFile, err := ioutil.TempFile("", "kotsadm-app-image-")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temp file")
+	}
+
+	if _, err := io.Copy(tmpFile, tarReader); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", errors.Wrapf(err, "failed to write file %q", imagePath)
+	}
+
+	// Close file to flush all data before pushing to registry
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", errors.Wrap(err, "failed to close tmp file")
+	}
+
+	return tmpFile.Name(), nil
+}
+
 func pushAppImage(opts types.PushAppImageOptions) error {
 	opts.ImageInfo.UploadStart = time.Now()
 	if opts.LogForUI {
@@ -593,18 +889,9 @@ func pushAppImage(opts types.PushAppImageOptions) error {
 		writeProgressLine(opts.ReportWriter, fmt.Sprintf("Pushing image %s", destImageStr))
 	}
 
-	var retryAttempts int = 5
-	var copyError error
-
-	for i := 0; i < retryAttempts; i++ {
-		copyError = image.CopyImage(opts.CopyImageOptions)
-		if copyError == nil {
-			break // image copy succeeded, exit the retry loop
-		} else {
-			opts.Log.ChildActionWithoutSpinner("encountered error (#%d) copying image, waiting 10s before trying again: %s", i+1, copyError.Error())
-			time.Sleep(time.Second * 10)
-		}
-	}
+	copyError := retryWithBackoff(opts.RetryAttempts, opts.Log, func() error {
+		return image.CopyImage(opts.CopyImageOptions)
+	})
 	if copyError != nil {
 		if opts.LogForUI {
 			opts.ReportWriter.Write([]byte(fmt.Sprintf("+file.error:%s\n", copyError)))
@@ -663,6 +950,20 @@ func GetImagesFromBundle(airgapBundle string, options types.PushImagesOptions) (
 		return nil, errors.Errorf("Airgap bundle format '%s' is not supported", airgap.Spec.Format)
 	}
 
+	delta, err := kotsutil.FindAirgapDeltaInBundle(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find airgap delta")
+	}
+	if delta != nil {
+		for _, baseImage := range delta.BaseImages {
+			rewrittenImage, err := image.RewriteDockerRegistryImage(options.Registry, baseImage)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to rewrite image %s", baseImage)
+			}
+			rewrittenImages = append(rewrittenImages, *rewrittenImage)
+		}
+	}
+
 	return rewrittenImages, nil
 }
 
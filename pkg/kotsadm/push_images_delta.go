@@ -0,0 +1,142 @@
+package kotsadm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/image"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+)
+
+// prePushSummary accumulates what the pre-push checks found so that the savings can be reported once all images are pushed.
+// Images can be pushed concurrently, so the summary is guarded by a mutex.
+type prePushSummary struct {
+	mtx           sync.Mutex
+	skippedImages int
+	reusedLayers  int
+	skippedBytes  int64
+}
+
+func (s *prePushSummary) report(progressWriter io.Writer) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.skippedImages == 0 && s.reusedLayers == 0 {
+		return
+	}
+	writeProgressLine(progressWriter, fmt.Sprintf("Skipped %d images and %d layers already present in registry (%s not pushed)", s.skippedImages, s.reusedLayers, units.HumanSize(float64(s.skippedBytes))))
+}
+
+// checkDestinationBeforePush is best effort. If the destination registry cannot be inspected, the image is pushed as usual.
+func checkDestinationBeforePush(opts types.PushAppImageOptions) *imagetypes.DestinationImageStatus {
+	status, err := image.CheckDestinationImage(opts.CopyImageOptions)
+	if err != nil {
+		opts.Log.Info("Failed to check if image %s is already in the registry: %v", opts.ImageID, err)
+		return nil
+	}
+	return status
+}
+
+// applyDestinationImageStatus marks the layers that the destination already has as uploaded and adds them to the summary.
+// Returns true if the destination already has the whole image and the push can be skipped.
+func applyDestinationImageStatus(opts types.PushAppImageOptions, status *imagetypes.DestinationImageStatus, summary *prePushSummary) bool {
+	if status == nil {
+		return false
+	}
+
+	now := time.Now()
+	for digest := range status.ExistingBlobs {
+		if layer := opts.ImageInfo.Layers[strings.TrimPrefix(digest, "sha256:")]; layer != nil {
+			layer.UploadStart = now
+			layer.UploadEnd = now
+		}
+	}
+
+	summary.mtx.Lock()
+	defer summary.mtx.Unlock()
+
+	summary.skippedBytes += status.SkippedBytes
+
+	destImageStr := opts.CopyImageOptions.DestRef.DockerReference().String()
+	if status.ImagePresent {
+		summary.skippedImages++
+		opts.ImageInfo.Status = "uploaded"
+		opts.ImageInfo.UploadStart = now
+		opts.ImageInfo.UploadEnd = now
+		writeProgressLine(opts.ReportWriter, fmt.Sprintf("Skipping image %s, already present in registry (%s)", destImageStr, units.HumanSize(float64(status.SkippedBytes))))
+		return true
+	}
+
+	summary.reusedLayers += len(status.ExistingBlobs)
+	if len(status.ExistingBlobs) > 0 {
+		writeProgressLine(opts.ReportWriter, fmt.Sprintf("Reusing %d layers of image %s already present in registry (%s)", len(status.ExistingBlobs), destImageStr, units.HumanSize(float64(status.SkippedBytes))))
+	}
+
+	return false
+}
+
+// verifyDeltaBaseImages makes sure that every image the delta bundle does not ship is already in the destination registry.
+func verifyDeltaBaseImages(delta *kotsutil.AirgapDelta, options types.PushImagesOptions) ([]kustomizetypes.Image, error) {
+	if delta == nil {
+		return []kustomizetypes.Image{}, nil
+	}
+
+	if options.LogForUI {
+		writeProgressLine(options.ProgressWriter, fmt.Sprintf("Checking images from base version %s...", deltaBaseName(delta)))
+	}
+
+	rewrittenImages := []kustomizetypes.Image{}
+	missingImages := []string{}
+	for _, baseImage := range delta.BaseImages {
+		rewrittenImage, err := image.RewriteDockerRegistryImage(options.Registry, baseImage)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to rewrite image %s", baseImage)
+		}
+
+		destStr := fmt.Sprintf("docker://%s", image.DestImageFromKustomizeImage(*rewrittenImage))
+		destRef, err := alltransports.ParseImageName(destStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse dest image %s", destStr)
+		}
+
+		exists, err := image.DestinationImageExists(imagetypes.CopyImageOptions{
+			DestRef: destRef,
+			DestAuth: imagetypes.RegistryAuth{
+				Username: options.Registry.Username,
+				Password: options.Registry.Password,
+			},
+			SkipDestTLSVerify: true,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check image %s", baseImage)
+		}
+		if !exists {
+			missingImages = append(missingImages, baseImage)
+			continue
+		}
+
+		rewrittenImages = append(rewrittenImages, *rewrittenImage)
+	}
+
+	if len(missingImages) > 0 {
+		return nil, errors.Errorf("delta bundle requires images from base version %s that are missing in the registry: %s", deltaBaseName(delta), strings.Join(missingImages, ", "))
+	}
+
+	return rewrittenImages, nil
+}
+
+func deltaBaseName(delta *kotsutil.AirgapDelta) string {
+	if delta.BaseVersionLabel != "" {
+		return delta.BaseVersionLabel
+	}
+	return delta.BaseUpdateCursor
+}
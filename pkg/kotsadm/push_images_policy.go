@@ -0,0 +1,32 @@
+package kotsadm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/image"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
+)
+
+// enforceAppImagePolicy checks the image against the image policy before it is pushed or skipped,
+// so that images that are already in the registry or in the push journal cannot bypass the policy.
+func enforceAppImagePolicy(opts types.PushAppImageOptions) error {
+	if err := image.EnforceImagePolicy(opts.ImagePolicy, opts.ImagePolicyReport, opts.CopyImageOptions, opts.OriginalImage); err != nil {
+		if opts.LogForUI {
+			opts.ReportWriter.Write([]byte(fmt.Sprintf("+file.error:%s\n", err)))
+		}
+		opts.Log.FinishChildSpinner()
+		return errors.Wrap(err, "failed to check image policy")
+	}
+	return nil
+}
+
+// writeImagePolicyReport lists which images passed or failed the image policy, including images that were skipped
+// because they were already in the registry.
+func writeImagePolicyReport(progressWriter io.Writer, policy *imagetypes.ImagePolicy, report *imagetypes.ImagePolicyReport) {
+	for _, line := range image.ImagePolicyReportLines(policy, report) {
+		writeProgressLine(progressWriter, line)
+	}
+}
@@ -0,0 +1,75 @@
+package kotsadm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_enforceAppImagePolicy(t *testing.T) {
+	srcRef, err := alltransports.ParseImageName("docker-archive:/does-not-exist.tar")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		policy     *imagetypes.ImagePolicy
+		wantErr    bool
+		wantPassed []bool
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+		},
+		{
+			name:       "failing image",
+			policy:     &imagetypes.ImagePolicy{RequireSBOM: true},
+			wantErr:    true,
+			wantPassed: []bool{false},
+		},
+		{
+			name:       "dry run",
+			policy:     &imagetypes.ImagePolicy{RequireSBOM: true, DryRun: true},
+			wantPassed: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &imagetypes.ImagePolicyReport{}
+			reportWriter := &bytes.Buffer{}
+
+			err := enforceAppImagePolicy(types.PushAppImageOptions{
+				ImageID:           "nginx",
+				Log:               logger.NewCLILogger(reportWriter),
+				LogForUI:          true,
+				ReportWriter:      reportWriter,
+				CopyImageOptions:  imagetypes.CopyImageOptions{SrcRef: srcRef},
+				OriginalImage:     "nginx:1.21",
+				ImagePolicy:       tt.policy,
+				ImagePolicyReport: report,
+			})
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, reportWriter.String(), "+file.error:")
+			} else {
+				require.NoError(t, err)
+			}
+
+			passed := []bool{}
+			for _, result := range report.Results() {
+				assert.Equal(t, "nginx:1.21", result.Image)
+				passed = append(passed, result.Passed)
+			}
+			if tt.wantPassed == nil {
+				assert.Empty(t, passed)
+			} else {
+				assert.Equal(t, tt.wantPassed, passed)
+			}
+		})
+	}
+}
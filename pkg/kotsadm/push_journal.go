@@ -0,0 +1,177 @@
+package kotsadm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/image"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+// PushJournalPath returns the path of the push journal that is written next to the airgap bundle.
+func PushJournalPath(airgapBundle string) string {
+	return airgapBundle + ".push-journal.json"
+}
+
+// pushJournal records images that were confirmed pushed so that a re-run can skip them.
+// A nil journal is valid and records nothing.
+type pushJournal struct {
+	path string
+	mtx  sync.Mutex
+
+	Bundle pushJournalBundle            `json:"bundle"`
+	Images map[string]pushJournalRecord `json:"images"` // keyed by destination image
+}
+
+type pushJournalBundle struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type pushJournalRecord struct {
+	Digest   string    `json:"digest"`
+	PushedAt time.Time `json:"pushedAt"`
+}
+
+// loadPushJournal reads the journal at path. If the journal was written for a different bundle or cannot be parsed,
+// it starts over. The journal is only an optimization, so a corrupt journal means images are pushed again.
+func loadPushJournal(path string, airgapBundle string, log *logger.CLILogger) (*pushJournal, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	fileInfo, err := os.Stat(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat airgap bundle")
+	}
+
+	bundle := pushJournalBundle{
+		Name:    filepath.Base(airgapBundle),
+		Size:    fileInfo.Size(),
+		ModTime: fileInfo.ModTime().UTC(),
+	}
+
+	journal := &pushJournal{
+		path:   path,
+		Bundle: bundle,
+		Images: map[string]pushJournalRecord{},
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return journal, nil
+		}
+		return nil, errors.Wrap(err, "failed to read push journal")
+	}
+
+	existing := pushJournal{}
+	if err := json.Unmarshal(b, &existing); err != nil {
+		log.Info("Ignoring push journal %s, failed to parse it: %v", path, err)
+		return journal, nil
+	}
+
+	if existing.Bundle.Name != bundle.Name || existing.Bundle.Size != bundle.Size || !existing.Bundle.ModTime.Equal(bundle.ModTime) {
+		return journal, nil
+	}
+
+	if existing.Images != nil {
+		journal.Images = existing.Images
+	}
+
+	return journal, nil
+}
+
+// isPushed returns true if the image was recorded as pushed and the destination still resolves to the recorded digest.
+func (j *pushJournal) isPushed(opts imagetypes.CopyImageOptions) bool {
+	if j == nil {
+		return false
+	}
+
+	j.mtx.Lock()
+	record, ok := j.Images[opts.DestRef.DockerReference().String()]
+	j.mtx.Unlock()
+	if !ok {
+		return false
+	}
+
+	digest, err := image.DestinationManifestDigest(opts)
+	if err != nil {
+		return false
+	}
+
+	return digest == record.Digest
+}
+
+// recordPushed saves the digest the destination resolves to after a successful push.
+func (j *pushJournal) recordPushed(opts imagetypes.CopyImageOptions) error {
+	if j == nil {
+		return nil
+	}
+
+	digest, err := image.DestinationManifestDigest(opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to get pushed digest")
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	j.Images[opts.DestRef.DockerReference().String()] = pushJournalRecord{
+		Digest:   digest,
+		PushedAt: time.Now().UTC(),
+	}
+
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal push journal")
+	}
+
+	// write to a temp file first so that an interrupted write does not corrupt the journal
+	tmpPath := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write push journal")
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return errors.Wrap(err, "failed to rename push journal")
+	}
+
+	return nil
+}
+
+// pushAppImageWithJournal pushes the image and records it in the journal once the destination confirms its digest.
+// Failing to record the image only means it will be pushed again on a re-run.
+func pushAppImageWithJournal(opts types.PushAppImageOptions, journal *pushJournal) error {
+	if err := pushAppImage(opts); err != nil {
+		return err
+	}
+
+	if err := journal.recordPushed(opts.CopyImageOptions); err != nil {
+		opts.Log.Info("Failed to record image %s in push journal: %v", opts.ImageID, err)
+	}
+
+	return nil
+}
+
+func skipJournaledAppImage(opts types.PushAppImageOptions) {
+	now := time.Now()
+	opts.ImageInfo.Status = "uploaded"
+	opts.ImageInfo.UploadStart = now
+	opts.ImageInfo.UploadEnd = now
+	for _, layer := range opts.ImageInfo.Layers {
+		layer.UploadStart = now
+		layer.UploadEnd = now
+	}
+
+	destImageStr := opts.CopyImageOptions.DestRef.DockerReference().String()
+	writeProgressLine(opts.ReportWriter, fmt.Sprintf("Skipping image %s, already pushed", destImageStr))
+}
@@ -0,0 +1,300 @@
+package kotsadm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/distribution/distribution/v3/configuration"
+	distributionregistry "github.com/distribution/distribution/v3/registry"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem" // this initializes the filesystem storage driver
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain serves the temp registry when the test binary is started by TempRegistry.Start,
+// which runs the current binary with "docker-registry serve <config>".
+func TestMain(m *testing.M) {
+	if len(os.Args) == 4 && os.Args[1] == "docker-registry" && os.Args[2] == "serve" {
+		if err := serveTempRegistry(os.Args[3]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func serveTempRegistry(configPath string) error {
+	fp, err := os.Open(configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to open config")
+	}
+	defer fp.Close()
+
+	config, err := configuration.Parse(fp)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse config")
+	}
+
+	reg, err := distributionregistry.NewRegistry(context.Background(), config)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize registry")
+	}
+
+	return reg.ListenAndServe()
+}
+
+// testDestRegistry serves a single manifest and records any request that would push to the registry
+type testDestRegistry struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	manifest string
+	pushes   []string
+}
+
+func newTestDestRegistry(manifest string) *testDestRegistry {
+	r := &testDestRegistry{manifest: manifest}
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			r.pushes = append(r.pushes, fmt.Sprintf("%s %s", req.Method, req.URL.Path))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if req.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if strings.Contains(req.URL.Path, "/manifests/") {
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Header().Set("Docker-Content-Digest", digest.FromString(r.manifest).String())
+			fmt.Fprint(w, r.manifest)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return r
+}
+
+func (r *testDestRegistry) setManifest(manifest string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.manifest = manifest
+}
+
+func (r *testDestRegistry) endpoint() string {
+	return strings.TrimPrefix(r.URL, "https://")
+}
+
+func testManifest(configDigest string) string {
+	return fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":2,"digest":"%s"},"layers":[]}`, configDigest)
+}
+
+func Test_loadPushJournal(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bundle := filepath.Join(tmpDir, "app.airgap")
+	err := ioutil.WriteFile(bundle, []byte("bundle"), 0644)
+	require.NoError(t, err)
+
+	fileInfo, err := os.Stat(bundle)
+	require.NoError(t, err)
+
+	records := map[string]pushJournalRecord{
+		"registry.example.com/app/nginx:1.21": {
+			Digest:   "sha256:abc",
+			PushedAt: time.Now().UTC(),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		journalBundle pushJournalBundle
+		want          map[string]pushJournalRecord
+	}{
+		{
+			name: "same bundle",
+			journalBundle: pushJournalBundle{
+				Name:    "app.airgap",
+				Size:    fileInfo.Size(),
+				ModTime: fileInfo.ModTime().UTC(),
+			},
+			want: records,
+		},
+		{
+			name: "different bundle size",
+			journalBundle: pushJournalBundle{
+				Name:    "app.airgap",
+				Size:    fileInfo.Size() + 1,
+				ModTime: fileInfo.ModTime().UTC(),
+			},
+			want: map[string]pushJournalRecord{},
+		},
+		{
+			name: "different bundle name",
+			journalBundle: pushJournalBundle{
+				Name:    "other.airgap",
+				Size:    fileInfo.Size(),
+				ModTime: fileInfo.ModTime().UTC(),
+			},
+			want: map[string]pushJournalRecord{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			journalPath := PushJournalPath(bundle)
+			b, err := json.Marshal(&pushJournal{
+				Bundle: tt.journalBundle,
+				Images: records,
+			})
+			req.NoError(err)
+			req.NoError(ioutil.WriteFile(journalPath, b, 0644))
+
+			journal, err := loadPushJournal(journalPath, bundle, nil)
+			req.NoError(err)
+
+			req.Equal(len(tt.want), len(journal.Images))
+			for k, v := range tt.want {
+				req.Equal(v.Digest, journal.Images[k].Digest)
+			}
+		})
+	}
+}
+
+func Test_loadPushJournalCorrupt(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bundle := filepath.Join(tmpDir, "app.airgap")
+	require.NoError(t, ioutil.WriteFile(bundle, []byte("bundle"), 0644))
+
+	journalPath := PushJournalPath(bundle)
+	require.NoError(t, ioutil.WriteFile(journalPath, []byte(`{"bundle":{"name":"app.airg`), 0644))
+
+	journal, err := loadPushJournal(journalPath, bundle, nil)
+	require.NoError(t, err)
+	require.NotNil(t, journal)
+	assert.Equal(t, "app.airgap", journal.Bundle.Name)
+	assert.Empty(t, journal.Images)
+}
+
+func Test_pushJournalRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bundle := filepath.Join(tmpDir, "app.airgap")
+	require.NoError(t, ioutil.WriteFile(bundle, []byte("bundle"), 0644))
+
+	registry := newTestDestRegistry(testManifest(digest.FromString("config-1").String()))
+	defer registry.Close()
+
+	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s/app/nginx:1.21", registry.endpoint()))
+	require.NoError(t, err)
+	opts := imagetypes.CopyImageOptions{
+		DestRef:           destRef,
+		SkipDestTLSVerify: true,
+	}
+
+	journalPath := PushJournalPath(bundle)
+	journal, err := loadPushJournal(journalPath, bundle, nil)
+	require.NoError(t, err)
+
+	assert.False(t, journal.isPushed(opts))
+	require.NoError(t, journal.recordPushed(opts))
+	assert.True(t, journal.isPushed(opts))
+
+	// a re-run loads the recorded images
+	reloaded, err := loadPushJournal(journalPath, bundle, nil)
+	require.NoError(t, err)
+	assert.True(t, reloaded.isPushed(opts))
+
+	// the image is pushed again if the tag was moved in the destination
+	registry.setManifest(testManifest(digest.FromString("config-2").String()))
+	assert.False(t, reloaded.isPushed(opts))
+}
+
+func Test_pushAppImagesFromTempRegistrySkipsJournaledImages(t *testing.T) {
+	airgapRootDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(airgapRootDir, "images"), 0755))
+
+	bundle := filepath.Join(t.TempDir(), "app.airgap")
+	require.NoError(t, ioutil.WriteFile(bundle, []byte("bundle"), 0644))
+
+	registry := newTestDestRegistry(testManifest(digest.FromString("config").String()))
+	defer registry.Close()
+
+	journal, err := loadPushJournal(PushJournalPath(bundle), bundle, nil)
+	require.NoError(t, err)
+
+	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s/app/nginx:1.21", registry.endpoint()))
+	require.NoError(t, err)
+	require.NoError(t, journal.recordPushed(imagetypes.CopyImageOptions{
+		DestRef:           destRef,
+		SkipDestTLSVerify: true,
+	}))
+
+	progress := &bytes.Buffer{}
+	images, err := pushAppImagesFromTempRegistry(airgapRootDir, []string{"nginx:1.21"}, types.PushImagesOptions{
+		Registry: registrytypes.RegistryOptions{
+			Endpoint:  registry.endpoint(),
+			Namespace: "app",
+		},
+		ProgressWriter: progress,
+	}, journal)
+	require.NoError(t, err)
+
+	assert.Len(t, images, 1)
+	assert.Contains(t, progress.String(), fmt.Sprintf("Skipping image %s/app/nginx:1.21, already pushed", registry.endpoint()))
+	assert.Empty(t, registry.pushes)
+}
+
+func Test_loadPushJournalDisabled(t *testing.T) {
+	journal, err := loadPushJournal("", "does-not-matter.airgap", nil)
+	require.NoError(t, err)
+	assert.Nil(t, journal)
+	assert.Nil(t, journal.recordPushed(imagetypes.CopyImageOptions{}))
+}
+
+func Test_retryWithBackoff(t *testing.T) {
+	defer func(d time.Duration) { pushRetryInitialBackoff = d }(pushRetryInitialBackoff)
+	pushRetryInitialBackoff = time.Millisecond
+
+	calls := 0
+	err := retryWithBackoff(3, nil, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("blip")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = retryWithBackoff(2, nil, func() error {
+		calls++
+		return errors.New("down")
+	})
+	require.EqualError(t, err, "down")
+	assert.Equal(t, 2, calls)
+}
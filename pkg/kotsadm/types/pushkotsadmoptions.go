@@ -15,6 +15,14 @@ type PushImagesOptions struct {
 	Log            *logger.CLILogger
 	ProgressWriter io.Writer
 	LogForUI       bool
+	// Concurrency is the number of images pushed in parallel. Images are always pushed one at a time when LogForUI is set.
+	Concurrency int
+	// RetryAttempts is the number of times pushing an image is attempted, 0 uses the default.
+	RetryAttempts int
+	// JournalPath is where images confirmed pushed are recorded so that a re-run can skip them. Empty disables the journal.
+	JournalPath string
+	// ImagePolicy is checked for every app image before it is pushed. Nil disables the check.
+	ImagePolicy *imagetypes.ImagePolicy
 }
 
 type PushAppImageOptions struct {
@@ -23,7 +31,12 @@ type PushAppImageOptions struct {
 	Log              *logger.CLILogger
 	LogForUI         bool
 	ReportWriter     io.Writer
+	RetryAttempts    int
 	CopyImageOptions imagetypes.CopyImageOptions
+	// OriginalImage is the image as referenced by the application, signatures must be made for its repository.
+	OriginalImage     string
+	ImagePolicy       *imagetypes.ImagePolicy
+	ImagePolicyReport *imagetypes.ImagePolicyReport
 }
 
 type ImageInfo struct {
@@ -142,6 +142,12 @@ func CreateApplicationBackup(ctx context.Context, a *apptypes.App, isScheduled b
 	}
 	veleroBackup.Spec.LabelSelector = &labelSelector
 
+	operatorHooks, err := store.GetStore().GetSnapshotHooks(a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get snapshot hooks")
+	}
+	veleroBackup.Spec.Hooks.Resources = append(veleroBackup.Spec.Hooks.Resources, operatorBackupHooks(a.Slug, operatorHooks, appNamespace)...)
+
 	includeClusterResources := true
 	if veleroBackup.Spec.IncludeClusterResources != nil {
 		includeClusterResources = *veleroBackup.Spec.IncludeClusterResources
@@ -160,6 +166,12 @@ func CreateApplicationBackup(ctx context.Context, a *apptypes.App, isScheduled b
 		}
 	}
 
+	retentionPolicy, err := store.GetStore().GetSnapshotRetentionPolicy(a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get snapshot retention policy")
+	}
+	applyRetentionPolicyTTL(veleroBackup, retentionPolicy)
+
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create k8s clientset")
@@ -180,8 +192,19 @@ func CreateApplicationBackup(ctx context.Context, a *apptypes.App, isScheduled b
 		return nil, errors.Wrap(err, "failed to create clientset")
 	}
 
+	// workloads are scaled back up by the snapshot scheduler once the backup has finished
+	scaleResults, err := runScaleDownHooks(ctx, clientset, a.Slug, operatorHooks, appNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run scale hooks")
+	}
+	if err := setOperatorHookResults(veleroBackup, scaleResults); err != nil {
+		runScaleUpHooks(ctx, clientset, scaleResults)
+		return nil, errors.Wrap(err, "failed to set operator hook results")
+	}
+
 	backup, err := veleroClient.Backups(kotsadmVeleroBackendStorageLocation.Namespace).Create(ctx, veleroBackup, metav1.CreateOptions{})
 	if err != nil {
+		runScaleUpHooks(ctx, clientset, scaleResults)
 		return nil, errors.Wrap(err, "failed to create velero backup")
 	}
 
@@ -210,6 +233,8 @@ func CreateInstanceBackup(ctx context.Context, cluster *downstreamtypes.Downstre
 	backupHooks := velerov1.BackupHooks{
 		Resources: []velerov1.BackupResourceHookSpec{},
 	}
+	operatorHooksBySlug := map[string][]types.OperatorHook{}
+	operatorHooksSlugs := []string{}
 	// non-supported fields that are intentionally left out cuz they might break full snapshots:
 	// - includedResources
 	// - excludedResources
@@ -308,6 +333,15 @@ func CreateInstanceBackup(ctx context.Context, cluster *downstreamtypes.Downstre
 
 		// backup hooks
 		backupHooks.Resources = append(backupHooks.Resources, veleroBackup.Spec.Hooks.Resources...)
+
+		// operator hooks
+		operatorHooks, err := store.GetStore().GetSnapshotHooks(a.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get snapshot hooks for app %s", a.Slug)
+		}
+		backupHooks.Resources = append(backupHooks.Resources, operatorBackupHooks(a.Slug, operatorHooks, appNamespace)...)
+		operatorHooksBySlug[a.Slug] = operatorHooks
+		operatorHooksSlugs = append(operatorHooksSlugs, a.Slug)
 	}
 
 	kotsadmVeleroBackendStorageLocation, err := kotssnapshot.FindBackupStoreLocation(ctx, kotsadmNamespace)
@@ -393,6 +427,12 @@ func CreateInstanceBackup(ctx context.Context, cluster *downstreamtypes.Downstre
 		}
 	}
 
+	retentionPolicy, err := store.GetStore().GetInstanceSnapshotRetentionPolicy(cluster.ClusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get instance snapshot retention policy")
+	}
+	applyRetentionPolicyTTL(veleroBackup, retentionPolicy)
+
 	err = excludeShutdownPodsFromBackup(ctx, clientset, veleroBackup)
 	if err != nil {
 		logger.Error(errors.Wrap(err, "failed to exclude shutdown pods from backup"))
@@ -408,8 +448,24 @@ func CreateInstanceBackup(ctx context.Context, cluster *downstreamtypes.Downstre
 		return nil, errors.Wrap(err, "failed to create velero clientset")
 	}
 
+	// workloads are scaled back up by the snapshot scheduler once the backup has finished
+	scaleResults := []types.OperatorHookResult{}
+	for _, appSlug := range operatorHooksSlugs {
+		results, err := runScaleDownHooks(ctx, clientset, appSlug, operatorHooksBySlug[appSlug], appNamespace)
+		scaleResults = append(scaleResults, results...)
+		if err != nil {
+			runScaleUpHooks(ctx, clientset, scaleResults)
+			return nil, errors.Wrapf(err, "failed to run scale hooks for app %s", appSlug)
+		}
+	}
+	if err := setOperatorHookResults(veleroBackup, scaleResults); err != nil {
+		runScaleUpHooks(ctx, clientset, scaleResults)
+		return nil, errors.Wrap(err, "failed to set operator hook results")
+	}
+
 	backup, err := veleroClient.Backups(kotsadmVeleroBackendStorageLocation.Namespace).Create(ctx, veleroBackup, metav1.CreateOptions{})
 	if err != nil {
+		runScaleUpHooks(ctx, clientset, scaleResults)
 		return nil, errors.Wrap(err, "failed to create velero backup")
 	}
 
@@ -486,6 +542,12 @@ func ListBackupsForApp(ctx context.Context, kotsadmNamespace string, appID strin
 			backup.SupportBundleID = supportBundleID
 		}
 
+		restoreVerification, err := GetRestoreVerification(&veleroBackup)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get restore verification")
+		}
+		backup.RestoreVerification = restoreVerification
+
 		volumeCount, volumeCountOk := veleroBackup.Annotations["kots.io/snapshot-volume-count"]
 		if volumeCountOk {
 			i, err := strconv.Atoi(volumeCount)
@@ -601,6 +663,12 @@ func ListInstanceBackups(ctx context.Context, kotsadmNamespace string) ([]*types
 			backup.Trigger = trigger
 		}
 
+		restoreVerification, err := GetRestoreVerification(&veleroBackup)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get restore verification")
+		}
+		backup.RestoreVerification = restoreVerification
+
 		volumeCount, volumeCountOk := veleroBackup.Annotations["kots.io/snapshot-volume-count"]
 		if volumeCountOk {
 			i, err := strconv.Atoi(volumeCount)
@@ -849,6 +917,8 @@ func GetBackupDetail(ctx context.Context, kotsadmNamespace string, backupName st
 		Status:     string(backup.Status.Phase),
 		Namespaces: backup.Spec.IncludedNamespaces,
 		Volumes:    listBackupVolumes(backupVolumes.Items),
+		// results of exec and freeze hooks are added from the backup logs once the backup has finished
+		OperatorHooks: operatorHookResultsForBackup(backup, nil),
 	}
 
 	totalBytesDone := int64(0)
@@ -862,6 +932,7 @@ func GetBackupDetail(ctx context.Context, kotsadmNamespace string, backupName st
 		result.Errors = errs
 		result.Warnings = warnings
 		result.Hooks = execs
+		result.OperatorHooks = operatorHookResultsForBackup(backup, execs)
 		if err != nil {
 			// do not fail on error
 			logger.Error(errors.Wrap(err, "failed to download backup logs"))
@@ -0,0 +1,669 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	kotssnapshot "github.com/replicatedhq/kots/pkg/snapshot"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	veleroclientv1 "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	"go.uber.org/zap"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// operatorHookPrefix prefixes the names of the velero hooks that are created from operator hooks, so that their
+	// output in the backup logs can be told apart from the hooks in the app's Backup spec
+	operatorHookPrefix = "kots-operator"
+
+	defaultScaleHookTimeout = 5 * time.Minute
+)
+
+var operatorHookNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+func ValidateOperatorHooks(hooks []types.OperatorHook) error {
+	names := map[string]bool{}
+	for _, hook := range hooks {
+		if !operatorHookNameRegex.MatchString(hook.Name) {
+			return errors.Errorf("hook name %q must consist of lower case alphanumeric characters or '-'", hook.Name)
+		}
+		if names[hook.Name] {
+			return errors.Errorf("hook name %q is used more than once", hook.Name)
+		}
+		names[hook.Name] = true
+
+		if hook.Timeout != "" {
+			if _, err := time.ParseDuration(hook.Timeout); err != nil {
+				return errors.Wrapf(err, "hook %s has an invalid timeout", hook.Name)
+			}
+		}
+		if hook.OnError != "" && hook.OnError != string(velerov1.HookErrorModeContinue) && hook.OnError != string(velerov1.HookErrorModeFail) {
+			return errors.Errorf("hook %s onError must be Continue or Fail", hook.Name)
+		}
+
+		switch hook.Type {
+		case types.OperatorHookExec:
+			if hook.Phase != "pre" && hook.Phase != "post" {
+				return errors.Errorf("exec hook %s phase must be pre or post", hook.Name)
+			}
+			if len(hook.Command) == 0 {
+				return errors.Errorf("exec hook %s does not have a command", hook.Name)
+			}
+			if len(hook.PodSelector) == 0 {
+				return errors.Errorf("exec hook %s does not have a pod selector", hook.Name)
+			}
+		case types.OperatorHookFreeze:
+			if hook.MountPath == "" {
+				return errors.Errorf("freeze hook %s does not have a mount path", hook.Name)
+			}
+			if len(hook.PodSelector) == 0 {
+				return errors.Errorf("freeze hook %s does not have a pod selector", hook.Name)
+			}
+		case types.OperatorHookScale:
+			if hook.WorkloadKind != "Deployment" && hook.WorkloadKind != "StatefulSet" {
+				return errors.Errorf("scale hook %s workload kind must be Deployment or StatefulSet", hook.Name)
+			}
+			if hook.WorkloadName == "" {
+				return errors.Errorf("scale hook %s does not have a workload name", hook.Name)
+			}
+			if hook.Replicas < 0 {
+				return errors.Errorf("scale hook %s replicas cannot be negative", hook.Name)
+			}
+		default:
+			return errors.Errorf("hook %s has unknown type %q", hook.Name, hook.Type)
+		}
+	}
+	return nil
+}
+
+// operatorBackupHooks converts the exec and freeze hooks of an app to velero backup hooks. Scale hooks are run by kotsadm.
+func operatorBackupHooks(appSlug string, hooks []types.OperatorHook, appNamespace string) []velerov1.BackupResourceHookSpec {
+	specs := []velerov1.BackupResourceHookSpec{}
+	for _, hook := range hooks {
+		if hook.Type != types.OperatorHookExec && hook.Type != types.OperatorHookFreeze {
+			continue
+		}
+
+		spec := velerov1.BackupResourceHookSpec{
+			Name:               operatorHookSpecName(appSlug, hook),
+			IncludedNamespaces: []string{operatorHookNamespace(hook, appNamespace)},
+			IncludedResources:  []string{"pods"},
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: hook.PodSelector,
+			},
+		}
+
+		onError := velerov1.HookErrorModeFail
+		if hook.OnError != "" {
+			onError = velerov1.HookErrorMode(hook.OnError)
+		}
+		execHook := func(command []string) velerov1.BackupResourceHook {
+			e := &velerov1.ExecHook{
+				Container: hook.Container,
+				Command:   command,
+				OnError:   onError,
+			}
+			if timeout, err := time.ParseDuration(hook.Timeout); err == nil {
+				e.Timeout = metav1.Duration{Duration: timeout}
+			}
+			return velerov1.BackupResourceHook{Exec: e}
+		}
+
+		if hook.Type == types.OperatorHookFreeze {
+			spec.PreHooks = []velerov1.BackupResourceHook{execHook([]string{"/sbin/fsfreeze", "--freeze", hook.MountPath})}
+			spec.PostHooks = []velerov1.BackupResourceHook{execHook([]string{"/sbin/fsfreeze", "--unfreeze", hook.MountPath})}
+		} else if hook.Phase == "pre" {
+			spec.PreHooks = []velerov1.BackupResourceHook{execHook(hook.Command)}
+		} else {
+			spec.PostHooks = []velerov1.BackupResourceHook{execHook(hook.Command)}
+		}
+
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+func operatorHookSpecName(appSlug string, hook types.OperatorHook) string {
+	return fmt.Sprintf("%s/%s/%s/%s", operatorHookPrefix, appSlug, hook.Type, hook.Name)
+}
+
+// parseOperatorHookSpecName returns false if the velero hook was not created from an operator hook
+func parseOperatorHookSpecName(name string) (appSlug string, hookType types.OperatorHookType, hookName string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != operatorHookPrefix {
+		return "", "", "", false
+	}
+	return parts[1], types.OperatorHookType(parts[2]), parts[3], true
+}
+
+func operatorHookNamespace(hook types.OperatorHook, appNamespace string) string {
+	if hook.Namespace != "" {
+		return hook.Namespace
+	}
+	return appNamespace
+}
+
+// runScaleDownHooks scales down the workloads of the scale hooks of an app and waits for them to scale down.
+// An error is returned if a hook that fails the backup on error fails, after the workloads that were already scaled down are scaled back up.
+func runScaleDownHooks(ctx context.Context, clientset kubernetes.Interface, appSlug string, hooks []types.OperatorHook, appNamespace string) ([]types.OperatorHookResult, error) {
+	results := []types.OperatorHookResult{}
+	for _, hook := range hooks {
+		if hook.Type != types.OperatorHookScale {
+			continue
+		}
+
+		startedAt := time.Now()
+		result := types.OperatorHookResult{
+			AppSlug:      appSlug,
+			Name:         hook.Name,
+			Type:         hook.Type,
+			Phase:        "pre",
+			Namespace:    operatorHookNamespace(hook, appNamespace),
+			WorkloadKind: hook.WorkloadKind,
+			WorkloadName: hook.WorkloadName,
+			StartedAt:    &startedAt,
+		}
+
+		timeout := defaultScaleHookTimeout
+		if d, err := time.ParseDuration(hook.Timeout); err == nil {
+			timeout = d
+		}
+
+		previousReplicas, err := scaleDownWorkload(ctx, clientset, result.Namespace, hook.WorkloadKind, hook.WorkloadName, hook.Replicas, timeout)
+		finishedAt := time.Now()
+		result.FinishedAt = &finishedAt
+		result.PreviousReplicas = previousReplicas
+		if err != nil {
+			result.Status = types.OperatorHookFailed
+			result.Error = err.Error()
+		} else {
+			result.Status = types.OperatorHookSucceeded
+		}
+		results = append(results, result)
+
+		if err != nil && hook.OnError != string(velerov1.HookErrorModeContinue) {
+			results = runScaleUpHooks(ctx, clientset, results)
+			return results, errors.Wrapf(err, "failed to run scale hook %s", hook.Name)
+		}
+	}
+	return results, nil
+}
+
+// runScaleUpHooks scales the workloads that were scaled down back to their previous replicas
+func runScaleUpHooks(ctx context.Context, clientset kubernetes.Interface, results []types.OperatorHookResult) []types.OperatorHookResult {
+	for _, pre := range pendingScaleUps(results) {
+		startedAt := time.Now()
+		result := pre
+		result.Phase = "post"
+		result.StartedAt = &startedAt
+		result.Error = ""
+
+		err := scaleUpWorkload(ctx, clientset, pre.Namespace, pre.WorkloadKind, pre.WorkloadName, *pre.PreviousReplicas)
+		finishedAt := time.Now()
+		result.FinishedAt = &finishedAt
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to scale %s %s back up", pre.WorkloadKind, pre.WorkloadName))
+			result.Status = types.OperatorHookFailed
+			result.Error = err.Error()
+		} else {
+			result.Status = types.OperatorHookSucceeded
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// pendingScaleUps returns the scale hooks that scaled a workload down which has not been scaled back up
+func pendingScaleUps(results []types.OperatorHookResult) []types.OperatorHookResult {
+	scaledUp := map[string]bool{}
+	for _, r := range results {
+		if r.Phase == "post" {
+			scaledUp[r.AppSlug+"/"+r.Name] = true
+		}
+	}
+
+	pending := []types.OperatorHookResult{}
+	for _, r := range results {
+		if r.Phase != "pre" || scaledUp[r.AppSlug+"/"+r.Name] {
+			continue
+		}
+		// a workload is scaled back up even if it did not finish scaling down
+		if r.PreviousReplicas != nil {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
+// scaleDownWorkload records the replicas of the workload in an annotation before it scales it down, so that the workload
+// can be scaled back up even if kotsadm stops before the backup finishes. If the annotation is already set, the workload
+// was not scaled back up after a previous backup and the annotation is kept.
+// Returns the original replicas of the workload, or nil if it was not scaled.
+func scaleDownWorkload(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string, replicas int32, timeout time.Duration) (*int32, error) {
+	originalReplicas, err := getOriginalReplicas(ctx, clientset, namespace, kind, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get original replicas")
+	}
+
+	if originalReplicas == nil {
+		scale, err := getWorkloadScale(ctx, clientset, namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		originalReplicas = &scale.Spec.Replicas
+
+		if err := setOriginalReplicas(ctx, clientset, namespace, kind, name, originalReplicas); err != nil {
+			return nil, errors.Wrap(err, "failed to record original replicas")
+		}
+	}
+
+	if _, err := scaleWorkload(ctx, clientset, namespace, kind, name, replicas, timeout); err != nil {
+		return originalReplicas, err
+	}
+
+	return originalReplicas, nil
+}
+
+// scaleUpWorkload scales the workload to its original replicas and removes the annotation that recorded them.
+// It does not wait for the workload to scale up, the backup is not affected by it.
+func scaleUpWorkload(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string, replicas int32) error {
+	if _, err := scaleWorkload(ctx, clientset, namespace, kind, name, replicas, 0); err != nil {
+		return err
+	}
+	if err := setOriginalReplicas(ctx, clientset, namespace, kind, name, nil); err != nil {
+		return errors.Wrap(err, "failed to remove original replicas")
+	}
+	return nil
+}
+
+// getOriginalReplicas returns the replicas recorded when the workload was scaled down, or nil if it is not scaled down
+func getOriginalReplicas(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string) (*int32, error) {
+	var annotations map[string]string
+	if kind == "StatefulSet" {
+		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s %s", kind, name)
+		}
+		annotations = statefulSet.Annotations
+	} else {
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s %s", kind, name)
+		}
+		annotations = deployment.Annotations
+	}
+
+	value, ok := annotations[types.OperatorHookOriginalReplicasAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s annotation of %s %s", types.OperatorHookOriginalReplicasAnnotation, kind, name)
+	}
+	original := int32(replicas)
+	return &original, nil
+}
+
+// setOriginalReplicas sets the annotation with the original replicas of the workload, or removes it if replicas is nil
+func setOriginalReplicas(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string, replicas *int32) error {
+	var value interface{}
+	if replicas != nil {
+		value = strconv.Itoa(int(*replicas))
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				types.OperatorHookOriginalReplicasAnnotation: value,
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal patch")
+	}
+
+	if kind == "StatefulSet" {
+		_, err = clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	} else {
+		_, err = clientset.AppsV1().Deployments(namespace).Patch(ctx, name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to patch %s %s", kind, name)
+	}
+	return nil
+}
+
+func getWorkloadScale(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string) (*autoscalingv1.Scale, error) {
+	var scale *autoscalingv1.Scale
+	var err error
+	if kind == "StatefulSet" {
+		scale, err = clientset.AppsV1().StatefulSets(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	} else {
+		scale, err = clientset.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get scale of %s %s", kind, name)
+	}
+	return scale, nil
+}
+
+// scaleWorkload returns the replicas of the workload before it was scaled, or nil if it was not scaled.
+// A zero timeout does not wait for the workload to scale.
+func scaleWorkload(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string, replicas int32, timeout time.Duration) (*int32, error) {
+	getScale := func() (*autoscalingv1.Scale, error) {
+		return getWorkloadScale(ctx, clientset, namespace, kind, name)
+	}
+
+	scale, err := getScale()
+	if err != nil {
+		return nil, err
+	}
+	previousReplicas := scale.Spec.Replicas
+
+	scale.Spec.Replicas = replicas
+	if kind == "StatefulSet" {
+		_, err = clientset.AppsV1().StatefulSets(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+	} else {
+		_, err = clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scale %s %s", kind, name)
+	}
+
+	if timeout == 0 {
+		return &previousReplicas, nil
+	}
+
+	err = wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		scale, err := getScale()
+		if err != nil {
+			return false, err
+		}
+		return scale.Status.Replicas == replicas, nil
+	})
+	if err != nil {
+		return &previousReplicas, errors.Wrapf(err, "failed to wait for %s %s to scale to %d replicas", kind, name, replicas)
+	}
+
+	return &previousReplicas, nil
+}
+
+func setOperatorHookResults(backup *velerov1.Backup, results []types.OperatorHookResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(results)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal operator hook results")
+	}
+	if backup.Annotations == nil {
+		backup.Annotations = map[string]string{}
+	}
+	backup.Annotations[types.OperatorHookResultsAnnotation] = string(b)
+	return nil
+}
+
+func getOperatorHookResults(backup *velerov1.Backup) ([]types.OperatorHookResult, error) {
+	results := []types.OperatorHookResult{}
+	str, ok := backup.Annotations[types.OperatorHookResultsAnnotation]
+	if !ok {
+		return results, nil
+	}
+	if err := json.Unmarshal([]byte(str), &results); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal operator hook results")
+	}
+	return results, nil
+}
+
+// FinishOperatorHooks scales the workloads that were scaled down by scale hooks back up once their backups have finished
+func FinishOperatorHooks(ctx context.Context, kotsadmNamespace string) error {
+	cfg, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to get cluster config")
+	}
+
+	veleroClient, err := veleroclientv1.NewForConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create velero clientset")
+	}
+
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to create k8s clientset")
+	}
+
+	backendStorageLocation, err := kotssnapshot.FindBackupStoreLocation(ctx, kotsadmNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to find backupstoragelocations")
+	}
+	if backendStorageLocation == nil {
+		return nil
+	}
+
+	veleroBackups, err := veleroClient.Backups(backendStorageLocation.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list velero backups")
+	}
+
+	for _, veleroBackup := range veleroBackups.Items {
+		if _, ok := veleroBackup.Annotations[types.OperatorHookResultsAnnotation]; !ok {
+			continue
+		}
+		if !isBackupFinished(veleroBackup.Status.Phase) {
+			continue
+		}
+
+		results, err := getOperatorHookResults(&veleroBackup)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get operator hook results of backup %s", veleroBackup.Name))
+			continue
+		}
+		if len(pendingScaleUps(results)) == 0 {
+			continue
+		}
+
+		logger.Info("scaling workloads back up after backup", zap.String("backup", veleroBackup.Name))
+		results = runScaleUpHooks(ctx, clientset, results)
+
+		if err := patchOperatorHookResults(ctx, veleroClient, veleroBackup.Namespace, veleroBackup.Name, results); err != nil {
+			return errors.Wrapf(err, "failed to update operator hook results of backup %s", veleroBackup.Name)
+		}
+	}
+
+	return nil
+}
+
+// RestoreScaledDownWorkloads scales the workloads of scale hooks that are still scaled down back to their original replicas,
+// unless an unfinished backup will scale them back up. This is the case for workloads that were restored from a backup,
+// which captured them while they were scaled down, and if kotsadm stopped before it could scale them back up.
+func RestoreScaledDownWorkloads(ctx context.Context, kotsadmNamespace string) error {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to create k8s clientset")
+	}
+
+	pendingWorkloads, err := getWorkloadsPendingScaleUp(ctx, kotsadmNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get workloads of unfinished backups")
+	}
+
+	apps, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		return errors.Wrap(err, "failed to list installed apps")
+	}
+
+	for _, a := range apps {
+		hooks, err := store.GetStore().GetSnapshotHooks(a.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get snapshot hooks for app %s", a.Slug)
+		}
+		restoreScaledDownHookWorkloads(ctx, clientset, hooks, util.AppNamespace(), pendingWorkloads)
+	}
+
+	return nil
+}
+
+func restoreScaledDownHookWorkloads(ctx context.Context, clientset kubernetes.Interface, hooks []types.OperatorHook, appNamespace string, pendingWorkloads map[string]bool) {
+	for _, hook := range hooks {
+		if hook.Type != types.OperatorHookScale {
+			continue
+		}
+
+		namespace := operatorHookNamespace(hook, appNamespace)
+		if pendingWorkloads[workloadKey(namespace, hook.WorkloadKind, hook.WorkloadName)] {
+			continue
+		}
+
+		originalReplicas, err := getOriginalReplicas(ctx, clientset, namespace, hook.WorkloadKind, hook.WorkloadName)
+		if err != nil {
+			if !kuberneteserrors.IsNotFound(errors.Cause(err)) {
+				logger.Error(errors.Wrapf(err, "failed to get original replicas of %s %s", hook.WorkloadKind, hook.WorkloadName))
+			}
+			continue
+		}
+		if originalReplicas == nil {
+			continue
+		}
+
+		logger.Info("scaling workload that was left scaled down by a backup back up", zap.String("kind", hook.WorkloadKind), zap.String("name", hook.WorkloadName), zap.Int32("replicas", *originalReplicas))
+		if err := scaleUpWorkload(ctx, clientset, namespace, hook.WorkloadKind, hook.WorkloadName, *originalReplicas); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to scale %s %s back up", hook.WorkloadKind, hook.WorkloadName))
+		}
+	}
+}
+
+// getWorkloadsPendingScaleUp returns the workloads that unfinished backups scaled down, they are scaled back up once the backups finish
+func getWorkloadsPendingScaleUp(ctx context.Context, kotsadmNamespace string) (map[string]bool, error) {
+	pendingWorkloads := map[string]bool{}
+
+	backendStorageLocation, err := kotssnapshot.FindBackupStoreLocation(ctx, kotsadmNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find backupstoragelocations")
+	}
+	if backendStorageLocation == nil {
+		return pendingWorkloads, nil
+	}
+
+	cfg, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+
+	veleroClient, err := veleroclientv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create velero clientset")
+	}
+
+	veleroBackups, err := veleroClient.Backups(backendStorageLocation.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list velero backups")
+	}
+
+	for _, veleroBackup := range veleroBackups.Items {
+		if isBackupFinished(veleroBackup.Status.Phase) {
+			continue
+		}
+		results, err := getOperatorHookResults(&veleroBackup)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get operator hook results of backup %s", veleroBackup.Name))
+			continue
+		}
+		for _, r := range pendingScaleUps(results) {
+			pendingWorkloads[workloadKey(r.Namespace, r.WorkloadKind, r.WorkloadName)] = true
+		}
+	}
+
+	return pendingWorkloads, nil
+}
+
+func workloadKey(namespace string, kind string, name string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
+
+func patchOperatorHookResults(ctx context.Context, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string, backupName string, results []types.OperatorHookResult) error {
+	b, err := json.Marshal(results)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal operator hook results")
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				types.OperatorHookResultsAnnotation: string(b),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal patch")
+	}
+
+	if _, err := veleroClient.Backups(veleroNamespace).Patch(ctx, backupName, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrap(err, "failed to patch backup")
+	}
+
+	return nil
+}
+
+func isBackupFinished(phase velerov1.BackupPhase) bool {
+	switch phase {
+	case velerov1.BackupPhaseCompleted, velerov1.BackupPhasePartiallyFailed, velerov1.BackupPhaseFailed, velerov1.BackupPhaseFailedValidation:
+		return true
+	}
+	return false
+}
+
+// operatorHookResultsForBackup combines the results of the scale hooks with the output of the operator exec and freeze hooks in the backup logs
+func operatorHookResultsForBackup(backup *velerov1.Backup, execs []*types.SnapshotHook) []*types.OperatorHookResult {
+	results := []*types.OperatorHookResult{}
+
+	scaleResults, err := getOperatorHookResults(backup)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get operator hook results of backup %s", backup.Name))
+	}
+	for i := range scaleResults {
+		results = append(results, &scaleResults[i])
+	}
+
+	for _, exec := range execs {
+		appSlug, hookType, hookName, ok := parseOperatorHookSpecName(exec.Name)
+		if !ok {
+			continue
+		}
+		result := &types.OperatorHookResult{
+			AppSlug:    appSlug,
+			Name:       hookName,
+			Type:       hookType,
+			Phase:      exec.Phase,
+			Namespace:  exec.Namespace,
+			Status:     types.OperatorHookSucceeded,
+			StartedAt:  exec.StartedAt,
+			FinishedAt: exec.FinishedAt,
+		}
+		if len(exec.Errors) > 0 {
+			result.Status = types.OperatorHookFailed
+			result.Error = exec.Errors[0].Message
+			if result.Error == "" {
+				result.Error = exec.Errors[0].Title
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -0,0 +1,256 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_ValidateOperatorHooks(t *testing.T) {
+	exec := types.OperatorHook{Name: "flush", Type: types.OperatorHookExec, Phase: "pre", PodSelector: map[string]string{"app": "db"}, Command: []string{"sync"}}
+	freeze := types.OperatorHook{Name: "freeze-data", Type: types.OperatorHookFreeze, PodSelector: map[string]string{"app": "db"}, MountPath: "/data"}
+	scale := types.OperatorHook{Name: "stop-workers", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "workers"}
+
+	assert.NoError(t, ValidateOperatorHooks(nil))
+	assert.NoError(t, ValidateOperatorHooks([]types.OperatorHook{exec, freeze, scale}))
+
+	duplicate := scale
+	duplicate.Name = exec.Name
+	assert.Error(t, ValidateOperatorHooks([]types.OperatorHook{exec, duplicate}))
+
+	badName := exec
+	badName.Name = "Flush/All"
+	assert.Error(t, ValidateOperatorHooks([]types.OperatorHook{badName}))
+
+	noPhase := exec
+	noPhase.Phase = ""
+	assert.Error(t, ValidateOperatorHooks([]types.OperatorHook{noPhase}))
+
+	badKind := scale
+	badKind.WorkloadKind = "DaemonSet"
+	assert.Error(t, ValidateOperatorHooks([]types.OperatorHook{badKind}))
+
+	badOnError := freeze
+	badOnError.OnError = "Ignore"
+	assert.Error(t, ValidateOperatorHooks([]types.OperatorHook{badOnError}))
+}
+
+func Test_operatorBackupHooks(t *testing.T) {
+	hooks := []types.OperatorHook{
+		{Name: "flush", Type: types.OperatorHookExec, Phase: "post", Namespace: "db", PodSelector: map[string]string{"app": "db"}, Container: "postgres", Command: []string{"psql", "-c", "checkpoint"}, Timeout: "1m", OnError: "Continue"},
+		{Name: "freeze-data", Type: types.OperatorHookFreeze, PodSelector: map[string]string{"app": "files"}, MountPath: "/data"},
+		{Name: "stop-workers", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "workers"},
+	}
+
+	specs := operatorBackupHooks("my-app", hooks, "default")
+	require.Len(t, specs, 2)
+
+	assert.Equal(t, "kots-operator/my-app/exec/flush", specs[0].Name)
+	assert.Equal(t, []string{"db"}, specs[0].IncludedNamespaces)
+	assert.Equal(t, &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}, specs[0].LabelSelector)
+	assert.Empty(t, specs[0].PreHooks)
+	require.Len(t, specs[0].PostHooks, 1)
+	assert.Equal(t, &velerov1.ExecHook{
+		Container: "postgres",
+		Command:   []string{"psql", "-c", "checkpoint"},
+		OnError:   velerov1.HookErrorModeContinue,
+		Timeout:   metav1.Duration{Duration: time.Minute},
+	}, specs[0].PostHooks[0].Exec)
+
+	assert.Equal(t, "kots-operator/my-app/freeze/freeze-data", specs[1].Name)
+	assert.Equal(t, []string{"default"}, specs[1].IncludedNamespaces)
+	require.Len(t, specs[1].PreHooks, 1)
+	require.Len(t, specs[1].PostHooks, 1)
+	assert.Equal(t, []string{"/sbin/fsfreeze", "--freeze", "/data"}, specs[1].PreHooks[0].Exec.Command)
+	assert.Equal(t, []string{"/sbin/fsfreeze", "--unfreeze", "/data"}, specs[1].PostHooks[0].Exec.Command)
+	assert.Equal(t, velerov1.HookErrorModeFail, specs[1].PreHooks[0].Exec.OnError)
+}
+
+func Test_pendingScaleUps(t *testing.T) {
+	replicas := int32(3)
+	results := []types.OperatorHookResult{
+		{AppSlug: "my-app", Name: "scaled", Phase: "pre", Status: types.OperatorHookSucceeded, PreviousReplicas: &replicas},
+		{AppSlug: "my-app", Name: "timed-out", Phase: "pre", Status: types.OperatorHookFailed, PreviousReplicas: &replicas},
+		{AppSlug: "my-app", Name: "not-found", Phase: "pre", Status: types.OperatorHookFailed},
+		{AppSlug: "my-app", Name: "scaled-up", Phase: "pre", Status: types.OperatorHookSucceeded, PreviousReplicas: &replicas},
+		{AppSlug: "my-app", Name: "scaled-up", Phase: "post", Status: types.OperatorHookSucceeded, PreviousReplicas: &replicas},
+	}
+
+	pending := pendingScaleUps(results)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "scaled", pending[0].Name)
+	assert.Equal(t, "timed-out", pending[1].Name)
+}
+
+func Test_operatorHookResultsForBackup(t *testing.T) {
+	backup := &velerov1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				types.OperatorHookResultsAnnotation: `[{"appSlug":"my-app","name":"stop-workers","type":"scale","phase":"pre","namespace":"default","status":"Succeeded","previousReplicas":2}]`,
+			},
+		},
+	}
+	execs := []*types.SnapshotHook{
+		{Name: "kots-operator/my-app/freeze/freeze-data", Namespace: "default", Phase: "pre"},
+		{Name: "kots-operator/my-app/exec/flush", Namespace: "default", Phase: "post", Errors: []types.SnapshotError{{Title: "Error executing hook", Message: "command terminated with exit code 1"}}},
+		{Name: "app-hook", Namespace: "default", Phase: "pre"},
+	}
+
+	results := operatorHookResultsForBackup(backup, execs)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, types.OperatorHookScale, results[0].Type)
+	assert.Equal(t, int32(2), *results[0].PreviousReplicas)
+
+	assert.Equal(t, "freeze-data", results[1].Name)
+	assert.Equal(t, types.OperatorHookFreeze, results[1].Type)
+	assert.Equal(t, types.OperatorHookSucceeded, results[1].Status)
+
+	assert.Equal(t, "flush", results[2].Name)
+	assert.Equal(t, types.OperatorHookFailed, results[2].Status)
+	assert.Equal(t, "command terminated with exit code 1", results[2].Error)
+}
+
+func newScaleTestClientset(deployments ...*appsv1.Deployment) *fake.Clientset {
+	objects := []runtime.Object{}
+	for _, d := range deployments {
+		objects = append(objects, d)
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+
+	// the fake clientset does not implement the scale subresource
+	clientset.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		getAction := action.(k8stesting.GetAction)
+		d, err := clientset.Tracker().Get(appsv1.SchemeGroupVersion.WithResource("deployments"), getAction.GetNamespace(), getAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		deployment := d.(*appsv1.Deployment)
+		return true, &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: deployment.Name, Namespace: deployment.Namespace},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: *deployment.Spec.Replicas},
+			Status:     autoscalingv1.ScaleStatus{Replicas: *deployment.Spec.Replicas},
+		}, nil
+	})
+	clientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		d, err := clientset.Tracker().Get(appsv1.SchemeGroupVersion.WithResource("deployments"), action.GetNamespace(), scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		deployment := d.(*appsv1.Deployment).DeepCopy()
+		deployment.Spec.Replicas = &scale.Spec.Replicas
+		if err := clientset.Tracker().Update(appsv1.SchemeGroupVersion.WithResource("deployments"), deployment, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, scale, nil
+	})
+
+	return clientset
+}
+
+func testDeployment(name string, replicas int32, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", Annotations: annotations},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+}
+
+func Test_scaleDownAndUpWorkload(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		deployment   *appsv1.Deployment
+		wantOriginal int32
+	}{
+		{
+			name:         "records the current replicas",
+			deployment:   testDeployment("db", 3, nil),
+			wantOriginal: 3,
+		},
+		{
+			name:         "keeps the replicas recorded by a backup that did not scale the workload back up",
+			deployment:   testDeployment("db", 0, map[string]string{types.OperatorHookOriginalReplicasAnnotation: "2"}),
+			wantOriginal: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := newScaleTestClientset(tt.deployment)
+
+			originalReplicas, err := scaleDownWorkload(ctx, clientset, "app", "Deployment", "db", 0, 0)
+			require.NoError(t, err)
+			require.NotNil(t, originalReplicas)
+			assert.Equal(t, tt.wantOriginal, *originalReplicas)
+
+			deployment, err := clientset.AppsV1().Deployments("app").Get(ctx, "db", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+			assert.Equal(t, fmt.Sprintf("%d", tt.wantOriginal), deployment.Annotations[types.OperatorHookOriginalReplicasAnnotation])
+
+			err = scaleUpWorkload(ctx, clientset, "app", "Deployment", "db", *originalReplicas)
+			require.NoError(t, err)
+
+			deployment, err = clientset.AppsV1().Deployments("app").Get(ctx, "db", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOriginal, *deployment.Spec.Replicas)
+			assert.NotContains(t, deployment.Annotations, types.OperatorHookOriginalReplicasAnnotation)
+		})
+	}
+}
+
+func Test_restoreScaledDownHookWorkloads(t *testing.T) {
+	ctx := context.Background()
+
+	clientset := newScaleTestClientset(
+		// restored from a backup that captured it scaled down
+		testDeployment("restored", 0, map[string]string{types.OperatorHookOriginalReplicasAnnotation: "3"}),
+		// scaled down by a backup that is still running
+		testDeployment("pending", 0, map[string]string{types.OperatorHookOriginalReplicasAnnotation: "2"}),
+		testDeployment("running", 1, nil),
+	)
+
+	hooks := []types.OperatorHook{
+		{Name: "restored", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "restored"},
+		{Name: "pending", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "pending"},
+		{Name: "running", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "running"},
+		{Name: "missing", Type: types.OperatorHookScale, WorkloadKind: "Deployment", WorkloadName: "missing"},
+	}
+	pendingWorkloads := map[string]bool{workloadKey("app", "Deployment", "pending"): true}
+
+	restoreScaledDownHookWorkloads(ctx, clientset, hooks, "app", pendingWorkloads)
+
+	wantReplicas := map[string]int32{
+		"restored": 3,
+		"pending":  0,
+		"running":  1,
+	}
+	for name, want := range wantReplicas {
+		deployment, err := clientset.AppsV1().Deployments("app").Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, want, *deployment.Spec.Replicas, name)
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("app").Get(ctx, "restored", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, deployment.Annotations, types.OperatorHookOriginalReplicasAnnotation)
+}
@@ -0,0 +1,192 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const veleroDefaultBackupTTL = 30 * 24 * time.Hour
+
+type retentionTier struct {
+	name  string
+	count int
+	// period returns the key of the period that t falls in
+	period func(t time.Time) string
+	// length is the longest duration of a period
+	length time.Duration
+}
+
+func retentionTiers(policy types.RetentionPolicy) []retentionTier {
+	return []retentionTier{
+		{name: "hourly", count: policy.Hourly, length: time.Hour, period: func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{name: "daily", count: policy.Daily, length: 24 * time.Hour, period: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{name: "weekly", count: policy.Weekly, length: 7 * 24 * time.Hour, period: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{name: "monthly", count: policy.Monthly, length: 31 * 24 * time.Hour, period: func(t time.Time) string { return t.Format("2006-01") }},
+	}
+}
+
+// RetentionPolicyTTL is the TTL of backups that a retention policy manages.
+// Velero deletes backups once their TTL expires, so it has to outlast the oldest backup the policy can keep,
+// with one more period to spare since the policy is evaluated periodically.
+func RetentionPolicyTTL(policy types.RetentionPolicy) time.Duration {
+	ttl := time.Duration(0)
+	for _, tier := range retentionTiers(policy) {
+		if tier.count == 0 {
+			continue
+		}
+		if tierTTL := time.Duration(tier.count+1) * tier.length; tierTTL > ttl {
+			ttl = tierTTL
+		}
+	}
+	return ttl
+}
+
+func ValidateRetentionPolicy(policy types.RetentionPolicy) error {
+	if policy.Hourly < 0 || policy.Daily < 0 || policy.Weekly < 0 || policy.Monthly < 0 {
+		return errors.New("the number of backups to keep cannot be negative")
+	}
+	if policy.Hourly == 0 && policy.Daily == 0 && policy.Weekly == 0 && policy.Monthly == 0 {
+		return errors.New("at least one hourly, daily, weekly or monthly backup has to be kept")
+	}
+	return nil
+}
+
+// applyRetentionPolicyTTL extends the TTL of the backup so that velero does not delete it before the retention policy prunes it
+func applyRetentionPolicyTTL(veleroBackup *velerov1.Backup, policy *types.RetentionPolicy) {
+	if policy == nil {
+		return
+	}
+	currentTTL := veleroBackup.Spec.TTL.Duration
+	if currentTTL == 0 {
+		// velero applies its default TTL when the backup does not have one
+		currentTTL = veleroDefaultBackupTTL
+	}
+	if ttl := RetentionPolicyTTL(*policy); ttl > currentTTL {
+		veleroBackup.Spec.TTL = metav1.Duration{Duration: ttl}
+	}
+}
+
+// EvaluateRetention decides which backups a retention policy keeps. For every tier, the latest backup of each of the
+// most recent periods that have a backup is kept, up to the number of backups of the tier. Periods are in UTC.
+// Completed and partially failed backups are kept or pruned by the tiers. Failed backups are pruned once a newer backup
+// completes, so that the latest failure can still be investigated.
+// Backups that have not finished or are being verified are neither kept nor pruned.
+func EvaluateRetention(policy types.RetentionPolicy, backups []*types.Backup) *types.RetentionPreview {
+	tiers := retentionTiers(policy)
+
+	preview := &types.RetentionPreview{
+		Keep:  []types.RetainedBackup{},
+		Prune: []*types.Backup{},
+	}
+
+	candidates := []*types.Backup{}
+	failed := []*types.Backup{}
+	var latestCompleted *time.Time
+	for _, b := range backups {
+		switch velerov1.BackupPhase(b.Status) {
+		case velerov1.BackupPhaseCompleted, velerov1.BackupPhasePartiallyFailed:
+			if b.StartedAt == nil || IsRestoreVerificationRunning(b.RestoreVerification) {
+				continue
+			}
+			candidates = append(candidates, b)
+			if latestCompleted == nil || b.StartedAt.After(*latestCompleted) {
+				latestCompleted = b.StartedAt
+			}
+		case velerov1.BackupPhaseFailed:
+			if !IsRestoreVerificationRunning(b.RestoreVerification) {
+				failed = append(failed, b)
+			}
+		}
+	}
+
+	for _, b := range failed {
+		if latestCompleted != nil && (b.StartedAt == nil || b.StartedAt.Before(*latestCompleted)) {
+			preview.Prune = append(preview.Prune, b)
+		}
+	}
+
+	// newest first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].StartedAt.After(*candidates[j].StartedAt)
+	})
+
+	keptBy := map[*types.Backup][]string{}
+	for _, tier := range tiers {
+		periods := map[string]bool{}
+		for _, b := range candidates {
+			if len(periods) >= tier.count {
+				break
+			}
+			period := tier.period(b.StartedAt.UTC())
+			if periods[period] {
+				continue
+			}
+			periods[period] = true
+			keptBy[b] = append(keptBy[b], tier.name)
+		}
+	}
+
+	for _, b := range candidates {
+		if tiers, ok := keptBy[b]; ok {
+			preview.Keep = append(preview.Keep, types.RetainedBackup{Backup: b, Tiers: tiers})
+		} else {
+			preview.Prune = append(preview.Prune, b)
+		}
+	}
+
+	return preview
+}
+
+// PreviewAppRetention lists the backups of an app that the policy keeps and prunes
+func PreviewAppRetention(ctx context.Context, kotsadmNamespace string, appID string, policy types.RetentionPolicy) (*types.RetentionPreview, error) {
+	backups, err := ListBackupsForApp(ctx, kotsadmNamespace, appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list backups")
+	}
+	return EvaluateRetention(policy, backups), nil
+}
+
+// PreviewInstanceRetention lists the instance backups that the policy keeps and prunes
+func PreviewInstanceRetention(ctx context.Context, kotsadmNamespace string, policy types.RetentionPolicy) (*types.RetentionPreview, error) {
+	backups, err := ListInstanceBackups(ctx, kotsadmNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list instance backups")
+	}
+	return EvaluateRetention(policy, backups), nil
+}
+
+// PruneBackups deletes the backups that the retention preview prunes. A backup that fails to be deleted does not stop
+// the others from being pruned, it is retried the next time the policy is evaluated.
+// Returns the number of deleted backups.
+func PruneBackups(ctx context.Context, kotsadmNamespace string, preview *types.RetentionPreview) (int, error) {
+	return pruneBackups(preview, func(name string) error {
+		return DeleteBackup(ctx, kotsadmNamespace, name)
+	})
+}
+
+func pruneBackups(preview *types.RetentionPreview, deleteBackup func(name string) error) (int, error) {
+	var pruneErr error
+	pruned := 0
+	for _, b := range preview.Prune {
+		logger.Info("pruning backup by retention policy", zap.String("backup", b.Name))
+		if err := deleteBackup(b.Name); err != nil {
+			pruneErr = multierr.Append(pruneErr, errors.Wrapf(err, "failed to delete backup %s", b.Name))
+			continue
+		}
+		pruned++
+	}
+	return pruned, pruneErr
+}
@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ValidateRetentionPolicy(t *testing.T) {
+	assert.NoError(t, ValidateRetentionPolicy(types.RetentionPolicy{Daily: 7}))
+	assert.Error(t, ValidateRetentionPolicy(types.RetentionPolicy{}))
+	assert.Error(t, ValidateRetentionPolicy(types.RetentionPolicy{Daily: 7, Weekly: -1}))
+}
+
+func Test_EvaluateRetention(t *testing.T) {
+	backup := func(name string, status string, startedAt string) *types.Backup {
+		b := &types.Backup{Name: name, Status: status}
+		if startedAt != "" {
+			s, _ := time.Parse(time.RFC3339, startedAt)
+			b.StartedAt = &s
+		}
+		return b
+	}
+
+	names := func(preview *types.RetentionPreview) ([]string, []string) {
+		keep, prune := []string{}, []string{}
+		for _, k := range preview.Keep {
+			keep = append(keep, k.Backup.Name)
+		}
+		for _, b := range preview.Prune {
+			prune = append(prune, b.Name)
+		}
+		return keep, prune
+	}
+
+	tests := []struct {
+		name      string
+		policy    types.RetentionPolicy
+		backups   []*types.Backup
+		wantKeep  []string
+		wantPrune []string
+	}{
+		{
+			name:   "keeps the latest backup of each day",
+			policy: types.RetentionPolicy{Daily: 2},
+			backups: []*types.Backup{
+				backup("a", "Completed", "2026-10-01T01:00:00Z"),
+				backup("b", "Completed", "2026-10-01T13:00:00Z"),
+				backup("c", "Completed", "2026-10-02T01:00:00Z"),
+				backup("d", "Completed", "2026-10-03T01:00:00Z"),
+			},
+			wantKeep:  []string{"d", "c"},
+			wantPrune: []string{"b", "a"},
+		},
+		{
+			name:   "tiers overlap",
+			policy: types.RetentionPolicy{Daily: 1, Weekly: 2},
+			backups: []*types.Backup{
+				backup("mon-week-1", "Completed", "2026-09-28T01:00:00Z"),
+				backup("sun-week-1", "Completed", "2026-10-04T01:00:00Z"),
+				backup("mon-week-2", "Completed", "2026-10-05T01:00:00Z"),
+			},
+			wantKeep:  []string{"mon-week-2", "sun-week-1"},
+			wantPrune: []string{"mon-week-1"},
+		},
+		{
+			name:   "failed backups are pruned once a newer backup completes and unfinished backups are skipped",
+			policy: types.RetentionPolicy{Monthly: 1},
+			backups: []*types.Backup{
+				backup("completed", "Completed", "2026-10-01T01:00:00Z"),
+				backup("partially-failed", "PartiallyFailed", "2026-09-01T01:00:00Z"),
+				backup("old-failed", "Failed", "2026-09-15T01:00:00Z"),
+				backup("failed", "Failed", "2026-10-02T01:00:00Z"),
+				backup("in-progress", "InProgress", "2026-10-03T01:00:00Z"),
+			},
+			wantKeep:  []string{"completed"},
+			wantPrune: []string{"old-failed", "partially-failed"},
+		},
+		{
+			name:   "failed backups are kept without a completed backup",
+			policy: types.RetentionPolicy{Daily: 1},
+			backups: []*types.Backup{
+				backup("failed", "Failed", "2026-10-02T01:00:00Z"),
+			},
+			wantKeep:  []string{},
+			wantPrune: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keep, prune := names(EvaluateRetention(test.policy, test.backups))
+			assert.Equal(t, test.wantKeep, keep)
+			assert.Equal(t, test.wantPrune, prune)
+		})
+	}
+}
+
+func Test_RetentionPolicyTTL(t *testing.T) {
+	day := 24 * time.Hour
+	assert.Equal(t, 8*day, RetentionPolicyTTL(types.RetentionPolicy{Daily: 7}))
+	assert.Equal(t, 13*31*day, RetentionPolicyTTL(types.RetentionPolicy{Hourly: 24, Daily: 7, Weekly: 4, Monthly: 12}))
+}
+
+func Test_applyRetentionPolicyTTL(t *testing.T) {
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		policy  *types.RetentionPolicy
+		wantTTL time.Duration
+	}{
+		{
+			name:    "no policy",
+			ttl:     day,
+			wantTTL: day,
+		},
+		{
+			name:    "policy outlasts the ttl",
+			ttl:     day,
+			policy:  &types.RetentionPolicy{Daily: 7},
+			wantTTL: 8 * day,
+		},
+		{
+			name:    "policy outlasts the default ttl",
+			policy:  &types.RetentionPolicy{Monthly: 6},
+			wantTTL: 7 * 31 * day,
+		},
+		{
+			name:    "ttl outlasts the policy",
+			ttl:     90 * day,
+			policy:  &types.RetentionPolicy{Daily: 7},
+			wantTTL: 90 * day,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroBackup := &velerov1.Backup{Spec: velerov1.BackupSpec{TTL: metav1.Duration{Duration: tt.ttl}}}
+			applyRetentionPolicyTTL(veleroBackup, tt.policy)
+			assert.Equal(t, tt.wantTTL, veleroBackup.Spec.TTL.Duration)
+		})
+	}
+}
+
+func Test_pruneBackups(t *testing.T) {
+	preview := &types.RetentionPreview{
+		Prune: []*types.Backup{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+	}
+
+	deleted := []string{}
+	pruned, err := pruneBackups(preview, func(name string) error {
+		if name == "a" {
+			return errors.New("backup is locked")
+		}
+		deleted = append(deleted, name)
+		return nil
+	})
+	require.EqualError(t, err, "failed to delete backup a: backup is locked")
+	assert.Equal(t, 2, pruned)
+	assert.Equal(t, []string{"b", "c"}, deleted)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"time"
 
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 )
 
@@ -28,6 +29,8 @@ type Backup struct {
 	VolumeSizeHuman    string     `json:"volumeSizeHuman"`
 	SupportBundleID    string     `json:"supportBundleId,omitempty"`
 	IncludedApps       []App      `json:"includedApps,omitempty"`
+	// RestoreVerification is the result of the last test restore of the backup
+	RestoreVerification *RestoreVerification `json:"restoreVerification,omitempty"`
 }
 
 type BackupDetail struct {
@@ -39,6 +42,8 @@ type BackupDetail struct {
 	Volumes         []SnapshotVolume `json:"volumes"`
 	Errors          []SnapshotError  `json:"errors"`
 	Warnings        []SnapshotError  `json:"warnings"`
+	// OperatorHooks are the results of the hooks that are configured in the admin console
+	OperatorHooks []*OperatorHookResult `json:"operatorHooks"`
 }
 
 type RestoreDetail struct {
@@ -49,6 +54,31 @@ type RestoreDetail struct {
 	Warnings []SnapshotError       `json:"warnings"`
 }
 
+// RestoreVerificationAnnotation is the annotation on velero backups with the result of the last test restore
+const RestoreVerificationAnnotation = "kots.io/restore-verification"
+
+type RestoreVerificationStatus string
+
+const (
+	RestoreVerificationRunning RestoreVerificationStatus = "Running"
+	RestoreVerificationPassed  RestoreVerificationStatus = "Passed"
+	RestoreVerificationFailed  RestoreVerificationStatus = "Failed"
+)
+
+// RestoreVerification is a test restore of a backup into scratch namespaces
+type RestoreVerification struct {
+	AppSlug     string                    `json:"appSlug"`
+	RestoreName string                    `json:"restoreName"`
+	Status      RestoreVerificationStatus `json:"status"`
+	// NamespaceMapping maps the namespaces in the backup to the scratch namespaces they were restored to
+	NamespaceMapping map[string]string `json:"namespaceMapping"`
+	// ResourceStates are the states of the app's status informers in the scratch namespaces
+	ResourceStates appstatetypes.ResourceStates `json:"resourceStates,omitempty"`
+	StartedAt      time.Time                    `json:"startedAt"`
+	FinishedAt     *time.Time                   `json:"finishedAt,omitempty"`
+	Error          string                       `json:"error,omitempty"`
+}
+
 type SnapshotHook struct {
 	Name          string          `json:"name"`
 	Namespace     string          `json:"namespace"`
@@ -117,6 +147,27 @@ type ParsedTTL struct {
 	Unit     string `json:"unit"`
 }
 
+// RetentionPolicy keeps the latest backup of each of the most recent hours, days, weeks and months.
+// Backups that are not kept by any of the tiers are pruned.
+type RetentionPolicy struct {
+	Hourly  int `json:"hourly"`
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// RetentionPreview lists the backups that a retention policy keeps and prunes
+type RetentionPreview struct {
+	Keep  []RetainedBackup `json:"keep"`
+	Prune []*Backup        `json:"prune"`
+}
+
+type RetainedBackup struct {
+	Backup *Backup `json:"backup"`
+	// Tiers are the tiers that keep the backup, e.g. "daily"
+	Tiers []string `json:"tiers"`
+}
+
 type ScheduledSnapshot struct {
 	ID                 string    `json:"id"`
 	AppID              string    `json:"appId"`
@@ -132,3 +183,65 @@ type ScheduledInstanceSnapshot struct {
 	// name of Backup CR will be set once scheduled
 	BackupName string `json:"backupName,omitempty"`
 }
+
+type OperatorHookType string
+
+const (
+	OperatorHookExec   OperatorHookType = "exec"
+	OperatorHookFreeze OperatorHookType = "freeze"
+	OperatorHookScale  OperatorHookType = "scale"
+)
+
+// OperatorHook is a backup hook that is configured for an app in the admin console, in addition to the hooks in the app's velero Backup spec
+type OperatorHook struct {
+	Name string           `json:"name"`
+	Type OperatorHookType `json:"type"`
+	// Namespace defaults to the namespace of the app
+	Namespace string `json:"namespace,omitempty"`
+	// Phase is "pre" or "post" for exec hooks. Freeze and scale hooks run before and after the backup.
+	Phase string `json:"phase,omitempty"`
+	// PodSelector, Container, Command and MountPath select the pods and containers that exec and freeze hooks run in.
+	// Velero only runs hooks in pods that are included in the backup.
+	PodSelector map[string]string `json:"podSelector,omitempty"`
+	Container   string            `json:"container,omitempty"`
+	Command     []string          `json:"command,omitempty"`
+	MountPath   string            `json:"mountPath,omitempty"`
+	// WorkloadKind, WorkloadName and Replicas select the Deployment or StatefulSet that scale hooks scale down
+	WorkloadKind string `json:"workloadKind,omitempty"`
+	WorkloadName string `json:"workloadName,omitempty"`
+	Replicas     int32  `json:"replicas,omitempty"`
+	// Timeout is a duration, and OnError is "Continue" or "Fail"
+	Timeout string `json:"timeout,omitempty"`
+	OnError string `json:"onError,omitempty"`
+}
+
+// OperatorHookResultsAnnotation is the annotation on velero backups with the results of the scale hooks, that kotsadm runs itself
+const OperatorHookResultsAnnotation = "kots.io/operator-hook-results"
+
+// OperatorHookOriginalReplicasAnnotation is set on the workloads of scale hooks to their replicas before they were scaled down.
+// Backups capture the workloads while they are scaled down, so restored workloads are scaled back to these replicas.
+const OperatorHookOriginalReplicasAnnotation = "kots.io/snapshot-original-replicas"
+
+type OperatorHookStatus string
+
+const (
+	OperatorHookSucceeded OperatorHookStatus = "Succeeded"
+	OperatorHookFailed    OperatorHookStatus = "Failed"
+)
+
+type OperatorHookResult struct {
+	AppSlug   string             `json:"appSlug"`
+	Name      string             `json:"name"`
+	Type      OperatorHookType   `json:"type"`
+	Phase     string             `json:"phase"`
+	Namespace string             `json:"namespace"`
+	Status    OperatorHookStatus `json:"status"`
+	Error     string             `json:"error,omitempty"`
+	// WorkloadKind, WorkloadName and PreviousReplicas are set for scale hooks, to scale the workload back up after the backup.
+	// PreviousReplicas is nil if the workload was not scaled.
+	WorkloadKind     string     `json:"workloadKind,omitempty"`
+	WorkloadName     string     `json:"workloadName,omitempty"`
+	PreviousReplicas *int32     `json:"previousReplicas,omitempty"`
+	StartedAt        *time.Time `json:"startedAt,omitempty"`
+	FinishedAt       *time.Time `json:"finishedAt,omitempty"`
+}
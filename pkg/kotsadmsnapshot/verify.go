@@ -0,0 +1,543 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/appstate"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	kotssnapshot "github.com/replicatedhq/kots/pkg/snapshot"
+	"github.com/replicatedhq/kots/pkg/store"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	veleroclientv1 "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	RestoreVerificationLabel = "kots.io/restore-verification"
+	// RestoreVerificationRestoreAnnotation is the name of the velero restore on the scratch namespaces it restores into
+	RestoreVerificationRestoreAnnotation = "kots.io/restore-verification-restore"
+
+	// RestoreVerificationTimeout is how long a test restore has to restore the backup and for the app to become ready
+	RestoreVerificationTimeout = time.Hour
+)
+
+var restoreVerificationPollInterval = 5 * time.Second
+
+var (
+	// activeRestoreVerifications are the restores that are being verified by this process
+	activeRestoreVerifications   = map[string]bool{}
+	activeRestoreVerificationsMu sync.Mutex
+)
+
+// StartRestoreVerification restores the backup of the app into scratch namespaces and returns right away.
+// In the background, it waits for the app's status informers to report ready in the scratch namespaces,
+// records the result on the backup, then deletes the scratch namespaces. Scratch namespaces and restores
+// left behind by a restart of the admin console are removed by CleanupInterruptedRestoreVerifications.
+func StartRestoreVerification(ctx context.Context, kotsadmNamespace string, a *apptypes.App, backupName string) (_ *types.RestoreVerification, finalErr error) {
+	bsl, err := kotssnapshot.FindBackupStoreLocation(ctx, kotsadmNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get velero namespace")
+	}
+	if bsl == nil {
+		return nil, errors.New("no backup store location found")
+	}
+
+	veleroNamespace := bsl.Namespace
+
+	cfg, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+
+	veleroClient, err := veleroclientv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create clientset")
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create k8s clientset")
+	}
+
+	backup, err := veleroClient.Backups(veleroNamespace).Get(ctx, backupName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get backup")
+	}
+
+	if backup.Status.Phase != velerov1.BackupPhaseCompleted {
+		return nil, errors.Errorf("backup %s is %s, only completed backups can be verified", backupName, backup.Status.Phase)
+	}
+	isInstanceBackup, err := isBackupOfApp(backup, a)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check backup")
+	}
+
+	previous, err := GetRestoreVerification(backup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get previous restore verification")
+	}
+	if IsRestoreVerificationRunning(previous) {
+		return nil, errors.Errorf("backup %s is already being verified", backupName)
+	}
+
+	suffix := strings.ToLower(rand.String(5))
+	namespaceMapping := map[string]string{}
+	for _, ns := range backup.Spec.IncludedNamespaces {
+		namespaceMapping[ns] = scratchNamespaceName(ns, suffix)
+	}
+	if len(namespaceMapping) == 0 {
+		return nil, errors.Errorf("backup %s does not include any namespaces", backupName)
+	}
+
+	restore := &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: veleroNamespace,
+			Name:      fmt.Sprintf("%s.verify-%s", backupName, suffix),
+			Labels: map[string]string{
+				RestoreVerificationLabel: "true",
+			},
+		},
+		Spec: velerov1.RestoreSpec{
+			BackupName:       backupName,
+			NamespaceMapping: namespaceMapping,
+			RestorePVs:       pointer.Bool(true),
+			// cluster scoped resources are shared with the running app
+			IncludeClusterResources: pointer.Bool(false),
+		},
+	}
+	if isInstanceBackup {
+		// only restore app-specific objects
+		restore.Spec.LabelSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"kots.io/app-slug": a.Slug,
+			},
+		}
+	}
+
+	logger.Info("starting restore verification",
+		zap.String("backup", backupName),
+		zap.String("restore", restore.Name))
+
+	verification := &types.RestoreVerification{
+		AppSlug:          a.Slug,
+		RestoreName:      restore.Name,
+		Status:           types.RestoreVerificationRunning,
+		NamespaceMapping: namespaceMapping,
+		StartedAt:        time.Now(),
+	}
+
+	setRestoreVerificationActive(restore.Name, true)
+	defer func() {
+		if finalErr == nil {
+			return
+		}
+		if err := cleanupRestoreVerification(context.Background(), clientset, veleroClient, veleroNamespace, verification); err != nil {
+			logger.Error(errors.Wrap(err, "failed to clean up restore verification"))
+		}
+		setRestoreVerificationActive(restore.Name, false)
+	}()
+
+	// the scratch namespaces are created before the restore so that they are labeled and can be found
+	// if the admin console restarts before the verification finishes
+	for _, namespace := range namespaceMapping {
+		if err := createScratchNamespace(ctx, clientset, namespace, restore.Name); err != nil {
+			return nil, errors.Wrapf(err, "failed to create scratch namespace %s", namespace)
+		}
+	}
+
+	if _, err := veleroClient.Restores(veleroNamespace).Create(ctx, restore, metav1.CreateOptions{}); err != nil {
+		return nil, errors.Wrap(err, "failed to create restore")
+	}
+
+	if err := setRestoreVerification(ctx, veleroClient, veleroNamespace, backupName, verification); err != nil {
+		return nil, errors.Wrap(err, "failed to record restore verification")
+	}
+
+	go func() {
+		defer setRestoreVerificationActive(restore.Name, false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), RestoreVerificationTimeout)
+		defer cancel()
+
+		verifyErr := verifyRestore(ctx, veleroClient, veleroNamespace, a, verification)
+
+		finishedAt := time.Now()
+		verification.FinishedAt = &finishedAt
+		if verifyErr != nil {
+			logger.Error(errors.Wrapf(verifyErr, "restore verification of backup %s failed", backupName))
+			verification.Status = types.RestoreVerificationFailed
+			verification.Error = verifyErr.Error()
+		} else {
+			logger.Info("restore verification passed", zap.String("backup", backupName))
+			verification.Status = types.RestoreVerificationPassed
+		}
+
+		// the verification context may have expired
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cleanupCancel()
+
+		if err := setRestoreVerification(cleanupCtx, veleroClient, veleroNamespace, backupName, verification); err != nil {
+			logger.Error(errors.Wrap(err, "failed to record restore verification"))
+		}
+		if err := cleanupRestoreVerification(cleanupCtx, clientset, veleroClient, veleroNamespace, verification); err != nil {
+			logger.Error(errors.Wrap(err, "failed to clean up restore verification"))
+		}
+	}()
+
+	return verification, nil
+}
+
+// CleanupInterruptedRestoreVerifications marks running verifications that are not running in this process as failed
+// and deletes the scratch namespaces and restores that they left behind, e.g. when the admin console restarted.
+func CleanupInterruptedRestoreVerifications(ctx context.Context, kotsadmNamespace string) error {
+	bsl, err := kotssnapshot.FindBackupStoreLocation(ctx, kotsadmNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get velero namespace")
+	}
+	if bsl == nil {
+		return nil
+	}
+
+	cfg, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to get cluster config")
+	}
+
+	veleroClient, err := veleroclientv1.NewForConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create clientset")
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create k8s clientset")
+	}
+
+	return cleanupInterruptedRestoreVerifications(ctx, clientset, veleroClient, bsl.Namespace)
+}
+
+func cleanupInterruptedRestoreVerifications(ctx context.Context, clientset kubernetes.Interface, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string) error {
+	backups, err := veleroClient.Backups(veleroNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list backups")
+	}
+
+	for _, backup := range backups.Items {
+		verification, err := GetRestoreVerification(&backup)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get restore verification of backup %s", backup.Name))
+			continue
+		}
+		if verification == nil || verification.Status != types.RestoreVerificationRunning || isRestoreVerificationActive(verification.RestoreName) {
+			continue
+		}
+
+		logger.Info("marking interrupted restore verification as failed",
+			zap.String("backup", backup.Name),
+			zap.String("restore", verification.RestoreName))
+
+		finishedAt := time.Now()
+		verification.FinishedAt = &finishedAt
+		verification.Status = types.RestoreVerificationFailed
+		verification.Error = "restore verification was interrupted by a restart of the admin console"
+		if err := setRestoreVerification(ctx, veleroClient, veleroNamespace, backup.Name, verification); err != nil {
+			return errors.Wrapf(err, "failed to record interrupted restore verification of backup %s", backup.Name)
+		}
+	}
+
+	selector := fmt.Sprintf("%s=true", RestoreVerificationLabel)
+
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list scratch namespaces")
+	}
+	for _, namespace := range namespaces.Items {
+		if isRestoreVerificationActive(namespace.Annotations[RestoreVerificationRestoreAnnotation]) || namespace.DeletionTimestamp != nil {
+			continue
+		}
+		logger.Info("deleting scratch namespace of interrupted restore verification", zap.String("namespace", namespace.Name))
+		err := clientset.CoreV1().Namespaces().Delete(ctx, namespace.Name, metav1.DeleteOptions{})
+		if err != nil && !kuberneteserrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete namespace %s", namespace.Name)
+		}
+	}
+
+	restores, err := veleroClient.Restores(veleroNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list verification restores")
+	}
+	for _, restore := range restores.Items {
+		if isRestoreVerificationActive(restore.Name) {
+			continue
+		}
+		logger.Info("deleting restore of interrupted restore verification", zap.String("restore", restore.Name))
+		err := veleroClient.Restores(veleroNamespace).Delete(ctx, restore.Name, metav1.DeleteOptions{})
+		if err != nil && !kuberneteserrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete restore %s", restore.Name)
+		}
+	}
+
+	return nil
+}
+
+func setRestoreVerificationActive(restoreName string, active bool) {
+	activeRestoreVerificationsMu.Lock()
+	defer activeRestoreVerificationsMu.Unlock()
+	if active {
+		activeRestoreVerifications[restoreName] = true
+	} else {
+		delete(activeRestoreVerifications, restoreName)
+	}
+}
+
+func isRestoreVerificationActive(restoreName string) bool {
+	activeRestoreVerificationsMu.Lock()
+	defer activeRestoreVerificationsMu.Unlock()
+	return activeRestoreVerifications[restoreName]
+}
+
+func createScratchNamespace(ctx context.Context, clientset kubernetes.Interface, name string, restoreName string) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				RestoreVerificationLabel: "true",
+			},
+			Annotations: map[string]string{
+				RestoreVerificationRestoreAnnotation: restoreName,
+			},
+		},
+	}
+	if _, err := clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil {
+		return errors.Wrap(err, "failed to create namespace")
+	}
+	return nil
+}
+
+// GetRestoreVerification returns the last restore verification recorded on the backup, or nil if it was never verified
+func GetRestoreVerification(backup *velerov1.Backup) (*types.RestoreVerification, error) {
+	str, ok := backup.Annotations[types.RestoreVerificationAnnotation]
+	if !ok || str == "" {
+		return nil, nil
+	}
+	verification := &types.RestoreVerification{}
+	if err := json.Unmarshal([]byte(str), verification); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal restore verification")
+	}
+	return verification, nil
+}
+
+// IsRestoreVerificationRunning returns false for verifications that timed out. Verifications interrupted by a restart
+// of the admin console are marked as failed by CleanupInterruptedRestoreVerifications.
+func IsRestoreVerificationRunning(verification *types.RestoreVerification) bool {
+	if verification == nil || verification.Status != types.RestoreVerificationRunning {
+		return false
+	}
+	return time.Since(verification.StartedAt) < RestoreVerificationTimeout
+}
+
+func isBackupOfApp(backup *velerov1.Backup, a *apptypes.App) (isInstanceBackup bool, err error) {
+	if backup.Annotations["kots.io/instance"] != "true" {
+		if backup.Annotations["kots.io/app-id"] != a.ID {
+			return false, errors.Errorf("backup %s is not a backup of app %s", backup.Name, a.Slug)
+		}
+		return false, nil
+	}
+
+	var apps map[string]int64
+	if err := json.Unmarshal([]byte(backup.Annotations["kots.io/apps-sequences"]), &apps); err != nil {
+		return false, errors.Wrap(err, "failed to unmarshal apps sequences")
+	}
+	if _, ok := apps[a.Slug]; !ok {
+		return false, errors.Errorf("backup %s does not include app %s", backup.Name, a.Slug)
+	}
+	return true, nil
+}
+
+// scratchNamespaceName returns a namespace name that fits in the 63 character limit
+func scratchNamespaceName(namespace string, suffix string) string {
+	suffix = fmt.Sprintf("-verify-%s", suffix)
+	if len(namespace)+len(suffix) > 63 {
+		namespace = strings.TrimRight(namespace[:63-len(suffix)], "-")
+	}
+	return namespace + suffix
+}
+
+func verifyRestore(ctx context.Context, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string, a *apptypes.App, verification *types.RestoreVerification) error {
+	restore, err := waitForRestore(ctx, veleroClient, veleroNamespace, verification.RestoreName)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for restore")
+	}
+	if restore.Status.Phase != velerov1.RestorePhaseCompleted {
+		msg := fmt.Sprintf("restore %s", strings.ToLower(string(restore.Status.Phase)))
+		if restore.Status.FailureReason != "" {
+			msg = fmt.Sprintf("%s: %s", msg, restore.Status.FailureReason)
+		} else if restore.Status.Errors > 0 {
+			msg = fmt.Sprintf("%s with %d errors", msg, restore.Status.Errors)
+		}
+		return errors.New(msg)
+	}
+
+	informers, err := getScratchStatusInformers(a.ID, verification.NamespaceMapping)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status informers")
+	}
+	if len(informers) == 0 {
+		logger.Info("app has no status informers, restore verification only checks that the restore completed",
+			zap.String("slug", a.Slug))
+		return nil
+	}
+
+	resourceStates, err := waitForStatusInformers(ctx, a, informers)
+	verification.ResourceStates = resourceStates
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func waitForRestore(ctx context.Context, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string, restoreName string) (*velerov1.Restore, error) {
+	for {
+		restore, err := veleroClient.Restores(veleroNamespace).Get(ctx, restoreName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get restore")
+		}
+
+		switch restore.Status.Phase {
+		case velerov1.RestorePhaseCompleted, velerov1.RestorePhasePartiallyFailed, velerov1.RestorePhaseFailed, velerov1.RestorePhaseFailedValidation:
+			return restore, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "timed out waiting for restore")
+		case <-time.After(restoreVerificationPollInterval):
+		}
+	}
+}
+
+// getScratchStatusInformers returns the resources of the app's status informers, moved to the scratch namespaces.
+// They are taken from the app status rather than rendered again from the app, so custom ready expressions are not used.
+func getScratchStatusInformers(appID string, namespaceMapping map[string]string) ([]appstatetypes.StatusInformer, error) {
+	appStatus, err := store.GetStore().GetAppStatus(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app status")
+	}
+
+	informers := []appstatetypes.StatusInformer{}
+	for _, rs := range appStatus.ResourceStates {
+		if rs.Kind == "EMPTY" {
+			continue
+		}
+		namespace, ok := namespaceMapping[rs.Namespace]
+		if !ok {
+			// the resource is not in the backup
+			continue
+		}
+		informers = append(informers, appstatetypes.StatusInformer{
+			Kind:      rs.Kind,
+			Name:      rs.Name,
+			Namespace: namespace,
+		})
+	}
+
+	return informers, nil
+}
+
+func waitForStatusInformers(ctx context.Context, a *apptypes.App, informers []appstatetypes.StatusInformer) (appstatetypes.ResourceStates, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get clientset")
+	}
+
+	dynamicClient, err := k8sutil.GetDynamicClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dynamic client")
+	}
+
+	monitor := appstate.NewAppMonitor(clientset, dynamicClient, informers[0].Namespace, a.ID, a.CurrentSequence)
+	defer func() {
+		monitor.Shutdown()
+		// the monitor may still be sending statuses
+		go func() {
+			for range monitor.AppStatusChan() {
+			}
+		}()
+	}()
+
+	monitor.Apply(informers)
+
+	var resourceStates appstatetypes.ResourceStates
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceStates, errors.Errorf("app is %s in the scratch namespaces", appstatetypes.GetState(resourceStates))
+		case appStatus, ok := <-monitor.AppStatusChan():
+			if !ok {
+				return resourceStates, errors.New("app monitor stopped")
+			}
+			resourceStates = appStatus.ResourceStates
+			if appstatetypes.GetState(resourceStates) == appstatetypes.StateReady {
+				return resourceStates, nil
+			}
+		}
+	}
+}
+
+func setRestoreVerification(ctx context.Context, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string, backupName string, verification *types.RestoreVerification) error {
+	b, err := json.Marshal(verification)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal restore verification")
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				types.RestoreVerificationAnnotation: string(b),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal patch")
+	}
+
+	if _, err := veleroClient.Backups(veleroNamespace).Patch(ctx, backupName, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrap(err, "failed to patch backup")
+	}
+
+	return nil
+}
+
+func cleanupRestoreVerification(ctx context.Context, clientset kubernetes.Interface, veleroClient veleroclientv1.VeleroV1Interface, veleroNamespace string, verification *types.RestoreVerification) error {
+	for _, namespace := range verification.NamespaceMapping {
+		err := clientset.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
+		if err != nil && !kuberneteserrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete namespace %s", namespace)
+		}
+	}
+
+	err := veleroClient.Restores(veleroNamespace).Delete(ctx, verification.RestoreName, metav1.DeleteOptions{})
+	if err != nil && !kuberneteserrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete restore %s", verification.RestoreName)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerofake "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_scratchNamespaceName(t *testing.T) {
+	assert.Equal(t, "default-verify-abcde", scratchNamespaceName("default", "abcde"))
+
+	long := scratchNamespaceName(strings.Repeat("a", 50)+"-"+strings.Repeat("b", 12), "abcde")
+	assert.Len(t, long, 63)
+	assert.True(t, strings.HasSuffix(long, "-verify-abcde"))
+
+	// names cannot have a dash before the suffix is added
+	trimmed := scratchNamespaceName(strings.Repeat("a", 49)+"-"+strings.Repeat("b", 12), "abcde")
+	assert.Equal(t, strings.Repeat("a", 49)+"-verify-abcde", trimmed)
+}
+
+func Test_isBackupOfApp(t *testing.T) {
+	a := &apptypes.App{ID: "app-id", Slug: "my-app"}
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		wantInstance bool
+		wantErr      bool
+	}{
+		{
+			name:        "app backup",
+			annotations: map[string]string{"kots.io/app-id": "app-id"},
+		},
+		{
+			name:        "backup of another app",
+			annotations: map[string]string{"kots.io/app-id": "other-app-id"},
+			wantErr:     true,
+		},
+		{
+			name:         "instance backup",
+			annotations:  map[string]string{"kots.io/instance": "true", "kots.io/apps-sequences": `{"my-app":2,"other-app":1}`},
+			wantInstance: true,
+		},
+		{
+			name:        "instance backup without the app",
+			annotations: map[string]string{"kots.io/instance": "true", "kots.io/apps-sequences": `{"other-app":1}`},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Annotations: tt.annotations}}
+			isInstance, err := isBackupOfApp(backup, a)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInstance, isInstance)
+		})
+	}
+}
+
+func TestGetRestoreVerification(t *testing.T) {
+	backup := &velerov1.Backup{}
+	verification, err := GetRestoreVerification(backup)
+	require.NoError(t, err)
+	assert.Nil(t, verification)
+
+	backup.Annotations = map[string]string{
+		types.RestoreVerificationAnnotation: `{"restoreName":"backup.verify-abcde","status":"Running","startedAt":"` + time.Now().Add(-time.Minute).Format(time.RFC3339) + `"}`,
+	}
+	verification, err = GetRestoreVerification(backup)
+	require.NoError(t, err)
+	require.NotNil(t, verification)
+	assert.Equal(t, "backup.verify-abcde", verification.RestoreName)
+	assert.True(t, IsRestoreVerificationRunning(verification))
+
+	// verifications that were interrupted are not running
+	verification.StartedAt = time.Now().Add(-2 * RestoreVerificationTimeout)
+	assert.False(t, IsRestoreVerificationRunning(verification))
+
+	verification.Status = types.RestoreVerificationPassed
+	verification.StartedAt = time.Now()
+	assert.False(t, IsRestoreVerificationRunning(verification))
+}
+
+func Test_cleanupInterruptedRestoreVerifications(t *testing.T) {
+	verificationAnnotation := func(restoreName string, status types.RestoreVerificationStatus) map[string]string {
+		b, err := json.Marshal(types.RestoreVerification{RestoreName: restoreName, Status: status, StartedAt: time.Now()})
+		require.NoError(t, err)
+		return map[string]string{types.RestoreVerificationAnnotation: string(b)}
+	}
+	scratchNamespace := func(name string, restoreName string) runtime.Object {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{RestoreVerificationLabel: "true"},
+			Annotations: map[string]string{RestoreVerificationRestoreAnnotation: restoreName},
+		}}
+	}
+	verificationRestore := func(name string) runtime.Object {
+		return &velerov1.Restore{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      name,
+			Labels:    map[string]string{RestoreVerificationLabel: "true"},
+		}}
+	}
+
+	clientset := fake.NewSimpleClientset(
+		scratchNamespace("app-verify-aaaaa", "interrupted.verify-aaaaa"),
+		scratchNamespace("app-verify-bbbbb", "active.verify-bbbbb"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	)
+	veleroClientset := velerofake.NewSimpleClientset(
+		&velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "interrupted", Annotations: verificationAnnotation("interrupted.verify-aaaaa", types.RestoreVerificationRunning)}},
+		&velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "active", Annotations: verificationAnnotation("active.verify-bbbbb", types.RestoreVerificationRunning)}},
+		&velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "passed", Annotations: verificationAnnotation("passed.verify-ccccc", types.RestoreVerificationPassed)}},
+		verificationRestore("interrupted.verify-aaaaa"),
+		verificationRestore("active.verify-bbbbb"),
+		&velerov1.Restore{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "user-restore"}},
+	)
+	veleroClient := veleroClientset.VeleroV1()
+
+	setRestoreVerificationActive("active.verify-bbbbb", true)
+	defer setRestoreVerificationActive("active.verify-bbbbb", false)
+
+	ctx := context.Background()
+	require.NoError(t, cleanupInterruptedRestoreVerifications(ctx, clientset, veleroClient, "velero"))
+
+	wantStatuses := map[string]types.RestoreVerificationStatus{
+		"interrupted": types.RestoreVerificationFailed,
+		"active":      types.RestoreVerificationRunning,
+		"passed":      types.RestoreVerificationPassed,
+	}
+	for backupName, wantStatus := range wantStatuses {
+		backup, err := veleroClient.Backups("velero").Get(ctx, backupName, metav1.GetOptions{})
+		require.NoError(t, err)
+		verification, err := GetRestoreVerification(backup)
+		require.NoError(t, err)
+		assert.Equal(t, wantStatus, verification.Status, backupName)
+	}
+
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	namespaceNames := []string{}
+	for _, namespace := range namespaces.Items {
+		namespaceNames = append(namespaceNames, namespace.Name)
+	}
+	assert.ElementsMatch(t, []string{"app", "app-verify-bbbbb"}, namespaceNames)
+
+	restores, err := veleroClient.Restores("velero").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	restoreNames := []string{}
+	for _, restore := range restores.Items {
+		restoreNames = append(restoreNames, restore.Name)
+	}
+	assert.ElementsMatch(t, []string{"active.verify-bbbbb", "user-restore"}, restoreNames)
+}
@@ -0,0 +1,104 @@
+package kotsutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// AirgapDeltaFileName is the name of the metadata file that marks an airgap bundle as a delta bundle.
+const AirgapDeltaFileName = "delta.yaml"
+
+// AirgapDelta describes an airgap bundle that leaves out the images that are unchanged since a base bundle.
+// Deltas are per image: an image is either shipped with all of its layers or left out entirely, and
+// the left out images are expected to already be in the destination registry. Shipped images that share
+// layers with the base are still complete in the bundle, only the push skips the blobs the registry has.
+type AirgapDelta struct {
+	BaseVersionLabel string `yaml:"baseVersionLabel" json:"baseVersionLabel"`
+	BaseUpdateCursor string `yaml:"baseUpdateCursor" json:"baseUpdateCursor"`
+	BaseChannelID    string `yaml:"baseChannelID" json:"baseChannelID"`
+	// BaseImages are images used by this release that are not included in the bundle
+	// because they were already shipped in the base bundle.
+	// Entries are image references, e.g. "quay.io/org/nginx:1.21", regardless of the bundle format.
+	BaseImages []string `yaml:"baseImages" json:"baseImages"`
+}
+
+// FindAirgapDeltaInDir returns nil if the extracted airgap bundle is not a delta bundle.
+func FindAirgapDeltaInDir(root string) (*AirgapDelta, error) {
+	content, err := ioutil.ReadFile(filepath.Join(root, AirgapDeltaFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read delta file")
+	}
+	return LoadAirgapDeltaFromBytes(content)
+}
+
+// FindAirgapDeltaInBundle returns nil if the airgap bundle is not a delta bundle.
+// Only the metadata files at the start of the bundle are read.
+func FindAirgapDeltaInBundle(airgapBundle string) (*AirgapDelta, error) {
+	fileReader, err := os.Open(airgapBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	defer fileReader.Close()
+
+	gzipReader, err := gzip.NewReader(fileReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get new gzip reader")
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get read archive")
+		}
+
+		if header.Name == "." {
+			continue
+		}
+
+		// First items in airgap archive are metadata files.
+		// As soon as we see the first directory, we are hitting images.
+		if header.Typeflag == tar.TypeDir {
+			break
+		}
+
+		if header.Typeflag != tar.TypeReg || filepath.Clean(header.Name) != AirgapDeltaFileName {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read delta file")
+		}
+		return LoadAirgapDeltaFromBytes(content)
+	}
+
+	return nil, nil
+}
+
+func LoadAirgapDeltaFromBytes(data []byte) (*AirgapDelta, error) {
+	delta := AirgapDelta{}
+	if err := yaml.Unmarshal(data, &delta); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal delta")
+	}
+
+	if delta.BaseVersionLabel == "" && delta.BaseUpdateCursor == "" {
+		return nil, errors.New("delta bundle does not specify a base version")
+	}
+
+	return &delta, nil
+}
@@ -1074,6 +1074,11 @@ type InstallationParams struct {
 	WaitDuration           time.Duration
 	WithMinio              bool
 	AppVersionLabel        string
+
+	// ImageDeletionExplicitlyEnabled is true only if "enable-image-deletion" is set to "true" rather than defaulted
+	ImageDeletionExplicitlyEnabled bool
+	// ImageDeletionProtectedImages are patterns for images that image garbage collection never deletes
+	ImageDeletionProtectedImages []string
 }
 
 func GetInstallationParams(configMapName string) (InstallationParams, error) {
@@ -1112,10 +1117,17 @@ func GetInstallationParams(configMapName string) (InstallationParams, error) {
 
 	if enableImageDeletion, ok := kotsadmConfigMap.Data["enable-image-deletion"]; ok {
 		autoConfig.EnableImageDeletion, _ = strconv.ParseBool(enableImageDeletion)
+		autoConfig.ImageDeletionExplicitlyEnabled = autoConfig.EnableImageDeletion
 	} else {
 		autoConfig.EnableImageDeletion = isKurl
 	}
 
+	for _, pattern := range strings.Split(kotsadmConfigMap.Data["image-deletion-protected-images"], ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			autoConfig.ImageDeletionProtectedImages = append(autoConfig.ImageDeletionProtectedImages, pattern)
+		}
+	}
+
 	return autoConfig, nil
 }
 
@@ -0,0 +1,139 @@
+package maintenancewindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// the admin console image does not include the time zone database
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	cron "github.com/robfig/cron/v3"
+)
+
+const (
+	dateFormat = "2006-01-02"
+
+	// maxActivations limits how many upcoming windows are checked for one that is not blacked out
+	maxActivations = 1000
+)
+
+type window struct {
+	schedule      cron.Schedule
+	duration      time.Duration
+	location      *time.Location
+	blackoutDates map[string]bool
+}
+
+// Validate returns an error if the window cannot be parsed
+func Validate(w apptypes.MaintenanceWindow) error {
+	_, err := parse(w)
+	return err
+}
+
+// IsOpen returns true if auto-deploys are allowed at t
+func IsOpen(w apptypes.MaintenanceWindow, t time.Time) (bool, error) {
+	parsed, err := parse(w)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse maintenance window")
+	}
+	return parsed.isOpen(t), nil
+}
+
+// NextOpening returns the first time at or after t when auto-deploys are allowed
+func NextOpening(w apptypes.MaintenanceWindow, t time.Time) (time.Time, error) {
+	parsed, err := parse(w)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to parse maintenance window")
+	}
+
+	if parsed.isOpen(t) {
+		return t, nil
+	}
+
+	start := t
+	for i := 0; i < maxActivations; i++ {
+		start = parsed.schedule.Next(start)
+		if start.IsZero() {
+			break
+		}
+		end := start.Add(parsed.duration)
+
+		// the window can start on a blackout date and still be open after midnight
+		opening := start
+		for opening.Before(end) && parsed.isBlackedOut(opening) {
+			opening = parsed.startOfNextDay(opening)
+		}
+		if opening.Before(end) {
+			return opening, nil
+		}
+	}
+
+	return time.Time{}, errors.New("no upcoming maintenance window found")
+}
+
+func parse(w apptypes.MaintenanceWindow) (*window, error) {
+	if w.Schedule == "" {
+		return nil, errors.New("schedule is required")
+	}
+	if strings.HasPrefix(w.Schedule, "TZ=") || strings.HasPrefix(w.Schedule, "CRON_TZ=") {
+		return nil, errors.New("schedule must not include a time zone, use the timezone field instead")
+	}
+
+	timezone := w.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone %s", timezone)
+	}
+
+	schedule, err := cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", timezone, w.Schedule))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse schedule %s", w.Schedule)
+	}
+
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse duration %s", w.Duration)
+	}
+	if duration <= 0 {
+		return nil, errors.New("duration must be greater than zero")
+	}
+
+	blackoutDates := map[string]bool{}
+	for _, date := range w.BlackoutDates {
+		d, err := time.Parse(dateFormat, date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse blackout date %s", date)
+		}
+		blackoutDates[d.Format(dateFormat)] = true
+	}
+
+	return &window{
+		schedule:      schedule,
+		duration:      duration,
+		location:      location,
+		blackoutDates: blackoutDates,
+	}, nil
+}
+
+func (w *window) isOpen(t time.Time) bool {
+	if w.isBlackedOut(t) {
+		return false
+	}
+	// the window is open if it started within the last duration
+	start := w.schedule.Next(t.Add(-w.duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+func (w *window) isBlackedOut(t time.Time) bool {
+	return w.blackoutDates[t.In(w.location).Format(dateFormat)]
+}
+
+func (w *window) startOfNextDay(t time.Time) time.Time {
+	local := t.In(w.location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, w.location)
+}
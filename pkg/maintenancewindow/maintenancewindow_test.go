@@ -0,0 +1,170 @@
+package maintenancewindow
+
+import (
+	"testing"
+	"time"
+
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  apptypes.MaintenanceWindow
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			window: apptypes.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h", Timezone: "America/New_York", BlackoutDates: []string{"2023-12-25"}},
+		},
+		{
+			name:   "default timezone",
+			window: apptypes.MaintenanceWindow{Schedule: "@daily", Duration: "30m"},
+		},
+		{
+			name:    "missing schedule",
+			window:  apptypes.MaintenanceWindow{Duration: "4h"},
+			wantErr: true,
+		},
+		{
+			name:    "timezone in schedule",
+			window:  apptypes.MaintenanceWindow{Schedule: "CRON_TZ=UTC 0 2 * * *", Duration: "4h"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule",
+			window:  apptypes.MaintenanceWindow{Schedule: "0 2 * *", Duration: "4h"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			window:  apptypes.MaintenanceWindow{Schedule: "0 2 * * *", Duration: "4 hours"},
+			wantErr: true,
+		},
+		{
+			name:    "zero duration",
+			window:  apptypes.MaintenanceWindow{Schedule: "0 2 * * *", Duration: "0s"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			window:  apptypes.MaintenanceWindow{Schedule: "0 2 * * *", Duration: "4h", Timezone: "Mars/Olympus_Mons"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid blackout date",
+			window:  apptypes.MaintenanceWindow{Schedule: "0 2 * * *", Duration: "4h", BlackoutDates: []string{"12/25/2023"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.window)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// saturdays from 2am to 6am new york time, except christmas
+	w := apptypes.MaintenanceWindow{
+		Schedule:      "0 2 * * 6",
+		Duration:      "4h",
+		Timezone:      "America/New_York",
+		BlackoutDates: []string{"2021-12-25"},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{
+			name: "at the start of the window",
+			t:    time.Date(2021, 12, 18, 2, 0, 0, 0, newYork),
+			want: true,
+		},
+		{
+			name: "during the window in another time zone",
+			t:    time.Date(2021, 12, 18, 10, 30, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "at the end of the window",
+			t:    time.Date(2021, 12, 18, 6, 0, 0, 0, newYork),
+			want: false,
+		},
+		{
+			name: "before the window",
+			t:    time.Date(2021, 12, 18, 1, 59, 0, 0, newYork),
+			want: false,
+		},
+		{
+			name: "on another day",
+			t:    time.Date(2021, 12, 19, 3, 0, 0, 0, newYork),
+			want: false,
+		},
+		{
+			name: "on a blackout date",
+			t:    time.Date(2021, 12, 25, 3, 0, 0, 0, newYork),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsOpen(w, tt.t)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNextOpening(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	w := apptypes.MaintenanceWindow{
+		Schedule:      "0 2 * * 6",
+		Duration:      "4h",
+		Timezone:      "America/New_York",
+		BlackoutDates: []string{"2021-12-25"},
+	}
+
+	// open now
+	now := time.Date(2021, 12, 18, 3, 0, 0, 0, newYork)
+	next, err := NextOpening(w, now)
+	require.NoError(t, err)
+	assert.True(t, next.Equal(now))
+
+	// after the window
+	next, err = NextOpening(w, time.Date(2021, 12, 18, 7, 0, 0, 0, newYork))
+	require.NoError(t, err)
+	assert.True(t, next.Equal(time.Date(2022, 1, 1, 2, 0, 0, 0, newYork)), "got %s", next)
+
+	// a window that starts on a blackout date opens at midnight
+	w = apptypes.MaintenanceWindow{
+		Schedule:      "0 22 * * *",
+		Duration:      "4h",
+		BlackoutDates: []string{"2021-12-25"},
+	}
+	next, err = NextOpening(w, time.Date(2021, 12, 25, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, next.Equal(time.Date(2021, 12, 26, 0, 0, 0, 0, time.UTC)), "got %s", next)
+
+	// a schedule that never runs
+	w = apptypes.MaintenanceWindow{
+		Schedule: "0 2 30 2 *",
+		Duration: "4h",
+	}
+	_, err = NextOpening(w, now)
+	assert.Error(t, err)
+}
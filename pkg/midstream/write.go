@@ -198,6 +198,7 @@ func RewriteBaseImages(options image.ProcessImageOptions, baseDir string, kotsKi
 		KotsKinds:    kotsKinds,
 		IsAirgap:     options.IsAirgap,
 		CopyImages:   options.CopyImages,
+		ImagePolicy:  options.ImagePolicy,
 	}
 	if license != nil {
 		rewriteImageOptions.AppSlug = license.Spec.AppSlug
@@ -236,6 +237,7 @@ func ProcessAirgapImages(options image.ProcessImageOptions, kotsKinds *kotsutil.
 			Username:  options.RegistrySettings.Username,
 			Password:  options.RegistrySettings.Password,
 		},
+		ImagePolicy: options.ImagePolicy,
 	}
 	if license != nil {
 		processAirgapImageOptions.ReplicatedRegistry.Username = license.Spec.LicenseID
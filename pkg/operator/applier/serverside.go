@@ -0,0 +1,490 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/dynamic"
+	rest "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultFieldManager is the field manager that owns fields applied by kots
+	DefaultFieldManager = "kotsadm"
+
+	AppSlugAnnotation = "kots.io/app-slug"
+)
+
+// how often and for how long to poll when waiting for objects to be observed or deleted
+var (
+	waitPollInterval = time.Second
+	waitTimeout      = 10 * time.Minute
+)
+
+type ResourceAction string
+
+const (
+	ResourceActionCreated    ResourceAction = "created"
+	ResourceActionConfigured ResourceAction = "configured"
+	ResourceActionUnchanged  ResourceAction = "unchanged"
+	ResourceActionDeleted    ResourceAction = "deleted"
+	ResourceActionNotFound   ResourceAction = "notfound"
+	ResourceActionFailed     ResourceAction = "failed"
+)
+
+// ResourceResult is the outcome of applying or removing a single object
+type ResourceResult struct {
+	Group     string         `json:"group"`
+	Version   string         `json:"version"`
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	Action    ResourceAction `json:"action"`
+	DryRun    bool           `json:"dryRun,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// ResultsReporter is implemented by appliers that can report structured per-resource results
+type ResultsReporter interface {
+	// TakeResults returns the results recorded since the last call and resets them
+	TakeResults() []ResourceResult
+}
+
+// ServerSide applies manifests using the dynamic client and server-side apply instead of kubectl
+type ServerSide struct {
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+	fieldManager  string
+
+	resultsMtx sync.Mutex
+	results    []ResourceResult
+}
+
+var _ KubectlInterface = &ServerSide{}
+var _ ResultsReporter = &ServerSide{}
+
+func NewServerSide(config *rest.Config, fieldManager string) (*ServerSide, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+
+	disc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discovery client")
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disc))
+
+	return newServerSide(dynamicClient, mapper, fieldManager), nil
+}
+
+func newServerSide(dynamicClient dynamic.Interface, mapper meta.RESTMapper, fieldManager string) *ServerSide {
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	return &ServerSide{
+		dynamicClient: dynamicClient,
+		mapper:        mapper,
+		fieldManager:  fieldManager,
+	}
+}
+
+func (s *ServerSide) TakeResults() []ResourceResult {
+	s.resultsMtx.Lock()
+	defer s.resultsMtx.Unlock()
+
+	results := s.results
+	s.results = nil
+	return results
+}
+
+func (s *ServerSide) recordResult(result ResourceResult) {
+	s.resultsMtx.Lock()
+	defer s.resultsMtx.Unlock()
+
+	s.results = append(s.results, result)
+}
+
+// Apply server-side applies every document in yamlDoc, forcing ownership of conflicting fields.
+// Documents that fail are reported and the remaining documents are still applied.
+// If wait is true, Apply waits for the applied objects to be observed by their controllers once all of them are applied.
+func (s *ServerSide) Apply(targetNamespace string, slug string, yamlDoc []byte, dryRun bool, wait bool, annotateSlug bool) ([]byte, []byte, error) {
+	var stdout, stderr, failed []string
+	var applied []appliedObject
+
+	// results are reported in document order once every object has been applied and waited for.
+	// documents that cannot be decoded have no result and are reported with their decode error instead.
+	var results []ResourceResult
+	decodeErrs := map[int]string{}
+
+	for i, doc := range util.ConvertToSingleDocs(yamlDoc) {
+		obj, err := decodeUnstructured(doc)
+		if err != nil {
+			decodeErrs[len(results)] = fmt.Sprintf("document %d", i+1)
+			results = append(results, ResourceResult{Action: ResourceActionFailed, Error: err.Error()})
+			continue
+		}
+		if obj == nil {
+			continue
+		}
+
+		if annotateSlug {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[AppSlugAnnotation] = slug
+			obj.SetAnnotations(annotations)
+		}
+
+		result, ri := s.applyOne(targetNamespace, obj, dryRun)
+		if result.Error == "" && wait && !dryRun {
+			applied = append(applied, appliedObject{index: len(results), ri: ri, name: obj.GetName()})
+		}
+		results = append(results, result)
+	}
+
+	for index, err := range waitForObserved(applied) {
+		results[index].Action = ResourceActionFailed
+		results[index].Error = errors.Wrap(err, "failed to wait for object to be observed").Error()
+	}
+
+	for index, result := range results {
+		if document, ok := decodeErrs[index]; ok {
+			stderr = append(stderr, fmt.Sprintf("%s: %s", document, result.Error))
+			failed = append(failed, document)
+			continue
+		}
+
+		s.recordResult(result)
+
+		if result.Error != "" {
+			stderr = append(stderr, fmt.Sprintf("%s: %s", resultRef(result), result.Error))
+			failed = append(failed, resultRef(result))
+			continue
+		}
+		stdout = append(stdout, resultLine(result))
+	}
+
+	var applyErr error
+	if len(failed) > 0 {
+		applyErr = errors.Errorf("failed to apply %s", strings.Join(failed, ", "))
+	}
+
+	return joinLines(stdout), joinLines(stderr), applyErr
+}
+
+// ApplyCreateOrPatch is the same as Apply. Server-side apply does not store the last applied
+// configuration in an annotation, so there is no need to fall back to create or patch.
+func (s *ServerSide) ApplyCreateOrPatch(targetNamespace string, slug string, yamlDoc []byte, dryRun bool, wait bool, annotateSlug bool) ([]byte, []byte, error) {
+	return s.Apply(targetNamespace, slug, yamlDoc, dryRun, wait, annotateSlug)
+}
+
+func (s *ServerSide) Remove(targetNamespace string, yamlDoc []byte, wait bool) ([]byte, []byte, error) {
+	var stdout, stderr []string
+	var removeErr error
+
+	for _, doc := range util.ConvertToSingleDocs(yamlDoc) {
+		obj, err := decodeUnstructured(doc)
+		if err != nil {
+			stderr = append(stderr, err.Error())
+			removeErr = errors.Wrap(err, "failed to decode document")
+			continue
+		}
+		if obj == nil {
+			continue
+		}
+
+		result := s.removeOne(targetNamespace, obj, wait)
+		s.recordResult(result)
+
+		if result.Error != "" {
+			stderr = append(stderr, fmt.Sprintf("%s: %s", resultRef(result), result.Error))
+			removeErr = errors.Errorf("failed to delete %s", resultRef(result))
+			continue
+		}
+		stdout = append(stdout, resultLine(result))
+	}
+
+	return joinLines(stdout), joinLines(stderr), removeErr
+}
+
+// appliedObject is an object that was applied successfully and that Apply waits for
+type appliedObject struct {
+	index int // index of the result of the object
+	ri    dynamic.ResourceInterface
+	name  string
+}
+
+// waitForObserved waits for all objects to be observed under a single deadline, so that slow objects do not add up.
+// It returns the errors of the objects that were not observed, keyed by result index.
+func waitForObserved(objects []appliedObject) map[int]error {
+	errs := map[int]error{}
+	if len(objects) == 0 {
+		return errs
+	}
+
+	pending := objects
+	err := wait.PollImmediate(waitPollInterval, waitTimeout, func() (bool, error) {
+		remaining := []appliedObject{}
+		for _, object := range pending {
+			current, err := object.ri.Get(context.TODO(), object.name, metav1.GetOptions{})
+			if err != nil {
+				errs[object.index] = err
+				continue
+			}
+			if !isObserved(current) {
+				remaining = append(remaining, object)
+			}
+		}
+		pending = remaining
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		for _, object := range pending {
+			errs[object.index] = err
+		}
+	}
+
+	return errs
+}
+
+// applyOne applies the object and returns the resource interface it was applied with
+func (s *ServerSide) applyOne(targetNamespace string, obj *unstructured.Unstructured, dryRun bool) (ResourceResult, dynamic.ResourceInterface) {
+	gvk := obj.GroupVersionKind()
+	result := ResourceResult{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+		DryRun:  dryRun,
+	}
+
+	ri, namespace, err := s.resourceInterface(targetNamespace, obj)
+	if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Namespace = namespace
+
+	existing, err := ri.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if kuberneteserrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = errors.Wrap(err, "failed to get existing object").Error()
+		return result, nil
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = errors.Wrap(err, "failed to marshal object").Error()
+		return result, nil
+	}
+
+	force := true
+	opts := metav1.PatchOptions{
+		FieldManager: s.fieldManager,
+		Force:        &force,
+	}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := ri.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, opts)
+	if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	switch {
+	case existing == nil:
+		result.Action = ResourceActionCreated
+	case isUnchanged(existing, applied, dryRun):
+		result.Action = ResourceActionUnchanged
+	default:
+		result.Action = ResourceActionConfigured
+	}
+
+	return result, ri
+}
+
+// isUnchanged returns true if applying did not modify the existing object. A dry run does not persist
+// the object, so its resource version is never bumped and the objects are compared instead.
+func isUnchanged(existing *unstructured.Unstructured, applied *unstructured.Unstructured, dryRun bool) bool {
+	if applied == nil {
+		return false
+	}
+	if !dryRun {
+		return applied.GetResourceVersion() == existing.GetResourceVersion()
+	}
+	return equality.Semantic.DeepEqual(withoutServerFields(existing), withoutServerFields(applied))
+}
+
+// withoutServerFields returns a copy of the object without the fields the server updates on every request
+func withoutServerFields(obj *unstructured.Unstructured) map[string]interface{} {
+	o := obj.DeepCopy()
+	unstructured.RemoveNestedField(o.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(o.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(o.Object, "metadata", "generation")
+	return o.Object
+}
+
+// isObserved returns true once the controller of the object has observed its current generation.
+// Custom resource definitions are observed once they are established and their kind can be used.
+func isObserved(obj *unstructured.Unstructured) bool {
+	if obj.GetKind() == "CustomResourceDefinition" {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if ok && condition["type"] == "Established" && condition["status"] == "True" {
+				return true
+			}
+		}
+		return false
+	}
+
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil || !found {
+		// objects without a controller that reports status are ready once they are applied
+		return true
+	}
+	return observedGeneration >= obj.GetGeneration()
+}
+
+func (s *ServerSide) removeOne(targetNamespace string, obj *unstructured.Unstructured, waitForDeletion bool) ResourceResult {
+	gvk := obj.GroupVersionKind()
+	result := ResourceResult{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+	}
+
+	ri, namespace, err := s.resourceInterface(targetNamespace, obj)
+	if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.Namespace = namespace
+
+	propagation := metav1.DeletePropagationBackground
+	if waitForDeletion {
+		propagation = metav1.DeletePropagationForeground
+	}
+
+	err = ri.Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if kuberneteserrors.IsNotFound(err) {
+		result.Action = ResourceActionNotFound
+		return result
+	} else if err != nil {
+		result.Action = ResourceActionFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if waitForDeletion {
+		err := wait.PollImmediate(waitPollInterval, waitTimeout, func() (bool, error) {
+			_, err := ri.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+			if kuberneteserrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			result.Action = ResourceActionFailed
+			result.Error = errors.Wrap(err, "failed to wait for object to be deleted").Error()
+			return result
+		}
+	}
+
+	result.Action = ResourceActionDeleted
+	return result
+}
+
+func (s *ServerSide) resourceInterface(targetNamespace string, obj *unstructured.Unstructured) (dynamic.ResourceInterface, string, error) {
+	gvk := obj.GroupVersionKind()
+
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get rest mapping for %s", gvk.String())
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return s.dynamicClient.Resource(mapping.Resource), "", nil
+	}
+
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = targetNamespace
+		obj.SetNamespace(namespace)
+	}
+
+	return s.dynamicClient.Resource(mapping.Resource).Namespace(namespace), namespace, nil
+}
+
+// decodeUnstructured returns nil if the document is empty
+func decodeUnstructured(doc []byte) (*unstructured.Unstructured, error) {
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(doc, &m); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal yaml")
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	obj := &unstructured.Unstructured{Object: m}
+	if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+		return nil, errors.New("document is missing apiVersion or kind")
+	}
+	if obj.GetName() == "" {
+		return nil, errors.Errorf("%s document is missing metadata.name", obj.GetKind())
+	}
+
+	return obj, nil
+}
+
+// resultRef formats the result the way kubectl refers to objects, e.g. deployment.apps/my-app
+func resultRef(result ResourceResult) string {
+	kind := strings.ToLower(result.Kind)
+	if result.Group != "" {
+		kind = fmt.Sprintf("%s.%s", kind, result.Group)
+	}
+	return fmt.Sprintf("%s/%s", kind, result.Name)
+}
+
+func resultLine(result ResourceResult) string {
+	line := fmt.Sprintf("%s %s", resultRef(result), result.Action)
+	if result.DryRun {
+		line = fmt.Sprintf("%s (server dry run)", line)
+	}
+	return line
+}
+
+func joinLines(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,387 @@
+package applier
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testServerSide(t *testing.T, objects ...runtime.Object) (*ServerSide, *dynamicfake.FakeDynamicClient) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	scheme := runtime.NewScheme()
+	gvrToListKind := map[schema.GroupVersionResource]string{
+		{Group: "", Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Group: "", Version: "v1", Resource: "namespaces"}: "NamespaceList",
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, gvrToListKind, objects...)
+
+	// the fake object tracker does not create objects on apply, so do it here
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		require.Equal(t, types.ApplyPatchType, patchAction.GetPatchType())
+
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(patchAction.GetPatch()))
+
+		existing, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), patchAction.GetName())
+		if err != nil {
+			obj.SetResourceVersion("1")
+			return true, obj, client.Tracker().Create(action.GetResource(), obj, action.GetNamespace())
+		}
+		existingObj := existing.(*unstructured.Unstructured)
+		if equalData(existingObj, obj) {
+			return true, existingObj, nil
+		}
+		obj.SetResourceVersion("2")
+		return true, obj, client.Tracker().Update(action.GetResource(), obj, action.GetNamespace())
+	})
+
+	return newServerSide(client, mapper, ""), client
+}
+
+func equalData(a, b *unstructured.Unstructured) bool {
+	aData, _, _ := unstructured.NestedStringMap(a.Object, "data")
+	bData, _, _ := unstructured.NestedStringMap(b.Object, "data")
+	return assert.ObjectsAreEqual(aData, bData)
+}
+
+func TestServerSide_Apply(t *testing.T) {
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("ConfigMap")
+	existing.SetName("existing")
+	existing.SetNamespace("default")
+	existing.SetResourceVersion("1")
+	_ = unstructured.SetNestedStringMap(existing.Object, map[string]string{"key": "value"}, "data")
+
+	s, client := testServerSide(t, existing)
+
+	manifests := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+  namespace: default
+data:
+  key: value
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: my-namespace
+`)
+
+	stdout, stderr, err := s.Apply("default", "my-app", manifests, false, false, true)
+	require.NoError(t, err)
+	assert.Empty(t, stderr)
+	assert.Equal(t, "configmap/new created\nconfigmap/existing unchanged\nnamespace/my-namespace created", string(stdout))
+
+	results := s.TakeResults()
+	assert.Equal(t, []ResourceResult{
+		{Version: "v1", Kind: "ConfigMap", Name: "new", Namespace: "default", Action: ResourceActionCreated},
+		{Version: "v1", Kind: "ConfigMap", Name: "existing", Namespace: "default", Action: ResourceActionUnchanged},
+		{Version: "v1", Kind: "Namespace", Name: "my-namespace", Action: ResourceActionCreated},
+	}, results)
+	assert.Empty(t, s.TakeResults(), "results should be reset after they are taken")
+
+	for _, action := range client.Actions() {
+		patchAction, ok := action.(k8stesting.PatchAction)
+		if !ok {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(patchAction.GetPatch()))
+		assert.Equal(t, "my-app", obj.GetAnnotations()[AppSlugAnnotation])
+	}
+
+	created, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("default").Get(context.TODO(), "new", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", created.GetName())
+}
+
+func TestServerSide_ApplyUnknownKind(t *testing.T) {
+	s, _ := testServerSide(t)
+
+	manifests := []byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+`)
+
+	_, stderr, err := s.Apply("default", "my-app", manifests, true, false, false)
+	require.Error(t, err)
+	assert.Contains(t, string(stderr), "widget.example.com/my-widget")
+
+	results := s.TakeResults()
+	require.Len(t, results, 1)
+	assert.Equal(t, ResourceActionFailed, results[0].Action)
+	assert.True(t, results[0].DryRun)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+func TestServerSide_Remove(t *testing.T) {
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("ConfigMap")
+	existing.SetName("existing")
+	existing.SetNamespace("default")
+
+	s, _ := testServerSide(t, existing)
+
+	manifests := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: missing
+`)
+
+	stdout, _, err := s.Remove("default", manifests, false)
+	require.NoError(t, err)
+	assert.Equal(t, "configmap/existing deleted\nconfigmap/missing notfound", string(stdout))
+
+	results := s.TakeResults()
+	require.Len(t, results, 2)
+	assert.Equal(t, ResourceActionDeleted, results[0].Action)
+	assert.Equal(t, ResourceActionNotFound, results[1].Action)
+}
+
+func Test_decodeUnstructured(t *testing.T) {
+	obj, err := decodeUnstructured([]byte("# just a comment\n"))
+	require.NoError(t, err)
+	assert.Nil(t, obj)
+
+	_, err = decodeUnstructured([]byte("kind: ConfigMap\nmetadata:\n  name: foo\n"))
+	assert.Error(t, err)
+
+	_, err = decodeUnstructured([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.Error(t, err)
+}
+
+func TestServerSide_ApplyContinuesAfterFailure(t *testing.T) {
+	s, client := testServerSide(t)
+
+	manifests := []byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+---
+kind: ConfigMap
+metadata:
+  name: no-api-version
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+data:
+  key: value
+`)
+
+	stdout, stderr, err := s.Apply("default", "my-app", manifests, false, false, false)
+	require.EqualError(t, err, "failed to apply widget.example.com/my-widget, document 2")
+	assert.Contains(t, string(stderr), "widget.example.com/my-widget")
+	assert.Contains(t, string(stderr), "document 2: document is missing apiVersion or kind")
+	assert.Equal(t, "configmap/new created", string(stdout))
+
+	results := s.TakeResults()
+	require.Len(t, results, 2)
+	assert.Equal(t, ResourceActionFailed, results[0].Action)
+	assert.Equal(t, ResourceActionCreated, results[1].Action)
+
+	_, err = client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("default").Get(context.TODO(), "new", metav1.GetOptions{})
+	require.NoError(t, err)
+}
+
+func TestServerSide_ApplyDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantStdout string
+		wantAction ResourceAction
+	}{
+		{
+			name:       "unchanged",
+			data:       "value",
+			wantStdout: "configmap/existing unchanged (server dry run)",
+			wantAction: ResourceActionUnchanged,
+		},
+		{
+			name:       "configured",
+			data:       "other",
+			wantStdout: "configmap/existing configured (server dry run)",
+			wantAction: ResourceActionConfigured,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &unstructured.Unstructured{}
+			existing.SetAPIVersion("v1")
+			existing.SetKind("ConfigMap")
+			existing.SetName("existing")
+			existing.SetNamespace("default")
+			existing.SetResourceVersion("1")
+			_ = unstructured.SetNestedStringMap(existing.Object, map[string]string{"key": "value"}, "data")
+
+			s, _ := testServerSide(t, existing)
+
+			manifests := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+  namespace: default
+data:
+  key: ` + tt.data + `
+`)
+
+			stdout, _, err := s.Apply("default", "my-app", manifests, true, false, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStdout, string(stdout))
+
+			results := s.TakeResults()
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.wantAction, results[0].Action)
+			assert.True(t, results[0].DryRun)
+		})
+	}
+}
+
+func TestServerSide_ApplyWait(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		waitPollInterval, waitTimeout = interval, timeout
+	}(waitPollInterval, waitTimeout)
+	waitPollInterval, waitTimeout = time.Millisecond, 50*time.Millisecond
+
+	s, client := testServerSide(t)
+
+	// the controller never observes the applied generation
+	client.PrependReactor("get", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), action.(k8stesting.GetAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		u := obj.(*unstructured.Unstructured).DeepCopy()
+		u.SetGeneration(2)
+		_ = unstructured.SetNestedField(u.Object, int64(1), "status", "observedGeneration")
+		return true, u, nil
+	})
+
+	manifests := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+data:
+  key: value
+`)
+
+	_, _, err := s.Apply("default", "my-app", manifests, true, true, false)
+	require.NoError(t, err, "dry runs do not wait")
+
+	_, stderr, err := s.Apply("default", "my-app", manifests, false, true, false)
+	require.Error(t, err)
+	assert.Contains(t, string(stderr), "failed to wait for object to be observed")
+
+	// all objects are applied before waiting and share the same deadline
+	s.TakeResults()
+	client.ClearActions()
+	manifests = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: third
+`)
+
+	start := time.Now()
+	_, stderr, err = s.Apply("default", "my-app", manifests, false, true, false)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 3*waitTimeout)
+	assert.Equal(t, 3, strings.Count(string(stderr), "failed to wait for object to be observed"))
+
+	patches := []string{}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			patches = append(patches, action.(k8stesting.PatchAction).GetName())
+		}
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, patches)
+
+	results := s.TakeResults()
+	require.Len(t, results, 3)
+	for _, result := range results {
+		assert.Equal(t, ResourceActionFailed, result.Action)
+	}
+}
+
+func Test_isObserved(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  string
+		want bool
+	}{
+		{
+			name: "no status",
+			obj:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`,
+			want: true,
+		},
+		{
+			name: "observed generation is current",
+			obj:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"a","generation":2},"status":{"observedGeneration":2}}`,
+			want: true,
+		},
+		{
+			name: "observed generation is old",
+			obj:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"a","generation":3},"status":{"observedGeneration":2}}`,
+			want: false,
+		},
+		{
+			name: "crd established",
+			obj:  `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"a"},"status":{"conditions":[{"type":"NamesAccepted","status":"True"},{"type":"Established","status":"True"}]}}`,
+			want: true,
+		},
+		{
+			name: "crd not established",
+			obj:  `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"a"},"status":{"conditions":[{"type":"Established","status":"False"}]}}`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			require.NoError(t, obj.UnmarshalJSON([]byte(tt.obj)))
+			assert.Equal(t, tt.want, isObserved(obj))
+		})
+	}
+}
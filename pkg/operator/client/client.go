@@ -20,7 +20,11 @@ import (
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	"github.com/replicatedhq/kots/pkg/appstate"
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/appstatushistory"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
 	"github.com/replicatedhq/kots/pkg/binaries"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/operator/applier"
@@ -43,6 +47,8 @@ type DeployResults struct {
 	ApplyStderr  []byte `json:"applyStderr"`
 	HelmStdout   []byte `json:"helmStdout"`
 	HelmStderr   []byte `json:"helmStderr"`
+
+	ResourceResults []applier.ResourceResult `json:"resourceResults,omitempty"`
 }
 
 // DesiredState is what we receive from the kotsadm api server
@@ -77,7 +83,12 @@ func (c *Client) Init() error {
 		return errors.Wrap(err, "failed to get k8s clientset")
 	}
 
-	c.appStateMonitor = appstate.NewMonitor(clientset, c.TargetNamespace)
+	dynamicClient, err := k8sutil.GetDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get k8s dynamic client")
+	}
+
+	c.appStateMonitor = appstate.NewMonitor(clientset, dynamicClient, c.TargetNamespace)
 	go c.runAppStateMonitor()
 
 	return nil
@@ -238,6 +249,7 @@ func (c *Client) deployManifests(deployArgs operatortypes.DeployAppArgs) (*deplo
 			AdditionalNamespaces: deployArgs.AdditionalNamespaces,
 			IsRestore:            deployArgs.IsRestore,
 			RestoreLabelSelector: deployArgs.RestoreLabelSelector,
+			ApplyMethod:          deployArgs.ApplyMethod,
 			KubectlVersion:       deployArgs.KubectlVersion,
 			KustomizeVersion:     deployArgs.KustomizeVersion,
 			Wait:                 deployArgs.Wait,
@@ -416,6 +428,7 @@ func (c *Client) undeployManifests(undeployArgs operatortypes.UndeployAppArgs) e
 			AdditionalNamespaces: undeployArgs.AdditionalNamespaces,
 			IsRestore:            undeployArgs.IsRestore,
 			RestoreLabelSelector: undeployArgs.RestoreLabelSelector,
+			ApplyMethod:          undeployArgs.ApplyMethod,
 			KubectlVersion:       undeployArgs.KubectlVersion,
 			KustomizeVersion:     undeployArgs.KustomizeVersion,
 			Wait:                 undeployArgs.Wait,
@@ -498,12 +511,14 @@ func (c *Client) setDeployResults(args operatortypes.DeployAppArgs, dryRunResult
 		results.IsError = results.IsError || dryRunResult.hasErr
 		results.DryrunStdout = bytes.Join(dryRunResult.multiStdout, []byte("\n"))
 		results.DryrunStderr = bytes.Join(dryRunResult.multiStderr, []byte("\n"))
+		results.ResourceResults = append(results.ResourceResults, dryRunResult.resourceResults...)
 	}
 
 	if applyResult != nil {
 		results.IsError = results.IsError || applyResult.hasErr
 		results.ApplyStdout = bytes.Join(applyResult.multiStdout, []byte("\n"))
 		results.ApplyStderr = bytes.Join(applyResult.multiStderr, []byte("\n"))
+		results.ResourceResults = append(results.ResourceResults, applyResult.resourceResults...)
 	}
 
 	if helmResult != nil {
@@ -543,6 +558,18 @@ func (c *Client) setDeployResults(args operatortypes.DeployAppArgs, dryRunResult
 		HelmStderr:   base64.StdEncoding.EncodeToString(results.HelmStderr),
 		RenderError:  "",
 	}
+	for _, r := range results.ResourceResults {
+		downstreamOutput.ResourceResults = append(downstreamOutput.ResourceResults, downstreamtypes.DownstreamResourceResult{
+			Group:     r.Group,
+			Version:   r.Version,
+			Kind:      r.Kind,
+			Name:      r.Name,
+			Namespace: r.Namespace,
+			Action:    string(r.Action),
+			DryRun:    r.DryRun,
+			Error:     r.Error,
+		})
+	}
 	err = store.GetStore().UpdateDownstreamDeployStatus(args.AppID, args.ClusterID, args.Sequence, results.IsError, downstreamOutput)
 	if err != nil {
 		return results, errors.Wrap(err, "failed to update downstream deploy status")
@@ -622,6 +649,19 @@ func (c *Client) setAppStatus(newAppStatus appstatetypes.AppStatus) error {
 	}
 
 	newAppState := appstatetypes.GetState(newAppStatus.ResourceStates)
+	if currentAppStatus == nil || appstatushistory.HasChanged(currentAppStatus.ResourceStates, newAppStatus.ResourceStates) {
+		err := store.GetStore().AddAppStatusHistoryEntry(appstatushistorytypes.Entry{
+			AppID:          newAppStatus.AppID,
+			State:          newAppState,
+			ResourceStates: newAppStatus.ResourceStates,
+			Sequence:       newAppStatus.Sequence,
+			CreatedAt:      newAppStatus.UpdatedAt,
+		})
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to add app status history entry"))
+		}
+	}
+
 	if currentAppStatus != nil && newAppState != currentAppStatus.State {
 		go func() {
 			err := reporting.GetReporter().SubmitAppInfo(newAppStatus.AppID)
@@ -629,12 +669,46 @@ func (c *Client) setAppStatus(newAppStatus appstatetypes.AppStatus) error {
 				logger.Debugf("failed to submit app info: %v", err)
 			}
 		}()
+		publishAppStatusChangedEvent(newAppStatus, currentAppStatus.State, newAppState)
 	}
 
 	return nil
 }
 
-func (c *Client) getApplier(kubectlVersion, kustomizeVersion string) (applier.KubectlInterface, error) {
+func publishAppStatusChangedEvent(newAppStatus appstatetypes.AppStatus, previousState appstatetypes.State, state appstatetypes.State) {
+	appSlug := ""
+	a, err := store.GetStore().GetApp(newAppStatus.AppID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app for status event"))
+	} else {
+		appSlug = a.Slug
+	}
+
+	events.PublishAppEvent(eventtypes.EventAppStatusChanged, newAppStatus.AppID, appSlug, newAppStatus.Sequence, map[string]interface{}{
+		"previousState": previousState,
+		"state":         state,
+	})
+}
+
+func (c *Client) getApplier(applyMethod, kubectlVersion, kustomizeVersion string) (applier.KubectlInterface, error) {
+	config, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+
+	switch applyMethod {
+	case operatortypes.ApplyMethodServerSide:
+		serverSide, err := applier.NewServerSide(config, applier.DefaultFieldManager)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create server-side applier")
+		}
+		return serverSide, nil
+	case "", operatortypes.ApplyMethodKubectl:
+		// default
+	default:
+		return nil, errors.Errorf("unknown apply method %q", applyMethod)
+	}
+
 	kubectl, err := binaries.GetKubectlPathForVersion(kubectlVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find kubectl")
@@ -645,10 +719,5 @@ func (c *Client) getApplier(kubectlVersion, kustomizeVersion string) (applier.Ku
 		return nil, errors.Wrap(err, "failed to find kustomize")
 	}
 
-	config, err := k8sutil.GetClusterConfig()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get cluster config")
-	}
-
 	return applier.NewKubectl(kubectl, kustomize, config), nil
 }
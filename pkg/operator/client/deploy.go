@@ -33,9 +33,10 @@ import (
 var imagePullSecretsMtx sync.Mutex
 
 type commandResult struct {
-	hasErr      bool
-	multiStdout [][]byte
-	multiStderr [][]byte
+	hasErr          bool
+	multiStdout     [][]byte
+	multiStderr     [][]byte
+	resourceResults []applier.ResourceResult
 }
 
 type deployResult struct {
@@ -119,7 +120,7 @@ func (c *Client) ensureImagePullSecretsPresent(namespace string, imagePullSecret
 func (c *Client) ensureResourcesPresent(deployArgs operatortypes.DeployAppArgs) (*deployResult, error) {
 	var deployRes deployResult
 
-	kubernetesApplier, err := c.getApplier(deployArgs.KubectlVersion, deployArgs.KustomizeVersion)
+	kubernetesApplier, err := c.getApplier(deployArgs.ApplyMethod, deployArgs.KubectlVersion, deployArgs.KustomizeVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get applier")
 	}
@@ -163,6 +164,9 @@ func (c *Client) ensureResourcesPresent(deployArgs operatortypes.DeployAppArgs)
 				if len(dryrunStderr) > 0 {
 					deployRes.dryRunResult.multiStderr = append(deployRes.dryRunResult.multiStderr, dryrunStderr)
 				}
+				if reporter, ok := kubernetesApplier.(applier.ResultsReporter); ok {
+					deployRes.dryRunResult.resourceResults = append(deployRes.dryRunResult.resourceResults, reporter.TakeResults()...)
+				}
 
 				if dryRunErr != nil {
 					logger.Infof("stdout (dryrun) = %s", dryrunStdout)
@@ -209,6 +213,9 @@ func (c *Client) ensureResourcesPresent(deployArgs operatortypes.DeployAppArgs)
 			if len(applyStderr) > 0 {
 				deployRes.applyResult.multiStderr = append(deployRes.applyResult.multiStderr, applyStderr)
 			}
+			if reporter, ok := kubernetesApplier.(applier.ResultsReporter); ok {
+				deployRes.applyResult.resourceResults = append(deployRes.applyResult.resourceResults, reporter.TakeResults()...)
+			}
 
 			if applyErr != nil {
 				logger.Infof("stdout (apply) = %s", applyStdout)
@@ -0,0 +1,239 @@
+package operator
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+)
+
+const (
+	DefaultHealthGateInterval         = 10 * time.Second
+	DefaultHealthGateUnhealthySamples = 3
+)
+
+// HealthGateOptions configures the post-deploy health gate. After a version is deployed,
+// the app status is watched for the duration of the window. If the app does not become ready
+// and is left unavailable or degraded, the previously deployed version is redeployed.
+type HealthGateOptions struct {
+	Window   time.Duration
+	Interval time.Duration
+	// UnhealthySamples is how many consecutive samples must be unavailable or degraded before rolling back,
+	// so that a single bad sample at the end of the window does not trigger a rollback
+	UnhealthySamples int
+}
+
+type healthGateResult string
+
+const (
+	healthGatePending   healthGateResult = "pending"
+	healthGateHealthy   healthGateResult = "healthy"
+	healthGateUnhealthy healthGateResult = "unhealthy"
+)
+
+// getHealthGateOptionsFromEnv returns nil if the health gate is not enabled
+func getHealthGateOptionsFromEnv() (*HealthGateOptions, error) {
+	windowStr := os.Getenv("KOTSADM_HEALTH_GATE_WINDOW")
+	if windowStr == "" {
+		return nil, nil
+	}
+
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse health gate window")
+	}
+	if window <= 0 {
+		return nil, nil
+	}
+
+	interval := DefaultHealthGateInterval
+	if intervalStr := os.Getenv("KOTSADM_HEALTH_GATE_INTERVAL"); intervalStr != "" {
+		interval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse health gate interval")
+		}
+		if interval <= 0 {
+			return nil, errors.New("health gate interval must be positive")
+		}
+	}
+
+	unhealthySamples := DefaultHealthGateUnhealthySamples
+	if samplesStr := os.Getenv("KOTSADM_HEALTH_GATE_UNHEALTHY_SAMPLES"); samplesStr != "" {
+		unhealthySamples, err = strconv.Atoi(samplesStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse health gate unhealthy samples")
+		}
+		if unhealthySamples <= 0 {
+			return nil, errors.New("health gate unhealthy samples must be positive")
+		}
+	}
+
+	return &HealthGateOptions{
+		Window:           window,
+		Interval:         interval,
+		UnhealthySamples: unhealthySamples,
+	}, nil
+}
+
+// evaluateHealthGate checks the app status reported by the appstate monitor for the given sequence.
+// Statuses reported for other sequences are ignored because the informers may not have been updated yet.
+func evaluateHealthGate(appStatus *appstatetypes.AppStatus, sequence int64) (healthGateResult, appstatetypes.State) {
+	if appStatus == nil || appStatus.Sequence != sequence {
+		return healthGatePending, ""
+	}
+
+	state := appstatetypes.GetState(appStatus.ResourceStates)
+	switch state {
+	case appstatetypes.StateReady:
+		return healthGateHealthy, state
+	case appstatetypes.StateUnavailable, appstatetypes.StateDegraded:
+		return healthGateUnhealthy, state
+	default:
+		return healthGatePending, state
+	}
+}
+
+func (o *Operator) startHealthGate(appID string, sequence int64) {
+	if o.healthGate == nil {
+		return
+	}
+	go func() {
+		if err := o.runHealthGate(appID, sequence, *o.healthGate); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to run health gate for app %s sequence %d", appID, sequence))
+		}
+	}()
+}
+
+func (o *Operator) runHealthGate(appID string, sequence int64, opts HealthGateOptions) error {
+	logger.Infof("watching app %s sequence %d for %s before marking it healthy", appID, sequence, opts.Window)
+
+	deadline := time.Now().Add(opts.Window)
+	lastResult, lastState := healthGatePending, appstatetypes.State("")
+	consecutiveUnhealthy := 0
+	var unhealthySince time.Time
+
+	requiredUnhealthy := opts.UnhealthySamples
+	if requiredUnhealthy < 1 {
+		requiredUnhealthy = 1
+	}
+
+	for {
+		isCurrent, err := o.isCurrentSequence(appID, sequence)
+		if err != nil {
+			return errors.Wrap(err, "failed to check current sequence")
+		}
+		if !isCurrent {
+			logger.Infof("app %s sequence %d is no longer the current version, stopping health gate", appID, sequence)
+			return nil
+		}
+
+		appStatus, err := o.store.GetAppStatus(appID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get app status")
+		}
+
+		lastResult, lastState = evaluateHealthGate(appStatus, sequence)
+		if lastResult == healthGateHealthy {
+			logger.Infof("app %s sequence %d passed the health gate", appID, sequence)
+			return nil
+		}
+		if lastResult == healthGateUnhealthy {
+			if consecutiveUnhealthy == 0 {
+				unhealthySince = time.Now()
+			}
+			consecutiveUnhealthy++
+		} else {
+			consecutiveUnhealthy = 0
+		}
+
+		// keep sampling past the window while the app is unhealthy but not for long enough
+		if !time.Now().Before(deadline) && (lastResult != healthGateUnhealthy || consecutiveUnhealthy >= requiredUnhealthy) {
+			break
+		}
+		time.Sleep(opts.Interval)
+	}
+
+	if lastResult != healthGateUnhealthy {
+		logger.Infof("app %s sequence %d did not report a status within %s, skipping rollback", appID, sequence, opts.Window)
+		return nil
+	}
+
+	// sampling continues past the window until the app has been unhealthy for enough samples, so report how long it actually was
+	unhealthyFor := time.Since(unhealthySince).Round(time.Second)
+	reason := fmt.Sprintf("App was %s for %s after deploying", lastState, unhealthyFor)
+	return o.rollbackUnhealthyVersion(appID, sequence, reason)
+}
+
+func (o *Operator) isCurrentSequence(appID string, sequence int64) (bool, error) {
+	currentVersion, err := o.store.GetCurrentDownstreamVersion(appID, o.clusterID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get current downstream version")
+	}
+	return currentVersion != nil && currentVersion.ParentSequence == sequence, nil
+}
+
+func (o *Operator) rollbackUnhealthyVersion(appID string, sequence int64, reason string) error {
+	// a version that is deployed while the health gate is running must not be replaced by the rollback
+	deployMtx := o.getDeployMtx(appID)
+	deployMtx.Lock()
+	defer deployMtx.Unlock()
+
+	isCurrent, err := o.isCurrentSequence(appID, sequence)
+	if err != nil {
+		return errors.Wrap(err, "failed to check current sequence")
+	}
+	if !isCurrent {
+		logger.Infof("app %s sequence %d is no longer the current version, skipping rollback", appID, sequence)
+		return nil
+	}
+
+	previousSequence, err := o.store.GetPreviouslyDeployedSequence(appID, o.clusterID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get previously deployed sequence")
+	}
+	if previousSequence == -1 {
+		logger.Infof("app %s sequence %d is unhealthy but there is no previously deployed version to roll back to", appID, sequence)
+		return nil
+	}
+
+	allowRollback, err := o.store.IsRollbackSupportedForVersion(appID, sequence)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if rollback is supported")
+	}
+	if !allowRollback {
+		logger.Infof("app %s sequence %d is unhealthy but the version does not allow rollbacks", appID, sequence)
+		return nil
+	}
+
+	logger.Infof("app %s sequence %d is unhealthy, rolling back to sequence %d", appID, sequence, previousSequence)
+
+	// mark the unhealthy version first so that it is not reported as deployed if the rollback fails
+	if err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionRolledBack, reason); err != nil {
+		return errors.Wrap(err, "failed to update downstream status")
+	}
+
+	if err := o.store.MarkAsCurrentDownstreamVersion(appID, previousSequence); err != nil {
+		return errors.Wrap(err, "failed to mark previous version as current")
+	}
+
+	// the health gate is not applied to the version being rolled back to
+	deployed, deployErr := o.deployAppLocked(appID, previousSequence)
+	if deployErr == nil && !deployed {
+		deployErr = errors.Errorf("sequence %d was not deployed", previousSequence)
+	}
+	if deployErr != nil {
+		// the status of the previous version is updated by deployAppLocked
+		message := fmt.Sprintf("%s. Rollback to sequence %d failed: %v", reason, previousSequence, deployErr)
+		if err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionFailed, message); err != nil {
+			logger.Error(errors.Wrap(err, "failed to update downstream status"))
+		}
+		return errors.Wrapf(deployErr, "failed to redeploy sequence %d", previousSequence)
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	mock_client "github.com/replicatedhq/kots/pkg/operator/client/mock"
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_evaluateHealthGate(t *testing.T) {
+	tests := []struct {
+		name       string
+		appStatus  *appstatetypes.AppStatus
+		sequence   int64
+		wantResult healthGateResult
+		wantState  appstatetypes.State
+	}{
+		{
+			name:       "no status",
+			appStatus:  nil,
+			sequence:   1,
+			wantResult: healthGatePending,
+		},
+		{
+			name: "status for a different sequence",
+			appStatus: &appstatetypes.AppStatus{
+				Sequence:       0,
+				ResourceStates: appstatetypes.ResourceStates{{Kind: "deployment", Name: "app", State: appstatetypes.StateReady}},
+			},
+			sequence:   1,
+			wantResult: healthGatePending,
+		},
+		{
+			name: "ready",
+			appStatus: &appstatetypes.AppStatus{
+				Sequence:       1,
+				ResourceStates: appstatetypes.ResourceStates{{Kind: "deployment", Name: "app", State: appstatetypes.StateReady}},
+			},
+			sequence:   1,
+			wantResult: healthGateHealthy,
+			wantState:  appstatetypes.StateReady,
+		},
+		{
+			name: "updating",
+			appStatus: &appstatetypes.AppStatus{
+				Sequence: 1,
+				ResourceStates: appstatetypes.ResourceStates{
+					{Kind: "deployment", Name: "app", State: appstatetypes.StateReady},
+					{Kind: "deployment", Name: "worker", State: appstatetypes.StateUpdating},
+				},
+			},
+			sequence:   1,
+			wantResult: healthGatePending,
+			wantState:  appstatetypes.StateUpdating,
+		},
+		{
+			name: "degraded",
+			appStatus: &appstatetypes.AppStatus{
+				Sequence: 1,
+				ResourceStates: appstatetypes.ResourceStates{
+					{Kind: "deployment", Name: "app", State: appstatetypes.StateReady},
+					{Kind: "deployment", Name: "worker", State: appstatetypes.StateDegraded},
+				},
+			},
+			sequence:   1,
+			wantResult: healthGateUnhealthy,
+			wantState:  appstatetypes.StateDegraded,
+		},
+		{
+			name: "unavailable",
+			appStatus: &appstatetypes.AppStatus{
+				Sequence:       1,
+				ResourceStates: appstatetypes.ResourceStates{{Kind: "deployment", Name: "app", State: appstatetypes.StateUnavailable}},
+			},
+			sequence:   1,
+			wantResult: healthGateUnhealthy,
+			wantState:  appstatetypes.StateUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotResult, gotState := evaluateHealthGate(tt.appStatus, tt.sequence)
+			assert.Equal(t, tt.wantResult, gotResult)
+			assert.Equal(t, tt.wantState, gotState)
+		})
+	}
+}
+
+func Test_getHealthGateOptionsFromEnv(t *testing.T) {
+	t.Setenv("KOTSADM_HEALTH_GATE_WINDOW", "")
+	opts, err := getHealthGateOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, opts)
+
+	t.Setenv("KOTSADM_HEALTH_GATE_WINDOW", "5m")
+	opts, err = getHealthGateOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &HealthGateOptions{Window: 5 * time.Minute, Interval: DefaultHealthGateInterval, UnhealthySamples: DefaultHealthGateUnhealthySamples}, opts)
+
+	t.Setenv("KOTSADM_HEALTH_GATE_INTERVAL", "30s")
+	t.Setenv("KOTSADM_HEALTH_GATE_UNHEALTHY_SAMPLES", "5")
+	opts, err = getHealthGateOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &HealthGateOptions{Window: 5 * time.Minute, Interval: 30 * time.Second, UnhealthySamples: 5}, opts)
+
+	t.Setenv("KOTSADM_HEALTH_GATE_UNHEALTHY_SAMPLES", "0")
+	_, err = getHealthGateOptionsFromEnv()
+	assert.Error(t, err)
+	t.Setenv("KOTSADM_HEALTH_GATE_UNHEALTHY_SAMPLES", "")
+
+	t.Setenv("KOTSADM_HEALTH_GATE_WINDOW", "five minutes")
+	_, err = getHealthGateOptionsFromEnv()
+	assert.Error(t, err)
+}
+
+func Test_runHealthGate(t *testing.T) {
+	appID := "app-id"
+	clusterID := "cluster-id"
+	opts := HealthGateOptions{Window: 0, Interval: time.Millisecond, UnhealthySamples: 2}
+
+	unhealthyStatus := &appstatetypes.AppStatus{
+		AppID:          appID,
+		Sequence:       2,
+		ResourceStates: appstatetypes.ResourceStates{{Kind: "deployment", Name: "app", State: appstatetypes.StateUnavailable}},
+	}
+
+	t.Run("healthy version is not rolled back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil)
+		mockStore.EXPECT().GetAppStatus(appID).Return(&appstatetypes.AppStatus{
+			AppID:          appID,
+			Sequence:       2,
+			ResourceStates: appstatetypes.ResourceStates{{Kind: "deployment", Name: "app", State: appstatetypes.StateReady}},
+		}, nil)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("superseded version is ignored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 3}, nil)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("unhealthy version that does not allow rollback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(3)
+		mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil).Times(2)
+		mockStore.EXPECT().GetPreviouslyDeployedSequence(appID, clusterID).Return(int64(1), nil)
+		mockStore.EXPECT().IsRollbackSupportedForVersion(appID, int64(2)).Return(false, nil)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("unhealthy version without a previous version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(3)
+		mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil).Times(2)
+		mockStore.EXPECT().GetPreviouslyDeployedSequence(appID, clusterID).Return(int64(-1), nil)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("version that recovers is not rolled back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(2)
+		// the app is only unhealthy for one sample before the informers report an updated status
+		gomock.InOrder(
+			mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil),
+			mockStore.EXPECT().GetAppStatus(appID).Return(&appstatetypes.AppStatus{AppID: appID, Sequence: 2}, nil),
+		)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("unhealthy version is rolled back", func(t *testing.T) {
+		t.Setenv("KOTSADM_ENV", "test")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		mockClient := mock_client.NewMockClientInterface(ctrl)
+		o := &Operator{store: mockStore, client: mockClient, clusterID: clusterID, k8sClientset: fake.NewSimpleClientset()}
+
+		previousArchiveFiles := map[string]string{
+			"upstream/app.yaml": `
+apiVersion: kots.io/v1beta1
+kind: Application
+metadata:
+  name: my-application
+spec:
+  statusInformers:
+    - deployment/some-deployment`,
+			"overlays/midstream/kustomization.yaml": `
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../base`,
+			"rendered/this-cluster/deployment.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: some-deployment`,
+		}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(3)
+		mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil).Times(2)
+
+		gomock.InOrder(
+			mockStore.EXPECT().GetPreviouslyDeployedSequence(appID, clusterID).Return(int64(1), nil),
+			mockStore.EXPECT().IsRollbackSupportedForVersion(appID, int64(2)).Return(true, nil),
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(2), storetypes.VersionRolledBack, gomock.Any()).DoAndReturn(func(_ string, _ int64, _ storetypes.DownstreamVersionStatus, reason string) error {
+				assert.Equal(t, "App was unavailable for 0s after deploying", reason)
+				return nil
+			}),
+			mockStore.EXPECT().MarkAsCurrentDownstreamVersion(appID, int64(1)).Return(nil),
+
+			// redeploy the previous version
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(1), storetypes.VersionDeploying, "").Return(nil),
+			mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID, Slug: "app-slug"}, nil),
+			mockStore.EXPECT().GetDownstream(clusterID).Return(&downstreamtypes.Downstream{Name: "this-cluster"}, nil),
+			mockStore.EXPECT().GetAppVersionArchive(appID, int64(1), gomock.Any()).DoAndReturn(func(_ string, _ int64, archiveDir string) error {
+				for name, content := range previousArchiveFiles {
+					require.NoError(t, os.MkdirAll(filepath.Join(archiveDir, filepath.Dir(name)), 0755))
+					require.NoError(t, os.WriteFile(filepath.Join(archiveDir, name), []byte(content), 0644))
+				}
+				return nil
+			}),
+			mockStore.EXPECT().GetRegistryDetailsForApp(appID).Return(registrytypes.RegistrySettings{}, nil),
+			mockStore.EXPECT().GetPreviouslyDeployedSequence(appID, clusterID).Return(int64(-1), nil),
+			mockClient.EXPECT().DeployApp(gomock.Any()).DoAndReturn(func(args operatortypes.DeployAppArgs) (bool, error) {
+				assert.Equal(t, int64(1), args.Sequence)
+				return true, nil
+			}),
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(1), storetypes.VersionDeployed, "").Return(nil),
+		)
+		mockClient.EXPECT().ApplyAppInformers(gomock.Any())
+		mockClient.EXPECT().ApplyNamespacesInformer(gomock.Any(), gomock.Any())
+		mockClient.EXPECT().ApplyHooksInformer(gomock.Any())
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("failed rollback is recorded on the unhealthy version", func(t *testing.T) {
+		t.Setenv("KOTSADM_ENV", "test")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(3)
+		mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil).Times(2)
+
+		gomock.InOrder(
+			mockStore.EXPECT().GetPreviouslyDeployedSequence(appID, clusterID).Return(int64(1), nil),
+			mockStore.EXPECT().IsRollbackSupportedForVersion(appID, int64(2)).Return(true, nil),
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(2), storetypes.VersionRolledBack, gomock.Any()).Return(nil),
+			mockStore.EXPECT().MarkAsCurrentDownstreamVersion(appID, int64(1)).Return(nil),
+
+			// redeploy the previous version
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(1), storetypes.VersionDeploying, "").Return(nil),
+			mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("app not found")),
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(1), storetypes.VersionFailed, gomock.Any()).Return(nil),
+
+			mockStore.EXPECT().SetDownstreamVersionStatus(appID, int64(2), storetypes.VersionFailed, gomock.Any()).DoAndReturn(func(_ string, _ int64, _ storetypes.DownstreamVersionStatus, message string) error {
+				assert.Contains(t, message, "Rollback to sequence 1 failed")
+				return nil
+			}),
+		)
+
+		require.Error(t, o.runHealthGate(appID, 2, opts))
+	})
+
+	t.Run("version deployed during the health gate is not rolled back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mock_store.NewMockStore(ctrl)
+		o := &Operator{store: mockStore, clusterID: clusterID}
+
+		gomock.InOrder(
+			mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 2}, nil).Times(2),
+			mockStore.EXPECT().GetCurrentDownstreamVersion(appID, clusterID).Return(&downstreamtypes.DownstreamVersion{ParentSequence: 3}, nil),
+		)
+		mockStore.EXPECT().GetAppStatus(appID).Return(unhealthyStatus, nil).Times(2)
+
+		require.NoError(t, o.runHealthGate(appID, 2, opts))
+	})
+}
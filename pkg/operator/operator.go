@@ -18,6 +18,8 @@ import (
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	"github.com/replicatedhq/kots/pkg/apparchive"
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	identitydeploy "github.com/replicatedhq/kots/pkg/identity/deploy"
 	identitytypes "github.com/replicatedhq/kots/pkg/identity/types"
 	kotsadmobjects "github.com/replicatedhq/kots/pkg/kotsadm/objects"
@@ -57,7 +59,9 @@ type Operator struct {
 	clusterToken string
 	clusterID    string
 	deployMtxs   map[string]*sync.Mutex // key is app id
+	mtxsLock     sync.Mutex
 	k8sClientset kubernetes.Interface
+	healthGate   *HealthGateOptions
 }
 
 func Init(client client.ClientInterface, store store.Store, clusterToken string, k8sClientset kubernetes.Interface) *Operator {
@@ -68,6 +72,13 @@ func Init(client client.ClientInterface, store store.Store, clusterToken string,
 		deployMtxs:   map[string]*sync.Mutex{},
 		k8sClientset: k8sClientset,
 	}
+
+	healthGate, err := getHealthGateOptionsFromEnv()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to parse health gate options, post-deploy health gate is disabled"))
+	}
+	operator.healthGate = healthGate
+
 	return operator
 }
 
@@ -153,13 +164,38 @@ func (o *Operator) resumeDeployment(a *apptypes.App) (bool, error) {
 	return true, nil
 }
 
-func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deployError error) {
+func (o *Operator) DeployApp(appID string, sequence int64) (bool, error) {
+	deployed, err := o.deployApp(appID, sequence)
+	if err == nil && deployed {
+		o.startHealthGate(appID, sequence)
+	}
+	return deployed, err
+}
+
+func (o *Operator) deployApp(appID string, sequence int64) (bool, error) {
+	deployMtx := o.getDeployMtx(appID)
+	deployMtx.Lock()
+	defer deployMtx.Unlock()
+
+	return o.deployAppLocked(appID, sequence)
+}
+
+// getDeployMtx returns the lock that is held while the app is deployed, undeployed or rolled back
+func (o *Operator) getDeployMtx(appID string) *sync.Mutex {
+	o.mtxsLock.Lock()
+	defer o.mtxsLock.Unlock()
+
+	if o.deployMtxs == nil {
+		o.deployMtxs = map[string]*sync.Mutex{}
+	}
 	if _, ok := o.deployMtxs[appID]; !ok {
 		o.deployMtxs[appID] = &sync.Mutex{}
 	}
-	o.deployMtxs[appID].Lock()
-	defer o.deployMtxs[appID].Unlock()
+	return o.deployMtxs[appID]
+}
 
+// deployAppLocked deploys the version of the app. The caller must hold the deploy lock of the app.
+func (o *Operator) deployAppLocked(appID string, sequence int64) (deployed bool, deployError error) {
 	if err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionDeploying, ""); err != nil {
 		return false, errors.Wrap(err, "failed to update downstream status")
 	}
@@ -180,12 +216,16 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		}()
 	}
 
+	appSlug := ""
 	defer func() {
 		if deployError != nil {
 			err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionFailed, deployError.Error())
 			if err != nil {
 				logger.Error(errors.Wrap(err, "failed to update downstream status"))
 			}
+			events.PublishAppEvent(eventtypes.EventDeployFailed, appID, appSlug, sequence, map[string]interface{}{
+				"error": deployError.Error(),
+			})
 			return
 		}
 		if !deployed {
@@ -193,18 +233,21 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 			if err != nil {
 				logger.Error(errors.Wrap(err, "failed to update downstream status"))
 			}
+			events.PublishAppEvent(eventtypes.EventDeployFailed, appID, appSlug, sequence, nil)
 			return
 		}
 		err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionDeployed, "")
 		if err != nil {
 			logger.Error(errors.Wrap(err, "failed to update downstream status"))
 		}
+		events.PublishAppEvent(eventtypes.EventDeploySucceeded, appID, appSlug, sequence, nil)
 	}()
 
 	app, err := o.store.GetApp(appID)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to get app")
 	}
+	appSlug = app.Slug
 
 	if app.RestoreInProgressName != "" {
 		return false, errors.Errorf("failed to deploy version %d because app restore is already in progress", sequence)
@@ -390,6 +433,7 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		AppSlug:                      app.Slug,
 		ClusterID:                    o.clusterID,
 		Sequence:                     sequence,
+		ApplyMethod:                  os.Getenv("KOTSADM_APPLY_METHOD"),
 		KubectlVersion:               kotsKinds.KotsApplication.Spec.KubectlVersion,
 		KustomizeVersion:             kotsKinds.KotsApplication.Spec.KustomizeVersion,
 		AdditionalNamespaces:         kotsKinds.KotsApplication.Spec.AdditionalNamespaces,
@@ -673,6 +717,11 @@ func (o *Operator) checkRestoreComplete(a *apptypes.App, restore *velerov1.Resto
 			logger.Error(errors.Wrap(err, "failed to update downstream status"))
 		}
 
+		// the backup captured the workloads of scale hooks while they were scaled down
+		if err := snapshot.RestoreScaledDownWorkloads(context.Background(), util.PodNamespace); err != nil {
+			logger.Error(errors.Wrap(err, "failed to scale restored workloads back up"))
+		}
+
 		troubleshootOpts := supportbundletypes.TroubleshootOptions{
 			InCluster: true,
 		}
@@ -703,11 +752,9 @@ func (o *Operator) checkRestoreComplete(a *apptypes.App, restore *velerov1.Resto
 }
 
 func (o *Operator) UndeployApp(a *apptypes.App, d *downstreamtypes.Downstream, isRestore bool) error {
-	if _, ok := o.deployMtxs[a.ID]; !ok {
-		o.deployMtxs[a.ID] = &sync.Mutex{}
-	}
-	o.deployMtxs[a.ID].Lock()
-	defer o.deployMtxs[a.ID].Unlock()
+	deployMtx := o.getDeployMtx(a.ID)
+	deployMtx.Lock()
+	defer deployMtx.Unlock()
 
 	deployedVersion, err := o.store.GetCurrentDownstreamVersion(a.ID, d.ClusterID)
 	if err != nil {
@@ -780,6 +827,7 @@ func (o *Operator) UndeployApp(a *apptypes.App, d *downstreamtypes.Downstream, i
 		AppID:                a.ID,
 		AppSlug:              a.Slug,
 		ClusterID:            o.clusterID,
+		ApplyMethod:          os.Getenv("KOTSADM_APPLY_METHOD"),
 		KubectlVersion:       kotsKinds.KotsApplication.Spec.KubectlVersion,
 		KustomizeVersion:     kotsKinds.KotsApplication.Spec.KustomizeVersion,
 		AdditionalNamespaces: kotsKinds.KotsApplication.Spec.AdditionalNamespaces,
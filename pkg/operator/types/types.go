@@ -21,11 +21,19 @@ const (
 	WaitForPropertiesAnnotation = "kots.io/wait-for-properties"
 )
 
+const (
+	// ApplyMethodKubectl shells out to the kubectl binary matching the application's kubectl version
+	ApplyMethodKubectl = "kubectl"
+	// ApplyMethodServerSide uses the dynamic client and server-side apply
+	ApplyMethodServerSide = "server-side"
+)
+
 type DeployAppArgs struct {
 	AppID                        string                `json:"app_id"`
 	AppSlug                      string                `json:"app_slug"`
 	ClusterID                    string                `json:"cluster_id"`
 	Sequence                     int64                 `json:"sequence"`
+	ApplyMethod                  string                `json:"apply_method"`
 	KubectlVersion               string                `json:"kubectl_version"`
 	KustomizeVersion             string                `json:"kustomize_version"`
 	AdditionalNamespaces         []string              `json:"additional_namespaces"`
@@ -51,6 +59,7 @@ type UndeployAppArgs struct {
 	AppID                string                `json:"app_id"`
 	AppSlug              string                `json:"app_slug"`
 	ClusterID            string                `json:"cluster_id"`
+	ApplyMethod          string                `json:"apply_method"`
 	KubectlVersion       string                `json:"kubectl_version"`
 	KustomizeVersion     string                `json:"kustomize_version"`
 	AdditionalNamespaces []string              `json:"additional_namespaces"`
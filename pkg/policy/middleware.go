@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/rbac"
 	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
@@ -41,7 +43,7 @@ func NewMiddleware(kotsStore store.Store, roles []rbactypes.Role) *Middleware {
 }
 
 func (m *Middleware) EnforceAccess(p *Policy, handler http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	enforce := func(w http.ResponseWriter, r *http.Request) {
 		sess := session.ContextGetSession(r)
 		if sess == nil {
 			logger.Error(errors.New("session empty"))
@@ -73,6 +75,48 @@ func (m *Middleware) EnforceAccess(p *Policy, handler http.HandlerFunc) http.Han
 
 		handler(w, r)
 	}
+
+	if p.action != ActionWrite {
+		return enforce
+	}
+	return m.recordAuditEvent(p, enforce)
+}
+
+// recordAuditEvent adds an event to the audit log for every mutating request to a write policy,
+// including requests that are denied access. Reads guarded by write policies, such as polled status routes, are not recorded.
+func (m *Middleware) recordAuditEvent(p *Policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isMutatingMethod(r.Method) {
+			next(w, r)
+			return
+		}
+
+		summary := audit.Summarize(audit.CaptureRequestBody(r))
+
+		r, details := audit.WithDetails(r)
+		recorder := audit.NewStatusRecorder(w)
+		next(recorder, r)
+
+		// vars added by the getters when the policy was enforced are available in the request vars,
+		// so the resource is rendered without calling the getters again
+		resource, err := p.render(mux.Vars(r))
+		if err != nil {
+			resource = ""
+		}
+
+		event := audit.NewEvent(r, session.ContextGetSession(r), resource, recorder.StatusCode, details.Summary(summary))
+		if err := m.KOTSStore.CreateAuditEvent(event); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to record audit event for %s %s", r.Method, r.URL.Path))
+		}
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
 }
 
 // TODO: move everything below here to a shared package
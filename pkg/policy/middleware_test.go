@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/audit"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/session"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_recordAuditEvent(t *testing.T) {
+	sess := &sessiontypes.Session{ID: "session-id", UserID: "user-id", Username: "jdoe"}
+
+	tests := []struct {
+		name        string
+		body        string
+		handler     http.HandlerFunc
+		wantStatus  int
+		wantOutcome audittypes.Outcome
+		wantSummary string
+	}{
+		{
+			name: "body is summarized",
+			body: `{"policy":{"days":3},"token":"abc"}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			wantStatus:  http.StatusOK,
+			wantOutcome: audittypes.OutcomeSuccess,
+			wantSummary: `policy.days=3 token=[REDACTED]`,
+		},
+		{
+			name: "recorded change is used instead of the body",
+			body: `{"policy":{"days":3}}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				audit.RecordChange(r, map[string]int{"days": 1}, map[string]int{"days": 3})
+				w.WriteHeader(http.StatusOK)
+			},
+			wantStatus:  http.StatusOK,
+			wantOutcome: audittypes.OutcomeSuccess,
+			wantSummary: `days: 1 -> 3`,
+		},
+		{
+			name: "denied requests are recorded",
+			body: `{"policy":{"days":3}}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus:  http.StatusForbidden,
+			wantOutcome: audittypes.OutcomeDenied,
+			wantSummary: `policy.days=3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockStore(ctrl)
+
+			var event audittypes.Event
+			mockStore.EXPECT().CreateAuditEvent(gomock.Any()).DoAndReturn(func(e audittypes.Event) error {
+				event = e
+				return nil
+			})
+
+			p := Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.soak"))
+			m := NewMiddleware(mockStore, nil)
+
+			req := httptest.NewRequest("PUT", "/api/v1/app/my-app/soak", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = mux.SetURLVars(req, map[string]string{"appSlug": "my-app"})
+			req = session.ContextSetSession(req, sess)
+
+			w := httptest.NewRecorder()
+			m.recordAuditEvent(p, tt.handler)(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			require.NotEmpty(t, event.ID)
+			assert.Equal(t, "PUT", event.Method)
+			assert.Equal(t, "/api/v1/app/my-app/soak", event.Path)
+			assert.Equal(t, "app.my-app.soak", event.Resource)
+			assert.Equal(t, "my-app", event.AppSlug)
+			assert.Equal(t, sess.ID, event.SessionID)
+			assert.Equal(t, sess.Username, event.Username)
+			assert.Equal(t, tt.wantStatus, event.StatusCode)
+			assert.Equal(t, tt.wantOutcome, event.Outcome)
+			assert.Equal(t, tt.wantSummary, event.Summary)
+		})
+	}
+}
+
+func TestMiddleware_EnforceAccess_readsAreNotAudited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// CreateAuditEvent is not expected, the mock fails the test if it is called
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	p := Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.airgap"))
+	m := NewMiddleware(mockStore, nil)
+
+	called := false
+	handler := m.EnforceAccess(p, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/app/my-app/airgap/status", nil)
+	req = mux.SetURLVars(req, map[string]string{"appSlug": "my-app"})
+	req = session.ContextSetSession(req, &sessiontypes.Session{ID: "session-id"})
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -63,6 +63,33 @@ var (
 	PasswordChange = Must(NewPolicy(ActionWrite, "passwordupdate."))
 )
 
+// Audit log
+
+var (
+	AuditRead = Must(NewPolicy(ActionRead, "audit."))
+)
+
+// Local users
+
+var (
+	UserRead  = Must(NewPolicy(ActionRead, "user."))
+	UserWrite = Must(NewPolicy(ActionWrite, "user."))
+)
+
+// File store
+
+var (
+	FilestoreRead  = Must(NewPolicy(ActionRead, "filestore."))
+	FilestoreWrite = Must(NewPolicy(ActionWrite, "filestore."))
+)
+
+// Webhooks
+
+var (
+	WebhookRead  = Must(NewPolicy(ActionRead, "webhook."))
+	WebhookWrite = Must(NewPolicy(ActionWrite, "webhook."))
+)
+
 // Kotsadm Identity Service
 
 var (
@@ -109,6 +136,13 @@ var (
 	AppSnapshotsettingsWrite = Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.snapshotsettings."))
 )
 
+// App version retention
+
+var (
+	AppVersionretentionRead  = Must(NewPolicy(ActionRead, "app.{{.appSlug}}.versionretention."))
+	AppVersionretentionWrite = Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.versionretention."))
+)
+
 // App registry
 
 var (
@@ -42,7 +42,12 @@ func (p *Policy) execute(r *http.Request, kotsStore store.Store) (action, resour
 			vars[key] = val
 		}
 	}
+	resource, err = p.render(vars)
+	return p.action, resource, err
+}
+
+func (p *Policy) render(vars map[string]string) (string, error) {
 	var buf bytes.Buffer
-	err = p.resourceTemplate.Execute(&buf, vars)
-	return p.action, buf.String(), err
+	err := p.resourceTemplate.Execute(&buf, vars)
+	return buf.String(), err
 }
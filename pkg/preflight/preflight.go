@@ -9,6 +9,8 @@ import (
 
 	"github.com/pkg/errors"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	"github.com/replicatedhq/kots/pkg/installers"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	kotstypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
@@ -164,9 +166,17 @@ func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir
 			uploadPreflightResults, err := execute(appID, sequence, preflight, ignoreRBAC)
 			if err != nil {
 				logger.Error(errors.Wrap(err, "failed to run preflight checks"))
+				events.PublishAppEvent(eventtypes.EventPreflightCompleted, appID, appSlug, sequence, map[string]interface{}{
+					"state": "error",
+					"error": err.Error(),
+				})
 				return
 			}
 
+			events.PublishAppEvent(eventtypes.EventPreflightCompleted, appID, appSlug, sequence, map[string]interface{}{
+				"state": GetPreflightState(uploadPreflightResults),
+			})
+
 			// Log the preflight results if there are any warnings or errors
 			// The app may not get installed so we need to see this info for debugging
 			if GetPreflightState(uploadPreflightResults) != "pass" {
@@ -194,7 +204,7 @@ func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir
 				logger.Error(errors.Wrapf(err, "failed to check downstream version %d status", sequence))
 				return
 			}
-			if status == storetypes.VersionDeployed || status == storetypes.VersionDeploying || status == storetypes.VersionFailed {
+			if status == storetypes.VersionDeployed || status == storetypes.VersionDeploying || status == storetypes.VersionFailed || status == storetypes.VersionRolledBack {
 				return
 			}
 
@@ -212,7 +222,7 @@ func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir
 				}
 			}
 		}()
-	} else if status != storetypes.VersionDeployed && status != storetypes.VersionFailed {
+	} else if status != storetypes.VersionDeployed && status != storetypes.VersionFailed && status != storetypes.VersionRolledBack {
 		if sequence == 0 {
 			_, err := maybeDeployFirstVersion(appID, sequence, &types.PreflightResults{})
 			if err != nil {
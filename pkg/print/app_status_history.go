@@ -0,0 +1,66 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+)
+
+func AppStatusHistory(history *appstatushistorytypes.History, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(history, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if len(history.Entries) == 0 {
+		fmt.Println("No status changes found in this window")
+	} else {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "TIME", "STATE", "SEQUENCE", "NOT READY")
+		for _, entry := range history.Entries {
+			fmt.Fprintf(w, fmtColumns, entry.CreatedAt.Format(time.RFC3339), entry.State, fmt.Sprintf("%d", entry.Sequence), notReadyResources(entry.ResourceStates))
+		}
+		w.Flush()
+	}
+
+	availability := history.Availability
+	fmt.Println()
+	if availability.ObservedSeconds == 0 {
+		fmt.Println("Availability: unknown, no status was recorded for this window")
+		return
+	}
+	fmt.Printf("Availability: %.3f%% (observed %s of %s)\n", availability.Percent, formatSeconds(availability.ObservedSeconds), availability.End.Sub(availability.Start).Round(time.Second))
+
+	states := []string{}
+	for state := range availability.StateSeconds {
+		states = append(states, string(state))
+	}
+	sort.Strings(states)
+	for _, state := range states {
+		fmt.Printf("  %s: %s\n", state, formatSeconds(availability.StateSeconds[appstatetypes.State(state)]))
+	}
+}
+
+func notReadyResources(resourceStates appstatetypes.ResourceStates) string {
+	notReady := []string{}
+	for _, r := range resourceStates {
+		if r.State != appstatetypes.StateReady {
+			notReady = append(notReady, fmt.Sprintf("%s/%s=%s", r.Kind, r.Name, r.State))
+		}
+	}
+	if len(notReady) == 0 {
+		return "-"
+	}
+	return strings.Join(notReady, ",")
+}
+
+func formatSeconds(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
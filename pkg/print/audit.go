@@ -0,0 +1,62 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+)
+
+func AuditEvents(events []audittypes.Event, format string) {
+	switch format {
+	case "json":
+		printAuditEventsJSON(events)
+	case "jsonl":
+		printAuditEventsJSONLines(events)
+	default:
+		printAuditEventsTable(events)
+	}
+}
+
+func printAuditEventsJSON(events []audittypes.Event) {
+	str, _ := json.MarshalIndent(events, "", "    ")
+	fmt.Println(string(str))
+}
+
+// printAuditEventsJSONLines prints one event per line so that the output can be exported to log pipelines
+func printAuditEventsJSONLines(events []audittypes.Event) {
+	for _, event := range events {
+		str, _ := json.Marshal(event)
+		fmt.Println(string(str))
+	}
+}
+
+func printAuditEventsTable(events []audittypes.Event) {
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "TIME", "USER", "ACTION", "APP", "SEQUENCE", "OUTCOME", "SUMMARY")
+	for _, event := range events {
+		user := event.Username
+		if user == "" {
+			user = event.UserID
+		}
+		if user == "" {
+			user = event.SessionID
+		}
+
+		action := event.Route
+		if action == "" {
+			action = fmt.Sprintf("%s %s", event.Method, event.Path)
+		}
+
+		sequence := ""
+		if event.Sequence != nil {
+			sequence = fmt.Sprintf("%d", *event.Sequence)
+		}
+
+		fmt.Fprintf(w, fmtColumns, event.CreatedAt.Format(time.RFC3339), user, action, event.AppSlug, sequence, event.Outcome, event.Summary)
+	}
+}
@@ -0,0 +1,88 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+)
+
+func GitOpsStatus(downstreams []gitopstypes.DownstreamDriftStatus, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(downstreams, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if len(downstreams) == 0 {
+		fmt.Println("GitOps is not enabled for this app")
+		return
+	}
+
+	for i, d := range downstreams {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Downstream: %s\n", d.Name)
+
+		status := d.Status
+		if status == nil {
+			fmt.Println("Status: not checked yet")
+			continue
+		}
+
+		fmt.Printf("Checked at: %s\n", status.CheckedAt.Format(time.RFC3339))
+		if status.RepoSequence >= 0 {
+			fmt.Printf("Repo sequence: %d\n", status.RepoSequence)
+		}
+		if status.Sequence >= 0 {
+			fmt.Printf("Deployed sequence: %d\n", status.Sequence)
+		}
+		if status.Error != "" {
+			fmt.Printf("Status: error: %s\n", status.Error)
+			continue
+		}
+		if status.Sequence < 0 && status.RepoSequence < 0 {
+			fmt.Println("Status: no version has been committed or deployed")
+			continue
+		}
+		if !status.HasDrift() {
+			fmt.Println("Status: in sync")
+			continue
+		}
+		fmt.Println("Status: drifted")
+
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "SOURCE", "CHANGE", "KIND", "NAME")
+		printResourceDrift(w, fmtColumns, "repo", status.Repo)
+		printResourceDrift(w, fmtColumns, "cluster", status.Cluster)
+		w.Flush()
+	}
+}
+
+func printResourceDrift(w io.Writer, fmtColumns string, source string, drift gitopstypes.ResourceDrift) {
+	changes := []struct {
+		change    string
+		resources []gitopstypes.Resource
+	}{
+		{"added", drift.Added},
+		{"modified", drift.Modified},
+		{"removed", drift.Removed},
+	}
+	for _, c := range changes {
+		for _, r := range c.resources {
+			fmt.Fprintf(w, fmtColumns, source, c.change, r.Kind, resourceName(r))
+		}
+	}
+}
+
+func resourceName(r gitopstypes.Resource) string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return strings.Join([]string{r.Namespace, r.Name}, "/")
+}
@@ -0,0 +1,60 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+)
+
+func SnapshotRetentionPolicy(policy *snapshottypes.RetentionPolicy, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(policy, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if policy == nil {
+		fmt.Println("No snapshot retention policy is set, backups are expired by their TTL")
+		return
+	}
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "HOURLY", "DAILY", "WEEKLY", "MONTHLY")
+	fmt.Fprintf(w, fmtColumns, fmt.Sprintf("%d", policy.Hourly), fmt.Sprintf("%d", policy.Daily), fmt.Sprintf("%d", policy.Weekly), fmt.Sprintf("%d", policy.Monthly))
+}
+
+func SnapshotRetentionPreview(preview *snapshottypes.RetentionPreview, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(preview, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if len(preview.Keep) > 0 || len(preview.Prune) > 0 {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "NAME", "STATUS", "STARTED", "KEPT BY")
+		for _, k := range preview.Keep {
+			fmt.Fprintf(w, fmtColumns, k.Backup.Name, k.Backup.Status, formatBackupStartedAt(k.Backup), strings.Join(k.Tiers, ","))
+		}
+		for _, b := range preview.Prune {
+			fmt.Fprintf(w, fmtColumns, b.Name, b.Status, formatBackupStartedAt(b), "")
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("%d backups would be kept, %d backups would be removed\n", len(preview.Keep), len(preview.Prune))
+}
+
+func formatBackupStartedAt(b *snapshottypes.Backup) string {
+	if b.StartedAt == nil {
+		return ""
+	}
+	return b.StartedAt.Format(time.RFC3339)
+}
@@ -0,0 +1,50 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+)
+
+func SoakPolicy(policy *soaktypes.Policy, approved []soaktypes.ApprovedVersions, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(map[string]interface{}{
+			"policy":           policy,
+			"approvedVersions": approved,
+		}, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if policy == nil {
+		fmt.Println("No soak policy is set, new versions are auto-deployed as soon as they are available")
+	} else {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "MIN DAYS", "APPROVING INSTANCES")
+		fmt.Fprintf(w, fmtColumns, fmt.Sprintf("%d", policy.MinDays), strings.Join(policy.ApprovingInstances, ","))
+		w.Flush()
+	}
+
+	if len(approved) == 0 {
+		return
+	}
+
+	fmt.Println("")
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "INSTANCE", "EXPORTED", "APPROVED VERSIONS", "LATEST")
+	for _, a := range approved {
+		latest := ""
+		if len(a.Versions) > 0 {
+			latest = a.Versions[0].VersionLabel
+		}
+		fmt.Fprintf(w, fmtColumns, a.InstanceName, a.ExportedAt.Format(time.RFC3339), fmt.Sprintf("%d", len(a.Versions)), latest)
+	}
+}
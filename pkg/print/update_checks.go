@@ -0,0 +1,54 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	updatecheckertypes "github.com/replicatedhq/kots/pkg/updatechecker/types"
+)
+
+func UpdateChecks(statuses []updatecheckertypes.AppUpdateCheckStatus, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(statuses, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "APP", "SCHEDULE", "LAST CHECK", "RESULT", "AVAILABLE", "NEXT CHECK", "ERROR")
+	for _, s := range statuses {
+		schedule := s.UpdateCheckerSpec
+		if s.IsAirgap {
+			schedule = "airgap"
+		} else if schedule == "" {
+			schedule = "@never"
+		}
+
+		lastCheck, result, available, checkErr := "", "", "", ""
+		if s.LastCheck != nil {
+			lastCheck = s.LastCheck.CheckedAt.Format(time.RFC3339)
+			result = string(s.LastCheck.Status)
+			if s.LastCheck.Status == updatecheckertypes.CheckStatusFailed {
+				if s.LastCheck.ConsecutiveFailures > 1 {
+					result = fmt.Sprintf("%s (%d times)", result, s.LastCheck.ConsecutiveFailures)
+				}
+				checkErr = s.LastCheck.Error
+			} else {
+				available = fmt.Sprintf("%d", s.LastCheck.AvailableUpdates)
+			}
+		}
+
+		nextCheck := ""
+		if s.NextRetryAt != nil {
+			nextCheck = fmt.Sprintf("%s (retry)", s.NextRetryAt.Format(time.RFC3339))
+		} else if s.NextCheckAt != nil {
+			nextCheck = s.NextCheckAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, fmtColumns, s.AppSlug, schedule, lastCheck, result, available, nextCheck, checkErr)
+	}
+}
@@ -0,0 +1,55 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type UserResponse struct {
+	ID          string     `json:"id"`
+	Username    string     `json:"username"`
+	Roles       []string   `json:"roles"`
+	IsDisabled  bool       `json:"isDisabled"`
+	IsLockedOut bool       `json:"isLockedOut"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+func Users(users []UserResponse, format string) {
+	switch format {
+	case "json":
+		printUsersJSON(users)
+	default:
+		printUsersTable(users)
+	}
+}
+
+func printUsersJSON(users []UserResponse) {
+	str, _ := json.MarshalIndent(users, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printUsersTable(users []UserResponse) {
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "USERNAME", "ROLES", "STATUS", "LAST LOGIN")
+	for _, user := range users {
+		status := "active"
+		if user.IsDisabled {
+			status = "disabled"
+		} else if user.IsLockedOut {
+			status = "locked"
+		}
+
+		lastLogin := "never"
+		if user.LastLoginAt != nil {
+			lastLogin = user.LastLoginAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, fmtColumns, user.Username, strings.Join(user.Roles, ","), status, lastLogin)
+	}
+}
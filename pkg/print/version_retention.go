@@ -0,0 +1,53 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	versionretentiontypes "github.com/replicatedhq/kots/pkg/versionretention/types"
+)
+
+func VersionRetentionPolicy(policy *versionretentiontypes.Policy, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(policy, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if policy == nil {
+		fmt.Println("No version retention policy is set, all versions are kept")
+		return
+	}
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "KEEP LAST", "KEEP PREVIOUSLY DEPLOYED", "KEEP NEWER THAN (DAYS)")
+	fmt.Fprintf(w, fmtColumns, fmt.Sprintf("%d", policy.KeepLast), fmt.Sprintf("%t", policy.KeepPreviouslyDeployed), fmt.Sprintf("%d", policy.KeepNewerThanDays))
+}
+
+func PruneResult(result *versionretentiontypes.PruneResult, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(result, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	if len(result.Pruned) > 0 {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "SEQUENCE", "VERSION", "CREATED", "PREVIOUSLY DEPLOYED")
+		for _, v := range result.Pruned {
+			fmt.Fprintf(w, fmtColumns, fmt.Sprintf("%d", v.Sequence), v.VersionLabel, v.CreatedAt.Format(time.RFC3339), fmt.Sprintf("%t", v.WasDeployed))
+		}
+		w.Flush()
+	}
+
+	if result.DryRun {
+		fmt.Printf("%d versions would be removed, %d versions would be kept\n", len(result.Pruned), result.Kept)
+	} else {
+		fmt.Printf("%d versions removed, %d versions kept\n", len(result.Pruned), result.Kept)
+	}
+}
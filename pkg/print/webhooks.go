@@ -0,0 +1,52 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	webhooktypes "github.com/replicatedhq/kots/pkg/webhooks/types"
+)
+
+func Webhooks(webhooks []webhooktypes.Webhook, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(webhooks, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "ID", "URL", "EVENTS", "DISABLED", "CREATED")
+	for _, webhook := range webhooks {
+		eventTypes := "all"
+		if len(webhook.EventTypes) > 0 {
+			eventTypes = strings.Join(webhook.EventTypes, ",")
+		}
+		fmt.Fprintf(w, fmtColumns, webhook.ID, webhook.URL, eventTypes, fmt.Sprintf("%t", webhook.IsDisabled), webhook.CreatedAt.Format(time.RFC3339))
+	}
+}
+
+func WebhookDeliveries(deliveries []webhooktypes.Delivery, format string) {
+	if format == "json" {
+		str, _ := json.MarshalIndent(deliveries, "", "    ")
+		fmt.Println(string(str))
+		return
+	}
+
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "ID", "EVENT", "STATUS", "ATTEMPTS", "STATUS CODE", "ERROR", "CREATED")
+	for _, delivery := range deliveries {
+		statusCode := ""
+		if delivery.StatusCode != 0 {
+			statusCode = fmt.Sprintf("%d", delivery.StatusCode)
+		}
+		fmt.Fprintf(w, fmtColumns, delivery.ID, delivery.EventType, delivery.Status, fmt.Sprintf("%d", delivery.Attempts), statusCode, delivery.Error, delivery.CreatedAt.Format(time.RFC3339))
+	}
+}
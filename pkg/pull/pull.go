@@ -315,6 +315,11 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		return "", errors.Wrap(err, "failed to check if version needs configuration")
 	}
 
+	imagePolicy, err := image.GetImagePolicy(clientset, util.PodNamespace)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get image policy")
+	}
+
 	processImageOptions := image.ProcessImageOptions{
 		AppSlug:          pullOptions.AppSlug,
 		Namespace:        pullOptions.Namespace,
@@ -328,6 +333,7 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		PushImages:       pullOptions.RewriteImageOptions.Hostname != "",
 		CreateAppDir:     pullOptions.CreateAppDir,
 		ReportWriter:     pullOptions.ReportWriter,
+		ImagePolicy:      imagePolicy,
 	}
 
 	if needsConfig {
@@ -0,0 +1,343 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/containers/image/v5/docker"
+	dockerref "github.com/containers/image/v5/docker/reference"
+	imagetypes "github.com/containers/image/v5/types"
+	"github.com/pkg/errors"
+	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
+	"github.com/replicatedhq/kots/pkg/image"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/registry/types"
+)
+
+// cosign stores signatures, SBOMs and attestations under tags derived from the digest of the image they belong to
+var cosignAttachmentTagRegex = regexp.MustCompile(`^sha256-([a-f0-9]{64})\.(sig|sbom|att)$`)
+
+// registryClient is the part of the OCI distribution API that garbage collection needs.
+// Repositories are relative to the registry hostname.
+type registryClient interface {
+	ListRepositories(ctx context.Context) ([]string, error)
+	ListTags(ctx context.Context, repository string) ([]string, error)
+	// GetDigest returns an empty digest if the tag does not exist
+	GetDigest(ctx context.Context, repository string, tag string) (string, error)
+	DeleteManifest(ctx context.Context, repository string, digest string) error
+}
+
+// distributionClient works with any registry that implements the distribution API, including a plain registry:2
+// started with REGISTRY_STORAGE_DELETE_ENABLED=true.
+type distributionClient struct {
+	hostname string
+	sysCtx   *imagetypes.SystemContext
+}
+
+func newDistributionClient(registry types.RegistrySettings) *distributionClient {
+	sysCtx := &imagetypes.SystemContext{
+		DockerInsecureSkipTLSVerify: imagetypes.OptionalBoolTrue,
+		DockerDisableV1Ping:         true,
+	}
+	if registry.Username != "" && registry.Password != "" {
+		sysCtx.DockerAuthConfig = &imagetypes.DockerAuthConfig{
+			Username: registry.Username,
+			Password: registry.Password,
+		}
+	}
+
+	return &distributionClient{
+		hostname: registry.Hostname,
+		sysCtx:   sysCtx,
+	}
+}
+
+func (c *distributionClient) ListRepositories(ctx context.Context) ([]string, error) {
+	searchResult, err := docker.SearchRegistry(ctx, c.sysCtx, c.hostname, "", math.MaxInt32)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to search registry")
+	}
+
+	repositories := []string{}
+	for _, r := range searchResult {
+		repositories = append(repositories, r.Name)
+	}
+	return repositories, nil
+}
+
+func (c *distributionClient) ListTags(ctx context.Context, repository string) ([]string, error) {
+	ref, err := docker.ParseReference(fmt.Sprintf("//%s", path.Join(c.hostname, repository)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse repository %s", repository)
+	}
+	return docker.GetRepositoryTags(ctx, c.sysCtx, ref)
+}
+
+func (c *distributionClient) GetDigest(ctx context.Context, repository string, tag string) (string, error) {
+	taggedName := fmt.Sprintf("%s:%s", path.Join(c.hostname, repository), tag)
+	ref, err := docker.ParseReference(fmt.Sprintf("//%s", taggedName))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse tagged ref %s", taggedName)
+	}
+
+	digest, err := docker.GetDigest(ctx, c.sysCtx, ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "StatusCode: 404") {
+			return "", nil
+		}
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+func (c *distributionClient) DeleteManifest(ctx context.Context, repository string, digest string) error {
+	imageName := fmt.Sprintf("%s@%s", path.Join(c.hostname, repository), digest)
+	ref, err := docker.ParseReference(fmt.Sprintf("//%s", imageName))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse image ref %s", imageName)
+	}
+	return ref.DeleteImage(ctx, c.sysCtx)
+}
+
+type registryManifest struct {
+	repository string
+	digest     string
+	tags       []string
+}
+
+func (m registryManifest) key() string {
+	return fmt.Sprintf("%s@%s", m.repository, m.digest)
+}
+
+// cosignSubjectKey returns the image a cosign signature, SBOM or attestation belongs to.
+// Manifests with any other tag are regular images.
+func (m registryManifest) cosignSubjectKey() (string, bool) {
+	subjectKey := ""
+	for _, tag := range m.tags {
+		matches := cosignAttachmentTagRegex.FindStringSubmatch(tag)
+		if matches == nil {
+			return "", false
+		}
+		subjectKey = fmt.Sprintf("%s@sha256:%s", m.repository, matches[1])
+	}
+	return subjectKey, subjectKey != ""
+}
+
+// garbageCollectRegistry deletes manifests in the registry namespace that none of the used images resolve to.
+// Deleting a manifest removes all of its tags, so a manifest is kept if any image resolves to it or any of its tags is protected.
+// Cosign attachments are kept as long as the image they belong to is kept.
+func garbageCollectRegistry(ctx context.Context, client registryClient, registry types.RegistrySettings, usedImages []string, options types.GarbageCollectOptions) (*types.GarbageCollectResult, error) {
+	usedKeys, usedRepositories, err := resolveUsedImages(ctx, client, registry, usedImages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve used images")
+	}
+
+	repositories := map[string]bool{}
+	for repository := range usedRepositories {
+		repositories[repository] = true
+	}
+
+	names, err := client.ListRepositories(ctx)
+	if err != nil {
+		// not all registries implement the catalog API, the repositories the apps use can still be cleaned up
+		logger.Infof("failed to list registry repositories, only repositories referenced by apps will be checked: %v", err)
+	}
+	for _, name := range names {
+		// the registry can be shared with other internal or external applications, specially if an external registry is configured.
+		// ONLY delete images from the configured application's registry namespace to avoid deleting non-related user data.
+		if repositoryNamespace(name) == registry.Namespace {
+			repositories[name] = true
+		}
+	}
+
+	manifests := map[string]*registryManifest{}
+	for _, repository := range sortedKeys(repositories) {
+		tags, err := client.ListTags(ctx, repository)
+		if err != nil {
+			logger.Errorf("failed to get repo tags for %q: %v", repository, err)
+			continue
+		}
+
+		for _, tag := range tags {
+			digest, err := client.GetDigest(ctx, repository, tag)
+			if err != nil {
+				logger.Errorf("failed to get digest for %s:%s: %v", repository, tag, err)
+				continue
+			}
+			if digest == "" {
+				logger.Infof("will not delete %s:%s it's not found in registry", repository, tag)
+				continue
+			}
+
+			m := &registryManifest{repository: repository, digest: digest}
+			if existing, ok := manifests[m.key()]; ok {
+				m = existing
+			} else {
+				manifests[m.key()] = m
+			}
+			m.tags = append(m.tags, tag)
+		}
+	}
+
+	result := &types.GarbageCollectResult{
+		DryRun:    options.DryRun,
+		Deleted:   []types.GarbageCollectImage{},
+		Protected: []types.GarbageCollectImage{},
+	}
+
+	keep := map[string]bool{}
+	candidates := []*registryManifest{}
+	attachments := []*registryManifest{}
+
+	for _, key := range sortedKeys(manifests) {
+		m := manifests[key]
+		if _, ok := m.cosignSubjectKey(); ok {
+			attachments = append(attachments, m)
+			continue
+		}
+		if usedKeys[key] {
+			keep[key] = true
+			result.Kept++
+			continue
+		}
+		if isProtectedManifest(options.ProtectedImages, registry.Hostname, m) {
+			keep[key] = true
+			result.Protected = append(result.Protected, m.toImage())
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	for _, m := range attachments {
+		subjectKey, _ := m.cosignSubjectKey()
+		if keep[subjectKey] || usedKeys[m.key()] {
+			result.Kept++
+			continue
+		}
+		if isProtectedManifest(options.ProtectedImages, registry.Hostname, m) {
+			result.Protected = append(result.Protected, m.toImage())
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	for _, m := range candidates {
+		if options.DryRun {
+			result.Deleted = append(result.Deleted, m.toImage())
+			continue
+		}
+
+		logger.Infof("Deleting digest %s for image %s:%s", m.digest, m.repository, strings.Join(m.tags, ","))
+		if err := client.DeleteManifest(ctx, m.repository, m.digest); err != nil {
+			logger.Infof("failed to delete image %s from registry: %v", m.key(), err)
+			image := m.toImage()
+			image.Error = err.Error()
+			result.Failed = append(result.Failed, image)
+			continue
+		}
+		result.Deleted = append(result.Deleted, m.toImage())
+	}
+
+	return result, nil
+}
+
+// resolveUsedImages returns the manifests the used images resolve to in the registry, keyed by repository@digest,
+// and the repositories they are in.
+func resolveUsedImages(ctx context.Context, client registryClient, registry types.RegistrySettings, usedImages []string) (map[string]bool, map[string]bool, error) {
+	registryOptions := registrytypes.RegistryOptions{
+		Endpoint:  registry.Hostname,
+		Namespace: registry.Namespace,
+		Username:  registry.Username,
+		Password:  registry.Password,
+	}
+
+	usedKeys := map[string]bool{}
+	usedRepositories := map[string]bool{}
+	for _, usedImage := range usedImages {
+		appImage, err := image.DestImage(registryOptions, usedImage)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get destination image for %s", usedImage)
+		}
+
+		named, err := dockerref.ParseNormalizedNamed(appImage)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to parse %s", appImage)
+		}
+		repository := dockerref.Path(named)
+		usedRepositories[repository] = true
+
+		if canonical, ok := named.(dockerref.Canonical); ok {
+			usedKeys[fmt.Sprintf("%s@%s", repository, canonical.Digest())] = true
+			continue
+		}
+
+		tag := "latest"
+		if tagged, ok := named.(dockerref.Tagged); ok {
+			tag = tagged.Tag()
+		}
+
+		digest, err := client.GetDigest(ctx, repository, tag)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get digest for %s", appImage)
+		}
+		if digest == "" {
+			logger.Infof("digest not found for image %q", appImage)
+			continue
+		}
+		usedKeys[fmt.Sprintf("%s@%s", repository, digest)] = true
+	}
+
+	return usedKeys, usedRepositories, nil
+}
+
+// isProtectedManifest matches the patterns against the repository, every repository:tag and repository@digest,
+// both with and without the registry hostname.
+func isProtectedManifest(patterns []string, hostname string, m *registryManifest) bool {
+	names := []string{m.repository, fmt.Sprintf("%s@%s", m.repository, m.digest)}
+	for _, tag := range m.tags {
+		names = append(names, fmt.Sprintf("%s:%s", m.repository, tag))
+	}
+
+	for _, pattern := range patterns {
+		for _, name := range names {
+			for _, candidate := range []string{name, path.Join(hostname, name)} {
+				if matched, _ := path.Match(pattern, candidate); matched {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (m registryManifest) toImage() types.GarbageCollectImage {
+	return types.GarbageCollectImage{
+		Repository: m.repository,
+		Digest:     m.digest,
+		Tags:       m.tags,
+	}
+}
+
+// repositoryNamespace returns everything but the image name, e.g.: my/namespace/imagename => my/namespace
+func repositoryNamespace(repository string) string {
+	parts := strings.Split(repository, "/")
+	if len(parts) > 1 {
+		return path.Join(parts[:len(parts)-1]...)
+	}
+	return ""
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/distribution/distribution/v3/configuration"
+	"github.com/distribution/distribution/v3/registry/handlers"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem" // this initializes the filesystem storage driver
+	"github.com/replicatedhq/kots/pkg/registry/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestRegistry returns the host of a registry that allows deletes.
+// TEST_REGISTRY_HOST can point to a running registry:2 started with REGISTRY_STORAGE_DELETE_ENABLED=true,
+// otherwise the registry:2 implementation is served in process.
+func startTestRegistry(t *testing.T) string {
+	if host := os.Getenv("TEST_REGISTRY_HOST"); host != "" {
+		resp, err := http.Get(fmt.Sprintf("http://%s/v2/", host))
+		if err != nil {
+			t.Skipf("registry %s is not available: %v", host, err)
+		}
+		resp.Body.Close()
+		return host
+	}
+
+	config, err := configuration.Parse(strings.NewReader(fmt.Sprintf(`version: 0.1
+storage:
+  filesystem:
+    rootdirectory: %s
+  delete:
+    enabled: true
+`, t.TempDir())))
+	require.NoError(t, err)
+
+	app := handlers.NewApp(context.Background(), config)
+	server := httptest.NewServer(app)
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// pushTestImage uploads an image with an empty config and no layers, the content makes the digest unique
+func pushTestImage(t *testing.T, host string, repository string, tag string, content string) string {
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]},"comment":%q}`, content))
+	configDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(config))
+
+	resp, err := http.Post(fmt.Sprintf("http://%s/v2/%s/blobs/uploads/", host, repository), "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	location := resp.Header.Get("Location")
+	if !strings.HasPrefix(location, "http") {
+		location = fmt.Sprintf("http://%s%s", host, location)
+	}
+	separator := "?"
+	if strings.Contains(location, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s%sdigest=%s", location, separator, configDigest), bytes.NewReader(config))
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":"%s"},"layers":[]}`, len(config), configDigest))
+	req, err = http.NewRequest("PUT", fmt.Sprintf("http://%s/v2/%s/manifests/%s", host, repository, tag), bytes.NewReader(manifest))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest))
+}
+
+func Test_garbageCollectRegistry_distributionClient(t *testing.T) {
+	host := startTestRegistry(t)
+
+	unusedDigest := pushTestImage(t, host, "app/nginx", "1.20", "nginx 1.20")
+	usedDigest := pushTestImage(t, host, "app/nginx", "1.21", "nginx 1.21")
+	protectedDigest := pushTestImage(t, host, "app/nginx", "stable", "nginx stable")
+	otherDigest := pushTestImage(t, host, "other/nginx", "1.20", "other nginx 1.20")
+
+	registry := types.RegistrySettings{
+		Hostname:  host,
+		Namespace: "app",
+	}
+	client := newDistributionClient(registry)
+	usedImages := []string{"nginx:1.21"}
+	options := types.GarbageCollectOptions{
+		ProtectedImages: []string{"*/nginx:stable"},
+	}
+
+	ctx := context.Background()
+
+	dryRunOptions := options
+	dryRunOptions.DryRun = true
+	result, err := garbageCollectRegistry(ctx, client, registry, usedImages, dryRunOptions)
+	require.NoError(t, err)
+	assert.Equal(t, []types.GarbageCollectImage{{Repository: "app/nginx", Digest: unusedDigest, Tags: []string{"1.20"}}}, result.Deleted)
+	assert.Equal(t, []types.GarbageCollectImage{{Repository: "app/nginx", Digest: protectedDigest, Tags: []string{"stable"}}}, result.Protected)
+	assert.Equal(t, 1, result.Kept)
+
+	digest, err := client.GetDigest(ctx, "app/nginx", "1.20")
+	require.NoError(t, err)
+	assert.Equal(t, unusedDigest, digest, "dry run must not delete")
+
+	result, err = garbageCollectRegistry(ctx, client, registry, usedImages, options)
+	require.NoError(t, err)
+	assert.Len(t, result.Deleted, 1)
+	assert.Empty(t, result.Failed)
+
+	wantDigests := map[string]string{
+		"app/nginx:1.20":   "",
+		"app/nginx:1.21":   usedDigest,
+		"app/nginx:stable": protectedDigest,
+		"other/nginx:1.20": otherDigest,
+	}
+	for image, want := range wantDigests {
+		parts := strings.SplitN(image, ":", 2)
+		digest, err := client.GetDigest(ctx, parts[0], parts[1])
+		require.NoError(t, err)
+		assert.Equal(t, want, digest, image)
+	}
+
+	result, err = garbageCollectRegistry(ctx, client, registry, usedImages, dryRunOptions)
+	require.NoError(t, err)
+	assert.Empty(t, result.Deleted)
+}
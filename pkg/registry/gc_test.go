@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/registry/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRegistryClient struct {
+	catalogErr error
+	// tags maps repository to tag to digest
+	tags      map[string]map[string]string
+	deleted   []string
+	deleteErr map[string]error
+}
+
+func (c *fakeRegistryClient) ListRepositories(ctx context.Context) ([]string, error) {
+	if c.catalogErr != nil {
+		return nil, c.catalogErr
+	}
+	repositories := []string{}
+	for repository := range c.tags {
+		repositories = append(repositories, repository)
+	}
+	return repositories, nil
+}
+
+func (c *fakeRegistryClient) ListTags(ctx context.Context, repository string) ([]string, error) {
+	return sortedKeys(c.tags[repository]), nil
+}
+
+func (c *fakeRegistryClient) GetDigest(ctx context.Context, repository string, tag string) (string, error) {
+	return c.tags[repository][tag], nil
+}
+
+func (c *fakeRegistryClient) DeleteManifest(ctx context.Context, repository string, digest string) error {
+	key := fmt.Sprintf("%s@%s", repository, digest)
+	if err := c.deleteErr[key]; err != nil {
+		return err
+	}
+	c.deleted = append(c.deleted, key)
+	return nil
+}
+
+func testDigest(c string) string {
+	return "sha256:" + strings.Repeat(c, 64)
+}
+
+func Test_garbageCollectRegistry(t *testing.T) {
+	registry := types.RegistrySettings{
+		Hostname:  "registry.example.com:5000",
+		Namespace: "app",
+	}
+
+	newClient := func() *fakeRegistryClient {
+		return &fakeRegistryClient{
+			tags: map[string]map[string]string{
+				"app/nginx": {
+					"1.20":   testDigest("a"),
+					"1.21":   testDigest("b"),
+					"latest": testDigest("b"),
+					"stable": testDigest("c"),
+					// signature of the unused 1.20 image and of the used 1.21 image
+					"sha256-" + strings.Repeat("a", 64) + ".sig": testDigest("d"),
+					"sha256-" + strings.Repeat("b", 64) + ".sig": testDigest("e"),
+				},
+				"app/redis": {
+					"6": testDigest("f"),
+				},
+				"other/redis": {
+					"6": testDigest("9"),
+				},
+			},
+		}
+	}
+
+	usedImages := []string{
+		"nginx:1.21",
+		fmt.Sprintf("docker.io/library/redis@%s", testDigest("f")),
+	}
+
+	tests := []struct {
+		name          string
+		client        *fakeRegistryClient
+		options       types.GarbageCollectOptions
+		wantDeleted   []string
+		wantProtected []string
+		wantFailed    []string
+		wantKept      int
+		wantRemoved   []string
+	}{
+		{
+			name:        "deletes unreferenced manifests and their signatures",
+			client:      newClient(),
+			wantDeleted: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c"), "app/nginx@" + testDigest("d")},
+			wantKept:    3,
+			wantRemoved: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c"), "app/nginx@" + testDigest("d")},
+		},
+		{
+			name:        "dry run does not delete",
+			client:      newClient(),
+			options:     types.GarbageCollectOptions{DryRun: true},
+			wantDeleted: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c"), "app/nginx@" + testDigest("d")},
+			wantKept:    3,
+		},
+		{
+			name:          "protected images keep their signatures",
+			client:        newClient(),
+			options:       types.GarbageCollectOptions{ProtectedImages: []string{"registry.example.com:5000/app/nginx:1.20", "*/nginx:stable"}},
+			wantProtected: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c")},
+			wantKept:      4,
+		},
+		{
+			name: "only repositories referenced by apps are checked without the catalog api",
+			client: func() *fakeRegistryClient {
+				c := newClient()
+				c.catalogErr = errors.New("UNSUPPORTED")
+				c.tags["app/unused"] = map[string]string{"1": testDigest("8")}
+				return c
+			}(),
+			wantDeleted: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c"), "app/nginx@" + testDigest("d")},
+			wantKept:    3,
+			wantRemoved: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("c"), "app/nginx@" + testDigest("d")},
+		},
+		{
+			name: "failed deletes are reported",
+			client: func() *fakeRegistryClient {
+				c := newClient()
+				c.deleteErr = map[string]error{"app/nginx@" + testDigest("c"): errors.New("UNSUPPORTED")}
+				return c
+			}(),
+			wantDeleted: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("d")},
+			wantFailed:  []string{"app/nginx@" + testDigest("c")},
+			wantKept:    3,
+			wantRemoved: []string{"app/nginx@" + testDigest("a"), "app/nginx@" + testDigest("d")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := garbageCollectRegistry(context.Background(), tt.client, registry, usedImages, tt.options)
+			require.NoError(t, err)
+
+			imageKeys := func(images []types.GarbageCollectImage) []string {
+				keys := []string{}
+				for _, image := range images {
+					keys = append(keys, fmt.Sprintf("%s@%s", image.Repository, image.Digest))
+				}
+				return keys
+			}
+
+			assert.Equal(t, tt.options.DryRun, result.DryRun)
+			assert.ElementsMatch(t, tt.wantDeleted, imageKeys(result.Deleted))
+			assert.ElementsMatch(t, tt.wantProtected, imageKeys(result.Protected))
+			assert.ElementsMatch(t, tt.wantFailed, imageKeys(result.Failed))
+			assert.Equal(t, tt.wantKept, result.Kept)
+			assert.ElementsMatch(t, tt.wantRemoved, tt.client.deleted)
+		})
+	}
+}
+
+func Test_isProtectedManifest(t *testing.T) {
+	m := &registryManifest{
+		repository: "app/nginx",
+		digest:     testDigest("a"),
+		tags:       []string{"1.21", "latest"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     bool
+	}{
+		{
+			name: "no patterns",
+			want: false,
+		},
+		{
+			name:     "repository",
+			patterns: []string{"app/*"},
+			want:     true,
+		},
+		{
+			name:     "tag with hostname",
+			patterns: []string{"registry.example.com/app/nginx:latest"},
+			want:     true,
+		},
+		{
+			name:     "digest",
+			patterns: []string{"app/nginx@" + testDigest("a")},
+			want:     true,
+		},
+		{
+			name:     "other tag",
+			patterns: []string{"app/nginx:1.20", "other/*"},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isProtectedManifest(tt.patterns, "registry.example.com", m))
+		})
+	}
+}
@@ -4,21 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"math"
-	"path"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	awssession "github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/containers/image/v5/docker"
-	imagetypes "github.com/containers/image/v5/types"
 	"github.com/pkg/errors"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
-	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
-	"github.com/replicatedhq/kots/pkg/image"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	kotsadmobjects "github.com/replicatedhq/kots/pkg/kotsadm/objects"
 	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
@@ -46,7 +39,9 @@ func (e AppRollbackError) Error() string {
 	return fmt.Sprintf("app:%s, version:%d", e.AppID, e.Sequence)
 }
 
-func shouldGarbageCollectImages(isKurl bool, kurlRegistryHost string, installParams kotsutil.InstallationParams, registrySettings types.RegistrySettings) bool {
+// shouldGarbageCollectImages only defaults to deleting images from the kurl registry.
+// Any other registry requires "enable-image-deletion" to be explicitly enabled.
+func shouldGarbageCollectImages(isKurlRegistry bool, installParams kotsutil.InstallationParams, registrySettings types.RegistrySettings) bool {
 	if !installParams.EnableImageDeletion {
 		logger.Info("ignoring image garbage collection because image deletion is disabled")
 		return false
@@ -57,13 +52,13 @@ func shouldGarbageCollectImages(isKurl bool, kurlRegistryHost string, installPar
 		return false
 	}
 
-	if !isKurl {
-		logger.Info("ignoring image garbage collection because cluster is not kurl")
+	if registrySettings.Hostname == "" {
+		logger.Info("ignoring image garbage collection because no registry is configured")
 		return false
 	}
 
-	if kurlRegistryHost != registrySettings.Hostname {
-		logger.Info("ignoring image garbage collection because registry is not kurl registry")
+	if !isKurlRegistry && !installParams.ImageDeletionExplicitlyEnabled {
+		logger.Info("ignoring image garbage collection because registry is not kurl registry and image deletion is not explicitly enabled")
 		return false
 	}
 
@@ -71,33 +66,43 @@ func shouldGarbageCollectImages(isKurl bool, kurlRegistryHost string, installPar
 }
 
 func DeleteUnusedImages(appID string, ignoreRollback bool) error {
+	_, err := GarbageCollectImages(appID, types.GarbageCollectOptions{IgnoreRollback: ignoreRollback})
+	return err
+}
+
+// GarbageCollectImages deletes the images in the app's registry that are not referenced by the retained versions of any
+// app using the same registry. The result is nil if garbage collection is not enabled for the registry.
+func GarbageCollectImages(appID string, options types.GarbageCollectOptions) (*types.GarbageCollectResult, error) {
 	installParams, err := kotsutil.GetInstallationParams(kotsadmtypes.KotsadmConfigMap)
 	if err != nil {
-		return errors.Wrap(err, "failed to get app registry info")
+		return nil, errors.Wrap(err, "failed to get app registry info")
 	}
 
 	registrySettings, err := store.GetStore().GetRegistryDetailsForApp(appID)
 	if err != nil {
-		return errors.Wrap(err, "failed to get app registry info")
+		return nil, errors.Wrap(err, "failed to get app registry info")
 	}
 
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
-		return errors.Wrap(err, "failed to get k8s clientset")
+		return nil, errors.Wrap(err, "failed to get k8s clientset")
 	}
 
 	isKurl, err := kurl.IsKurl(clientset)
 	if err != nil {
-		return errors.Wrap(err, "failed to check if cluster is kurl")
+		return nil, errors.Wrap(err, "failed to check if cluster is kurl")
 	}
 
 	kurlRegistryHost, _, _, err := kotsutil.GetKurlRegistryCreds()
 	if err != nil {
-		return errors.Wrap(err, "failed to get kurl registry creds")
+		return nil, errors.Wrap(err, "failed to get kurl registry creds")
 	}
 
-	if !shouldGarbageCollectImages(isKurl, kurlRegistryHost, installParams, registrySettings) {
-		return nil
+	// only the kurl registry can be garbage collected in place, other registries clean up deleted manifests on their own schedule
+	isKurlRegistry := isKurl && kurlRegistryHost == registrySettings.Hostname
+
+	if !shouldGarbageCollectImages(isKurlRegistry, installParams, registrySettings) {
+		return nil, nil
 	}
 
 	// we check all apps here because different apps could share the same images,
@@ -105,7 +110,7 @@ func DeleteUnusedImages(appID string, ignoreRollback bool) error {
 	// so, we also do not delete the images if rollback is enabled for any app.
 	appIDs, err := store.GetStore().GetAppIDsFromRegistry(registrySettings.Hostname)
 	if err != nil {
-		return errors.Wrap(err, "failed to get apps with registry")
+		return nil, errors.Wrap(err, "failed to get apps with registry")
 	}
 
 	activeVersions := []*downstreamtypes.DownstreamVersion{}
@@ -115,18 +120,18 @@ func DeleteUnusedImages(appID string, ignoreRollback bool) error {
 			errors.Wrap(err, "failed to get app")
 		}
 
-		if !ignoreRollback {
+		if !options.IgnoreRollback {
 			// rollback support is detected from the latest available version, not the currently deployed one
 			latestSequence, err := store.GetStore().GetLatestAppSequence(a.ID, true)
 			if err != nil {
-				return errors.Wrap(err, "failed to get latest app sequence")
+				return nil, errors.Wrap(err, "failed to get latest app sequence")
 			}
 			allowRollback, err := store.GetStore().IsRollbackSupportedForVersion(a.ID, latestSequence)
 			if err != nil {
-				return errors.Wrap(err, "failed to check if rollback is supported")
+				return nil, errors.Wrap(err, "failed to check if rollback is supported")
 			}
 			if allowRollback {
-				return AppRollbackError{AppID: a.ID, Sequence: latestSequence}
+				return nil, AppRollbackError{AppID: a.ID, Sequence: latestSequence}
 			}
 		} else {
 			logger.Info("ignoring the fact that rollback is enabled and will continue with the images removal process")
@@ -134,22 +139,28 @@ func DeleteUnusedImages(appID string, ignoreRollback bool) error {
 
 		downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
 		if err != nil {
-			return errors.Wrap(err, "failed to list downstreams for app")
+			return nil, errors.Wrap(err, "failed to list downstreams for app")
 		}
 
 		for _, d := range downstreams {
 			downstreamVersions, err := store.GetStore().GetDownstreamVersions(a.ID, d.ClusterID, false)
 			if err != nil {
-				return errors.Wrapf(err, "failed to get app versions for downstream %s", d.ClusterID)
+				return nil, errors.Wrapf(err, "failed to get app versions for downstream %s", d.ClusterID)
 			}
 
 			// current version already has additional details, get details for pending versions
 			if err := store.GetStore().AddDownstreamVersionsDetails(a.ID, d.ClusterID, downstreamVersions.PendingVersions, false); err != nil {
-				return errors.Wrapf(err, "failed to add details for pending versions for downstream %s", d.ClusterID)
+				return nil, errors.Wrapf(err, "failed to add details for pending versions for downstream %s", d.ClusterID)
+			}
+
+			// past versions are retained so they can be redeployed, their images must stay in the registry
+			if err := store.GetStore().AddDownstreamVersionsDetails(a.ID, d.ClusterID, downstreamVersions.PastVersions, false); err != nil {
+				return nil, errors.Wrapf(err, "failed to add details for past versions for downstream %s", d.ClusterID)
 			}
 
 			activeVersions = append(activeVersions, downstreamVersions.CurrentVersion)
 			activeVersions = append(activeVersions, downstreamVersions.PendingVersions...)
+			activeVersions = append(activeVersions, downstreamVersions.PastVersions...)
 		}
 	}
 
@@ -188,31 +199,40 @@ func DeleteUnusedImages(appID string, ignoreRollback bool) error {
 		}
 	}
 
-	err = deleteUnusedImages(context.Background(), registrySettings, usedImages)
+	options.ProtectedImages = append(installParams.ImageDeletionProtectedImages, options.ProtectedImages...)
+
+	result, err := deleteUnusedImages(context.Background(), registrySettings, usedImages, options, isKurlRegistry)
 	if err != nil {
-		return errors.Wrap(err, "failed to delete unused images")
+		return nil, errors.Wrap(err, "failed to delete unused images")
 	}
 
-	return nil
+	return result, nil
 }
 
-func deleteUnusedImages(ctx context.Context, registry types.RegistrySettings, usedImages []string) (finalError error) {
+func deleteUnusedImages(ctx context.Context, registry types.RegistrySettings, usedImages []string, options types.GarbageCollectOptions, isKurlRegistry bool) (result *types.GarbageCollectResult, finalError error) {
 	if registry.Hostname == "" {
-		return nil
+		return nil, nil
+	}
+
+	client := newDistributionClient(registry)
+
+	// a dry run does not change the registry, so it does not need to wait for or block other runs
+	if options.DryRun {
+		return garbageCollectRegistry(ctx, client, registry, usedImages, options)
 	}
 
 	currentStatus, _, err := store.GetStore().GetTaskStatus(deleteImagesTaskID)
 	if err != nil {
-		return errors.Wrap(err, "failed to get task status")
+		return nil, errors.Wrap(err, "failed to get task status")
 	}
 
 	if currentStatus == "running" {
 		logger.Debugf("%s is already running, not starting a new one", deleteImagesTaskID)
-		return nil
+		return nil, nil
 	}
 
 	if err := store.GetStore().SetTaskStatus(deleteImagesTaskID, "Searching registry...", "running"); err != nil {
-		return errors.Wrap(err, "failed to set task status")
+		return nil, errors.Wrap(err, "failed to set task status")
 	}
 
 	finishedChan := make(chan error)
@@ -223,123 +243,18 @@ func deleteUnusedImages(ctx context.Context, registry types.RegistrySettings, us
 		finishedChan <- finalError
 	}()
 
-	sysCtx := &imagetypes.SystemContext{
-		DockerInsecureSkipTLSVerify: imagetypes.OptionalBoolTrue,
-		DockerDisableV1Ping:         true,
-	}
-	if registry.Username != "" && registry.Password != "" {
-		sysCtx.DockerAuthConfig = &imagetypes.DockerAuthConfig{
-			Username: registry.Username,
-			Password: registry.Password,
-		}
-	}
-
-	searchResult, err := docker.SearchRegistry(ctx, sysCtx, registry.Hostname, "", math.MaxInt32)
+	result, err = garbageCollectRegistry(ctx, client, registry, usedImages, options)
 	if err != nil {
-		return errors.Wrap(err, "failed to search registry")
+		return nil, errors.Wrap(err, "failed to garbage collect registry")
 	}
 
-	digestsInRegistry := map[string]string{}
-	for _, r := range searchResult {
-		// the registry can be shared with other internal or external applications, specially if an external registry is configured.
-		// ONLY delete images from the configured application's registry namespace to avoid deleting non-related user data.
-		parts := strings.Split(r.Name, "/")
-		registryNamespace := ""
-		if len(parts) > 1 {
-			// e.g.: my/namespace/imagename => my/namespace
-			registryNamespace = path.Join(parts[:len(parts)-1]...)
-		}
-		if registryNamespace != registry.Namespace {
-			continue
-		}
-
-		imageName := path.Join(registry.Hostname, r.Name)
-
-		ref, err := docker.ParseReference(fmt.Sprintf("//%s", imageName))
-		if err != nil {
-			logger.Errorf("failed to parse registry image ref %q: %v", imageName, err)
-			continue
-		}
-
-		tags, err := docker.GetRepositoryTags(ctx, sysCtx, ref)
-		if err != nil {
-			logger.Errorf("failed to get repo tags for %q: %v", imageName, err)
-			continue
+	if isKurlRegistry {
+		if err := runGCCommand(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to run garbage collect command")
 		}
-
-		for _, tag := range tags {
-			taggedName := fmt.Sprintf("%s:%s", imageName, tag)
-			taggedRef, err := docker.ParseReference(fmt.Sprintf("//%s", taggedName))
-			if err != nil {
-				logger.Errorf("failed to parse tagged ref %q: %v", taggedName, err)
-				continue
-			}
-
-			digest, err := docker.GetDigest(ctx, sysCtx, taggedRef)
-			if err != nil {
-				if !strings.Contains(err.Error(), "StatusCode: 404") {
-					logger.Errorf("failed to get digest for %q: %v", taggedName, err)
-				} else {
-					logger.Infof("will not delete %q it's not found in registry", taggedName)
-				}
-				continue
-			}
-
-			// Multiple image names can map to the same digest, but we only need to know one to delete the digest.
-			digestsInRegistry[digest.String()] = taggedName
-		}
-	}
-
-	for _, usedImage := range usedImages {
-		registryOptions := registrytypes.RegistryOptions{
-			Endpoint:  registry.Hostname,
-			Namespace: registry.Namespace,
-			Username:  registry.Username,
-			Password:  registry.Password,
-		}
-
-		appImage, err := image.DestImage(registryOptions, usedImage)
-		if err != nil {
-			return errors.Wrapf(err, "failed to get destination image for %s", appImage)
-		}
-
-		appImageRef, err := docker.ParseReference(fmt.Sprintf("//%s", appImage))
-		if err != nil {
-			return errors.Wrapf(err, "failed to parse %s", appImage)
-		}
-
-		digest, err := docker.GetDigest(ctx, sysCtx, appImageRef)
-		if err != nil {
-			if !strings.Contains(err.Error(), "StatusCode: 404") {
-				return errors.Wrapf(err, "failed to get digest for %s", appImage)
-			}
-			logger.Infof("digest not found for image %q", appImage)
-			continue
-		}
-
-		delete(digestsInRegistry, digest.String())
-	}
-
-	for digest, imageName := range digestsInRegistry {
-		logger.Infof("Deleting digest %s for image %s", digest, imageName)
-		ref, err := docker.ParseReference(fmt.Sprintf("//%s", imageName))
-		if err != nil {
-			logger.Infof("failed to parse image ref %q: %v", imageName, err)
-			continue
-		}
-
-		err = ref.DeleteImage(ctx, sysCtx)
-		if err != nil {
-			logger.Infof("failed to delete image %q from registry: %v\n", imageName, err)
-			continue
-		}
-	}
-
-	if err := runGCCommand(ctx); err != nil {
-		return errors.Wrap(err, "failed to run garbage collect command")
 	}
 
-	return nil
+	return result, nil
 }
 
 func startDeleteImagesTaskMonitor(finishedChan <-chan error) {
@@ -9,8 +9,7 @@ import (
 
 func Test_shouldGarbageCollectImages(t *testing.T) {
 	type args struct {
-		isKurl           bool
-		kurlRegistryHost string
+		isKurlRegistry   bool
 		installParams    kotsutil.InstallationParams
 		registrySettings types.RegistrySettings
 	}
@@ -41,9 +40,8 @@ func Test_shouldGarbageCollectImages(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "return false if cluster is not kurl cluster",
+			name: "return false if no registry is configured",
 			args: args{
-				isKurl: false,
 				installParams: kotsutil.InstallationParams{
 					EnableImageDeletion: true,
 				},
@@ -54,10 +52,9 @@ func Test_shouldGarbageCollectImages(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "return false registry is not kurl registry/ when external registry is configured",
+			name: "return false for an external registry when image garbage collection is only enabled by default",
 			args: args{
-				isKurl:           true,
-				kurlRegistryHost: "registry.kurl.sh",
+				isKurlRegistry: false,
 				installParams: kotsutil.InstallationParams{
 					EnableImageDeletion: true,
 				},
@@ -69,10 +66,24 @@ func Test_shouldGarbageCollectImages(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "return true when image garbage collection is enabled",
+			name: "return true for an external registry when image garbage collection is explicitly enabled",
 			args: args{
-				isKurl:           true,
-				kurlRegistryHost: "registry.kurl.sh",
+				isKurlRegistry: false,
+				installParams: kotsutil.InstallationParams{
+					EnableImageDeletion:            true,
+					ImageDeletionExplicitlyEnabled: true,
+				},
+				registrySettings: types.RegistrySettings{
+					IsReadOnly: false,
+					Hostname:   "registry.replicated.com",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "return true for the kurl registry when image garbage collection is enabled",
+			args: args{
+				isKurlRegistry: true,
 				installParams: kotsutil.InstallationParams{
 					EnableImageDeletion: true,
 				},
@@ -86,7 +97,7 @@ func Test_shouldGarbageCollectImages(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := shouldGarbageCollectImages(tt.args.isKurl, tt.args.kurlRegistryHost, tt.args.installParams, tt.args.registrySettings); got != tt.want {
+			if got := shouldGarbageCollectImages(tt.args.isKurlRegistry, tt.args.installParams, tt.args.registrySettings); got != tt.want {
 				t.Errorf("shouldGarbageCollectImages() = %v, want %v", got, tt.want)
 			}
 		})
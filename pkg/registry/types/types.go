@@ -15,3 +15,33 @@ const (
 func (s RegistrySettings) IsValid() bool {
 	return s.Hostname != ""
 }
+
+type GarbageCollectOptions struct {
+	// IgnoreRollback deletes images even if a version allows rollbacks
+	IgnoreRollback bool
+	// DryRun only lists the images that would be deleted
+	DryRun bool
+	// ProtectedImages are patterns for images that are never deleted, see path.Match.
+	// Patterns are matched against the repository, repository:tag and repository@digest, with and without the registry hostname.
+	ProtectedImages []string
+}
+
+// GarbageCollectImage is a manifest in a repository and all the tags that point to it
+type GarbageCollectImage struct {
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest"`
+	Tags       []string `json:"tags"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type GarbageCollectResult struct {
+	DryRun bool `json:"dryRun"`
+	// Deleted are the unreferenced images that were deleted, or would be deleted in a dry run
+	Deleted []GarbageCollectImage `json:"deleted"`
+	// Protected are unreferenced images that match a protected pattern
+	Protected []GarbageCollectImage `json:"protected"`
+	// Failed are unreferenced images that could not be deleted
+	Failed []GarbageCollectImage `json:"failed,omitempty"`
+	// Kept is the number of images that are referenced by an app version
+	Kept int `json:"kept"`
+}
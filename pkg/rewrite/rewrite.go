@@ -23,6 +23,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/upstream"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
+	"github.com/replicatedhq/kots/pkg/util"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 )
 
@@ -247,6 +248,11 @@ func Rewrite(rewriteOptions RewriteOptions) error {
 	writeMidstreamOptions.MidstreamDir = filepath.Join(u.GetOverlaysDir(writeUpstreamOptions), "midstream")
 	writeMidstreamOptions.BaseDir = filepath.Join(u.GetBaseDir(writeUpstreamOptions), commonBase.Path)
 
+	imagePolicy, err := image.GetImagePolicy(clientset, util.PodNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get image policy")
+	}
+
 	processImageOptions := image.ProcessImageOptions{
 		AppSlug:          rewriteOptions.AppSlug,
 		Namespace:        rewriteOptions.K8sNamespace,
@@ -260,6 +266,7 @@ func Rewrite(rewriteOptions RewriteOptions) error {
 		PushImages:       rewriteOptions.RegistrySettings.Hostname != "",
 		CreateAppDir:     false,
 		ReportWriter:     rewriteOptions.ReportWriter,
+		ImagePolicy:      imagePolicy,
 	}
 
 	upstreamDir := u.GetUpstreamDir(writeUpstreamOptions)
@@ -4,6 +4,8 @@ import "time"
 
 type Session struct {
 	ID        string
+	UserID    string
+	Username  string
 	IssuedAt  time.Time
 	ExpiresAt time.Time
 	Roles     []string
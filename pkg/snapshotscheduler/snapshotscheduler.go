@@ -8,6 +8,8 @@ import (
 	"github.com/pkg/errors"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
 	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
 	"github.com/replicatedhq/kots/pkg/logger"
@@ -23,6 +25,14 @@ func Start() error {
 
 	startLoop(appScheduleLoop, 60)
 	startLoop(instanceScheduleLoop, 60)
+	startLoop(retentionLoop, 60*60)
+
+	// workloads can be left scaled down if kotsadm restarted while a backup was running
+	go restoreScaledDownWorkloads()
+	startLoop(operatorHooksLoop, 30)
+
+	// restore verifications do not survive a restart of kotsadm
+	startLoop(restoreVerificationCleanupLoop, 60*60)
 
 	return nil
 }
@@ -50,6 +60,9 @@ func appScheduleLoop() {
 		if err := handleApp(a); err != nil {
 			logger.Error(errors.Wrapf(err, "failed to handle scheduled snapshots for app %s", a.ID))
 		}
+		if err := handleRestoreVerification(a); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to handle scheduled restore verification for app %s", a.ID))
+		}
 	}
 }
 
@@ -67,6 +80,55 @@ func instanceScheduleLoop() {
 	}
 }
 
+func retentionLoop() {
+	appsList, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list installed apps for snapshot retention"))
+		return
+	}
+
+	for _, a := range appsList {
+		if a.RestoreInProgressName != "" {
+			continue
+		}
+		if err := handleAppRetention(a); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to apply snapshot retention policy for app %s", a.ID))
+		}
+	}
+
+	clusters, err := store.GetStore().ListClusters()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list clusters for instance snapshot retention"))
+		return
+	}
+
+	for _, c := range clusters {
+		if err := handleClusterRetention(c); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to apply instance snapshot retention policy for cluster %s", c.ClusterID))
+		}
+	}
+}
+
+// operatorHooksLoop scales the workloads that were scaled down for backups back up once the backups have finished
+func operatorHooksLoop() {
+	if err := snapshot.FinishOperatorHooks(context.Background(), util.PodNamespace); err != nil {
+		logger.Error(errors.Wrap(err, "failed to finish operator snapshot hooks"))
+	}
+}
+
+// restoreVerificationCleanupLoop removes the scratch namespaces and restores of interrupted restore verifications
+func restoreVerificationCleanupLoop() {
+	if err := snapshot.CleanupInterruptedRestoreVerifications(context.Background(), util.PodNamespace); err != nil {
+		logger.Error(errors.Wrap(err, "failed to clean up interrupted restore verifications"))
+	}
+}
+
+func restoreScaledDownWorkloads() {
+	if err := snapshot.RestoreScaledDownWorkloads(context.Background(), util.PodNamespace); err != nil {
+		logger.Error(errors.Wrap(err, "failed to restore scaled down workloads"))
+	}
+}
+
 /* App Level Scheduled Snapshots */
 func handleApp(a *apptypes.App) error {
 	if a.SnapshotSchedule == "" {
@@ -130,6 +192,12 @@ func handleApp(a *apptypes.App) error {
 	}
 	logger.Infof("Created application backup %s from scheduled snapshot %s", backup.ObjectMeta.Name, next.ID)
 
+	events.PublishAppEvent(eventtypes.EventSnapshotStarted, a.ID, a.Slug, -1, map[string]interface{}{
+		"backup":    backup.ObjectMeta.Name,
+		"type":      "application",
+		"scheduled": true,
+	})
+
 	if len(pending) > 1 {
 		err := store.GetStore().DeletePendingScheduledSnapshots(a.ID)
 		if err != nil {
@@ -150,6 +218,148 @@ func handleApp(a *apptypes.App) error {
 	return nil
 }
 
+/* Scheduled Restore Verifications */
+func handleRestoreVerification(a *apptypes.App) error {
+	if a.RestoreVerificationSchedule == "" {
+		return nil
+	}
+
+	cronSchedule, err := cron.ParseStandard(a.RestoreVerificationSchedule)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cron expression")
+	}
+
+	if a.RestoreVerificationNextAt == nil {
+		if err := store.GetStore().SetRestoreVerificationNextAt(a.ID, cronSchedule.Next(time.Now())); err != nil {
+			return errors.Wrap(err, "failed to schedule restore verification")
+		}
+		return nil
+	}
+
+	if a.RestoreVerificationNextAt.After(time.Now()) {
+		logger.Debugf("Not yet time to verify a snapshot restore for app %s", a.ID)
+		return nil
+	}
+
+	backupName, isVerifying, err := latestBackupToVerify(a)
+	if err != nil {
+		return errors.Wrap(err, "failed to find backup to verify")
+	}
+	if isVerifying {
+		logger.Infof("Postponing scheduled restore verification for app %s because one is in progress", a.ID)
+		return nil
+	}
+
+	if backupName != "" {
+		verification, err := snapshot.StartRestoreVerification(context.Background(), util.PodNamespace, a, backupName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to start restore verification of backup %s", backupName)
+		}
+		logger.Infof("Started scheduled restore verification %s of backup %s", verification.RestoreName, backupName)
+	} else {
+		logger.Infof("Skipping scheduled restore verification for app %s because it does not have a completed backup", a.ID)
+	}
+
+	if err := store.GetStore().SetRestoreVerificationNextAt(a.ID, cronSchedule.Next(time.Now())); err != nil {
+		return errors.Wrap(err, "failed to schedule next restore verification")
+	}
+
+	return nil
+}
+
+// latestBackupToVerify returns the name of the most recent completed backup that includes the app,
+// or an empty string if there is none. isVerifying is true if one of the app's backups is being verified.
+func latestBackupToVerify(a *apptypes.App) (name string, isVerifying bool, err error) {
+	appBackups, err := snapshot.ListBackupsForApp(context.Background(), util.PodNamespace, a.ID)
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to list app backups")
+	}
+
+	instanceBackups, err := snapshot.ListInstanceBackups(context.Background(), util.PodNamespace)
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to list instance backups")
+	}
+
+	backups := appBackups
+	for _, b := range instanceBackups {
+		for _, includedApp := range b.IncludedApps {
+			if includedApp.Slug == a.Slug {
+				backups = append(backups, b)
+				break
+			}
+		}
+	}
+
+	var latest *snapshottypes.Backup
+	for _, b := range backups {
+		if snapshot.IsRestoreVerificationRunning(b.RestoreVerification) {
+			return "", true, nil
+		}
+		if b.Status != "Completed" || b.StartedAt == nil {
+			continue
+		}
+		if latest == nil || b.StartedAt.After(*latest.StartedAt) {
+			latest = b
+		}
+	}
+
+	if latest == nil {
+		return "", false, nil
+	}
+	return latest.Name, false, nil
+}
+
+/* Snapshot Retention */
+func handleAppRetention(a *apptypes.App) error {
+	policy, err := store.GetStore().GetSnapshotRetentionPolicy(a.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get snapshot retention policy")
+	}
+	if policy == nil {
+		return nil
+	}
+
+	preview, err := snapshot.PreviewAppRetention(context.Background(), util.PodNamespace, a.ID, *policy)
+	if err != nil {
+		return errors.Wrap(err, "failed to evaluate snapshot retention policy")
+	}
+
+	pruned, err := snapshot.PruneBackups(context.Background(), util.PodNamespace, preview)
+	if pruned > 0 {
+		logger.Infof("Pruned %d application backups of app %s by retention policy", pruned, a.ID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to prune backups")
+	}
+
+	return nil
+}
+
+func handleClusterRetention(c *downstreamtypes.Downstream) error {
+	policy, err := store.GetStore().GetInstanceSnapshotRetentionPolicy(c.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get instance snapshot retention policy")
+	}
+	if policy == nil {
+		return nil
+	}
+
+	preview, err := snapshot.PreviewInstanceRetention(context.Background(), util.PodNamespace, *policy)
+	if err != nil {
+		return errors.Wrap(err, "failed to evaluate instance snapshot retention policy")
+	}
+
+	pruned, err := snapshot.PruneBackups(context.Background(), util.PodNamespace, preview)
+	if pruned > 0 {
+		logger.Infof("Pruned %d instance backups by retention policy", pruned)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to prune instance backups")
+	}
+
+	return nil
+}
+
 /* Cluster/Instance Level Scheduled Snapshots */
 func handleCluster(c *downstreamtypes.Downstream) error {
 	if c.SnapshotSchedule == "" {
@@ -213,6 +423,15 @@ func handleCluster(c *downstreamtypes.Downstream) error {
 	}
 	logger.Infof("Created instance backup %s from scheduled instance snapshot %s", backup.ObjectMeta.Name, next.ID)
 
+	events.Publish(eventtypes.Event{
+		Type: eventtypes.EventSnapshotStarted,
+		Data: map[string]interface{}{
+			"backup":    backup.ObjectMeta.Name,
+			"type":      "instance",
+			"scheduled": true,
+		},
+	})
+
 	if len(pending) > 1 {
 		err := store.GetStore().DeletePendingScheduledInstanceSnapshots(c.ClusterID)
 		if err != nil {
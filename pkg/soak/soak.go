@@ -0,0 +1,109 @@
+package soak
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+	"github.com/replicatedhq/kots/pkg/store"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+)
+
+func ValidatePolicy(policy soaktypes.Policy) error {
+	if policy.MinDays < 0 {
+		return errors.New("min days cannot be negative")
+	}
+	if policy.MinDays == 0 && len(policy.ApprovingInstances) == 0 {
+		return errors.New("min days or at least one approving instance is required")
+	}
+	for _, name := range policy.ApprovingInstances {
+		if name == "" {
+			return errors.New("approving instance names cannot be empty")
+		}
+	}
+	return nil
+}
+
+// IsSoaked returns true if the version can be auto-deployed under the policy. The release time of versions without an
+// upstream release time is unknown, so they have only soaked once they are approved by an approving instance.
+func IsSoaked(policy soaktypes.Policy, approved []soaktypes.ApprovedVersions, v *downstreamtypes.DownstreamVersion, now time.Time) bool {
+	if policy.MinDays > 0 && v.UpstreamReleasedAt != nil {
+		if !v.UpstreamReleasedAt.After(now.AddDate(0, 0, -policy.MinDays)) {
+			return true
+		}
+	}
+
+	for _, a := range approved {
+		if !isApprovingInstance(policy, a.InstanceName) {
+			continue
+		}
+		for _, approvedVersion := range a.Versions {
+			if approvedVersion.ChannelID == v.ChannelID && approvedVersion.UpdateCursor == v.UpdateCursor {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ExportApprovedVersions lists the upstream releases that were deployed successfully on this instance,
+// to be imported by instances that use instanceName as an approving instance
+func ExportApprovedVersions(appID string, instanceName string) (*soaktypes.ApprovedVersions, error) {
+	a, err := store.GetStore().GetApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app")
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list downstreams")
+	}
+	if len(downstreams) == 0 {
+		return nil, errors.Errorf("no downstreams found for app %s", a.Slug)
+	}
+
+	versions, err := store.GetStore().GetDownstreamVersions(appID, downstreams[0].ClusterID, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get downstream versions")
+	}
+
+	approved := &soaktypes.ApprovedVersions{
+		InstanceName: instanceName,
+		AppSlug:      a.Slug,
+		ExportedAt:   time.Now(),
+		Versions:     []soaktypes.ApprovedVersion{},
+	}
+
+	// versions created by config or license changes share the release of their upstream version
+	seen := map[string]bool{}
+	for _, v := range versions.AllVersions {
+		if v.Status != storetypes.VersionDeployed || v.UpdateCursor == "" {
+			continue
+		}
+		key := v.ChannelID + "/" + v.UpdateCursor
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		approved.Versions = append(approved.Versions, soaktypes.ApprovedVersion{
+			VersionLabel: v.VersionLabel,
+			ChannelID:    v.ChannelID,
+			UpdateCursor: v.UpdateCursor,
+			DeployedAt:   v.DeployedAt,
+		})
+	}
+
+	return approved, nil
+}
+
+func isApprovingInstance(policy soaktypes.Policy, instanceName string) bool {
+	for _, name := range policy.ApprovingInstances {
+		if name == instanceName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package soak
+
+import (
+	"testing"
+	"time"
+
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePolicy(t *testing.T) {
+	assert.NoError(t, ValidatePolicy(soaktypes.Policy{MinDays: 7}))
+	assert.NoError(t, ValidatePolicy(soaktypes.Policy{ApprovingInstances: []string{"staging"}}))
+	assert.Error(t, ValidatePolicy(soaktypes.Policy{}))
+	assert.Error(t, ValidatePolicy(soaktypes.Policy{MinDays: -1}))
+	assert.Error(t, ValidatePolicy(soaktypes.Policy{ApprovingInstances: []string{""}}))
+}
+
+func TestIsSoaked(t *testing.T) {
+	now := time.Date(2022, 6, 15, 12, 0, 0, 0, time.UTC)
+	releasedAt := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	approved := []soaktypes.ApprovedVersions{
+		{
+			InstanceName: "staging",
+			Versions: []soaktypes.ApprovedVersion{
+				{VersionLabel: "1.2.0", ChannelID: "stable", UpdateCursor: "12"},
+			},
+		},
+		{
+			InstanceName: "dev",
+			Versions: []soaktypes.ApprovedVersion{
+				{VersionLabel: "1.3.0", ChannelID: "stable", UpdateCursor: "13"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		policy  soaktypes.Policy
+		version downstreamtypes.DownstreamVersion
+		want    bool
+	}{
+		{
+			name:    "released long enough ago",
+			policy:  soaktypes.Policy{MinDays: 7},
+			version: downstreamtypes.DownstreamVersion{UpstreamReleasedAt: releasedAt(7)},
+			want:    true,
+		},
+		{
+			name:    "released too recently",
+			policy:  soaktypes.Policy{MinDays: 7},
+			version: downstreamtypes.DownstreamVersion{UpstreamReleasedAt: releasedAt(6)},
+			want:    false,
+		},
+		{
+			name:    "unknown release time does not use the download time",
+			policy:  soaktypes.Policy{MinDays: 7},
+			version: downstreamtypes.DownstreamVersion{CreatedOn: releasedAt(8)},
+			want:    false,
+		},
+		{
+			name:    "unknown release time approved by an approving instance",
+			policy:  soaktypes.Policy{MinDays: 7, ApprovingInstances: []string{"staging"}},
+			version: downstreamtypes.DownstreamVersion{ChannelID: "stable", UpdateCursor: "12", CreatedOn: releasedAt(8)},
+			want:    true,
+		},
+		{
+			name:    "approved by an approving instance",
+			policy:  soaktypes.Policy{MinDays: 7, ApprovingInstances: []string{"staging"}},
+			version: downstreamtypes.DownstreamVersion{ChannelID: "stable", UpdateCursor: "12", UpstreamReleasedAt: releasedAt(1)},
+			want:    true,
+		},
+		{
+			name:    "approved by an instance that is not trusted",
+			policy:  soaktypes.Policy{ApprovingInstances: []string{"staging"}},
+			version: downstreamtypes.DownstreamVersion{ChannelID: "stable", UpdateCursor: "13", UpstreamReleasedAt: releasedAt(30)},
+			want:    false,
+		},
+		{
+			name:    "same cursor on another channel",
+			policy:  soaktypes.Policy{ApprovingInstances: []string{"staging"}},
+			version: downstreamtypes.DownstreamVersion{ChannelID: "beta", UpdateCursor: "12"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsSoaked(tt.policy, approved, &tt.version, now))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"time"
+)
+
+// Policy holds new versions back from auto-deploy until they have soaked. A version can be auto-deployed once it
+// has been available upstream for MinDays, or once one of the approving instances has deployed it successfully.
+type Policy struct {
+	// MinDays is the number of days a version has to be available upstream
+	MinDays int `json:"minDays"`
+	// ApprovingInstances are the names of the instances whose approved versions are trusted
+	ApprovingInstances []string `json:"approvingInstances,omitempty"`
+}
+
+// ApprovedVersions is exported from an instance and lists the versions of an app that were deployed successfully there
+type ApprovedVersions struct {
+	InstanceName string            `json:"instanceName"`
+	AppSlug      string            `json:"appSlug"`
+	ExportedAt   time.Time         `json:"exportedAt"`
+	Versions     []ApprovedVersion `json:"versions"`
+}
+
+// ApprovedVersion identifies an upstream release by its channel and cursor, which are the same on every instance
+type ApprovedVersion struct {
+	VersionLabel string     `json:"versionLabel"`
+	ChannelID    string     `json:"channelId"`
+	UpdateCursor string     `json:"updateCursor"`
+	DeployedAt   *time.Time `json:"deployedAt,omitempty"`
+}
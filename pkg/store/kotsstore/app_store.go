@@ -1,6 +1,7 @@
 package kotsstore
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -146,7 +147,7 @@ func (s *KOTSStore) GetAppIDFromSlug(slug string) (string, error) {
 
 func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	db := persistence.MustGetDBSession()
-	query := `select id, name, license, upstream_uri, icon_uri, created_at, updated_at, slug, current_sequence, last_update_check_at, last_license_sync, is_airgap, snapshot_ttl_new, snapshot_schedule, restore_in_progress_name, restore_undeploy_status, update_checker_spec, semver_auto_deploy, install_state, channel_changed from app where id = ?`
+	query := `select id, name, license, upstream_uri, icon_uri, created_at, updated_at, slug, current_sequence, last_update_check_at, last_license_sync, is_airgap, snapshot_ttl_new, snapshot_schedule, restore_verification_schedule, restore_verification_next_at, restore_in_progress_name, restore_undeploy_status, update_checker_spec, semver_auto_deploy, maintenance_window, install_state, channel_changed from app where id = ?`
 	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
 		Query:     query,
 		Arguments: []interface{}{id},
@@ -169,12 +170,15 @@ func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	var lastLicenseSync gorqlite.NullTime
 	var snapshotTTLNew gorqlite.NullString
 	var snapshotSchedule gorqlite.NullString
+	var restoreVerificationSchedule gorqlite.NullString
+	var restoreVerificationNextAt gorqlite.NullInt64
 	var restoreInProgressName gorqlite.NullString
 	var restoreUndeployStatus gorqlite.NullString
 	var updateCheckerSpec gorqlite.NullString
 	var autoDeploy gorqlite.NullString
+	var maintenanceWindow gorqlite.NullString
 
-	if err := rows.Scan(&app.ID, &app.Name, &licenseStr, &upstreamURI, &iconURI, &app.CreatedAt, &updatedAt, &app.Slug, &currentSequence, &lastUpdateCheckAt, &lastLicenseSync, &app.IsAirgap, &snapshotTTLNew, &snapshotSchedule, &restoreInProgressName, &restoreUndeployStatus, &updateCheckerSpec, &autoDeploy, &app.InstallState, &app.ChannelChanged); err != nil {
+	if err := rows.Scan(&app.ID, &app.Name, &licenseStr, &upstreamURI, &iconURI, &app.CreatedAt, &updatedAt, &app.Slug, &currentSequence, &lastUpdateCheckAt, &lastLicenseSync, &app.IsAirgap, &snapshotTTLNew, &snapshotSchedule, &restoreVerificationSchedule, &restoreVerificationNextAt, &restoreInProgressName, &restoreUndeployStatus, &updateCheckerSpec, &autoDeploy, &maintenanceWindow, &app.InstallState, &app.ChannelChanged); err != nil {
 		return nil, errors.Wrap(err, "failed to scan app")
 	}
 
@@ -183,11 +187,24 @@ func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	app.IconURI = iconURI.String
 	app.SnapshotTTL = snapshotTTLNew.String
 	app.SnapshotSchedule = snapshotSchedule.String
+	app.RestoreVerificationSchedule = restoreVerificationSchedule.String
 	app.RestoreInProgressName = restoreInProgressName.String
 	app.RestoreUndeployStatus = apptypes.UndeployStatus(restoreUndeployStatus.String)
 	app.UpdateCheckerSpec = updateCheckerSpec.String
 	app.AutoDeploy = apptypes.AutoDeploy(autoDeploy.String)
 
+	if maintenanceWindow.String != "" {
+		app.MaintenanceWindow = &apptypes.MaintenanceWindow{}
+		if err := json.Unmarshal([]byte(maintenanceWindow.String), app.MaintenanceWindow); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal maintenance window")
+		}
+	}
+
+	if restoreVerificationNextAt.Valid {
+		nextAt := time.Unix(restoreVerificationNextAt.Int64, 0)
+		app.RestoreVerificationNextAt = &nextAt
+	}
+
 	if lastLicenseSync.Valid {
 		app.LastLicenseSync = lastLicenseSync.Time.Format(time.RFC3339)
 	}
@@ -486,6 +503,33 @@ func (s *KOTSStore) SetAutoDeploy(appID string, autoDeploy apptypes.AutoDeploy)
 	return nil
 }
 
+// SetMaintenanceWindow sets the window during which versions are auto-deployed. A nil window allows auto-deploys at any time.
+func (s *KOTSStore) SetMaintenanceWindow(appID string, maintenanceWindow *apptypes.MaintenanceWindow) error {
+	logger.Debug("setting maintenance window",
+		zap.String("appID", appID))
+
+	var marshalledWindow interface{}
+	if maintenanceWindow != nil {
+		b, err := json.Marshal(maintenanceWindow)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal maintenance window")
+		}
+		marshalledWindow = string(b)
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set maintenance_window = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalledWindow, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
 func (s *KOTSStore) SetSnapshotTTL(appID string, snapshotTTL string) error {
 	logger.Debug("Setting snapshot TTL",
 		zap.String("appID", appID))
@@ -520,6 +564,49 @@ func (s *KOTSStore) SetSnapshotSchedule(appID string, snapshotSchedule string) e
 	return nil
 }
 
+// SetRestoreVerificationSchedule sets the cron spec for verifying snapshot restores and when the next verification runs.
+// Scheduled verifications are disabled when the schedule is empty.
+func (s *KOTSStore) SetRestoreVerificationSchedule(appID string, schedule string, nextAt *time.Time) error {
+	logger.Debug("Setting restore verification schedule",
+		zap.String("appID", appID))
+
+	var nextAtUnix interface{}
+	if schedule != "" && nextAt != nil {
+		nextAtUnix = nextAt.Unix()
+	}
+
+	var scheduleStr interface{}
+	if schedule != "" {
+		scheduleStr = schedule
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set restore_verification_schedule = ?, restore_verification_next_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{scheduleStr, nextAtUnix, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) SetRestoreVerificationNextAt(appID string, nextAt time.Time) error {
+	db := persistence.MustGetDBSession()
+	query := `update app set restore_verification_next_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{nextAt.Unix(), appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
 func (s *KOTSStore) RemoveApp(appID string) error {
 	logger.Debug("Removing app",
 		zap.String("appID", appID))
@@ -564,6 +651,16 @@ func (s *KOTSStore) RemoveApp(appID string) error {
 		Arguments: []interface{}{appID},
 	})
 
+	statements = append(statements, gorqlite.ParameterizedStatement{
+		Query:     "delete from app_approved_versions where app_id = ?",
+		Arguments: []interface{}{appID},
+	})
+
+	statements = append(statements, gorqlite.ParameterizedStatement{
+		Query:     "delete from app_update_check where app_id = ?",
+		Arguments: []interface{}{appID},
+	})
+
 	statements = append(statements, gorqlite.ParameterizedStatement{
 		Query:     "delete from app where id = ?",
 		Arguments: []interface{}{appID},
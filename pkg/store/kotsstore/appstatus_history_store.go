@@ -0,0 +1,183 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+)
+
+func (s *KOTSStore) AddAppStatusHistoryEntry(entry appstatushistorytypes.Entry) error {
+	marshalledResourceStates, err := json.Marshal(entry.ResourceStates)
+	if err != nil {
+		return errors.Wrap(err, "failed to json marshal resource states")
+	}
+
+	if entry.ID == "" {
+		entry.ID = ksuid.New().String()
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `insert into app_status_history (id, app_id, state, resource_states, sequence, created_at) values (?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{entry.ID, entry.AppID, string(entry.State), string(marshalledResourceStates), entry.Sequence, entry.CreatedAt.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListAppStatusHistory returns the status transitions of the app between since and until, oldest first
+func (s *KOTSStore) ListAppStatusHistory(appID string, since time.Time, until time.Time) ([]appstatushistorytypes.Entry, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, app_id, state, resource_states, sequence, created_at from app_status_history
+	where app_id = ? and created_at >= ? and created_at <= ?
+	order by created_at asc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, since.Unix(), until.Unix()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	entries := []appstatushistorytypes.Entry{}
+	for rows.Next() {
+		entry, err := appStatusHistoryEntryFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get entry from row")
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// GetLatestAppStatusHistoryEntryBefore returns the last status transition of the app before the given time, or nil if there is none
+func (s *KOTSStore) GetLatestAppStatusHistoryEntryBefore(appID string, before time.Time) (*appstatushistorytypes.Entry, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, app_id, state, resource_states, sequence, created_at from app_status_history
+	where app_id = ? and created_at < ?
+	order by created_at desc limit 1`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, before.Unix()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	entry, err := appStatusHistoryEntryFromRow(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get entry from row")
+	}
+
+	return entry, nil
+}
+
+// DeleteAppStatusHistoryBefore deletes the status transitions before the given time, except for the latest one of each app.
+// The latest transition is the state of the app at the given time, which the uptime of later periods is computed from.
+func (s *KOTSStore) DeleteAppStatusHistoryBefore(before time.Time) error {
+	db := persistence.MustGetDBSession()
+	query := `select id, app_id from app_status_history where created_at < ? order by created_at desc, id desc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{before.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	entries := []appstatushistorytypes.Entry{}
+	for rows.Next() {
+		entry := appstatushistorytypes.Entry{}
+		if err := rows.Scan(&entry.ID, &entry.AppID); err != nil {
+			return errors.Wrap(err, "failed to scan")
+		}
+		entries = append(entries, entry)
+	}
+
+	statements := []gorqlite.ParameterizedStatement{}
+	ids := staleAppStatusHistoryIDs(entries)
+	for start := 0; start < len(ids); start += appStatusHistoryDeleteBatchSize {
+		end := start + appStatusHistoryDeleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		args := []interface{}{}
+		for _, id := range ids[start:end] {
+			args = append(args, id)
+		}
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     fmt.Sprintf("delete from app_status_history where id in (%s)", strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")),
+			Arguments: args,
+		})
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+
+	if wrs, err := db.WriteParameterized(statements); err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
+	}
+
+	return nil
+}
+
+const appStatusHistoryDeleteBatchSize = 100
+
+// staleAppStatusHistoryIDs returns the ids of all entries but the first one of each app. Entries are newest first.
+func staleAppStatusHistoryIDs(entries []appstatushistorytypes.Entry) []string {
+	ids := []string{}
+	latestKept := map[string]bool{}
+	for _, entry := range entries {
+		if !latestKept[entry.AppID] {
+			latestKept[entry.AppID] = true
+			continue
+		}
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func appStatusHistoryEntryFromRow(row gorqlite.QueryResult) (*appstatushistorytypes.Entry, error) {
+	entry := appstatushistorytypes.Entry{}
+
+	var state string
+	var resourceStatesStr gorqlite.NullString
+	var sequence gorqlite.NullInt64
+
+	if err := row.Scan(&entry.ID, &entry.AppID, &state, &resourceStatesStr, &sequence, &entry.CreatedAt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	entry.State = appstatetypes.State(state)
+	entry.Sequence = sequence.Int64
+	entry.ResourceStates = appstatetypes.ResourceStates{}
+
+	if resourceStatesStr.Valid && resourceStatesStr.String != "" {
+		if err := json.Unmarshal([]byte(resourceStatesStr.String), &entry.ResourceStates); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal resource states")
+		}
+	}
+
+	return &entry, nil
+}
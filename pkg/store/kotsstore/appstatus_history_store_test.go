@@ -0,0 +1,45 @@
+package kotsstore
+
+import (
+	"testing"
+
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_staleAppStatusHistoryIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []appstatushistorytypes.Entry
+		want    []string
+	}{
+		{
+			name:    "no entries",
+			entries: []appstatushistorytypes.Entry{},
+			want:    []string{},
+		},
+		{
+			name: "the only entry of an app is kept",
+			entries: []appstatushistorytypes.Entry{
+				{ID: "a-1", AppID: "a"},
+			},
+			want: []string{},
+		},
+		{
+			name: "the latest entry of each app is kept",
+			entries: []appstatushistorytypes.Entry{
+				{ID: "a-3", AppID: "a"},
+				{ID: "b-2", AppID: "b"},
+				{ID: "a-2", AppID: "a"},
+				{ID: "b-1", AppID: "b"},
+				{ID: "a-1", AppID: "a"},
+			},
+			want: []string{"a-2", "b-1", "a-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, staleAppStatusHistoryIDs(tt.entries))
+		})
+	}
+}
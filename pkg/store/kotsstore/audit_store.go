@@ -0,0 +1,125 @@
+package kotsstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+)
+
+// CreateAuditEvent appends an event to the audit log. There is intentionally no way to update or delete events.
+func (s *KOTSStore) CreateAuditEvent(event audittypes.Event) error {
+	db := persistence.MustGetDBSession()
+
+	var sequence interface{}
+	if event.Sequence != nil {
+		sequence = *event.Sequence
+	}
+
+	query := `insert into kotsadm_audit_event (id, created_at, user_id, username, session_id, method, path, route, resource, app_slug, sequence, outcome, status_code, summary) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+		Arguments: []interface{}{
+			event.ID,
+			event.CreatedAt.Unix(),
+			event.UserID,
+			event.Username,
+			event.SessionID,
+			event.Method,
+			event.Path,
+			event.Route,
+			event.Resource,
+			event.AppSlug,
+			sequence,
+			string(event.Outcome),
+			event.StatusCode,
+			event.Summary,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents returns the events matching the options, newest first
+func (s *KOTSStore) ListAuditEvents(opts audittypes.ListOptions) ([]audittypes.Event, error) {
+	db := persistence.MustGetDBSession()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if opts.AppSlug != "" {
+		conditions = append(conditions, "app_slug = ?")
+		args = append(args, opts.AppSlug)
+	}
+	if opts.Username != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, opts.Username)
+	}
+	if opts.Since != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, opts.Since.Unix())
+	}
+	if opts.Until != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, opts.Until.Unix())
+	}
+
+	query := `select id, created_at, user_id, username, session_id, method, path, route, resource, app_slug, sequence, outcome, status_code, summary from kotsadm_audit_event`
+	if len(conditions) > 0 {
+		query = fmt.Sprintf("%s where %s", query, strings.Join(conditions, " and "))
+	}
+	query = fmt.Sprintf("%s order by created_at desc, id desc", query)
+	if opts.Limit > 0 {
+		query = fmt.Sprintf("%s limit %d", query, opts.Limit)
+	}
+
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: args,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	events := []audittypes.Event{}
+	for rows.Next() {
+		var userID gorqlite.NullString
+		var username gorqlite.NullString
+		var sessionID gorqlite.NullString
+		var route gorqlite.NullString
+		var resource gorqlite.NullString
+		var appSlug gorqlite.NullString
+		var sequence gorqlite.NullInt64
+		var outcome string
+		var statusCode int64
+		var summary gorqlite.NullString
+
+		event := audittypes.Event{}
+		if err := rows.Scan(&event.ID, &event.CreatedAt, &userID, &username, &sessionID, &event.Method, &event.Path, &route, &resource, &appSlug, &sequence, &outcome, &statusCode, &summary); err != nil {
+			return nil, errors.Wrap(err, "failed to scan audit event")
+		}
+
+		event.UserID = userID.String
+		event.Username = username.String
+		event.SessionID = sessionID.String
+		event.Route = route.String
+		event.Resource = resource.String
+		event.AppSlug = appSlug.String
+		event.Outcome = audittypes.Outcome(outcome)
+		event.StatusCode = int(statusCode)
+		event.Summary = summary.String
+
+		if sequence.Valid {
+			event.Sequence = &sequence.Int64
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -2,6 +2,7 @@ package kotsstore
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	"github.com/replicatedhq/kots/pkg/cursor"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/persistence"
@@ -143,6 +145,12 @@ func (s *KOTSStore) MarkAsCurrentDownstreamVersion(appID string, sequence int64)
 		Arguments: []interface{}{time.Now().Unix(), sequence, appID},
 	})
 
+	// a version waiting for a maintenance window is no longer pending once it or a newer version is deployed
+	statements = append(statements, gorqlite.ParameterizedStatement{
+		Query:     `update app_downstream_version set auto_deploy_scheduled_at = null where app_id = ? and sequence <= ? and auto_deploy_scheduled_at is not null`,
+		Arguments: []interface{}{appID, sequence},
+	})
+
 	if wrs, err := db.WriteParameterized(statements); err != nil {
 		wrErrs := []error{}
 		for _, wr := range wrs {
@@ -261,6 +269,11 @@ func (s *KOTSStore) GetCurrentDownstreamVersion(appID string, clusterID string)
 	adv.preflight_skipped,
 	adv.git_commit_url,
 	adv.git_deployable,
+	adv.git_pr_number,
+	adv.git_pr_url,
+	adv.git_pr_branch,
+	adv.git_pr_state,
+	adv.auto_deploy_scheduled_at,
 	ado.is_error,
 	av.upstream_released_at,
 	av.version_label,
@@ -356,6 +369,11 @@ func (s *KOTSStore) GetDownstreamVersions(appID string, clusterID string, downlo
 	adv.preflight_skipped,
 	adv.git_commit_url,
 	adv.git_deployable,
+	adv.git_pr_number,
+	adv.git_pr_url,
+	adv.git_pr_branch,
+	adv.git_pr_state,
+	adv.auto_deploy_scheduled_at,
 	ado.is_error,
 	av.upstream_released_at,
 	av.version_label,
@@ -766,6 +784,11 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 	var preflightSkipped gorqlite.NullBool
 	var commitURL gorqlite.NullString
 	var gitDeployable gorqlite.NullBool
+	var prNumber gorqlite.NullInt64
+	var prURL gorqlite.NullString
+	var prBranch gorqlite.NullString
+	var prState gorqlite.NullString
+	var autoDeployScheduledAt gorqlite.NullInt64
 	var hasError gorqlite.NullBool
 	var upstreamReleasedAt gorqlite.NullTime
 
@@ -779,6 +802,11 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 		&preflightSkipped,
 		&commitURL,
 		&gitDeployable,
+		&prNumber,
+		&prURL,
+		&prBranch,
+		&prState,
+		&autoDeployScheduledAt,
 		&hasError,
 		&upstreamReleasedAt,
 		&versionLabel,
@@ -817,6 +845,20 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 	v.CommitURL = commitURL.String
 	v.GitDeployable = gitDeployable.Bool
 
+	if prNumber.Valid {
+		v.PullRequest = &gitopstypes.PullRequest{
+			Number: prNumber.Int64,
+			URL:    prURL.String,
+			Branch: prBranch.String,
+			State:  gitopstypes.PullRequestState(prState.String),
+		}
+	}
+
+	if autoDeployScheduledAt.Valid {
+		scheduledAt := time.Unix(autoDeployScheduledAt.Int64, 0)
+		v.AutoDeployScheduledAt = &scheduledAt
+	}
+
 	if upstreamReleasedAt.Valid {
 		v.UpstreamReleasedAt = &upstreamReleasedAt.Time
 	}
@@ -1046,7 +1088,8 @@ func (s *KOTSStore) GetDownstreamOutput(appID string, clusterID string, sequence
 	ado.apply_stdout,
 	ado.apply_stderr,
 	ado.helm_stdout,
-	ado.helm_stderr
+	ado.helm_stderr,
+	ado.resource_results
 FROM
 	app_downstream_version adv
 LEFT JOIN
@@ -1077,8 +1120,9 @@ WHERE
 	var applyStderr gorqlite.NullString
 	var helmStdout gorqlite.NullString
 	var helmStderr gorqlite.NullString
+	var resourceResults gorqlite.NullString
 
-	if err := rows.Scan(&status, &statusInfo, &dryrunStdout, &dryrunStderr, &applyStdout, &applyStderr, &helmStdout, &helmStderr); err != nil {
+	if err := rows.Scan(&status, &statusInfo, &dryrunStdout, &dryrunStderr, &applyStdout, &applyStderr, &helmStdout, &helmStderr, &resourceResults); err != nil {
 		return nil, errors.Wrap(err, "failed to select downstream")
 	}
 
@@ -1133,6 +1177,12 @@ WHERE
 		RenderError:  string(renderError),
 	}
 
+	if resourceResults.String != "" {
+		if err := json.Unmarshal([]byte(resourceResults.String), &output.ResourceResults); err != nil {
+			logger.Error(errors.Wrap(err, "failed to unmarshal resource results"))
+		}
+	}
+
 	return output, nil
 }
 
@@ -1165,14 +1215,23 @@ func (s *KOTSStore) IsDownstreamDeploySuccessful(appID string, clusterID string,
 func (s *KOTSStore) UpdateDownstreamDeployStatus(appID string, clusterID string, sequence int64, isError bool, output downstreamtypes.DownstreamOutput) error {
 	db := persistence.MustGetDBSession()
 
-	query := `insert into app_downstream_output (app_id, cluster_id, downstream_sequence, is_error, dryrun_stdout, dryrun_stderr, apply_stdout, apply_stderr, helm_stdout, helm_stderr)
-	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) on conflict (app_id, cluster_id, downstream_sequence) do update set is_error = EXCLUDED.is_error,
+	resourceResults := ""
+	if len(output.ResourceResults) > 0 {
+		b, err := json.Marshal(output.ResourceResults)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal resource results")
+		}
+		resourceResults = string(b)
+	}
+
+	query := `insert into app_downstream_output (app_id, cluster_id, downstream_sequence, is_error, dryrun_stdout, dryrun_stderr, apply_stdout, apply_stderr, helm_stdout, helm_stderr, resource_results)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) on conflict (app_id, cluster_id, downstream_sequence) do update set is_error = EXCLUDED.is_error,
 	dryrun_stdout = EXCLUDED.dryrun_stdout, dryrun_stderr = EXCLUDED.dryrun_stderr, apply_stdout = EXCLUDED.apply_stdout, apply_stderr = EXCLUDED.apply_stderr,
-	helm_stdout = EXCLUDED.helm_stdout, helm_stderr = EXCLUDED.helm_stderr`
+	helm_stdout = EXCLUDED.helm_stdout, helm_stderr = EXCLUDED.helm_stderr, resource_results = EXCLUDED.resource_results`
 
 	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
 		Query:     query,
-		Arguments: []interface{}{appID, clusterID, sequence, isError, output.DryrunStdout, output.DryrunStderr, output.ApplyStdout, output.ApplyStderr, output.HelmStdout, output.HelmStderr},
+		Arguments: []interface{}{appID, clusterID, sequence, isError, output.DryrunStdout, output.DryrunStderr, output.ApplyStdout, output.ApplyStderr, output.HelmStdout, output.HelmStderr, resourceResults},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
@@ -1196,3 +1255,162 @@ func (s *KOTSStore) DeleteDownstreamDeployStatus(appID string, clusterID string,
 
 	return nil
 }
+
+// GetLatestGitOpsCommittedParentSequence returns the parent sequence of the latest downstream version that is in the
+// gitops branch, which is a version that was committed to it or whose pull request was merged. Returns -1 if there is none.
+func (s *KOTSStore) GetLatestGitOpsCommittedParentSequence(appID string, clusterID string) (int64, error) {
+	db := persistence.MustGetDBSession()
+	query := `select parent_sequence from app_downstream_version
+	where app_id = ? and cluster_id = ? and git_commit_url is not null and git_commit_url != ''
+	and (git_pr_number is null or git_pr_state = ?)
+	order by sequence desc limit 1`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, clusterID, string(gitopstypes.PullRequestStateMerged)},
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return -1, nil
+	}
+
+	var parentSequence gorqlite.NullInt64
+	if err := rows.Scan(&parentSequence); err != nil {
+		return -1, errors.Wrap(err, "failed to scan")
+	}
+
+	if !parentSequence.Valid {
+		return -1, nil
+	}
+
+	return parentSequence.Int64, nil
+}
+
+// SetDownstreamVersionGitOpsCommit records a commit made for a downstream version after the version was created
+func (s *KOTSStore) SetDownstreamVersionGitOpsCommit(appID string, clusterID string, sequence int64, commitURL string, pullRequest *gitopstypes.PullRequest) error {
+	var prNumber, prURL, prBranch, prState interface{}
+	if pullRequest != nil {
+		prNumber, prURL, prBranch, prState = pullRequest.Number, pullRequest.URL, pullRequest.Branch, string(pullRequest.State)
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app_downstream_version set git_commit_url = ?, git_deployable = ?, git_pr_number = ?, git_pr_url = ?, git_pr_branch = ?, git_pr_state = ?
+	where app_id = ? and cluster_id = ? and sequence = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{commitURL, commitURL != "", prNumber, prURL, prBranch, prState, appID, clusterID, sequence},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListOpenGitOpsPullRequests returns the pull requests opened for downstream versions that have not been merged or closed yet
+func (s *KOTSStore) ListOpenGitOpsPullRequests() ([]gitopstypes.DownstreamPullRequest, error) {
+	db := persistence.MustGetDBSession()
+	query := `select app_id, cluster_id, sequence, git_pr_number, git_pr_url, git_pr_branch, git_pr_state from app_downstream_version where git_pr_number is not null and git_pr_state = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(gitopstypes.PullRequestStateOpen)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	pullRequests := []gitopstypes.DownstreamPullRequest{}
+	for rows.Next() {
+		pr := gitopstypes.DownstreamPullRequest{}
+		var prURL gorqlite.NullString
+		var prBranch gorqlite.NullString
+		var prState gorqlite.NullString
+		if err := rows.Scan(&pr.AppID, &pr.ClusterID, &pr.Sequence, &pr.PullRequest.Number, &prURL, &prBranch, &prState); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		pr.PullRequest.URL = prURL.String
+		pr.PullRequest.Branch = prBranch.String
+		pr.PullRequest.State = gitopstypes.PullRequestState(prState.String)
+		pullRequests = append(pullRequests, pr)
+	}
+
+	return pullRequests, nil
+}
+
+func (s *KOTSStore) SetDownstreamVersionPullRequestState(appID string, clusterID string, sequence int64, state gitopstypes.PullRequestState) error {
+	db := persistence.MustGetDBSession()
+	query := `update app_downstream_version set git_pr_state = ? where app_id = ? and cluster_id = ? and sequence = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(state), appID, clusterID, sequence},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// SetPendingAutoDeploy marks the version as waiting to be auto-deployed at scheduledAt.
+// Only one version of a downstream can be pending.
+func (s *KOTSStore) SetPendingAutoDeploy(appID string, clusterID string, sequence int64, scheduledAt time.Time) error {
+	db := persistence.MustGetDBSession()
+	statements := []gorqlite.ParameterizedStatement{
+		{
+			Query:     `update app_downstream_version set auto_deploy_scheduled_at = null where app_id = ? and cluster_id = ? and sequence != ?`,
+			Arguments: []interface{}{appID, clusterID, sequence},
+		},
+		{
+			Query:     `update app_downstream_version set auto_deploy_scheduled_at = ? where app_id = ? and cluster_id = ? and sequence = ?`,
+			Arguments: []interface{}{scheduledAt.Unix(), appID, clusterID, sequence},
+		},
+	}
+	if wrs, err := db.WriteParameterized(statements); err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
+	}
+
+	return nil
+}
+
+// ClearPendingAutoDeploy removes the pending auto-deploy of the downstream, if any
+func (s *KOTSStore) ClearPendingAutoDeploy(appID string, clusterID string) error {
+	db := persistence.MustGetDBSession()
+	query := `update app_downstream_version set auto_deploy_scheduled_at = null where app_id = ? and cluster_id = ? and auto_deploy_scheduled_at is not null`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, clusterID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListPendingAutoDeploys returns the versions of all downstreams that are waiting for a maintenance window
+func (s *KOTSStore) ListPendingAutoDeploys() ([]downstreamtypes.PendingAutoDeploy, error) {
+	db := persistence.MustGetDBSession()
+	query := `select app_id, cluster_id, sequence, auto_deploy_scheduled_at from app_downstream_version where auto_deploy_scheduled_at is not null`
+	rows, err := db.QueryOne(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	pending := []downstreamtypes.PendingAutoDeploy{}
+	for rows.Next() {
+		p := downstreamtypes.PendingAutoDeploy{}
+		var scheduledAt int64
+		if err := rows.Scan(&p.AppID, &p.ClusterID, &p.Sequence, &scheduledAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		p.ScheduledAt = time.Unix(scheduledAt, 0)
+		pending = append(pending, p)
+	}
+
+	return pending, nil
+}
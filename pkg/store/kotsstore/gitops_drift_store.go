@@ -0,0 +1,94 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+)
+
+func (s *KOTSStore) SetGitOpsDriftStatus(status gitopstypes.DriftStatus) error {
+	marshalledRepoDrift, err := json.Marshal(status.Repo)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal repo drift")
+	}
+	marshalledClusterDrift, err := json.Marshal(status.Cluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal cluster drift")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `
+	insert into gitops_drift_status (app_id, cluster_id, sequence, repo_sequence, checked_at, repo_drift, cluster_drift, error)
+	values (?, ?, ?, ?, ?, ?, ?, ?)
+	on conflict (app_id, cluster_id) do update set
+	  sequence = EXCLUDED.sequence,
+	  repo_sequence = EXCLUDED.repo_sequence,
+	  checked_at = EXCLUDED.checked_at,
+	  repo_drift = EXCLUDED.repo_drift,
+	  cluster_drift = EXCLUDED.cluster_drift,
+	  error = EXCLUDED.error`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{status.AppID, status.ClusterID, status.Sequence, status.RepoSequence, status.CheckedAt.Unix(), string(marshalledRepoDrift), string(marshalledClusterDrift), status.Error},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) GetGitOpsDriftStatus(appID string, clusterID string) (*gitopstypes.DriftStatus, error) {
+	db := persistence.MustGetDBSession()
+	query := `select sequence, repo_sequence, checked_at, repo_drift, cluster_drift, error from gitops_drift_status where app_id = ? and cluster_id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, clusterID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	var sequence gorqlite.NullInt64
+	var repoSequence gorqlite.NullInt64
+	var checkedAt int64
+	var repoDrift gorqlite.NullString
+	var clusterDrift gorqlite.NullString
+	var errMsg gorqlite.NullString
+	if err := rows.Scan(&sequence, &repoSequence, &checkedAt, &repoDrift, &clusterDrift, &errMsg); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	status := gitopstypes.DriftStatus{
+		AppID:        appID,
+		ClusterID:    clusterID,
+		Sequence:     sequence.Int64,
+		RepoSequence: -1,
+		CheckedAt:    time.Unix(checkedAt, 0),
+		Error:        errMsg.String,
+	}
+	if repoSequence.Valid {
+		status.RepoSequence = repoSequence.Int64
+	}
+	if repoDrift.Valid && repoDrift.String != "" {
+		if err := json.Unmarshal([]byte(repoDrift.String), &status.Repo); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal repo drift")
+		}
+	}
+	if clusterDrift.Valid && clusterDrift.String != "" {
+		if err := json.Unmarshal([]byte(clusterDrift.String), &status.Cluster); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal cluster drift")
+		}
+	}
+
+	return &status, nil
+}
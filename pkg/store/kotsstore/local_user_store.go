@@ -0,0 +1,233 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+)
+
+// timestamps in kotsadm_user are stored in microseconds, so that sessions issued in the same second as a password
+// reset can be told apart from sessions issued before it
+const localUserColumns = `id, username, roles, is_disabled, failed_login_count, created_at, password_updated_at, last_login_at`
+
+func (s *KOTSStore) ListUsers() ([]*usertypes.User, error) {
+	db := persistence.MustGetDBSession()
+
+	query := fmt.Sprintf(`select %s from kotsadm_user order by username`, localUserColumns)
+	rows, err := db.QueryOne(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	users := []*usertypes.User{}
+	for rows.Next() {
+		user, err := scanLocalUser(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan user")
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (s *KOTSStore) GetUser(userID string) (*usertypes.User, error) {
+	return s.getLocalUser("id", userID)
+}
+
+func (s *KOTSStore) GetUserByUsername(username string) (*usertypes.User, error) {
+	return s.getLocalUser("username", username)
+}
+
+func (s *KOTSStore) getLocalUser(column string, value string) (*usertypes.User, error) {
+	db := persistence.MustGetDBSession()
+
+	query := fmt.Sprintf(`select %s from kotsadm_user where %s = ?`, localUserColumns, column)
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{value},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	user, err := scanLocalUser(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan user")
+	}
+
+	return user, nil
+}
+
+func scanLocalUser(rows gorqlite.QueryResult) (*usertypes.User, error) {
+	var rolesStr string
+	var isDisabled bool
+	var failedLoginCount int64
+	var createdAt int64
+	var passwordUpdatedAt gorqlite.NullInt64
+	var lastLoginAt gorqlite.NullInt64
+
+	user := usertypes.User{}
+	if err := rows.Scan(&user.ID, &user.Username, &rolesStr, &isDisabled, &failedLoginCount, &createdAt, &passwordUpdatedAt, &lastLoginAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(rolesStr), &user.Roles); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal roles")
+	}
+
+	user.IsDisabled = isDisabled
+	user.FailedLoginCount = int(failedLoginCount)
+	user.CreatedAt = time.UnixMicro(createdAt)
+
+	if passwordUpdatedAt.Valid {
+		t := time.UnixMicro(passwordUpdatedAt.Int64)
+		user.PasswordUpdatedAt = &t
+	}
+	if lastLoginAt.Valid {
+		t := time.UnixMicro(lastLoginAt.Int64)
+		user.LastLoginAt = &t
+	}
+
+	return &user, nil
+}
+
+func (s *KOTSStore) GetUserPasswordBcrypt(userID string) ([]byte, error) {
+	db := persistence.MustGetDBSession()
+
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `select password_bcrypt from kotsadm_user where id = ?`,
+		Arguments: []interface{}{userID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var passwordBcrypt string
+	if err := rows.Scan(&passwordBcrypt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan password")
+	}
+
+	return []byte(passwordBcrypt), nil
+}
+
+func (s *KOTSStore) CreateUser(username string, passwordBcrypt []byte, roles []string) (*usertypes.User, error) {
+	db := persistence.MustGetDBSession()
+
+	marshalledRoles, err := json.Marshal(roles)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal roles")
+	}
+
+	id := ksuid.New().String()
+	now := time.Now()
+
+	query := `insert into kotsadm_user (id, username, password_bcrypt, roles, is_disabled, failed_login_count, created_at, password_updated_at) values (?, ?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id, username, string(passwordBcrypt), string(marshalledRoles), false, 0, now.UnixMicro(), now.UnixMicro()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert user: %v: %v", err, wr.Err)
+	}
+
+	return s.GetUser(id)
+}
+
+// SetUserPasswordBcrypt replaces the user's password and unlocks the account
+func (s *KOTSStore) SetUserPasswordBcrypt(userID string, passwordBcrypt []byte) error {
+	db := persistence.MustGetDBSession()
+
+	query := `update kotsadm_user set password_bcrypt = ?, failed_login_count = 0, password_updated_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(passwordBcrypt), time.Now().UnixMicro(), userID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v: %v", err, wr.Err)
+	}
+	if wr.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) SetUserRoles(userID string, roles []string) error {
+	db := persistence.MustGetDBSession()
+
+	marshalledRoles, err := json.Marshal(roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles")
+	}
+
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `update kotsadm_user set roles = ? where id = ?`,
+		Arguments: []interface{}{string(marshalledRoles), userID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update roles: %v: %v", err, wr.Err)
+	}
+	if wr.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) SetUserDisabled(userID string, isDisabled bool) error {
+	db := persistence.MustGetDBSession()
+
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `update kotsadm_user set is_disabled = ? where id = ?`,
+		Arguments: []interface{}{isDisabled, userID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %v: %v", err, wr.Err)
+	}
+	if wr.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) FlagInvalidUserPassword(userID string) error {
+	db := persistence.MustGetDBSession()
+
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `update kotsadm_user set failed_login_count = failed_login_count + 1 where id = ?`,
+		Arguments: []interface{}{userID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to increment failed login count: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) FlagSuccessfulUserLogin(userID string) error {
+	db := persistence.MustGetDBSession()
+
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `update kotsadm_user set failed_login_count = 0, last_login_at = ? where id = ?`,
+		Arguments: []interface{}{time.Now().UnixMicro(), userID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset failed login count: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
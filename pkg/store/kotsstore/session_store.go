@@ -125,6 +125,8 @@ func (s *KOTSStore) CreateSession(forUser *usertypes.User, issuedAt time.Time, e
 
 	session := sessiontypes.Session{
 		ID:        id,
+		UserID:    forUser.ID,
+		Username:  forUser.Username,
 		IssuedAt:  issuedAt,
 		ExpiresAt: expiresAt,
 		Roles:     roles,
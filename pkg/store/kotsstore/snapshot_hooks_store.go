@@ -0,0 +1,65 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+)
+
+// GetSnapshotHooks returns the backup hooks that are configured for the app in the admin console
+func (s *KOTSStore) GetSnapshotHooks(appID string) ([]snapshottypes.OperatorHook, error) {
+	db := persistence.MustGetDBSession()
+	query := `select snapshot_hooks from app where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var marshalledHooks gorqlite.NullString
+	if err := rows.Scan(&marshalledHooks); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	hooks := []snapshottypes.OperatorHook{}
+	if marshalledHooks.String == "" {
+		return hooks, nil
+	}
+	if err := json.Unmarshal([]byte(marshalledHooks.String), &hooks); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal hooks")
+	}
+
+	return hooks, nil
+}
+
+func (s *KOTSStore) SetSnapshotHooks(appID string, hooks []snapshottypes.OperatorHook) error {
+	var marshalledHooks interface{}
+	if len(hooks) > 0 {
+		b, err := json.Marshal(hooks)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal hooks")
+		}
+		marshalledHooks = string(b)
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set snapshot_hooks = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalledHooks, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
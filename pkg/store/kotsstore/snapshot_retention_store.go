@@ -0,0 +1,82 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+)
+
+// GetSnapshotRetentionPolicy returns nil if the app backups are only expired by their TTL
+func (s *KOTSStore) GetSnapshotRetentionPolicy(appID string) (*snapshottypes.RetentionPolicy, error) {
+	return getSnapshotRetentionPolicy(`select snapshot_retention_policy from app where id = ?`, appID)
+}
+
+// SetSnapshotRetentionPolicy sets the retention policy for app backups. A nil policy removes it.
+func (s *KOTSStore) SetSnapshotRetentionPolicy(appID string, policy *snapshottypes.RetentionPolicy) error {
+	return setSnapshotRetentionPolicy(`update app set snapshot_retention_policy = ? where id = ?`, appID, policy)
+}
+
+// GetInstanceSnapshotRetentionPolicy returns nil if the instance backups are only expired by their TTL
+func (s *KOTSStore) GetInstanceSnapshotRetentionPolicy(clusterID string) (*snapshottypes.RetentionPolicy, error) {
+	return getSnapshotRetentionPolicy(`select snapshot_retention_policy from cluster where id = ?`, clusterID)
+}
+
+// SetInstanceSnapshotRetentionPolicy sets the retention policy for instance backups. A nil policy removes it.
+func (s *KOTSStore) SetInstanceSnapshotRetentionPolicy(clusterID string, policy *snapshottypes.RetentionPolicy) error {
+	return setSnapshotRetentionPolicy(`update cluster set snapshot_retention_policy = ? where id = ?`, clusterID, policy)
+}
+
+func getSnapshotRetentionPolicy(query string, id string) (*snapshottypes.RetentionPolicy, error) {
+	db := persistence.MustGetDBSession()
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var marshalledPolicy gorqlite.NullString
+	if err := rows.Scan(&marshalledPolicy); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+	if marshalledPolicy.String == "" {
+		return nil, nil
+	}
+
+	policy := snapshottypes.RetentionPolicy{}
+	if err := json.Unmarshal([]byte(marshalledPolicy.String), &policy); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal policy")
+	}
+
+	return &policy, nil
+}
+
+func setSnapshotRetentionPolicy(query string, id string, policy *snapshottypes.RetentionPolicy) error {
+	var marshalledPolicy interface{}
+	if policy != nil {
+		b, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal policy")
+		}
+		marshalledPolicy = string(b)
+	}
+
+	db := persistence.MustGetDBSession()
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalledPolicy, id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
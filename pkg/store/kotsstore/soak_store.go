@@ -0,0 +1,123 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
+	"github.com/rqlite/gorqlite"
+)
+
+// GetSoakPolicy returns nil if the app does not have a soak policy
+func (s *KOTSStore) GetSoakPolicy(appID string) (*soaktypes.Policy, error) {
+	db := persistence.MustGetDBSession()
+	query := `select soak_policy from app where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var marshalledPolicy gorqlite.NullString
+	if err := rows.Scan(&marshalledPolicy); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+	if marshalledPolicy.String == "" {
+		return nil, nil
+	}
+
+	policy := soaktypes.Policy{}
+	if err := json.Unmarshal([]byte(marshalledPolicy.String), &policy); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal policy")
+	}
+
+	return &policy, nil
+}
+
+// SetSoakPolicy sets the soak policy for the app. A nil policy auto-deploys versions as soon as they are available.
+func (s *KOTSStore) SetSoakPolicy(appID string, policy *soaktypes.Policy) error {
+	var marshalledPolicy interface{}
+	if policy != nil {
+		b, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal policy")
+		}
+		marshalledPolicy = string(b)
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set soak_policy = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalledPolicy, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// SetApprovedVersions replaces the approved versions previously imported from the same instance
+func (s *KOTSStore) SetApprovedVersions(appID string, approved soaktypes.ApprovedVersions) error {
+	marshalledVersions, err := json.Marshal(approved.Versions)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal versions")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `
+	insert into app_approved_versions (app_id, instance_name, exported_at, imported_at, versions)
+	values (?, ?, ?, ?, ?)
+	on conflict (app_id, instance_name) do update set
+	  exported_at = EXCLUDED.exported_at,
+	  imported_at = EXCLUDED.imported_at,
+	  versions = EXCLUDED.versions`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID, approved.InstanceName, approved.ExportedAt.Unix(), time.Now().Unix(), string(marshalledVersions)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListApprovedVersions returns the approved versions imported from each instance
+func (s *KOTSStore) ListApprovedVersions(appID string) ([]soaktypes.ApprovedVersions, error) {
+	db := persistence.MustGetDBSession()
+	query := `select a.slug, aav.instance_name, aav.exported_at, aav.versions from app_approved_versions aav inner join app a on a.id = aav.app_id where aav.app_id = ? order by aav.instance_name`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	approved := []soaktypes.ApprovedVersions{}
+	for rows.Next() {
+		a := soaktypes.ApprovedVersions{}
+		var exportedAt int64
+		var marshalledVersions string
+		if err := rows.Scan(&a.AppSlug, &a.InstanceName, &exportedAt, &marshalledVersions); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		a.ExportedAt = time.Unix(exportedAt, 0)
+		if err := json.Unmarshal([]byte(marshalledVersions), &a.Versions); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal versions approved by %s", a.InstanceName)
+		}
+		approved = append(approved, a)
+	}
+
+	return approved, nil
+}
@@ -0,0 +1,93 @@
+package kotsstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	updatecheckertypes "github.com/replicatedhq/kots/pkg/updatechecker/types"
+	"github.com/rqlite/gorqlite"
+)
+
+func (s *KOTSStore) SetUpdateCheckResult(result updatecheckertypes.CheckResult) error {
+	db := persistence.MustGetDBSession()
+	query := `
+	insert into app_update_check (app_id, checked_at, status, available_updates, error, consecutive_failures)
+	values (?, ?, ?, ?, ?, ?)
+	on conflict (app_id) do update set
+	  checked_at = EXCLUDED.checked_at,
+	  status = EXCLUDED.status,
+	  available_updates = EXCLUDED.available_updates,
+	  error = EXCLUDED.error,
+	  consecutive_failures = EXCLUDED.consecutive_failures`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{result.AppID, result.CheckedAt.Unix(), string(result.Status), result.AvailableUpdates, result.Error, result.ConsecutiveFailures},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// GetUpdateCheckResult returns nil if the result of an update check has not been recorded for the app
+func (s *KOTSStore) GetUpdateCheckResult(appID string) (*updatecheckertypes.CheckResult, error) {
+	db := persistence.MustGetDBSession()
+	query := `select app_id, checked_at, status, available_updates, error, consecutive_failures from app_update_check where app_id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	return updateCheckResultFromRow(rows)
+}
+
+func (s *KOTSStore) ListUpdateCheckResults() ([]updatecheckertypes.CheckResult, error) {
+	db := persistence.MustGetDBSession()
+	query := `select app_id, checked_at, status, available_updates, error, consecutive_failures from app_update_check`
+	rows, err := db.QueryOne(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	results := []updatecheckertypes.CheckResult{}
+	for rows.Next() {
+		result, err := updateCheckResultFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+func updateCheckResultFromRow(row gorqlite.QueryResult) (*updatecheckertypes.CheckResult, error) {
+	result := updatecheckertypes.CheckResult{}
+
+	var checkedAt int64
+	var status string
+	var availableUpdates gorqlite.NullInt64
+	var checkError gorqlite.NullString
+	var consecutiveFailures gorqlite.NullInt64
+	if err := row.Scan(&result.AppID, &checkedAt, &status, &availableUpdates, &checkError, &consecutiveFailures); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	result.CheckedAt = time.Unix(checkedAt, 0)
+	result.Status = updatecheckertypes.CheckStatus(status)
+	result.AvailableUpdates = availableUpdates.Int64
+	result.Error = checkError.String
+	result.ConsecutiveFailures = int(consecutiveFailures.Int64)
+
+	return &result, nil
+}
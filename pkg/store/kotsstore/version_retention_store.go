@@ -0,0 +1,152 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	versionretentiontypes "github.com/replicatedhq/kots/pkg/versionretention/types"
+	"github.com/rqlite/gorqlite"
+)
+
+// GetVersionRetentionPolicy returns nil if the app does not have a retention policy
+func (s *KOTSStore) GetVersionRetentionPolicy(appID string) (*versionretentiontypes.Policy, error) {
+	db := persistence.MustGetDBSession()
+	query := `select version_retention_policy from app where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var marshalledPolicy gorqlite.NullString
+	if err := rows.Scan(&marshalledPolicy); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+	if marshalledPolicy.String == "" {
+		return nil, nil
+	}
+
+	policy := versionretentiontypes.Policy{}
+	if err := json.Unmarshal([]byte(marshalledPolicy.String), &policy); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal policy")
+	}
+
+	return &policy, nil
+}
+
+// SetVersionRetentionPolicy sets the retention policy for the app. A nil policy disables pruning.
+func (s *KOTSStore) SetVersionRetentionPolicy(appID string, policy *versionretentiontypes.Policy) error {
+	var marshalledPolicy interface{}
+	if policy != nil {
+		b, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal policy")
+		}
+		marshalledPolicy = string(b)
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set version_retention_policy = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalledPolicy, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListAppVersionsForRetention returns all versions of the app, newest first
+func (s *KOTSStore) ListAppVersionsForRetention(appID string) ([]versionretentiontypes.Version, error) {
+	db := persistence.MustGetDBSession()
+	query := `select av.sequence, av.version_label, av.created_at,
+	(select count(1) from app_downstream_version adv where adv.app_id = av.app_id and adv.parent_sequence = av.sequence and adv.applied_at is not null)
+from app_version av
+where av.app_id = ?
+order by av.sequence desc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	versions := []versionretentiontypes.Version{}
+	for rows.Next() {
+		var versionLabel gorqlite.NullString
+		var createdAt gorqlite.NullTime
+		var deployedCount int64
+
+		version := versionretentiontypes.Version{}
+		if err := rows.Scan(&version.Sequence, &versionLabel, &createdAt, &deployedCount); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+
+		version.VersionLabel = versionLabel.String
+		if createdAt.Valid {
+			version.CreatedAt = createdAt.Time
+		}
+		version.WasDeployed = deployedCount > 0
+
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// DeleteAppVersions deletes the app versions along with all rows that refer to them by sequence: downstream versions
+// (including their preflight results, gitops pull requests and pending auto-deploys), their deployment output,
+// gitops drift status and preflight specs. All rows are deleted in a single transaction. Archives are not deleted.
+// Status history is kept because availability is calculated from it, it is pruned by age instead.
+func (s *KOTSStore) DeleteAppVersions(appID string, sequences []int64) error {
+	if len(sequences) == 0 {
+		return nil
+	}
+
+	db := persistence.MustGetDBSession()
+	statements := []gorqlite.ParameterizedStatement{}
+
+	for _, sequence := range sequences {
+		// downstream versions have their own sequence, parent_sequence is the app version's sequence
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     `delete from app_downstream_output where app_id = ? and downstream_sequence in (select sequence from app_downstream_version where app_id = ? and parent_sequence = ?)`,
+			Arguments: []interface{}{appID, appID, sequence},
+		})
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     `delete from gitops_drift_status where app_id = ? and sequence = ?`,
+			Arguments: []interface{}{appID, sequence},
+		})
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     `delete from preflight_spec where watch_id = ? and sequence = ?`,
+			Arguments: []interface{}{appID, sequence},
+		})
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     `delete from app_downstream_version where app_id = ? and parent_sequence = ?`,
+			Arguments: []interface{}{appID, sequence},
+		})
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     `delete from app_version where app_id = ? and sequence = ?`,
+			Arguments: []interface{}{appID, sequence},
+		})
+	}
+
+	if wrs, err := db.WriteParameterized(statements); err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
+	}
+
+	return nil
+}
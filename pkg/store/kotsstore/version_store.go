@@ -418,7 +418,7 @@ func (s *KOTSStore) CreatePendingDownloadAppVersion(appID string, update upstrea
 	for _, d := range downstreams {
 		downstreamVersionStatements, err := s.upsertAppDownstreamVersionStatements(a.ID, d.ClusterID, newSequence,
 			kotsKinds.Installation.Spec.VersionLabel, types.VersionPendingDownload,
-			"Upstream Update", "", "", "", false, false)
+			"Upstream Update", "", "", "", false, nil, false)
 		if err != nil {
 			return 0, errors.Wrap(err, "failed to construct app downstream version statements")
 		}
@@ -617,14 +617,21 @@ func (s *KOTSStore) upsertAppVersionStatements(appID string, sequence int64, bas
 			}
 		}
 
-		commitURL, err := gitops.CreateGitOpsDownstreamCommit(appID, d.ClusterID, int(sequence), filesInDir, d.Name)
+		commitResult, err := gitops.CreateGitOpsDownstreamCommit(appID, d.ClusterID, int(sequence), filesInDir, d.Name)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create gitops commit")
 		}
 
+		commitURL := ""
+		var pullRequest *gitopstypes.PullRequest
+		if commitResult != nil {
+			commitURL = commitResult.CommitURL
+			pullRequest = commitResult.PullRequest
+		}
+
 		downstreamVersionStatements, err := s.upsertAppDownstreamVersionStatements(appID, d.ClusterID, sequence,
 			kotsKinds.Installation.Spec.VersionLabel, downstreamStatus,
-			source, diffSummary, diffSummaryError, commitURL, commitURL != "", skipPreflights)
+			source, diffSummary, diffSummaryError, commitURL, commitURL != "", pullRequest, skipPreflights)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to construct app downstream version statements")
 		}
@@ -771,11 +778,16 @@ func (s *KOTSStore) upsertAppVersionRecordStatements(appID string, sequence int6
 	return statements, nil
 }
 
-func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID string, sequence int64, versionLabel string, status types.DownstreamVersionStatus, source string, diffSummary string, diffSummaryError string, commitURL string, gitDeployable bool, preflightsSkipped bool) ([]gorqlite.ParameterizedStatement, error) {
+func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID string, sequence int64, versionLabel string, status types.DownstreamVersionStatus, source string, diffSummary string, diffSummaryError string, commitURL string, gitDeployable bool, pullRequest *gitopstypes.PullRequest, preflightsSkipped bool) ([]gorqlite.ParameterizedStatement, error) {
 	statements := []gorqlite.ParameterizedStatement{}
 
-	query := `insert into app_downstream_version (app_id, cluster_id, sequence, parent_sequence, created_at, version_label, status, source, diff_summary, diff_summary_error, git_commit_url, git_deployable, preflight_skipped)
-		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	var prNumber, prURL, prBranch, prState interface{}
+	if pullRequest != nil {
+		prNumber, prURL, prBranch, prState = pullRequest.Number, pullRequest.URL, pullRequest.Branch, string(pullRequest.State)
+	}
+
+	query := `insert into app_downstream_version (app_id, cluster_id, sequence, parent_sequence, created_at, version_label, status, source, diff_summary, diff_summary_error, git_commit_url, git_deployable, git_pr_number, git_pr_url, git_pr_branch, git_pr_state, preflight_skipped)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(app_id, cluster_id, sequence) DO UPDATE SET
 		created_at = EXCLUDED.created_at,
 		version_label = EXCLUDED.version_label,
@@ -785,6 +797,10 @@ func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID
 		diff_summary_error = EXCLUDED.diff_summary_error,
 		git_commit_url = EXCLUDED.git_commit_url,
 		git_deployable = EXCLUDED.git_deployable,
+		git_pr_number = EXCLUDED.git_pr_number,
+		git_pr_url = EXCLUDED.git_pr_url,
+		git_pr_branch = EXCLUDED.git_pr_branch,
+		git_pr_state = EXCLUDED.git_pr_state,
 		preflight_skipped= EXCLUDED.preflight_skipped`
 
 	statements = append(statements, gorqlite.ParameterizedStatement{
@@ -802,6 +818,10 @@ func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID
 			diffSummaryError,
 			commitURL,
 			gitDeployable,
+			prNumber,
+			prURL,
+			prBranch,
+			prState,
 			preflightsSkipped,
 		},
 	})
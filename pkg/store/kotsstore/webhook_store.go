@@ -0,0 +1,314 @@
+package kotsstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	webhooktypes "github.com/replicatedhq/kots/pkg/webhooks/types"
+	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+)
+
+func (s *KOTSStore) ListWebhooks() ([]webhooktypes.Webhook, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select id, url, event_types, is_disabled, created_at from kotsadm_webhook order by created_at`
+	rows, err := db.QueryOne(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	webhooks := []webhooktypes.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan webhook")
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (s *KOTSStore) GetWebhook(id string) (*webhooktypes.Webhook, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select id, url, event_types, is_disabled, created_at from kotsadm_webhook where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	webhook, err := scanWebhook(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan webhook")
+	}
+
+	return webhook, nil
+}
+
+func scanWebhook(rows gorqlite.QueryResult) (*webhooktypes.Webhook, error) {
+	var eventTypes gorqlite.NullString
+	var isDisabled bool
+
+	webhook := webhooktypes.Webhook{}
+	if err := rows.Scan(&webhook.ID, &webhook.URL, &eventTypes, &isDisabled, &webhook.CreatedAt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	webhook.IsDisabled = isDisabled
+	webhook.EventTypes = []string{}
+	if eventTypes.String != "" {
+		if err := json.Unmarshal([]byte(eventTypes.String), &webhook.EventTypes); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal event types")
+		}
+	}
+
+	return &webhook, nil
+}
+
+// GetWebhookSecret returns the decrypted secret used to sign deliveries
+func (s *KOTSStore) GetWebhookSecret(id string) (string, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select secret_enc from kotsadm_webhook where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return "", ErrNotFound
+	}
+
+	var secretEnc string
+	if err := rows.Scan(&secretEnc); err != nil {
+		return "", errors.Wrap(err, "failed to scan")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(secretEnc)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode secret")
+	}
+	decrypted, err := crypto.Decrypt(decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt secret")
+	}
+
+	return string(decrypted), nil
+}
+
+func (s *KOTSStore) CreateWebhook(url string, secret string, eventTypes []string) (*webhooktypes.Webhook, error) {
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	marshalledEventTypes, err := json.Marshal(eventTypes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal event types")
+	}
+
+	webhook := webhooktypes.Webhook{
+		ID:         ksuid.New().String(),
+		URL:        url,
+		EventTypes: eventTypes,
+		CreatedAt:  time.Now(),
+	}
+	secretEnc := base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte(secret)))
+
+	db := persistence.MustGetDBSession()
+	query := `insert into kotsadm_webhook (id, url, secret_enc, event_types, is_disabled, created_at) values (?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{webhook.ID, webhook.URL, secretEnc, string(marshalledEventTypes), false, webhook.CreatedAt.Unix()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %v: %v", err, wr.Err)
+	}
+
+	return &webhook, nil
+}
+
+// DeleteWebhook deletes the webhook and its delivery history
+func (s *KOTSStore) DeleteWebhook(id string) error {
+	db := persistence.MustGetDBSession()
+
+	statements := []gorqlite.ParameterizedStatement{
+		{
+			Query:     `delete from kotsadm_webhook_delivery where webhook_id = ?`,
+			Arguments: []interface{}{id},
+		},
+		{
+			Query:     `delete from kotsadm_webhook where id = ?`,
+			Arguments: []interface{}{id},
+		},
+	}
+
+	wrs, err := db.WriteParameterized(statements)
+	if err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
+	}
+	if len(wrs) == 2 && wrs[1].RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) CreateWebhookDelivery(delivery webhooktypes.Delivery) error {
+	db := persistence.MustGetDBSession()
+
+	query := `insert into kotsadm_webhook_delivery (id, webhook_id, event_id, event_type, payload, status, attempts, status_code, error, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+		Arguments: []interface{}{
+			delivery.ID,
+			delivery.WebhookID,
+			delivery.EventID,
+			delivery.EventType,
+			delivery.Payload,
+			string(delivery.Status),
+			delivery.Attempts,
+			delivery.StatusCode,
+			delivery.Error,
+			delivery.CreatedAt.Unix(),
+			delivery.UpdatedAt.Unix(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook delivery: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) UpdateWebhookDelivery(delivery webhooktypes.Delivery) error {
+	db := persistence.MustGetDBSession()
+
+	query := `update kotsadm_webhook_delivery set status = ?, attempts = ?, status_code = ?, error = ?, updated_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+		Arguments: []interface{}{
+			string(delivery.Status),
+			delivery.Attempts,
+			delivery.StatusCode,
+			delivery.Error,
+			delivery.UpdatedAt.Unix(),
+			delivery.ID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, status_code, error, created_at, updated_at`
+
+// ListWebhookDeliveries returns the deliveries of the webhook, newest first. A limit of 0 returns all deliveries.
+func (s *KOTSStore) ListWebhookDeliveries(webhookID string, limit int) ([]webhooktypes.Delivery, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select ` + webhookDeliveryColumns + ` from kotsadm_webhook_delivery where webhook_id = ? order by created_at desc, id desc`
+	if limit > 0 {
+		query = fmt.Sprintf("%s limit %d", query, limit)
+	}
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{webhookID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// ListPendingWebhookDeliveries returns the deliveries of all webhooks that have not succeeded or failed yet, oldest first
+func (s *KOTSStore) ListPendingWebhookDeliveries() ([]webhooktypes.Delivery, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select ` + webhookDeliveryColumns + ` from kotsadm_webhook_delivery where status = ? order by created_at, id`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(webhooktypes.DeliveryPending)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// PruneWebhookDeliveries deletes finished deliveries created before the given time, and all but the newest
+// keepPerWebhook finished deliveries of each webhook. Pending deliveries are never deleted.
+func (s *KOTSStore) PruneWebhookDeliveries(before time.Time, keepPerWebhook int) error {
+	db := persistence.MustGetDBSession()
+
+	statements := []gorqlite.ParameterizedStatement{
+		{
+			Query:     `delete from kotsadm_webhook_delivery where status != ? and created_at < ?`,
+			Arguments: []interface{}{string(webhooktypes.DeliveryPending), before.Unix()},
+		},
+		{
+			Query: `delete from kotsadm_webhook_delivery where status != ? and id not in (
+	select newest.id from kotsadm_webhook_delivery newest where newest.webhook_id = kotsadm_webhook_delivery.webhook_id order by newest.created_at desc, newest.id desc limit ?
+)`,
+			Arguments: []interface{}{string(webhooktypes.DeliveryPending), keepPerWebhook},
+		},
+	}
+
+	wrs, err := db.WriteParameterized(statements)
+	if err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
+	}
+
+	return nil
+}
+
+func scanWebhookDeliveries(rows gorqlite.QueryResult) ([]webhooktypes.Delivery, error) {
+	deliveries := []webhooktypes.Delivery{}
+	for rows.Next() {
+		var payload gorqlite.NullString
+		var status string
+		var attempts int64
+		var statusCode gorqlite.NullInt64
+		var deliveryError gorqlite.NullString
+
+		delivery := webhooktypes.Delivery{}
+		if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload, &status, &attempts, &statusCode, &deliveryError, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan webhook delivery")
+		}
+
+		delivery.Payload = payload.String
+		delivery.Status = webhooktypes.DeliveryStatus(status)
+		delivery.Attempts = int(attempts)
+		delivery.StatusCode = int(statusCode.Int64)
+		delivery.Error = deliveryError.String
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
@@ -15,17 +15,23 @@ import (
 	types1 "github.com/replicatedhq/kots/pkg/api/version/types"
 	types2 "github.com/replicatedhq/kots/pkg/app/types"
 	types3 "github.com/replicatedhq/kots/pkg/appstate/types"
-	types4 "github.com/replicatedhq/kots/pkg/gitops/types"
-	types5 "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
-	types6 "github.com/replicatedhq/kots/pkg/online/types"
-	types7 "github.com/replicatedhq/kots/pkg/preflight/types"
-	types8 "github.com/replicatedhq/kots/pkg/registry/types"
-	types9 "github.com/replicatedhq/kots/pkg/render/types"
-	types10 "github.com/replicatedhq/kots/pkg/session/types"
-	types11 "github.com/replicatedhq/kots/pkg/store/types"
-	types12 "github.com/replicatedhq/kots/pkg/supportbundle/types"
-	types13 "github.com/replicatedhq/kots/pkg/upstream/types"
-	types14 "github.com/replicatedhq/kots/pkg/user/types"
+	types4 "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	types5 "github.com/replicatedhq/kots/pkg/audit/types"
+	types6 "github.com/replicatedhq/kots/pkg/gitops/types"
+	types7 "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	types8 "github.com/replicatedhq/kots/pkg/online/types"
+	types9 "github.com/replicatedhq/kots/pkg/preflight/types"
+	types10 "github.com/replicatedhq/kots/pkg/registry/types"
+	types11 "github.com/replicatedhq/kots/pkg/render/types"
+	types12 "github.com/replicatedhq/kots/pkg/session/types"
+	types13 "github.com/replicatedhq/kots/pkg/soak/types"
+	types14 "github.com/replicatedhq/kots/pkg/store/types"
+	types15 "github.com/replicatedhq/kots/pkg/supportbundle/types"
+	types16 "github.com/replicatedhq/kots/pkg/updatechecker/types"
+	types17 "github.com/replicatedhq/kots/pkg/upstream/types"
+	types18 "github.com/replicatedhq/kots/pkg/user/types"
+	types19 "github.com/replicatedhq/kots/pkg/versionretention/types"
+	types20 "github.com/replicatedhq/kots/pkg/webhooks/types"
 	v1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	redact "github.com/replicatedhq/troubleshoot/pkg/redact"
 )
@@ -53,6 +59,20 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AddAppStatusHistoryEntry mocks base method.
+func (m *MockStore) AddAppStatusHistoryEntry(entry types4.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAppStatusHistoryEntry", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAppStatusHistoryEntry indicates an expected call of AddAppStatusHistoryEntry.
+func (mr *MockStoreMockRecorder) AddAppStatusHistoryEntry(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAppStatusHistoryEntry", reflect.TypeOf((*MockStore)(nil).AddAppStatusHistoryEntry), entry)
+}
+
 // AddAppToAllDownstreams mocks base method.
 func (m *MockStore) AddAppToAllDownstreams(appID string) error {
 	m.ctrl.T.Helper()
@@ -95,6 +115,20 @@ func (mr *MockStoreMockRecorder) AddDownstreamVersionsDetails(appID, clusterID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDownstreamVersionsDetails", reflect.TypeOf((*MockStore)(nil).AddDownstreamVersionsDetails), appID, clusterID, versions, checkIfDeployable)
 }
 
+// ClearPendingAutoDeploy mocks base method.
+func (m *MockStore) ClearPendingAutoDeploy(appID, clusterID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearPendingAutoDeploy", appID, clusterID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearPendingAutoDeploy indicates an expected call of ClearPendingAutoDeploy.
+func (mr *MockStoreMockRecorder) ClearPendingAutoDeploy(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearPendingAutoDeploy", reflect.TypeOf((*MockStore)(nil).ClearPendingAutoDeploy), appID, clusterID)
+}
+
 // ClearTaskStatus mocks base method.
 func (m *MockStore) ClearTaskStatus(taskID string) error {
 	m.ctrl.T.Helper()
@@ -125,7 +159,7 @@ func (mr *MockStoreMockRecorder) CreateApp(name, upstreamURI, licenseData, isAir
 }
 
 // CreateAppVersion mocks base method.
-func (m *MockStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) (int64, error) {
+func (m *MockStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateAppVersion", appID, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -153,8 +187,22 @@ func (mr *MockStoreMockRecorder) CreateAppVersionArchive(appID, sequence, archiv
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppVersionArchive", reflect.TypeOf((*MockStore)(nil).CreateAppVersionArchive), appID, sequence, archivePath)
 }
 
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(event types5.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), event)
+}
+
 // CreateInProgressSupportBundle mocks base method.
-func (m *MockStore) CreateInProgressSupportBundle(supportBundle *types12.SupportBundle) error {
+func (m *MockStore) CreateInProgressSupportBundle(supportBundle *types15.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateInProgressSupportBundle", supportBundle)
 	ret0, _ := ret[0].(error)
@@ -198,7 +246,7 @@ func (mr *MockStoreMockRecorder) CreateNewCluster(userID, isAllUsers, title, tok
 }
 
 // CreatePendingDownloadAppVersion mocks base method.
-func (m *MockStore) CreatePendingDownloadAppVersion(appID string, update types13.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
+func (m *MockStore) CreatePendingDownloadAppVersion(appID string, update types17.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePendingDownloadAppVersion", appID, update, kotsApplication, license)
 	ret0, _ := ret[0].(int64)
@@ -241,10 +289,10 @@ func (mr *MockStoreMockRecorder) CreateScheduledSnapshot(snapshotID, appID, time
 }
 
 // CreateSession mocks base method.
-func (m *MockStore) CreateSession(user *types14.User, issuedAt, expiresAt time.Time, roles []string) (*types10.Session, error) {
+func (m *MockStore) CreateSession(user *types18.User, issuedAt, expiresAt time.Time, roles []string) (*types12.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSession", user, issuedAt, expiresAt, roles)
-	ret0, _ := ret[0].(*types10.Session)
+	ret0, _ := ret[0].(*types12.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -256,10 +304,10 @@ func (mr *MockStoreMockRecorder) CreateSession(user, issuedAt, expiresAt, roles
 }
 
 // CreateSupportBundle mocks base method.
-func (m *MockStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types12.SupportBundle, error) {
+func (m *MockStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types15.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSupportBundle", bundleID, appID, archivePath, marshalledTree)
-	ret0, _ := ret[0].(*types12.SupportBundle)
+	ret0, _ := ret[0].(*types15.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -270,6 +318,78 @@ func (mr *MockStoreMockRecorder) CreateSupportBundle(bundleID, appID, archivePat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupportBundle", reflect.TypeOf((*MockStore)(nil).CreateSupportBundle), bundleID, appID, archivePath, marshalledTree)
 }
 
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(username string, passwordBcrypt []byte, roles []string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", username, passwordBcrypt, roles)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(username, passwordBcrypt, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), username, passwordBcrypt, roles)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStore) CreateWebhook(url, secret string, eventTypes []string) (*types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", url, secret, eventTypes)
+	ret0, _ := ret[0].(*types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStoreMockRecorder) CreateWebhook(url, secret, eventTypes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStore)(nil).CreateWebhook), url, secret, eventTypes)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStore) CreateWebhookDelivery(delivery types20.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStoreMockRecorder) CreateWebhookDelivery(delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CreateWebhookDelivery), delivery)
+}
+
+// DeleteAppStatusHistoryBefore mocks base method.
+func (m *MockStore) DeleteAppStatusHistoryBefore(before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppStatusHistoryBefore", before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppStatusHistoryBefore indicates an expected call of DeleteAppStatusHistoryBefore.
+func (mr *MockStoreMockRecorder) DeleteAppStatusHistoryBefore(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppStatusHistoryBefore", reflect.TypeOf((*MockStore)(nil).DeleteAppStatusHistoryBefore), before)
+}
+
+// DeleteAppVersions mocks base method.
+func (m *MockStore) DeleteAppVersions(appID string, sequences []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppVersions", appID, sequences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppVersions indicates an expected call of DeleteAppVersions.
+func (mr *MockStoreMockRecorder) DeleteAppVersions(appID, sequences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppVersions", reflect.TypeOf((*MockStore)(nil).DeleteAppVersions), appID, sequences)
+}
+
 // DeleteDownstreamDeployStatus mocks base method.
 func (m *MockStore) DeleteDownstreamDeployStatus(appID, clusterID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -354,6 +474,20 @@ func (mr *MockStoreMockRecorder) DeleteSupportBundle(bundleID, appID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSupportBundle", reflect.TypeOf((*MockStore)(nil).DeleteSupportBundle), bundleID, appID)
 }
 
+// DeleteWebhook mocks base method.
+func (m *MockStore) DeleteWebhook(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStoreMockRecorder) DeleteWebhook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStore)(nil).DeleteWebhook), id)
+}
+
 // FindDownstreamVersions mocks base method.
 func (m *MockStore) FindDownstreamVersions(appID string, downloadedOnly bool) (*types0.DownstreamVersions, error) {
 	m.ctrl.T.Helper()
@@ -383,6 +517,20 @@ func (mr *MockStoreMockRecorder) FlagInvalidPassword() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagInvalidPassword", reflect.TypeOf((*MockStore)(nil).FlagInvalidPassword))
 }
 
+// FlagInvalidUserPassword mocks base method.
+func (m *MockStore) FlagInvalidUserPassword(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagInvalidUserPassword", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagInvalidUserPassword indicates an expected call of FlagInvalidUserPassword.
+func (mr *MockStoreMockRecorder) FlagInvalidUserPassword(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagInvalidUserPassword", reflect.TypeOf((*MockStore)(nil).FlagInvalidUserPassword), userID)
+}
+
 // FlagSuccessfulLogin mocks base method.
 func (m *MockStore) FlagSuccessfulLogin() error {
 	m.ctrl.T.Helper()
@@ -397,6 +545,20 @@ func (mr *MockStoreMockRecorder) FlagSuccessfulLogin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulLogin", reflect.TypeOf((*MockStore)(nil).FlagSuccessfulLogin))
 }
 
+// FlagSuccessfulUserLogin mocks base method.
+func (m *MockStore) FlagSuccessfulUserLogin(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagSuccessfulUserLogin", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagSuccessfulUserLogin indicates an expected call of FlagSuccessfulUserLogin.
+func (mr *MockStoreMockRecorder) FlagSuccessfulUserLogin(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulUserLogin", reflect.TypeOf((*MockStore)(nil).FlagSuccessfulUserLogin), userID)
+}
+
 // GetAirgapInstallStatus mocks base method.
 func (m *MockStore) GetAirgapInstallStatus(appID string) (*types.InstallStatus, error) {
 	m.ctrl.T.Helper()
@@ -713,10 +875,10 @@ func (mr *MockStoreMockRecorder) GetDownstreamVersionSource(appID, sequence inte
 }
 
 // GetDownstreamVersionStatus mocks base method.
-func (m *MockStore) GetDownstreamVersionStatus(appID string, sequence int64) (types11.DownstreamVersionStatus, error) {
+func (m *MockStore) GetDownstreamVersionStatus(appID string, sequence int64) (types14.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDownstreamVersionStatus", appID, sequence)
-	ret0, _ := ret[0].(types11.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types14.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -772,6 +934,21 @@ func (mr *MockStoreMockRecorder) GetEmbeddedClusterInstallCommandRoles(token int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddedClusterInstallCommandRoles", reflect.TypeOf((*MockStore)(nil).GetEmbeddedClusterInstallCommandRoles), token)
 }
 
+// GetGitOpsDriftStatus mocks base method.
+func (m *MockStore) GetGitOpsDriftStatus(appID, clusterID string) (*types6.DriftStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGitOpsDriftStatus", appID, clusterID)
+	ret0, _ := ret[0].(*types6.DriftStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGitOpsDriftStatus indicates an expected call of GetGitOpsDriftStatus.
+func (mr *MockStoreMockRecorder) GetGitOpsDriftStatus(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGitOpsDriftStatus", reflect.TypeOf((*MockStore)(nil).GetGitOpsDriftStatus), appID, clusterID)
+}
+
 // GetIgnoreRBACErrors mocks base method.
 func (m *MockStore) GetIgnoreRBACErrors(appID string, sequence int64) (bool, error) {
 	m.ctrl.T.Helper()
@@ -802,6 +979,21 @@ func (mr *MockStoreMockRecorder) GetInitialBranding() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInitialBranding", reflect.TypeOf((*MockStore)(nil).GetInitialBranding))
 }
 
+// GetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockStore) GetInstanceSnapshotRetentionPolicy(clusterID string) (*types7.RetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceSnapshotRetentionPolicy", clusterID)
+	ret0, _ := ret[0].(*types7.RetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceSnapshotRetentionPolicy indicates an expected call of GetInstanceSnapshotRetentionPolicy.
+func (mr *MockStoreMockRecorder) GetInstanceSnapshotRetentionPolicy(clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockStore)(nil).GetInstanceSnapshotRetentionPolicy), clusterID)
+}
+
 // GetLatestAppSequence mocks base method.
 func (m *MockStore) GetLatestAppSequence(appID string, downloadedOnly bool) (int64, error) {
 	m.ctrl.T.Helper()
@@ -817,6 +1009,21 @@ func (mr *MockStoreMockRecorder) GetLatestAppSequence(appID, downloadedOnly inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestAppSequence", reflect.TypeOf((*MockStore)(nil).GetLatestAppSequence), appID, downloadedOnly)
 }
 
+// GetLatestAppStatusHistoryEntryBefore mocks base method.
+func (m *MockStore) GetLatestAppStatusHistoryEntryBefore(appID string, before time.Time) (*types4.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestAppStatusHistoryEntryBefore", appID, before)
+	ret0, _ := ret[0].(*types4.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestAppStatusHistoryEntryBefore indicates an expected call of GetLatestAppStatusHistoryEntryBefore.
+func (mr *MockStoreMockRecorder) GetLatestAppStatusHistoryEntryBefore(appID, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestAppStatusHistoryEntryBefore", reflect.TypeOf((*MockStore)(nil).GetLatestAppStatusHistoryEntryBefore), appID, before)
+}
+
 // GetLatestBranding mocks base method.
 func (m *MockStore) GetLatestBranding() ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -864,6 +1071,21 @@ func (mr *MockStoreMockRecorder) GetLatestDeployableDownstreamVersion(appID, clu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDeployableDownstreamVersion", reflect.TypeOf((*MockStore)(nil).GetLatestDeployableDownstreamVersion), appID, clusterID)
 }
 
+// GetLatestGitOpsCommittedParentSequence mocks base method.
+func (m *MockStore) GetLatestGitOpsCommittedParentSequence(appID, clusterID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestGitOpsCommittedParentSequence", appID, clusterID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestGitOpsCommittedParentSequence indicates an expected call of GetLatestGitOpsCommittedParentSequence.
+func (mr *MockStoreMockRecorder) GetLatestGitOpsCommittedParentSequence(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestGitOpsCommittedParentSequence", reflect.TypeOf((*MockStore)(nil).GetLatestGitOpsCommittedParentSequence), appID, clusterID)
+}
+
 // GetLatestLicenseForApp mocks base method.
 func (m *MockStore) GetLatestLicenseForApp(appID string) (*v1beta1.License, error) {
 	m.ctrl.T.Helper()
@@ -955,10 +1177,10 @@ func (mr *MockStoreMockRecorder) GetPendingAirgapUploadApp() *gomock.Call {
 }
 
 // GetPendingInstallationStatus mocks base method.
-func (m *MockStore) GetPendingInstallationStatus() (*types6.InstallStatus, error) {
+func (m *MockStore) GetPendingInstallationStatus() (*types8.InstallStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPendingInstallationStatus")
-	ret0, _ := ret[0].(*types6.InstallStatus)
+	ret0, _ := ret[0].(*types8.InstallStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -985,10 +1207,10 @@ func (mr *MockStoreMockRecorder) GetPreflightProgress(appID, sequence interface{
 }
 
 // GetPreflightResults mocks base method.
-func (m *MockStore) GetPreflightResults(appID string, sequence int64) (*types7.PreflightResult, error) {
+func (m *MockStore) GetPreflightResults(appID string, sequence int64) (*types9.PreflightResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPreflightResults", appID, sequence)
-	ret0, _ := ret[0].(*types7.PreflightResult)
+	ret0, _ := ret[0].(*types9.PreflightResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1045,10 +1267,10 @@ func (mr *MockStoreMockRecorder) GetRedactions(bundleID interface{}) *gomock.Cal
 }
 
 // GetRegistryDetailsForApp mocks base method.
-func (m *MockStore) GetRegistryDetailsForApp(appID string) (types8.RegistrySettings, error) {
+func (m *MockStore) GetRegistryDetailsForApp(appID string) (types10.RegistrySettings, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRegistryDetailsForApp", appID)
-	ret0, _ := ret[0].(types8.RegistrySettings)
+	ret0, _ := ret[0].(types10.RegistrySettings)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1060,10 +1282,10 @@ func (mr *MockStoreMockRecorder) GetRegistryDetailsForApp(appID interface{}) *go
 }
 
 // GetSession mocks base method.
-func (m *MockStore) GetSession(sessionID string) (*types10.Session, error) {
+func (m *MockStore) GetSession(sessionID string) (*types12.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSession", sessionID)
-	ret0, _ := ret[0].(*types10.Session)
+	ret0, _ := ret[0].(*types12.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1089,11 +1311,56 @@ func (mr *MockStoreMockRecorder) GetSharedPasswordBcrypt() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedPasswordBcrypt", reflect.TypeOf((*MockStore)(nil).GetSharedPasswordBcrypt))
 }
 
+// GetSnapshotHooks mocks base method.
+func (m *MockStore) GetSnapshotHooks(appID string) ([]types7.OperatorHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotHooks", appID)
+	ret0, _ := ret[0].([]types7.OperatorHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotHooks indicates an expected call of GetSnapshotHooks.
+func (mr *MockStoreMockRecorder) GetSnapshotHooks(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotHooks", reflect.TypeOf((*MockStore)(nil).GetSnapshotHooks), appID)
+}
+
+// GetSnapshotRetentionPolicy mocks base method.
+func (m *MockStore) GetSnapshotRetentionPolicy(appID string) (*types7.RetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotRetentionPolicy", appID)
+	ret0, _ := ret[0].(*types7.RetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotRetentionPolicy indicates an expected call of GetSnapshotRetentionPolicy.
+func (mr *MockStoreMockRecorder) GetSnapshotRetentionPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotRetentionPolicy", reflect.TypeOf((*MockStore)(nil).GetSnapshotRetentionPolicy), appID)
+}
+
+// GetSoakPolicy mocks base method.
+func (m *MockStore) GetSoakPolicy(appID string) (*types13.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSoakPolicy", appID)
+	ret0, _ := ret[0].(*types13.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSoakPolicy indicates an expected call of GetSoakPolicy.
+func (mr *MockStoreMockRecorder) GetSoakPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSoakPolicy", reflect.TypeOf((*MockStore)(nil).GetSoakPolicy), appID)
+}
+
 // GetStatusForVersion mocks base method.
-func (m *MockStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types11.DownstreamVersionStatus, error) {
+func (m *MockStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types14.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStatusForVersion", appID, clusterID, sequence)
-	ret0, _ := ret[0].(types11.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types14.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1105,10 +1372,10 @@ func (mr *MockStoreMockRecorder) GetStatusForVersion(appID, clusterID, sequence
 }
 
 // GetSupportBundle mocks base method.
-func (m *MockStore) GetSupportBundle(bundleID string) (*types12.SupportBundle, error) {
+func (m *MockStore) GetSupportBundle(bundleID string) (*types15.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundle", bundleID)
-	ret0, _ := ret[0].(*types12.SupportBundle)
+	ret0, _ := ret[0].(*types15.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1120,10 +1387,10 @@ func (mr *MockStoreMockRecorder) GetSupportBundle(bundleID interface{}) *gomock.
 }
 
 // GetSupportBundleAnalysis mocks base method.
-func (m *MockStore) GetSupportBundleAnalysis(bundleID string) (*types12.SupportBundleAnalysis, error) {
+func (m *MockStore) GetSupportBundleAnalysis(bundleID string) (*types15.SupportBundleAnalysis, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundleAnalysis", bundleID)
-	ret0, _ := ret[0].(*types12.SupportBundleAnalysis)
+	ret0, _ := ret[0].(*types15.SupportBundleAnalysis)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1180,6 +1447,111 @@ func (mr *MockStoreMockRecorder) GetTaskStatus(taskID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskStatus", reflect.TypeOf((*MockStore)(nil).GetTaskStatus), taskID)
 }
 
+// GetUpdateCheckResult mocks base method.
+func (m *MockStore) GetUpdateCheckResult(appID string) (*types16.CheckResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateCheckResult", appID)
+	ret0, _ := ret[0].(*types16.CheckResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateCheckResult indicates an expected call of GetUpdateCheckResult.
+func (mr *MockStoreMockRecorder) GetUpdateCheckResult(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateCheckResult", reflect.TypeOf((*MockStore)(nil).GetUpdateCheckResult), appID)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(userID string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userID)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), userID)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(username string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", username)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), username)
+}
+
+// GetUserPasswordBcrypt mocks base method.
+func (m *MockStore) GetUserPasswordBcrypt(userID string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPasswordBcrypt", userID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPasswordBcrypt indicates an expected call of GetUserPasswordBcrypt.
+func (mr *MockStoreMockRecorder) GetUserPasswordBcrypt(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPasswordBcrypt", reflect.TypeOf((*MockStore)(nil).GetUserPasswordBcrypt), userID)
+}
+
+// GetVersionRetentionPolicy mocks base method.
+func (m *MockStore) GetVersionRetentionPolicy(appID string) (*types19.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersionRetentionPolicy", appID)
+	ret0, _ := ret[0].(*types19.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersionRetentionPolicy indicates an expected call of GetVersionRetentionPolicy.
+func (mr *MockStoreMockRecorder) GetVersionRetentionPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersionRetentionPolicy", reflect.TypeOf((*MockStore)(nil).GetVersionRetentionPolicy), appID)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStore) GetWebhook(id string) (*types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", id)
+	ret0, _ := ret[0].(*types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStoreMockRecorder) GetWebhook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStore)(nil).GetWebhook), id)
+}
+
+// GetWebhookSecret mocks base method.
+func (m *MockStore) GetWebhookSecret(id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSecret", id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSecret indicates an expected call of GetWebhookSecret.
+func (mr *MockStoreMockRecorder) GetWebhookSecret(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSecret", reflect.TypeOf((*MockStore)(nil).GetWebhookSecret), id)
+}
+
 // HasStrictPreflights mocks base method.
 func (m *MockStore) HasStrictPreflights(appID string, sequence int64) (bool, error) {
 	m.ctrl.T.Helper()
@@ -1315,7 +1687,7 @@ func (mr *MockStoreMockRecorder) IsRollbackSupportedForVersion(appID, sequence i
 }
 
 // IsSnapshotsSupportedForVersion mocks base method.
-func (m *MockStore) IsSnapshotsSupportedForVersion(a *types2.App, sequence int64, renderer types9.Renderer) (bool, error) {
+func (m *MockStore) IsSnapshotsSupportedForVersion(a *types2.App, sequence int64, renderer types11.Renderer) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "IsSnapshotsSupportedForVersion", a, sequence, renderer)
 	ret0, _ := ret[0].(bool)
@@ -1329,6 +1701,51 @@ func (mr *MockStoreMockRecorder) IsSnapshotsSupportedForVersion(a, sequence, ren
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSnapshotsSupportedForVersion", reflect.TypeOf((*MockStore)(nil).IsSnapshotsSupportedForVersion), a, sequence, renderer)
 }
 
+// ListAppStatusHistory mocks base method.
+func (m *MockStore) ListAppStatusHistory(appID string, since, until time.Time) ([]types4.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppStatusHistory", appID, since, until)
+	ret0, _ := ret[0].([]types4.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppStatusHistory indicates an expected call of ListAppStatusHistory.
+func (mr *MockStoreMockRecorder) ListAppStatusHistory(appID, since, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppStatusHistory", reflect.TypeOf((*MockStore)(nil).ListAppStatusHistory), appID, since, until)
+}
+
+// ListAppVersionsForRetention mocks base method.
+func (m *MockStore) ListAppVersionsForRetention(appID string) ([]types19.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppVersionsForRetention", appID)
+	ret0, _ := ret[0].([]types19.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppVersionsForRetention indicates an expected call of ListAppVersionsForRetention.
+func (mr *MockStoreMockRecorder) ListAppVersionsForRetention(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppVersionsForRetention", reflect.TypeOf((*MockStore)(nil).ListAppVersionsForRetention), appID)
+}
+
+// ListApprovedVersions mocks base method.
+func (m *MockStore) ListApprovedVersions(appID string) ([]types13.ApprovedVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApprovedVersions", appID)
+	ret0, _ := ret[0].([]types13.ApprovedVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApprovedVersions indicates an expected call of ListApprovedVersions.
+func (mr *MockStoreMockRecorder) ListApprovedVersions(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApprovedVersions", reflect.TypeOf((*MockStore)(nil).ListApprovedVersions), appID)
+}
+
 // ListAppsForDownstream mocks base method.
 func (m *MockStore) ListAppsForDownstream(clusterID string) ([]*types2.App, error) {
 	m.ctrl.T.Helper()
@@ -1344,6 +1761,21 @@ func (mr *MockStoreMockRecorder) ListAppsForDownstream(clusterID interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppsForDownstream", reflect.TypeOf((*MockStore)(nil).ListAppsForDownstream), clusterID)
 }
 
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(opts types5.ListOptions) ([]types5.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", opts)
+	ret0, _ := ret[0].([]types5.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), opts)
+}
+
 // ListClusters mocks base method.
 func (m *MockStore) ListClusters() ([]*types0.Downstream, error) {
 	m.ctrl.T.Helper()
@@ -1419,11 +1851,41 @@ func (mr *MockStoreMockRecorder) ListInstalledApps() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstalledApps", reflect.TypeOf((*MockStore)(nil).ListInstalledApps))
 }
 
+// ListOpenGitOpsPullRequests mocks base method.
+func (m *MockStore) ListOpenGitOpsPullRequests() ([]types6.DownstreamPullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenGitOpsPullRequests")
+	ret0, _ := ret[0].([]types6.DownstreamPullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenGitOpsPullRequests indicates an expected call of ListOpenGitOpsPullRequests.
+func (mr *MockStoreMockRecorder) ListOpenGitOpsPullRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenGitOpsPullRequests", reflect.TypeOf((*MockStore)(nil).ListOpenGitOpsPullRequests))
+}
+
+// ListPendingAutoDeploys mocks base method.
+func (m *MockStore) ListPendingAutoDeploys() ([]types0.PendingAutoDeploy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingAutoDeploys")
+	ret0, _ := ret[0].([]types0.PendingAutoDeploy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingAutoDeploys indicates an expected call of ListPendingAutoDeploys.
+func (mr *MockStoreMockRecorder) ListPendingAutoDeploys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingAutoDeploys", reflect.TypeOf((*MockStore)(nil).ListPendingAutoDeploys))
+}
+
 // ListPendingScheduledInstanceSnapshots mocks base method.
-func (m *MockStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types5.ScheduledInstanceSnapshot, error) {
+func (m *MockStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types7.ScheduledInstanceSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledInstanceSnapshots", clusterID)
-	ret0, _ := ret[0].([]types5.ScheduledInstanceSnapshot)
+	ret0, _ := ret[0].([]types7.ScheduledInstanceSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1435,10 +1897,10 @@ func (mr *MockStoreMockRecorder) ListPendingScheduledInstanceSnapshots(clusterID
 }
 
 // ListPendingScheduledSnapshots mocks base method.
-func (m *MockStore) ListPendingScheduledSnapshots(appID string) ([]types5.ScheduledSnapshot, error) {
+func (m *MockStore) ListPendingScheduledSnapshots(appID string) ([]types7.ScheduledSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledSnapshots", appID)
-	ret0, _ := ret[0].([]types5.ScheduledSnapshot)
+	ret0, _ := ret[0].([]types7.ScheduledSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1449,19 +1911,94 @@ func (mr *MockStoreMockRecorder) ListPendingScheduledSnapshots(appID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingScheduledSnapshots", reflect.TypeOf((*MockStore)(nil).ListPendingScheduledSnapshots), appID)
 }
 
-// ListSupportBundles mocks base method.
-func (m *MockStore) ListSupportBundles(appID string) ([]*types12.SupportBundle, error) {
+// ListPendingWebhookDeliveries mocks base method.
+func (m *MockStore) ListPendingWebhookDeliveries() ([]types20.Delivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSupportBundles", appID)
-	ret0, _ := ret[0].([]*types12.SupportBundle)
+	ret := m.ctrl.Call(m, "ListPendingWebhookDeliveries")
+	ret0, _ := ret[0].([]types20.Delivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListSupportBundles indicates an expected call of ListSupportBundles.
-func (mr *MockStoreMockRecorder) ListSupportBundles(appID interface{}) *gomock.Call {
+// ListPendingWebhookDeliveries indicates an expected call of ListPendingWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListPendingWebhookDeliveries() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSupportBundles", reflect.TypeOf((*MockStore)(nil).ListSupportBundles), appID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListPendingWebhookDeliveries))
+}
+
+// ListSupportBundles mocks base method.
+func (m *MockStore) ListSupportBundles(appID string) ([]*types15.SupportBundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSupportBundles", appID)
+	ret0, _ := ret[0].([]*types15.SupportBundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSupportBundles indicates an expected call of ListSupportBundles.
+func (mr *MockStoreMockRecorder) ListSupportBundles(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSupportBundles", reflect.TypeOf((*MockStore)(nil).ListSupportBundles), appID)
+}
+
+// ListUpdateCheckResults mocks base method.
+func (m *MockStore) ListUpdateCheckResults() ([]types16.CheckResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUpdateCheckResults")
+	ret0, _ := ret[0].([]types16.CheckResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUpdateCheckResults indicates an expected call of ListUpdateCheckResults.
+func (mr *MockStoreMockRecorder) ListUpdateCheckResults() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUpdateCheckResults", reflect.TypeOf((*MockStore)(nil).ListUpdateCheckResults))
+}
+
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers() ([]*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers")
+	ret0, _ := ret[0].([]*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers))
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(webhookID string, limit int) ([]types20.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", webhookID, limit)
+	ret0, _ := ret[0].([]types20.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), webhookID, limit)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockStore) ListWebhooks() ([]types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks")
+	ret0, _ := ret[0].([]types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockStoreMockRecorder) ListWebhooks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockStore)(nil).ListWebhooks))
 }
 
 // MarkAsCurrentDownstreamVersion mocks base method.
@@ -1478,6 +2015,20 @@ func (mr *MockStoreMockRecorder) MarkAsCurrentDownstreamVersion(appID, sequence
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsCurrentDownstreamVersion", reflect.TypeOf((*MockStore)(nil).MarkAsCurrentDownstreamVersion), appID, sequence)
 }
 
+// PruneWebhookDeliveries mocks base method.
+func (m *MockStore) PruneWebhookDeliveries(before time.Time, keepPerWebhook int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneWebhookDeliveries", before, keepPerWebhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PruneWebhookDeliveries indicates an expected call of PruneWebhookDeliveries.
+func (mr *MockStoreMockRecorder) PruneWebhookDeliveries(before, keepPerWebhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).PruneWebhookDeliveries), before, keepPerWebhook)
+}
+
 // RemoveApp mocks base method.
 func (m *MockStore) RemoveApp(appID string) error {
 	m.ctrl.T.Helper()
@@ -1588,6 +2139,20 @@ func (mr *MockStoreMockRecorder) SetAppStatus(appID, resourceStates, updatedAt,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppStatus", reflect.TypeOf((*MockStore)(nil).SetAppStatus), appID, resourceStates, updatedAt, sequence)
 }
 
+// SetApprovedVersions mocks base method.
+func (m *MockStore) SetApprovedVersions(appID string, approved types13.ApprovedVersions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetApprovedVersions", appID, approved)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetApprovedVersions indicates an expected call of SetApprovedVersions.
+func (mr *MockStoreMockRecorder) SetApprovedVersions(appID, approved interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApprovedVersions", reflect.TypeOf((*MockStore)(nil).SetApprovedVersions), appID, approved)
+}
+
 // SetAutoDeploy mocks base method.
 func (m *MockStore) SetAutoDeploy(appID string, autoDeploy types2.AutoDeploy) error {
 	m.ctrl.T.Helper()
@@ -1602,8 +2167,36 @@ func (mr *MockStoreMockRecorder) SetAutoDeploy(appID, autoDeploy interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutoDeploy", reflect.TypeOf((*MockStore)(nil).SetAutoDeploy), appID, autoDeploy)
 }
 
+// SetDownstreamVersionGitOpsCommit mocks base method.
+func (m *MockStore) SetDownstreamVersionGitOpsCommit(appID, clusterID string, sequence int64, commitURL string, pullRequest *types6.PullRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionGitOpsCommit", appID, clusterID, sequence, commitURL, pullRequest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionGitOpsCommit indicates an expected call of SetDownstreamVersionGitOpsCommit.
+func (mr *MockStoreMockRecorder) SetDownstreamVersionGitOpsCommit(appID, clusterID, sequence, commitURL, pullRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionGitOpsCommit", reflect.TypeOf((*MockStore)(nil).SetDownstreamVersionGitOpsCommit), appID, clusterID, sequence, commitURL, pullRequest)
+}
+
+// SetDownstreamVersionPullRequestState mocks base method.
+func (m *MockStore) SetDownstreamVersionPullRequestState(appID, clusterID string, sequence int64, state types6.PullRequestState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionPullRequestState", appID, clusterID, sequence, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionPullRequestState indicates an expected call of SetDownstreamVersionPullRequestState.
+func (mr *MockStoreMockRecorder) SetDownstreamVersionPullRequestState(appID, clusterID, sequence, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionPullRequestState", reflect.TypeOf((*MockStore)(nil).SetDownstreamVersionPullRequestState), appID, clusterID, sequence, state)
+}
+
 // SetDownstreamVersionStatus mocks base method.
-func (m *MockStore) SetDownstreamVersionStatus(appID string, sequence int64, status types11.DownstreamVersionStatus, statusInfo string) error {
+func (m *MockStore) SetDownstreamVersionStatus(appID string, sequence int64, status types14.DownstreamVersionStatus, statusInfo string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetDownstreamVersionStatus", appID, sequence, status, statusInfo)
 	ret0, _ := ret[0].(error)
@@ -1645,6 +2238,20 @@ func (mr *MockStoreMockRecorder) SetEmbeddedClusterInstallCommandRoles(roles int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmbeddedClusterInstallCommandRoles", reflect.TypeOf((*MockStore)(nil).SetEmbeddedClusterInstallCommandRoles), roles)
 }
 
+// SetGitOpsDriftStatus mocks base method.
+func (m *MockStore) SetGitOpsDriftStatus(status types6.DriftStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGitOpsDriftStatus", status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGitOpsDriftStatus indicates an expected call of SetGitOpsDriftStatus.
+func (mr *MockStoreMockRecorder) SetGitOpsDriftStatus(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGitOpsDriftStatus", reflect.TypeOf((*MockStore)(nil).SetGitOpsDriftStatus), status)
+}
+
 // SetIgnorePreflightPermissionErrors mocks base method.
 func (m *MockStore) SetIgnorePreflightPermissionErrors(appID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -1659,6 +2266,20 @@ func (mr *MockStoreMockRecorder) SetIgnorePreflightPermissionErrors(appID, seque
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIgnorePreflightPermissionErrors", reflect.TypeOf((*MockStore)(nil).SetIgnorePreflightPermissionErrors), appID, sequence)
 }
 
+// SetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockStore) SetInstanceSnapshotRetentionPolicy(clusterID string, policy *types7.RetentionPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInstanceSnapshotRetentionPolicy", clusterID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInstanceSnapshotRetentionPolicy indicates an expected call of SetInstanceSnapshotRetentionPolicy.
+func (mr *MockStoreMockRecorder) SetInstanceSnapshotRetentionPolicy(clusterID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockStore)(nil).SetInstanceSnapshotRetentionPolicy), clusterID, policy)
+}
+
 // SetInstanceSnapshotSchedule mocks base method.
 func (m *MockStore) SetInstanceSnapshotSchedule(clusterID, snapshotSchedule string) error {
 	m.ctrl.T.Helper()
@@ -1701,6 +2322,34 @@ func (mr *MockStoreMockRecorder) SetIsKotsadmIDGenerated() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIsKotsadmIDGenerated", reflect.TypeOf((*MockStore)(nil).SetIsKotsadmIDGenerated))
 }
 
+// SetMaintenanceWindow mocks base method.
+func (m *MockStore) SetMaintenanceWindow(appID string, maintenanceWindow *types2.MaintenanceWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaintenanceWindow", appID, maintenanceWindow)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMaintenanceWindow indicates an expected call of SetMaintenanceWindow.
+func (mr *MockStoreMockRecorder) SetMaintenanceWindow(appID, maintenanceWindow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindow", reflect.TypeOf((*MockStore)(nil).SetMaintenanceWindow), appID, maintenanceWindow)
+}
+
+// SetPendingAutoDeploy mocks base method.
+func (m *MockStore) SetPendingAutoDeploy(appID, clusterID string, sequence int64, scheduledAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingAutoDeploy", appID, clusterID, sequence, scheduledAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingAutoDeploy indicates an expected call of SetPendingAutoDeploy.
+func (mr *MockStoreMockRecorder) SetPendingAutoDeploy(appID, clusterID, sequence, scheduledAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingAutoDeploy", reflect.TypeOf((*MockStore)(nil).SetPendingAutoDeploy), appID, clusterID, sequence, scheduledAt)
+}
+
 // SetPreflightProgress mocks base method.
 func (m *MockStore) SetPreflightProgress(appID string, sequence int64, progress string) error {
 	m.ctrl.T.Helper()
@@ -1757,6 +2406,62 @@ func (mr *MockStoreMockRecorder) SetRedactions(bundleID, redacts interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRedactions", reflect.TypeOf((*MockStore)(nil).SetRedactions), bundleID, redacts)
 }
 
+// SetRestoreVerificationNextAt mocks base method.
+func (m *MockStore) SetRestoreVerificationNextAt(appID string, nextAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRestoreVerificationNextAt", appID, nextAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRestoreVerificationNextAt indicates an expected call of SetRestoreVerificationNextAt.
+func (mr *MockStoreMockRecorder) SetRestoreVerificationNextAt(appID, nextAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRestoreVerificationNextAt", reflect.TypeOf((*MockStore)(nil).SetRestoreVerificationNextAt), appID, nextAt)
+}
+
+// SetRestoreVerificationSchedule mocks base method.
+func (m *MockStore) SetRestoreVerificationSchedule(appID, schedule string, nextAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRestoreVerificationSchedule", appID, schedule, nextAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRestoreVerificationSchedule indicates an expected call of SetRestoreVerificationSchedule.
+func (mr *MockStoreMockRecorder) SetRestoreVerificationSchedule(appID, schedule, nextAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRestoreVerificationSchedule", reflect.TypeOf((*MockStore)(nil).SetRestoreVerificationSchedule), appID, schedule, nextAt)
+}
+
+// SetSnapshotHooks mocks base method.
+func (m *MockStore) SetSnapshotHooks(appID string, hooks []types7.OperatorHook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotHooks", appID, hooks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotHooks indicates an expected call of SetSnapshotHooks.
+func (mr *MockStoreMockRecorder) SetSnapshotHooks(appID, hooks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotHooks", reflect.TypeOf((*MockStore)(nil).SetSnapshotHooks), appID, hooks)
+}
+
+// SetSnapshotRetentionPolicy mocks base method.
+func (m *MockStore) SetSnapshotRetentionPolicy(appID string, policy *types7.RetentionPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotRetentionPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotRetentionPolicy indicates an expected call of SetSnapshotRetentionPolicy.
+func (mr *MockStoreMockRecorder) SetSnapshotRetentionPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotRetentionPolicy", reflect.TypeOf((*MockStore)(nil).SetSnapshotRetentionPolicy), appID, policy)
+}
+
 // SetSnapshotSchedule mocks base method.
 func (m *MockStore) SetSnapshotSchedule(appID, snapshotSchedule string) error {
 	m.ctrl.T.Helper()
@@ -1785,6 +2490,20 @@ func (mr *MockStoreMockRecorder) SetSnapshotTTL(appID, snapshotTTL interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotTTL", reflect.TypeOf((*MockStore)(nil).SetSnapshotTTL), appID, snapshotTTL)
 }
 
+// SetSoakPolicy mocks base method.
+func (m *MockStore) SetSoakPolicy(appID string, policy *types13.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSoakPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSoakPolicy indicates an expected call of SetSoakPolicy.
+func (mr *MockStoreMockRecorder) SetSoakPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSoakPolicy", reflect.TypeOf((*MockStore)(nil).SetSoakPolicy), appID, policy)
+}
+
 // SetSupportBundleAnalysis mocks base method.
 func (m *MockStore) SetSupportBundleAnalysis(bundleID string, insights []byte) error {
 	m.ctrl.T.Helper()
@@ -1813,6 +2532,20 @@ func (mr *MockStoreMockRecorder) SetTaskStatus(taskID, message, status interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTaskStatus", reflect.TypeOf((*MockStore)(nil).SetTaskStatus), taskID, message, status)
 }
 
+// SetUpdateCheckResult mocks base method.
+func (m *MockStore) SetUpdateCheckResult(result types16.CheckResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUpdateCheckResult", result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUpdateCheckResult indicates an expected call of SetUpdateCheckResult.
+func (mr *MockStoreMockRecorder) SetUpdateCheckResult(result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdateCheckResult", reflect.TypeOf((*MockStore)(nil).SetUpdateCheckResult), result)
+}
+
 // SetUpdateCheckerSpec mocks base method.
 func (m *MockStore) SetUpdateCheckerSpec(appID, updateCheckerSpec string) error {
 	m.ctrl.T.Helper()
@@ -1827,8 +2560,64 @@ func (mr *MockStoreMockRecorder) SetUpdateCheckerSpec(appID, updateCheckerSpec i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdateCheckerSpec", reflect.TypeOf((*MockStore)(nil).SetUpdateCheckerSpec), appID, updateCheckerSpec)
 }
 
+// SetUserDisabled mocks base method.
+func (m *MockStore) SetUserDisabled(userID string, isDisabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", userID, isDisabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockStoreMockRecorder) SetUserDisabled(userID, isDisabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockStore)(nil).SetUserDisabled), userID, isDisabled)
+}
+
+// SetUserPasswordBcrypt mocks base method.
+func (m *MockStore) SetUserPasswordBcrypt(userID string, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPasswordBcrypt", userID, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPasswordBcrypt indicates an expected call of SetUserPasswordBcrypt.
+func (mr *MockStoreMockRecorder) SetUserPasswordBcrypt(userID, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPasswordBcrypt", reflect.TypeOf((*MockStore)(nil).SetUserPasswordBcrypt), userID, passwordBcrypt)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockStore) SetUserRoles(userID string, roles []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", userID, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockStoreMockRecorder) SetUserRoles(userID, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockStore)(nil).SetUserRoles), userID, roles)
+}
+
+// SetVersionRetentionPolicy mocks base method.
+func (m *MockStore) SetVersionRetentionPolicy(appID string, policy *types19.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersionRetentionPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVersionRetentionPolicy indicates an expected call of SetVersionRetentionPolicy.
+func (mr *MockStoreMockRecorder) SetVersionRetentionPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersionRetentionPolicy", reflect.TypeOf((*MockStore)(nil).SetVersionRetentionPolicy), appID, policy)
+}
+
 // UpdateAppLicense mocks base method.
-func (m *MockStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) (int64, error) {
+func (m *MockStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppLicense", appID, sequence, archiveDir, newLicense, originalLicenseData, channelChanged, failOnVersionCreate, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -1857,7 +2646,7 @@ func (mr *MockStoreMockRecorder) UpdateAppLicenseSyncNow(appID interface{}) *gom
 }
 
 // UpdateAppVersion mocks base method.
-func (m *MockStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) error {
+func (m *MockStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppVersion", appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(error)
@@ -1969,7 +2758,7 @@ func (mr *MockStoreMockRecorder) UpdateSessionExpiresAt(sessionID, expiresAt int
 }
 
 // UpdateSupportBundle mocks base method.
-func (m *MockStore) UpdateSupportBundle(bundle *types12.SupportBundle) error {
+func (m *MockStore) UpdateSupportBundle(bundle *types15.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateSupportBundle", bundle)
 	ret0, _ := ret[0].(error)
@@ -1996,6 +2785,20 @@ func (mr *MockStoreMockRecorder) UpdateTaskStatusTimestamp(taskID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatusTimestamp", reflect.TypeOf((*MockStore)(nil).UpdateTaskStatusTimestamp), taskID)
 }
 
+// UpdateWebhookDelivery mocks base method.
+func (m *MockStore) UpdateWebhookDelivery(delivery types20.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockStoreMockRecorder) UpdateWebhookDelivery(delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).UpdateWebhookDelivery), delivery)
+}
+
 // UploadSupportBundle mocks base method.
 func (m *MockStore) UploadSupportBundle(bundleID, archivePath string, marshalledTree []byte) error {
 	m.ctrl.T.Helper()
@@ -2098,10 +2901,10 @@ func (mr *MockRegistryStoreMockRecorder) GetAppIDsFromRegistry(hostname interfac
 }
 
 // GetRegistryDetailsForApp mocks base method.
-func (m *MockRegistryStore) GetRegistryDetailsForApp(appID string) (types8.RegistrySettings, error) {
+func (m *MockRegistryStore) GetRegistryDetailsForApp(appID string) (types10.RegistrySettings, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRegistryDetailsForApp", appID)
-	ret0, _ := ret[0].(types8.RegistrySettings)
+	ret0, _ := ret[0].(types10.RegistrySettings)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2150,7 +2953,7 @@ func (m *MockSupportBundleStore) EXPECT() *MockSupportBundleStoreMockRecorder {
 }
 
 // CreateInProgressSupportBundle mocks base method.
-func (m *MockSupportBundleStore) CreateInProgressSupportBundle(supportBundle *types12.SupportBundle) error {
+func (m *MockSupportBundleStore) CreateInProgressSupportBundle(supportBundle *types15.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateInProgressSupportBundle", supportBundle)
 	ret0, _ := ret[0].(error)
@@ -2164,10 +2967,10 @@ func (mr *MockSupportBundleStoreMockRecorder) CreateInProgressSupportBundle(supp
 }
 
 // CreateSupportBundle mocks base method.
-func (m *MockSupportBundleStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types12.SupportBundle, error) {
+func (m *MockSupportBundleStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types15.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSupportBundle", bundleID, appID, archivePath, marshalledTree)
-	ret0, _ := ret[0].(*types12.SupportBundle)
+	ret0, _ := ret[0].(*types15.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2208,10 +3011,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetRedactions(bundleID interface{}
 }
 
 // GetSupportBundle mocks base method.
-func (m *MockSupportBundleStore) GetSupportBundle(bundleID string) (*types12.SupportBundle, error) {
+func (m *MockSupportBundleStore) GetSupportBundle(bundleID string) (*types15.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundle", bundleID)
-	ret0, _ := ret[0].(*types12.SupportBundle)
+	ret0, _ := ret[0].(*types15.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2223,10 +3026,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetSupportBundle(bundleID interfac
 }
 
 // GetSupportBundleAnalysis mocks base method.
-func (m *MockSupportBundleStore) GetSupportBundleAnalysis(bundleID string) (*types12.SupportBundleAnalysis, error) {
+func (m *MockSupportBundleStore) GetSupportBundleAnalysis(bundleID string) (*types15.SupportBundleAnalysis, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundleAnalysis", bundleID)
-	ret0, _ := ret[0].(*types12.SupportBundleAnalysis)
+	ret0, _ := ret[0].(*types15.SupportBundleAnalysis)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2253,10 +3056,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetSupportBundleArchive(bundleID i
 }
 
 // ListSupportBundles mocks base method.
-func (m *MockSupportBundleStore) ListSupportBundles(appID string) ([]*types12.SupportBundle, error) {
+func (m *MockSupportBundleStore) ListSupportBundles(appID string) ([]*types15.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListSupportBundles", appID)
-	ret0, _ := ret[0].([]*types12.SupportBundle)
+	ret0, _ := ret[0].([]*types15.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2296,7 +3099,7 @@ func (mr *MockSupportBundleStoreMockRecorder) SetSupportBundleAnalysis(bundleID,
 }
 
 // UpdateSupportBundle mocks base method.
-func (m *MockSupportBundleStore) UpdateSupportBundle(bundle *types12.SupportBundle) error {
+func (m *MockSupportBundleStore) UpdateSupportBundle(bundle *types15.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateSupportBundle", bundle)
 	ret0, _ := ret[0].(error)
@@ -2362,10 +3165,10 @@ func (mr *MockPreflightStoreMockRecorder) GetPreflightProgress(appID, sequence i
 }
 
 // GetPreflightResults mocks base method.
-func (m *MockPreflightStore) GetPreflightResults(appID string, sequence int64) (*types7.PreflightResult, error) {
+func (m *MockPreflightStore) GetPreflightResults(appID string, sequence int64) (*types9.PreflightResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPreflightResults", appID, sequence)
-	ret0, _ := ret[0].(*types7.PreflightResult)
+	ret0, _ := ret[0].(*types9.PreflightResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2670,10 +3473,10 @@ func (m *MockSessionStore) EXPECT() *MockSessionStoreMockRecorder {
 }
 
 // CreateSession mocks base method.
-func (m *MockSessionStore) CreateSession(user *types14.User, issuedAt, expiresAt time.Time, roles []string) (*types10.Session, error) {
+func (m *MockSessionStore) CreateSession(user *types18.User, issuedAt, expiresAt time.Time, roles []string) (*types12.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSession", user, issuedAt, expiresAt, roles)
-	ret0, _ := ret[0].(*types10.Session)
+	ret0, _ := ret[0].(*types12.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2713,10 +3516,10 @@ func (mr *MockSessionStoreMockRecorder) DeleteSession(sessionID interface{}) *go
 }
 
 // GetSession mocks base method.
-func (m *MockSessionStore) GetSession(sessionID string) (*types10.Session, error) {
+func (m *MockSessionStore) GetSession(sessionID string) (*types12.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSession", sessionID)
-	ret0, _ := ret[0].(*types10.Session)
+	ret0, _ := ret[0].(*types12.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2793,6 +3596,87 @@ func (mr *MockAppStatusStoreMockRecorder) SetAppStatus(appID, resourceStates, up
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppStatus", reflect.TypeOf((*MockAppStatusStore)(nil).SetAppStatus), appID, resourceStates, updatedAt, sequence)
 }
 
+// MockAppStatusHistoryStore is a mock of AppStatusHistoryStore interface.
+type MockAppStatusHistoryStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppStatusHistoryStoreMockRecorder
+}
+
+// MockAppStatusHistoryStoreMockRecorder is the mock recorder for MockAppStatusHistoryStore.
+type MockAppStatusHistoryStoreMockRecorder struct {
+	mock *MockAppStatusHistoryStore
+}
+
+// NewMockAppStatusHistoryStore creates a new mock instance.
+func NewMockAppStatusHistoryStore(ctrl *gomock.Controller) *MockAppStatusHistoryStore {
+	mock := &MockAppStatusHistoryStore{ctrl: ctrl}
+	mock.recorder = &MockAppStatusHistoryStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppStatusHistoryStore) EXPECT() *MockAppStatusHistoryStoreMockRecorder {
+	return m.recorder
+}
+
+// AddAppStatusHistoryEntry mocks base method.
+func (m *MockAppStatusHistoryStore) AddAppStatusHistoryEntry(entry types4.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAppStatusHistoryEntry", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAppStatusHistoryEntry indicates an expected call of AddAppStatusHistoryEntry.
+func (mr *MockAppStatusHistoryStoreMockRecorder) AddAppStatusHistoryEntry(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAppStatusHistoryEntry", reflect.TypeOf((*MockAppStatusHistoryStore)(nil).AddAppStatusHistoryEntry), entry)
+}
+
+// DeleteAppStatusHistoryBefore mocks base method.
+func (m *MockAppStatusHistoryStore) DeleteAppStatusHistoryBefore(before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppStatusHistoryBefore", before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppStatusHistoryBefore indicates an expected call of DeleteAppStatusHistoryBefore.
+func (mr *MockAppStatusHistoryStoreMockRecorder) DeleteAppStatusHistoryBefore(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppStatusHistoryBefore", reflect.TypeOf((*MockAppStatusHistoryStore)(nil).DeleteAppStatusHistoryBefore), before)
+}
+
+// GetLatestAppStatusHistoryEntryBefore mocks base method.
+func (m *MockAppStatusHistoryStore) GetLatestAppStatusHistoryEntryBefore(appID string, before time.Time) (*types4.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestAppStatusHistoryEntryBefore", appID, before)
+	ret0, _ := ret[0].(*types4.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestAppStatusHistoryEntryBefore indicates an expected call of GetLatestAppStatusHistoryEntryBefore.
+func (mr *MockAppStatusHistoryStoreMockRecorder) GetLatestAppStatusHistoryEntryBefore(appID, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestAppStatusHistoryEntryBefore", reflect.TypeOf((*MockAppStatusHistoryStore)(nil).GetLatestAppStatusHistoryEntryBefore), appID, before)
+}
+
+// ListAppStatusHistory mocks base method.
+func (m *MockAppStatusHistoryStore) ListAppStatusHistory(appID string, since, until time.Time) ([]types4.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppStatusHistory", appID, since, until)
+	ret0, _ := ret[0].([]types4.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppStatusHistory indicates an expected call of ListAppStatusHistory.
+func (mr *MockAppStatusHistoryStoreMockRecorder) ListAppStatusHistory(appID, since, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppStatusHistory", reflect.TypeOf((*MockAppStatusHistoryStore)(nil).ListAppStatusHistory), appID, since, until)
+}
+
 // MockAppStore is a mock of AppStore interface.
 type MockAppStore struct {
 	ctrl     *gomock.Controller
@@ -3051,38 +3935,80 @@ func (mr *MockAppStoreMockRecorder) SetAutoDeploy(appID, autoDeploy interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutoDeploy", reflect.TypeOf((*MockAppStore)(nil).SetAutoDeploy), appID, autoDeploy)
 }
 
-// SetSnapshotSchedule mocks base method.
-func (m *MockAppStore) SetSnapshotSchedule(appID, snapshotSchedule string) error {
+// SetMaintenanceWindow mocks base method.
+func (m *MockAppStore) SetMaintenanceWindow(appID string, maintenanceWindow *types2.MaintenanceWindow) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetSnapshotSchedule", appID, snapshotSchedule)
+	ret := m.ctrl.Call(m, "SetMaintenanceWindow", appID, maintenanceWindow)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetSnapshotSchedule indicates an expected call of SetSnapshotSchedule.
-func (mr *MockAppStoreMockRecorder) SetSnapshotSchedule(appID, snapshotSchedule interface{}) *gomock.Call {
+// SetMaintenanceWindow indicates an expected call of SetMaintenanceWindow.
+func (mr *MockAppStoreMockRecorder) SetMaintenanceWindow(appID, maintenanceWindow interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotSchedule", reflect.TypeOf((*MockAppStore)(nil).SetSnapshotSchedule), appID, snapshotSchedule)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindow", reflect.TypeOf((*MockAppStore)(nil).SetMaintenanceWindow), appID, maintenanceWindow)
 }
 
-// SetSnapshotTTL mocks base method.
-func (m *MockAppStore) SetSnapshotTTL(appID, snapshotTTL string) error {
+// SetRestoreVerificationNextAt mocks base method.
+func (m *MockAppStore) SetRestoreVerificationNextAt(appID string, nextAt time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetSnapshotTTL", appID, snapshotTTL)
+	ret := m.ctrl.Call(m, "SetRestoreVerificationNextAt", appID, nextAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetSnapshotTTL indicates an expected call of SetSnapshotTTL.
-func (mr *MockAppStoreMockRecorder) SetSnapshotTTL(appID, snapshotTTL interface{}) *gomock.Call {
+// SetRestoreVerificationNextAt indicates an expected call of SetRestoreVerificationNextAt.
+func (mr *MockAppStoreMockRecorder) SetRestoreVerificationNextAt(appID, nextAt interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotTTL", reflect.TypeOf((*MockAppStore)(nil).SetSnapshotTTL), appID, snapshotTTL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRestoreVerificationNextAt", reflect.TypeOf((*MockAppStore)(nil).SetRestoreVerificationNextAt), appID, nextAt)
 }
 
-// SetUpdateCheckerSpec mocks base method.
-func (m *MockAppStore) SetUpdateCheckerSpec(appID, updateCheckerSpec string) error {
+// SetRestoreVerificationSchedule mocks base method.
+func (m *MockAppStore) SetRestoreVerificationSchedule(appID, schedule string, nextAt *time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetUpdateCheckerSpec", appID, updateCheckerSpec)
+	ret := m.ctrl.Call(m, "SetRestoreVerificationSchedule", appID, schedule, nextAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRestoreVerificationSchedule indicates an expected call of SetRestoreVerificationSchedule.
+func (mr *MockAppStoreMockRecorder) SetRestoreVerificationSchedule(appID, schedule, nextAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRestoreVerificationSchedule", reflect.TypeOf((*MockAppStore)(nil).SetRestoreVerificationSchedule), appID, schedule, nextAt)
+}
+
+// SetSnapshotSchedule mocks base method.
+func (m *MockAppStore) SetSnapshotSchedule(appID, snapshotSchedule string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotSchedule", appID, snapshotSchedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotSchedule indicates an expected call of SetSnapshotSchedule.
+func (mr *MockAppStoreMockRecorder) SetSnapshotSchedule(appID, snapshotSchedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotSchedule", reflect.TypeOf((*MockAppStore)(nil).SetSnapshotSchedule), appID, snapshotSchedule)
+}
+
+// SetSnapshotTTL mocks base method.
+func (m *MockAppStore) SetSnapshotTTL(appID, snapshotTTL string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotTTL", appID, snapshotTTL)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotTTL indicates an expected call of SetSnapshotTTL.
+func (mr *MockAppStoreMockRecorder) SetSnapshotTTL(appID, snapshotTTL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotTTL", reflect.TypeOf((*MockAppStore)(nil).SetSnapshotTTL), appID, snapshotTTL)
+}
+
+// SetUpdateCheckerSpec mocks base method.
+func (m *MockAppStore) SetUpdateCheckerSpec(appID, updateCheckerSpec string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUpdateCheckerSpec", appID, updateCheckerSpec)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
@@ -3144,6 +4070,20 @@ func (mr *MockDownstreamStoreMockRecorder) AddDownstreamVersionsDetails(appID, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDownstreamVersionsDetails", reflect.TypeOf((*MockDownstreamStore)(nil).AddDownstreamVersionsDetails), appID, clusterID, versions, checkIfDeployable)
 }
 
+// ClearPendingAutoDeploy mocks base method.
+func (m *MockDownstreamStore) ClearPendingAutoDeploy(appID, clusterID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearPendingAutoDeploy", appID, clusterID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearPendingAutoDeploy indicates an expected call of ClearPendingAutoDeploy.
+func (mr *MockDownstreamStoreMockRecorder) ClearPendingAutoDeploy(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearPendingAutoDeploy", reflect.TypeOf((*MockDownstreamStore)(nil).ClearPendingAutoDeploy), appID, clusterID)
+}
+
 // DeleteDownstreamDeployStatus mocks base method.
 func (m *MockDownstreamStore) DeleteDownstreamDeployStatus(appID, clusterID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -3264,10 +4204,10 @@ func (mr *MockDownstreamStoreMockRecorder) GetDownstreamVersionSource(appID, seq
 }
 
 // GetDownstreamVersionStatus mocks base method.
-func (m *MockDownstreamStore) GetDownstreamVersionStatus(appID string, sequence int64) (types11.DownstreamVersionStatus, error) {
+func (m *MockDownstreamStore) GetDownstreamVersionStatus(appID string, sequence int64) (types14.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDownstreamVersionStatus", appID, sequence)
-	ret0, _ := ret[0].(types11.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types14.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3325,6 +4265,21 @@ func (mr *MockDownstreamStoreMockRecorder) GetLatestDeployableDownstreamVersion(
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDeployableDownstreamVersion", reflect.TypeOf((*MockDownstreamStore)(nil).GetLatestDeployableDownstreamVersion), appID, clusterID)
 }
 
+// GetLatestGitOpsCommittedParentSequence mocks base method.
+func (m *MockDownstreamStore) GetLatestGitOpsCommittedParentSequence(appID, clusterID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestGitOpsCommittedParentSequence", appID, clusterID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestGitOpsCommittedParentSequence indicates an expected call of GetLatestGitOpsCommittedParentSequence.
+func (mr *MockDownstreamStoreMockRecorder) GetLatestGitOpsCommittedParentSequence(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestGitOpsCommittedParentSequence", reflect.TypeOf((*MockDownstreamStore)(nil).GetLatestGitOpsCommittedParentSequence), appID, clusterID)
+}
+
 // GetParentSequenceForSequence mocks base method.
 func (m *MockDownstreamStore) GetParentSequenceForSequence(appID, clusterID string, sequence int64) (int64, error) {
 	m.ctrl.T.Helper()
@@ -3356,10 +4311,10 @@ func (mr *MockDownstreamStoreMockRecorder) GetPreviouslyDeployedSequence(appID,
 }
 
 // GetStatusForVersion mocks base method.
-func (m *MockDownstreamStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types11.DownstreamVersionStatus, error) {
+func (m *MockDownstreamStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types14.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStatusForVersion", appID, clusterID, sequence)
-	ret0, _ := ret[0].(types11.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types14.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3401,6 +4356,36 @@ func (mr *MockDownstreamStoreMockRecorder) IsDownstreamDeploySuccessful(appID, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDownstreamDeploySuccessful", reflect.TypeOf((*MockDownstreamStore)(nil).IsDownstreamDeploySuccessful), appID, clusterID, sequence)
 }
 
+// ListOpenGitOpsPullRequests mocks base method.
+func (m *MockDownstreamStore) ListOpenGitOpsPullRequests() ([]types6.DownstreamPullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenGitOpsPullRequests")
+	ret0, _ := ret[0].([]types6.DownstreamPullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenGitOpsPullRequests indicates an expected call of ListOpenGitOpsPullRequests.
+func (mr *MockDownstreamStoreMockRecorder) ListOpenGitOpsPullRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenGitOpsPullRequests", reflect.TypeOf((*MockDownstreamStore)(nil).ListOpenGitOpsPullRequests))
+}
+
+// ListPendingAutoDeploys mocks base method.
+func (m *MockDownstreamStore) ListPendingAutoDeploys() ([]types0.PendingAutoDeploy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingAutoDeploys")
+	ret0, _ := ret[0].([]types0.PendingAutoDeploy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingAutoDeploys indicates an expected call of ListPendingAutoDeploys.
+func (mr *MockDownstreamStoreMockRecorder) ListPendingAutoDeploys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingAutoDeploys", reflect.TypeOf((*MockDownstreamStore)(nil).ListPendingAutoDeploys))
+}
+
 // MarkAsCurrentDownstreamVersion mocks base method.
 func (m *MockDownstreamStore) MarkAsCurrentDownstreamVersion(appID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -3415,8 +4400,36 @@ func (mr *MockDownstreamStoreMockRecorder) MarkAsCurrentDownstreamVersion(appID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsCurrentDownstreamVersion", reflect.TypeOf((*MockDownstreamStore)(nil).MarkAsCurrentDownstreamVersion), appID, sequence)
 }
 
+// SetDownstreamVersionGitOpsCommit mocks base method.
+func (m *MockDownstreamStore) SetDownstreamVersionGitOpsCommit(appID, clusterID string, sequence int64, commitURL string, pullRequest *types6.PullRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionGitOpsCommit", appID, clusterID, sequence, commitURL, pullRequest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionGitOpsCommit indicates an expected call of SetDownstreamVersionGitOpsCommit.
+func (mr *MockDownstreamStoreMockRecorder) SetDownstreamVersionGitOpsCommit(appID, clusterID, sequence, commitURL, pullRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionGitOpsCommit", reflect.TypeOf((*MockDownstreamStore)(nil).SetDownstreamVersionGitOpsCommit), appID, clusterID, sequence, commitURL, pullRequest)
+}
+
+// SetDownstreamVersionPullRequestState mocks base method.
+func (m *MockDownstreamStore) SetDownstreamVersionPullRequestState(appID, clusterID string, sequence int64, state types6.PullRequestState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionPullRequestState", appID, clusterID, sequence, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionPullRequestState indicates an expected call of SetDownstreamVersionPullRequestState.
+func (mr *MockDownstreamStoreMockRecorder) SetDownstreamVersionPullRequestState(appID, clusterID, sequence, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionPullRequestState", reflect.TypeOf((*MockDownstreamStore)(nil).SetDownstreamVersionPullRequestState), appID, clusterID, sequence, state)
+}
+
 // SetDownstreamVersionStatus mocks base method.
-func (m *MockDownstreamStore) SetDownstreamVersionStatus(appID string, sequence int64, status types11.DownstreamVersionStatus, statusInfo string) error {
+func (m *MockDownstreamStore) SetDownstreamVersionStatus(appID string, sequence int64, status types14.DownstreamVersionStatus, statusInfo string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetDownstreamVersionStatus", appID, sequence, status, statusInfo)
 	ret0, _ := ret[0].(error)
@@ -3429,6 +4442,20 @@ func (mr *MockDownstreamStoreMockRecorder) SetDownstreamVersionStatus(appID, seq
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionStatus", reflect.TypeOf((*MockDownstreamStore)(nil).SetDownstreamVersionStatus), appID, sequence, status, statusInfo)
 }
 
+// SetPendingAutoDeploy mocks base method.
+func (m *MockDownstreamStore) SetPendingAutoDeploy(appID, clusterID string, sequence int64, scheduledAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingAutoDeploy", appID, clusterID, sequence, scheduledAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingAutoDeploy indicates an expected call of SetPendingAutoDeploy.
+func (mr *MockDownstreamStoreMockRecorder) SetPendingAutoDeploy(appID, clusterID, sequence, scheduledAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingAutoDeploy", reflect.TypeOf((*MockDownstreamStore)(nil).SetPendingAutoDeploy), appID, clusterID, sequence, scheduledAt)
+}
+
 // UpdateDownstreamDeployStatus mocks base method.
 func (m *MockDownstreamStore) UpdateDownstreamDeployStatus(appID, clusterID string, sequence int64, isError bool, output types0.DownstreamOutput) error {
 	m.ctrl.T.Helper()
@@ -3443,6 +4470,58 @@ func (mr *MockDownstreamStoreMockRecorder) UpdateDownstreamDeployStatus(appID, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDownstreamDeployStatus", reflect.TypeOf((*MockDownstreamStore)(nil).UpdateDownstreamDeployStatus), appID, clusterID, sequence, isError, output)
 }
 
+// MockGitOpsDriftStore is a mock of GitOpsDriftStore interface.
+type MockGitOpsDriftStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockGitOpsDriftStoreMockRecorder
+}
+
+// MockGitOpsDriftStoreMockRecorder is the mock recorder for MockGitOpsDriftStore.
+type MockGitOpsDriftStoreMockRecorder struct {
+	mock *MockGitOpsDriftStore
+}
+
+// NewMockGitOpsDriftStore creates a new mock instance.
+func NewMockGitOpsDriftStore(ctrl *gomock.Controller) *MockGitOpsDriftStore {
+	mock := &MockGitOpsDriftStore{ctrl: ctrl}
+	mock.recorder = &MockGitOpsDriftStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGitOpsDriftStore) EXPECT() *MockGitOpsDriftStoreMockRecorder {
+	return m.recorder
+}
+
+// GetGitOpsDriftStatus mocks base method.
+func (m *MockGitOpsDriftStore) GetGitOpsDriftStatus(appID, clusterID string) (*types6.DriftStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGitOpsDriftStatus", appID, clusterID)
+	ret0, _ := ret[0].(*types6.DriftStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGitOpsDriftStatus indicates an expected call of GetGitOpsDriftStatus.
+func (mr *MockGitOpsDriftStoreMockRecorder) GetGitOpsDriftStatus(appID, clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGitOpsDriftStatus", reflect.TypeOf((*MockGitOpsDriftStore)(nil).GetGitOpsDriftStatus), appID, clusterID)
+}
+
+// SetGitOpsDriftStatus mocks base method.
+func (m *MockGitOpsDriftStore) SetGitOpsDriftStatus(status types6.DriftStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGitOpsDriftStatus", status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGitOpsDriftStatus indicates an expected call of SetGitOpsDriftStatus.
+func (mr *MockGitOpsDriftStoreMockRecorder) SetGitOpsDriftStatus(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGitOpsDriftStatus", reflect.TypeOf((*MockGitOpsDriftStore)(nil).SetGitOpsDriftStatus), status)
+}
+
 // MockSnapshotStore is a mock of SnapshotStore interface.
 type MockSnapshotStore struct {
 	ctrl     *gomock.Controller
@@ -3522,11 +4601,56 @@ func (mr *MockSnapshotStoreMockRecorder) DeletePendingScheduledSnapshots(appID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingScheduledSnapshots", reflect.TypeOf((*MockSnapshotStore)(nil).DeletePendingScheduledSnapshots), appID)
 }
 
+// GetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockSnapshotStore) GetInstanceSnapshotRetentionPolicy(clusterID string) (*types7.RetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceSnapshotRetentionPolicy", clusterID)
+	ret0, _ := ret[0].(*types7.RetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceSnapshotRetentionPolicy indicates an expected call of GetInstanceSnapshotRetentionPolicy.
+func (mr *MockSnapshotStoreMockRecorder) GetInstanceSnapshotRetentionPolicy(clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockSnapshotStore)(nil).GetInstanceSnapshotRetentionPolicy), clusterID)
+}
+
+// GetSnapshotHooks mocks base method.
+func (m *MockSnapshotStore) GetSnapshotHooks(appID string) ([]types7.OperatorHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotHooks", appID)
+	ret0, _ := ret[0].([]types7.OperatorHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotHooks indicates an expected call of GetSnapshotHooks.
+func (mr *MockSnapshotStoreMockRecorder) GetSnapshotHooks(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotHooks", reflect.TypeOf((*MockSnapshotStore)(nil).GetSnapshotHooks), appID)
+}
+
+// GetSnapshotRetentionPolicy mocks base method.
+func (m *MockSnapshotStore) GetSnapshotRetentionPolicy(appID string) (*types7.RetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotRetentionPolicy", appID)
+	ret0, _ := ret[0].(*types7.RetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotRetentionPolicy indicates an expected call of GetSnapshotRetentionPolicy.
+func (mr *MockSnapshotStoreMockRecorder) GetSnapshotRetentionPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotRetentionPolicy", reflect.TypeOf((*MockSnapshotStore)(nil).GetSnapshotRetentionPolicy), appID)
+}
+
 // ListPendingScheduledInstanceSnapshots mocks base method.
-func (m *MockSnapshotStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types5.ScheduledInstanceSnapshot, error) {
+func (m *MockSnapshotStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types7.ScheduledInstanceSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledInstanceSnapshots", clusterID)
-	ret0, _ := ret[0].([]types5.ScheduledInstanceSnapshot)
+	ret0, _ := ret[0].([]types7.ScheduledInstanceSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3538,10 +4662,10 @@ func (mr *MockSnapshotStoreMockRecorder) ListPendingScheduledInstanceSnapshots(c
 }
 
 // ListPendingScheduledSnapshots mocks base method.
-func (m *MockSnapshotStore) ListPendingScheduledSnapshots(appID string) ([]types5.ScheduledSnapshot, error) {
+func (m *MockSnapshotStore) ListPendingScheduledSnapshots(appID string) ([]types7.ScheduledSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledSnapshots", appID)
-	ret0, _ := ret[0].([]types5.ScheduledSnapshot)
+	ret0, _ := ret[0].([]types7.ScheduledSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3552,6 +4676,48 @@ func (mr *MockSnapshotStoreMockRecorder) ListPendingScheduledSnapshots(appID int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingScheduledSnapshots", reflect.TypeOf((*MockSnapshotStore)(nil).ListPendingScheduledSnapshots), appID)
 }
 
+// SetInstanceSnapshotRetentionPolicy mocks base method.
+func (m *MockSnapshotStore) SetInstanceSnapshotRetentionPolicy(clusterID string, policy *types7.RetentionPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInstanceSnapshotRetentionPolicy", clusterID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInstanceSnapshotRetentionPolicy indicates an expected call of SetInstanceSnapshotRetentionPolicy.
+func (mr *MockSnapshotStoreMockRecorder) SetInstanceSnapshotRetentionPolicy(clusterID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstanceSnapshotRetentionPolicy", reflect.TypeOf((*MockSnapshotStore)(nil).SetInstanceSnapshotRetentionPolicy), clusterID, policy)
+}
+
+// SetSnapshotHooks mocks base method.
+func (m *MockSnapshotStore) SetSnapshotHooks(appID string, hooks []types7.OperatorHook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotHooks", appID, hooks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotHooks indicates an expected call of SetSnapshotHooks.
+func (mr *MockSnapshotStoreMockRecorder) SetSnapshotHooks(appID, hooks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotHooks", reflect.TypeOf((*MockSnapshotStore)(nil).SetSnapshotHooks), appID, hooks)
+}
+
+// SetSnapshotRetentionPolicy mocks base method.
+func (m *MockSnapshotStore) SetSnapshotRetentionPolicy(appID string, policy *types7.RetentionPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotRetentionPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotRetentionPolicy indicates an expected call of SetSnapshotRetentionPolicy.
+func (mr *MockSnapshotStoreMockRecorder) SetSnapshotRetentionPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotRetentionPolicy", reflect.TypeOf((*MockSnapshotStore)(nil).SetSnapshotRetentionPolicy), appID, policy)
+}
+
 // UpdateScheduledInstanceSnapshot mocks base method.
 func (m *MockSnapshotStore) UpdateScheduledInstanceSnapshot(snapshotID, backupName string) error {
 	m.ctrl.T.Helper()
@@ -3604,7 +4770,7 @@ func (m *MockVersionStore) EXPECT() *MockVersionStoreMockRecorder {
 }
 
 // CreateAppVersion mocks base method.
-func (m *MockVersionStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) (int64, error) {
+func (m *MockVersionStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateAppVersion", appID, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -3633,7 +4799,7 @@ func (mr *MockVersionStoreMockRecorder) CreateAppVersionArchive(appID, sequence,
 }
 
 // CreatePendingDownloadAppVersion mocks base method.
-func (m *MockVersionStore) CreatePendingDownloadAppVersion(appID string, update types13.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
+func (m *MockVersionStore) CreatePendingDownloadAppVersion(appID string, update types17.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePendingDownloadAppVersion", appID, update, kotsApplication, license)
 	ret0, _ := ret[0].(int64)
@@ -3813,7 +4979,7 @@ func (mr *MockVersionStoreMockRecorder) IsRollbackSupportedForVersion(appID, seq
 }
 
 // IsSnapshotsSupportedForVersion mocks base method.
-func (m *MockVersionStore) IsSnapshotsSupportedForVersion(a *types2.App, sequence int64, renderer types9.Renderer) (bool, error) {
+func (m *MockVersionStore) IsSnapshotsSupportedForVersion(a *types2.App, sequence int64, renderer types11.Renderer) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "IsSnapshotsSupportedForVersion", a, sequence, renderer)
 	ret0, _ := ret[0].(bool)
@@ -3828,7 +4994,7 @@ func (mr *MockVersionStoreMockRecorder) IsSnapshotsSupportedForVersion(a, sequen
 }
 
 // UpdateAppVersion mocks base method.
-func (m *MockVersionStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) error {
+func (m *MockVersionStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppVersion", appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(error)
@@ -3938,7 +5104,7 @@ func (mr *MockLicenseStoreMockRecorder) GetLicenseForAppVersion(appID, sequence
 }
 
 // UpdateAppLicense mocks base method.
-func (m *MockLicenseStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types4.DownstreamGitOps, renderer types9.Renderer) (int64, error) {
+func (m *MockLicenseStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types6.DownstreamGitOps, renderer types11.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppLicense", appID, sequence, archiveDir, newLicense, originalLicenseData, channelChanged, failOnVersionCreate, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -3989,6 +5155,21 @@ func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
 	return m.recorder
 }
 
+// CreateUser mocks base method.
+func (m *MockUserStore) CreateUser(username string, passwordBcrypt []byte, roles []string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", username, passwordBcrypt, roles)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserStoreMockRecorder) CreateUser(username, passwordBcrypt, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserStore)(nil).CreateUser), username, passwordBcrypt, roles)
+}
+
 // FlagInvalidPassword mocks base method.
 func (m *MockUserStore) FlagInvalidPassword() error {
 	m.ctrl.T.Helper()
@@ -4003,6 +5184,20 @@ func (mr *MockUserStoreMockRecorder) FlagInvalidPassword() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagInvalidPassword", reflect.TypeOf((*MockUserStore)(nil).FlagInvalidPassword))
 }
 
+// FlagInvalidUserPassword mocks base method.
+func (m *MockUserStore) FlagInvalidUserPassword(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagInvalidUserPassword", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagInvalidUserPassword indicates an expected call of FlagInvalidUserPassword.
+func (mr *MockUserStoreMockRecorder) FlagInvalidUserPassword(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagInvalidUserPassword", reflect.TypeOf((*MockUserStore)(nil).FlagInvalidUserPassword), userID)
+}
+
 // FlagSuccessfulLogin mocks base method.
 func (m *MockUserStore) FlagSuccessfulLogin() error {
 	m.ctrl.T.Helper()
@@ -4017,6 +5212,20 @@ func (mr *MockUserStoreMockRecorder) FlagSuccessfulLogin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulLogin", reflect.TypeOf((*MockUserStore)(nil).FlagSuccessfulLogin))
 }
 
+// FlagSuccessfulUserLogin mocks base method.
+func (m *MockUserStore) FlagSuccessfulUserLogin(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagSuccessfulUserLogin", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagSuccessfulUserLogin indicates an expected call of FlagSuccessfulUserLogin.
+func (mr *MockUserStoreMockRecorder) FlagSuccessfulUserLogin(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulUserLogin", reflect.TypeOf((*MockUserStore)(nil).FlagSuccessfulUserLogin), userID)
+}
+
 // GetPasswordUpdatedAt mocks base method.
 func (m *MockUserStore) GetPasswordUpdatedAt() (*time.Time, error) {
 	m.ctrl.T.Helper()
@@ -4047,6 +5256,558 @@ func (mr *MockUserStoreMockRecorder) GetSharedPasswordBcrypt() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedPasswordBcrypt", reflect.TypeOf((*MockUserStore)(nil).GetSharedPasswordBcrypt))
 }
 
+// GetUser mocks base method.
+func (m *MockUserStore) GetUser(userID string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userID)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserStoreMockRecorder) GetUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserStore)(nil).GetUser), userID)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockUserStore) GetUserByUsername(username string) (*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", username)
+	ret0, _ := ret[0].(*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockUserStoreMockRecorder) GetUserByUsername(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockUserStore)(nil).GetUserByUsername), username)
+}
+
+// GetUserPasswordBcrypt mocks base method.
+func (m *MockUserStore) GetUserPasswordBcrypt(userID string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPasswordBcrypt", userID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPasswordBcrypt indicates an expected call of GetUserPasswordBcrypt.
+func (mr *MockUserStoreMockRecorder) GetUserPasswordBcrypt(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPasswordBcrypt", reflect.TypeOf((*MockUserStore)(nil).GetUserPasswordBcrypt), userID)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserStore) ListUsers() ([]*types18.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers")
+	ret0, _ := ret[0].([]*types18.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserStoreMockRecorder) ListUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserStore)(nil).ListUsers))
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockUserStore) SetUserDisabled(userID string, isDisabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", userID, isDisabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockUserStoreMockRecorder) SetUserDisabled(userID, isDisabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockUserStore)(nil).SetUserDisabled), userID, isDisabled)
+}
+
+// SetUserPasswordBcrypt mocks base method.
+func (m *MockUserStore) SetUserPasswordBcrypt(userID string, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPasswordBcrypt", userID, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPasswordBcrypt indicates an expected call of SetUserPasswordBcrypt.
+func (mr *MockUserStoreMockRecorder) SetUserPasswordBcrypt(userID, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPasswordBcrypt", reflect.TypeOf((*MockUserStore)(nil).SetUserPasswordBcrypt), userID, passwordBcrypt)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockUserStore) SetUserRoles(userID string, roles []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", userID, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockUserStoreMockRecorder) SetUserRoles(userID, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockUserStore)(nil).SetUserRoles), userID, roles)
+}
+
+// MockAuditStore is a mock of AuditStore interface.
+type MockAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStoreMockRecorder
+}
+
+// MockAuditStoreMockRecorder is the mock recorder for MockAuditStore.
+type MockAuditStoreMockRecorder struct {
+	mock *MockAuditStore
+}
+
+// NewMockAuditStore creates a new mock instance.
+func NewMockAuditStore(ctrl *gomock.Controller) *MockAuditStore {
+	mock := &MockAuditStore{ctrl: ctrl}
+	mock.recorder = &MockAuditStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditStore) EXPECT() *MockAuditStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAuditStore) CreateAuditEvent(event types5.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditStoreMockRecorder) CreateAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAuditStore)(nil).CreateAuditEvent), event)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuditStore) ListAuditEvents(opts types5.ListOptions) ([]types5.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", opts)
+	ret0, _ := ret[0].([]types5.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditStoreMockRecorder) ListAuditEvents(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuditStore)(nil).ListAuditEvents), opts)
+}
+
+// MockSoakStore is a mock of SoakStore interface.
+type MockSoakStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSoakStoreMockRecorder
+}
+
+// MockSoakStoreMockRecorder is the mock recorder for MockSoakStore.
+type MockSoakStoreMockRecorder struct {
+	mock *MockSoakStore
+}
+
+// NewMockSoakStore creates a new mock instance.
+func NewMockSoakStore(ctrl *gomock.Controller) *MockSoakStore {
+	mock := &MockSoakStore{ctrl: ctrl}
+	mock.recorder = &MockSoakStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSoakStore) EXPECT() *MockSoakStoreMockRecorder {
+	return m.recorder
+}
+
+// GetSoakPolicy mocks base method.
+func (m *MockSoakStore) GetSoakPolicy(appID string) (*types13.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSoakPolicy", appID)
+	ret0, _ := ret[0].(*types13.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSoakPolicy indicates an expected call of GetSoakPolicy.
+func (mr *MockSoakStoreMockRecorder) GetSoakPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSoakPolicy", reflect.TypeOf((*MockSoakStore)(nil).GetSoakPolicy), appID)
+}
+
+// ListApprovedVersions mocks base method.
+func (m *MockSoakStore) ListApprovedVersions(appID string) ([]types13.ApprovedVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApprovedVersions", appID)
+	ret0, _ := ret[0].([]types13.ApprovedVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApprovedVersions indicates an expected call of ListApprovedVersions.
+func (mr *MockSoakStoreMockRecorder) ListApprovedVersions(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApprovedVersions", reflect.TypeOf((*MockSoakStore)(nil).ListApprovedVersions), appID)
+}
+
+// SetApprovedVersions mocks base method.
+func (m *MockSoakStore) SetApprovedVersions(appID string, approved types13.ApprovedVersions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetApprovedVersions", appID, approved)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetApprovedVersions indicates an expected call of SetApprovedVersions.
+func (mr *MockSoakStoreMockRecorder) SetApprovedVersions(appID, approved interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApprovedVersions", reflect.TypeOf((*MockSoakStore)(nil).SetApprovedVersions), appID, approved)
+}
+
+// SetSoakPolicy mocks base method.
+func (m *MockSoakStore) SetSoakPolicy(appID string, policy *types13.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSoakPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSoakPolicy indicates an expected call of SetSoakPolicy.
+func (mr *MockSoakStoreMockRecorder) SetSoakPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSoakPolicy", reflect.TypeOf((*MockSoakStore)(nil).SetSoakPolicy), appID, policy)
+}
+
+// MockUpdateCheckStore is a mock of UpdateCheckStore interface.
+type MockUpdateCheckStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdateCheckStoreMockRecorder
+}
+
+// MockUpdateCheckStoreMockRecorder is the mock recorder for MockUpdateCheckStore.
+type MockUpdateCheckStoreMockRecorder struct {
+	mock *MockUpdateCheckStore
+}
+
+// NewMockUpdateCheckStore creates a new mock instance.
+func NewMockUpdateCheckStore(ctrl *gomock.Controller) *MockUpdateCheckStore {
+	mock := &MockUpdateCheckStore{ctrl: ctrl}
+	mock.recorder = &MockUpdateCheckStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdateCheckStore) EXPECT() *MockUpdateCheckStoreMockRecorder {
+	return m.recorder
+}
+
+// GetUpdateCheckResult mocks base method.
+func (m *MockUpdateCheckStore) GetUpdateCheckResult(appID string) (*types16.CheckResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateCheckResult", appID)
+	ret0, _ := ret[0].(*types16.CheckResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateCheckResult indicates an expected call of GetUpdateCheckResult.
+func (mr *MockUpdateCheckStoreMockRecorder) GetUpdateCheckResult(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateCheckResult", reflect.TypeOf((*MockUpdateCheckStore)(nil).GetUpdateCheckResult), appID)
+}
+
+// ListUpdateCheckResults mocks base method.
+func (m *MockUpdateCheckStore) ListUpdateCheckResults() ([]types16.CheckResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUpdateCheckResults")
+	ret0, _ := ret[0].([]types16.CheckResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUpdateCheckResults indicates an expected call of ListUpdateCheckResults.
+func (mr *MockUpdateCheckStoreMockRecorder) ListUpdateCheckResults() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUpdateCheckResults", reflect.TypeOf((*MockUpdateCheckStore)(nil).ListUpdateCheckResults))
+}
+
+// SetUpdateCheckResult mocks base method.
+func (m *MockUpdateCheckStore) SetUpdateCheckResult(result types16.CheckResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUpdateCheckResult", result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUpdateCheckResult indicates an expected call of SetUpdateCheckResult.
+func (mr *MockUpdateCheckStoreMockRecorder) SetUpdateCheckResult(result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdateCheckResult", reflect.TypeOf((*MockUpdateCheckStore)(nil).SetUpdateCheckResult), result)
+}
+
+// MockVersionRetentionStore is a mock of VersionRetentionStore interface.
+type MockVersionRetentionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionRetentionStoreMockRecorder
+}
+
+// MockVersionRetentionStoreMockRecorder is the mock recorder for MockVersionRetentionStore.
+type MockVersionRetentionStoreMockRecorder struct {
+	mock *MockVersionRetentionStore
+}
+
+// NewMockVersionRetentionStore creates a new mock instance.
+func NewMockVersionRetentionStore(ctrl *gomock.Controller) *MockVersionRetentionStore {
+	mock := &MockVersionRetentionStore{ctrl: ctrl}
+	mock.recorder = &MockVersionRetentionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVersionRetentionStore) EXPECT() *MockVersionRetentionStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteAppVersions mocks base method.
+func (m *MockVersionRetentionStore) DeleteAppVersions(appID string, sequences []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppVersions", appID, sequences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppVersions indicates an expected call of DeleteAppVersions.
+func (mr *MockVersionRetentionStoreMockRecorder) DeleteAppVersions(appID, sequences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppVersions", reflect.TypeOf((*MockVersionRetentionStore)(nil).DeleteAppVersions), appID, sequences)
+}
+
+// GetVersionRetentionPolicy mocks base method.
+func (m *MockVersionRetentionStore) GetVersionRetentionPolicy(appID string) (*types19.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersionRetentionPolicy", appID)
+	ret0, _ := ret[0].(*types19.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersionRetentionPolicy indicates an expected call of GetVersionRetentionPolicy.
+func (mr *MockVersionRetentionStoreMockRecorder) GetVersionRetentionPolicy(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersionRetentionPolicy", reflect.TypeOf((*MockVersionRetentionStore)(nil).GetVersionRetentionPolicy), appID)
+}
+
+// ListAppVersionsForRetention mocks base method.
+func (m *MockVersionRetentionStore) ListAppVersionsForRetention(appID string) ([]types19.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppVersionsForRetention", appID)
+	ret0, _ := ret[0].([]types19.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppVersionsForRetention indicates an expected call of ListAppVersionsForRetention.
+func (mr *MockVersionRetentionStoreMockRecorder) ListAppVersionsForRetention(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppVersionsForRetention", reflect.TypeOf((*MockVersionRetentionStore)(nil).ListAppVersionsForRetention), appID)
+}
+
+// SetVersionRetentionPolicy mocks base method.
+func (m *MockVersionRetentionStore) SetVersionRetentionPolicy(appID string, policy *types19.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersionRetentionPolicy", appID, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVersionRetentionPolicy indicates an expected call of SetVersionRetentionPolicy.
+func (mr *MockVersionRetentionStoreMockRecorder) SetVersionRetentionPolicy(appID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersionRetentionPolicy", reflect.TypeOf((*MockVersionRetentionStore)(nil).SetVersionRetentionPolicy), appID, policy)
+}
+
+// MockWebhookStore is a mock of WebhookStore interface.
+type MockWebhookStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookStoreMockRecorder
+}
+
+// MockWebhookStoreMockRecorder is the mock recorder for MockWebhookStore.
+type MockWebhookStoreMockRecorder struct {
+	mock *MockWebhookStore
+}
+
+// NewMockWebhookStore creates a new mock instance.
+func NewMockWebhookStore(ctrl *gomock.Controller) *MockWebhookStore {
+	mock := &MockWebhookStore{ctrl: ctrl}
+	mock.recorder = &MockWebhookStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookStore) EXPECT() *MockWebhookStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookStore) CreateWebhook(url, secret string, eventTypes []string) (*types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", url, secret, eventTypes)
+	ret0, _ := ret[0].(*types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookStoreMockRecorder) CreateWebhook(url, secret, eventTypes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookStore)(nil).CreateWebhook), url, secret, eventTypes)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockWebhookStore) CreateWebhookDelivery(delivery types20.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockWebhookStoreMockRecorder) CreateWebhookDelivery(delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockWebhookStore)(nil).CreateWebhookDelivery), delivery)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookStore) DeleteWebhook(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookStoreMockRecorder) DeleteWebhook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookStore)(nil).DeleteWebhook), id)
+}
+
+// GetWebhook mocks base method.
+func (m *MockWebhookStore) GetWebhook(id string) (*types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", id)
+	ret0, _ := ret[0].(*types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockWebhookStoreMockRecorder) GetWebhook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockWebhookStore)(nil).GetWebhook), id)
+}
+
+// GetWebhookSecret mocks base method.
+func (m *MockWebhookStore) GetWebhookSecret(id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSecret", id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSecret indicates an expected call of GetWebhookSecret.
+func (mr *MockWebhookStoreMockRecorder) GetWebhookSecret(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSecret", reflect.TypeOf((*MockWebhookStore)(nil).GetWebhookSecret), id)
+}
+
+// ListPendingWebhookDeliveries mocks base method.
+func (m *MockWebhookStore) ListPendingWebhookDeliveries() ([]types20.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingWebhookDeliveries")
+	ret0, _ := ret[0].([]types20.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingWebhookDeliveries indicates an expected call of ListPendingWebhookDeliveries.
+func (mr *MockWebhookStoreMockRecorder) ListPendingWebhookDeliveries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingWebhookDeliveries", reflect.TypeOf((*MockWebhookStore)(nil).ListPendingWebhookDeliveries))
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhookStore) ListWebhookDeliveries(webhookID string, limit int) ([]types20.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", webhookID, limit)
+	ret0, _ := ret[0].([]types20.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookStoreMockRecorder) ListWebhookDeliveries(webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhookStore)(nil).ListWebhookDeliveries), webhookID, limit)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockWebhookStore) ListWebhooks() ([]types20.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks")
+	ret0, _ := ret[0].([]types20.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockWebhookStoreMockRecorder) ListWebhooks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockWebhookStore)(nil).ListWebhooks))
+}
+
+// PruneWebhookDeliveries mocks base method.
+func (m *MockWebhookStore) PruneWebhookDeliveries(before time.Time, keepPerWebhook int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneWebhookDeliveries", before, keepPerWebhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PruneWebhookDeliveries indicates an expected call of PruneWebhookDeliveries.
+func (mr *MockWebhookStoreMockRecorder) PruneWebhookDeliveries(before, keepPerWebhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneWebhookDeliveries", reflect.TypeOf((*MockWebhookStore)(nil).PruneWebhookDeliveries), before, keepPerWebhook)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockWebhookStore) UpdateWebhookDelivery(delivery types20.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockWebhookStoreMockRecorder) UpdateWebhookDelivery(delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockWebhookStore)(nil).UpdateWebhookDelivery), delivery)
+}
+
 // MockClusterStore is a mock of ClusterStore interface.
 type MockClusterStore struct {
 	ctrl     *gomock.Controller
@@ -4182,10 +5943,10 @@ func (m *MockInstallationStore) EXPECT() *MockInstallationStoreMockRecorder {
 }
 
 // GetPendingInstallationStatus mocks base method.
-func (m *MockInstallationStore) GetPendingInstallationStatus() (*types6.InstallStatus, error) {
+func (m *MockInstallationStore) GetPendingInstallationStatus() (*types8.InstallStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPendingInstallationStatus")
-	ret0, _ := ret[0].(*types6.InstallStatus)
+	ret0, _ := ret[0].(*types8.InstallStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -9,6 +9,8 @@ import (
 	versiontypes "github.com/replicatedhq/kots/pkg/api/version/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	appstatushistorytypes "github.com/replicatedhq/kots/pkg/appstatushistory/types"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
 	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
 	installationtypes "github.com/replicatedhq/kots/pkg/online/types"
@@ -16,10 +18,14 @@ import (
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	rendertypes "github.com/replicatedhq/kots/pkg/render/types"
 	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
 	"github.com/replicatedhq/kots/pkg/store/types"
 	supportbundletypes "github.com/replicatedhq/kots/pkg/supportbundle/types"
+	updatecheckertypes "github.com/replicatedhq/kots/pkg/updatechecker/types"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
 	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	versionretentiontypes "github.com/replicatedhq/kots/pkg/versionretention/types"
+	webhooktypes "github.com/replicatedhq/kots/pkg/webhooks/types"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	troubleshootredact "github.com/replicatedhq/troubleshoot/pkg/redact"
 )
@@ -34,6 +40,7 @@ type Store interface {
 	TaskStore
 	SessionStore
 	AppStatusStore
+	AppStatusHistoryStore
 	AppStore
 	DownstreamStore
 	VersionStore
@@ -46,6 +53,12 @@ type Store interface {
 	EmbeddedStore
 	BrandingStore
 	EmbeddedClusterStore
+	AuditStore
+	VersionRetentionStore
+	SoakStore
+	UpdateCheckStore
+	WebhookStore
+	GitOpsDriftStore
 
 	Init() error // this may need options
 	WaitForReady(ctx context.Context) error
@@ -118,6 +131,13 @@ type AppStatusStore interface {
 	SetAppStatus(appID string, resourceStates appstatetypes.ResourceStates, updatedAt time.Time, sequence int64) error
 }
 
+type AppStatusHistoryStore interface {
+	AddAppStatusHistoryEntry(entry appstatushistorytypes.Entry) error
+	ListAppStatusHistory(appID string, since time.Time, until time.Time) ([]appstatushistorytypes.Entry, error)
+	GetLatestAppStatusHistoryEntryBefore(appID string, before time.Time) (*appstatushistorytypes.Entry, error)
+	DeleteAppStatusHistoryBefore(before time.Time) error
+}
+
 type AppStore interface {
 	AddAppToAllDownstreams(appID string) error
 	SetAppInstallState(appID string, state string) error
@@ -134,8 +154,11 @@ type AppStore interface {
 	IsGitOpsEnabledForApp(appID string) (bool, error)
 	SetUpdateCheckerSpec(appID string, updateCheckerSpec string) error
 	SetAutoDeploy(appID string, autoDeploy apptypes.AutoDeploy) error
+	SetMaintenanceWindow(appID string, maintenanceWindow *apptypes.MaintenanceWindow) error
 	SetSnapshotTTL(appID string, snapshotTTL string) error
 	SetSnapshotSchedule(appID string, snapshotSchedule string) error
+	SetRestoreVerificationSchedule(appID string, schedule string, nextAt *time.Time) error
+	SetRestoreVerificationNextAt(appID string, nextAt time.Time) error
 	RemoveApp(appID string) error
 	SetAppChannelChanged(appID string, channelChanged bool) error
 }
@@ -166,6 +189,19 @@ type DownstreamStore interface {
 	IsDownstreamDeploySuccessful(appID string, clusterID string, sequence int64) (bool, error)
 	UpdateDownstreamDeployStatus(appID string, clusterID string, sequence int64, isError bool, output downstreamtypes.DownstreamOutput) error
 	DeleteDownstreamDeployStatus(appID string, clusterID string, sequence int64) error
+	GetLatestGitOpsCommittedParentSequence(appID string, clusterID string) (int64, error)
+	SetDownstreamVersionGitOpsCommit(appID string, clusterID string, sequence int64, commitURL string, pullRequest *gitopstypes.PullRequest) error
+	ListOpenGitOpsPullRequests() ([]gitopstypes.DownstreamPullRequest, error)
+	SetDownstreamVersionPullRequestState(appID string, clusterID string, sequence int64, state gitopstypes.PullRequestState) error
+	SetPendingAutoDeploy(appID string, clusterID string, sequence int64, scheduledAt time.Time) error
+	ClearPendingAutoDeploy(appID string, clusterID string) error
+	ListPendingAutoDeploys() ([]downstreamtypes.PendingAutoDeploy, error)
+}
+
+type GitOpsDriftStore interface {
+	SetGitOpsDriftStatus(status gitopstypes.DriftStatus) error
+	// GetGitOpsDriftStatus returns the result of the last drift check of the downstream, or nil if it has not been checked
+	GetGitOpsDriftStatus(appID string, clusterID string) (*gitopstypes.DriftStatus, error)
 }
 
 type SnapshotStore interface {
@@ -178,6 +214,13 @@ type SnapshotStore interface {
 	UpdateScheduledInstanceSnapshot(snapshotID string, backupName string) error
 	DeletePendingScheduledInstanceSnapshots(clusterID string) error
 	CreateScheduledInstanceSnapshot(snapshotID string, clusterID string, timestamp time.Time) error
+
+	GetSnapshotRetentionPolicy(appID string) (*snapshottypes.RetentionPolicy, error)
+	SetSnapshotRetentionPolicy(appID string, policy *snapshottypes.RetentionPolicy) error
+	GetInstanceSnapshotRetentionPolicy(clusterID string) (*snapshottypes.RetentionPolicy, error)
+	SetInstanceSnapshotRetentionPolicy(clusterID string, policy *snapshottypes.RetentionPolicy) error
+	GetSnapshotHooks(appID string) ([]snapshottypes.OperatorHook, error)
+	SetSnapshotHooks(appID string, hooks []snapshottypes.OperatorHook) error
 }
 
 type VersionStore interface {
@@ -216,6 +259,55 @@ type UserStore interface {
 	GetPasswordUpdatedAt() (*time.Time, error)
 	FlagInvalidPassword() error
 	FlagSuccessfulLogin() error
+
+	ListUsers() ([]*usertypes.User, error)
+	GetUser(userID string) (*usertypes.User, error)
+	GetUserByUsername(username string) (*usertypes.User, error)
+	GetUserPasswordBcrypt(userID string) ([]byte, error)
+	CreateUser(username string, passwordBcrypt []byte, roles []string) (*usertypes.User, error)
+	SetUserPasswordBcrypt(userID string, passwordBcrypt []byte) error
+	SetUserRoles(userID string, roles []string) error
+	SetUserDisabled(userID string, isDisabled bool) error
+	FlagInvalidUserPassword(userID string) error
+	FlagSuccessfulUserLogin(userID string) error
+}
+
+type AuditStore interface {
+	CreateAuditEvent(event audittypes.Event) error
+	ListAuditEvents(opts audittypes.ListOptions) ([]audittypes.Event, error)
+}
+
+type SoakStore interface {
+	GetSoakPolicy(appID string) (*soaktypes.Policy, error)
+	SetSoakPolicy(appID string, policy *soaktypes.Policy) error
+	SetApprovedVersions(appID string, approved soaktypes.ApprovedVersions) error
+	ListApprovedVersions(appID string) ([]soaktypes.ApprovedVersions, error)
+}
+
+type UpdateCheckStore interface {
+	SetUpdateCheckResult(result updatecheckertypes.CheckResult) error
+	GetUpdateCheckResult(appID string) (*updatecheckertypes.CheckResult, error)
+	ListUpdateCheckResults() ([]updatecheckertypes.CheckResult, error)
+}
+
+type VersionRetentionStore interface {
+	GetVersionRetentionPolicy(appID string) (*versionretentiontypes.Policy, error)
+	SetVersionRetentionPolicy(appID string, policy *versionretentiontypes.Policy) error
+	ListAppVersionsForRetention(appID string) ([]versionretentiontypes.Version, error)
+	DeleteAppVersions(appID string, sequences []int64) error
+}
+
+type WebhookStore interface {
+	ListWebhooks() ([]webhooktypes.Webhook, error)
+	GetWebhook(id string) (*webhooktypes.Webhook, error)
+	GetWebhookSecret(id string) (string, error)
+	CreateWebhook(url string, secret string, eventTypes []string) (*webhooktypes.Webhook, error)
+	DeleteWebhook(id string) error
+	CreateWebhookDelivery(delivery webhooktypes.Delivery) error
+	UpdateWebhookDelivery(delivery webhooktypes.Delivery) error
+	ListWebhookDeliveries(webhookID string, limit int) ([]webhooktypes.Delivery, error)
+	ListPendingWebhookDeliveries() ([]webhooktypes.Delivery, error)
+	PruneWebhookDeliveries(before time.Time, keepPerWebhook int) error
 }
 
 type ClusterStore interface {
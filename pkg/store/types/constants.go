@@ -11,4 +11,5 @@ const (
 	VersionDeploying        DownstreamVersionStatus = "deploying"         // is being deployed
 	VersionDeployed         DownstreamVersionStatus = "deployed"          // did deploy successfully
 	VersionFailed           DownstreamVersionStatus = "failed"            // did not deploy successfully
+	VersionRolledBack       DownstreamVersionStatus = "rolled_back"       // deployed but did not become healthy, previous version was redeployed
 )
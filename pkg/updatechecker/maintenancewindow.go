@@ -0,0 +1,149 @@
+package updatechecker
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/maintenancewindow"
+	cron "github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// pendingAutoDeployCronSpec - how often versions waiting for a maintenance window are checked
+	pendingAutoDeployCronSpec = "@every 1m"
+)
+
+// StartPendingAutoDeployCronJob starts the job that deploys versions that were found outside of their app's
+// maintenance window once the window opens
+func StartPendingAutoDeployCronJob() error {
+	logger.Debug("starting pending auto-deploy cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(pendingAutoDeployCronSpec, func() {
+		pending, err := store.ListPendingAutoDeploys()
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to list pending auto-deploys"))
+			return
+		}
+		now := time.Now()
+		for _, p := range pending {
+			if p.ScheduledAt.After(now) {
+				continue
+			}
+			if err := deployPendingVersion(p, now); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to deploy pending version of app %s", p.AppID))
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// queueIfMaintenanceWindowIsClosed marks the version as pending until the next opening of the window
+// and returns true if the window is closed
+func queueIfMaintenanceWindowIsClosed(appID string, clusterID string, sequence int64, maintenanceWindow *apptypes.MaintenanceWindow) (bool, error) {
+	if maintenanceWindow == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	nextOpening, err := maintenancewindow.NextOpening(*maintenanceWindow, now)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get next maintenance window")
+	}
+	if !nextOpening.After(now) {
+		return false, nil
+	}
+
+	logger.Info("maintenance window is closed, queueing auto-deploy",
+		zap.String("appID", appID),
+		zap.Int64("sequence", sequence),
+		zap.Time("scheduledAt", nextOpening))
+	if err := store.SetPendingAutoDeploy(appID, clusterID, sequence, nextOpening); err != nil {
+		return false, errors.Wrap(err, "failed to set pending auto-deploy")
+	}
+
+	return true, nil
+}
+
+// ReschedulePendingAutoDeploys moves the versions of the app that are waiting for a maintenance window
+// to the next opening of the new window. They are deployed by the next run of the pending auto-deploy job
+// if the window is open or was removed.
+func ReschedulePendingAutoDeploys(appID string, maintenanceWindow *apptypes.MaintenanceWindow) error {
+	pending, err := store.ListPendingAutoDeploys()
+	if err != nil {
+		return errors.Wrap(err, "failed to list pending auto-deploys")
+	}
+
+	now := time.Now()
+	scheduledAt := now
+	if maintenanceWindow != nil {
+		scheduledAt, err = maintenancewindow.NextOpening(*maintenanceWindow, now)
+		if err != nil {
+			return errors.Wrap(err, "failed to get next maintenance window")
+		}
+	}
+
+	for _, p := range pending {
+		if p.AppID != appID || p.ScheduledAt.Equal(scheduledAt) {
+			continue
+		}
+		if err := store.SetPendingAutoDeploy(p.AppID, p.ClusterID, p.Sequence, scheduledAt); err != nil {
+			return errors.Wrapf(err, "failed to reschedule pending auto-deploy of sequence %d", p.Sequence)
+		}
+	}
+
+	return nil
+}
+
+// deployPendingVersion runs the auto-deploy again when the window opens, so that the newest version that is allowed
+// at that time is deployed. The pending version is cleared first, since waiting for preflights can take longer than
+// the interval of the job, and is not retried if it cannot be deployed.
+func deployPendingVersion(p downstreamtypes.PendingAutoDeploy, now time.Time) error {
+	a, err := store.GetApp(p.AppID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get app")
+	}
+
+	if a.MaintenanceWindow != nil {
+		nextOpening, err := maintenancewindow.NextOpening(*a.MaintenanceWindow, now)
+		if err != nil {
+			return errors.Wrap(err, "failed to get next maintenance window")
+		}
+		if nextOpening.After(now) {
+			// the window was changed after the version was queued
+			if err := store.SetPendingAutoDeploy(p.AppID, p.ClusterID, p.Sequence, nextOpening); err != nil {
+				return errors.Wrap(err, "failed to reschedule pending auto-deploy")
+			}
+			return nil
+		}
+	}
+
+	logger.Info("maintenance window is open, deploying pending version",
+		zap.String("slug", a.Slug),
+		zap.Int64("sequence", p.Sequence))
+
+	if err := store.ClearPendingAutoDeploy(p.AppID, p.ClusterID); err != nil {
+		return errors.Wrap(err, "failed to clear pending auto-deploy")
+	}
+
+	opts := CheckForUpdatesOpts{
+		AppID:       p.AppID,
+		IsAutomatic: true,
+	}
+	if err := autoDeploy(opts, p.ClusterID, a.AutoDeploy); err != nil {
+		return errors.Wrap(err, "failed to auto deploy")
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package updatechecker
+
+import (
+	"hash/fnv"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	updatecheckertypes "github.com/replicatedhq/kots/pkg/updatechecker/types"
+	"github.com/replicatedhq/kots/pkg/util"
+	cron "github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxUpdateCheckJitter is the longest an app's scheduled update checks are delayed by
+	maxUpdateCheckJitter = 5 * time.Minute
+
+	// failed update checks are retried after initialRetryDelay, doubling after every failure up to maxRetryDelay
+	initialRetryDelay = time.Minute
+	maxRetryDelay     = time.Hour
+)
+
+// upstreamError is returned when an update check fails to get the license or the updates from the upstream.
+// These and network errors are the only failures that are retried, other failures are not fixed by retrying.
+type upstreamError struct {
+	err error
+}
+
+func (e upstreamError) Error() string {
+	return e.err.Error()
+}
+
+func (e upstreamError) Cause() error {
+	return e.err
+}
+
+func (e upstreamError) Unwrap() error {
+	return e.err
+}
+
+// isRetryable returns true if the update check failed to reach the upstream
+func isRetryable(checkErr error) bool {
+	if cause, ok := errors.Cause(checkErr).(util.ActionableError); ok && cause.NoRetry {
+		return false
+	}
+
+	var upstreamErr upstreamError
+	if errors.As(checkErr, &upstreamErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(checkErr, &netErr)
+}
+
+type retry struct {
+	failures int
+	timer    *time.Timer
+	at       time.Time
+}
+
+// jitteredSchedule delays every activation of a schedule by the same offset
+type jitteredSchedule struct {
+	schedule cron.Schedule
+	offset   time.Duration
+}
+
+// newJitteredSchedule returns the schedule delayed by an offset that is derived from the app id,
+// so that it does not change when the schedule is reconfigured or the admin console restarts
+func newJitteredSchedule(schedule cron.Schedule, appID string) jitteredSchedule {
+	h := fnv.New32a()
+	h.Write([]byte(appID))
+	offset := time.Duration(h.Sum32()%uint32(maxUpdateCheckJitter/time.Second)) * time.Second
+	return jitteredSchedule{schedule: schedule, offset: offset}
+}
+
+func (s jitteredSchedule) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.offset)
+}
+
+// retryDelay returns how long to wait before retrying after the given number of consecutive failures
+func retryDelay(failures int) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// scheduleRetry checks for updates again after a backoff, unless the next scheduled check is sooner
+// or the error cannot be fixed by retrying
+func scheduleRetry(appID string, appSlug string, checkErr error) {
+	if !isRetryable(checkErr) {
+		resetRetries(appID)
+		return
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	id, ok := entries[appID]
+	if !ok {
+		// update checks were disabled while checking
+		return
+	}
+
+	r, ok := retries[appID]
+	if !ok {
+		r = &retry{}
+		retries[appID] = r
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.failures++
+
+	at := time.Now().Add(retryDelay(r.failures))
+	if next := getScheduler().Entry(id).Next; !next.IsZero() && !at.Before(next) {
+		return
+	}
+
+	logger.Info("retrying failed update check",
+		zap.String("slug", appSlug),
+		zap.Int("failures", r.failures),
+		zap.Time("at", at))
+
+	// retries do not run in the scheduler, recover from panics the same way it does
+	retryJob := cron.NewChain(cron.Recover(cron.DefaultLogger)).Then(cron.FuncJob(func() {
+		runScheduledUpdateCheck(appID, appSlug)
+	}))
+
+	r.at = at
+	r.timer = time.AfterFunc(time.Until(at), retryJob.Run)
+}
+
+func resetRetries(appID string) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	cancelRetry(appID)
+}
+
+// cancelRetry must be called with mtx held
+func cancelRetry(appID string) {
+	if r, ok := retries[appID]; ok {
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		delete(retries, appID)
+	}
+}
+
+// recordUpdateCheck persists the result of an update check so that failing checks can be reported
+func recordUpdateCheck(appID string, ucr *UpdateCheckResponse, checkErr error) {
+	result := updatecheckertypes.CheckResult{
+		AppID:     appID,
+		CheckedAt: time.Now(),
+		Status:    updatecheckertypes.CheckStatusSuccess,
+	}
+
+	if checkErr != nil {
+		result.Status = updatecheckertypes.CheckStatusFailed
+		result.Error = checkErr.Error()
+		result.ConsecutiveFailures = 1
+
+		previous, err := store.GetUpdateCheckResult(appID)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get previous update check result"))
+		} else if previous != nil && previous.Status == updatecheckertypes.CheckStatusFailed {
+			result.ConsecutiveFailures = previous.ConsecutiveFailures + 1
+		}
+	} else if ucr != nil {
+		result.AvailableUpdates = ucr.AvailableUpdates
+	}
+
+	if err := store.SetUpdateCheckResult(result); err != nil {
+		logger.Error(errors.Wrap(err, "failed to record update check result"))
+	}
+}
+
+// GetUpdateCheckStatuses returns the schedule and the result of the last update check of every installed app
+func GetUpdateCheckStatuses() ([]updatecheckertypes.AppUpdateCheckStatus, error) {
+	apps, err := store.ListInstalledApps()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list installed apps")
+	}
+
+	results, err := store.ListUpdateCheckResults()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list update check results")
+	}
+	resultsByAppID := map[string]updatecheckertypes.CheckResult{}
+	for _, r := range results {
+		resultsByAppID[r.AppID] = r
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	statuses := []updatecheckertypes.AppUpdateCheckStatus{}
+	for _, a := range apps {
+		status := updatecheckertypes.AppUpdateCheckStatus{
+			AppSlug:           a.Slug,
+			IsAirgap:          a.IsAirgap,
+			UpdateCheckerSpec: a.UpdateCheckerSpec,
+		}
+
+		if id, ok := entries[a.ID]; ok {
+			if next := getScheduler().Entry(id).Next; !next.IsZero() {
+				status.NextCheckAt = &next
+			}
+		}
+		if r, ok := retries[a.ID]; ok && r.timer != nil {
+			at := r.at
+			status.NextRetryAt = &at
+		}
+		if r, ok := resultsByAppID[a.ID]; ok {
+			result := r
+			status.LastCheck = &result
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,86 @@
+package updatechecker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	cron "github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitteredSchedule(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 */4 * * *")
+	require.NoError(t, err)
+
+	s1 := newJitteredSchedule(schedule, "app-1")
+	s2 := newJitteredSchedule(schedule, "app-2")
+
+	// the offset of an app does not change when it is rescheduled
+	assert.Equal(t, s1.offset, newJitteredSchedule(schedule, "app-1").offset)
+	assert.NotEqual(t, s1.offset, s2.offset)
+
+	now := time.Date(2021, 12, 18, 3, 0, 0, 0, time.UTC)
+	for _, s := range []jitteredSchedule{s1, s2} {
+		assert.True(t, s.offset >= 0 && s.offset < maxUpdateCheckJitter, "offset %s", s.offset)
+
+		next := s.Next(now)
+		assert.Equal(t, time.Date(2021, 12, 18, 4, 0, 0, 0, time.UTC).Add(s.offset), next)
+
+		// a check that was delayed into the next hour is not skipped
+		assert.Equal(t, time.Date(2021, 12, 18, 8, 0, 0, 0, time.UTC).Add(s.offset), s.Next(next))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Minute},
+		{failures: 2, want: 2 * time.Minute},
+		{failures: 4, want: 8 * time.Minute},
+		{failures: 7, want: time.Hour},
+		{failures: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, retryDelay(tt.failures), "failures: %d", tt.failures)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "upstream error",
+			err:  errors.Wrap(upstreamError{err: errors.New("unexpected result from get request: 502")}, "failed to get updates"),
+			want: true,
+		},
+		{
+			name: "network error",
+			err:  errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "failed to create app version"),
+			want: true,
+		},
+		{
+			name: "upstream error that cannot be retried",
+			err:  errors.Wrap(upstreamError{err: util.ActionableError{NoRetry: true, Message: "License is expired"}}, "failed to sync license"),
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  errors.Wrap(errors.New("no downstreams found for app"), "failed to get kots app updates"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isRetryable(tt.err))
+		})
+	}
+}
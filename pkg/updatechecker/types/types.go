@@ -0,0 +1,36 @@
+package types
+
+import (
+	"time"
+)
+
+type CheckStatus string
+
+const (
+	CheckStatusSuccess CheckStatus = "success"
+	CheckStatusFailed  CheckStatus = "failed"
+)
+
+// CheckResult is the result of the last update check of an app
+type CheckResult struct {
+	AppID            string      `json:"appId"`
+	CheckedAt        time.Time   `json:"checkedAt"`
+	Status           CheckStatus `json:"status"`
+	AvailableUpdates int64       `json:"availableUpdates"`
+	Error            string      `json:"error,omitempty"`
+	// ConsecutiveFailures is the number of checks that have failed in a row, including this one
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// AppUpdateCheckStatus is the health of the scheduled update checks of an installed app
+type AppUpdateCheckStatus struct {
+	AppSlug           string `json:"appSlug"`
+	IsAirgap          bool   `json:"isAirgap"`
+	UpdateCheckerSpec string `json:"updateCheckerSpec"`
+	// NextCheckAt is nil when update checks are not scheduled
+	NextCheckAt *time.Time `json:"nextCheckAt,omitempty"`
+	// NextRetryAt is set when the last scheduled check failed and is retried before the next check
+	NextRetryAt *time.Time `json:"nextRetryAt,omitempty"`
+	// LastCheck is nil if updates have not been checked since this was recorded
+	LastCheck *CheckResult `json:"lastCheck,omitempty"`
+}
@@ -11,6 +11,8 @@ import (
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	"github.com/replicatedhq/kots/pkg/app"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
 	"github.com/replicatedhq/kots/pkg/helm"
 	"github.com/replicatedhq/kots/pkg/kotsadmconfig"
 	license "github.com/replicatedhq/kots/pkg/kotsadmlicense"
@@ -23,6 +25,8 @@ import (
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	"github.com/replicatedhq/kots/pkg/reporting"
 	kotssemver "github.com/replicatedhq/kots/pkg/semver"
+	"github.com/replicatedhq/kots/pkg/soak"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
 	storepkg "github.com/replicatedhq/kots/pkg/store"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
 	"github.com/replicatedhq/kots/pkg/tasks"
@@ -34,8 +38,15 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
-// jobs maps app ids to their cron jobs
-var jobs = make(map[string]*cron.Cron)
+// scheduler runs the scheduled update checks of all apps
+var scheduler *cron.Cron
+
+// entries maps app ids to their scheduled update checks
+var entries = make(map[string]cron.EntryID)
+
+// retries maps app ids to the update checks that are retried after a failure
+var retries = make(map[string]*retry)
+
 var mtx sync.Mutex
 var store = storepkg.GetStore()
 
@@ -62,9 +73,10 @@ func Start() error {
 }
 
 // Configure will check if the app has scheduled update checks enabled and:
-// if enabled, and cron job was NOT found: add a new cron job to check app updates
-// if enabled, and a cron job was found, update the existing cron job with the latest cron spec
-// if disabled: stop the current running cron job (if exists)
+// if enabled, and the app is NOT scheduled: schedule update checks for the app
+// if enabled, and the app is scheduled: reschedule update checks with the latest cron spec
+// if disabled: remove the scheduled update checks (if exist)
+// the checks of each app are delayed by a jitter so that apps with the same schedule are not checked at once.
 // no-op for airgap applications
 func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 	appId := a.GetID()
@@ -84,7 +96,7 @@ func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 	cronSpec := updateCheckerSpec
 
 	if cronSpec == "@never" || cronSpec == "" {
-		Stop(appId)
+		stop(appId)
 		return nil
 	}
 
@@ -96,65 +108,72 @@ func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 		cronSpec = fmt.Sprintf("%d %d/4 * * *", m, h)
 	}
 
-	job, ok := jobs[appId]
-	if ok {
-		// job already exists, remove entries
-		entries := job.Entries()
-		for _, entry := range entries {
-			job.Remove(entry.ID)
-		}
-	} else {
-		// job does not exist, create a new one
-		job = cron.New(cron.WithChain(
-			cron.Recover(cron.DefaultLogger),
-		))
+	schedule, err := cron.ParseStandard(cronSpec)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cron spec")
 	}
 
+	stop(appId)
+
 	jobAppID := appId
 	jobAppSlug := appSlug
+	entries[appId] = getScheduler().Schedule(newJitteredSchedule(schedule, appId), cron.FuncJob(func() {
+		runScheduledUpdateCheck(jobAppID, jobAppSlug)
+	}))
 
-	_, err := job.AddFunc(cronSpec, func() {
-		logger.Debug("checking updates for app", zap.String("slug", jobAppSlug))
+	return nil
+}
 
-		opts := CheckForUpdatesOpts{
-			AppID:       jobAppID,
-			IsAutomatic: true,
-		}
-		ucr, err := CheckForUpdates(opts)
-		if err != nil {
-			logger.Error(errors.Wrapf(err, "failed to check updates for app %s", jobAppSlug))
-			return
-		}
-		if ucr != nil {
-			if ucr.AvailableUpdates > 0 {
-				logger.Debug("updates found for app",
-					zap.String("slug", jobAppSlug),
-					zap.Int64("available updates", ucr.AvailableUpdates))
-			} else {
-				logger.Debug("no updates found for app", zap.String("slug", jobAppSlug))
-			}
-		}
-	})
-	if err != nil {
-		return errors.Wrap(err, "failed to add func")
-	}
+// Stop will remove the scheduled update checks (if exist) for a specific app
+func Stop(appID string) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	stop(appID)
+}
 
-	job.Start()
-	jobs[appId] = job
+func stop(appID string) {
+	cancelRetry(appID)
+	if id, ok := entries[appID]; ok {
+		getScheduler().Remove(id)
+		delete(entries, appID)
+	}
+}
 
-	return nil
+// getScheduler must be called with mtx held
+func getScheduler() *cron.Cron {
+	if scheduler == nil {
+		scheduler = cron.New(cron.WithChain(
+			cron.Recover(cron.DefaultLogger),
+		))
+		scheduler.Start()
+	}
+	return scheduler
 }
 
-// Stop will stop a running cron job (if exists) for a specific app
-func Stop(appID string) {
-	if jobs == nil {
-		logger.Debug("no cron jobs found")
+func runScheduledUpdateCheck(appID string, appSlug string) {
+	logger.Debug("checking updates for app", zap.String("slug", appSlug))
+
+	opts := CheckForUpdatesOpts{
+		AppID:       appID,
+		IsAutomatic: true,
+	}
+	ucr, err := CheckForUpdates(opts)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to check updates for app %s", appSlug))
+		scheduleRetry(appID, appSlug, err)
 		return
 	}
-	if job, ok := jobs[appID]; ok {
-		job.Stop()
-	} else {
-		logger.Debug("cron job not found for app", zap.String("appID", appID))
+
+	resetRetries(appID)
+
+	if ucr != nil {
+		if ucr.AvailableUpdates > 0 {
+			logger.Debug("updates found for app",
+				zap.String("slug", appSlug),
+				zap.Int64("available updates", ucr.AvailableUpdates))
+		} else {
+			logger.Debug("no updates found for app", zap.String("slug", appSlug))
+		}
 	}
 }
 
@@ -218,6 +237,9 @@ func CheckForUpdates(opts CheckForUpdatesOpts) (ucr *UpdateCheckResponse, finalE
 			return
 		}
 	} else {
+		defer func() {
+			recordUpdateCheck(opts.AppID, ucr, finalError)
+		}()
 		ucr, finalError = checkForKotsAppUpdates(opts, finishedChan)
 		if finalError != nil {
 			finalError = errors.Wrap(finalError, "failed to get kots app updates")
@@ -312,7 +334,7 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 	// sync license, this method is only called when online
 	latestLicense, _, err := license.Sync(a, "", false)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to sync license")
+		return nil, errors.Wrap(upstreamError{err: err}, "failed to sync license")
 	}
 
 	// reload app because license sync could have created a new release
@@ -340,7 +362,7 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 	// get updates
 	updates, err := kotspull.GetUpdates(fmt.Sprintf("replicated://%s", latestLicense.Spec.AppSlug), getUpdatesOptions)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get updates")
+		return nil, errors.Wrap(upstreamError{err: err}, "failed to get updates")
 	}
 
 	downstreams, err := store.ListDownstreamsForApp(a.ID)
@@ -396,6 +418,15 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 		return &ucr, nil
 	}
 
+	availableVersions := []string{}
+	for _, r := range availableReleases {
+		availableVersions = append(availableVersions, r.Version)
+	}
+	events.PublishAppEvent(eventtypes.EventUpdateAvailable, a.ID, a.Slug, -1, map[string]interface{}{
+		"availableUpdates":  ucr.AvailableUpdates,
+		"availableVersions": availableVersions,
+	})
+
 	// this is to avoid a race condition where the UI polls the task status before it is set by the goroutine
 	status := fmt.Sprintf("%d Updates available...", ucr.AvailableUpdates)
 	if err := store.SetTaskStatus("update-download", status, "running"); err != nil {
@@ -614,6 +645,9 @@ func deployVersionLabel(opts CheckForUpdatesOpts, clusterID string, versionLabel
 	return nil
 }
 
+// autoDeploy deploys the newest version allowed by the auto deploy policy that has soaked according to the soak policy
+// of the app. If the maintenance window of the app is closed, the version is marked as pending and is deployed when
+// the next window opens.
 func autoDeploy(opts CheckForUpdatesOpts, clusterID string, autoDeploy apptypes.AutoDeploy) error {
 	if autoDeploy == "" || autoDeploy == apptypes.AutoDeployDisabled {
 		return nil
@@ -632,16 +666,58 @@ func autoDeploy(opts CheckForUpdatesOpts, clusterID string, autoDeploy apptypes.
 		return nil
 	}
 
+	soakPolicy, err := store.GetSoakPolicy(opts.AppID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get soak policy")
+	}
+
+	isSoaked := func(v *downstreamtypes.DownstreamVersion) bool {
+		return true
+	}
+	if soakPolicy != nil {
+		var approved []soaktypes.ApprovedVersions
+		if len(soakPolicy.ApprovingInstances) > 0 {
+			approved, err = store.ListApprovedVersions(opts.AppID)
+			if err != nil {
+				return errors.Wrap(err, "failed to list approved versions")
+			}
+		}
+		now := time.Now()
+		isSoaked = func(v *downstreamtypes.DownstreamVersion) bool {
+			if soak.IsSoaked(*soakPolicy, approved, v, now) {
+				return true
+			}
+			if soakPolicy.MinDays > 0 && v.UpstreamReleasedAt == nil {
+				logger.Info("release time of version is unknown, it will only be auto-deployed once it is approved by an approving instance",
+					zap.String("appID", opts.AppID),
+					zap.String("versionLabel", v.VersionLabel))
+				return false
+			}
+			logger.Debug("version has not soaked yet, skipping auto-deploy",
+				zap.String("appID", opts.AppID),
+				zap.String("versionLabel", v.VersionLabel))
+			return false
+		}
+	}
+
 	var versionToDeploy *downstreamtypes.DownstreamVersion
 
 	if autoDeploy == apptypes.AutoDeploySequence {
 		// semver is not required/enabled, we only need to check if the newest app version is newer than the current version.
 		// use cursor instead of sequence in order to only deploy newer upstream versions, and not versions created by config changes, license changes, etc...
+		// older versions are only considered when the newer ones have not soaked yet.
 		currentCursor := currentVersion.Cursor
-		latestCursor := appVersions.AllVersions[0].Cursor
-		if currentCursor != nil && latestCursor != nil && (*currentCursor).Before(*latestCursor) {
-			versionToDeploy = appVersions.AllVersions[0]
-		} else {
+		for _, v := range appVersions.AllVersions {
+			latestCursor := v.Cursor
+			if currentCursor == nil || latestCursor == nil || !(*currentCursor).Before(*latestCursor) {
+				break
+			}
+			if isSoaked(v) {
+				versionToDeploy = v
+				break
+			}
+		}
+		if versionToDeploy == nil {
 			return nil
 		}
 	} else if currentVersion.Semver != nil { // semver is required
@@ -656,6 +732,10 @@ func autoDeploy(opts CheckForUpdatesOpts, clusterID string, autoDeploy apptypes.
 				break
 			}
 
+			if !isSoaked(v) {
+				continue
+			}
+
 			switch autoDeploy {
 			case apptypes.AutoDeploySemverPatch:
 				if v.Semver.Major == currentVersion.Semver.Major && v.Semver.Minor == currentVersion.Semver.Minor {
@@ -680,10 +760,35 @@ func autoDeploy(opts CheckForUpdatesOpts, clusterID string, autoDeploy apptypes.
 		return nil
 	}
 
+	a, err := store.GetApp(opts.AppID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get app")
+	}
+	queued, err := queueIfMaintenanceWindowIsClosed(opts.AppID, clusterID, versionToDeploy.Sequence, a.MaintenanceWindow)
+	if err != nil {
+		return errors.Wrap(err, "failed to check maintenance window")
+	}
+	if queued {
+		return nil
+	}
+
 	if err := waitForPreflightsToFinish(opts.AppID, versionToDeploy.Sequence); err != nil {
 		return errors.Wrap(err, "not able to auto-deploy due to failed preflight check")
 	}
 
+	// preflights can take long enough for the window to close or be changed
+	a, err = store.GetApp(opts.AppID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get app")
+	}
+	queued, err = queueIfMaintenanceWindowIsClosed(opts.AppID, clusterID, versionToDeploy.Sequence, a.MaintenanceWindow)
+	if err != nil {
+		return errors.Wrap(err, "failed to check maintenance window")
+	}
+	if queued {
+		return nil
+	}
+
 	if err := deployVersion(opts, clusterID, appVersions, versionToDeploy); err != nil {
 		return errors.Wrapf(err, "failed to deploy sequence %d with version label %s", versionToDeploy.Sequence, versionToDeploy.VersionLabel)
 	}
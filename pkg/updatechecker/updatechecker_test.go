@@ -3,6 +3,7 @@ package updatechecker
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/golang/mock/gomock"
@@ -11,6 +12,7 @@ import (
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	"github.com/replicatedhq/kots/pkg/cursor"
 	preflighttypes "github.com/replicatedhq/kots/pkg/preflight/types"
+	soaktypes "github.com/replicatedhq/kots/pkg/soak/types"
 	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
@@ -125,6 +127,7 @@ func TestAutoDeployCurrentVersionSemverIsNilDoesNothing(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 
 	store = mockStore
 
@@ -157,6 +160,7 @@ func TestAutoDeploySequenceQuitsIfCurrentVersionSequenceIsGreaterThanOrEqualToMo
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 
 	store = mockStore
 
@@ -190,6 +194,7 @@ func TestAutoDeploySequenceDeploysSequenceUpgradeIfCurrentVersionLessThanMostRec
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
 
 	store = mockStore
@@ -200,6 +205,206 @@ func TestAutoDeploySequenceDeploysSequenceUpgradeIfCurrentVersionLessThanMostRec
 	}
 }
 
+func TestAutoDeployQueuesIfMaintenanceWindowIsClosed(t *testing.T) {
+	var autoDeployType = apptypes.AutoDeploySequence
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	var opts = CheckForUpdatesOpts{AppID: appID}
+	var currentCursor = cursor.MustParse("1")
+	var upgradeCursor = cursor.MustParse("2")
+	var downstreamVersions = &downstreamtypes.DownstreamVersions{
+		CurrentVersion: &downstreamtypes.DownstreamVersion{
+			Cursor:   &currentCursor,
+			Sequence: 1,
+		},
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{
+				Cursor:   &upgradeCursor,
+				Sequence: 2,
+			},
+		},
+	}
+	// open for one second a year
+	var maintenanceWindow = &apptypes.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: "1s",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
+	mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID, MaintenanceWindow: maintenanceWindow}, nil)
+	mockStore.EXPECT().SetPendingAutoDeploy(appID, clusterID, int64(2), gomock.Any()).Return(nil)
+
+	store = mockStore
+
+	err := autoDeploy(opts, clusterID, autoDeployType)
+	require.NoError(t, err)
+}
+
+func TestAutoDeployQueuesIfMaintenanceWindowClosesDuringPreflights(t *testing.T) {
+	var autoDeployType = apptypes.AutoDeploySequence
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	var opts = CheckForUpdatesOpts{AppID: appID}
+	var currentCursor = cursor.MustParse("1")
+	var upgradeCursor = cursor.MustParse("2")
+	var downstreamVersions = &downstreamtypes.DownstreamVersions{
+		CurrentVersion: &downstreamtypes.DownstreamVersion{
+			Cursor:   &currentCursor,
+			Sequence: 1,
+		},
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{
+				Cursor:   &upgradeCursor,
+				Sequence: 2,
+			},
+		},
+	}
+	// the window was set while waiting for preflights, it's open for one second a year
+	var app = &apptypes.App{
+		ID: appID,
+		MaintenanceWindow: &apptypes.MaintenanceWindow{
+			Schedule: "0 0 1 1 *",
+			Duration: "1s",
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
+	mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID}, nil)
+	mockStore.EXPECT().GetApp(appID).Return(app, nil).Times(2)
+	mockStore.EXPECT().SetPendingAutoDeploy(appID, clusterID, int64(2), gomock.Any()).Return(nil)
+
+	store = mockStore
+
+	err := autoDeploy(opts, clusterID, autoDeployType)
+	require.NoError(t, err)
+}
+
+func TestReschedulePendingAutoDeploys(t *testing.T) {
+	var appID = "some-app"
+	var scheduledAt = time.Now().Add(time.Hour).Truncate(time.Second)
+	var pending = []downstreamtypes.PendingAutoDeploy{
+		{AppID: appID, ClusterID: "cluster-1", Sequence: 2, ScheduledAt: scheduledAt},
+		{AppID: appID, ClusterID: "cluster-2", Sequence: 3, ScheduledAt: scheduledAt},
+		{AppID: "other-app", ClusterID: "cluster-1", Sequence: 5, ScheduledAt: scheduledAt},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().ListPendingAutoDeploys().Return(pending, nil)
+	// removing the window deploys the pending versions on the next run of the job
+	mockStore.EXPECT().SetPendingAutoDeploy(appID, "cluster-1", int64(2), gomock.Any()).DoAndReturn(func(_ string, _ string, _ int64, at time.Time) error {
+		require.False(t, at.After(time.Now()))
+		return nil
+	})
+	mockStore.EXPECT().SetPendingAutoDeploy(appID, "cluster-2", int64(3), gomock.Any()).Return(nil)
+
+	store = mockStore
+
+	err := ReschedulePendingAutoDeploys(appID, nil)
+	require.NoError(t, err)
+}
+
+func TestAutoDeployDeploysIfMaintenanceWindowIsOpen(t *testing.T) {
+	var autoDeployType = apptypes.AutoDeploySequence
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	var opts = CheckForUpdatesOpts{AppID: appID}
+	var currentCursor = cursor.MustParse("1")
+	var upgradeCursor = cursor.MustParse("2")
+	var downstreamVersions = &downstreamtypes.DownstreamVersions{
+		CurrentVersion: &downstreamtypes.DownstreamVersion{
+			Cursor:   &currentCursor,
+			Sequence: 1,
+		},
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{
+				Cursor:   &upgradeCursor,
+				Sequence: 2,
+			},
+		},
+	}
+	// always open
+	var maintenanceWindow = &apptypes.MaintenanceWindow{
+		Schedule: "* * * * *",
+		Duration: "1m",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
+	mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID, MaintenanceWindow: maintenanceWindow}, nil)
+	mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
+
+	store = mockStore
+
+	err := autoDeploy(opts, clusterID, autoDeployType)
+	if err != nil && !strings.Contains(err.Error(), "quitting early so as not to test the waitForPreflightsToFinish method") {
+		t.Errorf("autoDeploy() returned error = %v, wanted %s", err, "quitting early so as not to test the waitForPreflightsToFinish method")
+	}
+}
+
+func TestAutoDeploySkipsVersionsThatHaveNotSoaked(t *testing.T) {
+	var autoDeployType = apptypes.AutoDeploySequence
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	var opts = CheckForUpdatesOpts{AppID: appID}
+	var currentCursor = cursor.MustParse("1")
+	var soakedCursor = cursor.MustParse("2")
+	var newCursor = cursor.MustParse("3")
+	var soakedReleasedAt = time.Now().AddDate(0, 0, -10)
+	var newReleasedAt = time.Now().AddDate(0, 0, -1)
+	var downstreamVersions = &downstreamtypes.DownstreamVersions{
+		CurrentVersion: &downstreamtypes.DownstreamVersion{
+			Cursor:   &currentCursor,
+			Sequence: 1,
+		},
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{
+				Cursor:             &newCursor,
+				Sequence:           3,
+				UpstreamReleasedAt: &newReleasedAt,
+			},
+			{
+				Cursor:             &soakedCursor,
+				Sequence:           2,
+				UpstreamReleasedAt: &soakedReleasedAt,
+			},
+		},
+	}
+	var soakPolicy = &soaktypes.Policy{
+		MinDays: 7,
+	}
+	// the pending version shows which version would be deployed
+	var maintenanceWindow = &apptypes.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: "1s",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(soakPolicy, nil)
+	mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID, MaintenanceWindow: maintenanceWindow}, nil)
+	mockStore.EXPECT().SetPendingAutoDeploy(appID, clusterID, int64(2), gomock.Any()).Return(nil)
+
+	store = mockStore
+
+	err := autoDeploy(opts, clusterID, autoDeployType)
+	require.NoError(t, err)
+}
+
 func TestAutoDeploySequenceDoesNotDeployIfCurrentVersionIsSameUpstream(t *testing.T) {
 	var autoDeployType = apptypes.AutoDeploySequence
 	var appID = "some-app"
@@ -224,6 +429,7 @@ func TestAutoDeploySequenceDoesNotDeployIfCurrentVersionIsSameUpstream(t *testin
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 
 	store = mockStore
 
@@ -249,6 +455,7 @@ func TestAutoDeploySemverRequiredAllVersionsIndexIsNil(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -279,6 +486,7 @@ func TestAutoDeploySemverRequiredAllVersionsHasNilSemver(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -320,6 +528,7 @@ func TestAutoDeploySemverRequiredNoNewVersionToDeploy(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -356,6 +565,7 @@ func TestAutoDeploySemverRequiredPatchUpdateMajorsDontMatch(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -395,6 +605,7 @@ func TestAutoDeploySemverRequiredPatchUpdateMajorsMatchMinorsDontMatch(t *testin
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -439,6 +650,7 @@ func TestAutoDeploySemverRequiredPatchUpdateMajorsMatchMinorsMatchWillUpgrade(t
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
 
 	store = mockStore
@@ -477,6 +689,7 @@ func TestAutoDeploySemverRequiredMinorUpdateMajorsDontMatch(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	// do not call waitForPreflightsToFinish
 
 	store = mockStore
@@ -518,6 +731,7 @@ func TestAutoDeploySemverRequiredMinorUpdateMajorsMatchWillUpgrade(t *testing.T)
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
 
 	store = mockStore
@@ -556,6 +770,7 @@ func TestAutoDeploySemverRequiredMajorUpdateWillUpgrade(t *testing.T) {
 	defer ctrl.Finish()
 	mockStore := mock_store.NewMockStore(ctrl)
 	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetSoakPolicy(appID).Return(nil, nil)
 	mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
 
 	store = mockStore
@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
 	"github.com/replicatedhq/kots/pkg/image"
+	imagetypes "github.com/replicatedhq/kots/pkg/image/types"
 	"github.com/replicatedhq/kots/pkg/kotsadm"
 	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/logger"
@@ -26,6 +27,7 @@ type ProcessAirgapImagesOptions struct {
 	ReplicatedRegistry  registrytypes.RegistryOptions
 	ReportWriter        io.Writer
 	DestinationRegistry registrytypes.RegistryOptions
+	ImagePolicy         *imagetypes.ImagePolicy
 }
 
 type ProcessAirgapImagesResult struct {
@@ -39,6 +41,7 @@ func ProcessAirgapImages(options ProcessAirgapImagesOptions) (*ProcessAirgapImag
 		Log:            options.Log,
 		ProgressWriter: options.ReportWriter,
 		LogForUI:       true,
+		ImagePolicy:    options.ImagePolicy,
 	}
 
 	var foundImages []kustomizetypes.Image
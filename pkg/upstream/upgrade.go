@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/airgap/integrity"
 	"github.com/replicatedhq/kots/pkg/auth"
 	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
@@ -68,6 +69,10 @@ func Upgrade(appSlug string, options UpgradeOptions) (*UpgradeResponse, error) {
 
 		airgapPath = airgapRootDir
 
+		if err := kotsadm.VerifyAirgapBundle(options.AirgapBundle, options.Namespace, airgapRootDir, log); err != nil {
+			return nil, errors.Wrap(err, "failed to verify airgap bundle")
+		}
+
 		err = kotsadm.ExtractAppAirgapArchive(options.AirgapBundle, airgapRootDir, options.DisableImagePush, os.Stdout)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to extract images")
@@ -131,6 +136,15 @@ func Upgrade(appSlug string, options UpgradeOptions) (*UpgradeResponse, error) {
 			return nil, errors.Wrap(err, "failed to create part from images.json")
 		}
 
+		for _, name := range []string{integrity.ManifestFileName, integrity.SignatureFileName} {
+			if _, err := os.Stat(filepath.Join(airgapPath, name)); os.IsNotExist(err) {
+				continue
+			}
+			if err := createPartFromFile(writer, airgapPath, name); err != nil {
+				return nil, errors.Wrapf(err, "failed to create part from %s", name)
+			}
+		}
+
 		err = writer.Close()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to close multi-part writer")
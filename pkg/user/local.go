@@ -0,0 +1,193 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/rbac"
+	"github.com/replicatedhq/kots/pkg/store"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/segmentio/ksuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MaxFailedLoginAttempts is the number of consecutive failed logins after which a local user is locked
+	// out until their password is reset
+	MaxFailedLoginAttempts = 10
+
+	minPasswordLength = 6
+	bcryptCost        = 10
+)
+
+var (
+	ErrUserExists       = errors.New("user already exists")
+	ErrInvalidUsername  = errors.New("username must start with a letter or number and may only contain letters, numbers, '.', '_', '@' and '-'")
+	ErrPasswordTooShort = errors.New("password must be at least 6 characters")
+	ErrNoRoles          = errors.New("at least one role is required")
+	ErrUnknownRole      = errors.New("unknown role")
+
+	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]{0,63}$`)
+
+	dummyPasswordBcrypt     []byte
+	dummyPasswordBcryptErr  error
+	dummyPasswordBcryptOnce sync.Once
+)
+
+// IsLockedOut returns true if the user has too many consecutive failed logins
+func IsLockedOut(u *usertypes.User) bool {
+	return u.FailedLoginCount >= MaxFailedLoginAttempts
+}
+
+// logInLocalUser always compares the password, even if the user does not exist, so that the response time and the
+// error do not reveal which usernames exist. Disabled and locked out users are checked before the password and get the
+// same error whether or not the password is correct, so that passwords cannot be guessed while the account is locked.
+func logInLocalUser(kotsStore store.Store, username string, password string) (*usertypes.User, error) {
+	dummyPasswordBcrypt, err := getDummyPasswordBcrypt()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dummy password bcrypt")
+	}
+
+	foundUser, err := kotsStore.GetUserByUsername(username)
+	if kotsStore.IsNotFound(err) {
+		bcrypt.CompareHashAndPassword(dummyPasswordBcrypt, []byte(password))
+		return nil, ErrInvalidPassword
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get user")
+	}
+
+	if foundUser.IsDisabled || IsLockedOut(foundUser) {
+		bcrypt.CompareHashAndPassword(dummyPasswordBcrypt, []byte(password))
+		if foundUser.IsDisabled {
+			return nil, ErrUserDisabled
+		}
+		return nil, ErrTooManyAttempts
+	}
+
+	shaBytes, err := kotsStore.GetUserPasswordBcrypt(foundUser.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user password bcrypt")
+	}
+
+	if err := bcrypt.CompareHashAndPassword(shaBytes, []byte(password)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			if err := kotsStore.FlagInvalidUserPassword(foundUser.ID); err != nil {
+				logger.Infof("failed to flag failed login for user %s: %v", username, err)
+			}
+			return nil, ErrInvalidPassword
+		}
+
+		return nil, errors.Wrap(err, "failed to compare password")
+	}
+
+	if err := kotsStore.FlagSuccessfulUserLogin(foundUser.ID); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to flag successful login for user %s", username))
+	}
+
+	return foundUser, nil
+}
+
+// getDummyPasswordBcrypt returns a hash with the same cost as real passwords, that unknown users are compared with.
+// The hash is generated once, logins fail if it cannot be generated.
+func getDummyPasswordBcrypt() ([]byte, error) {
+	dummyPasswordBcryptOnce.Do(func() {
+		dummyPasswordBcrypt, dummyPasswordBcryptErr = bcrypt.GenerateFromPassword([]byte(ksuid.New().String()), bcryptCost)
+	})
+	return dummyPasswordBcrypt, dummyPasswordBcryptErr
+}
+
+// CreateUser creates a new local user with the given roles
+func CreateUser(kotsStore store.Store, username string, password string, roles []string) (*usertypes.User, error) {
+	if !usernameRegex.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+	if err := ValidateRoles(roles); err != nil {
+		return nil, err
+	}
+
+	_, err := kotsStore.GetUserByUsername(username)
+	if err == nil {
+		return nil, ErrUserExists
+	} else if !kotsStore.IsNotFound(err) {
+		return nil, errors.Wrap(err, "failed to check for existing user")
+	}
+
+	shaBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate encrypted password")
+	}
+
+	createdUser, err := kotsStore.CreateUser(username, shaBytes, roles)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create user")
+	}
+
+	return createdUser, nil
+}
+
+// ResetPassword sets a new password for the user and clears any lockout. Existing sessions
+// for the user are no longer valid after the password is reset.
+func ResetPassword(kotsStore store.Store, userID string, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	shaBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate encrypted password")
+	}
+
+	if err := kotsStore.SetUserPasswordBcrypt(userID, shaBytes); err != nil {
+		return errors.Wrap(err, "failed to set user password")
+	}
+
+	return nil
+}
+
+// SetRoles replaces the roles assigned to the user
+func SetRoles(kotsStore store.Store, userID string, roles []string) error {
+	if err := ValidateRoles(roles); err != nil {
+		return err
+	}
+
+	if err := kotsStore.SetUserRoles(userID, roles); err != nil {
+		return errors.Wrap(err, "failed to set user roles")
+	}
+
+	return nil
+}
+
+// ValidateRoles makes sure that every role is one that the rbac policies know about
+func ValidateRoles(roles []string) error {
+	if len(roles) == 0 {
+		return ErrNoRoles
+	}
+
+	for _, roleID := range roles {
+		found := false
+		for _, role := range rbac.DefaultRoles() {
+			if role.ID == roleID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w %q", ErrUnknownRole, roleID)
+		}
+	}
+
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errNotFound = errors.New("not found")
+
+func getMockStore(t *testing.T) *mock_store.MockStore {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().IsNotFound(gomock.Any()).DoAndReturn(func(err error) bool {
+		return errors.Cause(err) == errNotFound
+	}).AnyTimes()
+	return mockStore
+}
+
+func Test_logInLocalUser(t *testing.T) {
+	passwordBcrypt, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	existingUser := &usertypes.User{ID: "user-id", Username: "jdoe", Roles: []string{"support"}}
+
+	t.Run("valid password", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(existingUser, nil)
+		mockStore.EXPECT().GetUserPasswordBcrypt("user-id").Return(passwordBcrypt, nil)
+		mockStore.EXPECT().FlagSuccessfulUserLogin("user-id").Return(nil)
+
+		got, err := logInLocalUser(mockStore, "jdoe", "password")
+		require.NoError(t, err)
+		assert.Equal(t, existingUser, got)
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(existingUser, nil)
+		mockStore.EXPECT().GetUserPasswordBcrypt("user-id").Return(passwordBcrypt, nil)
+		mockStore.EXPECT().FlagInvalidUserPassword("user-id").Return(nil)
+
+		_, err := logInLocalUser(mockStore, "jdoe", "wrong")
+		assert.Equal(t, ErrInvalidPassword, err)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("nobody").Return(nil, errNotFound)
+
+		_, err := logInLocalUser(mockStore, "nobody", "password")
+		assert.Equal(t, ErrInvalidPassword, err)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(&usertypes.User{ID: "user-id", Username: "jdoe", IsDisabled: true}, nil)
+
+		_, err := logInLocalUser(mockStore, "jdoe", "password")
+		assert.Equal(t, ErrUserDisabled, err)
+	})
+
+	t.Run("disabled user with invalid password", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(&usertypes.User{ID: "user-id", Username: "jdoe", IsDisabled: true}, nil)
+
+		// the error does not depend on the password
+		_, err := logInLocalUser(mockStore, "jdoe", "wrong")
+		assert.Equal(t, ErrUserDisabled, err)
+	})
+
+	t.Run("locked out user", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(&usertypes.User{ID: "user-id", Username: "jdoe", FailedLoginCount: MaxFailedLoginAttempts}, nil)
+
+		_, err := logInLocalUser(mockStore, "jdoe", "password")
+		assert.Equal(t, ErrTooManyAttempts, err)
+	})
+
+	t.Run("locked out user with invalid password", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(&usertypes.User{ID: "user-id", Username: "jdoe", FailedLoginCount: MaxFailedLoginAttempts}, nil)
+
+		// the error does not depend on the password
+		_, err := logInLocalUser(mockStore, "jdoe", "wrong")
+		assert.Equal(t, ErrTooManyAttempts, err)
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	t.Run("creates user", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(nil, errNotFound)
+		mockStore.EXPECT().CreateUser("jdoe", gomock.Any(), []string{"cluster-admin"}).DoAndReturn(
+			func(username string, passwordBcrypt []byte, roles []string) (*usertypes.User, error) {
+				assert.NoError(t, bcrypt.CompareHashAndPassword(passwordBcrypt, []byte("password")))
+				return &usertypes.User{ID: "user-id", Username: username, Roles: roles}, nil
+			})
+
+		got, err := CreateUser(mockStore, "jdoe", "password", []string{"cluster-admin"})
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", got.ID)
+	})
+
+	t.Run("existing user", func(t *testing.T) {
+		mockStore := getMockStore(t)
+		mockStore.EXPECT().GetUserByUsername("jdoe").Return(&usertypes.User{ID: "user-id", Username: "jdoe"}, nil)
+
+		_, err := CreateUser(mockStore, "jdoe", "password", []string{"cluster-admin"})
+		assert.Equal(t, ErrUserExists, err)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		mockStore := getMockStore(t)
+
+		_, err := CreateUser(mockStore, "-jdoe", "password", []string{"cluster-admin"})
+		assert.Equal(t, ErrInvalidUsername, err)
+
+		_, err = CreateUser(mockStore, "jdoe", "pass", []string{"cluster-admin"})
+		assert.Equal(t, ErrPasswordTooShort, err)
+
+		_, err = CreateUser(mockStore, "jdoe", "password", nil)
+		assert.Equal(t, ErrNoRoles, err)
+
+		_, err = CreateUser(mockStore, "jdoe", "password", []string{"superuser"})
+		assert.ErrorIs(t, err, ErrUnknownRole)
+	})
+}
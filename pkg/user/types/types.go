@@ -1,5 +1,26 @@
 package types
 
+import "time"
+
+const (
+	// SharedPasswordUserID is the user id for sessions created with the shared admin console password
+	SharedPasswordUserID = "000000"
+)
+
 type User struct {
-	ID string
+	ID string `json:"id"`
+	// Username is only set for local users
+	Username          string     `json:"username,omitempty"`
+	Roles             []string   `json:"roles,omitempty"`
+	IsDisabled        bool       `json:"isDisabled"`
+	FailedLoginCount  int        `json:"failedLoginCount"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	PasswordUpdatedAt *time.Time `json:"passwordUpdatedAt,omitempty"`
+	LastLoginAt       *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+// IsLocal returns true if the user is managed in the kotsadm user store rather than
+// being the shared password user or a user from the identity service
+func (u User) IsLocal() bool {
+	return u.Username != ""
 }
@@ -14,12 +14,19 @@ var (
 	loginMutex         sync.Mutex
 	ErrInvalidPassword = errors.New("invalid password")
 	ErrTooManyAttempts = errors.New("too many attempts")
+	ErrUserDisabled    = errors.New("user is disabled")
 )
 
-func LogIn(password string) (*usertypes.User, error) {
+// LogIn authenticates a local user when a username is provided, otherwise it
+// compares the password with the shared admin console password
+func LogIn(username string, password string) (*usertypes.User, error) {
 	loginMutex.Lock()
 	defer loginMutex.Unlock()
 
+	if username != "" {
+		return logInLocalUser(store.GetStore(), username, password)
+	}
+
 	shaBytes, err := store.GetStore().GetSharedPasswordBcrypt()
 
 	// this is rough...  the error is defined twice but we can't wrap it if this is the error
@@ -46,6 +53,6 @@ func LogIn(password string) (*usertypes.User, error) {
 	}
 
 	return &usertypes.User{
-		ID: "000000",
+		ID: usertypes.SharedPasswordUserID,
 	}, nil
 }
@@ -8,6 +8,10 @@ import (
 )
 
 func PromptForNewPassword() (string, error) {
+	return PromptForNewPasswordWithLabel("Enter a new password for the admin console (6+ characters):")
+}
+
+func PromptForNewPasswordWithLabel(label string) (string, error) {
 	templates := &promptui.PromptTemplates{
 		Prompt:  "{{ . | bold }} ",
 		Valid:   "{{ . | green }} ",
@@ -16,7 +20,7 @@ func PromptForNewPassword() (string, error) {
 	}
 
 	prompt := promptui.Prompt{
-		Label:     "Enter a new password for the admin console (6+ characters):",
+		Label:     label,
 		Templates: templates,
 		Mask:      rune('•'),
 		Validate: func(input string) error {
@@ -0,0 +1,218 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/apparchive"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
+	"github.com/replicatedhq/kots/pkg/gitops"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// gitOpsDriftCheckCronSpec - how often the gitops repos and the cluster are compared to the current versions
+	gitOpsDriftCheckCronSpec = "@every 15m"
+)
+
+// StartGitOpsDriftCheckCronJob starts the job that detects drift between the current version of each gitops
+// enabled downstream, the gitops repo and the cluster
+func StartGitOpsDriftCheckCronJob() error {
+	logger.Debug("starting gitops drift check cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(gitOpsDriftCheckCronSpec, func() {
+		apps, err := store.GetStore().ListInstalledApps()
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to list installed apps"))
+			return
+		}
+		for _, a := range apps {
+			if _, err := CheckGitOpsDrift(a); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to check gitops drift for app %s", a.Slug))
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// CheckGitOpsDrift checks every gitops enabled downstream of the app for drift and stores the results.
+// Failures to check a downstream are recorded in its status rather than returned.
+func CheckGitOpsDrift(a *apptypes.App) ([]gitopstypes.DriftStatus, error) {
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list downstreams")
+	}
+
+	statuses := []gitopstypes.DriftStatus{}
+	for _, d := range downstreams {
+		gitOpsConfig, err := gitops.GetDownstreamGitOps(a.ID, d.ClusterID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get gitops config for downstream %s", d.Name)
+		}
+		if gitOpsConfig == nil || !gitOpsConfig.IsConnected {
+			continue
+		}
+
+		status := checkDownstreamGitOpsDrift(a, d, gitOpsConfig)
+
+		previous, err := store.GetStore().GetGitOpsDriftStatus(a.ID, d.ClusterID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get previous drift status")
+		}
+		if err := store.GetStore().SetGitOpsDriftStatus(status); err != nil {
+			return nil, errors.Wrap(err, "failed to set drift status")
+		}
+
+		if status.HasDrift() && !isSameDrift(previous, status) {
+			events.PublishAppEvent(eventtypes.EventGitOpsDrift, a.ID, a.Slug, status.Sequence, map[string]interface{}{
+				"clusterId":    d.ClusterID,
+				"repoSequence": status.RepoSequence,
+				"repo":         status.Repo,
+				"cluster":      status.Cluster,
+			})
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func checkDownstreamGitOpsDrift(a *apptypes.App, d downstreamtypes.Downstream, gitOpsConfig *gitops.GitOpsConfig) gitopstypes.DriftStatus {
+	status := gitopstypes.DriftStatus{
+		AppID:        a.ID,
+		ClusterID:    d.ClusterID,
+		Sequence:     -1,
+		RepoSequence: -1,
+		CheckedAt:    time.Now(),
+	}
+
+	// every version is committed to the repo when it's created, so the repo is compared to the latest version in the
+	// branch rather than the deployed version, which can be older
+	repoSequence, err := store.GetStore().GetLatestGitOpsCommittedParentSequence(a.ID, d.ClusterID)
+	if err != nil {
+		status.Error = errors.Wrap(err, "failed to get latest committed sequence").Error()
+		return status
+	}
+
+	deployedSequence, err := store.GetStore().GetCurrentParentSequence(a.ID, d.ClusterID)
+	if err != nil {
+		status.Error = errors.Wrap(err, "failed to get current sequence").Error()
+		return status
+	}
+
+	renderedBySequence := map[int64][]byte{}
+	getRendered := func(sequence int64) ([]byte, error) {
+		if rendered, ok := renderedBySequence[sequence]; ok {
+			return rendered, nil
+		}
+		rendered, err := getRenderedAppForSequence(a.ID, sequence, d.Name)
+		if err != nil {
+			return nil, err
+		}
+		renderedBySequence[sequence] = rendered
+		return rendered, nil
+	}
+
+	if repoSequence != -1 {
+		status.RepoSequence = repoSequence
+
+		rendered, err := getRendered(repoSequence)
+		if err != nil {
+			status.Error = errors.Wrapf(err, "failed to get rendered app for sequence %d", repoSequence).Error()
+			return status
+		}
+
+		repoManifests, err := gitops.GetRepoManifests(gitOpsConfig, a.Slug)
+		if err != nil {
+			status.Error = errors.Wrap(err, "failed to get manifests from repo").Error()
+			return status
+		}
+
+		status.Repo, err = gitops.CompareManifests(rendered, repoManifests)
+		if err != nil {
+			status.Error = errors.Wrap(err, "failed to compare repo to rendered manifests").Error()
+			return status
+		}
+	}
+
+	if deployedSequence != -1 {
+		status.Sequence = deployedSequence
+
+		rendered, err := getRendered(deployedSequence)
+		if err != nil {
+			status.Error = errors.Wrapf(err, "failed to get rendered app for sequence %d", deployedSequence).Error()
+			return status
+		}
+
+		getLiveObject, err := gitops.NewClusterObjectGetter()
+		if err != nil {
+			status.Error = errors.Wrap(err, "failed to create cluster object getter").Error()
+			return status
+		}
+
+		status.Cluster, err = gitops.CompareClusterObjects(rendered, util.AppNamespace(), getLiveObject)
+		if err != nil {
+			status.Error = errors.Wrap(err, "failed to compare cluster to rendered manifests").Error()
+			return status
+		}
+	}
+
+	return status
+}
+
+func getRenderedAppForSequence(appID string, sequence int64, downstreamName string) ([]byte, error) {
+	archiveDir, err := ioutil.TempDir("", "kotsadm")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(archiveDir)
+
+	if err := store.GetStore().GetAppVersionArchive(appID, sequence, archiveDir); err != nil {
+		return nil, errors.Wrap(err, "failed to get app version archive")
+	}
+
+	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kots kinds")
+	}
+
+	rendered, _, err := apparchive.GetRenderedApp(archiveDir, downstreamName, kotsKinds.GetKustomizeBinaryPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render app")
+	}
+
+	return rendered, nil
+}
+
+// isSameDrift returns true if the previous check found the same drift for the same version, so that an event is
+// only sent when the drift changes
+func isSameDrift(previous *gitopstypes.DriftStatus, current gitopstypes.DriftStatus) bool {
+	if previous == nil {
+		return false
+	}
+	return previous.Sequence == current.Sequence &&
+		previous.RepoSequence == current.RepoSequence &&
+		reflect.DeepEqual(previous.Repo, current.Repo) &&
+		reflect.DeepEqual(previous.Cluster, current.Cluster)
+}
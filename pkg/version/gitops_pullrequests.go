@@ -0,0 +1,83 @@
+package version
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/gitops"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// gitOpsPullRequestSyncCronSpec - how often the state of open gitops pull requests is refreshed from the provider
+	gitOpsPullRequestSyncCronSpec = "@every 5m"
+)
+
+// StartGitOpsPullRequestSyncCronJob starts the job that records when pull requests opened for versions are merged or closed
+func StartGitOpsPullRequestSyncCronJob() error {
+	logger.Debug("starting gitops pull request sync cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(gitOpsPullRequestSyncCronSpec, func() {
+		if err := SyncGitOpsPullRequests(); err != nil {
+			logger.Error(errors.Wrap(err, "failed to sync gitops pull requests"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// SyncGitOpsPullRequests updates the state of the open pull requests of all downstream versions
+func SyncGitOpsPullRequests() error {
+	pullRequests, err := store.GetStore().ListOpenGitOpsPullRequests()
+	if err != nil {
+		return errors.Wrap(err, "failed to list open pull requests")
+	}
+
+	providers := map[string]gitops.PullRequestProvider{}
+	for _, pr := range pullRequests {
+		downstreamKey := fmt.Sprintf("%s-%s", pr.AppID, pr.ClusterID)
+		provider, ok := providers[downstreamKey]
+		if !ok {
+			gitOpsConfig, err := gitops.GetDownstreamGitOps(pr.AppID, pr.ClusterID)
+			if err != nil {
+				logger.Error(errors.Wrapf(err, "failed to get gitops config for app %s", pr.AppID))
+				continue
+			}
+			if gitOpsConfig != nil && gitOpsConfig.Action == gitops.ActionPullRequest {
+				provider, err = gitOpsConfig.PullRequestProvider()
+				if err != nil {
+					logger.Error(errors.Wrapf(err, "failed to get pull request provider for app %s", pr.AppID))
+				}
+			}
+			// a nil provider skips the pull requests of a downstream that is no longer in pull request mode
+			providers[downstreamKey] = provider
+		}
+		if provider == nil {
+			continue
+		}
+
+		current, err := provider.GetPullRequest(pr.PullRequest.Number)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get pull request %d for app %s", pr.PullRequest.Number, pr.AppID))
+			continue
+		}
+		if current.State == pr.PullRequest.State {
+			continue
+		}
+
+		if err := store.GetStore().SetDownstreamVersionPullRequestState(pr.AppID, pr.ClusterID, pr.Sequence, current.State); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to set state of pull request %d for app %s", pr.PullRequest.Number, pr.AppID))
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/api/version/types"
 	"github.com/replicatedhq/kots/pkg/gitops"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/operator"
@@ -29,25 +30,25 @@ import (
 type DownstreamGitOps struct {
 }
 
-func (d *DownstreamGitOps) CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, filesInDir string, downstreamName string) (string, error) {
+func (d *DownstreamGitOps) CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, filesInDir string, downstreamName string) (*gitopstypes.CommitResult, error) {
 	downstreamGitOps, err := gitops.GetDownstreamGitOps(appID, clusterID)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get downstream gitops")
+		return nil, errors.Wrap(err, "failed to get downstream gitops")
 	}
 	if downstreamGitOps == nil || !downstreamGitOps.IsConnected {
-		return "", nil
+		return nil, nil
 	}
 
 	a, err := store.GetStore().GetApp(appID)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get app")
+		return nil, errors.Wrap(err, "failed to get app")
 	}
-	createdCommitURL, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(newSequence), filesInDir, downstreamName)
+	result, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(newSequence), filesInDir, downstreamName)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create gitops commit")
+		return nil, errors.Wrap(err, "failed to create gitops commit")
 	}
 
-	return createdCommitURL, nil
+	return result, nil
 }
 
 // DeployVersion deploys the version for the given sequence
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+)
+
+// Policy decides which app versions are kept. A version is kept if any of the rules keeps it,
+// all other versions are pruned. The deployed version, versions newer than it and the latest
+// version are always kept.
+type Policy struct {
+	// KeepLast keeps the given number of most recent versions
+	KeepLast int `json:"keepLast"`
+	// KeepPreviouslyDeployed keeps every version that has ever been deployed
+	KeepPreviouslyDeployed bool `json:"keepPreviouslyDeployed"`
+	// KeepNewerThanDays keeps versions created in the given number of days
+	KeepNewerThanDays int `json:"keepNewerThanDays"`
+}
+
+// Version is the information about an app version that retention decisions are based on
+type Version struct {
+	Sequence     int64     `json:"sequence"`
+	VersionLabel string    `json:"versionLabel"`
+	CreatedAt    time.Time `json:"createdAt"`
+	WasDeployed  bool      `json:"wasDeployed"`
+}
+
+type PruneResult struct {
+	AppSlug string    `json:"appSlug"`
+	DryRun  bool      `json:"dryRun"`
+	Pruned  []Version `json:"pruned"`
+	Kept    int       `json:"kept"`
+}
@@ -0,0 +1,232 @@
+package versionretention
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/filestore"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/versionretention/types"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// pruneVersionsCronSpec - daily cron spec for the version pruning job
+	pruneVersionsCronSpec = "0 2 * * *"
+)
+
+var archivePathRegex = regexp.MustCompile(`^[^/]+/(\d+)\.tar\.gz$`)
+
+// StartPruneCronJob starts the job that prunes old versions of all apps that have a retention policy
+func StartPruneCronJob() error {
+	logger.Debug("starting version pruning cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(pruneVersionsCronSpec, func() {
+		logger.Debug("running version pruning job")
+		if err := pruneAllApps(); err != nil {
+			logger.Error(errors.Wrap(err, "failed to prune app versions"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+func pruneAllApps() error {
+	apps, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		return errors.Wrap(err, "failed to list installed apps")
+	}
+
+	for _, a := range apps {
+		policy, err := store.GetStore().GetVersionRetentionPolicy(a.ID)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get version retention policy for app %s", a.Slug))
+			continue
+		}
+		if policy == nil {
+			continue
+		}
+
+		result, err := Prune(a.ID, *policy, false)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to prune versions for app %s", a.Slug))
+			continue
+		}
+		if len(result.Pruned) > 0 {
+			logger.Infof("pruned %d versions of app %s", len(result.Pruned), a.Slug)
+		}
+	}
+
+	return nil
+}
+
+// ValidatePolicy makes sure the policy keeps something besides the protected versions
+func ValidatePolicy(policy types.Policy) error {
+	if policy.KeepLast < 0 {
+		return errors.New("keepLast cannot be negative")
+	}
+	if policy.KeepNewerThanDays < 0 {
+		return errors.New("keepNewerThanDays cannot be negative")
+	}
+	if policy.KeepLast == 0 && !policy.KeepPreviouslyDeployed && policy.KeepNewerThanDays == 0 {
+		return errors.New("at least one of keepLast, keepPreviouslyDeployed or keepNewerThanDays must be set")
+	}
+	return nil
+}
+
+// Prune deletes the versions of the app that are not kept by the policy, along with their archives.
+// When dryRun is true, the versions that would be deleted are returned but nothing is deleted.
+func Prune(appID string, policy types.Policy, dryRun bool) (*types.PruneResult, error) {
+	a, err := store.GetStore().GetApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app")
+	}
+
+	versions, err := store.GetStore().ListAppVersionsForRetention(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list versions")
+	}
+
+	protected, err := getProtectedSequences(appID, a.CurrentSequence)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get protected sequences")
+	}
+
+	pruned := SelectVersionsToPrune(policy, versions, protected, time.Now())
+
+	result := &types.PruneResult{
+		AppSlug: a.Slug,
+		DryRun:  dryRun,
+		Pruned:  pruned,
+		Kept:    len(versions) - len(pruned),
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	sequences := []int64{}
+	for _, v := range pruned {
+		sequences = append(sequences, v.Sequence)
+	}
+
+	// rows are deleted first so that a version never exists without its archive.
+	// archives that fail to delete are picked up on the next run.
+	if err := store.GetStore().DeleteAppVersions(appID, sequences); err != nil {
+		return nil, errors.Wrap(err, "failed to delete versions")
+	}
+
+	if err := deleteOrphanedArchives(appID, versions, pruned); err != nil {
+		return nil, errors.Wrap(err, "failed to delete archives")
+	}
+
+	return result, nil
+}
+
+// getProtectedSequences returns the sequences that are never pruned: the latest version,
+// the version deployed to each downstream and anything newer than it.
+func getProtectedSequences(appID string, latestSequence int64) (map[int64]bool, error) {
+	protected := map[int64]bool{
+		latestSequence: true,
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list downstreams")
+	}
+
+	for _, d := range downstreams {
+		deployedSequence, err := store.GetStore().GetCurrentParentSequence(appID, d.ClusterID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get deployed sequence for cluster %s", d.ClusterID)
+		}
+		if deployedSequence < 0 {
+			// nothing has been deployed yet, keep everything that could still be deployed
+			deployedSequence = 0
+		}
+		for sequence := deployedSequence; sequence <= latestSequence; sequence++ {
+			protected[sequence] = true
+		}
+	}
+
+	return protected, nil
+}
+
+// SelectVersionsToPrune returns the versions that are not kept by the policy and are not protected, newest first
+func SelectVersionsToPrune(policy types.Policy, versions []types.Version, protected map[int64]bool, now time.Time) []types.Version {
+	sorted := append([]types.Version{}, versions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Sequence > sorted[j].Sequence
+	})
+
+	pruned := []types.Version{}
+	for i, v := range sorted {
+		if protected[v.Sequence] {
+			continue
+		}
+		if i < policy.KeepLast {
+			continue
+		}
+		if policy.KeepPreviouslyDeployed && v.WasDeployed {
+			continue
+		}
+		if policy.KeepNewerThanDays > 0 && v.CreatedAt.After(now.AddDate(0, 0, -policy.KeepNewerThanDays)) {
+			continue
+		}
+		pruned = append(pruned, v)
+	}
+
+	return pruned
+}
+
+// deleteOrphanedArchives deletes the archives of the pruned versions, as well as archives of versions
+// that were pruned in a previous run but could not be deleted then
+func deleteOrphanedArchives(appID string, versions []types.Version, pruned []types.Version) error {
+	remaining := map[int64]bool{}
+	maxSequence := int64(-1)
+	for _, v := range versions {
+		remaining[v.Sequence] = true
+		if v.Sequence > maxSequence {
+			maxSequence = v.Sequence
+		}
+	}
+	for _, v := range pruned {
+		delete(remaining, v.Sequence)
+	}
+
+	archivePaths, err := filestore.GetStore().ListArchives(fmt.Sprintf("%s/", appID))
+	if err != nil {
+		return errors.Wrap(err, "failed to list archives")
+	}
+
+	for _, archivePath := range archivePaths {
+		matches := archivePathRegex.FindStringSubmatch(archivePath)
+		if len(matches) != 2 {
+			continue
+		}
+		sequence, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		// archives of newer sequences may belong to a version that is being created
+		if remaining[sequence] || sequence >= maxSequence {
+			continue
+		}
+		if err := filestore.GetStore().DeleteArchive(archivePath); err != nil {
+			return errors.Wrapf(err, "failed to delete archive %s", archivePath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package versionretention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/versionretention/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectVersionsToPrune(t *testing.T) {
+	now := time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+
+	versions := []types.Version{
+		{Sequence: 0, CreatedAt: daysAgo(100), WasDeployed: true},
+		{Sequence: 1, CreatedAt: daysAgo(90)},
+		{Sequence: 2, CreatedAt: daysAgo(60), WasDeployed: true},
+		{Sequence: 3, CreatedAt: daysAgo(20)},
+		{Sequence: 4, CreatedAt: daysAgo(10), WasDeployed: true},
+		{Sequence: 5, CreatedAt: daysAgo(1)},
+	}
+
+	tests := []struct {
+		name      string
+		policy    types.Policy
+		protected map[int64]bool
+		want      []int64
+	}{
+		{
+			name:      "keep last",
+			policy:    types.Policy{KeepLast: 2},
+			protected: map[int64]bool{},
+			want:      []int64{3, 2, 1, 0},
+		},
+		{
+			name:      "keep previously deployed",
+			policy:    types.Policy{KeepPreviouslyDeployed: true},
+			protected: map[int64]bool{},
+			want:      []int64{5, 3, 1},
+		},
+		{
+			name:      "keep newer than",
+			policy:    types.Policy{KeepNewerThanDays: 30},
+			protected: map[int64]bool{},
+			want:      []int64{2, 1, 0},
+		},
+		{
+			name:      "rules are combined",
+			policy:    types.Policy{KeepLast: 1, KeepPreviouslyDeployed: true, KeepNewerThanDays: 30},
+			protected: map[int64]bool{},
+			want:      []int64{1},
+		},
+		{
+			name:      "protected versions are kept",
+			policy:    types.Policy{KeepLast: 1},
+			protected: map[int64]bool{4: true, 5: true},
+			want:      []int64{3, 2, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pruned := SelectVersionsToPrune(tt.policy, versions, tt.protected, now)
+
+			got := []int64{}
+			for _, v := range pruned {
+				got = append(got, v.Sequence)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	assert.NoError(t, ValidatePolicy(types.Policy{KeepLast: 10}))
+	assert.NoError(t, ValidatePolicy(types.Policy{KeepPreviouslyDeployed: true}))
+	assert.Error(t, ValidatePolicy(types.Policy{}))
+	assert.Error(t, ValidatePolicy(types.Policy{KeepLast: -1, KeepPreviouslyDeployed: true}))
+}
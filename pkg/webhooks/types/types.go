@@ -0,0 +1,49 @@
+package types
+
+import (
+	"time"
+)
+
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// EventTypes the webhook is subscribed to, all events are sent when empty
+	EventTypes []string  `json:"eventTypes"`
+	IsDisabled bool      `json:"isDisabled"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Subscribes returns true if the webhook should receive events of the given type
+func (w Webhook) Subscribes(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type Delivery struct {
+	ID         string         `json:"id"`
+	WebhookID  string         `json:"webhookId"`
+	EventID    string         `json:"eventId"`
+	EventType  string         `json:"eventType"`
+	Payload    string         `json:"payload,omitempty"`
+	Status     DeliveryStatus `json:"status"`
+	Attempts   int            `json:"attempts"`
+	StatusCode int            `json:"statusCode,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
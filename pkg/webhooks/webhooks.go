@@ -0,0 +1,363 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/buildversion"
+	"github.com/replicatedhq/kots/pkg/events"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/webhooks/types"
+	"github.com/robfig/cron/v3"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	HeaderEvent     = "X-Kots-Event"
+	HeaderDelivery  = "X-Kots-Delivery"
+	HeaderTimestamp = "X-Kots-Timestamp"
+	HeaderSignature = "X-Kots-Signature"
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 5 * time.Second
+	requestTimeout        = 10 * time.Second
+
+	// pending deliveries older than this are not resumed after a restart, the event is no longer relevant
+	maxPendingDeliveryAge = 24 * time.Hour
+
+	// deliveryRetention and deliveriesPerWebhook limit the delivery history that is kept
+	deliveryRetention    = 30 * 24 * time.Hour
+	deliveriesPerWebhook = 1000
+	pruneCronSpec        = "@hourly"
+
+	// AllowPrivateTargetsEnv allows webhooks to be delivered to loopback and private network addresses,
+	// such as services in the cluster. Link-local addresses, which include cloud metadata endpoints,
+	// are never allowed.
+	AllowPrivateTargetsEnv = "KOTSADM_WEBHOOK_ALLOW_PRIVATE_TARGETS"
+)
+
+var ErrBlockedTarget = errors.New("webhook target address is not allowed")
+
+// Dispatcher delivers events to the configured webhooks
+type Dispatcher struct {
+	Store               store.Store
+	Client              *http.Client
+	MaxAttempts         int
+	InitialBackoff      time.Duration
+	AllowPrivateTargets bool
+}
+
+func NewDispatcher(s store.Store) *Dispatcher {
+	d := &Dispatcher{
+		Store:               s,
+		MaxAttempts:         defaultMaxAttempts,
+		InitialBackoff:      defaultInitialBackoff,
+		AllowPrivateTargets: os.Getenv(AllowPrivateTargetsEnv) == "true",
+	}
+
+	// the address is checked when connecting so that a host name cannot resolve to a blocked address.
+	// when a proxy is configured, it is the proxy's address that is checked.
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			return d.checkTargetAddress(address)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	d.Client = &http.Client{Timeout: requestTimeout, Transport: transport}
+
+	return d
+}
+
+// Start subscribes a dispatcher to all events published on the event bus, resumes deliveries
+// that were interrupted by a restart and prunes the delivery history
+func Start() {
+	dispatcher := NewDispatcher(store.GetStore())
+	events.Subscribe(dispatcher.HandleEvent)
+
+	go dispatcher.ResumePendingDeliveries()
+
+	if err := startPruneCronJob(dispatcher); err != nil {
+		logger.Error(errors.Wrap(err, "failed to start webhook delivery prune job"))
+	}
+}
+
+func startPruneCronJob(d *Dispatcher) error {
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(pruneCronSpec, func() {
+		if err := d.Store.PruneWebhookDeliveries(time.Now().Add(-deliveryRetention), deliveriesPerWebhook); err != nil {
+			logger.Error(errors.Wrap(err, "failed to prune webhook deliveries"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// ResumePendingDeliveries continues the retries of deliveries that were pending when the admin console stopped,
+// and fails deliveries whose webhook was disabled or that are too old. It returns once all deliveries have finished.
+func (d *Dispatcher) ResumePendingDeliveries() {
+	deliveries, err := d.Store.ListPendingWebhookDeliveries()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list pending webhook deliveries"))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		webhook, err := d.Store.GetWebhook(delivery.WebhookID)
+		if err != nil && !d.Store.IsNotFound(err) {
+			logger.Error(errors.Wrapf(err, "failed to get webhook %s", delivery.WebhookID))
+			continue
+		}
+
+		failure := ""
+		switch {
+		case webhook == nil:
+			failure = "webhook was deleted"
+		case webhook.IsDisabled:
+			failure = "webhook was disabled"
+		case time.Since(delivery.CreatedAt) > maxPendingDeliveryAge:
+			failure = "delivery was interrupted by a restart of the admin console"
+		}
+		if failure != "" {
+			delivery.Status = types.DeliveryFailed
+			delivery.Error = failure
+			delivery.UpdatedAt = time.Now()
+			if err := d.Store.UpdateWebhookDelivery(delivery); err != nil {
+				logger.Error(errors.Wrap(err, "failed to update delivery"))
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(webhook types.Webhook, delivery types.Delivery) {
+			defer wg.Done()
+			if err := d.resumeDelivery(webhook, delivery); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to resume delivery %s to webhook %s", delivery.ID, webhook.ID))
+			}
+		}(*webhook, delivery)
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) resumeDelivery(webhook types.Webhook, delivery types.Delivery) error {
+	secret, err := d.Store.GetWebhookSecret(webhook.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get webhook secret")
+	}
+
+	// a restart interrupts at most one attempt, so the delivery is attempted at least once more
+	maxAttempts := d.MaxAttempts
+	if delivery.Attempts >= maxAttempts {
+		maxAttempts = delivery.Attempts + 1
+	}
+
+	d.attempt(webhook, secret, &delivery, []byte(delivery.Payload), maxAttempts)
+	return nil
+}
+
+// HandleEvent delivers the event to every enabled webhook that subscribes to its type
+func (d *Dispatcher) HandleEvent(event eventtypes.Event) {
+	webhooks, err := d.Store.ListWebhooks()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list webhooks"))
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.IsDisabled || !webhook.Subscribes(string(event.Type)) {
+			continue
+		}
+		go func(webhook types.Webhook) {
+			if _, err := d.Deliver(webhook, event, d.MaxAttempts); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to deliver event %s to webhook %s", event.ID, webhook.ID))
+			}
+		}(webhook)
+	}
+}
+
+// SendTestEvent synchronously delivers a test event to the webhook with a single attempt
+func SendTestEvent(webhookID string) (*types.Delivery, error) {
+	webhook, err := store.GetStore().GetWebhook(webhookID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webhook")
+	}
+
+	event := eventtypes.Event{
+		ID:        ksuid.New().String(),
+		Type:      eventtypes.EventWebhookTest,
+		CreatedAt: time.Now(),
+	}
+
+	dispatcher := NewDispatcher(store.GetStore())
+	return dispatcher.Deliver(*webhook, event, 1)
+}
+
+// Deliver sends the event to the webhook, retrying failed attempts with exponential backoff.
+// The delivery is recorded in the delivery history and updated after every attempt.
+// An error is only returned if the delivery could not be recorded or signed, a failed
+// delivery is reported through the status of the returned delivery.
+func (d *Dispatcher) Deliver(webhook types.Webhook, event eventtypes.Event, maxAttempts int) (*types.Delivery, error) {
+	secret, err := d.Store.GetWebhookSecret(webhook.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webhook secret")
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal event")
+	}
+
+	now := time.Now()
+	delivery := types.Delivery{
+		ID:        ksuid.New().String(),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: string(event.Type),
+		Payload:   string(payload),
+		Status:    types.DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := d.Store.CreateWebhookDelivery(delivery); err != nil {
+		return nil, errors.Wrap(err, "failed to create delivery")
+	}
+
+	d.attempt(webhook, secret, &delivery, payload, maxAttempts)
+
+	return &delivery, nil
+}
+
+// attempt sends the delivery until it succeeds, fails with an error that is not retried, or reaches maxAttempts.
+// Attempts already made for the delivery count towards maxAttempts.
+func (d *Dispatcher) attempt(webhook types.Webhook, secret string, delivery *types.Delivery, payload []byte, maxAttempts int) {
+	backoff := d.InitialBackoff
+	for i := 1; i < delivery.Attempts; i++ {
+		backoff *= 2
+	}
+
+	for attempt := delivery.Attempts + 1; attempt <= maxAttempts; attempt++ {
+		statusCode, err := d.send(webhook.URL, secret, *delivery, payload)
+
+		delivery.Attempts = attempt
+		delivery.StatusCode = statusCode
+		delivery.UpdatedAt = time.Now()
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		retry := err != nil && !errors.Is(err, ErrBlockedTarget) && isRetryable(statusCode) && attempt < maxAttempts
+		switch {
+		case err == nil:
+			delivery.Status = types.DeliverySucceeded
+		case retry:
+			delivery.Status = types.DeliveryPending
+		default:
+			delivery.Status = types.DeliveryFailed
+		}
+
+		if err := d.Store.UpdateWebhookDelivery(*delivery); err != nil {
+			logger.Error(errors.Wrap(err, "failed to update delivery"))
+		}
+
+		if !retry {
+			break
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// checkTargetAddress rejects link-local addresses, and loopback, private and unspecified addresses
+// unless private targets are allowed
+func (d *Dispatcher) checkTargetAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrap(err, "failed to split host and port")
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errors.Errorf("failed to parse ip address %q", host)
+	}
+
+	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return errors.Wrapf(ErrBlockedTarget, "%s is a link-local address", ip)
+	}
+	if !d.AllowPrivateTargets && (ip.IsLoopback() || ip.IsPrivate()) {
+		return errors.Wrapf(ErrBlockedTarget, "%s is a private address, set %s=true on the admin console to allow it", ip, AllowPrivateTargetsEnv)
+	}
+
+	return nil
+}
+
+// send returns the status code of the response, or 0 if no response was received
+func (d *Dispatcher) send(url string, secret string, delivery types.Delivery, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create request")
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("KOTS/%s", buildversion.Version()))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// isRetryable returns false for client errors, which will not succeed when retried
+func isRetryable(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode < 400 || statusCode >= 500
+}
+
+// Sign returns the value of the signature header for a payload. Receivers verify deliveries by computing
+// the HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret and comparing it to the header.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
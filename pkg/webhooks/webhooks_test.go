@@ -0,0 +1,219 @@
+package webhooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	eventtypes "github.com/replicatedhq/kots/pkg/events/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/replicatedhq/kots/pkg/webhooks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	got := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	assert.Equal(t, "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686", got)
+	assert.NotEqual(t, got, Sign("other", 1700000000, []byte(`{"a":1}`)))
+	assert.NotEqual(t, got, Sign("secret", 1700000001, []byte(`{"a":1}`)))
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name             string
+		responses        []int
+		wantStatus       types.DeliveryStatus
+		wantAttempts     int
+		wantStatusCode   int
+		wantErrorMessage bool
+	}{
+		{
+			name:           "succeeds on first attempt",
+			responses:      []int{http.StatusOK},
+			wantStatus:     types.DeliverySucceeded,
+			wantAttempts:   1,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "retries server errors",
+			responses:      []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusNoContent},
+			wantStatus:     types.DeliverySucceeded,
+			wantAttempts:   3,
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			name:             "does not retry client errors",
+			responses:        []int{http.StatusNotFound},
+			wantStatus:       types.DeliveryFailed,
+			wantAttempts:     1,
+			wantStatusCode:   http.StatusNotFound,
+			wantErrorMessage: true,
+		},
+		{
+			name:             "fails after max attempts",
+			responses:        []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			wantStatus:       types.DeliveryFailed,
+			wantAttempts:     3,
+			wantStatusCode:   http.StatusInternalServerError,
+			wantErrorMessage: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := atomic.AddInt32(&requests, 1) - 1
+
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				require.NoError(t, err)
+				assert.Equal(t, Sign("secret", timestamp, body), r.Header.Get(HeaderSignature))
+				assert.Equal(t, string(eventtypes.EventDeploySucceeded), r.Header.Get(HeaderEvent))
+				assert.NotEmpty(t, r.Header.Get(HeaderDelivery))
+
+				w.WriteHeader(tt.responses[i])
+			}))
+			defer server.Close()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mock_store.NewMockStore(ctrl)
+
+			webhook := types.Webhook{ID: "webhook-id", URL: server.URL}
+			event := eventtypes.Event{ID: "event-id", Type: eventtypes.EventDeploySucceeded, AppSlug: "my-app"}
+
+			mockStore.EXPECT().GetWebhookSecret("webhook-id").Return("secret", nil)
+			mockStore.EXPECT().CreateWebhookDelivery(gomock.Any()).DoAndReturn(func(delivery types.Delivery) error {
+				assert.Equal(t, types.DeliveryPending, delivery.Status)
+				assert.Equal(t, "event-id", delivery.EventID)
+				return nil
+			})
+			mockStore.EXPECT().UpdateWebhookDelivery(gomock.Any()).Return(nil).Times(tt.wantAttempts)
+
+			dispatcher := NewDispatcher(mockStore)
+			dispatcher.InitialBackoff = 0
+			dispatcher.AllowPrivateTargets = true
+
+			delivery, err := dispatcher.Deliver(webhook, event, 3)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			assert.Equal(t, tt.wantAttempts, delivery.Attempts)
+			assert.Equal(t, tt.wantStatusCode, delivery.StatusCode)
+			assert.Equal(t, tt.wantErrorMessage, delivery.Error != "")
+			assert.Equal(t, int32(tt.wantAttempts), atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestDeliver_blockedTarget(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	mockStore.EXPECT().GetWebhookSecret("webhook-id").Return("secret", nil)
+	mockStore.EXPECT().CreateWebhookDelivery(gomock.Any()).Return(nil)
+	mockStore.EXPECT().UpdateWebhookDelivery(gomock.Any()).Return(nil)
+
+	dispatcher := NewDispatcher(mockStore)
+	dispatcher.InitialBackoff = 0
+	dispatcher.AllowPrivateTargets = false
+
+	webhook := types.Webhook{ID: "webhook-id", URL: server.URL}
+	delivery, err := dispatcher.Deliver(webhook, eventtypes.Event{ID: "event-id", Type: eventtypes.EventDeploySucceeded}, 3)
+	require.NoError(t, err)
+
+	// blocked targets are not retried
+	assert.Equal(t, types.DeliveryFailed, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Contains(t, delivery.Error, "private address")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+}
+
+func TestCheckTargetAddress(t *testing.T) {
+	tests := []struct {
+		address      string
+		allowPrivate bool
+		wantBlocked  bool
+	}{
+		{address: "203.0.113.10:443"},
+		{address: "169.254.169.254:80", wantBlocked: true},
+		{address: "169.254.169.254:80", allowPrivate: true, wantBlocked: true},
+		{address: "[fe80::1]:80", allowPrivate: true, wantBlocked: true},
+		{address: "0.0.0.0:80", allowPrivate: true, wantBlocked: true},
+		{address: "10.96.0.10:80", wantBlocked: true},
+		{address: "10.96.0.10:80", allowPrivate: true},
+		{address: "127.0.0.1:8080", wantBlocked: true},
+		{address: "[fd00::1]:80", wantBlocked: true},
+	}
+	for _, tt := range tests {
+		d := &Dispatcher{AllowPrivateTargets: tt.allowPrivate}
+		err := d.checkTargetAddress(tt.address)
+		assert.Equal(t, tt.wantBlocked, errors.Is(err, ErrBlockedTarget), "%s allowPrivate=%v: %v", tt.address, tt.allowPrivate, err)
+	}
+}
+
+func TestResumePendingDeliveries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "interrupted", r.Header.Get(HeaderDelivery))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	notFound := errors.New("not found")
+	mockStore.EXPECT().IsNotFound(gomock.Any()).DoAndReturn(func(err error) bool { return err == notFound }).AnyTimes()
+
+	mockStore.EXPECT().ListPendingWebhookDeliveries().Return([]types.Delivery{
+		{ID: "interrupted", WebhookID: "webhook-id", Payload: `{"id":"event-id"}`, Status: types.DeliveryPending, Attempts: 2, CreatedAt: time.Now()},
+		{ID: "too-old", WebhookID: "webhook-id", Status: types.DeliveryPending, Attempts: 1, CreatedAt: time.Now().Add(-2 * maxPendingDeliveryAge)},
+		{ID: "deleted-webhook", WebhookID: "deleted-id", Status: types.DeliveryPending, CreatedAt: time.Now()},
+	}, nil)
+	mockStore.EXPECT().GetWebhook("webhook-id").Return(&types.Webhook{ID: "webhook-id", URL: server.URL}, nil).Times(2)
+	mockStore.EXPECT().GetWebhook("deleted-id").Return(nil, notFound)
+	mockStore.EXPECT().GetWebhookSecret("webhook-id").Return("secret", nil)
+
+	var mu sync.Mutex
+	updated := map[string]types.Delivery{}
+	mockStore.EXPECT().UpdateWebhookDelivery(gomock.Any()).DoAndReturn(func(delivery types.Delivery) error {
+		mu.Lock()
+		defer mu.Unlock()
+		updated[delivery.ID] = delivery
+		return nil
+	}).Times(3)
+
+	dispatcher := NewDispatcher(mockStore)
+	dispatcher.InitialBackoff = 0
+	dispatcher.AllowPrivateTargets = true
+	dispatcher.ResumePendingDeliveries()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, types.DeliverySucceeded, updated["interrupted"].Status)
+	assert.Equal(t, 3, updated["interrupted"].Attempts)
+	assert.Equal(t, types.DeliveryFailed, updated["too-old"].Status)
+	assert.Equal(t, types.DeliveryFailed, updated["deleted-webhook"].Status)
+}